@@ -8,6 +8,8 @@ import (
 	"backend/internal/repository"
 	"backend/internal/service"
 	"backend/internal/websocket"
+	"backend/pkg/vnadmin"
+	"backend/pkg/xsd"
 	"context"
 	"log"
 	"net/http"
@@ -43,6 +45,7 @@ func main() {
 	}
 
 	port := getEnv("PORT", "8080")
+	initWards()
 
 	// 2. Initialize Gin Router
 	if os.Getenv("GIN_MODE") == "release" {
@@ -98,6 +101,15 @@ func main() {
 	}
 	log.Println("Connected to Database successfully.")
 
+	// Base currency the books are kept in; changing it restates stored amounts once when allowed
+	baseCurrency := getEnv("BASE_CURRENCY", "USD")
+	if err := service.InitBaseCurrency(baseCurrency); err != nil {
+		log.Fatalf("CRITICAL: Invalid BASE_CURRENCY: %v", err)
+	}
+	if err := database.MigrateBaseCurrency(db, strings.ToUpper(strings.TrimSpace(baseCurrency)), getEnv("BASE_CURRENCY_MIGRATE", "") == "true"); err != nil {
+		log.Fatalf("CRITICAL: %v", err)
+	}
+
 	// 6. Initialize Repositories
 	txManager := repository.NewTransactionManager(db)
 	userRepo := repository.NewUserRepository(db)
@@ -113,28 +125,72 @@ func main() {
 	statsRepo := repository.NewStatisticsRepository(db)
 	revenueRepo := repository.NewRevenueRepository(db)
 	partnerRepo := repository.NewPartnerRepository(db)
+	fulfillmentRepo := repository.NewFulfillmentRepository(db)
+	vehicleRepo := repository.NewVehicleRepository(db)
+	costingRepo := repository.NewCostingRepository(db)
+	customsRepo := repository.NewCustomsRepository(db)
+	documentTemplateRepo := repository.NewDocumentTemplateRepository(db)
+	einvoiceRepo := repository.NewEInvoiceRepository(db)
+	sequenceRepo := repository.NewDocumentSequenceRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	ledgerRepo := repository.NewLedgerRepository(db)
+	periodRepo := repository.NewFiscalPeriodRepository(db)
+	rateRepo := repository.NewExchangeRateRepository(db)
+	taxReportRepo := repository.NewTaxReportRepository(db)
 
 	// 7. Initialize Services & Handlers
 	wsHub := websocket.NewHub()
 	go wsHub.Run()
 
 	userService := service.NewUserService(userRepo)
-	inventoryService := service.NewInventoryService(productRepo, orderRepo, approvalRepo, auditRepo, partnerRepo, txManager, wsHub)
+	inventoryService := service.NewInventoryService(productRepo, orderRepo, approvalRepo, auditRepo, partnerRepo, taxRuleRepo, paymentRepo, rateRepo, txManager, wsHub)
 	auditService := service.NewAuditService(auditRepo)
 	statisticsService := service.NewStatisticsService(statsRepo)
-	taxService := service.NewTaxService(taxRuleRepo, auditRepo)
-	expenseService := service.NewExpenseService(expenseRepo, auditRepo, approvalRepo, txManager, taxService)
+	taxService := service.NewTaxService(taxRuleRepo, partnerRepo, productRepo, auditRepo)
+	expenseService := service.NewExpenseService(expenseRepo, orderRepo, auditRepo, approvalRepo, partnerRepo, periodRepo, rateRepo, txManager, taxService)
 	roleService := service.NewRoleService(roleRepo, txManager)
-	invoiceService := service.NewInvoiceService(invoiceRepo, taxRuleRepo, orderRepo, expenseRepo, partnerRepo, txManager)
+	invoiceService := service.NewInvoiceService(invoiceRepo, taxRuleRepo, orderRepo, expenseRepo, partnerRepo, approvalRepo, auditRepo, sequenceRepo, paymentRepo, ledgerRepo, periodRepo, txManager)
 	revenueService := service.NewRevenueService(revenueRepo)
-	approvalService := service.NewApprovalService(approvalRepo, auditRepo, orderRepo, productRepo, expenseRepo, invoiceRepo, taxRuleRepo, invTxRepo, partnerRepo, txManager)
+	approvalService := service.NewApprovalService(approvalRepo, auditRepo, orderRepo, productRepo, expenseRepo, invoiceRepo, taxRuleRepo, invTxRepo, partnerRepo, fulfillmentRepo, costingRepo, sequenceRepo, ledgerRepo, periodRepo, paymentRepo, txManager)
 	partnerService := service.NewPartnerService(partnerRepo, txManager)
+	fulfillmentService := service.NewFulfillmentService(fulfillmentRepo, orderRepo, productRepo, auditRepo, sequenceRepo, txManager)
+	deliveryService := service.NewDeliveryService(vehicleRepo, orderRepo, auditRepo)
+	costingService := service.NewCostingService(costingRepo, orderRepo, invoiceRepo, expenseRepo, customsRepo, auditRepo, ledgerRepo, sequenceRepo, periodRepo, txManager)
+	customsService := service.NewCustomsService(customsRepo, orderRepo, expenseRepo, approvalRepo, auditRepo, txManager, taxService)
+	sequenceService := service.NewDocumentSequenceService(sequenceRepo, auditRepo, txManager)
+	documentService := service.NewDocumentService(documentTemplateRepo, invoiceRepo, orderRepo, expenseRepo, partnerRepo, auditRepo)
+	einvoiceService := service.NewEInvoiceService(einvoiceRepo, invoiceRepo, documentTemplateRepo, auditRepo, initEInvoiceSigner(), initEInvoiceProvider(), service.EInvoiceConfig{
+		FormNo:          getEnv("EINVOICE_FORM_NO", "1"),
+		Series:          os.Getenv("EINVOICE_SERIES"),
+		ProviderTaxCode: os.Getenv("EINVOICE_PROVIDER_TAX_CODE"),
+		Schema:          initEInvoiceSchema(),
+	})
+	paymentService := service.NewPaymentService(paymentRepo, invoiceRepo, expenseRepo, partnerRepo, sequenceRepo, auditRepo, ledgerRepo, periodRepo, rateRepo, txManager)
+	ledgerService := service.NewLedgerService(ledgerRepo, auditRepo, txManager)
+	periodService := service.NewFiscalPeriodService(periodRepo, auditRepo, txManager)
+	exchangeRateService := service.NewExchangeRateService(rateRepo, auditRepo, txManager)
+	taxReportService := service.NewTaxReportService(taxReportRepo, documentTemplateRepo, rateRepo, taxRuleRepo, auditRepo)
 
 	// Seed default roles and permissions
 	if seedErr := roleService.SeedDefaultRolesAndPermissions(context.Background()); seedErr != nil {
 		log.Printf("WARNING: Failed to seed roles/permissions: %v", seedErr)
 	}
 
+	// Seed the default (VAS) chart of accounts
+	if seedErr := ledgerService.SeedDefaultAccounts(context.Background()); seedErr != nil {
+		log.Printf("WARNING: Failed to seed chart of accounts: %v", seedErr)
+	}
+
+	// Seed the default currencies
+	if seedErr := exchangeRateService.SeedDefaultCurrencies(context.Background()); seedErr != nil {
+		log.Printf("WARNING: Failed to seed currencies: %v", seedErr)
+	}
+
+	// Seed the tax types the calculations rely on
+	if seedErr := taxService.SeedDefaultTaxTypes(context.Background()); seedErr != nil {
+		log.Printf("WARNING: Failed to seed tax types: %v", seedErr)
+	}
+
 	// Init permission middleware with DB for RequirePermission
 	middleware.InitPermissionMiddleware(db)
 
@@ -148,6 +204,16 @@ func main() {
 	invoiceHandler := handler.NewInvoiceHandler(invoiceService, revenueService)
 	approvalHandler := handler.NewApprovalHandler(approvalService)
 	partnerHandler := handler.NewPartnerHandler(partnerService)
+	fulfillmentHandler := handler.NewFulfillmentHandler(fulfillmentService)
+	deliveryHandler := handler.NewDeliveryHandler(deliveryService)
+	costingHandler := handler.NewCostingHandler(costingService)
+	customsHandler := handler.NewCustomsHandler(customsService)
+	documentHandler := handler.NewDocumentHandler(documentService, sequenceService)
+	einvoiceHandler := handler.NewEInvoiceHandler(einvoiceService)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
+	ledgerHandler := handler.NewLedgerHandler(ledgerService, periodService)
+	exchangeRateHandler := handler.NewExchangeRateHandler(exchangeRateService)
+	taxReportHandler := handler.NewTaxReportHandler(taxReportService)
 
 	// 8. Register API Routes (synchronous — guaranteed available before serving)
 	apiGroup := router.Group("")
@@ -161,6 +227,16 @@ func main() {
 	invoiceHandler.RegisterRoutes(apiGroup)
 	approvalHandler.RegisterRoutes(apiGroup)
 	partnerHandler.RegisterRoutes(apiGroup)
+	fulfillmentHandler.RegisterRoutes(apiGroup)
+	deliveryHandler.RegisterRoutes(apiGroup)
+	costingHandler.RegisterRoutes(apiGroup)
+	customsHandler.RegisterRoutes(apiGroup)
+	documentHandler.RegisterRoutes(apiGroup)
+	einvoiceHandler.RegisterRoutes(apiGroup)
+	paymentHandler.RegisterRoutes(apiGroup)
+	ledgerHandler.RegisterRoutes(apiGroup)
+	exchangeRateHandler.RegisterRoutes(apiGroup)
+	taxReportHandler.RegisterRoutes(apiGroup)
 
 	// WebSocket endpoint
 	router.GET("/ws", func(c *gin.Context) {
@@ -241,6 +317,63 @@ func buildDSN() string {
 	return dsn
 }
 
+// initEInvoiceSigner loads the e-invoice signing certificate. Without one, issuing e-invoices is refused.
+func initEInvoiceSigner() *service.EInvoiceSigner {
+	certPath := os.Getenv("EINVOICE_CERT_PATH")
+	if certPath == "" {
+		log.Println("Note: EINVOICE_CERT_PATH is not set, e-invoice issuing is disabled")
+		return nil
+	}
+	signer, err := service.LoadEInvoiceSigner(certPath, os.Getenv("EINVOICE_CERT_PASSWORD"))
+	if err != nil {
+		log.Printf("WARNING: Failed to load e-invoice certificate: %v", err)
+		return nil
+	}
+	log.Printf("E-invoice certificate loaded: %s", signer.Subject())
+	return signer
+}
+
+// initEInvoiceProvider selects the service that transmits e-invoices to the tax authority.
+// Without one, issuing e-invoices is refused; an unknown name stops the server so that a typo
+// never falls back to the mock.
+func initEInvoiceProvider() service.EInvoiceProvider {
+	switch name := os.Getenv("EINVOICE_PROVIDER"); name {
+	case "":
+		log.Println("Note: EINVOICE_PROVIDER is not set, e-invoice issuing is disabled")
+		return nil
+	case "mock":
+		log.Println("WARNING: EINVOICE_PROVIDER=mock accepts every e-invoice with a simulated tax authority code (MCCQT); do not use it in production")
+		return service.NewMockEInvoiceProvider()
+	default:
+		log.Fatalf("CRITICAL: Unknown EINVOICE_PROVIDER %q", name)
+		return nil
+	}
+}
+
+// initEInvoiceSchema compiles the schema e-invoices are validated against before submission
+func initEInvoiceSchema() *xsd.Schema {
+	path := os.Getenv("EINVOICE_XSD_PATH")
+	if path == "" {
+		log.Println("Note: EINVOICE_XSD_PATH is not set, e-invoices are validated against the bundled schema, not the official GDT XSD")
+	}
+	schema, err := service.LoadEInvoiceSchema(path)
+	if err != nil {
+		log.Fatalf("CRITICAL: Failed to load e-invoice schema: %v", err)
+	}
+	return schema
+}
+
+// initWards replaces the bundled ward list with the one at VNADMIN_WARDS_PATH, if set
+func initWards() {
+	path := os.Getenv("VNADMIN_WARDS_PATH")
+	if path == "" {
+		return
+	}
+	if err := vnadmin.LoadWardsFile(path); err != nil {
+		log.Fatalf("CRITICAL: Failed to load the ward list: %v", err)
+	}
+}
+
 // getEnv retrieves env with fallback
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
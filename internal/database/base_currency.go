@@ -0,0 +1,297 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"backend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legacyBaseCurrency is the base currency of installations that predate the base currency setting
+const legacyBaseCurrency = "USD"
+
+// renameBaseCurrencyColumns renames the amount columns that were named after USD when it was the
+// only base currency, keeping their data. It runs before AutoMigrate so the new columns are not
+// created empty next to the old ones.
+func renameBaseCurrencyColumns(db *gorm.DB) {
+	renames := []struct {
+		model    interface{}
+		from, to string
+	}{
+		{&model.Expense{}, "converted_amount_usd", "converted_amount"},
+		{&model.Order{}, "total_amount_usd", "total_amount_base"},
+		{&model.Payment{}, "amount_usd", "amount_base"},
+		{&model.PaymentAllocation{}, "payment_amount_usd", "payment_amount_base"},
+		{&model.PaymentRunLine{}, "amount_usd", "amount_base"},
+		{&model.CustomsDeclaration{}, "customs_value_usd", "customs_value_base"},
+		{&model.CustomsDeclarationLine{}, "customs_value_usd", "customs_value_base"},
+	}
+	migrator := db.Migrator()
+	for _, r := range renames {
+		if !migrator.HasColumn(r.model, r.from) || migrator.HasColumn(r.model, r.to) {
+			continue
+		}
+		if err := migrator.RenameColumn(r.model, r.from, r.to); err != nil {
+			log.Printf("WARNING: Failed to rename column %s to %s: %v", r.from, r.to, err)
+		}
+	}
+}
+
+// addLegacyCurrencyColumns adds the currency column to documents recorded before they had one,
+// filled with the legacy base currency. The columns have no default (every document is given its
+// currency explicitly), so AutoMigrate alone could not add them to tables that already hold rows;
+// it drops the default set here afterwards.
+func addLegacyCurrencyColumns(db *gorm.DB) {
+	models := []interface{}{
+		&model.Order{},
+		&model.Invoice{},
+		&model.Payment{},
+		&model.PaymentRunLine{},
+		&model.CustomsDeclaration{},
+	}
+	migrator := db.Migrator()
+	for _, m := range models {
+		if !migrator.HasTable(m) || migrator.HasColumn(m, "currency") {
+			continue
+		}
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			log.Printf("WARNING: Failed to parse model %T: %v", m, err)
+			continue
+		}
+		if err := db.Exec("ALTER TABLE ? ADD COLUMN currency varchar(10) NOT NULL DEFAULT '"+legacyBaseCurrency+"'",
+			clause.Table{Name: stmt.Table}).Error; err != nil {
+			log.Printf("WARNING: Failed to add currency column to %s: %v", stmt.Table, err)
+		}
+	}
+}
+
+// MigrateBaseCurrency records the base currency the books are kept in and checks it against the
+// configured one (BASE_CURRENCY). Books that already hold documents in another base currency are
+// only restated when restate is true (BASE_CURRENCY_MIGRATE); otherwise an error is returned so
+// the server does not start mixing currencies.
+func MigrateBaseCurrency(db *gorm.DB, code string, restate bool) error {
+	var setting model.CompanySetting
+	err := db.First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Books started before the setting existed were kept in USD
+		var hasData bool
+		if err := db.Raw(`SELECT EXISTS (SELECT 1 FROM invoices) OR EXISTS (SELECT 1 FROM expenses)
+			OR EXISTS (SELECT 1 FROM payments) OR EXISTS (SELECT 1 FROM journal_entries)`).Scan(&hasData).Error; err != nil {
+			return fmt.Errorf("failed to check for existing documents: %w", err)
+		}
+		setting = model.CompanySetting{ID: 1, BaseCurrency: code}
+		if hasData {
+			setting.BaseCurrency = legacyBaseCurrency
+		}
+		if err := db.Create(&setting).Error; err != nil {
+			return fmt.Errorf("failed to record base currency: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch company settings: %w", err)
+	}
+
+	if setting.BaseCurrency == code {
+		return nil
+	}
+	if !restate {
+		return fmt.Errorf("the books are kept in %s but BASE_CURRENCY is %s; set BASE_CURRENCY_MIGRATE=true once to restate all stored amounts in %s",
+			setting.BaseCurrency, code, code)
+	}
+
+	log.Printf("Restating the books from %s to %s...", setting.BaseCurrency, code)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := restateBaseCurrency(tx, setting.BaseCurrency, code); err != nil {
+			return err
+		}
+		now := time.Now()
+		setting.BaseCurrency, setting.RebasedAt = code, &now
+		return tx.Save(&setting).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restate the books in %s: %w", code, err)
+	}
+	log.Printf("Books restated in %s.", code)
+	return nil
+}
+
+// restateBaseCurrency converts every stored base amount from one base currency to another.
+//
+// Each document is converted with a divisor: for documents issued in the new base currency, its
+// own booked rate, so their base amounts become their document amounts again; for everything
+// else, the rate of the new base currency (in the old one) on the document date. Document rates
+// are divided by the same divisor. Amounts are rounded to the new currency (whole units for VND),
+// invoice and customs headers are rebuilt from their lines and journal entries that rounding
+// leaves unbalanced are evened out on their largest debit line. The rate table is restated last,
+// the old base currency becoming a regular currency.
+func restateBaseCurrency(tx *gorm.DB, from, to string) error {
+	args := map[string]interface{}{
+		"from":  from,
+		"to":    to,
+		"scale": model.CurrencyScale(to),
+	}
+	rateOn := func(date string) string {
+		return `(SELECT r.rate FROM exchange_rates r WHERE r.currency = @to AND r.rate_date <= (` + date +
+			`)::date ORDER BY r.rate_date DESC LIMIT 1)`
+	}
+
+	// Divisors are fixed before anything is converted
+	divisors := []struct{ table, query string }{
+		{"rebase_invoices", `SELECT id, created_at::date AS day,
+			CASE WHEN currency = @to THEN exchange_rate ELSE ` + rateOn("created_at") + ` END AS d FROM invoices`},
+		{"rebase_payments", `SELECT id, payment_date::date AS day,
+			CASE WHEN currency = @to THEN exchange_rate ELSE ` + rateOn("payment_date") + ` END AS d FROM payments`},
+		{"rebase_expenses", `SELECT id, created_at::date AS day,
+			CASE WHEN currency = @to THEN exchange_rate ELSE ` + rateOn("created_at") + ` END AS d FROM expenses`},
+		{"rebase_orders", `SELECT id, created_at::date AS day,
+			CASE WHEN currency = @to THEN exchange_rate ELSE ` + rateOn("created_at") + ` END AS d FROM orders`},
+		{"rebase_customs", `SELECT id, COALESCE(registered_at, created_at)::date AS day,
+			CASE WHEN currency = @to THEN exchange_rate ELSE ` + rateOn("COALESCE(registered_at, created_at)") + ` END AS d
+			FROM customs_declarations`},
+		{"rebase_journal_entries", `SELECT e.id, e.entry_date::date AS day,
+			COALESCE(ri.d, rp.d, ` + rateOn("e.entry_date") + `) AS d
+			FROM journal_entries e
+			LEFT JOIN rebase_invoices ri ON e.source_type = 'INVOICE' AND ri.id = e.source_id
+			LEFT JOIN rebase_payments rp ON e.source_type = 'PAYMENT' AND rp.id = e.source_id`},
+		{"rebase_cost_layers", `SELECT id, received_at::date AS day, ` + rateOn("received_at") + ` AS d FROM cost_layers`},
+		{"rebase_inventory_transactions", `SELECT id, created_at::date AS day, ` + rateOn("created_at") + ` AS d
+			FROM inventory_transactions`},
+		{"rebase_landed_costs", `SELECT id, created_at::date AS day, ` + rateOn("created_at") + ` AS d
+			FROM landed_cost_allocations`},
+		{"rebase_rates", `SELECT id, rate_date AS day, ` + rateOn("rate_date") + ` AS d
+			FROM exchange_rates WHERE currency <> @to`},
+		{"rebase_today", `SELECT 1 AS id, CURRENT_DATE AS day, ` + rateOn("CURRENT_DATE") + ` AS d`},
+	}
+	for _, d := range divisors {
+		if err := tx.Exec(`CREATE TEMP TABLE `+d.table+` ON COMMIT DROP AS `+d.query, args).Error; err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", d.table, err)
+		}
+		var missing *time.Time
+		if err := tx.Raw(`SELECT MIN(day) FROM ` + d.table + ` WHERE d IS NULL OR d <= 0`).Scan(&missing).Error; err != nil {
+			return fmt.Errorf("failed to check exchange rates: %w", err)
+		}
+		if missing != nil {
+			return fmt.Errorf("no %s exchange rate on or before %s; import %s rates covering the whole history first",
+				to, missing.Format("2006-01-02"), to)
+		}
+	}
+
+	statements := []string{
+		// Invoices: lines, then headers (from their lines when they have any)
+		`UPDATE invoice_lines l SET
+		   unit_price = ROUND(l.unit_price / r.d, @scale), discount = ROUND(l.discount / r.d, @scale),
+		   amount = ROUND(l.amount / r.d, @scale), tax_amount = ROUND(l.tax_amount / r.d, @scale)
+		 FROM rebase_invoices r WHERE r.id = l.invoice_id`,
+		`UPDATE invoices i SET
+		   subtotal = ROUND(i.subtotal / r.d, @scale), tax_amount = ROUND(i.tax_amount / r.d, @scale),
+		   side_fees = ROUND(i.side_fees / r.d, @scale), exchange_rate = i.exchange_rate / r.d
+		 FROM rebase_invoices r WHERE r.id = i.id`,
+		`UPDATE invoices i SET subtotal = l.subtotal, tax_amount = l.tax_amount
+		 FROM (SELECT invoice_id, SUM(amount) AS subtotal, SUM(tax_amount) AS tax_amount FROM invoice_lines GROUP BY invoice_id) l
+		 WHERE l.invoice_id = i.id`,
+		`UPDATE invoices SET subtotal = doc_subtotal, tax_amount = doc_tax_amount, side_fees = doc_side_fees
+		 WHERE currency = @to AND doc_total_amount <> 0`,
+		`UPDATE invoices SET total_amount = subtotal + tax_amount + side_fees`,
+
+		// Orders and expenses
+		`UPDATE orders o SET
+		   total_amount_base = CASE WHEN o.currency = @to THEN o.total_amount ELSE ROUND(o.total_amount_base / r.d, @scale) END,
+		   exchange_rate = o.exchange_rate / r.d,
+		   rate_source = CASE WHEN o.currency = @to THEN 'BASE' WHEN o.rate_source = 'BASE' THEN 'TABLE' ELSE o.rate_source END
+		 FROM rebase_orders r WHERE r.id = o.id`,
+		`UPDATE expenses e SET
+		   converted_amount = CASE WHEN e.currency = @to THEN e.original_amount ELSE ROUND(e.converted_amount / r.d, @scale) END,
+		   fct_amount = ROUND(e.fct_amount / r.d, @scale), vat_amount = ROUND(e.vat_amount / r.d, @scale),
+		   exchange_rate = e.exchange_rate / r.d,
+		   rate_source = CASE WHEN e.currency = @to THEN 'BASE' WHEN e.rate_source = 'BASE' THEN 'TABLE' ELSE e.rate_source END
+		 FROM rebase_expenses r WHERE r.id = e.id`,
+
+		// Payments, their allocations (cleared at the invoice's divisor, drawn at the payment's)
+		// and the paid and allocated totals
+		`UPDATE payments p SET
+		   amount_base = CASE WHEN p.currency = @to THEN p.amount ELSE ROUND(p.amount_base / r.d, @scale) END,
+		   exchange_rate = p.exchange_rate / r.d
+		 FROM rebase_payments r WHERE r.id = p.id`,
+		`UPDATE payment_allocations a SET
+		   amount = CASE WHEN i.currency = @to THEN a.doc_amount ELSE ROUND(a.amount / ri.d, @scale) END,
+		   payment_amount_base = a.payment_amount_base / rp.d
+		 FROM invoices i, rebase_invoices ri, payments p, rebase_payments rp
+		 WHERE i.id = a.invoice_id AND ri.id = a.invoice_id AND p.id = a.payment_id AND rp.id = a.payment_id`,
+		`UPDATE payment_allocations a SET payment_amount_base = ROUND(a.payment_amount_base, @scale),
+		   fx_gain_loss = CASE WHEN p.payment_type = 'DISBURSEMENT' THEN a.amount - ROUND(a.payment_amount_base, @scale)
+		                       ELSE ROUND(a.payment_amount_base, @scale) - a.amount END
+		 FROM payments p WHERE p.id = a.payment_id`,
+		`UPDATE payments p SET allocated_amount = COALESCE((SELECT SUM(a.payment_amount_base) FROM payment_allocations a WHERE a.payment_id = p.id), 0)`,
+		`UPDATE invoices i SET paid_amount = COALESCE((SELECT SUM(a.amount) FROM payment_allocations a WHERE a.invoice_id = i.id), 0)`,
+		`UPDATE payment_run_lines l SET
+		   amount_base = CASE WHEN l.currency = @to THEN l.amount ELSE ROUND(l.amount_base / r.d, @scale) END,
+		   exchange_rate = l.exchange_rate / r.d
+		 FROM rebase_invoices r WHERE r.id = l.invoice_id`,
+
+		// Customs declarations: lines, then headers
+		`UPDATE customs_declaration_lines l SET
+		   customs_value_base = CASE WHEN c.currency = @to THEN l.customs_value ELSE ROUND(l.customs_value_base / r.d, @scale) END,
+		   duty_amount = ROUND(l.duty_amount / r.d, @scale), vat_amount = ROUND(l.vat_amount / r.d, @scale)
+		 FROM customs_declarations c, rebase_customs r WHERE c.id = l.declaration_id AND r.id = l.declaration_id`,
+		`UPDATE customs_declarations c SET
+		   customs_value_base = ROUND(c.customs_value_base / r.d, @scale), duty_amount = ROUND(c.duty_amount / r.d, @scale),
+		   vat_amount = ROUND(c.vat_amount / r.d, @scale), exchange_rate = c.exchange_rate / r.d
+		 FROM rebase_customs r WHERE r.id = c.id`,
+		`UPDATE customs_declarations c SET customs_value_base = l.value, duty_amount = l.duty, vat_amount = l.vat
+		 FROM (SELECT declaration_id, SUM(customs_value_base) AS value, SUM(duty_amount) AS duty, SUM(vat_amount) AS vat
+		       FROM customs_declaration_lines GROUP BY declaration_id) l
+		 WHERE l.declaration_id = c.id`,
+
+		// Inventory costs; unit costs keep 4 places
+		`UPDATE cost_layers c SET unit_cost = ROUND(c.unit_cost / r.d, 4), landed_cost_per_unit = ROUND(c.landed_cost_per_unit / r.d, 4)
+		 FROM rebase_cost_layers r WHERE r.id = c.id`,
+		`UPDATE inventory_transactions t SET cost_amount = ROUND(t.cost_amount / r.d, @scale)
+		 FROM rebase_inventory_transactions r WHERE r.id = t.id`,
+		`UPDATE landed_cost_allocation_lines l SET
+		   freight_amount = ROUND(l.freight_amount / r.d, @scale), duty_amount = ROUND(l.duty_amount / r.d, @scale),
+		   expense_amount = ROUND(l.expense_amount / r.d, @scale), total_amount = ROUND(l.total_amount / r.d, @scale),
+		   landed_cost_per_unit = ROUND(l.landed_cost_per_unit / r.d, 4)
+		 FROM rebase_landed_costs r WHERE r.id = l.allocation_id`,
+		`UPDATE landed_cost_allocations a SET
+		   freight_amount = ROUND(a.freight_amount / r.d, @scale), duty_amount = ROUND(a.duty_amount / r.d, @scale),
+		   expense_amount = ROUND(a.expense_amount / r.d, @scale), total_amount = ROUND(a.total_amount / r.d, @scale)
+		 FROM rebase_landed_costs r WHERE r.id = a.id`,
+
+		// Ledger: lines, rounding differences, entry totals
+		`UPDATE journal_lines l SET debit = ROUND(l.debit / r.d, @scale), credit = ROUND(l.credit / r.d, @scale)
+		 FROM rebase_journal_entries r WHERE r.id = l.entry_id`,
+		`WITH diff AS (
+		   SELECT entry_id, SUM(credit) - SUM(debit) AS diff FROM journal_lines
+		   GROUP BY entry_id HAVING SUM(credit) <> SUM(debit)
+		 ), target AS (
+		   SELECT DISTINCT ON (l.entry_id) l.id, diff.diff FROM journal_lines l JOIN diff ON diff.entry_id = l.entry_id
+		   WHERE l.debit > 0 ORDER BY l.entry_id, l.debit DESC
+		 )
+		 UPDATE journal_lines l SET debit = l.debit + target.diff FROM target WHERE target.id = l.id`,
+		`UPDATE journal_entries e SET total_amount = COALESCE((SELECT SUM(l.debit) FROM journal_lines l WHERE l.entry_id = e.id), 0)`,
+
+		// Limits and list prices at today's rate
+		`UPDATE partners SET credit_limit = ROUND(credit_limit / (SELECT d FROM rebase_today), @scale)`,
+		`UPDATE products SET price = ROUND(price / (SELECT d FROM rebase_today), LEAST(@scale, 2))`,
+
+		// Rate table: other currencies against the new base, the old base as a regular currency
+		`UPDATE exchange_rates x SET rate = x.rate / r.d FROM rebase_rates r WHERE r.id = x.id`,
+		`INSERT INTO exchange_rates (id, currency, rate_date, rate, source, created_at, updated_at)
+		 SELECT gen_random_uuid(), @from, rate_date, ROUND(1 / rate, 12), source, NOW(), NOW()
+		 FROM exchange_rates WHERE currency = @to`,
+		`DELETE FROM exchange_rates WHERE currency = @to`,
+		`INSERT INTO currencies (code, name, is_active, created_at, updated_at) VALUES (@from, @from, true, NOW(), NOW())
+		 ON CONFLICT (code) DO UPDATE SET is_active = true`,
+	}
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt, args).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
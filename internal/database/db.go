@@ -16,6 +16,9 @@ func NewConnection(dsn string) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	renameBaseCurrencyColumns(db)
+	addLegacyCurrencyColumns(db)
+
 	// Auto-migrate core models
 	err = db.AutoMigrate(
 		&model.User{},
@@ -26,17 +29,95 @@ func NewConnection(dsn string) (*gorm.DB, error) {
 		&model.RefreshToken{},
 		&model.AuditLog{},
 		&model.TaxRule{},
+		&model.TaxTypeDefinition{},
 		&model.Expense{},
 		&model.Role{},
 		&model.Permission{},
 		&model.Invoice{},
+		&model.InvoiceLine{},
 		&model.ApprovalRequest{},
 		&model.Partner{},
 		&model.PartnerAddress{},
+		&model.PickList{},
+		&model.PickListItem{},
+		&model.Package{},
+		&model.PackageItem{},
+		&model.Vehicle{},
+		&model.CostLayer{},
+		&model.LandedCostAllocation{},
+		&model.LandedCostAllocationLine{},
+		&model.CustomsDeclaration{},
+		&model.CustomsDeclarationLine{},
+		&model.DocumentTemplate{},
+		&model.EInvoice{},
+		&model.DocumentSequence{},
+		&model.Payment{},
+		&model.PaymentAllocation{},
+		&model.PaymentRun{},
+		&model.PaymentRunLine{},
+		&model.Account{},
+		&model.JournalEntry{},
+		&model.JournalLine{},
+		&model.FiscalPeriod{},
+		&model.Currency{},
+		&model.ExchangeRate{},
+		&model.CompanySetting{},
+		&model.CITSetting{},
 	)
 	if err != nil {
 		log.Println("WARNING: Failed to auto-migrate models:", err)
 	}
 
+	backfillDocumentCurrency(db)
+	backfillAllocationDates(db)
+
 	return db, nil
 }
+
+// backfillDocumentCurrency fills the document-currency amounts of invoices, payment allocations
+// and orders recorded before they had a currency: expense invoices take the currency and rate of
+// their expense, everything else stays in USD. Each statement only touches rows not filled yet,
+// so running it on every start is harmless.
+func backfillDocumentCurrency(db *gorm.DB) {
+	statements := []string{
+		`UPDATE invoices i SET currency = e.currency, exchange_rate = e.exchange_rate
+		 FROM expenses e
+		 WHERE i.reference_type = 'EXPENSE' AND i.reference_id = e.id
+		   AND i.doc_total_amount = 0 AND i.total_amount <> 0
+		   AND i.currency = 'USD' AND e.currency <> 'USD'`,
+		`UPDATE invoices SET
+		   doc_subtotal = ROUND(subtotal / exchange_rate, 4),
+		   doc_tax_amount = ROUND(tax_amount / exchange_rate, 4),
+		   doc_side_fees = ROUND(side_fees / exchange_rate, 4),
+		   doc_total_amount = ROUND(subtotal / exchange_rate, 4) + ROUND(tax_amount / exchange_rate, 4) + ROUND(side_fees / exchange_rate, 4)
+		 WHERE doc_total_amount = 0 AND total_amount <> 0`,
+		`UPDATE payment_allocations pa SET
+		   doc_amount = ROUND(pa.amount / i.exchange_rate, 4),
+		   payment_amount_base = pa.amount
+		 FROM invoices i
+		 WHERE i.id = pa.invoice_id AND pa.doc_amount = 0 AND pa.amount <> 0`,
+		`UPDATE invoices i SET doc_paid_amount = a.total
+		 FROM (SELECT invoice_id, SUM(doc_amount) AS total FROM payment_allocations GROUP BY invoice_id) a
+		 WHERE a.invoice_id = i.id AND i.doc_paid_amount = 0 AND i.paid_amount <> 0`,
+		`UPDATE orders o SET total_amount = i.doc_total_amount, total_amount_base = i.total_amount
+		 FROM invoices i
+		 WHERE i.reference_id = o.id AND i.reference_type IN ('ORDER_IMPORT', 'ORDER_EXPORT')
+		   AND i.invoice_type = 'STANDARD' AND o.total_amount = 0`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			log.Println("WARNING: Failed to backfill document currency amounts:", err)
+			return
+		}
+	}
+}
+
+// backfillAllocationDates dates the payment allocations recorded before allocations had a date
+// on their payment date, which is how the aging report used to treat them
+func backfillAllocationDates(db *gorm.DB) {
+	if err := db.Exec(`UPDATE payment_allocations pa SET allocated_at = pm.payment_date
+		FROM payments pm
+		WHERE pm.id = pa.payment_id AND pa.allocated_at IS NULL`).Error; err != nil {
+		log.Println("WARNING: Failed to backfill payment allocation dates:", err)
+	}
+}
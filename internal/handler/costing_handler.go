@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CostingHandler struct {
+	costingService service.CostingService
+}
+
+func NewCostingHandler(costingService service.CostingService) *CostingHandler {
+	return &CostingHandler{costingService: costingService}
+}
+
+func (h *CostingHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/api/orders/:id/landed-cost", middleware.RequirePermission("costing.read"), h.GetLandedCost)
+	router.POST("/api/orders/:id/landed-cost", middleware.RequirePermission("costing.write"), h.AllocateLandedCost)
+	router.GET("/api/inventory/valuation", middleware.RequirePermission("costing.read"), h.GetInventoryValuation)
+}
+
+// GetLandedCost returns the landed cost allocation of an import order
+// @Summary      Get landed cost allocation
+// @Tags         costing
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  response.Response{data=service.LandedCostResponse}
+// @Failure      404  {object}  response.Response
+// @Router       /api/orders/{id}/landed-cost [get]
+func (h *CostingHandler) GetLandedCost(c *gin.Context) {
+	allocation, err := h.costingService.GetLandedCost(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.Error(http.StatusNotFound, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, allocation))
+}
+
+// AllocateLandedCost spreads freight, import duty and handling expenses over an import order's items
+// @Summary      Allocate landed cost
+// @Description  Distributes freight (import invoice side fees), import duty (IMPORT_TAX rule) and approved order expenses across the order items by VALUE, QUANTITY or WEIGHT, and updates the cost layers used for valuation. Re-running replaces the previous allocation.
+// @Tags         costing
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                             true  "Order ID"
+// @Param        payload  body      service.AllocateLandedCostRequest  true  "Allocation method"
+// @Success      200      {object}  response.Response{data=service.LandedCostResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/orders/{id}/landed-cost [post]
+func (h *CostingHandler) AllocateLandedCost(c *gin.Context) {
+	var req service.AllocateLandedCostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	allocation, err := h.costingService.AllocateLandedCost(c.Request.Context(), c.Param("id"), c.GetString("userID"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, allocation))
+}
+
+// GetInventoryValuation values the remaining stock of every cost layer
+// @Summary      Inventory valuation
+// @Description  Values on-hand stock from open FIFO cost layers (purchase cost + allocated landed cost)
+// @Tags         costing
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  response.Response{data=service.InventoryValuationResponse}
+// @Failure      500  {object}  response.Response
+// @Router       /api/inventory/valuation [get]
+func (h *CostingHandler) GetInventoryValuation(c *gin.Context) {
+	valuation, err := h.costingService.GetInventoryValuation(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, valuation))
+}
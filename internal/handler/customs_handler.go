@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CustomsHandler struct {
+	customsService service.CustomsService
+}
+
+func NewCustomsHandler(customsService service.CustomsService) *CustomsHandler {
+	return &CustomsHandler{customsService: customsService}
+}
+
+func (h *CustomsHandler) RegisterRoutes(router *gin.RouterGroup) {
+	declarations := router.Group("/api/customs-declarations")
+	{
+		declarations.GET("", middleware.RequirePermission("customs.read"), h.ListDeclarations)
+		declarations.GET("/:id", middleware.RequirePermission("customs.read"), h.GetDeclaration)
+		declarations.POST("", middleware.RequirePermission("customs.write"), h.CreateDeclaration)
+		declarations.PUT("/:id", middleware.RequirePermission("customs.write"), h.UpdateDeclaration)
+		declarations.PUT("/:id/status", middleware.RequirePermission("customs.write"), h.UpdateStatus)
+	}
+}
+
+// ListDeclarations returns a paginated list of customs declarations
+// @Summary      List customs declarations
+// @Tags         customs
+// @Security     BearerAuth
+// @Produce      json
+// @Param        status    query     string  false  "Filter by status (DRAFT, SUBMITTED, CLEARED, REJECTED, CANCELLED)"
+// @Param        order_id  query     string  false  "Filter by order"
+// @Param        page      query     int     false  "Page number (default 1)"
+// @Param        limit     query     int     false  "Number of items per page (default 20)"
+// @Success      200       {object}  response.Response{data=object}
+// @Failure      400       {object}  response.Response
+// @Router       /api/customs-declarations [get]
+func (h *CustomsHandler) ListDeclarations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filter := service.CustomsFilter{
+		Status:  c.Query("status"),
+		OrderID: c.Query("order_id"),
+		Page:    page,
+		Limit:   limit,
+	}
+
+	declarations, total, err := h.customsService.ListDeclarations(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, map[string]interface{}{
+		"declarations": declarations,
+		"total":        total,
+		"page":         page,
+		"limit":        limit,
+	}))
+}
+
+// GetDeclaration returns a customs declaration with its lines
+// @Summary      Get customs declaration
+// @Tags         customs
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Declaration ID"
+// @Success      200  {object}  response.Response{data=service.CustomsDeclarationResponse}
+// @Failure      404  {object}  response.Response
+// @Router       /api/customs-declarations/{id} [get]
+func (h *CustomsHandler) GetDeclaration(c *gin.Context) {
+	declaration, err := h.customsService.GetDeclaration(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.Error(http.StatusNotFound, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, declaration))
+}
+
+// CreateDeclaration drafts a customs declaration for an order
+// @Summary      Create customs declaration
+// @Description  Drafts a declaration with HS code, origin and customs value per order item; import duty (IMPORT_TAX) and import VAT (VAT_INTL) are computed from the active tax rules
+// @Tags         customs
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      service.CreateCustomsDeclarationRequest  true  "Declaration payload"
+// @Success      201      {object}  response.Response{data=service.CustomsDeclarationResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/customs-declarations [post]
+func (h *CustomsHandler) CreateDeclaration(c *gin.Context) {
+	var req service.CreateCustomsDeclarationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	declaration, err := h.customsService.CreateDeclaration(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, declaration))
+}
+
+// UpdateDeclaration replaces the header and lines of a DRAFT or REJECTED declaration
+// @Summary      Update customs declaration
+// @Tags         customs
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                   true  "Declaration ID"
+// @Param        payload  body      service.UpdateCustomsDeclarationRequest  true  "Declaration payload"
+// @Success      200      {object}  response.Response{data=service.CustomsDeclarationResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/customs-declarations/{id} [put]
+func (h *CustomsHandler) UpdateDeclaration(c *gin.Context) {
+	var req service.UpdateCustomsDeclarationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	declaration, err := h.customsService.UpdateDeclaration(c.Request.Context(), c.Param("id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, declaration))
+}
+
+// UpdateStatus moves a declaration through the customs workflow
+// @Summary      Change customs declaration status
+// @Description  SUBMITTED registers the customs declaration number and fixes tax rates; CLEARED on an IMPORT declaration raises a duty + import VAT expense for approval
+// @Tags         customs
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                              true  "Declaration ID"
+// @Param        payload  body      service.UpdateCustomsStatusRequest  true  "New status"
+// @Success      200      {object}  response.Response{data=service.CustomsDeclarationResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/customs-declarations/{id}/status [put]
+func (h *CustomsHandler) UpdateStatus(c *gin.Context) {
+	var req service.UpdateCustomsStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	declaration, err := h.customsService.UpdateStatus(c.Request.Context(), c.Param("id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, declaration))
+}
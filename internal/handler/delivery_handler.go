@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeliveryHandler struct {
+	deliveryService service.DeliveryService
+}
+
+func NewDeliveryHandler(deliveryService service.DeliveryService) *DeliveryHandler {
+	return &DeliveryHandler{deliveryService: deliveryService}
+}
+
+func (h *DeliveryHandler) RegisterRoutes(router *gin.RouterGroup) {
+	vehicles := router.Group("/api/vehicles")
+	{
+		vehicles.GET("", middleware.RequirePermission("delivery.read"), h.ListVehicles)
+		vehicles.POST("", middleware.RequirePermission("delivery.write"), h.CreateVehicle)
+		vehicles.PUT("/:id", middleware.RequirePermission("delivery.write"), h.UpdateVehicle)
+		vehicles.DELETE("/:id", middleware.RequirePermission("delivery.write"), h.DeleteVehicle)
+	}
+
+	router.POST("/api/routes/plan", middleware.RequirePermission("delivery.read"), h.PlanRoutes)
+}
+
+// ListVehicles returns the delivery fleet
+// @Summary      List vehicles
+// @Tags         delivery
+// @Security     BearerAuth
+// @Produce      json
+// @Param        active  query     bool  false  "Only active vehicles"
+// @Success      200     {object}  response.Response{data=[]service.VehicleResponse}
+// @Failure      500     {object}  response.Response
+// @Router       /api/vehicles [get]
+func (h *DeliveryHandler) ListVehicles(c *gin.Context) {
+	activeOnly := c.Query("active") == "true"
+
+	vehicles, err := h.deliveryService.ListVehicles(c.Request.Context(), activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, vehicles))
+}
+
+// CreateVehicle registers a delivery vehicle with its capacity
+// @Summary      Create vehicle
+// @Tags         delivery
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      service.VehicleRequest  true  "Vehicle payload"
+// @Success      201      {object}  response.Response{data=service.VehicleResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/vehicles [post]
+func (h *DeliveryHandler) CreateVehicle(c *gin.Context) {
+	var req service.VehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	vehicle, err := h.deliveryService.CreateVehicle(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, vehicle))
+}
+
+// UpdateVehicle updates a delivery vehicle
+// @Summary      Update vehicle
+// @Tags         delivery
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "Vehicle ID"
+// @Param        payload  body      service.VehicleRequest  true  "Vehicle payload"
+// @Success      200      {object}  response.Response{data=service.VehicleResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/vehicles/{id} [put]
+func (h *DeliveryHandler) UpdateVehicle(c *gin.Context) {
+	var req service.VehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	vehicle, err := h.deliveryService.UpdateVehicle(c.Request.Context(), c.GetString("userID"), c.Param("id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, vehicle))
+}
+
+// DeleteVehicle removes a delivery vehicle
+// @Summary      Delete vehicle
+// @Tags         delivery
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Vehicle ID"
+// @Success      200  {object}  response.Response
+// @Failure      400  {object}  response.Response
+// @Router       /api/vehicles/{id} [delete]
+func (h *DeliveryHandler) DeleteVehicle(c *gin.Context) {
+	if err := h.deliveryService.DeleteVehicle(c.Request.Context(), c.GetString("userID"), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, gin.H{"message": "Vehicle deleted successfully"}))
+}
+
+// PlanRoutes groups approved export orders into delivery routes per vehicle
+// @Summary      Plan delivery routes
+// @Description  Groups approved EXPORT orders into one route per vehicle, respecting weight/volume capacity and delivery time windows (nearest neighbour + 2-opt, no external map service)
+// @Tags         delivery
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      service.PlanRoutesRequest  true  "Orders, vehicles and depot"
+// @Success      200      {object}  response.Response{data=service.RoutePlanResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/routes/plan [post]
+func (h *DeliveryHandler) PlanRoutes(c *gin.Context) {
+	var req service.PlanRoutesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	plan, err := h.deliveryService.PlanRoutes(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, plan))
+}
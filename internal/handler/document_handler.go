@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DocumentHandler struct {
+	documentService service.DocumentService
+	sequenceService service.DocumentSequenceService
+}
+
+func NewDocumentHandler(documentService service.DocumentService, sequenceService service.DocumentSequenceService) *DocumentHandler {
+	return &DocumentHandler{
+		documentService: documentService,
+		sequenceService: sequenceService,
+	}
+}
+
+func (h *DocumentHandler) RegisterRoutes(router *gin.RouterGroup) {
+	templates := router.Group("/api/document-templates")
+	{
+		templates.GET("", middleware.RequirePermission("documents.manage"), h.ListTemplates)
+		templates.PUT("/:doc_type", middleware.RequirePermission("documents.manage"), h.UpdateTemplate)
+	}
+
+	sequences := router.Group("/api/document-sequences")
+	{
+		sequences.GET("", middleware.RequirePermission("documents.manage"), h.ListSequences)
+		sequences.PUT("/:doc_type", middleware.RequirePermission("documents.manage"), h.UpdateSequence)
+	}
+
+	router.GET("/api/invoices/:id/pdf", middleware.RequirePermission("invoices.read"), h.DownloadInvoice)
+	router.GET("/api/orders/:id/pdf", middleware.RequirePermission("inventory.read"), h.DownloadOrder)
+	router.GET("/api/expenses/:id/pdf", middleware.RequirePermission("expenses.read"), h.DownloadExpenseVoucher)
+}
+
+// ListTemplates returns the print template of every document type
+// @Summary      List document templates
+// @Description  Returns the configured template of each document (INVOICE, DELIVERY_NOTE, GOODS_RECEIPT, PAYMENT_VOUCHER) or its built-in default
+// @Tags         documents
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  response.Response{data=[]service.DocumentTemplateResponse}
+// @Failure      500  {object}  response.Response
+// @Router       /api/document-templates [get]
+func (h *DocumentHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.documentService.ListTemplates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, templates))
+}
+
+// UpdateTemplate configures the company header, titles, footer and QR code of a document type
+// @Summary      Update document template
+// @Tags         documents
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        doc_type  path      string                                 true  "INVOICE, DELIVERY_NOTE, GOODS_RECEIPT or PAYMENT_VOUCHER"
+// @Param        payload   body      service.UpdateDocumentTemplateRequest  true  "Template payload"
+// @Success      200       {object}  response.Response{data=service.DocumentTemplateResponse}
+// @Failure      400       {object}  response.Response
+// @Router       /api/document-templates/{doc_type} [put]
+func (h *DocumentHandler) UpdateTemplate(c *gin.Context) {
+	var req service.UpdateDocumentTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	template, err := h.documentService.UpdateTemplate(c.Request.Context(), c.Param("doc_type"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, template))
+}
+
+// ListSequences returns the numbering pattern and next number of every document type
+// @Summary      List document number sequences
+// @Tags         documents
+// @Security     BearerAuth
+// @Produce      json
+// @Param        year  query     int  false  "Year (default: current year)"
+// @Success      200   {object}  response.Response{data=[]service.DocumentSequenceResponse}
+// @Failure      500   {object}  response.Response
+// @Router       /api/document-sequences [get]
+func (h *DocumentHandler) ListSequences(c *gin.Context) {
+	year, _ := strconv.Atoi(c.Query("year"))
+
+	sequences, err := h.sequenceService.ListSequences(c.Request.Context(), year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, sequences))
+}
+
+// UpdateSequence configures the numbering pattern (and, before first use, the start number) of a document type for a year
+// @Summary      Update document number sequence
+// @Tags         documents
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        doc_type  path      string                                 true  "INVOICE, PICK_LIST or PACKAGE"
+// @Param        payload   body      service.UpdateDocumentSequenceRequest  true  "Sequence payload, e.g. pattern HD{YYYY}-{SEQ:4}"
+// @Success      200       {object}  response.Response{data=service.DocumentSequenceResponse}
+// @Failure      400       {object}  response.Response
+// @Router       /api/document-sequences/{doc_type} [put]
+func (h *DocumentHandler) UpdateSequence(c *gin.Context) {
+	var req service.UpdateDocumentSequenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	sequence, err := h.sequenceService.UpdateSequence(c.Request.Context(), c.Param("doc_type"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, sequence))
+}
+
+// DownloadInvoice renders the printable invoice
+// @Summary      Download invoice PDF
+// @Tags         documents
+// @Security     BearerAuth
+// @Produce      application/pdf
+// @Param        id   path  string  true  "Invoice ID"
+// @Success      200  {file}  file
+// @Failure      400  {object}  response.Response
+// @Router       /api/invoices/{id}/pdf [get]
+func (h *DocumentHandler) DownloadInvoice(c *gin.Context) {
+	pdfBytes, filename, err := h.documentService.RenderInvoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// DownloadOrder renders the delivery note (EXPORT) or goods receipt (IMPORT) of an order
+// @Summary      Download order PDF
+// @Tags         documents
+// @Security     BearerAuth
+// @Produce      application/pdf
+// @Param        id   path  string  true  "Order ID"
+// @Success      200  {file}  file
+// @Failure      400  {object}  response.Response
+// @Router       /api/orders/{id}/pdf [get]
+func (h *DocumentHandler) DownloadOrder(c *gin.Context) {
+	pdfBytes, filename, err := h.documentService.RenderOrder(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// DownloadExpenseVoucher renders the payment voucher of an expense
+// @Summary      Download payment voucher PDF
+// @Tags         documents
+// @Security     BearerAuth
+// @Produce      application/pdf
+// @Param        id   path  string  true  "Expense ID"
+// @Success      200  {file}  file
+// @Failure      400  {object}  response.Response
+// @Router       /api/expenses/{id}/pdf [get]
+func (h *DocumentHandler) DownloadExpenseVoucher(c *gin.Context) {
+	pdfBytes, filename, err := h.documentService.RenderExpenseVoucher(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EInvoiceHandler struct {
+	einvoiceService service.EInvoiceService
+}
+
+func NewEInvoiceHandler(einvoiceService service.EInvoiceService) *EInvoiceHandler {
+	return &EInvoiceHandler{einvoiceService: einvoiceService}
+}
+
+func (h *EInvoiceHandler) RegisterRoutes(router *gin.RouterGroup) {
+	einvoices := router.Group("/api/invoices/:id/e-invoice")
+	{
+		einvoices.POST("", middleware.RequirePermission("einvoices.issue"), h.IssueEInvoice)
+		einvoices.GET("", middleware.RequirePermission("invoices.read"), h.GetEInvoice)
+		einvoices.GET("/xml", middleware.RequirePermission("invoices.read"), h.DownloadEInvoiceXML)
+	}
+}
+
+// IssueEInvoice signs an approved sales invoice and submits it to the tax authority
+// @Summary      Issue e-invoice
+// @Description  Builds the e-invoice XML, signs it with the seller certificate, validates it against the schema and submits it through the configured provider
+// @Tags         einvoices
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                        true   "Invoice ID"
+// @Param        payload  body      service.IssueEInvoiceRequest  false  "Exchange rate and payment method"
+// @Success      201      {object}  response.Response{data=service.EInvoiceResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/invoices/{id}/e-invoice [post]
+func (h *EInvoiceHandler) IssueEInvoice(c *gin.Context) {
+	var req service.IssueEInvoiceRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+			return
+		}
+	}
+
+	userID := c.GetString("userID")
+
+	einvoice, err := h.einvoiceService.IssueEInvoice(c.Request.Context(), c.Param("id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, einvoice))
+}
+
+// GetEInvoice returns the e-invoice of an invoice, refreshing its status while the tax authority has not answered
+// @Summary      Get e-invoice status
+// @Tags         einvoices
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Invoice ID"
+// @Success      200  {object}  response.Response{data=service.EInvoiceResponse}
+// @Failure      404  {object}  response.Response
+// @Router       /api/invoices/{id}/e-invoice [get]
+func (h *EInvoiceHandler) GetEInvoice(c *gin.Context) {
+	einvoice, err := h.einvoiceService.GetEInvoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.Error(http.StatusNotFound, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, einvoice))
+}
+
+// DownloadEInvoiceXML returns the signed e-invoice XML
+// @Summary      Download e-invoice XML
+// @Tags         einvoices
+// @Security     BearerAuth
+// @Produce      application/xml
+// @Param        id   path  string  true  "Invoice ID"
+// @Success      200  {file}  file
+// @Failure      404  {object}  response.Response
+// @Router       /api/invoices/{id}/e-invoice/xml [get]
+func (h *EInvoiceHandler) DownloadEInvoiceXML(c *gin.Context) {
+	xmlBytes, filename, err := h.einvoiceService.GetEInvoiceXML(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.Error(http.StatusNotFound, err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/xml", xmlBytes)
+}
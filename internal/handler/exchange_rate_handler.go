@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExchangeRateHandler struct {
+	rateService service.ExchangeRateService
+}
+
+func NewExchangeRateHandler(rateService service.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{rateService: rateService}
+}
+
+func (h *ExchangeRateHandler) RegisterRoutes(router *gin.RouterGroup) {
+	currencies := router.Group("/api/currencies")
+	{
+		currencies.GET("", middleware.RequirePermission("exchange_rates.read"), h.ListCurrencies)
+		currencies.POST("", middleware.RequirePermission("exchange_rates.write"), h.CreateCurrency)
+		currencies.PUT("/:code", middleware.RequirePermission("exchange_rates.write"), h.UpdateCurrency)
+	}
+
+	rates := router.Group("/api/exchange-rates")
+	{
+		rates.GET("", middleware.RequirePermission("exchange_rates.read"), h.ListRates)
+		rates.GET("/lookup", middleware.RequirePermission("exchange_rates.read"), h.LookupRate)
+		rates.POST("", middleware.RequirePermission("exchange_rates.write"), h.SetRate)
+		rates.POST("/import", middleware.RequirePermission("exchange_rates.write"), h.ImportRates)
+	}
+}
+
+// ListCurrencies returns the currencies expenses can be recorded in
+// @Summary      List currencies
+// @Tags         exchange-rates
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  response.Response{data=[]service.CurrencyResponse}
+// @Failure      500  {object}  response.Response
+// @Router       /api/currencies [get]
+func (h *ExchangeRateHandler) ListCurrencies(c *gin.Context) {
+	currencies, err := h.rateService.ListCurrencies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, currencies))
+}
+
+// CreateCurrency adds a currency
+// @Summary      Create currency
+// @Tags         exchange-rates
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      service.CreateCurrencyRequest  true  "Currency payload"
+// @Success      201      {object}  response.Response{data=service.CurrencyResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/currencies [post]
+func (h *ExchangeRateHandler) CreateCurrency(c *gin.Context) {
+	var req service.CreateCurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	currency, err := h.rateService.CreateCurrency(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, currency))
+}
+
+// UpdateCurrency renames or (de)activates a currency
+// @Summary      Update currency
+// @Tags         exchange-rates
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        code     path      string                         true  "Currency code"
+// @Param        payload  body      service.UpdateCurrencyRequest  true  "Currency payload"
+// @Success      200      {object}  response.Response{data=service.CurrencyResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/currencies/{code} [put]
+func (h *ExchangeRateHandler) UpdateCurrency(c *gin.Context) {
+	var req service.UpdateCurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	currency, err := h.rateService.UpdateCurrency(c.Request.Context(), c.Param("code"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, currency))
+}
+
+// ListRates returns a paginated list of daily exchange rates
+// @Summary      List exchange rates
+// @Tags         exchange-rates
+// @Security     BearerAuth
+// @Produce      json
+// @Param        currency   query     string  false  "Filter by currency"
+// @Param        date_from  query     string  false  "Rates dated on or after (YYYY-MM-DD)"
+// @Param        date_to    query     string  false  "Rates dated on or before (YYYY-MM-DD)"
+// @Param        page       query     int     false  "Page number (default 1)"
+// @Param        limit      query     int     false  "Number of items per page (default 20)"
+// @Success      200        {object}  response.Response{data=object}
+// @Failure      400        {object}  response.Response
+// @Router       /api/exchange-rates [get]
+func (h *ExchangeRateHandler) ListRates(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filter := service.ExchangeRateListFilter{
+		Currency: c.Query("currency"),
+		DateFrom: c.Query("date_from"),
+		DateTo:   c.Query("date_to"),
+		Page:     page,
+		Limit:    limit,
+	}
+
+	rates, total, err := h.rateService.ListRates(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, map[string]interface{}{
+		"rates": rates,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	}))
+}
+
+// LookupRate returns the rate applying to a currency on a date
+// @Summary      Look up exchange rate
+// @Description  Returns the latest rate dated on or before the date, as used for new expenses; rates older than 7 days are not used
+// @Tags         exchange-rates
+// @Security     BearerAuth
+// @Produce      json
+// @Param        currency  query     string  true   "Currency code"
+// @Param        date      query     string  false  "Date (YYYY-MM-DD, default: today)"
+// @Success      200       {object}  response.Response{data=service.ExchangeRateResponse}
+// @Failure      404       {object}  response.Response
+// @Router       /api/exchange-rates/lookup [get]
+func (h *ExchangeRateHandler) LookupRate(c *gin.Context) {
+	rate, err := h.rateService.LookupRate(c.Request.Context(), c.Query("currency"), c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.Error(http.StatusNotFound, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, rate))
+}
+
+// SetRate enters the rate of a currency for a day
+// @Summary      Set exchange rate
+// @Description  Stores the rate of a currency for a day, replacing the one already stored. Quote UNITS_PER_BASE takes the rate as units of the currency per unit of base currency.
+// @Tags         exchange-rates
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      service.SetExchangeRateRequest  true  "Rate payload"
+// @Success      200      {object}  response.Response{data=service.ExchangeRateResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/exchange-rates [post]
+func (h *ExchangeRateHandler) SetRate(c *gin.Context) {
+	var req service.SetExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	rate, err := h.rateService.SetRate(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, rate))
+}
+
+// ImportRates imports a CSV bank rate sheet
+// @Summary      Import exchange rates
+// @Description  Imports a CSV with date (YYYY-MM-DD or DD/MM/YYYY), currency and rate columns. Nothing is stored when a row is invalid; the row errors are returned instead.
+// @Tags         exchange-rates
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file   formData  file    true   "CSV rate sheet"
+// @Param        quote  formData  string  false  "BASE_PER_UNIT (default) or UNITS_PER_BASE"
+// @Success      200    {object}  response.Response{data=service.RateImportResult}
+// @Failure      400    {object}  response.Response
+// @Failure      422    {object}  response.Response{data=service.RateImportResult}
+// @Router       /api/exchange-rates/import [post]
+func (h *ExchangeRateHandler) ImportRates(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+	defer file.Close()
+
+	userID := c.GetString("userID")
+
+	result, err := h.rateService.ImportRates(c.Request.Context(), userID, c.PostForm("quote"), file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+	if len(result.Errors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, response.Response{
+			Status:     "error",
+			StatusCode: http.StatusUnprocessableEntity,
+			Data:       result,
+			Error:      "the rate sheet has invalid rows, nothing was imported",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, result))
+}
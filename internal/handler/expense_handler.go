@@ -24,6 +24,7 @@ func (h *ExpenseHandler) RegisterRoutes(router *gin.RouterGroup) {
 	{
 		expenses.GET("", middleware.RequirePermission("expenses.read"), h.GetExpenses)
 		expenses.POST("", middleware.RequirePermission("expenses.write"), h.CreateExpense)
+		expenses.GET("/revaluation", middleware.RequirePermission("expenses.read"), h.GetRevaluation)
 	}
 }
 
@@ -85,3 +86,31 @@ func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, expense))
 }
+
+// GetRevaluation restates foreign-currency expenses at today's exchange rates
+// @Summary      Expense revaluation at today's rate
+// @Description  Compares the base-currency amount booked for each foreign-currency expense with its amount at the latest exchange rate
+// @Tags         expenses
+// @Security     BearerAuth
+// @Produce      json
+// @Param        currency   query     string  false  "Filter by currency"
+// @Param        date_from  query     string  false  "Expenses created on or after (YYYY-MM-DD)"
+// @Param        date_to    query     string  false  "Expenses created on or before (YYYY-MM-DD)"
+// @Success      200        {object}  response.Response{data=service.ExpenseRevaluationReport}
+// @Failure      400        {object}  response.Response
+// @Router       /api/expenses/revaluation [get]
+func (h *ExpenseHandler) GetRevaluation(c *gin.Context) {
+	filter := service.ExpenseRevaluationFilter{
+		Currency: c.Query("currency"),
+		DateFrom: c.Query("date_from"),
+		DateTo:   c.Query("date_to"),
+	}
+
+	report, err := h.expenseService.GetRevaluation(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, report))
+}
@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FulfillmentHandler struct {
+	fulfillmentService service.FulfillmentService
+}
+
+func NewFulfillmentHandler(fulfillmentService service.FulfillmentService) *FulfillmentHandler {
+	return &FulfillmentHandler{fulfillmentService: fulfillmentService}
+}
+
+func (h *FulfillmentHandler) RegisterRoutes(router *gin.RouterGroup) {
+	orders := router.Group("/api/orders/:id")
+	{
+		orders.GET("/pick-list", middleware.RequirePermission("fulfillment.read"), h.GetPickList)
+		orders.POST("/pick-list", middleware.RequirePermission("fulfillment.write"), h.GeneratePickList)
+		orders.PUT("/pick-list/confirm", middleware.RequirePermission("fulfillment.write"), h.ConfirmPicking)
+		orders.GET("/pick-list/pdf", middleware.RequirePermission("fulfillment.read"), h.DownloadPickSlip)
+		orders.GET("/packages", middleware.RequirePermission("fulfillment.read"), h.ListPackages)
+		orders.POST("/packages", middleware.RequirePermission("fulfillment.write"), h.CreatePackage)
+		orders.DELETE("/packages/:packageId", middleware.RequirePermission("fulfillment.write"), h.DeletePackage)
+		orders.GET("/pack-slip/pdf", middleware.RequirePermission("fulfillment.read"), h.DownloadPackSlip)
+		orders.PUT("/packing/confirm", middleware.RequirePermission("fulfillment.write"), h.ConfirmPacking)
+		orders.PUT("/ship", middleware.RequirePermission("fulfillment.write"), h.ShipOrder)
+	}
+}
+
+// GetPickList returns the pick list of an order grouped by bin location
+// @Summary      Get pick list
+// @Description  Returns the pick list of an approved EXPORT order, grouped by bin location
+// @Tags         fulfillment
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  response.Response{data=service.PickListResponse}
+// @Failure      404  {object}  response.Response
+// @Router       /api/orders/{id}/pick-list [get]
+func (h *FulfillmentHandler) GetPickList(c *gin.Context) {
+	pickList, err := h.fulfillmentService.GetPickList(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.Error(http.StatusNotFound, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, pickList))
+}
+
+// GeneratePickList creates a pick list for an approved EXPORT order that has none
+// @Summary      Generate pick list
+// @Description  Generates a pick list for an approved EXPORT order approved before the fulfillment workflow existed
+// @Tags         fulfillment
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      201  {object}  response.Response{data=service.PickListResponse}
+// @Failure      400  {object}  response.Response
+// @Router       /api/orders/{id}/pick-list [post]
+func (h *FulfillmentHandler) GeneratePickList(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	pickList, err := h.fulfillmentService.GeneratePickList(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, pickList))
+}
+
+// ConfirmPicking records picked quantities; the pick list completes once every line is fully picked
+// @Summary      Confirm picking
+// @Description  Records picked quantities per pick list line
+// @Tags         fulfillment
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                         true  "Order ID"
+// @Param        payload  body      service.ConfirmPickingRequest  true  "Picked quantities"
+// @Success      200      {object}  response.Response{data=service.PickListResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/orders/{id}/pick-list/confirm [put]
+func (h *FulfillmentHandler) ConfirmPicking(c *gin.Context) {
+	var req service.ConfirmPickingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	pickList, err := h.fulfillmentService.ConfirmPicking(c.Request.Context(), c.Param("id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, pickList))
+}
+
+// DownloadPickSlip renders the printable pick slip
+// @Summary      Download pick slip PDF
+// @Tags         fulfillment
+// @Security     BearerAuth
+// @Produce      application/pdf
+// @Param        id   path  string  true  "Order ID"
+// @Success      200  {file}  file
+// @Failure      400  {object}  response.Response
+// @Router       /api/orders/{id}/pick-list/pdf [get]
+func (h *FulfillmentHandler) DownloadPickSlip(c *gin.Context) {
+	pdfBytes, filename, err := h.fulfillmentService.RenderPickSlip(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// ListPackages returns all packages of an order
+// @Summary      List packages
+// @Tags         fulfillment
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  response.Response{data=[]service.PackageResponse}
+// @Failure      400  {object}  response.Response
+// @Router       /api/orders/{id}/packages [get]
+func (h *FulfillmentHandler) ListPackages(c *gin.Context) {
+	packages, err := h.fulfillmentService.ListPackages(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, packages))
+}
+
+// CreatePackage records which order items went into a new package
+// @Summary      Create package
+// @Description  Records a package and the quantity of each order item placed inside it
+// @Tags         fulfillment
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                        true  "Order ID"
+// @Param        payload  body      service.CreatePackageRequest  true  "Package payload"
+// @Success      201      {object}  response.Response{data=service.PackageResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/orders/{id}/packages [post]
+func (h *FulfillmentHandler) CreatePackage(c *gin.Context) {
+	var req service.CreatePackageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	pkg, err := h.fulfillmentService.CreatePackage(c.Request.Context(), c.Param("id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, pkg))
+}
+
+// DeletePackage removes a package before packing is confirmed
+// @Summary      Delete package
+// @Tags         fulfillment
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id         path      string  true  "Order ID"
+// @Param        packageId  path      string  true  "Package ID"
+// @Success      200        {object}  response.Response
+// @Failure      400        {object}  response.Response
+// @Router       /api/orders/{id}/packages/{packageId} [delete]
+func (h *FulfillmentHandler) DeletePackage(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	if err := h.fulfillmentService.DeletePackage(c.Request.Context(), c.Param("id"), c.Param("packageId"), userID); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, gin.H{"message": "Package deleted successfully"}))
+}
+
+// DownloadPackSlip renders the printable packing slip listing every package
+// @Summary      Download packing slip PDF
+// @Tags         fulfillment
+// @Security     BearerAuth
+// @Produce      application/pdf
+// @Param        id   path  string  true  "Order ID"
+// @Success      200  {file}  file
+// @Failure      400  {object}  response.Response
+// @Router       /api/orders/{id}/pack-slip/pdf [get]
+func (h *FulfillmentHandler) DownloadPackSlip(c *gin.Context) {
+	pdfBytes, filename, err := h.fulfillmentService.RenderPackSlip(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// ConfirmPacking marks the order PACKED once every line is fully packed
+// @Summary      Confirm packing
+// @Tags         fulfillment
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  response.Response{data=service.FulfillmentStatusResponse}
+// @Failure      400  {object}  response.Response
+// @Router       /api/orders/{id}/packing/confirm [put]
+func (h *FulfillmentHandler) ConfirmPacking(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	status, err := h.fulfillmentService.ConfirmPacking(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, status))
+}
+
+// ShipOrder marks a PACKED order as SHIPPED
+// @Summary      Ship order
+// @Tags         fulfillment
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  response.Response{data=service.FulfillmentStatusResponse}
+// @Failure      400  {object}  response.Response
+// @Router       /api/orders/{id}/ship [put]
+func (h *FulfillmentHandler) ShipOrder(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	status, err := h.fulfillmentService.ShipOrder(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, status))
+}
@@ -27,6 +27,7 @@ func (h *InventoryHandler) RegisterRoutes(router *gin.RouterGroup) {
 		inventory.PUT("/products/:id", middleware.RequirePermission("inventory.write"), h.UpdateProduct)
 		inventory.DELETE("/products/:id", middleware.RequirePermission("inventory.write"), h.DeleteProduct)
 		inventory.POST("/orders", middleware.RequirePermission("inventory.write"), h.CreateOrder)
+		inventory.POST("/orders/:id/credit-override", middleware.RequirePermission("credit.override"), h.OverrideCreditHold)
 	}
 }
 
@@ -159,7 +160,7 @@ func (h *InventoryHandler) DeleteProduct(c *gin.Context) {
 
 // CreateOrder handles EXPORT/IMPORT order creation with DB Transactions
 // @Summary      Create inventory order
-// @Description  Creates an EXPORT or IMPORT order manipulating stock via strict ACID transactions and broadcasting WS updates
+// @Description  Creates an EXPORT or IMPORT order manipulating stock via strict ACID transactions and broadcasting WS updates. EXPORT orders that take the customer over its credit limit are put on credit hold
 // @Tags         inventory
 // @Security     BearerAuth
 // @Accept       json
@@ -186,3 +187,31 @@ func (h *InventoryHandler) CreateOrder(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, "Order created successfully"))
 }
+
+// OverrideCreditHold releases an export order from credit hold
+// @Summary      Override credit hold
+// @Description  Allows an order held for exceeding the customer's credit limit to be approved; the override and its reason are audited
+// @Tags         inventory
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                         true  "Order ID"
+// @Param        payload  body      service.CreditOverrideRequest  true  "Override reason"
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Router       /api/orders/{id}/credit-override [post]
+func (h *InventoryHandler) OverrideCreditHold(c *gin.Context) {
+	var req service.CreditOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+	if err := h.inventoryService.OverrideCreditHold(c.Request.Context(), c.Param("id"), userID, req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, "Credit hold overridden"))
+}
@@ -29,15 +29,21 @@ func (h *InvoiceHandler) RegisterRoutes(router *gin.RouterGroup) {
 	{
 		invoices.POST("", middleware.RequirePermission("invoices.write"), h.CreateInvoice)
 		invoices.GET("", middleware.RequirePermission("invoices.read"), h.ListInvoices)
+		invoices.GET("/:id", middleware.RequirePermission("invoices.read"), h.GetInvoice)
 		invoices.PUT("/:id", middleware.RequirePermission("invoices.write"), h.UpdateInvoice)
 		invoices.PUT("/:id/approve", middleware.RequirePermission("approvals.approve"), h.ApproveInvoice)
 		invoices.PUT("/:id/reject", middleware.RequirePermission("approvals.approve"), h.RejectInvoice)
+		invoices.POST("/:id/adjustments", middleware.RequirePermission("invoices.write"), h.CreateAdjustment)
+		invoices.POST("/:id/replace", middleware.RequirePermission("invoices.write"), h.ReplaceInvoice)
 	}
 
 	// Revenue statistics — separate route group
 	stats := router.Group("/api/statistics")
 	{
 		stats.GET("/revenue", middleware.RequirePermission("finance.read"), h.GetRevenueStatistics)
+		stats.GET("/aging", middleware.RequirePermission("finance.read"), h.GetAgingReport)
+		stats.GET("/aging/invoices", middleware.RequirePermission("finance.read"), h.GetAgingInvoices)
+		stats.GET("/aging/export", middleware.RequirePermission("finance.read"), h.ExportAgingReport)
 	}
 }
 
@@ -68,6 +74,26 @@ func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
 	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, invoice))
 }
 
+// GetInvoice returns the full invoice for viewing and reprinting
+// @Summary      Get invoice
+// @Description  Returns the invoice header, lines, tax breakdown by type and rate, partner snapshot and approval history
+// @Tags         invoices
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Invoice ID"
+// @Success      200  {object}  response.Response{data=service.InvoiceDetailResponse}
+// @Failure      404  {object}  response.Response
+// @Router       /api/invoices/{id} [get]
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	invoice, err := h.invoiceService.GetInvoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.Error(http.StatusNotFound, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, invoice))
+}
+
 // UpdateInvoice updates partner hard-copy fields on a PENDING invoice
 func (h *InvoiceHandler) UpdateInvoice(c *gin.Context) {
 	id := c.Param("id")
@@ -95,6 +121,8 @@ func (h *InvoiceHandler) UpdateInvoice(c *gin.Context) {
 // @Param        status      query     string  false  "Filter by approval status (PENDING, APPROVED, REJECTED)"
 // @Param        invoice_no  query     string  false  "Search by invoice number"
 // @Param        ref_type    query     string  false  "Filter by reference type (ORDER_IMPORT, ORDER_EXPORT, EXPENSE)"
+// @Param        type        query     string  false  "Filter by invoice type (STANDARD, CREDIT_NOTE, DEBIT_NOTE, REPLACEMENT)"
+// @Param        payment_status  query  string  false  "Filter by payment status (UNPAID, PARTIAL, PAID)"
 // @Param        page        query     int     false  "Page number (default 1)"
 // @Param        limit       query     int     false  "Number of items per page (default 20)"
 // @Success      200     {object}  response.Response{data=object}
@@ -108,6 +136,8 @@ func (h *InvoiceHandler) ListInvoices(c *gin.Context) {
 		ApprovalStatus: c.Query("status"),
 		InvoiceNo:      c.Query("invoice_no"),
 		ReferenceType:  c.Query("ref_type"),
+		InvoiceType:    c.Query("type"),
+		PaymentStatus:  c.Query("payment_status"),
 		Page:           page,
 		Limit:          limit,
 	}
@@ -174,15 +204,76 @@ func (h *InvoiceHandler) RejectInvoice(c *gin.Context) {
 	c.JSON(http.StatusOK, response.Success(http.StatusOK, invoice))
 }
 
+// CreateAdjustment raises a credit or debit note against an approved invoice
+// @Summary      Create credit/debit note
+// @Description  Creates a PENDING credit note (decrease) or debit note (increase) referencing an approved invoice; it takes effect once approved via PUT /api/invoices/{id}/approve
+// @Tags         invoices
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                  true  "Original invoice ID"
+// @Param        payload  body      service.CreateInvoiceAdjustmentRequest  true  "Adjustment payload"
+// @Success      201      {object}  response.Response{data=service.InvoiceResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/invoices/{id}/adjustments [post]
+func (h *InvoiceHandler) CreateAdjustment(c *gin.Context) {
+	var req service.CreateInvoiceAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	invoice, err := h.invoiceService.CreateAdjustment(c.Request.Context(), c.Param("id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, invoice))
+}
+
+// ReplaceInvoice issues a replacement that voids the original invoice once approved
+// @Summary      Replace invoice
+// @Description  Creates a PENDING replacement invoice; approving it voids the original, which then no longer counts toward revenue
+// @Tags         invoices
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                         true  "Original invoice ID"
+// @Param        payload  body      service.ReplaceInvoiceRequest  true  "Replacement payload"
+// @Success      201      {object}  response.Response{data=service.InvoiceResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/invoices/{id}/replace [post]
+func (h *InvoiceHandler) ReplaceInvoice(c *gin.Context) {
+	var req service.ReplaceInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	invoice, err := h.invoiceService.ReplaceInvoice(c.Request.Context(), c.Param("id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, invoice))
+}
+
 // GetRevenueStatistics returns revenue data grouped by period (week/month/quarter)
 // @Summary      Get revenue statistics
-// @Description  Returns revenue, expense, and tax data grouped by time period
+// @Description  Returns revenue, expense, and tax data grouped by time period, in a reporting currency. Invoices issued in that currency count at their document amounts; others are converted from the base currency at the latest rate on or before the invoice date, and invoices without such a rate are left out and counted in unconverted_invoices.
 // @Tags         statistics
 // @Security     BearerAuth
 // @Produce      json
 // @Param        group_by    query     string  false  "Group by period: week, month, quarter, year (default: month)"
 // @Param        start_date  query     string  false  "Start date (RFC3339)"
 // @Param        end_date    query     string  false  "End date (RFC3339)"
+// @Param        currency    query     string  false  "Reporting currency (default: the base currency)"
 // @Success      200         {object}  response.Response{data=[]service.RevenueDataPoint}
 // @Failure      500         {object}  response.Response
 // @Router       /api/statistics/revenue [get]
@@ -204,6 +295,7 @@ func (h *InvoiceHandler) GetRevenueStatistics(c *gin.Context) {
 		GroupBy:   groupBy,
 		StartDate: startDateStr,
 		EndDate:   endDateStr,
+		Currency:  c.Query("currency"),
 	}
 
 	data, err := h.revenueService.GetRevenueStatistics(c.Request.Context(), filter)
@@ -214,3 +306,84 @@ func (h *InvoiceHandler) GetRevenueStatistics(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response.Success(http.StatusOK, data))
 }
+
+// GetAgingReport returns receivables or payables per partner in aging buckets
+// @Summary      Get aging report
+// @Description  Buckets the invoices open at the end of as_of by days past due (current, 1-30, 31-60, 61-90, 90+), per partner, in the base currency. Balances are rebuilt as of that date from approved invoices, credit/debit notes and payments
+// @Tags         statistics
+// @Security     BearerAuth
+// @Produce      json
+// @Param        type        query     string  true   "receivable or payable"
+// @Param        as_of       query     string  false  "Report date (YYYY-MM-DD, default today)"
+// @Param        partner_id  query     string  false  "Filter by partner"
+// @Success      200         {object}  response.Response{data=service.AgingReportResponse}
+// @Failure      400         {object}  response.Response
+// @Router       /api/statistics/aging [get]
+func (h *InvoiceHandler) GetAgingReport(c *gin.Context) {
+	report, err := h.revenueService.GetAgingReport(c.Request.Context(), agingFilter(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, report))
+}
+
+// GetAgingInvoices returns the open invoices behind an aging report
+// @Summary      Get aging invoices
+// @Tags         statistics
+// @Security     BearerAuth
+// @Produce      json
+// @Param        type        query     string  true   "receivable or payable"
+// @Param        as_of       query     string  false  "Report date (YYYY-MM-DD, default today)"
+// @Param        partner_id  query     string  false  "Filter by partner"
+// @Param        bucket      query     string  false  "current, 1-30, 31-60, 61-90 or 90+"
+// @Success      200         {object}  response.Response{data=[]service.AgingInvoiceResponse}
+// @Failure      400         {object}  response.Response
+// @Router       /api/statistics/aging/invoices [get]
+func (h *InvoiceHandler) GetAgingInvoices(c *gin.Context) {
+	invoices, err := h.revenueService.GetAgingInvoices(c.Request.Context(), agingFilter(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, invoices))
+}
+
+// ExportAgingReport downloads the aging report as CSV or XLSX
+// @Summary      Export aging report
+// @Tags         statistics
+// @Security     BearerAuth
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        type        query  string  true   "receivable or payable"
+// @Param        as_of       query  string  false  "Report date (YYYY-MM-DD, default today)"
+// @Param        partner_id  query  string  false  "Filter by partner"
+// @Param        bucket      query  string  false  "Bucket of the invoices exported with detail=true"
+// @Param        format      query  string  false  "csv or xlsx (default csv)"
+// @Param        detail      query  bool    false  "Export the invoices instead of the partner totals"
+// @Success      200         {file}  file
+// @Failure      400         {object}  response.Response
+// @Router       /api/statistics/aging/export [get]
+func (h *InvoiceHandler) ExportAgingReport(c *gin.Context) {
+	detail, _ := strconv.ParseBool(c.DefaultQuery("detail", "false"))
+
+	data, filename, contentType, err := h.revenueService.ExportAgingReport(c.Request.Context(), agingFilter(c), c.DefaultQuery("format", "csv"), detail)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+func agingFilter(c *gin.Context) service.AgingFilter {
+	return service.AgingFilter{
+		Type:      c.Query("type"),
+		AsOf:      c.Query("as_of"),
+		PartnerID: c.Query("partner_id"),
+		Bucket:    c.Query("bucket"),
+	}
+}
@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LedgerHandler struct {
+	ledgerService service.LedgerService
+	periodService service.FiscalPeriodService
+}
+
+func NewLedgerHandler(ledgerService service.LedgerService, periodService service.FiscalPeriodService) *LedgerHandler {
+	return &LedgerHandler{ledgerService: ledgerService, periodService: periodService}
+}
+
+func (h *LedgerHandler) RegisterRoutes(router *gin.RouterGroup) {
+	accounts := router.Group("/api/accounts")
+	{
+		accounts.GET("", middleware.RequirePermission("ledger.read"), h.ListAccounts)
+		accounts.POST("", middleware.RequirePermission("ledger.manage"), h.CreateAccount)
+		accounts.PUT("/:code", middleware.RequirePermission("ledger.manage"), h.UpdateAccount)
+	}
+
+	entries := router.Group("/api/journal-entries")
+	{
+		entries.GET("", middleware.RequirePermission("ledger.read"), h.ListJournalEntries)
+		entries.GET("/:id", middleware.RequirePermission("ledger.read"), h.GetJournalEntry)
+	}
+
+	ledger := router.Group("/api/ledger")
+	{
+		ledger.GET("/trial-balance", middleware.RequirePermission("ledger.read"), h.GetTrialBalance)
+		ledger.GET("/general-ledger/:code", middleware.RequirePermission("ledger.read"), h.GetGeneralLedger)
+		ledger.GET("/accounts/:code", middleware.RequirePermission("ledger.read"), h.GetAccountDetail)
+	}
+
+	periods := router.Group("/api/fiscal-periods")
+	{
+		periods.GET("", middleware.RequirePermission("ledger.read"), h.ListPeriods)
+		periods.POST("/:period/close", middleware.RequirePermission("periods.close"), h.ClosePeriod)
+		periods.POST("/:period/reopen", middleware.RequirePermission("periods.reopen"), h.ReopenPeriod)
+	}
+}
+
+// ListAccounts returns the chart of accounts
+// @Summary      List chart of accounts
+// @Tags         ledger
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  response.Response{data=[]service.AccountResponse}
+// @Failure      500  {object}  response.Response
+// @Router       /api/accounts [get]
+func (h *LedgerHandler) ListAccounts(c *gin.Context) {
+	accounts, err := h.ledgerService.ListAccounts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, accounts))
+}
+
+// CreateAccount adds a company sub-account to the chart
+// @Summary      Create sub-account
+// @Description  Adds a sub-account whose code extends its parent's code; the parent must not have postings yet
+// @Tags         ledger
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      service.CreateAccountRequest  true  "Account payload"
+// @Success      201      {object}  response.Response{data=service.AccountResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/accounts [post]
+func (h *LedgerHandler) CreateAccount(c *gin.Context) {
+	var req service.CreateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	account, err := h.ledgerService.CreateAccount(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, account))
+}
+
+// UpdateAccount renames or (de)activates an account
+// @Summary      Update account
+// @Tags         ledger
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        code     path      string                        true  "Account code"
+// @Param        payload  body      service.UpdateAccountRequest  true  "Account payload"
+// @Success      200      {object}  response.Response{data=service.AccountResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/accounts/{code} [put]
+func (h *LedgerHandler) UpdateAccount(c *gin.Context) {
+	var req service.UpdateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	account, err := h.ledgerService.UpdateAccount(c.Request.Context(), c.Param("code"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, account))
+}
+
+// ListJournalEntries returns a paginated list of journal entries
+// @Summary      List journal entries
+// @Tags         ledger
+// @Security     BearerAuth
+// @Produce      json
+// @Param        source_type  query     string  false  "Filter by source (INVOICE, PAYMENT, ORDER)"
+// @Param        source_id    query     string  false  "Filter by source document"
+// @Param        account      query     string  false  "Entries with a line on this account or its sub-accounts"
+// @Param        date_from    query     string  false  "Entries dated on or after (YYYY-MM-DD)"
+// @Param        date_to      query     string  false  "Entries dated on or before (YYYY-MM-DD)"
+// @Param        page         query     int     false  "Page number (default 1)"
+// @Param        limit        query     int     false  "Number of items per page (default 20)"
+// @Success      200          {object}  response.Response{data=object}
+// @Failure      400          {object}  response.Response
+// @Router       /api/journal-entries [get]
+func (h *LedgerHandler) ListJournalEntries(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filter := service.JournalEntryFilter{
+		SourceType:  c.Query("source_type"),
+		SourceID:    c.Query("source_id"),
+		AccountCode: c.Query("account"),
+		DateFrom:    c.Query("date_from"),
+		DateTo:      c.Query("date_to"),
+		Page:        page,
+		Limit:       limit,
+	}
+
+	entries, total, err := h.ledgerService.ListJournalEntries(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, map[string]interface{}{
+		"entries": entries,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	}))
+}
+
+// GetJournalEntry returns a journal entry with its lines
+// @Summary      Get journal entry
+// @Tags         ledger
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Journal entry ID"
+// @Success      200  {object}  response.Response{data=service.JournalEntryResponse}
+// @Failure      404  {object}  response.Response
+// @Router       /api/journal-entries/{id} [get]
+func (h *LedgerHandler) GetJournalEntry(c *gin.Context) {
+	entry, err := h.ledgerService.GetJournalEntry(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.Error(http.StatusNotFound, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, entry))
+}
+
+// GetTrialBalance returns opening balances, movements and closing balances of every account
+// @Summary      Trial balance
+// @Description  Balances per account for the period (default: current year to date), parents rolled up from their sub-accounts
+// @Tags         ledger
+// @Security     BearerAuth
+// @Produce      json
+// @Param        date_from  query     string  false  "Period start (YYYY-MM-DD)"
+// @Param        date_to    query     string  false  "Period end, inclusive (YYYY-MM-DD)"
+// @Success      200        {object}  response.Response{data=service.TrialBalanceResponse}
+// @Failure      400        {object}  response.Response
+// @Router       /api/ledger/trial-balance [get]
+func (h *LedgerHandler) GetTrialBalance(c *gin.Context) {
+	report, err := h.ledgerService.GetTrialBalance(c.Request.Context(), ledgerPeriodFilter(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, report))
+}
+
+// GetGeneralLedger returns the postings of an account with a running balance
+// @Summary      General ledger of an account
+// @Description  Lines posted to the account and its sub-accounts in the period, with contra accounts and running balance
+// @Tags         ledger
+// @Security     BearerAuth
+// @Produce      json
+// @Param        code        path      string  true   "Account code"
+// @Param        date_from   query     string  false  "Period start (YYYY-MM-DD)"
+// @Param        date_to     query     string  false  "Period end, inclusive (YYYY-MM-DD)"
+// @Param        partner_id  query     string  false  "Only lines of this partner"
+// @Success      200         {object}  response.Response{data=service.GeneralLedgerResponse}
+// @Failure      400         {object}  response.Response
+// @Router       /api/ledger/general-ledger/{code} [get]
+func (h *LedgerHandler) GetGeneralLedger(c *gin.Context) {
+	report, err := h.ledgerService.GetGeneralLedger(c.Request.Context(), c.Param("code"), ledgerPeriodFilter(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, report))
+}
+
+// GetAccountDetail returns an account's balances by sub-account and by partner
+// @Summary      Account detail
+// @Description  Opening, movements and closing of the account per sub-account and per partner, e.g. each customer's receivable on 131
+// @Tags         ledger
+// @Security     BearerAuth
+// @Produce      json
+// @Param        code       path      string  true   "Account code"
+// @Param        date_from  query     string  false  "Period start (YYYY-MM-DD)"
+// @Param        date_to    query     string  false  "Period end, inclusive (YYYY-MM-DD)"
+// @Success      200        {object}  response.Response{data=service.AccountDetailResponse}
+// @Failure      400        {object}  response.Response
+// @Router       /api/ledger/accounts/{code} [get]
+func (h *LedgerHandler) GetAccountDetail(c *gin.Context) {
+	report, err := h.ledgerService.GetAccountDetail(c.Request.Context(), c.Param("code"), ledgerPeriodFilter(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, report))
+}
+
+// ListPeriods returns the status of every month of a year
+// @Summary      List fiscal periods
+// @Tags         ledger
+// @Security     BearerAuth
+// @Produce      json
+// @Param        year  query     int  false  "Year (default: current year)"
+// @Success      200   {object}  response.Response{data=[]service.FiscalPeriodResponse}
+// @Failure      500   {object}  response.Response
+// @Router       /api/fiscal-periods [get]
+func (h *LedgerHandler) ListPeriods(c *gin.Context) {
+	year, _ := strconv.Atoi(c.Query("year"))
+
+	periods, err := h.periodService.ListPeriods(c.Request.Context(), year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, periods))
+}
+
+// ClosePeriod soft-closes or closes a month
+// @Summary      Close fiscal period
+// @Description  SOFT_CLOSED stops new documents dated in the month while pending ones can still be approved; CLOSED stops both
+// @Tags         ledger
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        period   path      string                      true  "Period (YYYY-MM)"
+// @Param        payload  body      service.ClosePeriodRequest  true  "Target status"
+// @Success      200      {object}  response.Response{data=service.FiscalPeriodResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/fiscal-periods/{period}/close [post]
+func (h *LedgerHandler) ClosePeriod(c *gin.Context) {
+	var req service.ClosePeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	period, err := h.periodService.ClosePeriod(c.Request.Context(), c.Param("period"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, period))
+}
+
+// ReopenPeriod moves a closed month back to SOFT_CLOSED or OPEN
+// @Summary      Reopen fiscal period
+// @Tags         ledger
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        period   path      string                       true  "Period (YYYY-MM)"
+// @Param        payload  body      service.ReopenPeriodRequest  true  "Target status and reason"
+// @Success      200      {object}  response.Response{data=service.FiscalPeriodResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/fiscal-periods/{period}/reopen [post]
+func (h *LedgerHandler) ReopenPeriod(c *gin.Context) {
+	var req service.ReopenPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	period, err := h.periodService.ReopenPeriod(c.Request.Context(), c.Param("period"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, period))
+}
+
+func ledgerPeriodFilter(c *gin.Context) service.LedgerPeriodFilter {
+	return service.LedgerPeriodFilter{
+		DateFrom:  c.Query("date_from"),
+		DateTo:    c.Query("date_to"),
+		PartnerID: c.Query("partner_id"),
+	}
+}
@@ -23,6 +23,7 @@ func (h *PartnerHandler) RegisterRoutes(router *gin.RouterGroup) {
 	partners := router.Group("/api/partners")
 	{
 		partners.GET("", middleware.RequirePermission("partners.read"), h.ListPartners)
+		partners.GET("/provinces", middleware.RequirePermission("partners.read"), h.ListProvinces)
 		partners.POST("", middleware.RequirePermission("partners.write"), h.CreatePartner)
 		partners.PUT("/:id", middleware.RequirePermission("partners.write"), h.UpdatePartner)
 		partners.DELETE("/:id", middleware.RequirePermission("partners.write"), h.DeletePartner)
@@ -66,6 +67,18 @@ func (h *PartnerHandler) ListPartners(c *gin.Context) {
 	c.JSON(http.StatusOK, response.SuccessWithPagination(http.StatusOK, partners, page, limit, total))
 }
 
+// ListProvinces returns the bundled Vietnamese provincial-level units used to validate addresses
+// @Summary      List provinces
+// @Description  Returns the 34 provincial-level units in force since 07/2025 with the pre-merger names each one absorbed
+// @Tags         partners
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  response.Response{data=[]service.ProvinceResponse}
+// @Router       /api/partners/provinces [get]
+func (h *PartnerHandler) ListProvinces(c *gin.Context) {
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, h.partnerService.ListProvinces()))
+}
+
 // CreatePartner creates a new partner
 // @Summary      Create partner
 // @Tags         partners
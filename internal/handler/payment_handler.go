@@ -0,0 +1,418 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentHandler struct {
+	paymentService service.PaymentService
+}
+
+func NewPaymentHandler(paymentService service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{paymentService: paymentService}
+}
+
+func (h *PaymentHandler) RegisterRoutes(router *gin.RouterGroup) {
+	payments := router.Group("/api/payments")
+	{
+		payments.GET("", middleware.RequirePermission("payments.read"), h.ListPayments)
+		payments.GET("/:id", middleware.RequirePermission("payments.read"), h.GetPayment)
+		payments.POST("/receipts", middleware.RequirePermission("payments.write"), h.CreateReceipt)
+		payments.POST("/disbursements", middleware.RequirePermission("payments.write"), h.CreateDisbursement)
+		payments.POST("/:id/allocations", middleware.RequirePermission("payments.write"), h.AllocatePayment)
+	}
+
+	receivables := router.Group("/api/receivables")
+	{
+		receivables.GET("/partners", middleware.RequirePermission("payments.read"), h.ListReceivables)
+		receivables.GET("/partners/:id", middleware.RequirePermission("payments.read"), h.GetPartnerReceivables)
+	}
+
+	payables := router.Group("/api/payables")
+	{
+		payables.GET("/partners", middleware.RequirePermission("payments.read"), h.ListPayables)
+		payables.GET("/partners/:id", middleware.RequirePermission("payments.read"), h.GetPartnerPayables)
+	}
+
+	runs := router.Group("/api/payment-runs")
+	{
+		runs.GET("", middleware.RequirePermission("payments.read"), h.ListPaymentRuns)
+		runs.GET("/:id", middleware.RequirePermission("payments.read"), h.GetPaymentRun)
+		runs.GET("/:id/bank-file", middleware.RequirePermission("payments.read"), h.DownloadBankFile)
+		runs.POST("", middleware.RequirePermission("payments.write"), h.CreatePaymentRun)
+		runs.POST("/:id/confirm", middleware.RequirePermission("payments.approve"), h.ConfirmPaymentRun)
+		runs.POST("/:id/cancel", middleware.RequirePermission("payments.write"), h.CancelPaymentRun)
+	}
+}
+
+// ListPayments returns a paginated list of payments
+// @Summary      List payments
+// @Tags         payments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        type         query     string  false  "Filter by payment type (RECEIPT, DISBURSEMENT)"
+// @Param        partner_id   query     string  false  "Filter by partner"
+// @Param        date_from    query     string  false  "Payments dated on or after (YYYY-MM-DD)"
+// @Param        date_to      query     string  false  "Payments dated on or before (YYYY-MM-DD)"
+// @Param        unallocated  query     bool    false  "Only payments with an unallocated amount"
+// @Param        page         query     int     false  "Page number (default 1)"
+// @Param        limit        query     int     false  "Number of items per page (default 20)"
+// @Success      200          {object}  response.Response{data=object}
+// @Failure      400          {object}  response.Response
+// @Router       /api/payments [get]
+func (h *PaymentHandler) ListPayments(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	unallocated, _ := strconv.ParseBool(c.DefaultQuery("unallocated", "false"))
+
+	filter := service.PaymentFilter{
+		PaymentType: c.Query("type"),
+		PartnerID:   c.Query("partner_id"),
+		DateFrom:    c.Query("date_from"),
+		DateTo:      c.Query("date_to"),
+		Unallocated: unallocated,
+		Page:        page,
+		Limit:       limit,
+	}
+
+	payments, total, err := h.paymentService.ListPayments(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, map[string]interface{}{
+		"payments": payments,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	}))
+}
+
+// GetPayment returns a payment with its invoice allocations
+// @Summary      Get payment
+// @Tags         payments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Payment ID"
+// @Success      200  {object}  response.Response{data=service.PaymentResponse}
+// @Failure      404  {object}  response.Response
+// @Router       /api/payments/{id} [get]
+func (h *PaymentHandler) GetPayment(c *gin.Context) {
+	payment, err := h.paymentService.GetPayment(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.Error(http.StatusNotFound, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, payment))
+}
+
+// CreateReceipt records money received from a customer
+// @Summary      Record customer receipt
+// @Description  Records a cash or bank receipt in any currency; its base-currency amount can be allocated to approved ORDER_EXPORT invoices of the customer right away or later
+// @Tags         payments
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      service.CreatePaymentRequest  true  "Receipt payload"
+// @Success      201      {object}  response.Response{data=service.PaymentResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/payments/receipts [post]
+func (h *PaymentHandler) CreateReceipt(c *gin.Context) {
+	var req service.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	payment, err := h.paymentService.CreateReceipt(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, payment))
+}
+
+// AllocatePayment applies the unallocated amount of a payment to invoices
+// @Summary      Allocate payment to invoices
+// @Description  Each amount (in the invoice currency) must not exceed the outstanding amount of the invoice, and together they must not exceed the unallocated amount of the payment
+// @Tags         payments
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                          true  "Payment ID"
+// @Param        payload  body      service.AllocatePaymentRequest  true  "Allocations"
+// @Success      200      {object}  response.Response{data=service.PaymentResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/payments/{id}/allocations [post]
+func (h *PaymentHandler) AllocatePayment(c *gin.Context) {
+	var req service.AllocatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	payment, err := h.paymentService.AllocatePayment(c.Request.Context(), c.Param("id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, payment))
+}
+
+// ListReceivables returns the outstanding balance per customer
+// @Summary      List receivables by partner
+// @Tags         payments
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  response.Response{data=[]service.PartnerBalanceResponse}
+// @Failure      500  {object}  response.Response
+// @Router       /api/receivables/partners [get]
+func (h *PaymentHandler) ListReceivables(c *gin.Context) {
+	balances, err := h.paymentService.ListReceivables(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, balances))
+}
+
+// GetPartnerReceivables returns the open invoices and unallocated receipts of a customer
+// @Summary      Get partner receivables
+// @Tags         payments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Partner ID"
+// @Success      200  {object}  response.Response{data=service.PartnerStatementResponse}
+// @Failure      404  {object}  response.Response
+// @Router       /api/receivables/partners/{id} [get]
+func (h *PaymentHandler) GetPartnerReceivables(c *gin.Context) {
+	statement, err := h.paymentService.GetPartnerReceivables(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.Error(http.StatusNotFound, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, statement))
+}
+
+// CreateDisbursement records money paid to a supplier
+// @Summary      Record supplier payment
+// @Description  Records a cash or bank payment in any currency; withheld_amount is the FCT kept back from the supplier and paid to the tax authority. Its base-currency amount can be allocated to approved ORDER_IMPORT and EXPENSE invoices of the supplier
+// @Tags         payments
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      service.CreatePaymentRequest  true  "Disbursement payload"
+// @Success      201      {object}  response.Response{data=service.PaymentResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/payments/disbursements [post]
+func (h *PaymentHandler) CreateDisbursement(c *gin.Context) {
+	var req service.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	payment, err := h.paymentService.CreateDisbursement(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, payment))
+}
+
+// ListPayables returns the outstanding balance per supplier
+// @Summary      List payables by partner
+// @Tags         payments
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  response.Response{data=[]service.PartnerBalanceResponse}
+// @Failure      500  {object}  response.Response
+// @Router       /api/payables/partners [get]
+func (h *PaymentHandler) ListPayables(c *gin.Context) {
+	balances, err := h.paymentService.ListPayables(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, balances))
+}
+
+// GetPartnerPayables returns the open purchase and expense invoices and unallocated payments of a supplier
+// @Summary      Get partner payables
+// @Tags         payments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Partner ID"
+// @Success      200  {object}  response.Response{data=service.PartnerStatementResponse}
+// @Failure      404  {object}  response.Response
+// @Router       /api/payables/partners/{id} [get]
+func (h *PaymentHandler) GetPartnerPayables(c *gin.Context) {
+	statement, err := h.paymentService.GetPartnerPayables(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.Error(http.StatusNotFound, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, statement))
+}
+
+// ListPaymentRuns returns a paginated list of supplier payment runs
+// @Summary      List payment runs
+// @Tags         payments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        status  query     string  false  "Filter by status (DRAFT, CONFIRMED, CANCELLED)"
+// @Param        page    query     int     false  "Page number (default 1)"
+// @Param        limit   query     int     false  "Number of items per page (default 20)"
+// @Success      200     {object}  response.Response{data=object}
+// @Failure      400     {object}  response.Response
+// @Router       /api/payment-runs [get]
+func (h *PaymentHandler) ListPaymentRuns(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	runs, total, err := h.paymentService.ListPaymentRuns(c.Request.Context(), c.Query("status"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, map[string]interface{}{
+		"payment_runs": runs,
+		"total":        total,
+		"page":         page,
+		"limit":        limit,
+	}))
+}
+
+// GetPaymentRun returns a payment run with its invoice lines
+// @Summary      Get payment run
+// @Tags         payments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Payment run ID"
+// @Success      200  {object}  response.Response{data=service.PaymentRunResponse}
+// @Failure      404  {object}  response.Response
+// @Router       /api/payment-runs/{id} [get]
+func (h *PaymentHandler) GetPaymentRun(c *gin.Context) {
+	run, err := h.paymentService.GetPaymentRun(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, response.Error(http.StatusNotFound, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, run))
+}
+
+// CreatePaymentRun drafts a payment run from the supplier invoices due by a date
+// @Summary      Create payment run
+// @Description  Selects approved ORDER_IMPORT and EXPENSE invoices with an outstanding amount due on or before due_before. Expense invoices are paid in the expense currency from Expense.TotalPayable, with the FCT withheld from the transfer
+// @Tags         payments
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      service.CreatePaymentRunRequest  true  "Run payload"
+// @Success      201      {object}  response.Response{data=service.PaymentRunResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/payment-runs [post]
+func (h *PaymentHandler) CreatePaymentRun(c *gin.Context) {
+	var req service.CreatePaymentRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	run, err := h.paymentService.CreatePaymentRun(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, run))
+}
+
+// ConfirmPaymentRun records the disbursements of a draft run
+// @Summary      Confirm payment run
+// @Description  Records one bank disbursement per supplier and currency and allocates it to the invoices of the run
+// @Tags         payments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Payment run ID"
+// @Success      200  {object}  response.Response{data=service.PaymentRunResponse}
+// @Failure      400  {object}  response.Response
+// @Router       /api/payment-runs/{id}/confirm [post]
+func (h *PaymentHandler) ConfirmPaymentRun(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	run, err := h.paymentService.ConfirmPaymentRun(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, run))
+}
+
+// CancelPaymentRun discards a draft payment run
+// @Summary      Cancel payment run
+// @Tags         payments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Payment run ID"
+// @Success      200  {object}  response.Response{data=service.PaymentRunResponse}
+// @Failure      400  {object}  response.Response
+// @Router       /api/payment-runs/{id}/cancel [post]
+func (h *PaymentHandler) CancelPaymentRun(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	run, err := h.paymentService.CancelPaymentRun(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, run))
+}
+
+// DownloadBankFile returns the bank batch file of a confirmed payment run
+// @Summary      Download payment run bank file
+// @Tags         payments
+// @Security     BearerAuth
+// @Produce      text/csv
+// @Param        id   path  string  true  "Payment run ID"
+// @Success      200  {file}  file
+// @Failure      400  {object}  response.Response
+// @Router       /api/payment-runs/{id}/bank-file [get]
+func (h *PaymentHandler) DownloadBankFile(c *gin.Context) {
+	data, filename, err := h.paymentService.ExportPaymentRunFile(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "text/csv", data)
+}
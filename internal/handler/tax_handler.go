@@ -24,10 +24,18 @@ func (h *TaxHandler) RegisterRoutes(router *gin.RouterGroup) {
 	{
 		tax.GET("", middleware.RequirePermission("tax_rules.read"), h.GetTaxRules)
 		tax.GET("/active", middleware.RequirePermission("tax_rules.read"), h.GetActiveTaxRate)
+		tax.POST("/evaluate", middleware.RequirePermission("tax_rules.read"), h.EvaluateTax)
 		tax.POST("", middleware.RequirePermission("tax_rules.write"), h.CreateTaxRule)
 		tax.PUT("/:id", middleware.RequirePermission("tax_rules.write"), h.UpdateTaxRule)
 		tax.DELETE("/:id", middleware.RequirePermission("tax_rules.write"), h.DeleteTaxRule)
 	}
+
+	taxTypes := router.Group("/api/tax-types")
+	{
+		taxTypes.GET("", middleware.RequirePermission("tax_rules.read"), h.ListTaxTypes)
+		taxTypes.POST("", middleware.RequirePermission("tax_rules.write"), h.CreateTaxType)
+		taxTypes.PUT("/:code", middleware.RequirePermission("tax_rules.write"), h.UpdateTaxType)
+	}
 }
 
 // GetTaxRules returns paginated tax rules ordered by effective_from DESC
@@ -69,11 +77,11 @@ func (h *TaxHandler) GetTaxRules(c *gin.Context) {
 
 // GetActiveTaxRate returns the currently active tax rate for a given type
 // @Summary      Get active tax rate
-// @Description  Returns the currently active tax rate for a given type (VAT_INLAND, VAT_INTL, FCT)
+// @Description  Returns the currently active tax rate for a registered tax type (e.g. VAT_INLAND, VAT_INTL, FCT, IMPORT_TAX) among the rules without conditions
 // @Tags         tax-rules
 // @Security     BearerAuth
 // @Produce      json
-// @Param        type  query     string  true  "Tax type: VAT_INLAND, VAT_INTL, FCT"
+// @Param        type  query     string  true  "Tax type code, see /api/tax-types"
 // @Success      200   {object}  response.Response{data=service.TaxRuleResponse}
 // @Failure      400   {object}  response.Response
 // @Failure      404   {object}  response.Response
@@ -82,7 +90,7 @@ func (h *TaxHandler) GetTaxRules(c *gin.Context) {
 func (h *TaxHandler) GetActiveTaxRate(c *gin.Context) {
 	taxType := c.Query("type")
 	if taxType == "" {
-		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "query parameter 'type' is required (VAT_INLAND, VAT_INTL, FCT)"))
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "query parameter 'type' is required (a tax type code, e.g. VAT_INLAND)"))
 		return
 	}
 
@@ -100,9 +108,36 @@ func (h *TaxHandler) GetActiveTaxRate(c *gin.Context) {
 	c.JSON(http.StatusOK, response.Success(http.StatusOK, rate))
 }
 
+// EvaluateTax dry-runs the tax rules for a line
+// @Summary      Evaluate tax rules
+// @Description  Checks the conditions of every rule of the type in effect on the date against the line (product category, partner type, country), ranks them by priority, number of conditions and rate, and explains which rule matched and why. Nothing is saved
+// @Tags         tax-rules
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      service.TaxDryRunRequest  true  "Line to evaluate"
+// @Success      200      {object}  response.Response{data=service.TaxDryRunResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/tax-rules/evaluate [post]
+func (h *TaxHandler) EvaluateTax(c *gin.Context) {
+	var req service.TaxDryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	result, err := h.taxService.DryRunTax(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, result))
+}
+
 // CreateTaxRule creates a new tax rule entry
 // @Summary      Create tax rule
-// @Description  Creates a new tax rule with type, rate, and effective date
+// @Description  Creates a new tax rule with type, rate, effective dates and optional conditions (product category, partner type, country) and priority
 // @Tags         tax-rules
 // @Security     BearerAuth
 // @Accept       json
@@ -186,3 +221,81 @@ func (h *TaxHandler) DeleteTaxRule(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response.Success(http.StatusOK, gin.H{"message": "Tax rule deleted successfully"}))
 }
+
+// ListTaxTypes returns the registered tax types
+// @Summary      List tax types
+// @Description  Returns the tax types rules can be created for, with their category (VAT, IMPORT_DUTY, CONTRACTOR, OTHER) and the document types they apply to
+// @Tags         tax-rules
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  response.Response{data=[]service.TaxTypeResponse}
+// @Failure      500  {object}  response.Response
+// @Router       /api/tax-types [get]
+func (h *TaxHandler) ListTaxTypes(c *gin.Context) {
+	types, err := h.taxService.ListTaxTypes(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, types))
+}
+
+// CreateTaxType registers a tax type
+// @Summary      Create tax type
+// @Description  Registers a tax type. The category decides how its taxes are posted and declared; the document types (ORDER_IMPORT, ORDER_EXPORT, EXPENSE, CUSTOMS_DECLARATION) where its rules may be used
+// @Tags         tax-rules
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      service.CreateTaxTypeRequest  true  "Tax type payload"
+// @Success      201      {object}  response.Response{data=service.TaxTypeResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/tax-types [post]
+func (h *TaxHandler) CreateTaxType(c *gin.Context) {
+	var req service.CreateTaxTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	taxType, err := h.taxService.CreateTaxType(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(http.StatusCreated, taxType))
+}
+
+// UpdateTaxType relabels, recategorizes or (de)activates a tax type
+// @Summary      Update tax type
+// @Description  Updates a tax type. The category of the built-in types cannot change; inactive types get no new rules and cannot be used on new documents
+// @Tags         tax-rules
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        code     path      string                        true  "Tax type code"
+// @Param        payload  body      service.UpdateTaxTypeRequest  true  "Tax type payload"
+// @Success      200      {object}  response.Response{data=service.TaxTypeResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/tax-types/{code} [put]
+func (h *TaxHandler) UpdateTaxType(c *gin.Context) {
+	var req service.UpdateTaxTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	taxType, err := h.taxService.UpdateTaxType(c.Request.Context(), c.Param("code"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, taxType))
+}
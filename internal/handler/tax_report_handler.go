@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+	"backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxReportHandler struct {
+	taxReportService service.TaxReportService
+}
+
+func NewTaxReportHandler(taxReportService service.TaxReportService) *TaxReportHandler {
+	return &TaxReportHandler{taxReportService: taxReportService}
+}
+
+func (h *TaxReportHandler) RegisterRoutes(router *gin.RouterGroup) {
+	reports := router.Group("/api/tax-reports")
+	{
+		reports.GET("/vat", middleware.RequirePermission("finance.read"), h.GetVATReturn)
+		reports.GET("/vat/export", middleware.RequirePermission("finance.read"), h.ExportVATReturn)
+		reports.GET("/fct", middleware.RequirePermission("finance.read"), h.GetFCTReturn)
+		reports.GET("/fct/export", middleware.RequirePermission("finance.read"), h.ExportFCTReturn)
+		reports.GET("/cit", middleware.RequirePermission("finance.read"), h.GetCITEstimate)
+		reports.GET("/cit/settings", middleware.RequirePermission("finance.read"), h.GetCITSettings)
+		reports.PUT("/cit/settings", middleware.RequirePermission("tax_rules.write"), h.UpdateCITSettings)
+	}
+}
+
+// GetVATReturn returns the VAT return (form 01/GTGT) of a month or quarter
+// @Summary      Get VAT return
+// @Description  Aggregates output VAT of approved export invoices and deductible input VAT of approved invoices of deductible expenses issued in the period, grouped by rate, with the invoices sold and purchased. Amounts are in whole VND, converted at the VND rate of each invoice date when the base currency is not VND
+// @Tags         tax-reports
+// @Security     BearerAuth
+// @Produce      json
+// @Param        period          query     string  true   "Month (YYYY-MM) or quarter (YYYY-Qn)"
+// @Param        carried_credit  query     string  false  "Line 22: VAT credit carried from the previous return (VND)"
+// @Success      200             {object}  response.Response{data=service.VATReturnResponse}
+// @Failure      400             {object}  response.Response
+// @Router       /api/tax-reports/vat [get]
+func (h *TaxReportHandler) GetVATReturn(c *gin.Context) {
+	report, err := h.taxReportService.GetVATReturn(c.Request.Context(), vatReturnFilter(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, report))
+}
+
+// ExportVATReturn downloads the VAT return as HTKK XML or XLSX
+// @Summary      Export VAT return
+// @Description  xml: form 01/GTGT in the XML layout imported by HTKK, with the invoices sold and purchased as appendices; needs the company name and tax code on the INVOICE document template. xlsx: the form and both invoice lists as sheets
+// @Tags         tax-reports
+// @Security     BearerAuth
+// @Produce      application/xml
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        period          query  string  true   "Month (YYYY-MM) or quarter (YYYY-Qn)"
+// @Param        carried_credit  query  string  false  "Line 22: VAT credit carried from the previous return (VND)"
+// @Param        format          query  string  false  "xml or xlsx (default xml)"
+// @Success      200             {file}  file
+// @Failure      400             {object}  response.Response
+// @Router       /api/tax-reports/vat/export [get]
+func (h *TaxReportHandler) ExportVATReturn(c *gin.Context) {
+	data, filename, contentType, err := h.taxReportService.ExportVATReturn(c.Request.Context(), vatReturnFilter(c), c.DefaultQuery("format", "xml"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// GetFCTReturn returns the foreign contractor tax declaration (form 01/NTNN) of a month or quarter
+// @Summary      Get FCT return
+// @Description  Lists the foreign vendor expenses whose invoice was approved in the period, by vendor, with the contract value, taxable revenue, VAT portion (expense VAT), CIT portion (FCT computed on the expense) and the FCT withheld on disbursements by the end of the period. Amounts are in whole VND
+// @Tags         tax-reports
+// @Security     BearerAuth
+// @Produce      json
+// @Param        period  query     string  true  "Month (YYYY-MM) or quarter (YYYY-Qn)"
+// @Success      200     {object}  response.Response{data=service.FCTReturnResponse}
+// @Failure      400     {object}  response.Response
+// @Router       /api/tax-reports/fct [get]
+func (h *TaxReportHandler) GetFCTReturn(c *gin.Context) {
+	report, err := h.taxReportService.GetFCTReturn(c.Request.Context(), c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, report))
+}
+
+// ExportFCTReturn downloads the FCT declaration as XML or XLSX
+// @Summary      Export FCT return
+// @Description  xml: form 01/NTNN with one detail per contract; needs the company name and tax code on the INVOICE document template. xlsx: the contracts and the vendor summary as sheets
+// @Tags         tax-reports
+// @Security     BearerAuth
+// @Produce      application/xml
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        period  query  string  true   "Month (YYYY-MM) or quarter (YYYY-Qn)"
+// @Param        format  query  string  false  "xml or xlsx (default xml)"
+// @Success      200     {file}  file
+// @Failure      400     {object}  response.Response
+// @Router       /api/tax-reports/fct/export [get]
+func (h *TaxReportHandler) ExportFCTReturn(c *gin.Context) {
+	data, filename, contentType, err := h.taxReportService.ExportFCTReturn(c.Request.Context(), c.Query("period"), c.DefaultQuery("format", "xml"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+func vatReturnFilter(c *gin.Context) service.VATReturnFilter {
+	return service.VATReturnFilter{
+		Period:        c.Query("period"),
+		CarriedCredit: c.Query("carried_credit"),
+	}
+}
+
+// GetCITEstimate returns the provisional corporate income tax of a quarter
+// @Summary      Get CIT estimate
+// @Description  Estimates the CIT of a quarter: taxable revenue of the approved export invoices and notes, less the cost of goods sold and the deductible expenses whose invoice was approved in the quarter, times the CIT rate. Each expense lists why it is not deductible (MISSING_INVOICE, CASH_OVER_LIMIT). Amounts are in whole VND
+// @Tags         tax-reports
+// @Security     BearerAuth
+// @Produce      json
+// @Param        period  query     string  true  "Quarter (YYYY-Qn)"
+// @Success      200     {object}  response.Response{data=service.CITEstimateResponse}
+// @Failure      400     {object}  response.Response
+// @Router       /api/tax-reports/cit [get]
+func (h *TaxReportHandler) GetCITEstimate(c *gin.Context) {
+	report, err := h.taxReportService.GetCITEstimate(c.Request.Context(), c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, report))
+}
+
+// GetCITSettings returns the rules of the CIT estimate
+// @Summary      Get CIT rules
+// @Description  Returns the CIT rate, the cash payment limit (VND, VAT included) and the expense documents that support a deduction; the defaults apply until they are saved
+// @Tags         tax-reports
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  response.Response{data=service.CITSettingsResponse}
+// @Failure      500  {object}  response.Response
+// @Router       /api/tax-reports/cit/settings [get]
+func (h *TaxReportHandler) GetCITSettings(c *gin.Context) {
+	settings, err := h.taxReportService.GetCITSettings(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Error(http.StatusInternalServerError, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, settings))
+}
+
+// UpdateCITSettings changes the rules of the CIT estimate
+// @Summary      Update CIT rules
+// @Tags         tax-reports
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      service.UpdateCITSettingsRequest  true  "CIT rules"
+// @Success      200      {object}  response.Response{data=service.CITSettingsResponse}
+// @Failure      400      {object}  response.Response
+// @Router       /api/tax-reports/cit/settings [put]
+func (h *TaxReportHandler) UpdateCITSettings(c *gin.Context) {
+	var req service.UpdateCITSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, "Invalid request payload: "+err.Error()))
+		return
+	}
+
+	userID := c.GetString("userID")
+
+	settings, err := h.taxReportService.UpdateCITSettings(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(http.StatusOK, settings))
+}
@@ -15,6 +15,9 @@ const (
 	ActionCreateTaxRule  = "CREATE_TAX_RULE"
 	ActionUpdateTaxRule  = "UPDATE_TAX_RULE"
 	ActionDeleteTaxRule  = "DELETE_TAX_RULE"
+	ActionCreateTaxType  = "CREATE_TAX_TYPE"
+	ActionUpdateTaxType  = "UPDATE_TAX_TYPE"
+	ActionUpdateCITRules = "UPDATE_CIT_RULES"
 
 	// Approval workflow actions
 	ActionCreateApprovalRequest     = "CREATE_APPROVAL_REQUEST"
@@ -22,6 +25,60 @@ const (
 	ActionRejectRequest             = "REJECT_REQUEST"
 	ActionCreateInvoiceFromApproval = "CREATE_INVOICE_FROM_APPROVAL"
 	ActionCreateExpense             = "CREATE_EXPENSE"
+
+	// Warehouse fulfillment actions
+	ActionGeneratePickList = "GENERATE_PICK_LIST"
+	ActionConfirmPicking   = "CONFIRM_PICKING"
+	ActionCreatePackage    = "CREATE_PACKAGE"
+	ActionDeletePackage    = "DELETE_PACKAGE"
+	ActionConfirmPacking   = "CONFIRM_PACKING"
+	ActionShipOrder        = "SHIP_ORDER"
+
+	// Delivery actions
+	ActionCreateVehicle = "CREATE_VEHICLE"
+	ActionUpdateVehicle = "UPDATE_VEHICLE"
+	ActionDeleteVehicle = "DELETE_VEHICLE"
+
+	// Costing actions
+	ActionAllocateLandedCost = "ALLOCATE_LANDED_COST"
+
+	// Customs actions
+	ActionCreateCustomsDeclaration = "CREATE_CUSTOMS_DECLARATION"
+	ActionUpdateCustomsDeclaration = "UPDATE_CUSTOMS_DECLARATION"
+	ActionChangeCustomsStatus      = "CHANGE_CUSTOMS_STATUS"
+
+	// Document printing actions
+	ActionUpdateDocumentTemplate = "UPDATE_DOCUMENT_TEMPLATE"
+	ActionIssueEInvoice          = "ISSUE_E_INVOICE"
+	ActionUpdateDocumentSequence = "UPDATE_DOCUMENT_SEQUENCE"
+
+	// Invoice adjustment actions
+	ActionCreateInvoiceAdjustment  = "CREATE_INVOICE_ADJUSTMENT"
+	ActionApproveInvoiceAdjustment = "APPROVE_INVOICE_ADJUSTMENT"
+	ActionVoidInvoice              = "VOID_INVOICE"
+
+	// Payment actions
+	ActionCreatePayment     = "CREATE_PAYMENT"
+	ActionAllocatePayment   = "ALLOCATE_PAYMENT"
+	ActionCreatePaymentRun  = "CREATE_PAYMENT_RUN"
+	ActionConfirmPaymentRun = "CONFIRM_PAYMENT_RUN"
+	ActionCancelPaymentRun  = "CANCEL_PAYMENT_RUN"
+	ActionCreditHold        = "CREDIT_HOLD"
+	ActionCreditOverride    = "CREDIT_OVERRIDE"
+
+	// Ledger actions
+	ActionCreateAccount = "CREATE_ACCOUNT"
+	ActionUpdateAccount = "UPDATE_ACCOUNT"
+
+	// Fiscal period actions
+	ActionClosePeriod  = "CLOSE_PERIOD"
+	ActionReopenPeriod = "REOPEN_PERIOD"
+
+	// Currency & exchange rate actions
+	ActionCreateCurrency      = "CREATE_CURRENCY"
+	ActionUpdateCurrency      = "UPDATE_CURRENCY"
+	ActionSetExchangeRate     = "SET_EXCHANGE_RATE"
+	ActionImportExchangeRates = "IMPORT_EXCHANGE_RATES"
 )
 
 // AuditLog tracks Who, What, and When for critical system changes
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// CompanySetting holds company-wide settings. There is a single row (ID 1).
+type CompanySetting struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	BaseCurrency string     `gorm:"type:varchar(10);not null" json:"base_currency"` // Currency the books are kept in
+	RebasedAt    *time.Time `json:"rebased_at"`                                     // Last time stored amounts were restated in a new base currency
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// CITSetting holds the rules of the corporate income tax (CIT) estimate. There is a single row
+// (ID 1); until it is saved the defaults apply.
+type CITSetting struct {
+	ID               uint            `gorm:"primaryKey" json:"id"`
+	Rate             decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"rate"`               // e.g. 0.20 = 20%
+	CashPaymentLimit decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"cash_payment_limit"` // VND, VAT included: purchases from this value paid in cash are not deductible
+	DocumentTypes    string          `gorm:"type:text;not null" json:"document_types"`              // Comma-separated expense document types that support a deduction
+	UpdatedBy        *uuid.UUID      `gorm:"type:uuid" json:"updated_by"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// CostLayer is one receipt of stock at a known cost. Layers are created when an IMPORT order is
+// approved and consumed first-in-first-out when EXPORT orders are approved; the remaining
+// quantity of every layer is what inventory valuation is based on.
+type CostLayer struct {
+	ID                uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ProductID         uuid.UUID       `gorm:"type:uuid;not null;index" json:"product_id"`
+	Product           *Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	OrderID           uuid.UUID       `gorm:"type:uuid;not null;index" json:"order_id"`
+	OrderItemID       uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex" json:"order_item_id"`
+	ReceivedAt        time.Time       `gorm:"not null;index" json:"received_at"`
+	Quantity          int             `gorm:"type:int;not null" json:"quantity"`
+	RemainingQuantity int             `gorm:"type:int;not null" json:"remaining_quantity"`
+	UnitCost          decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"unit_cost"`                      // Purchase price
+	LandedCostPerUnit decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"landed_cost_per_unit"` // Freight, duty, handling allocated per unit
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// TotalUnitCost is the purchase price plus allocated landed cost
+func (l CostLayer) TotalUnitCost() decimal.Decimal {
+	return l.UnitCost.Add(l.LandedCostPerUnit)
+}
+
+// Landed cost allocation methods
+const (
+	AllocationByValue    = "VALUE"    // Proportional to quantity × unit price
+	AllocationByQuantity = "QUANTITY" // Proportional to units received
+	AllocationByWeight   = "WEIGHT"   // Proportional to quantity × product weight
+)
+
+// LandedCostAllocation spreads freight, import duty and handling expenses of an IMPORT order
+// over its items. An order has at most one allocation; re-allocating replaces it and reverses
+// the journal entry of the previous one.
+type LandedCostAllocation struct {
+	ID            uuid.UUID                  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID       uuid.UUID                  `gorm:"type:uuid;not null;uniqueIndex" json:"order_id"`
+	Method        string                     `gorm:"type:varchar(20);not null" json:"method"` // VALUE, QUANTITY, WEIGHT
+	FreightAmount decimal.Decimal            `gorm:"type:decimal(18,4);not null;default:0" json:"freight_amount"`
+	DutyAmount    decimal.Decimal            `gorm:"type:decimal(18,4);not null;default:0" json:"duty_amount"`
+	ExpenseAmount decimal.Decimal            `gorm:"type:decimal(18,4);not null;default:0" json:"expense_amount"`
+	TotalAmount   decimal.Decimal            `gorm:"type:decimal(18,4);not null;default:0" json:"total_amount"`
+	IssuedAmount  decimal.Decimal            `gorm:"type:decimal(18,4);not null;default:0" json:"issued_amount"` // Landed cost of units already issued, moved from 1562 to 632 by the allocation's journal entry
+	Lines         []LandedCostAllocationLine `gorm:"foreignKey:AllocationID;constraint:OnDelete:CASCADE" json:"lines"`
+	AllocatedBy   *uuid.UUID                 `gorm:"type:uuid" json:"allocated_by"`
+	CreatedAt     time.Time                  `json:"created_at"`
+	UpdatedAt     time.Time                  `json:"updated_at"`
+}
+
+// LandedCostAllocationLine is the share of one order item
+type LandedCostAllocationLine struct {
+	ID                uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AllocationID      uuid.UUID       `gorm:"type:uuid;not null;index" json:"allocation_id"`
+	OrderItemID       uuid.UUID       `gorm:"type:uuid;not null" json:"order_item_id"`
+	ProductID         uuid.UUID       `gorm:"type:uuid;not null" json:"product_id"`
+	CostLayerID       uuid.UUID       `gorm:"type:uuid;not null" json:"cost_layer_id"`
+	Basis             decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"basis"` // Value, quantity or weight of the line
+	FreightAmount     decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"freight_amount"`
+	DutyAmount        decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"duty_amount"`
+	ExpenseAmount     decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"expense_amount"`
+	TotalAmount       decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"total_amount"`
+	LandedCostPerUnit decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"landed_cost_per_unit"`
+}
+
+// InventoryValuationRow aggregates the open cost layers of one product
+type InventoryValuationRow struct {
+	ProductID    string          `json:"product_id"`
+	ProductSKU   string          `json:"product_sku"`
+	ProductName  string          `json:"product_name"`
+	Quantity     int             `json:"quantity"`
+	PurchaseCost decimal.Decimal `json:"purchase_cost"`
+	LandedCost   decimal.Decimal `json:"landed_cost"`
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// CustomsStatus constants follow a declaration through the customs office
+const (
+	CustomsStatusDraft     = "DRAFT"     // Being prepared, lines can still be edited
+	CustomsStatusSubmitted = "SUBMITTED" // Registered with customs, declaration number assigned
+	CustomsStatusCleared   = "CLEARED"   // Goods released; duty and import VAT become payable
+	CustomsStatusRejected  = "REJECTED"  // Returned by customs, can be amended and resubmitted
+	CustomsStatusCancelled = "CANCELLED"
+)
+
+// DocTypeCustomsDeclaration marks expenses generated from a cleared customs declaration
+const DocTypeCustomsDeclaration = "CUSTOMS_DECLARATION"
+
+// CustomsDeclaration (Tờ khai hải quan) covers the goods of one IMPORT or EXPORT order.
+// Customs values are declared in Currency and converted to the base currency with ExchangeRate;
+// duty and VAT amounts are stored in the base currency like expenses.
+type CustomsDeclaration struct {
+	ID               uuid.UUID                `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID          uuid.UUID                `gorm:"type:uuid;not null;index" json:"order_id"`
+	Order            *Order                   `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Direction        string                   `gorm:"type:varchar(20);not null" json:"direction"`         // IMPORT, EXPORT (copied from the order)
+	DeclarationNo    *string                  `gorm:"type:varchar(30);uniqueIndex" json:"declaration_no"` // Issued by the customs office on registration
+	CustomsOffice    string                   `gorm:"type:varchar(255)" json:"customs_office"`
+	OriginCountry    string                   `gorm:"type:varchar(2);not null" json:"origin_country"` // ISO 3166-1 alpha-2, default for lines
+	Status           string                   `gorm:"type:varchar(20);not null;default:'DRAFT';index" json:"status"`
+	Currency         string                   `gorm:"type:varchar(10);not null" json:"currency"`
+	ExchangeRate     decimal.Decimal          `gorm:"type:decimal(24,12);not null;default:1" json:"exchange_rate"`
+	CustomsValueBase decimal.Decimal          `gorm:"column:customs_value_base;type:decimal(18,4);not null;default:0" json:"customs_value_base"`
+	DutyRate         decimal.Decimal          `gorm:"type:decimal(10,4);not null;default:0" json:"duty_rate"` // Effective IMPORT_TAX rate over the lines
+	DutyAmount       decimal.Decimal          `gorm:"type:decimal(18,4);not null;default:0" json:"duty_amount"`
+	VATRate          decimal.Decimal          `gorm:"type:decimal(10,4);not null;default:0" json:"vat_rate"` // Effective VAT_INTL rate over the lines
+	VATAmount        decimal.Decimal          `gorm:"column:vat_amount;type:decimal(18,4);not null;default:0" json:"vat_amount"`
+	Lines            []CustomsDeclarationLine `gorm:"foreignKey:DeclarationID;constraint:OnDelete:CASCADE" json:"lines"`
+	ExpenseID        *uuid.UUID               `gorm:"type:uuid;index" json:"expense_id"` // Expense raised on clearance
+	Note             string                   `gorm:"type:text" json:"note"`
+	RegisteredAt     *time.Time               `json:"registered_at"` // Tax rates are fixed on this date
+	ClearedAt        *time.Time               `json:"cleared_at"`
+	CreatedBy        *uuid.UUID               `gorm:"type:uuid" json:"created_by"`
+	CreatedAt        time.Time                `json:"created_at"`
+	UpdatedAt        time.Time                `json:"updated_at"`
+}
+
+// CustomsDeclarationLine is one order item on a declaration with its HS code and taxes
+type CustomsDeclarationLine struct {
+	ID               uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	DeclarationID    uuid.UUID       `gorm:"type:uuid;not null;index" json:"declaration_id"`
+	OrderItemID      uuid.UUID       `gorm:"type:uuid;not null;index" json:"order_item_id"`
+	ProductID        uuid.UUID       `gorm:"type:uuid;not null;index" json:"product_id"`
+	Product          *Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	HSCode           string          `gorm:"type:varchar(12);not null;index" json:"hs_code"`
+	OriginCountry    string          `gorm:"type:varchar(2);not null" json:"origin_country"`
+	Quantity         int             `gorm:"type:int;not null" json:"quantity"`
+	CustomsValue     decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"customs_value"` // In declaration currency
+	CustomsValueBase decimal.Decimal `gorm:"column:customs_value_base;type:decimal(18,4);not null" json:"customs_value_base"`
+	DutyRate         decimal.Decimal `gorm:"type:decimal(10,4);not null;default:0" json:"duty_rate"` // IMPORT_TAX rule for the HS code and origin
+	DutyAmount       decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"duty_amount"`
+	VATRate          decimal.Decimal `gorm:"type:decimal(10,4);not null;default:0" json:"vat_rate"` // VAT_INTL rule for the HS code and origin
+	VATAmount        decimal.Decimal `gorm:"column:vat_amount;type:decimal(18,4);not null;default:0" json:"vat_amount"`
+}
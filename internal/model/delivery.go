@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Vehicle is a delivery truck used by route planning. A zero capacity means "not limited".
+type Vehicle struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PlateNumber string         `gorm:"type:varchar(20);uniqueIndex;not null" json:"plate_number"`
+	Name        string         `gorm:"type:varchar(255)" json:"name"`
+	MaxWeightKg float64        `gorm:"type:decimal(12,3);default:0" json:"max_weight_kg"`
+	MaxVolumeM3 float64        `gorm:"type:decimal(12,4);default:0" json:"max_volume_m3"`
+	IsActive    bool           `gorm:"default:true" json:"is_active"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
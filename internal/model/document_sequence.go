@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Document types numbered by a DocumentSequence
+const (
+	SequenceInvoice      = "INVOICE"      // Invoices, credit/debit notes and replacements share one series
+	SequencePickList     = "PICK_LIST"    // Phiếu soạn hàng
+	SequencePackage      = "PACKAGE"      // Kiện hàng
+	SequenceReceipt      = "RECEIPT"      // Phiếu thu
+	SequenceDisbursement = "DISBURSEMENT" // Phiếu chi
+	SequencePaymentRun   = "PAYMENT_RUN"  // Đợt chi trả
+	SequenceJournal      = "JOURNAL"      // Bút toán
+)
+
+// DocumentSequence numbers one document type within a year. Numbers are allocated inside the
+// transaction that creates the document, under a row lock, so a rolled-back document returns its
+// number and concurrent transactions wait instead of colliding. Pattern tokens: {YYYY}, {YY},
+// {SEQ} and {SEQ:n} (zero-padded to n digits), e.g. "HD{YYYY}-{SEQ:4}" → HD2026-0001.
+type DocumentSequence struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	DocType   string     `gorm:"type:varchar(30);not null;uniqueIndex:idx_document_sequence_type_year" json:"doc_type"`
+	Year      int        `gorm:"not null;uniqueIndex:idx_document_sequence_type_year" json:"year"`
+	Pattern   string     `gorm:"type:varchar(50);not null" json:"pattern"`
+	NextValue int64      `gorm:"not null;default:1" json:"next_value"`
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DocumentTemplate types, one printable layout each
+const (
+	DocTemplateInvoice        = "INVOICE"         // Hóa đơn
+	DocTemplateDeliveryNote   = "DELIVERY_NOTE"   // Phiếu xuất kho / giao hàng (EXPORT orders)
+	DocTemplateGoodsReceipt   = "GOODS_RECEIPT"   // Phiếu nhập kho (IMPORT orders)
+	DocTemplatePaymentVoucher = "PAYMENT_VOUCHER" // Phiếu chi (expenses)
+)
+
+// DocumentTemplate holds the configurable parts of a printed document: the company header,
+// bilingual title, footer and QR code settings. Rows are created on first update; until then
+// the built-in defaults are used.
+type DocumentTemplate struct {
+	ID                 uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	DocType            string     `gorm:"type:varchar(30);uniqueIndex;not null" json:"doc_type"` // INVOICE, DELIVERY_NOTE, GOODS_RECEIPT, PAYMENT_VOUCHER
+	Title              string     `gorm:"type:varchar(255);not null" json:"title"`               // Vietnamese title, e.g. "HÓA ĐƠN GIÁ TRỊ GIA TĂNG"
+	TitleEn            string     `gorm:"type:varchar(255)" json:"title_en"`                     // English subtitle
+	CompanyName        string     `gorm:"type:varchar(255)" json:"company_name"`
+	CompanyTaxCode     string     `gorm:"type:varchar(50)" json:"company_tax_code"`
+	CompanyAddress     string     `gorm:"type:text" json:"company_address"`
+	CompanyPhone       string     `gorm:"type:varchar(50)" json:"company_phone"`
+	CompanyEmail       string     `gorm:"type:varchar(255)" json:"company_email"`
+	CompanyBankAccount string     `gorm:"type:varchar(255)" json:"company_bank_account"`
+	FooterNote         string     `gorm:"type:text" json:"footer_note"`
+	ShowQRCode         bool       `gorm:"default:true" json:"show_qr_code"`
+	QRBaseURL          string     `gorm:"column:qr_base_url;type:varchar(255)" json:"qr_base_url"` // When set the QR code links to <url>/<document id>
+	UpdatedBy          *uuid.UUID `gorm:"type:uuid" json:"updated_by"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
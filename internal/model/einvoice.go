@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EInvoiceStatus constants follow an e-invoice from signing to the tax authority's answer
+const (
+	EInvoiceStatusSigned    = "SIGNED"    // Signed and schema-valid, not yet accepted by the provider
+	EInvoiceStatusSubmitted = "SUBMITTED" // Sent, waiting for the tax authority code
+	EInvoiceStatusAccepted  = "ACCEPTED"  // Tax authority code (MCCQT) issued
+	EInvoiceStatusRejected  = "REJECTED"  // Refused by the provider/tax authority, can be re-issued
+)
+
+// EInvoice is the electronic (XML) issue of an approved sales invoice under Decree 123 /
+// Circular 78. The signed XML is stored as sent so it can be downloaded and re-submitted.
+type EInvoice struct {
+	ID               uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	InvoiceID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"invoice_id"`
+	Invoice          *Invoice   `gorm:"foreignKey:InvoiceID" json:"invoice,omitempty"`
+	FormNo           string     `gorm:"type:varchar(1);not null" json:"form_no"`                                       // KHMSHDon, 1 = VAT invoice
+	Series           string     `gorm:"type:varchar(6);not null;uniqueIndex:idx_einvoice_series_number" json:"series"` // KHHDon, e.g. C26TAA
+	Number           int        `gorm:"type:int;not null;uniqueIndex:idx_einvoice_series_number" json:"number"`        // SHDon
+	Status           string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	Provider         string     `gorm:"type:varchar(50);not null" json:"provider"`
+	ProviderRef      string     `gorm:"type:varchar(100)" json:"provider_ref"`
+	TaxAuthorityCode string     `gorm:"type:varchar(34)" json:"tax_authority_code"` // MCCQT
+	SignedXML        string     `gorm:"type:text;not null" json:"-"`
+	CertSubject      string     `gorm:"type:varchar(255)" json:"cert_subject"`
+	ErrorMessage     string     `gorm:"type:text" json:"error_message"`
+	SignedAt         *time.Time `json:"signed_at"`
+	SubmittedAt      *time.Time `json:"submitted_at"`
+	AcceptedAt       *time.Time `json:"accepted_at"`
+	IssuedBy         *uuid.UUID `gorm:"type:uuid" json:"issued_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
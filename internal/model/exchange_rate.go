@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRate source enum constants
+const (
+	RateSourceManual = "MANUAL" // Entered by hand
+	RateSourceImport = "IMPORT" // Imported from a bank rate sheet
+)
+
+// Expense and order rate source enum constants
+const (
+	ExpenseRateBase     = "BASE"     // Document in the base currency, rate is 1
+	ExpenseRateTable    = "TABLE"    // Rate looked up from the exchange rate table
+	ExpenseRateOverride = "OVERRIDE" // Rate typed in by the user, with a reason
+)
+
+// zeroDecimalCurrencies have no minor unit in use (ISO 4217 exponent 0)
+var zeroDecimalCurrencies = map[string]bool{"VND": true, "JPY": true, "KRW": true}
+
+// CurrencyScale is the number of decimal places amounts in a currency are rounded to: none for
+// currencies without a minor unit, otherwise the 4 places amounts are stored with
+func CurrencyScale(code string) int32 {
+	if zeroDecimalCurrencies[code] {
+		return 0
+	}
+	return 4
+}
+
+// Currency is a currency expenses and payments may be recorded in
+type Currency struct {
+	Code      string    `gorm:"type:varchar(10);primaryKey" json:"code"` // ISO 4217, e.g. VND, EUR
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	IsActive  bool      `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExchangeRate is the daily rate of a currency against the base currency. The rate applying on a
+// date is the latest one on or before it.
+type ExchangeRate struct {
+	ID        uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Currency  string          `gorm:"type:varchar(10);not null;uniqueIndex:idx_exchange_rate_currency_date" json:"currency"`
+	RateDate  time.Time       `gorm:"type:date;not null;uniqueIndex:idx_exchange_rate_currency_date" json:"rate_date"`
+	Rate      decimal.Decimal `gorm:"type:decimal(24,12);not null" json:"rate"` // Base currency per unit of currency; 12 places keep VND rates exact enough
+	Source    string          `gorm:"type:varchar(20);not null" json:"source"`  // MANUAL, IMPORT
+	CreatedBy *uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
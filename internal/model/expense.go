@@ -21,28 +21,31 @@ const (
 	FCTTypeGross = "GROSS"
 )
 
-// Expense represents a payment/cost entry with multi-currency support (base: USD)
+// Expense represents a payment/cost entry with multi-currency support, converted to the base currency
 type Expense struct {
 	ID       uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	OrderID  *uuid.UUID `gorm:"type:uuid;index" json:"order_id"`
 	VendorID *uuid.UUID `gorm:"type:uuid;index" json:"vendor_id"`
 
 	// Currency & Exchange Rate
-	Currency           string          `gorm:"type:varchar(10);not null;default:'USD'" json:"currency"`
-	ExchangeRate       decimal.Decimal `gorm:"type:decimal(18,6);not null;default:1" json:"exchange_rate"`                                    // 1 if USD
-	OriginalAmount     decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"original_amount"`                                            // Amount in original currency
-	ConvertedAmountUSD decimal.Decimal `gorm:"column:converted_amount_usd;type:decimal(18,4);not null;default:0" json:"converted_amount_usd"` // = original_amount * exchange_rate
+	Currency           string          `gorm:"type:varchar(10);not null" json:"currency"`
+	ExchangeRate       decimal.Decimal `gorm:"type:decimal(24,12);not null;default:1" json:"exchange_rate"`                           // Base currency per unit of currency; 1 for the base currency
+	OriginalAmount     decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"original_amount"`                                    // Amount in original currency
+	ConvertedAmount    decimal.Decimal `gorm:"column:converted_amount;type:decimal(18,4);not null;default:0" json:"converted_amount"` // = original_amount * exchange_rate
+	RateSource         string          `gorm:"type:varchar(20);not null;default:'OVERRIDE'" json:"rate_source"`                       // BASE, TABLE, OVERRIDE
+	RateDate           *time.Time      `gorm:"type:date" json:"rate_date"`                                                            // Date of the table rate used
+	RateOverrideReason string          `gorm:"type:text" json:"rate_override_reason"`
 
 	// FCT (Foreign Contractor Tax)
 	IsForeignVendor bool            `gorm:"default:false" json:"is_foreign_vendor"`
 	FCTType         string          `gorm:"type:varchar(10)" json:"fct_type"`                                 // NET or GROSS
 	FCTRate         decimal.Decimal `gorm:"type:decimal(10,4);default:0" json:"fct_rate"`                     // Rate fetched from tax_rules
-	FCTAmount       decimal.Decimal `gorm:"column:fct_amount;type:decimal(18,4);default:0" json:"fct_amount"` // Tax amount in USD
+	FCTAmount       decimal.Decimal `gorm:"column:fct_amount;type:decimal(18,4);default:0" json:"fct_amount"` // Tax amount in the base currency
 	TotalPayable    decimal.Decimal `gorm:"type:decimal(18,4);default:0" json:"total_payable"`                // Final amount in original currency
 
 	// VAT
 	VATRate   decimal.Decimal `gorm:"type:decimal(10,4);default:0" json:"vat_rate"`                     // Rate fetched from tax_rules
-	VATAmount decimal.Decimal `gorm:"column:vat_amount;type:decimal(18,4);default:0" json:"vat_amount"` // VAT amount in USD
+	VATAmount decimal.Decimal `gorm:"column:vat_amount;type:decimal(18,4);default:0" json:"vat_amount"` // VAT amount in the base currency
 
 	// Document & Deductibility (Rào chắn chi phí hợp lệ)
 	DocumentType        string  `gorm:"type:varchar(30);not null;default:'NONE'" json:"document_type"` // VAT_INVOICE, DIRECT_INVOICE, RETAIL_RECEIPT, NONE
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FiscalPeriod status enum constants
+const (
+	PeriodOpen       = "OPEN"
+	PeriodSoftClosed = "SOFT_CLOSED" // No new documents; documents already pending can still be approved
+	PeriodClosed     = "CLOSED"      // Nothing can be created or approved in the period
+)
+
+// FiscalPeriod is one accounting month. Months without a row are OPEN. Documents are checked
+// against the period of their accounting date: the approval date for approvals and invoices,
+// the payment date for payments.
+type FiscalPeriod struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Year         int        `gorm:"not null;uniqueIndex:idx_fiscal_period_year_month" json:"year"`
+	Month        int        `gorm:"not null;uniqueIndex:idx_fiscal_period_year_month" json:"month"`
+	Status       string     `gorm:"type:varchar(20);not null;default:'OPEN';index" json:"status"` // OPEN, SOFT_CLOSED, CLOSED
+	ClosedBy     *uuid.UUID `gorm:"type:uuid" json:"closed_by"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	ReopenedBy   *uuid.UUID `gorm:"type:uuid" json:"reopened_by"`
+	ReopenedAt   *time.Time `json:"reopened_at"`
+	ReopenReason string     `gorm:"type:text" json:"reopen_reason"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
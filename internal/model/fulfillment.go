@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// FulfillmentStatus constants track warehouse progress of an approved EXPORT order
+const (
+	FulfillmentPicking = "PICKING" // Pick list generated, staff are collecting items
+	FulfillmentPacked  = "PACKED"  // Packing confirmed, ready to hand over
+	FulfillmentShipped = "SHIPPED" // Handed over to the carrier / truck
+)
+
+// PickListStatus constants
+const (
+	PickListOpen      = "OPEN"
+	PickListCompleted = "COMPLETED"
+)
+
+// PickList is generated when an EXPORT order is approved. Lines are ordered by bin location
+// so warehouse staff can walk the aisles once.
+type PickList struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PickListNo  string         `gorm:"type:varchar(30);uniqueIndex;not null" json:"pick_list_no"`
+	OrderID     uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex" json:"order_id"`
+	Order       *Order         `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Status      string         `gorm:"type:varchar(20);not null;default:'OPEN'" json:"status"` // OPEN, COMPLETED
+	Items       []PickListItem `gorm:"foreignKey:PickListID;constraint:OnDelete:CASCADE" json:"items"`
+	CompletedBy *uuid.UUID     `gorm:"type:uuid" json:"completed_by"`
+	CompletedAt *time.Time     `json:"completed_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// PickListItem is one line of a pick list (snapshot of product + bin at generation time)
+type PickListItem struct {
+	ID               uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PickListID       uuid.UUID `gorm:"type:uuid;not null;index" json:"pick_list_id"`
+	OrderItemID      uuid.UUID `gorm:"type:uuid;not null;index" json:"order_item_id"`
+	ProductID        uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
+	ProductSKU       string    `gorm:"type:varchar(100)" json:"product_sku"`
+	ProductName      string    `gorm:"type:varchar(255)" json:"product_name"`
+	BinLocation      string    `gorm:"type:varchar(50);index" json:"bin_location"`
+	QuantityRequired int       `gorm:"type:int;not null" json:"quantity_required"`
+	QuantityPicked   int       `gorm:"type:int;not null;default:0" json:"quantity_picked"`
+}
+
+// Package records a physical parcel/carton and which order items went into it
+type Package struct {
+	ID        uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PackageNo string          `gorm:"type:varchar(30);uniqueIndex;not null" json:"package_no"`
+	OrderID   uuid.UUID       `gorm:"type:uuid;not null;index" json:"order_id"`
+	WeightKg  decimal.Decimal `gorm:"type:decimal(12,3);not null;default:0" json:"weight_kg"`
+	Note      string          `gorm:"type:text" json:"note"`
+	Items     []PackageItem   `gorm:"foreignKey:PackageID;constraint:OnDelete:CASCADE" json:"items"`
+	CreatedBy *uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// PackageItem is the quantity of one order item placed inside a package
+type PackageItem struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PackageID   uuid.UUID `gorm:"type:uuid;not null;index" json:"package_id"`
+	OrderItemID uuid.UUID `gorm:"type:uuid;not null;index" json:"order_item_id"`
+	ProductID   uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
+	Product     Product   `gorm:"foreignKey:ProductID" json:"-"`
+	Quantity    int       `gorm:"type:int;not null" json:"quantity"`
+}
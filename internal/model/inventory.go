@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -12,8 +13,12 @@ type Product struct {
 	ID           uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	SKU          string         `gorm:"type:varchar(100);uniqueIndex;not null" json:"sku"`
 	Name         string         `gorm:"type:varchar(255);not null" json:"name"`
+	Category     string         `gorm:"type:varchar(100);index" json:"category"` // Matched by tax rule conditions
 	CurrentStock int            `gorm:"type:int;default:0;not null" json:"current_stock"`
-	Price        float64        `gorm:"type:decimal(10,2);not null" json:"price"`
+	Price        float64        `gorm:"type:decimal(18,2);not null" json:"price"`      // In the base currency
+	BinLocation  string         `gorm:"type:varchar(50)" json:"bin_location"`          // Warehouse bin, e.g. "A-01-03"
+	WeightKg     float64        `gorm:"type:decimal(12,3);default:0" json:"weight_kg"` // Per unit, used for vehicle capacity
+	VolumeM3     float64        `gorm:"type:decimal(12,4);default:0" json:"volume_m3"` // Per unit, used for vehicle capacity
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
@@ -46,18 +51,50 @@ type Order struct {
 	ShippingAddressID *uuid.UUID      `gorm:"type:uuid" json:"shipping_address_id"`
 	ShippingAddress   *PartnerAddress `gorm:"foreignKey:ShippingAddressID" json:"shipping_address,omitempty"`
 	Items             []OrderItem     `gorm:"foreignKey:OrderID" json:"items"`
-	CreatedAt         time.Time       `json:"created_at"`
-	UpdatedAt         time.Time       `json:"updated_at"`
+	// --- Currency: item prices are in the order currency ---
+	Currency           string          `gorm:"type:varchar(10);not null" json:"currency"`
+	ExchangeRate       decimal.Decimal `gorm:"type:decimal(24,12);not null;default:1" json:"exchange_rate"` // Base currency per unit of currency; 1 for the base currency
+	RateSource         string          `gorm:"type:varchar(20);not null;default:'BASE'" json:"rate_source"` // BASE, TABLE, OVERRIDE
+	RateDate           *time.Time      `gorm:"type:date" json:"rate_date"`                                  // Date of the table rate used
+	RateOverrideReason string          `gorm:"type:text" json:"rate_override_reason"`
+	TotalAmount        decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"total_amount"`                               // Items, line taxes and side fees in the order currency
+	TotalAmountBase    decimal.Decimal `gorm:"column:total_amount_base;type:decimal(18,4);not null;default:0" json:"total_amount_base"` // = total_amount * exchange_rate
+	// --- Fulfillment (EXPORT only, set after approval) ---
+	FulfillmentStatus  string     `gorm:"type:varchar(20);index" json:"fulfillment_status"` // PICKING, PACKED, SHIPPED
+	PackingConfirmedBy *uuid.UUID `gorm:"type:uuid" json:"packing_confirmed_by"`
+	PackingConfirmedAt *time.Time `json:"packing_confirmed_at"`
+	ShippedAt          *time.Time `json:"shipped_at"`
+	// --- Credit hold (EXPORT only): set when the order would take the customer over its credit
+	// limit; the order can only be approved after a credit override ---
+	CreditHold           bool       `gorm:"not null;default:false;index" json:"credit_hold"`
+	CreditHoldReason     string     `gorm:"type:text" json:"credit_hold_reason"`
+	CreditOverrideBy     *uuid.UUID `gorm:"type:uuid" json:"credit_override_by"`
+	CreditOverrideAt     *time.Time `json:"credit_override_at"`
+	CreditOverrideReason string     `gorm:"type:text" json:"credit_override_reason"`
+	// --- Delivery time window (optional, used by route planning) ---
+	DeliveryWindowStart *time.Time `json:"delivery_window_start"`
+	DeliveryWindowEnd   *time.Time `json:"delivery_window_end"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 
 // OrderItem represents a line item within an Order
 type OrderItem struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	OrderID   uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
-	ProductID uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
-	Product   Product   `gorm:"foreignKey:ProductID" json:"-"`
-	Quantity  int       `gorm:"type:int;not null" json:"quantity"`
-	UnitPrice float64   `gorm:"type:decimal(10,2);not null" json:"unit_price"`
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"order_id"`
+	ProductID uuid.UUID  `gorm:"type:uuid;not null;index" json:"product_id"`
+	Product   Product    `gorm:"foreignKey:ProductID" json:"-"`
+	Quantity  int        `gorm:"type:int;not null" json:"quantity"`
+	UnitPrice float64    `gorm:"type:decimal(18,4);not null" json:"unit_price"`         // In the order currency
+	Discount  float64    `gorm:"type:decimal(18,4);not null;default:0" json:"discount"` // Line discount amount
+	TaxRuleID *uuid.UUID `gorm:"type:uuid;index" json:"tax_rule_id"`                    // Line tax; falls back to the order-level rule when nil
+	TaxRule   *TaxRule   `gorm:"foreignKey:TaxRuleID" json:"-"`
+}
+
+// NetAmount is quantity × unit price less the line discount
+func (i OrderItem) NetAmount() decimal.Decimal {
+	gross := decimal.NewFromFloat(i.UnitPrice).Mul(decimal.NewFromInt(int64(i.Quantity)))
+	return gross.Sub(decimal.NewFromFloat(i.Discount))
 }
 
 // TransactionType Enum Simulation
@@ -68,12 +105,13 @@ const (
 
 // InventoryTransaction (Thẻ kho) records stock changes strictly
 type InventoryTransaction struct {
-	ID              uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	ProductID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"product_id"`
-	OrderID         *uuid.UUID `gorm:"type:uuid;index" json:"order_id"`                   // Nullable in case of manual adjustments
-	TransactionType string     `gorm:"type:varchar(10);not null" json:"transaction_type"` // IN, OUT
-	QuantityChanged int        `gorm:"type:int;not null" json:"quantity_changed"`
-	StockAfter      int        `gorm:"type:int;not null" json:"stock_after"`
-	CreatedAt       time.Time  `json:"created_at"`
-	UpdatedAt       time.Time  `json:"updated_at"`
+	ID              uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ProductID       uuid.UUID       `gorm:"type:uuid;not null;index" json:"product_id"`
+	OrderID         *uuid.UUID      `gorm:"type:uuid;index" json:"order_id"`                   // Nullable in case of manual adjustments
+	TransactionType string          `gorm:"type:varchar(10);not null" json:"transaction_type"` // IN, OUT
+	QuantityChanged int             `gorm:"type:int;not null" json:"quantity_changed"`
+	StockAfter      int             `gorm:"type:int;not null" json:"stock_after"`
+	CostAmount      decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"cost_amount"` // Receipt cost (IN) or FIFO cost of goods issued (OUT)
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
 }
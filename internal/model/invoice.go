@@ -21,24 +21,56 @@ const (
 	ApprovalRejected = "REJECTED"
 )
 
+// InvoiceType enum constants
+const (
+	InvoiceTypeStandard    = "STANDARD"
+	InvoiceTypeCreditNote  = "CREDIT_NOTE" // Decreases the original invoice; amounts are negative
+	InvoiceTypeDebitNote   = "DEBIT_NOTE"  // Increases the original invoice
+	InvoiceTypeReplacement = "REPLACEMENT" // Voids and supersedes the original invoice once approved
+)
+
 // Invoice represents a financial document generated from orders or expenses.
-// Only APPROVED invoices count toward revenue statistics.
+// Lines and the Subtotal/TaxAmount/SideFees/TotalAmount/PaidAmount header amounts are in the
+// base currency; the Doc* amounts repeat the header in the invoice currency, converted at
+// ExchangeRate.
+// Only APPROVED invoices that have not been voided count toward revenue statistics.
+// Approved invoices are never edited: corrections are credit/debit notes or a replacement
+// invoice referencing the original through OriginalInvoiceID.
 type Invoice struct {
 	ID             uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	InvoiceNo      string          `gorm:"type:varchar(30);uniqueIndex;not null" json:"invoice_no"`
 	ReferenceType  string          `gorm:"type:varchar(20);not null;index" json:"reference_type"` // ORDER_IMPORT, ORDER_EXPORT, EXPENSE
 	ReferenceID    uuid.UUID       `gorm:"type:uuid;not null;index" json:"reference_id"`          // FK to orders.id or expenses.id
-	TaxRuleID      *uuid.UUID      `gorm:"type:uuid;index" json:"tax_rule_id"`                    // FK to tax_rules.id (nullable)
+	TaxRuleID      *uuid.UUID      `gorm:"type:uuid;index" json:"tax_rule_id"`                    // FK to tax_rules.id; nil when lines use different rules
 	TaxRule        *TaxRule        `gorm:"foreignKey:TaxRuleID" json:"tax_rule,omitempty"`
 	Subtotal       decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"subtotal"`             // Pre-tax amount
-	TaxAmount      decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"tax_amount"` // Computed from tax rule
+	TaxAmount      decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"tax_amount"` // Sum of line taxes (or computed from tax rule)
 	SideFees       decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"side_fees"`  // Additional fees
 	TotalAmount    decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"total_amount"`         // subtotal + tax_amount + side_fees
+	Currency       string          `gorm:"type:varchar(10);not null" json:"currency"`
+	ExchangeRate   decimal.Decimal `gorm:"type:decimal(24,12);not null;default:1" json:"exchange_rate"` // Base currency per unit of currency; 1 for the base currency
+	DocSubtotal    decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"doc_subtotal"`
+	DocTaxAmount   decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"doc_tax_amount"`
+	DocSideFees    decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"doc_side_fees"`
+	DocTotalAmount decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"doc_total_amount"`
 	ApprovalStatus string          `gorm:"type:varchar(20);not null;default:'PENDING';index" json:"approval_status"`
 	ApprovedBy     *uuid.UUID      `gorm:"type:uuid" json:"approved_by"`
 	Approver       *User           `gorm:"foreignKey:ApprovedBy" json:"approver,omitempty"`
 	ApprovedAt     *time.Time      `json:"approved_at"`
 	Note           string          `gorm:"type:text" json:"note"`
+	Lines          []InvoiceLine   `gorm:"foreignKey:InvoiceID;constraint:OnDelete:CASCADE" json:"lines,omitempty"`
+	// --- Adjustments ---
+	InvoiceType       string     `gorm:"type:varchar(20);not null;default:'STANDARD';index" json:"invoice_type"` // STANDARD, CREDIT_NOTE, DEBIT_NOTE, REPLACEMENT
+	OriginalInvoiceID *uuid.UUID `gorm:"type:uuid;index" json:"original_invoice_id"`                             // Invoice adjusted or replaced by this one
+	OriginalInvoice   *Invoice   `gorm:"foreignKey:OriginalInvoiceID" json:"original_invoice,omitempty"`
+	AdjustmentReason  string     `gorm:"type:text" json:"adjustment_reason"`
+	VoidedAt          *time.Time `json:"voided_at"`                             // Set when an approved replacement supersedes this invoice
+	ReplacedByID      *uuid.UUID `gorm:"type:uuid;index" json:"replaced_by_id"` // The replacement invoice
+	// --- Payments (kept on the original invoice; notes roll into its balance) ---
+	PaymentStatus string          `gorm:"type:varchar(20);not null;default:'UNPAID';index" json:"payment_status"` // UNPAID, PARTIAL, PAID
+	PaidAmount    decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"paid_amount"`
+	DocPaidAmount decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"doc_paid_amount"` // In the invoice currency
+	DueDate       *time.Time      `gorm:"index" json:"due_date"`                                        // Approval date plus the partner's payment terms
 	// --- Partner hard-copy fields (snapshot at invoice creation) ---
 	PartnerID      *uuid.UUID `gorm:"type:uuid;index" json:"partner_id"`
 	Partner        *Partner   `gorm:"foreignKey:PartnerID" json:"partner,omitempty"`
@@ -48,3 +80,24 @@ type Invoice struct {
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
 }
+
+// InvoiceLine is one line of an invoice taxed at its own rate, so a single invoice can mix
+// VAT rates (0%, 5%, 8%, 10%). Description, tax type and rate are copied at invoice creation
+// so the invoice can be reprinted unchanged.
+type InvoiceLine struct {
+	ID          uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	InvoiceID   uuid.UUID       `gorm:"type:uuid;not null;index" json:"invoice_id"`
+	LineNo      int             `gorm:"type:int;not null;default:0" json:"line_no"`
+	OrderItemID *uuid.UUID      `gorm:"type:uuid;index" json:"order_item_id"`
+	ProductID   *uuid.UUID      `gorm:"type:uuid;index" json:"product_id"`
+	Product     *Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Description string          `gorm:"type:text" json:"description"`
+	Quantity    int             `gorm:"type:int;not null" json:"quantity"`
+	UnitPrice   decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"unit_price"`
+	Discount    decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"discount"`
+	Amount      decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"amount"` // Quantity × unit price − discount, before tax
+	TaxRuleID   *uuid.UUID      `gorm:"type:uuid;index" json:"tax_rule_id"`
+	TaxType     string          `gorm:"type:varchar(20)" json:"tax_type"` // Empty = not subject to tax
+	TaxRate     decimal.Decimal `gorm:"type:decimal(10,4);not null;default:0" json:"tax_rate"`
+	TaxAmount   decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"tax_amount"`
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// AccountType enum constants
+const (
+	AccountTypeAsset     = "ASSET"
+	AccountTypeLiability = "LIABILITY"
+	AccountTypeEquity    = "EQUITY"
+	AccountTypeRevenue   = "REVENUE"
+	AccountTypeExpense   = "EXPENSE"
+)
+
+// Normal balance sides
+const (
+	BalanceDebit  = "DEBIT"
+	BalanceCredit = "CREDIT"
+)
+
+// Accounts posted automatically (VAS, Circular 200/2014/TT-BTC)
+const (
+	AccountCash             = "1111"  // Tiền mặt Việt Nam
+	AccountBank             = "1121"  // Tiền gửi ngân hàng
+	AccountReceivable       = "131"   // Phải thu của khách hàng
+	AccountInputVAT         = "1331"  // Thuế GTGT được khấu trừ của hàng hóa, dịch vụ
+	AccountGoodsPurchase    = "1561"  // Giá mua hàng hóa
+	AccountGoodsPurchaseFee = "1562"  // Chi phí thu mua hàng hóa
+	AccountPayable          = "331"   // Phải trả cho người bán
+	AccountOutputVAT        = "33311" // Thuế GTGT đầu ra
+	AccountImportExportTax  = "3333"  // Thuế xuất, nhập khẩu
+	AccountContractorTax    = "3338"  // Thuế bảo vệ môi trường và các loại thuế khác (FCT)
+	AccountSalesRevenue     = "5111"  // Doanh thu bán hàng hóa
+	AccountServiceRevenue   = "5113"  // Doanh thu cung cấp dịch vụ
+	AccountFinancialIncome  = "515"   // Doanh thu hoạt động tài chính (lãi tỷ giá)
+	AccountCostOfGoodsSold  = "632"   // Giá vốn hàng bán
+	AccountFinancialExpense = "635"   // Chi phí tài chính (lỗ tỷ giá)
+	AccountSellingExpense   = "6418"  // Chi phí bán hàng bằng tiền khác
+	AccountAdminExpense     = "6428"  // Chi phí bằng tiền khác
+)
+
+// Journal entry sources
+const (
+	JournalSourceInvoice    = "INVOICE"     // Invoices, credit/debit notes and replacements
+	JournalSourcePayment    = "PAYMENT"     // Receipts and disbursements
+	JournalSourceOrder      = "ORDER"       // Cost of goods sold of an approved export order
+	JournalSourceLandedCost = "LANDED_COST" // Landed cost of units issued before an import order was allocated
+)
+
+// Account is one account of the chart of accounts. Accounts form a tree through ParentCode;
+// only accounts without children take postings, and balances roll up to their parents.
+type Account struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Code          string    `gorm:"type:varchar(20);uniqueIndex;not null" json:"code"`
+	Name          string    `gorm:"type:varchar(255);not null" json:"name"`
+	AccountType   string    `gorm:"type:varchar(20);not null;index" json:"account_type"` // ASSET, LIABILITY, EQUITY, REVENUE, EXPENSE
+	NormalBalance string    `gorm:"type:varchar(10);not null" json:"normal_balance"`     // DEBIT, CREDIT
+	ParentCode    *string   `gorm:"type:varchar(20);index" json:"parent_code"`           // Nil for level-1 accounts
+	IsSystem      bool      `gorm:"not null;default:false" json:"is_system"`             // Seeded from the VAS chart
+	IsActive      bool      `gorm:"not null;default:true" json:"is_active"`              // Inactive accounts take no new postings
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// JournalEntry is a balanced double-entry posting in the base currency. Entries are never
+// edited: an entry is cancelled by a reversal entry that swaps its debits and credits.
+type JournalEntry struct {
+	ID           uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntryNo      string          `gorm:"type:varchar(30);uniqueIndex;not null" json:"entry_no"`
+	EntryDate    time.Time       `gorm:"not null;index" json:"entry_date"`
+	SourceType   string          `gorm:"type:varchar(20);not null;index:idx_journal_entry_source" json:"source_type"` // INVOICE, PAYMENT, ORDER, LANDED_COST
+	SourceID     uuid.UUID       `gorm:"type:uuid;not null;index:idx_journal_entry_source" json:"source_id"`
+	SourceNo     string          `gorm:"type:varchar(100)" json:"source_no"` // Invoice, payment or order number
+	Description  string          `gorm:"type:text" json:"description"`
+	TotalAmount  decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"total_amount"` // Sum of debits = sum of credits
+	ReversalOfID *uuid.UUID      `gorm:"type:uuid;index" json:"reversal_of_id"`
+	ReversedByID *uuid.UUID      `gorm:"type:uuid" json:"reversed_by_id"`
+	CreatedBy    *uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	Lines        []JournalLine   `gorm:"foreignKey:EntryID;constraint:OnDelete:CASCADE" json:"lines,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// JournalLine debits or credits one account; exactly one of Debit and Credit is non-zero
+type JournalLine struct {
+	ID          uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntryID     uuid.UUID       `gorm:"type:uuid;not null;index" json:"entry_id"`
+	LineNo      int             `gorm:"not null" json:"line_no"`
+	AccountCode string          `gorm:"type:varchar(20);not null;index" json:"account_code"`
+	PartnerID   *uuid.UUID      `gorm:"type:uuid;index" json:"partner_id"` // Receivable/payable sub-ledger
+	Debit       decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"debit"`
+	Credit      decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"credit"`
+	Description string          `gorm:"type:text" json:"description"`
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -23,20 +24,22 @@ const (
 
 // Partner represents a customer, supplier, or both
 type Partner struct {
-	ID            uuid.UUID        `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Name          string           `gorm:"type:varchar(255);not null" json:"name"`
-	Type          string           `gorm:"type:varchar(20);not null;index" json:"type"` // CUSTOMER, SUPPLIER, BOTH
-	TaxCode       string           `gorm:"type:varchar(50)" json:"tax_code"`
-	CompanyName   string           `gorm:"type:varchar(255)" json:"company_name"`
-	BankAccount   string           `gorm:"type:varchar(100)" json:"bank_account"`
-	ContactPerson string           `gorm:"type:varchar(255)" json:"contact_person"`
-	Phone         string           `gorm:"type:varchar(50)" json:"phone"`
-	Email         string           `gorm:"type:varchar(255)" json:"email"`
-	IsActive      bool             `gorm:"default:true" json:"is_active"`
-	Addresses     []PartnerAddress `gorm:"foreignKey:PartnerID;constraint:OnDelete:CASCADE" json:"addresses"`
-	CreatedAt     time.Time        `json:"created_at"`
-	UpdatedAt     time.Time        `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt   `gorm:"index" json:"-"`
+	ID              uuid.UUID        `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name            string           `gorm:"type:varchar(255);not null" json:"name"`
+	Type            string           `gorm:"type:varchar(20);not null;index" json:"type"` // CUSTOMER, SUPPLIER, BOTH
+	TaxCode         string           `gorm:"type:varchar(50)" json:"tax_code"`
+	CompanyName     string           `gorm:"type:varchar(255)" json:"company_name"`
+	BankAccount     string           `gorm:"type:varchar(100)" json:"bank_account"`
+	ContactPerson   string           `gorm:"type:varchar(255)" json:"contact_person"`
+	Phone           string           `gorm:"type:varchar(50)" json:"phone"`
+	Email           string           `gorm:"type:varchar(255)" json:"email"`
+	PaymentTermDays int              `gorm:"not null;default:0" json:"payment_term_days"`               // Invoices fall due this many days after approval
+	CreditLimit     decimal.Decimal  `gorm:"type:decimal(18,4);not null;default:0" json:"credit_limit"` // Base currency; 0 = no limit
+	IsActive        bool             `gorm:"default:true" json:"is_active"`
+	Addresses       []PartnerAddress `gorm:"foreignKey:PartnerID;constraint:OnDelete:CASCADE" json:"addresses"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt   `gorm:"index" json:"-"`
 }
 
 // PartnerAddress represents a partner's address (Billing, Shipping, Origin)
@@ -45,7 +48,17 @@ type PartnerAddress struct {
 	PartnerID   uuid.UUID `gorm:"type:uuid;not null;index" json:"partner_id"`
 	AddressType string    `gorm:"type:varchar(20);not null" json:"address_type"` // BILLING, SHIPPING, ORIGIN
 	FullAddress string    `gorm:"type:text;not null" json:"full_address"`
-	IsDefault   bool      `gorm:"default:false" json:"is_default"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	// --- Structured fields (optional; Province is validated against pkg/vnadmin for VN) ---
+	Street       string    `gorm:"type:varchar(255)" json:"street"`
+	Ward         string    `gorm:"type:varchar(100)" json:"ward"`
+	District     string    `gorm:"type:varchar(100)" json:"district"` // Only for addresses predating the 2025 two-tier reform
+	Province     string    `gorm:"type:varchar(100);index" json:"province"`
+	ProvinceCode string    `gorm:"type:varchar(10)" json:"province_code"`
+	Country      string    `gorm:"type:varchar(2);default:'VN'" json:"country"` // ISO 3166-1 alpha-2
+	PostalCode   string    `gorm:"type:varchar(20)" json:"postal_code"`
+	IsDefault    bool      `gorm:"default:false" json:"is_default"`
+	Latitude     *float64  `gorm:"type:decimal(10,7)" json:"latitude"`
+	Longitude    *float64  `gorm:"type:decimal(10,7)" json:"longitude"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// PaymentType enum constants
+const (
+	PaymentTypeReceipt      = "RECEIPT"      // Money received from a customer (phiếu thu)
+	PaymentTypeDisbursement = "DISBURSEMENT" // Money paid to a supplier (phiếu chi)
+)
+
+// PaymentMethod enum constants
+const (
+	PaymentMethodCash = "CASH"
+	PaymentMethodBank = "BANK_TRANSFER"
+)
+
+// Invoice payment status enum constants
+const (
+	PaymentStatusUnpaid  = "UNPAID"
+	PaymentStatusPartial = "PARTIAL"
+	PaymentStatusPaid    = "PAID"
+)
+
+// Payment is money received from (or paid to) a partner. Its base amount is allocated to one or
+// more invoices of that partner; whatever is not allocated stays on account for later invoices.
+type Payment struct {
+	ID              uuid.UUID           `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PaymentNo       string              `gorm:"type:varchar(30);uniqueIndex;not null" json:"payment_no"`
+	PaymentType     string              `gorm:"type:varchar(20);not null;index" json:"payment_type"` // RECEIPT, DISBURSEMENT
+	PartnerID       uuid.UUID           `gorm:"type:uuid;not null;index" json:"partner_id"`
+	Partner         *Partner            `gorm:"foreignKey:PartnerID" json:"partner,omitempty"`
+	Method          string              `gorm:"type:varchar(20);not null" json:"method"` // CASH, BANK_TRANSFER
+	PaymentDate     time.Time           `gorm:"not null;index" json:"payment_date"`
+	Currency        string              `gorm:"type:varchar(10);not null" json:"currency"`
+	ExchangeRate    decimal.Decimal     `gorm:"type:decimal(24,12);not null;default:1" json:"exchange_rate"` // Base currency per unit of currency; 1 for the base currency
+	Amount          decimal.Decimal     `gorm:"type:decimal(18,4);not null" json:"amount"`                   // In the payment currency
+	AmountBase      decimal.Decimal     `gorm:"column:amount_base;type:decimal(18,4);not null" json:"amount_base"`
+	WithheldAmount  decimal.Decimal     `gorm:"type:decimal(18,4);not null;default:0" json:"withheld_amount"`  // Part of Amount withheld as FCT and paid to the tax authority instead
+	AllocatedAmount decimal.Decimal     `gorm:"type:decimal(18,4);not null;default:0" json:"allocated_amount"` // Base amount allocated to invoices
+	PaymentRunID    *uuid.UUID          `gorm:"type:uuid;index" json:"payment_run_id"`
+	Reference       string              `gorm:"type:varchar(100)" json:"reference"` // Bank transaction or cash book reference
+	Note            string              `gorm:"type:text" json:"note"`
+	CreatedBy       *uuid.UUID          `gorm:"type:uuid" json:"created_by"`
+	Allocations     []PaymentAllocation `gorm:"foreignKey:PaymentID;constraint:OnDelete:CASCADE" json:"allocations,omitempty"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+}
+
+// PaymentAllocation applies part of a payment to an invoice. Amount is the base amount cleared
+// from the invoice at the invoice rate and PaymentAmountBase the base amount drawn from the
+// payment at the payment rate; the difference is the realized exchange gain (positive) or loss.
+type PaymentAllocation struct {
+	ID                uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PaymentID         uuid.UUID       `gorm:"type:uuid;not null;index" json:"payment_id"`
+	InvoiceID         uuid.UUID       `gorm:"type:uuid;not null;index" json:"invoice_id"`
+	Invoice           *Invoice        `gorm:"foreignKey:InvoiceID" json:"invoice,omitempty"`
+	Amount            decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"amount"`
+	DocAmount         decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"doc_amount"` // In the invoice currency
+	PaymentAmountBase decimal.Decimal `gorm:"column:payment_amount_base;type:decimal(18,4);not null;default:0" json:"payment_amount_base"`
+	FXGainLoss        decimal.Decimal `gorm:"column:fx_gain_loss;type:decimal(18,4);not null;default:0" json:"fx_gain_loss"`
+	AllocatedAt       time.Time       `gorm:"index" json:"allocated_at"` // Payment date when allocated on entry, otherwise the day it was made
+	CreatedBy         *uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt         time.Time       `json:"created_at"`
+}
+
+// PaymentRun status enum constants
+const (
+	PaymentRunDraft     = "DRAFT"
+	PaymentRunConfirmed = "CONFIRMED"
+	PaymentRunCancelled = "CANCELLED"
+)
+
+// PaymentRun is a batch of supplier payments proposed from the payables due by a date. Confirming
+// it records one disbursement per supplier and currency and allocates it to the invoices.
+type PaymentRun struct {
+	ID           uuid.UUID        `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RunNo        string           `gorm:"type:varchar(30);uniqueIndex;not null" json:"run_no"`
+	Status       string           `gorm:"type:varchar(20);not null;default:'DRAFT';index" json:"status"` // DRAFT, CONFIRMED, CANCELLED
+	DueBefore    time.Time        `gorm:"not null" json:"due_before"`                                    // Invoices due on or before this date
+	PaymentDate  time.Time        `gorm:"not null" json:"payment_date"`
+	DebitAccount string           `gorm:"type:varchar(100)" json:"debit_account"` // Company bank account the batch is paid from
+	Note         string           `gorm:"type:text" json:"note"`
+	CreatedBy    *uuid.UUID       `gorm:"type:uuid" json:"created_by"`
+	ConfirmedBy  *uuid.UUID       `gorm:"type:uuid" json:"confirmed_by"`
+	ConfirmedAt  *time.Time       `json:"confirmed_at"`
+	Lines        []PaymentRunLine `gorm:"foreignKey:PaymentRunID;constraint:OnDelete:CASCADE" json:"lines,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+// PaymentRunLine is one invoice proposed for payment. Amount is in the invoice currency, which
+// the supplier is paid in, and includes WithheldAmount; AmountBase is what it clears of the invoice.
+type PaymentRunLine struct {
+	ID             uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PaymentRunID   uuid.UUID       `gorm:"type:uuid;not null;index" json:"payment_run_id"`
+	InvoiceID      uuid.UUID       `gorm:"type:uuid;not null;index" json:"invoice_id"`
+	Invoice        *Invoice        `gorm:"foreignKey:InvoiceID" json:"invoice,omitempty"`
+	PartnerID      uuid.UUID       `gorm:"type:uuid;not null" json:"partner_id"`
+	Partner        *Partner        `gorm:"foreignKey:PartnerID" json:"partner,omitempty"`
+	DueDate        time.Time       `gorm:"not null" json:"due_date"`
+	Currency       string          `gorm:"type:varchar(10);not null" json:"currency"`
+	ExchangeRate   decimal.Decimal `gorm:"type:decimal(24,12);not null;default:1" json:"exchange_rate"`
+	Amount         decimal.Decimal `gorm:"type:decimal(18,4);not null" json:"amount"`
+	WithheldAmount decimal.Decimal `gorm:"type:decimal(18,4);not null;default:0" json:"withheld_amount"`
+	AmountBase     decimal.Decimal `gorm:"column:amount_base;type:decimal(18,4);not null" json:"amount_base"`
+	PaymentID      *uuid.UUID      `gorm:"type:uuid" json:"payment_id"` // Set when the run is confirmed
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,14 +17,62 @@ const (
 	TaxTypeContractor = "CONTRACTOR_TAX"
 )
 
-// TaxRule stores tax rates with temporal validity
+// Tax category enum constants: how taxes of a type are declared and posted
+const (
+	TaxCategoryVAT        = "VAT"         // Output/input VAT, declared on the VAT return; several rates may run side by side
+	TaxCategoryImportDuty = "IMPORT_DUTY" // Duty on imported goods, posted to 3333
+	TaxCategoryContractor = "CONTRACTOR"  // Foreign contractor tax withheld from vendors, posted to 3338
+	TaxCategoryOther      = "OTHER"       // Other taxes, posted to 3338
+)
+
+// TaxTypeDefinition registers a tax type rules can be created for, with the documents it applies
+// to: the invoice reference types (ORDER_IMPORT, ORDER_EXPORT, EXPENSE) and CUSTOMS_DECLARATION.
+// The codes used by the calculations (VAT_INLAND, VAT_INTL, FCT, IMPORT_TAX, CONTRACTOR_TAX) are
+// seeded at startup.
+type TaxTypeDefinition struct {
+	Code          string    `gorm:"type:varchar(20);primaryKey" json:"code"` // e.g. VAT_INLAND
+	Label         string    `gorm:"type:varchar(255);not null" json:"label"`
+	Category      string    `gorm:"type:varchar(20);not null" json:"category"`           // VAT, IMPORT_DUTY, CONTRACTOR, OTHER
+	DocumentTypes string    `gorm:"type:text;not null;default:''" json:"document_types"` // Comma-separated, e.g. "ORDER_IMPORT,CUSTOMS_DECLARATION"
+	IsActive      bool      `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// AppliesTo reports whether the tax type may be used on a document type
+func (t TaxTypeDefinition) AppliesTo(documentType string) bool {
+	for _, d := range strings.Split(t.DocumentTypes, ",") {
+		if d == documentType {
+			return true
+		}
+	}
+	return false
+}
+
+// TaxRule stores tax rates with temporal validity. A rule applies to a line when all of its
+// conditions hold; empty conditions match anything. Among the rules that apply, the highest
+// priority wins, then the most conditions, then the longest HS code, then the latest start date,
+// then the narrowest window.
 type TaxRule struct {
 	ID            uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	TaxType       string          `gorm:"type:varchar(20);not null;index" json:"tax_type"` // VAT_INLAND, VAT_INTL, FCT
+	TaxType       string          `gorm:"type:varchar(20);not null;index" json:"tax_type"` // Code of a TaxTypeDefinition
 	Rate          decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"rate"`         // e.g. 0.10 = 10%
 	EffectiveFrom time.Time       `gorm:"type:date;not null;index" json:"effective_from"`  // Start date
 	EffectiveTo   *time.Time      `gorm:"type:date;index" json:"effective_to"`             // End date, nullable = currently active
-	Description   string          `gorm:"type:text" json:"description"`
-	CreatedAt     time.Time       `json:"created_at"`
-	UpdatedAt     time.Time       `json:"updated_at"`
+	Priority      int             `gorm:"not null;default:0" json:"priority"`              // e.g. a temporary reduction outranks the standard rate
+
+	// Conditions
+	ProductCategory string `gorm:"type:varchar(100);not null;default:''" json:"product_category"`      // Product.Category, case-insensitive
+	PartnerType     string `gorm:"type:varchar(20);not null;default:''" json:"partner_type"`           // CUSTOMER or SUPPLIER; BOTH partners match either
+	Country         string `gorm:"type:varchar(2);not null;default:''" json:"country"`                 // ISO 3166-1 alpha-2 of the partner, or of the goods origin for customs
+	HSCode          string `gorm:"column:hs_code;type:varchar(10);not null;default:''" json:"hs_code"` // Prefix of the HS code of customs lines, e.g. 8471 for the whole heading
+
+	Description string    `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// HasConditions reports whether the rule only applies to some lines
+func (r TaxRule) HasConditions() bool {
+	return r.ProductCategory != "" || r.PartnerType != "" || r.Country != "" || r.HSCode != ""
 }
@@ -7,14 +7,18 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ApprovalRepository interface {
 	Create(ctx context.Context, req *model.ApprovalRequest) error
 	FindByID(ctx context.Context, id uuid.UUID) (*model.ApprovalRequest, error)
+	// FindByIDForUpdate locks the request row until the surrounding transaction ends
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.ApprovalRequest, error)
 	FindByIDWithRelations(ctx context.Context, id uuid.UUID) (*model.ApprovalRequest, error)
 	List(ctx context.Context, status string, page, limit int) ([]model.ApprovalRequest, int64, error)
 	Update(ctx context.Context, req *model.ApprovalRequest) error
+	ListByReferenceID(ctx context.Context, referenceID uuid.UUID) ([]model.ApprovalRequest, error)
 }
 
 type approvalRepository struct {
@@ -37,6 +41,14 @@ func (r *approvalRepository) FindByID(ctx context.Context, id uuid.UUID) (*model
 	return &req, nil
 }
 
+func (r *approvalRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.ApprovalRequest, error) {
+	var req model.ApprovalRequest
+	if err := GetDB(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&req, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
 func (r *approvalRepository) FindByIDWithRelations(ctx context.Context, id uuid.UUID) (*model.ApprovalRequest, error) {
 	var req model.ApprovalRequest
 	if err := GetDB(ctx, r.db).Preload("Requester").Preload("Approver").First(&req, "id = ?", id).Error; err != nil {
@@ -74,3 +86,14 @@ func (r *approvalRepository) List(ctx context.Context, status string, page, limi
 func (r *approvalRepository) Update(ctx context.Context, req *model.ApprovalRequest) error {
 	return GetDB(ctx, r.db).Save(req).Error
 }
+
+func (r *approvalRepository) ListByReferenceID(ctx context.Context, referenceID uuid.UUID) ([]model.ApprovalRequest, error) {
+	var requests []model.ApprovalRequest
+	if err := GetDB(ctx, r.db).Preload("Requester").Preload("Approver").
+		Where("reference_id = ?", referenceID).
+		Order("created_at ASC").
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
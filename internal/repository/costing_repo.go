@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CostingRepository interface {
+	CreateLayer(ctx context.Context, layer *model.CostLayer) error
+	UpdateLayer(ctx context.Context, layer *model.CostLayer) error
+	UpdateLandedCost(ctx context.Context, layerID uuid.UUID, perUnit decimal.Decimal) error
+	FindLayersByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.CostLayer, error)
+	FindOpenLayersForUpdate(ctx context.Context, productID uuid.UUID) ([]model.CostLayer, error)
+	// FindLayersByOrderIDForUpdate locks every layer of an order, including the fully issued ones
+	FindLayersByOrderIDForUpdate(ctx context.Context, orderID uuid.UUID) ([]model.CostLayer, error)
+	ListValuation(ctx context.Context) ([]model.InventoryValuationRow, error)
+
+	CreateAllocation(ctx context.Context, allocation *model.LandedCostAllocation) error
+	FindAllocationByOrderID(ctx context.Context, orderID uuid.UUID) (*model.LandedCostAllocation, error)
+	DeleteAllocationByOrderID(ctx context.Context, orderID uuid.UUID) error
+}
+
+type costingRepository struct {
+	db *gorm.DB
+}
+
+func NewCostingRepository(db *gorm.DB) CostingRepository {
+	return &costingRepository{db: db}
+}
+
+func (r *costingRepository) CreateLayer(ctx context.Context, layer *model.CostLayer) error {
+	return GetDB(ctx, r.db).Create(layer).Error
+}
+
+func (r *costingRepository) UpdateLayer(ctx context.Context, layer *model.CostLayer) error {
+	return GetDB(ctx, r.db).Omit("Product").Save(layer).Error
+}
+
+func (r *costingRepository) UpdateLandedCost(ctx context.Context, layerID uuid.UUID, perUnit decimal.Decimal) error {
+	return GetDB(ctx, r.db).Model(&model.CostLayer{}).Where("id = ?", layerID).Update("landed_cost_per_unit", perUnit).Error
+}
+
+func (r *costingRepository) FindLayersByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.CostLayer, error) {
+	var layers []model.CostLayer
+	if err := GetDB(ctx, r.db).Where("order_id = ?", orderID).Find(&layers).Error; err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+func (r *costingRepository) FindLayersByOrderIDForUpdate(ctx context.Context, orderID uuid.UUID) ([]model.CostLayer, error) {
+	var layers []model.CostLayer
+	if err := GetDB(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ?", orderID).
+		Order("id ASC").
+		Find(&layers).Error; err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// FindOpenLayersForUpdate locks the layers of a product that still hold stock, oldest first (FIFO)
+func (r *costingRepository) FindOpenLayersForUpdate(ctx context.Context, productID uuid.UUID) ([]model.CostLayer, error) {
+	var layers []model.CostLayer
+	if err := GetDB(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND remaining_quantity > 0", productID).
+		Order("received_at ASC, created_at ASC").
+		Find(&layers).Error; err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+func (r *costingRepository) ListValuation(ctx context.Context) ([]model.InventoryValuationRow, error) {
+	var rows []model.InventoryValuationRow
+	if err := GetDB(ctx, r.db).Table("cost_layers").
+		Select("products.id AS product_id, products.sku AS product_sku, products.name AS product_name, " +
+			"SUM(cost_layers.remaining_quantity) AS quantity, " +
+			"SUM(cost_layers.remaining_quantity * cost_layers.unit_cost) AS purchase_cost, " +
+			"SUM(cost_layers.remaining_quantity * cost_layers.landed_cost_per_unit) AS landed_cost").
+		Joins("JOIN products ON products.id = cost_layers.product_id").
+		Where("cost_layers.remaining_quantity > 0 AND products.deleted_at IS NULL").
+		Group("products.id, products.sku, products.name").
+		Order("products.sku ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *costingRepository) CreateAllocation(ctx context.Context, allocation *model.LandedCostAllocation) error {
+	return GetDB(ctx, r.db).Create(allocation).Error
+}
+
+func (r *costingRepository) FindAllocationByOrderID(ctx context.Context, orderID uuid.UUID) (*model.LandedCostAllocation, error) {
+	var allocation model.LandedCostAllocation
+	if err := GetDB(ctx, r.db).Preload("Lines").First(&allocation, "order_id = ?", orderID).Error; err != nil {
+		return nil, err
+	}
+	return &allocation, nil
+}
+
+func (r *costingRepository) DeleteAllocationByOrderID(ctx context.Context, orderID uuid.UUID) error {
+	db := GetDB(ctx, r.db)
+	if err := db.Where("allocation_id IN (?)", db.Model(&model.LandedCostAllocation{}).Select("id").Where("order_id = ?", orderID)).
+		Delete(&model.LandedCostAllocationLine{}).Error; err != nil {
+		return err
+	}
+	return db.Where("order_id = ?", orderID).Delete(&model.LandedCostAllocation{}).Error
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CustomsRepository interface {
+	Create(ctx context.Context, declaration *model.CustomsDeclaration) error
+	Update(ctx context.Context, declaration *model.CustomsDeclaration) error
+	ReplaceLines(ctx context.Context, declarationID uuid.UUID, lines []model.CustomsDeclarationLine) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.CustomsDeclaration, error)
+	// FindByIDForUpdate locks the declaration row until the surrounding transaction ends
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.CustomsDeclaration, error)
+	ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.CustomsDeclaration, error)
+	List(ctx context.Context, status string, orderID *uuid.UUID, page, limit int) ([]model.CustomsDeclaration, int64, error)
+	ExistsDeclarationNo(ctx context.Context, declarationNo string, excludeID uuid.UUID) (bool, error)
+}
+
+type customsRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomsRepository(db *gorm.DB) CustomsRepository {
+	return &customsRepository{db: db}
+}
+
+func (r *customsRepository) Create(ctx context.Context, declaration *model.CustomsDeclaration) error {
+	return GetDB(ctx, r.db).Create(declaration).Error
+}
+
+func (r *customsRepository) Update(ctx context.Context, declaration *model.CustomsDeclaration) error {
+	return GetDB(ctx, r.db).Omit("Lines", "Order").Save(declaration).Error
+}
+
+func (r *customsRepository) ReplaceLines(ctx context.Context, declarationID uuid.UUID, lines []model.CustomsDeclarationLine) error {
+	db := GetDB(ctx, r.db)
+	if err := db.Where("declaration_id = ?", declarationID).Delete(&model.CustomsDeclarationLine{}).Error; err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	for i := range lines {
+		lines[i].DeclarationID = declarationID
+	}
+	return db.Omit("Product").Create(&lines).Error
+}
+
+func (r *customsRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.CustomsDeclaration, error) {
+	var declaration model.CustomsDeclaration
+	if err := GetDB(ctx, r.db).
+		Preload("Order").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("hs_code ASC")
+		}).
+		Preload("Lines.Product").
+		First(&declaration, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &declaration, nil
+}
+
+func (r *customsRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.CustomsDeclaration, error) {
+	var declaration model.CustomsDeclaration
+	if err := GetDB(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Order").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("hs_code ASC")
+		}).
+		Preload("Lines.Product").
+		First(&declaration, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &declaration, nil
+}
+
+func (r *customsRepository) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.CustomsDeclaration, error) {
+	var declarations []model.CustomsDeclaration
+	if err := GetDB(ctx, r.db).Preload("Lines").Where("order_id = ?", orderID).Order("created_at ASC").Find(&declarations).Error; err != nil {
+		return nil, err
+	}
+	return declarations, nil
+}
+
+func (r *customsRepository) List(ctx context.Context, status string, orderID *uuid.UUID, page, limit int) ([]model.CustomsDeclaration, int64, error) {
+	var declarations []model.CustomsDeclaration
+	var total int64
+
+	query := GetDB(ctx, r.db).Model(&model.CustomsDeclaration{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if orderID != nil {
+		query = query.Where("order_id = ?", *orderID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Preload("Order").Order("created_at desc").Offset(offset).Limit(limit).Find(&declarations).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return declarations, total, nil
+}
+
+func (r *customsRepository) ExistsDeclarationNo(ctx context.Context, declarationNo string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	if err := GetDB(ctx, r.db).Model(&model.CustomsDeclaration{}).
+		Where("declaration_no = ? AND id <> ?", declarationNo, excludeID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
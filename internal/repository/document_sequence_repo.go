@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DocumentSequenceRepository interface {
+	// FindForUpdate locks the sequence row until the surrounding transaction ends
+	FindForUpdate(ctx context.Context, docType string, year int) (*model.DocumentSequence, error)
+	FindLatest(ctx context.Context, docType string) (*model.DocumentSequence, error)
+	// CreateIfMissing inserts the sequence unless another transaction already created it
+	CreateIfMissing(ctx context.Context, seq *model.DocumentSequence) error
+	ListByYear(ctx context.Context, year int) ([]model.DocumentSequence, error)
+	Save(ctx context.Context, seq *model.DocumentSequence) error
+}
+
+type documentSequenceRepository struct {
+	db *gorm.DB
+}
+
+func NewDocumentSequenceRepository(db *gorm.DB) DocumentSequenceRepository {
+	return &documentSequenceRepository{db: db}
+}
+
+func (r *documentSequenceRepository) FindForUpdate(ctx context.Context, docType string, year int) (*model.DocumentSequence, error) {
+	var seq model.DocumentSequence
+	if err := GetDB(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&seq, "doc_type = ? AND year = ?", docType, year).Error; err != nil {
+		return nil, err
+	}
+	return &seq, nil
+}
+
+// FindLatest returns the sequence of the most recent year, whose pattern a new year inherits
+func (r *documentSequenceRepository) FindLatest(ctx context.Context, docType string) (*model.DocumentSequence, error) {
+	var seq model.DocumentSequence
+	if err := GetDB(ctx, r.db).Where("doc_type = ?", docType).Order("year DESC").First(&seq).Error; err != nil {
+		return nil, err
+	}
+	return &seq, nil
+}
+
+func (r *documentSequenceRepository) CreateIfMissing(ctx context.Context, seq *model.DocumentSequence) error {
+	return GetDB(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "doc_type"}, {Name: "year"}},
+		DoNothing: true,
+	}).Create(seq).Error
+}
+
+func (r *documentSequenceRepository) ListByYear(ctx context.Context, year int) ([]model.DocumentSequence, error) {
+	var seqs []model.DocumentSequence
+	if err := GetDB(ctx, r.db).Where("year = ?", year).Order("doc_type ASC").Find(&seqs).Error; err != nil {
+		return nil, err
+	}
+	return seqs, nil
+}
+
+func (r *documentSequenceRepository) Save(ctx context.Context, seq *model.DocumentSequence) error {
+	return GetDB(ctx, r.db).Save(seq).Error
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type DocumentTemplateRepository interface {
+	FindByDocType(ctx context.Context, docType string) (*model.DocumentTemplate, error)
+	List(ctx context.Context) ([]model.DocumentTemplate, error)
+	Save(ctx context.Context, template *model.DocumentTemplate) error
+}
+
+type documentTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewDocumentTemplateRepository(db *gorm.DB) DocumentTemplateRepository {
+	return &documentTemplateRepository{db: db}
+}
+
+func (r *documentTemplateRepository) FindByDocType(ctx context.Context, docType string) (*model.DocumentTemplate, error) {
+	var template model.DocumentTemplate
+	if err := GetDB(ctx, r.db).First(&template, "doc_type = ?", docType).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *documentTemplateRepository) List(ctx context.Context) ([]model.DocumentTemplate, error) {
+	var templates []model.DocumentTemplate
+	if err := GetDB(ctx, r.db).Order("doc_type ASC").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (r *documentTemplateRepository) Save(ctx context.Context, template *model.DocumentTemplate) error {
+	return GetDB(ctx, r.db).Save(template).Error
+}
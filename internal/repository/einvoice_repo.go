@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EInvoiceRepository interface {
+	Create(ctx context.Context, einvoice *model.EInvoice) error
+	Update(ctx context.Context, einvoice *model.EInvoice) error
+	FindByInvoiceID(ctx context.Context, invoiceID uuid.UUID) (*model.EInvoice, error)
+}
+
+type einvoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewEInvoiceRepository(db *gorm.DB) EInvoiceRepository {
+	return &einvoiceRepository{db: db}
+}
+
+func (r *einvoiceRepository) Create(ctx context.Context, einvoice *model.EInvoice) error {
+	return GetDB(ctx, r.db).Create(einvoice).Error
+}
+
+func (r *einvoiceRepository) Update(ctx context.Context, einvoice *model.EInvoice) error {
+	return GetDB(ctx, r.db).Omit("Invoice").Save(einvoice).Error
+}
+
+func (r *einvoiceRepository) FindByInvoiceID(ctx context.Context, invoiceID uuid.UUID) (*model.EInvoice, error) {
+	var einvoice model.EInvoice
+	if err := GetDB(ctx, r.db).First(&einvoice, "invoice_id = ?", invoiceID).Error; err != nil {
+		return nil, err
+	}
+	return &einvoice, nil
+}
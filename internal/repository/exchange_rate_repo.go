@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExchangeRateFilter holds filters for listing exchange rates
+type ExchangeRateFilter struct {
+	Currency string
+	DateFrom *time.Time
+	DateTo   *time.Time
+	Page     int
+	Limit    int
+}
+
+type ExchangeRateRepository interface {
+	CreateCurrency(ctx context.Context, currency *model.Currency) error
+	// CreateCurrencyIfMissing inserts the currency unless its code already exists
+	CreateCurrencyIfMissing(ctx context.Context, currency *model.Currency) error
+	UpdateCurrency(ctx context.Context, currency *model.Currency) error
+	FindCurrency(ctx context.Context, code string) (*model.Currency, error)
+	ListCurrencies(ctx context.Context) ([]model.Currency, error)
+
+	// UpsertRate stores the rate of a currency for a day, replacing the one already stored
+	UpsertRate(ctx context.Context, rate *model.ExchangeRate) error
+	// FindRateOn returns the latest rate of the currency dated on or before the given day
+	FindRateOn(ctx context.Context, currency string, date time.Time) (*model.ExchangeRate, error)
+	ListRates(ctx context.Context, filter ExchangeRateFilter) ([]model.ExchangeRate, int64, error)
+}
+
+type exchangeRateRepository struct {
+	db *gorm.DB
+}
+
+func NewExchangeRateRepository(db *gorm.DB) ExchangeRateRepository {
+	return &exchangeRateRepository{db: db}
+}
+
+func (r *exchangeRateRepository) CreateCurrency(ctx context.Context, currency *model.Currency) error {
+	return GetDB(ctx, r.db).Create(currency).Error
+}
+
+func (r *exchangeRateRepository) CreateCurrencyIfMissing(ctx context.Context, currency *model.Currency) error {
+	return GetDB(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoNothing: true,
+	}).Create(currency).Error
+}
+
+func (r *exchangeRateRepository) UpdateCurrency(ctx context.Context, currency *model.Currency) error {
+	return GetDB(ctx, r.db).Save(currency).Error
+}
+
+func (r *exchangeRateRepository) FindCurrency(ctx context.Context, code string) (*model.Currency, error) {
+	var currency model.Currency
+	if err := GetDB(ctx, r.db).First(&currency, "code = ?", code).Error; err != nil {
+		return nil, err
+	}
+	return &currency, nil
+}
+
+func (r *exchangeRateRepository) ListCurrencies(ctx context.Context) ([]model.Currency, error) {
+	var currencies []model.Currency
+	if err := GetDB(ctx, r.db).Order("code ASC").Find(&currencies).Error; err != nil {
+		return nil, err
+	}
+	return currencies, nil
+}
+
+func (r *exchangeRateRepository) UpsertRate(ctx context.Context, rate *model.ExchangeRate) error {
+	return GetDB(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}, {Name: "rate_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "created_by", "updated_at"}),
+	}).Create(rate).Error
+}
+
+func (r *exchangeRateRepository) FindRateOn(ctx context.Context, currency string, date time.Time) (*model.ExchangeRate, error) {
+	var rate model.ExchangeRate
+	if err := GetDB(ctx, r.db).
+		Where("currency = ? AND rate_date <= ?", currency, date).
+		Order("rate_date DESC").
+		First(&rate).Error; err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *exchangeRateRepository) ListRates(ctx context.Context, filter ExchangeRateFilter) ([]model.ExchangeRate, int64, error) {
+	var rates []model.ExchangeRate
+	var total int64
+
+	query := GetDB(ctx, r.db).Model(&model.ExchangeRate{})
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("rate_date >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("rate_date <= ?", *filter.DateTo)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("rate_date DESC, currency ASC").Offset(offset).Limit(filter.Limit).Find(&rates).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return rates, total, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"backend/internal/model"
 
@@ -13,6 +14,13 @@ type ExpenseRepository interface {
 	Create(ctx context.Context, expense *model.Expense) error
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Expense, error)
 	List(ctx context.Context, page, limit int) ([]model.Expense, int64, error)
+	ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.Expense, error)
+	// FindOrderType returns the type (IMPORT, EXPORT) of the order an expense is booked on, or ""
+	// when it is not booked on an order
+	FindOrderType(ctx context.Context, id uuid.UUID) (string, error)
+	// ListForeignCurrency returns the expenses not recorded in the base currency, optionally of one
+	// currency and created within [from, to)
+	ListForeignCurrency(ctx context.Context, baseCurrency, currency string, from, to *time.Time) ([]model.Expense, error)
 }
 
 type expenseRepository struct {
@@ -51,3 +59,40 @@ func (r *expenseRepository) List(ctx context.Context, page, limit int) ([]model.
 
 	return expenses, total, nil
 }
+
+func (r *expenseRepository) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.Expense, error) {
+	var expenses []model.Expense
+	if err := GetDB(ctx, r.db).Where("order_id = ?", orderID).Order("created_at ASC").Find(&expenses).Error; err != nil {
+		return nil, err
+	}
+	return expenses, nil
+}
+
+func (r *expenseRepository) FindOrderType(ctx context.Context, id uuid.UUID) (string, error) {
+	var orderType string
+	err := GetDB(ctx, r.db).Model(&model.Expense{}).
+		Select("COALESCE(o.type, '')").
+		Joins("LEFT JOIN orders o ON o.id = expenses.order_id").
+		Where("expenses.id = ?", id).
+		Row().Scan(&orderType)
+	return orderType, err
+}
+
+func (r *expenseRepository) ListForeignCurrency(ctx context.Context, baseCurrency, currency string, from, to *time.Time) ([]model.Expense, error) {
+	query := GetDB(ctx, r.db).Where("currency <> ?", baseCurrency)
+	if currency != "" {
+		query = query.Where("currency = ?", currency)
+	}
+	if from != nil {
+		query = query.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("created_at < ?", *to)
+	}
+
+	var expenses []model.Expense
+	if err := query.Order("currency ASC, created_at ASC").Find(&expenses).Error; err != nil {
+		return nil, err
+	}
+	return expenses, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FiscalPeriodRepository interface {
+	// FindForShare reads the period and blocks it from being closed until the surrounding
+	// transaction ends, so a document cannot slip into a period being closed
+	FindForShare(ctx context.Context, year, month int) (*model.FiscalPeriod, error)
+	FindForUpdate(ctx context.Context, year, month int) (*model.FiscalPeriod, error)
+	// CreateIfMissing inserts the period unless another transaction already created it
+	CreateIfMissing(ctx context.Context, period *model.FiscalPeriod) error
+	ListByYear(ctx context.Context, year int) ([]model.FiscalPeriod, error)
+	Save(ctx context.Context, period *model.FiscalPeriod) error
+}
+
+type fiscalPeriodRepository struct {
+	db *gorm.DB
+}
+
+func NewFiscalPeriodRepository(db *gorm.DB) FiscalPeriodRepository {
+	return &fiscalPeriodRepository{db: db}
+}
+
+func (r *fiscalPeriodRepository) FindForShare(ctx context.Context, year, month int) (*model.FiscalPeriod, error) {
+	var period model.FiscalPeriod
+	if err := GetDB(ctx, r.db).Clauses(clause.Locking{Strength: "SHARE"}).
+		First(&period, "year = ? AND month = ?", year, month).Error; err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+func (r *fiscalPeriodRepository) FindForUpdate(ctx context.Context, year, month int) (*model.FiscalPeriod, error) {
+	var period model.FiscalPeriod
+	if err := GetDB(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&period, "year = ? AND month = ?", year, month).Error; err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+func (r *fiscalPeriodRepository) CreateIfMissing(ctx context.Context, period *model.FiscalPeriod) error {
+	return GetDB(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "year"}, {Name: "month"}},
+		DoNothing: true,
+	}).Create(period).Error
+}
+
+func (r *fiscalPeriodRepository) ListByYear(ctx context.Context, year int) ([]model.FiscalPeriod, error) {
+	var periods []model.FiscalPeriod
+	if err := GetDB(ctx, r.db).Where("year = ?", year).Order("month ASC").Find(&periods).Error; err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+func (r *fiscalPeriodRepository) Save(ctx context.Context, period *model.FiscalPeriod) error {
+	return GetDB(ctx, r.db).Save(period).Error
+}
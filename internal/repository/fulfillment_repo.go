@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FulfillmentRepository interface {
+	CreatePickList(ctx context.Context, pickList *model.PickList) error
+	FindPickListByOrderID(ctx context.Context, orderID uuid.UUID) (*model.PickList, error)
+	UpdatePickList(ctx context.Context, pickList *model.PickList) error
+	UpdatePickListItem(ctx context.Context, item *model.PickListItem) error
+
+	CreatePackage(ctx context.Context, pkg *model.Package) error
+	FindPackageByID(ctx context.Context, id uuid.UUID) (*model.Package, error)
+	ListPackagesByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.Package, error)
+	DeletePackage(ctx context.Context, id uuid.UUID) error
+}
+
+type fulfillmentRepository struct {
+	db *gorm.DB
+}
+
+func NewFulfillmentRepository(db *gorm.DB) FulfillmentRepository {
+	return &fulfillmentRepository{db: db}
+}
+
+func (r *fulfillmentRepository) CreatePickList(ctx context.Context, pickList *model.PickList) error {
+	return GetDB(ctx, r.db).Create(pickList).Error
+}
+
+func (r *fulfillmentRepository) FindPickListByOrderID(ctx context.Context, orderID uuid.UUID) (*model.PickList, error) {
+	var pickList model.PickList
+	if err := GetDB(ctx, r.db).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("bin_location ASC, product_sku ASC")
+		}).
+		First(&pickList, "order_id = ?", orderID).Error; err != nil {
+		return nil, err
+	}
+	return &pickList, nil
+}
+
+func (r *fulfillmentRepository) UpdatePickList(ctx context.Context, pickList *model.PickList) error {
+	return GetDB(ctx, r.db).Omit("Items", "Order").Save(pickList).Error
+}
+
+func (r *fulfillmentRepository) UpdatePickListItem(ctx context.Context, item *model.PickListItem) error {
+	return GetDB(ctx, r.db).Save(item).Error
+}
+
+func (r *fulfillmentRepository) CreatePackage(ctx context.Context, pkg *model.Package) error {
+	return GetDB(ctx, r.db).Create(pkg).Error
+}
+
+func (r *fulfillmentRepository) FindPackageByID(ctx context.Context, id uuid.UUID) (*model.Package, error) {
+	var pkg model.Package
+	if err := GetDB(ctx, r.db).Preload("Items").Preload("Items.Product").First(&pkg, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+func (r *fulfillmentRepository) ListPackagesByOrderID(ctx context.Context, orderID uuid.UUID) ([]model.Package, error) {
+	var packages []model.Package
+	if err := GetDB(ctx, r.db).
+		Preload("Items").
+		Preload("Items.Product").
+		Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Find(&packages).Error; err != nil {
+		return nil, err
+	}
+	return packages, nil
+}
+
+func (r *fulfillmentRepository) DeletePackage(ctx context.Context, id uuid.UUID) error {
+	db := GetDB(ctx, r.db)
+	if err := db.Where("package_id = ?", id).Delete(&model.PackageItem{}).Error; err != nil {
+		return err
+	}
+	return db.Where("id = ?", id).Delete(&model.Package{}).Error
+}
@@ -7,16 +7,21 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type InvoiceRepository interface {
 	Create(ctx context.Context, invoice *model.Invoice) error
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Invoice, error)
+	// FindByIDForUpdate locks the invoice row until the surrounding transaction ends
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.Invoice, error)
 	FindByIDWithTaxRule(ctx context.Context, id uuid.UUID) (*model.Invoice, error)
+	FindByIDWithDetails(ctx context.Context, id uuid.UUID) (*model.Invoice, error)
 	List(ctx context.Context, filter InvoiceListFilter) ([]model.Invoice, int64, error)
 	UpdateApproval(ctx context.Context, invoice *model.Invoice) error
 	Update(ctx context.Context, invoice *model.Invoice) error
-	CountByPrefix(ctx context.Context, prefix string) (int64, error)
+	FindByReferences(ctx context.Context, refType string, refIDs []uuid.UUID) ([]model.Invoice, error)
+	ListAdjustments(ctx context.Context, originalID uuid.UUID) ([]model.Invoice, error)
 }
 
 type invoiceRepository struct {
@@ -39,9 +44,35 @@ func (r *invoiceRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.
 	return &invoice, nil
 }
 
+func (r *invoiceRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.Invoice, error) {
+	var invoice model.Invoice
+	if err := GetDB(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
 func (r *invoiceRepository) FindByIDWithTaxRule(ctx context.Context, id uuid.UUID) (*model.Invoice, error) {
 	var invoice model.Invoice
-	if err := GetDB(ctx, r.db).Preload("TaxRule").Preload("Partner").First(&invoice, "id = ?", id).Error; err != nil {
+	if err := GetDB(ctx, r.db).Preload("TaxRule").Preload("Partner").Preload("Lines").First(&invoice, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// FindByIDWithDetails loads everything needed to reprint an invoice
+func (r *invoiceRepository) FindByIDWithDetails(ctx context.Context, id uuid.UUID) (*model.Invoice, error) {
+	var invoice model.Invoice
+	if err := GetDB(ctx, r.db).
+		Preload("TaxRule").
+		Preload("Partner").
+		Preload("Approver").
+		Preload("OriginalInvoice").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_no ASC")
+		}).
+		Preload("Lines.Product").
+		First(&invoice, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &invoice, nil
@@ -52,6 +83,8 @@ type InvoiceListFilter struct {
 	ApprovalStatus string
 	InvoiceNo      string
 	ReferenceType  string
+	InvoiceType    string
+	PaymentStatus  string
 	Page           int
 	Limit          int
 }
@@ -71,13 +104,19 @@ func (r *invoiceRepository) List(ctx context.Context, filter InvoiceListFilter)
 	if filter.ReferenceType != "" {
 		query = query.Where("reference_type = ?", filter.ReferenceType)
 	}
+	if filter.InvoiceType != "" {
+		query = query.Where("invoice_type = ?", filter.InvoiceType)
+	}
+	if filter.PaymentStatus != "" {
+		query = query.Where("payment_status = ?", filter.PaymentStatus)
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	offset := (filter.Page - 1) * filter.Limit
-	fetchQuery := db.Preload("TaxRule").Preload("Partner")
+	fetchQuery := db.Preload("TaxRule").Preload("Partner").Preload("Lines")
 	if filter.ApprovalStatus != "" {
 		fetchQuery = fetchQuery.Where("approval_status = ?", filter.ApprovalStatus)
 	}
@@ -87,6 +126,12 @@ func (r *invoiceRepository) List(ctx context.Context, filter InvoiceListFilter)
 	if filter.ReferenceType != "" {
 		fetchQuery = fetchQuery.Where("reference_type = ?", filter.ReferenceType)
 	}
+	if filter.InvoiceType != "" {
+		fetchQuery = fetchQuery.Where("invoice_type = ?", filter.InvoiceType)
+	}
+	if filter.PaymentStatus != "" {
+		fetchQuery = fetchQuery.Where("payment_status = ?", filter.PaymentStatus)
+	}
 	if err := fetchQuery.Order("created_at desc").Offset(offset).Limit(filter.Limit).Find(&invoices).Error; err != nil {
 		return nil, 0, err
 	}
@@ -95,17 +140,37 @@ func (r *invoiceRepository) List(ctx context.Context, filter InvoiceListFilter)
 }
 
 func (r *invoiceRepository) UpdateApproval(ctx context.Context, invoice *model.Invoice) error {
-	return GetDB(ctx, r.db).Save(invoice).Error
+	return GetDB(ctx, r.db).Omit("Lines").Save(invoice).Error
 }
 
 func (r *invoiceRepository) Update(ctx context.Context, invoice *model.Invoice) error {
-	return GetDB(ctx, r.db).Save(invoice).Error
+	return GetDB(ctx, r.db).Omit("Lines").Save(invoice).Error
+}
+
+// FindByReferences returns the invoices generated for the given orders or expenses
+func (r *invoiceRepository) FindByReferences(ctx context.Context, refType string, refIDs []uuid.UUID) ([]model.Invoice, error) {
+	var invoices []model.Invoice
+	if len(refIDs) == 0 {
+		return invoices, nil
+	}
+	if err := GetDB(ctx, r.db).
+		Where("reference_type = ? AND reference_id IN ?", refType, refIDs).
+		Order("created_at ASC").
+		Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	return invoices, nil
 }
 
-func (r *invoiceRepository) CountByPrefix(ctx context.Context, prefix string) (int64, error) {
-	var count int64
-	if err := GetDB(ctx, r.db).Model(&model.Invoice{}).Where("invoice_no LIKE ?", prefix+"%").Count(&count).Error; err != nil {
-		return 0, err
+// ListAdjustments returns the credit notes, debit notes and replacements of an invoice
+func (r *invoiceRepository) ListAdjustments(ctx context.Context, originalID uuid.UUID) ([]model.Invoice, error) {
+	var invoices []model.Invoice
+	if err := GetDB(ctx, r.db).
+		Preload("Lines").
+		Where("original_invoice_id = ?", originalID).
+		Order("created_at ASC").
+		Find(&invoices).Error; err != nil {
+		return nil, err
 	}
-	return count, nil
+	return invoices, nil
 }
@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AccountTotalRow holds the postings of one account before a period and within it
+type AccountTotalRow struct {
+	AccountCode   string          `gorm:"column:account_code"`
+	OpeningDebit  decimal.Decimal `gorm:"column:opening_debit"`
+	OpeningCredit decimal.Decimal `gorm:"column:opening_credit"`
+	PeriodDebit   decimal.Decimal `gorm:"column:period_debit"`
+	PeriodCredit  decimal.Decimal `gorm:"column:period_credit"`
+}
+
+// PartnerTotalRow holds the postings of one partner on an account before a period and within it
+type PartnerTotalRow struct {
+	PartnerID     *uuid.UUID      `gorm:"column:partner_id"`
+	PartnerName   string          `gorm:"column:partner_name"`
+	OpeningDebit  decimal.Decimal `gorm:"column:opening_debit"`
+	OpeningCredit decimal.Decimal `gorm:"column:opening_credit"`
+	PeriodDebit   decimal.Decimal `gorm:"column:period_debit"`
+	PeriodCredit  decimal.Decimal `gorm:"column:period_credit"`
+}
+
+// LedgerLineRow is a journal line with its entry and the other accounts of the entry
+type LedgerLineRow struct {
+	EntryID        uuid.UUID       `gorm:"column:entry_id"`
+	EntryNo        string          `gorm:"column:entry_no"`
+	EntryDate      time.Time       `gorm:"column:entry_date"`
+	SourceType     string          `gorm:"column:source_type"`
+	SourceNo       string          `gorm:"column:source_no"`
+	Description    string          `gorm:"column:description"`
+	AccountCode    string          `gorm:"column:account_code"`
+	PartnerID      *uuid.UUID      `gorm:"column:partner_id"`
+	PartnerName    string          `gorm:"column:partner_name"`
+	ContraAccounts string          `gorm:"column:contra_accounts"` // Comma-separated
+	Debit          decimal.Decimal `gorm:"column:debit"`
+	Credit         decimal.Decimal `gorm:"column:credit"`
+}
+
+// LedgerPeriod bounds ledger queries: postings from From (inclusive) up to To (exclusive)
+type LedgerPeriod struct {
+	From time.Time
+	To   time.Time
+}
+
+// JournalEntryFilter holds filters for listing journal entries
+type JournalEntryFilter struct {
+	SourceType  string
+	SourceID    *uuid.UUID
+	AccountCode string // Entries with a line on this account or its sub-accounts
+	DateFrom    *time.Time
+	DateTo      *time.Time // Exclusive
+	Page        int
+	Limit       int
+}
+
+type LedgerRepository interface {
+	CreateAccount(ctx context.Context, account *model.Account) error
+	// CreateAccountIfMissing inserts the account unless its code already exists
+	CreateAccountIfMissing(ctx context.Context, account *model.Account) error
+	UpdateAccount(ctx context.Context, account *model.Account) error
+	FindAccountByCode(ctx context.Context, code string) (*model.Account, error)
+	FindAccountsByCodes(ctx context.Context, codes []string) ([]model.Account, error)
+	ListAccounts(ctx context.Context) ([]model.Account, error)
+	CountChildAccounts(ctx context.Context, code string) (int64, error)
+	CountAccountLines(ctx context.Context, code string) (int64, error)
+
+	CreateEntry(ctx context.Context, entry *model.JournalEntry) error
+	MarkEntryReversed(ctx context.Context, id uuid.UUID, reversedByID uuid.UUID) error
+	FindEntryByID(ctx context.Context, id uuid.UUID) (*model.JournalEntry, error)
+	ListEntries(ctx context.Context, filter JournalEntryFilter) ([]model.JournalEntry, int64, error)
+	// ListOpenEntriesBySource returns the entries of the sources that are neither reversals nor
+	// reversed yet
+	ListOpenEntriesBySource(ctx context.Context, sourceType string, sourceIDs []uuid.UUID) ([]model.JournalEntry, error)
+
+	ListAccountTotals(ctx context.Context, period LedgerPeriod) ([]AccountTotalRow, error)
+	ListPartnerTotals(ctx context.Context, codePrefix string, period LedgerPeriod) ([]PartnerTotalRow, error)
+	ListLedgerLines(ctx context.Context, codePrefix string, partnerID *uuid.UUID, period LedgerPeriod) ([]LedgerLineRow, error)
+}
+
+type ledgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+func (r *ledgerRepository) CreateAccount(ctx context.Context, account *model.Account) error {
+	return GetDB(ctx, r.db).Create(account).Error
+}
+
+func (r *ledgerRepository) CreateAccountIfMissing(ctx context.Context, account *model.Account) error {
+	return GetDB(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoNothing: true,
+	}).Create(account).Error
+}
+
+func (r *ledgerRepository) UpdateAccount(ctx context.Context, account *model.Account) error {
+	return GetDB(ctx, r.db).Save(account).Error
+}
+
+func (r *ledgerRepository) FindAccountByCode(ctx context.Context, code string) (*model.Account, error) {
+	var account model.Account
+	if err := GetDB(ctx, r.db).First(&account, "code = ?", code).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *ledgerRepository) FindAccountsByCodes(ctx context.Context, codes []string) ([]model.Account, error) {
+	var accounts []model.Account
+	if len(codes) == 0 {
+		return accounts, nil
+	}
+	if err := GetDB(ctx, r.db).Where("code IN ?", codes).Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (r *ledgerRepository) ListAccounts(ctx context.Context) ([]model.Account, error) {
+	var accounts []model.Account
+	if err := GetDB(ctx, r.db).Order("code ASC").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (r *ledgerRepository) CountChildAccounts(ctx context.Context, code string) (int64, error) {
+	var count int64
+	err := GetDB(ctx, r.db).Model(&model.Account{}).Where("parent_code = ?", code).Count(&count).Error
+	return count, err
+}
+
+func (r *ledgerRepository) CountAccountLines(ctx context.Context, code string) (int64, error) {
+	var count int64
+	err := GetDB(ctx, r.db).Model(&model.JournalLine{}).Where("account_code = ?", code).Count(&count).Error
+	return count, err
+}
+
+func (r *ledgerRepository) CreateEntry(ctx context.Context, entry *model.JournalEntry) error {
+	return GetDB(ctx, r.db).Create(entry).Error
+}
+
+func (r *ledgerRepository) MarkEntryReversed(ctx context.Context, id uuid.UUID, reversedByID uuid.UUID) error {
+	return GetDB(ctx, r.db).Model(&model.JournalEntry{}).Where("id = ?", id).Update("reversed_by_id", reversedByID).Error
+}
+
+func (r *ledgerRepository) FindEntryByID(ctx context.Context, id uuid.UUID) (*model.JournalEntry, error) {
+	var entry model.JournalEntry
+	if err := GetDB(ctx, r.db).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_no ASC")
+		}).
+		First(&entry, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *ledgerRepository) ListEntries(ctx context.Context, filter JournalEntryFilter) ([]model.JournalEntry, int64, error) {
+	var entries []model.JournalEntry
+	var total int64
+
+	query := GetDB(ctx, r.db).Model(&model.JournalEntry{})
+	if filter.SourceType != "" {
+		query = query.Where("source_type = ?", filter.SourceType)
+	}
+	if filter.SourceID != nil {
+		query = query.Where("source_id = ?", *filter.SourceID)
+	}
+	if filter.AccountCode != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM journal_lines jl WHERE jl.entry_id = journal_entries.id AND jl.account_code LIKE ?)", filter.AccountCode+"%")
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("entry_date >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("entry_date < ?", *filter.DateTo)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_no ASC")
+		}).
+		Order("entry_date DESC, entry_no DESC").
+		Offset(offset).
+		Limit(filter.Limit).
+		Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+func (r *ledgerRepository) ListOpenEntriesBySource(ctx context.Context, sourceType string, sourceIDs []uuid.UUID) ([]model.JournalEntry, error) {
+	var entries []model.JournalEntry
+	if len(sourceIDs) == 0 {
+		return entries, nil
+	}
+	if err := GetDB(ctx, r.db).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_no ASC")
+		}).
+		Where("source_type = ? AND source_id IN ?", sourceType, sourceIDs).
+		Where("reversal_of_id IS NULL AND reversed_by_id IS NULL").
+		Order("entry_date ASC, entry_no ASC").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListAccountTotals sums the postings of every account with lines, before the period (opening)
+// and within it
+func (r *ledgerRepository) ListAccountTotals(ctx context.Context, period LedgerPeriod) ([]AccountTotalRow, error) {
+	query := `
+		SELECT
+			jl.account_code,
+			COALESCE(SUM(CASE WHEN je.entry_date < ? THEN jl.debit END), 0) AS opening_debit,
+			COALESCE(SUM(CASE WHEN je.entry_date < ? THEN jl.credit END), 0) AS opening_credit,
+			COALESCE(SUM(CASE WHEN je.entry_date >= ? THEN jl.debit END), 0) AS period_debit,
+			COALESCE(SUM(CASE WHEN je.entry_date >= ? THEN jl.credit END), 0) AS period_credit
+		FROM journal_lines jl
+		JOIN journal_entries je ON je.id = jl.entry_id
+		WHERE je.entry_date < ?
+		GROUP BY jl.account_code
+		ORDER BY jl.account_code
+	`
+
+	var rows []AccountTotalRow
+	if err := GetDB(ctx, r.db).Raw(query,
+		period.From, period.From, period.From, period.From, period.To,
+	).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListPartnerTotals sums the postings on an account and its sub-accounts per partner; lines
+// without a partner are grouped under a nil partner
+func (r *ledgerRepository) ListPartnerTotals(ctx context.Context, codePrefix string, period LedgerPeriod) ([]PartnerTotalRow, error) {
+	query := `
+		SELECT
+			jl.partner_id,
+			COALESCE(p.name, '') AS partner_name,
+			COALESCE(SUM(CASE WHEN je.entry_date < ? THEN jl.debit END), 0) AS opening_debit,
+			COALESCE(SUM(CASE WHEN je.entry_date < ? THEN jl.credit END), 0) AS opening_credit,
+			COALESCE(SUM(CASE WHEN je.entry_date >= ? THEN jl.debit END), 0) AS period_debit,
+			COALESCE(SUM(CASE WHEN je.entry_date >= ? THEN jl.credit END), 0) AS period_credit
+		FROM journal_lines jl
+		JOIN journal_entries je ON je.id = jl.entry_id
+		LEFT JOIN partners p ON p.id = jl.partner_id
+		WHERE jl.account_code LIKE ? AND je.entry_date < ?
+		GROUP BY jl.partner_id, p.name
+		ORDER BY partner_name
+	`
+
+	var rows []PartnerTotalRow
+	if err := GetDB(ctx, r.db).Raw(query,
+		period.From, period.From, period.From, period.From, codePrefix+"%", period.To,
+	).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListLedgerLines returns the lines posted to an account and its sub-accounts within the
+// period, in posting order
+func (r *ledgerRepository) ListLedgerLines(ctx context.Context, codePrefix string, partnerID *uuid.UUID, period LedgerPeriod) ([]LedgerLineRow, error) {
+	query := `
+		SELECT
+			je.id AS entry_id,
+			je.entry_no,
+			je.entry_date,
+			je.source_type,
+			je.source_no,
+			COALESCE(NULLIF(jl.description, ''), je.description) AS description,
+			jl.account_code,
+			jl.partner_id,
+			COALESCE(p.name, '') AS partner_name,
+			COALESCE((
+				SELECT STRING_AGG(DISTINCT o.account_code, ',')
+				FROM journal_lines o
+				WHERE o.entry_id = jl.entry_id
+				  AND o.account_code NOT LIKE ?
+				  AND (o.debit > 0) <> (jl.debit > 0)
+			), '') AS contra_accounts,
+			jl.debit,
+			jl.credit
+		FROM journal_lines jl
+		JOIN journal_entries je ON je.id = jl.entry_id
+		LEFT JOIN partners p ON p.id = jl.partner_id
+		WHERE jl.account_code LIKE ? AND je.entry_date >= ? AND je.entry_date < ?`
+	args := []interface{}{codePrefix + "%", codePrefix + "%", period.From, period.To}
+	if partnerID != nil {
+		query += " AND jl.partner_id = ?"
+		args = append(args, *partnerID)
+	}
+	query += " ORDER BY je.entry_date ASC, je.entry_no ASC, jl.line_no ASC"
+
+	var rows []LedgerLineRow
+	if err := GetDB(ctx, r.db).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
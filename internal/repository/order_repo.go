@@ -6,14 +6,24 @@ import (
 	"backend/internal/model"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepository interface {
 	Create(ctx context.Context, order *model.Order) error
 	CreateItem(ctx context.Context, item *model.OrderItem) error
 	FindByIDWithItems(ctx context.Context, id uuid.UUID) (*model.Order, error)
+	// FindByIDWithItemsForUpdate is FindByIDWithItems locking the order row until the surrounding
+	// transaction ends
+	FindByIDWithItemsForUpdate(ctx context.Context, id uuid.UUID) (*model.Order, error)
+	FindByIDsWithProducts(ctx context.Context, ids []uuid.UUID) ([]model.Order, error)
 	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
+	UpdateColumns(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error
+	// SumPendingExportBase totals the base-currency amount of a customer's export orders awaiting
+	// approval, other than excludeID
+	SumPendingExportBase(ctx context.Context, partnerID, excludeID uuid.UUID) (decimal.Decimal, error)
 	List(ctx context.Context, page, limit int) ([]model.Order, int64, error)
 }
 
@@ -37,6 +47,7 @@ func (r *orderRepository) FindByIDWithItems(ctx context.Context, id uuid.UUID) (
 	var order model.Order
 	if err := GetDB(ctx, r.db).
 		Preload("Items").
+		Preload("Items.Product").
 		Preload("Partner").
 		Preload("Partner.Addresses").
 		Preload("OriginAddress").
@@ -47,10 +58,54 @@ func (r *orderRepository) FindByIDWithItems(ctx context.Context, id uuid.UUID) (
 	return &order, nil
 }
 
+func (r *orderRepository) FindByIDWithItemsForUpdate(ctx context.Context, id uuid.UUID) (*model.Order, error) {
+	var order model.Order
+	if err := GetDB(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Items").
+		Preload("Items.Product").
+		Preload("Partner").
+		Preload("Partner.Addresses").
+		Preload("OriginAddress").
+		Preload("ShippingAddress").
+		First(&order, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// FindByIDsWithProducts loads orders with item products (weight/volume) and the shipping address
+func (r *orderRepository) FindByIDsWithProducts(ctx context.Context, ids []uuid.UUID) ([]model.Order, error) {
+	var orders []model.Order
+	if err := GetDB(ctx, r.db).
+		Preload("Items").
+		Preload("Items.Product").
+		Preload("Partner").
+		Preload("ShippingAddress").
+		Where("id IN ?", ids).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
 func (r *orderRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
 	return GetDB(ctx, r.db).Model(&model.Order{}).Where("id = ?", id).Update("status", status).Error
 }
 
+func (r *orderRepository) UpdateColumns(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	return GetDB(ctx, r.db).Model(&model.Order{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *orderRepository) SumPendingExportBase(ctx context.Context, partnerID, excludeID uuid.UUID) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := GetDB(ctx, r.db).Model(&model.Order{}).
+		Select("COALESCE(SUM(total_amount_base), 0)").
+		Where("partner_id = ? AND type = ? AND status = ? AND id != ?",
+			partnerID, model.OrderTypeExport, model.OrderStatusPendingApproval, excludeID).
+		Scan(&total).Error
+	return total, err
+}
+
 func (r *orderRepository) List(ctx context.Context, page, limit int) ([]model.Order, int64, error) {
 	var orders []model.Order
 	var total int64
@@ -0,0 +1,423 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InvoiceBalance is an approved invoice with its net amount (after approved credit and debit
+// notes), the amount allocated to it and what is still open
+type InvoiceBalance struct {
+	InvoiceID         uuid.UUID       `gorm:"column:invoice_id"`
+	InvoiceNo         string          `gorm:"column:invoice_no"`
+	ReferenceType     string          `gorm:"column:reference_type"`
+	ReferenceID       uuid.UUID       `gorm:"column:reference_id"`
+	PartnerID         uuid.UUID       `gorm:"column:partner_id"`
+	InvoiceDate       time.Time       `gorm:"column:invoice_date"`
+	DueDate           time.Time       `gorm:"column:due_date"`
+	NetAmount         decimal.Decimal `gorm:"column:net_amount"`
+	PaidAmount        decimal.Decimal `gorm:"column:paid_amount"`
+	OutstandingAmount decimal.Decimal `gorm:"column:outstanding_amount"`
+	PaymentStatus     string          `gorm:"column:payment_status"`
+	// Invoice currency
+	Currency             string          `gorm:"column:currency"`
+	ExchangeRate         decimal.Decimal `gorm:"column:exchange_rate"`
+	DocOutstandingAmount decimal.Decimal `gorm:"column:doc_outstanding_amount"`
+}
+
+// PartnerBalance totals the open invoices and unallocated payments of a partner
+type PartnerBalance struct {
+	PartnerID         uuid.UUID       `gorm:"column:partner_id"`
+	PartnerName       string          `gorm:"column:partner_name"`
+	InvoiceCount      int64           `gorm:"column:invoice_count"`
+	NetAmount         decimal.Decimal `gorm:"column:net_amount"`
+	PaidAmount        decimal.Decimal `gorm:"column:paid_amount"`
+	OutstandingAmount decimal.Decimal `gorm:"column:outstanding_amount"`
+	UnallocatedAmount decimal.Decimal `gorm:"column:unallocated_amount"`
+}
+
+// InvoiceBalanceFilter selects the invoices of ListInvoiceBalances
+type InvoiceBalanceFilter struct {
+	RefTypes  []string
+	PartnerID *uuid.UUID
+	OpenOnly  bool       // Leave out settled invoices
+	DueBefore *time.Time // Only invoices due on or before this time
+}
+
+// PaymentListFilter holds filters for listing payments
+type PaymentListFilter struct {
+	PaymentType string
+	PartnerID   *uuid.UUID
+	DateFrom    *time.Time
+	DateTo      *time.Time
+	Unallocated bool // Only payments with an amount not yet allocated to invoices
+	Page        int
+	Limit       int
+}
+
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *model.Payment) error
+	Update(ctx context.Context, payment *model.Payment) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Payment, error)
+	// FindByIDForUpdate locks the payment row until the surrounding transaction ends
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.Payment, error)
+	List(ctx context.Context, filter PaymentListFilter) ([]model.Payment, int64, error)
+	ListByRun(ctx context.Context, runID uuid.UUID) ([]model.Payment, error)
+	ListUnallocated(ctx context.Context, paymentType string, partnerID uuid.UUID) ([]model.Payment, error)
+	CreateAllocations(ctx context.Context, allocations []model.PaymentAllocation) error
+	ListAllocationsByInvoice(ctx context.Context, invoiceID uuid.UUID) ([]model.PaymentAllocation, error)
+	SumAllocatedByInvoice(ctx context.Context, invoiceID uuid.UUID) (decimal.Decimal, error)
+	// SumAllocatedDocByInvoice sums the allocations of an invoice in the invoice currency
+	SumAllocatedDocByInvoice(ctx context.Context, invoiceID uuid.UUID) (decimal.Decimal, error)
+	ReassignAllocations(ctx context.Context, fromInvoiceID, toInvoiceID uuid.UUID) error
+	ListInvoiceBalances(ctx context.Context, filter InvoiceBalanceFilter) ([]InvoiceBalance, error)
+	ListPartnerBalances(ctx context.Context, paymentType string, refTypes []string) ([]PartnerBalance, error)
+	GetPartnerBalance(ctx context.Context, partnerID uuid.UUID, paymentType string, refTypes []string) (PartnerBalance, error)
+
+	CreateRun(ctx context.Context, run *model.PaymentRun) error
+	UpdateRun(ctx context.Context, run *model.PaymentRun) error
+	UpdateRunLine(ctx context.Context, line *model.PaymentRunLine) error
+	FindRunByID(ctx context.Context, id uuid.UUID) (*model.PaymentRun, error)
+	// FindRunByIDForUpdate locks the run row until the surrounding transaction ends
+	FindRunByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.PaymentRun, error)
+	ListRuns(ctx context.Context, status string, page, limit int) ([]model.PaymentRun, int64, error)
+	ListDraftRunInvoiceIDs(ctx context.Context) ([]uuid.UUID, error)
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) Create(ctx context.Context, payment *model.Payment) error {
+	return GetDB(ctx, r.db).Omit("Allocations").Create(payment).Error
+}
+
+func (r *paymentRepository) Update(ctx context.Context, payment *model.Payment) error {
+	return GetDB(ctx, r.db).Omit("Allocations", "Partner").Save(payment).Error
+}
+
+func (r *paymentRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Payment, error) {
+	var payment model.Payment
+	if err := GetDB(ctx, r.db).
+		Preload("Partner").
+		Preload("Allocations", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Allocations.Invoice").
+		First(&payment, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.Payment, error) {
+	var payment model.Payment
+	if err := GetDB(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&payment, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) List(ctx context.Context, filter PaymentListFilter) ([]model.Payment, int64, error) {
+	var payments []model.Payment
+	var total int64
+
+	query := GetDB(ctx, r.db).Model(&model.Payment{})
+	if filter.PaymentType != "" {
+		query = query.Where("payment_type = ?", filter.PaymentType)
+	}
+	if filter.PartnerID != nil {
+		query = query.Where("partner_id = ?", *filter.PartnerID)
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("payment_date >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("payment_date <= ?", *filter.DateTo)
+	}
+	if filter.Unallocated {
+		query = query.Where("amount_base > allocated_amount")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Preload("Partner").Order("payment_date desc, created_at desc").Offset(offset).Limit(filter.Limit).Find(&payments).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return payments, total, nil
+}
+
+func (r *paymentRepository) ListByRun(ctx context.Context, runID uuid.UUID) ([]model.Payment, error) {
+	var payments []model.Payment
+	if err := GetDB(ctx, r.db).Preload("Partner").Where("payment_run_id = ?", runID).Order("payment_no ASC").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// ListUnallocated returns the payments of a partner that still have an amount on account, oldest first
+func (r *paymentRepository) ListUnallocated(ctx context.Context, paymentType string, partnerID uuid.UUID) ([]model.Payment, error) {
+	var payments []model.Payment
+	if err := GetDB(ctx, r.db).
+		Where("payment_type = ? AND partner_id = ? AND amount_base > allocated_amount", paymentType, partnerID).
+		Order("payment_date ASC, created_at ASC").
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *paymentRepository) CreateAllocations(ctx context.Context, allocations []model.PaymentAllocation) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+	return GetDB(ctx, r.db).Omit("Invoice").Create(&allocations).Error
+}
+
+func (r *paymentRepository) ListAllocationsByInvoice(ctx context.Context, invoiceID uuid.UUID) ([]model.PaymentAllocation, error) {
+	var allocations []model.PaymentAllocation
+	if err := GetDB(ctx, r.db).Where("invoice_id = ?", invoiceID).Order("created_at ASC").Find(&allocations).Error; err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+func (r *paymentRepository) SumAllocatedByInvoice(ctx context.Context, invoiceID uuid.UUID) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	if err := GetDB(ctx, r.db).Model(&model.PaymentAllocation{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("invoice_id = ?", invoiceID).
+		Scan(&total).Error; err != nil {
+		return decimal.Zero, err
+	}
+	return total, nil
+}
+
+func (r *paymentRepository) SumAllocatedDocByInvoice(ctx context.Context, invoiceID uuid.UUID) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	if err := GetDB(ctx, r.db).Model(&model.PaymentAllocation{}).
+		Select("COALESCE(SUM(doc_amount), 0)").
+		Where("invoice_id = ?", invoiceID).
+		Scan(&total).Error; err != nil {
+		return decimal.Zero, err
+	}
+	return total, nil
+}
+
+// ReassignAllocations moves the allocations of a voided invoice to its replacement
+func (r *paymentRepository) ReassignAllocations(ctx context.Context, fromInvoiceID, toInvoiceID uuid.UUID) error {
+	return GetDB(ctx, r.db).Model(&model.PaymentAllocation{}).
+		Where("invoice_id = ?", fromInvoiceID).
+		Update("invoice_id", toInvoiceID).Error
+}
+
+// invoiceBalanceQuery selects the approved, non-voided standard and replacement invoices with
+// a partner; credit and debit notes are folded into the invoice they adjust
+const invoiceBalanceQuery = `
+	SELECT
+		i.id AS invoice_id,
+		i.invoice_no,
+		i.reference_type,
+		i.reference_id,
+		i.partner_id,
+		COALESCE(i.approved_at, i.created_at) AS invoice_date,
+		COALESCE(i.due_date, i.approved_at, i.created_at) AS due_date,
+		i.total_amount + COALESCE(n.total, 0) AS net_amount,
+		i.paid_amount,
+		i.total_amount + COALESCE(n.total, 0) - i.paid_amount AS outstanding_amount,
+		i.payment_status,
+		i.currency,
+		i.exchange_rate,
+		i.doc_total_amount + COALESCE(n.doc_total, 0) - i.doc_paid_amount AS doc_outstanding_amount
+	FROM invoices i
+	LEFT JOIN (
+		SELECT original_invoice_id, SUM(total_amount) AS total, SUM(doc_total_amount) AS doc_total
+		FROM invoices
+		WHERE invoice_type IN ('CREDIT_NOTE', 'DEBIT_NOTE') AND approval_status = 'APPROVED'
+		GROUP BY original_invoice_id
+	) n ON n.original_invoice_id = i.id
+	WHERE i.approval_status = 'APPROVED'
+	  AND i.voided_at IS NULL
+	  AND i.invoice_type IN ('STANDARD', 'REPLACEMENT')
+	  AND i.partner_id IS NOT NULL
+	  AND i.reference_type IN ?
+`
+
+// ListInvoiceBalances returns the invoice balances of the given reference types, by due date.
+// Invoices approved before due dates were kept fall due on approval.
+func (r *paymentRepository) ListInvoiceBalances(ctx context.Context, filter InvoiceBalanceFilter) ([]InvoiceBalance, error) {
+	query := invoiceBalanceQuery
+	args := []interface{}{filter.RefTypes}
+	if filter.PartnerID != nil {
+		query += " AND i.partner_id = ?"
+		args = append(args, *filter.PartnerID)
+	}
+	if filter.OpenOnly {
+		query += " AND i.total_amount + COALESCE(n.total, 0) - i.paid_amount <> 0"
+	}
+	if filter.DueBefore != nil {
+		query += " AND COALESCE(i.due_date, i.approved_at, i.created_at) <= ?"
+		args = append(args, *filter.DueBefore)
+	}
+	query += " ORDER BY due_date ASC, i.invoice_no ASC"
+
+	var rows []InvoiceBalance
+	if err := GetDB(ctx, r.db).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListPartnerBalances totals the invoice balances per partner and adds the part of the
+// partner's payments of paymentType not yet allocated to any invoice
+func (r *paymentRepository) ListPartnerBalances(ctx context.Context, paymentType string, refTypes []string) ([]PartnerBalance, error) {
+	query := `
+		SELECT
+			p.id AS partner_id,
+			p.name AS partner_name,
+			COALESCE(b.invoice_count, 0) AS invoice_count,
+			COALESCE(b.net_amount, 0) AS net_amount,
+			COALESCE(b.paid_amount, 0) AS paid_amount,
+			COALESCE(b.outstanding_amount, 0) AS outstanding_amount,
+			COALESCE(u.unallocated, 0) AS unallocated_amount
+		FROM partners p
+		LEFT JOIN (
+			SELECT partner_id,
+				COUNT(*) AS invoice_count,
+				SUM(net_amount) AS net_amount,
+				SUM(paid_amount) AS paid_amount,
+				SUM(outstanding_amount) AS outstanding_amount
+			FROM (` + invoiceBalanceQuery + `) ib
+			GROUP BY partner_id
+		) b ON b.partner_id = p.id
+		LEFT JOIN (
+			SELECT partner_id, SUM(amount_base - allocated_amount) AS unallocated
+			FROM payments
+			WHERE payment_type = ?
+			GROUP BY partner_id
+		) u ON u.partner_id = p.id
+		WHERE COALESCE(b.outstanding_amount, 0) <> 0 OR COALESCE(u.unallocated, 0) <> 0
+		ORDER BY outstanding_amount DESC, p.name ASC
+	`
+
+	var rows []PartnerBalance
+	if err := GetDB(ctx, r.db).Raw(query, refTypes, paymentType).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetPartnerBalance totals the invoice balances and unallocated payments of one partner; a
+// partner without invoices or payments gets a zero balance
+func (r *paymentRepository) GetPartnerBalance(ctx context.Context, partnerID uuid.UUID, paymentType string, refTypes []string) (PartnerBalance, error) {
+	query := `
+		SELECT
+			CAST(? AS uuid) AS partner_id,
+			COUNT(*) FILTER (WHERE ib.outstanding_amount <> 0) AS invoice_count,
+			COALESCE(SUM(ib.net_amount), 0) AS net_amount,
+			COALESCE(SUM(ib.paid_amount), 0) AS paid_amount,
+			COALESCE(SUM(ib.outstanding_amount), 0) AS outstanding_amount,
+			(
+				SELECT COALESCE(SUM(amount_base - allocated_amount), 0)
+				FROM payments
+				WHERE payment_type = ? AND partner_id = ?
+			) AS unallocated_amount
+		FROM (` + invoiceBalanceQuery + ` AND i.partner_id = ?) ib
+	`
+
+	var balance PartnerBalance
+	if err := GetDB(ctx, r.db).Raw(query, partnerID, paymentType, partnerID, refTypes, partnerID).Scan(&balance).Error; err != nil {
+		return PartnerBalance{}, err
+	}
+	return balance, nil
+}
+
+func (r *paymentRepository) CreateRun(ctx context.Context, run *model.PaymentRun) error {
+	return GetDB(ctx, r.db).Create(run).Error
+}
+
+func (r *paymentRepository) UpdateRun(ctx context.Context, run *model.PaymentRun) error {
+	return GetDB(ctx, r.db).Omit("Lines").Save(run).Error
+}
+
+func (r *paymentRepository) UpdateRunLine(ctx context.Context, line *model.PaymentRunLine) error {
+	return GetDB(ctx, r.db).Omit("Invoice", "Partner").Save(line).Error
+}
+
+func (r *paymentRepository) FindRunByID(ctx context.Context, id uuid.UUID) (*model.PaymentRun, error) {
+	var run model.PaymentRun
+	if err := GetDB(ctx, r.db).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("due_date ASC")
+		}).
+		Preload("Lines.Invoice").
+		Preload("Lines.Partner").
+		First(&run, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *paymentRepository) FindRunByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.PaymentRun, error) {
+	var run model.PaymentRun
+	if err := GetDB(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&run, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if err := GetDB(ctx, r.db).
+		Preload("Invoice").
+		Preload("Partner").
+		Where("payment_run_id = ?", id).
+		Order("due_date ASC").
+		Find(&run.Lines).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *paymentRepository) ListRuns(ctx context.Context, status string, page, limit int) ([]model.PaymentRun, int64, error) {
+	var runs []model.PaymentRun
+	var total int64
+
+	query := GetDB(ctx, r.db).Model(&model.PaymentRun{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Preload("Lines").Order("created_at desc").Offset(offset).Limit(limit).Find(&runs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return runs, total, nil
+}
+
+// ListDraftRunInvoiceIDs returns the invoices already proposed in a draft run
+func (r *paymentRepository) ListDraftRunInvoiceIDs(ctx context.Context) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := GetDB(ctx, r.db).Model(&model.PaymentRunLine{}).
+		Joins("JOIN payment_runs ON payment_runs.id = payment_run_lines.payment_run_id").
+		Where("payment_runs.status = ?", model.PaymentRunDraft).
+		Pluck("payment_run_lines.invoice_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -3,7 +3,10 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -14,10 +17,36 @@ type RevenueDataRow struct {
 	TotalTaxCollected float64 `gorm:"column:total_tax_collected"`
 	TotalTaxPaid      float64 `gorm:"column:total_tax_paid"`
 	TotalSideFees     float64 `gorm:"column:total_side_fees"`
+	UnconvertedCount  int64   `gorm:"column:unconverted_count"`
+}
+
+// AgingInvoiceRow is an invoice open at the cutoff of an aging report, with the amounts as
+// they stood at that moment
+type AgingInvoiceRow struct {
+	InvoiceID         uuid.UUID       `gorm:"column:invoice_id"`
+	InvoiceNo         string          `gorm:"column:invoice_no"`
+	ReferenceType     string          `gorm:"column:reference_type"`
+	PartnerID         *uuid.UUID      `gorm:"column:partner_id"` // Nil for expense invoices without a vendor
+	PartnerName       string          `gorm:"column:partner_name"`
+	InvoiceDate       time.Time       `gorm:"column:invoice_date"`
+	DueDate           time.Time       `gorm:"column:due_date"`
+	NetAmount         decimal.Decimal `gorm:"column:net_amount"`
+	PaidAmount        decimal.Decimal `gorm:"column:paid_amount"`
+	OutstandingAmount decimal.Decimal `gorm:"column:outstanding_amount"`
+}
+
+// AgingUnallocatedRow is the part of a partner's payments dated before the cutoff that is
+// not allocated to any invoice
+type AgingUnallocatedRow struct {
+	PartnerID   uuid.UUID       `gorm:"column:partner_id"`
+	PartnerName string          `gorm:"column:partner_name"`
+	Amount      decimal.Decimal `gorm:"column:amount"`
 }
 
 type RevenueRepository interface {
-	GetRevenueStatistics(ctx context.Context, groupBy, startDate, endDate, exportType, importType, expenseType, approvedStatus string) ([]RevenueDataRow, error)
+	GetRevenueStatistics(ctx context.Context, groupBy, startDate, endDate, exportType, importType, expenseType, approvedStatus, currency, baseCurrency string) ([]RevenueDataRow, error)
+	GetAgingInvoices(ctx context.Context, refTypes []string, cutoff time.Time, partnerID *uuid.UUID) ([]AgingInvoiceRow, error)
+	GetAgingUnallocated(ctx context.Context, paymentType string, cutoff time.Time, partnerID *uuid.UUID) ([]AgingUnallocatedRow, error)
 }
 
 type revenueRepository struct {
@@ -28,29 +57,151 @@ func NewRevenueRepository(db *gorm.DB) RevenueRepository {
 	return &revenueRepository{db: db}
 }
 
-func (r *revenueRepository) GetRevenueStatistics(ctx context.Context, groupBy, startDate, endDate, exportType, importType, expenseType, approvedStatus string) ([]RevenueDataRow, error) {
+// GetRevenueStatistics sums approved invoices per period. Credit notes carry negative amounts
+// and debit notes positive ones, so they net into the period they were issued in; invoices
+// voided by an approved replacement are left out in favour of the replacement.
+// Amounts are reported in currency: invoices issued in it count at their document amounts,
+// others are converted from the base currency at the latest rate of currency on or before the
+// invoice date. Invoices without such a rate are left out and counted in unconverted_count.
+func (r *revenueRepository) GetRevenueStatistics(ctx context.Context, groupBy, startDate, endDate, exportType, importType, expenseType, approvedStatus, currency, baseCurrency string) ([]RevenueDataRow, error) {
 	query := `
 		SELECT
-			TO_CHAR(DATE_TRUNC($1, i.created_at), 'YYYY-MM-DD') AS period,
-			COALESCE(SUM(CASE WHEN i.reference_type = $4 THEN i.total_amount ELSE 0 END), 0) AS total_revenue,
-			COALESCE(SUM(CASE WHEN i.reference_type IN ($5, $6) THEN i.total_amount ELSE 0 END), 0) AS total_expense,
-			COALESCE(SUM(CASE WHEN i.reference_type = $4 THEN i.tax_amount ELSE 0 END), 0) AS total_tax_collected,
-			COALESCE(SUM(CASE WHEN i.reference_type IN ($5, $6) THEN i.tax_amount ELSE 0 END), 0) AS total_tax_paid,
-			COALESCE(SUM(i.side_fees), 0) AS total_side_fees
-		FROM invoices i
-		WHERE i.approval_status = $7
-		  AND i.created_at >= $2::timestamptz
-		  AND i.created_at <= $3::timestamptz
-		GROUP BY DATE_TRUNC($1, i.created_at)
+			TO_CHAR(DATE_TRUNC($1, c.created_at), 'YYYY-MM-DD') AS period,
+			COALESCE(SUM(CASE WHEN c.reference_type = $4 THEN c.total_amount ELSE 0 END), 0) AS total_revenue,
+			COALESCE(SUM(CASE WHEN c.reference_type IN ($5, $6) THEN c.total_amount ELSE 0 END), 0) AS total_expense,
+			COALESCE(SUM(CASE WHEN c.reference_type = $4 THEN c.tax_amount ELSE 0 END), 0) AS total_tax_collected,
+			COALESCE(SUM(CASE WHEN c.reference_type IN ($5, $6) THEN c.tax_amount ELSE 0 END), 0) AS total_tax_paid,
+			COALESCE(SUM(c.side_fees), 0) AS total_side_fees,
+			COUNT(*) FILTER (WHERE c.total_amount IS NULL) AS unconverted_count
+		FROM (
+			SELECT
+				i.created_at,
+				i.reference_type,
+				CASE WHEN i.currency = $8::varchar THEN i.doc_total_amount
+				     WHEN $8::varchar = $9::varchar THEN i.total_amount
+				     ELSE i.total_amount / r.rate END AS total_amount,
+				CASE WHEN i.currency = $8::varchar THEN i.doc_tax_amount
+				     WHEN $8::varchar = $9::varchar THEN i.tax_amount
+				     ELSE i.tax_amount / r.rate END AS tax_amount,
+				CASE WHEN i.currency = $8::varchar THEN i.doc_side_fees
+				     WHEN $8::varchar = $9::varchar THEN i.side_fees
+				     ELSE i.side_fees / r.rate END AS side_fees
+			FROM invoices i
+			LEFT JOIN LATERAL (
+				SELECT er.rate
+				FROM exchange_rates er
+				WHERE er.currency = $8::varchar
+				  AND er.rate_date <= i.created_at::date
+				ORDER BY er.rate_date DESC
+				LIMIT 1
+			) r ON TRUE
+			WHERE i.approval_status = $7
+			  AND i.voided_at IS NULL
+			  AND i.created_at >= $2::timestamptz
+			  AND i.created_at <= $3::timestamptz
+		) c
+		GROUP BY DATE_TRUNC($1, c.created_at)
 		ORDER BY period
 	`
 
 	var rows []RevenueDataRow
 	if err := r.db.WithContext(ctx).Raw(query,
-		groupBy, startDate, endDate, exportType, importType, expenseType, approvedStatus,
+		groupBy, startDate, endDate, exportType, importType, expenseType, approvedStatus, currency, baseCurrency,
 	).Scan(&rows).Error; err != nil {
 		return nil, fmt.Errorf("failed to query revenue statistics: %w", err)
 	}
 
 	return rows, nil
 }
+
+// GetAgingInvoices rebuilds the open invoices as they stood just before cutoff: invoices and
+// credit/debit notes approved before it, minus invoices already voided by then, less the
+// allocations dated before it. Allocations moved to a replacement invoice that was approved
+// after the cutoff still count for the original. Invoices without a partner (expenses without a
+// vendor) are included with an empty partner.
+func (r *revenueRepository) GetAgingInvoices(ctx context.Context, refTypes []string, cutoff time.Time, partnerID *uuid.UUID) ([]AgingInvoiceRow, error) {
+	query := `
+		SELECT * FROM (
+			SELECT
+				i.id AS invoice_id,
+				i.invoice_no,
+				i.reference_type,
+				i.partner_id,
+				COALESCE(p.name, '') AS partner_name,
+				i.approved_at AS invoice_date,
+				COALESCE(i.due_date, i.approved_at) AS due_date,
+				i.total_amount + COALESCE(n.total, 0) AS net_amount,
+				COALESCE(a.paid, 0) AS paid_amount,
+				i.total_amount + COALESCE(n.total, 0) - COALESCE(a.paid, 0) AS outstanding_amount
+			FROM invoices i
+			LEFT JOIN partners p ON p.id = i.partner_id
+			LEFT JOIN LATERAL (
+				SELECT SUM(total_amount) AS total
+				FROM invoices
+				WHERE original_invoice_id = i.id
+				  AND invoice_type IN ('CREDIT_NOTE', 'DEBIT_NOTE')
+				  AND approval_status = 'APPROVED'
+				  AND approved_at < ?
+			) n ON TRUE
+			LEFT JOIN LATERAL (
+				SELECT SUM(pa.amount) AS paid
+				FROM payment_allocations pa
+				JOIN invoices ai ON ai.id = pa.invoice_id
+				WHERE (ai.id = i.id OR (ai.original_invoice_id = i.id AND ai.invoice_type = 'REPLACEMENT'))
+				  AND pa.allocated_at < ?
+			) a ON TRUE
+			WHERE i.approval_status = 'APPROVED'
+			  AND i.approved_at < ?
+			  AND (i.voided_at IS NULL OR i.voided_at >= ?)
+			  AND i.invoice_type IN ('STANDARD', 'REPLACEMENT')
+			  AND i.reference_type IN ?`
+	args := []interface{}{cutoff, cutoff, cutoff, cutoff, refTypes}
+	if partnerID != nil {
+		query += " AND i.partner_id = ?"
+		args = append(args, *partnerID)
+	}
+	query += `
+		) ib
+		WHERE outstanding_amount <> 0
+		ORDER BY partner_id IS NULL, partner_name ASC, due_date ASC, invoice_no ASC`
+
+	var rows []AgingInvoiceRow
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query aging invoices: %w", err)
+	}
+
+	return rows, nil
+}
+
+// GetAgingUnallocated sums per partner what the payments dated before cutoff had not been
+// allocated by then: allocations dated on or after the cutoff still count as unallocated,
+// consistent with GetAgingInvoices.
+func (r *revenueRepository) GetAgingUnallocated(ctx context.Context, paymentType string, cutoff time.Time, partnerID *uuid.UUID) ([]AgingUnallocatedRow, error) {
+	query := `
+		SELECT pm.partner_id, p.name AS partner_name, SUM(pm.amount_base - COALESCE(a.allocated, 0)) AS amount
+		FROM payments pm
+		JOIN partners p ON p.id = pm.partner_id
+		LEFT JOIN LATERAL (
+			SELECT SUM(pa.payment_amount_base) AS allocated
+			FROM payment_allocations pa
+			WHERE pa.payment_id = pm.id
+			  AND pa.allocated_at < ?
+		) a ON TRUE
+		WHERE pm.payment_type = ?
+		  AND pm.payment_date < ?`
+	args := []interface{}{cutoff, paymentType, cutoff}
+	if partnerID != nil {
+		query += " AND pm.partner_id = ?"
+		args = append(args, *partnerID)
+	}
+	query += `
+		GROUP BY pm.partner_id, p.name
+		HAVING SUM(pm.amount_base - COALESCE(a.allocated, 0)) <> 0`
+
+	var rows []AgingUnallocatedRow
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query unallocated payments: %w", err)
+	}
+
+	return rows, nil
+}
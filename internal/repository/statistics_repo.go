@@ -29,7 +29,7 @@ func (r *statisticsRepository) GetOrderStatistics(ctx context.Context, orderType
 		Count int
 	}
 	r.db.WithContext(ctx).Table("order_items").
-		Select("COALESCE(CAST(SUM(order_items.quantity * order_items.unit_price) AS TEXT), '0') as value, COUNT(DISTINCT orders.id) as count").
+		Select("COALESCE(CAST(SUM(order_items.quantity * order_items.unit_price - order_items.discount) AS TEXT), '0') as value, COUNT(DISTINCT orders.id) as count").
 		Joins("JOIN orders ON orders.id = order_items.order_id").
 		Where("orders.type = ? AND orders.status = ? AND orders.created_at >= ? AND orders.created_at <= ?", orderType, status, start, end).
 		Scan(&result)
@@ -39,7 +39,7 @@ func (r *statisticsRepository) GetOrderStatistics(ctx context.Context, orderType
 func (r *statisticsRepository) GetTopProducts(ctx context.Context, orderType, status string, start, end time.Time, limit int) ([]model.ProductRanking, error) {
 	var rankings []model.ProductRanking
 	if err := r.db.WithContext(ctx).Table("order_items").
-		Select("products.id as product_id, products.name as product_name, products.sku as product_sku, SUM(order_items.quantity) as total_quantity, SUM(order_items.quantity * order_items.unit_price) as total_value").
+		Select("products.id as product_id, products.name as product_name, products.sku as product_sku, SUM(order_items.quantity) as total_quantity, SUM(order_items.quantity * order_items.unit_price - order_items.discount) as total_value").
 		Joins("JOIN products ON products.id = order_items.product_id").
 		Joins("JOIN orders ON orders.id = order_items.order_id").
 		Where("orders.type = ? AND orders.status = ? AND orders.created_at >= ? AND orders.created_at <= ?", orderType, status, start, end).
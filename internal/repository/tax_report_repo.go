@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// VATInvoiceRow is the part of an approved invoice taxed at one rate: the sum of its lines with
+// that tax type and rate. Amounts are in the base currency; credit notes are negative.
+type VATInvoiceRow struct {
+	InvoiceID   uuid.UUID       `gorm:"column:invoice_id"`
+	InvoiceNo   string          `gorm:"column:invoice_no"`
+	InvoiceType string          `gorm:"column:invoice_type"`
+	InvoiceDate time.Time       `gorm:"column:invoice_date"`
+	PartnerName string          `gorm:"column:partner_name"`
+	TaxCode     string          `gorm:"column:tax_code"`
+	TaxType     string          `gorm:"column:tax_type"` // Empty for lines not subject to VAT
+	TaxRate     decimal.Decimal `gorm:"column:tax_rate"`
+	Amount      decimal.Decimal `gorm:"column:amount"`
+	TaxAmount   decimal.Decimal `gorm:"column:tax_amount"`
+}
+
+type TaxReportRepository interface {
+	// ListSalesVAT returns the untaxed lines and the lines of taxTypes of approved sales invoices
+	// of refType issued in [from, to); side fees are returned as untaxed lines
+	ListSalesVAT(ctx context.Context, refType string, taxTypes []string, from, to time.Time) ([]VATInvoiceRow, error)
+	// ListPurchaseVAT returns the lines of taxTypes on approved invoices of deductible expenses
+	// issued in [from, to)
+	ListPurchaseVAT(ctx context.Context, taxTypes []string, from, to time.Time) ([]VATInvoiceRow, error)
+	// ListFCTExpenses returns the foreign vendor expenses whose invoice was approved in [from, to)
+	ListFCTExpenses(ctx context.Context, from, to time.Time) ([]FCTExpenseRow, error)
+
+	// ListCITRevenue returns the approved sales invoices and notes of refType issued in [from, to)
+	ListCITRevenue(ctx context.Context, refType string, from, to time.Time) ([]CITRevenueRow, error)
+	// ListCITExpenses returns the expenses not linked to an order whose invoice was approved in
+	// [from, to)
+	ListCITExpenses(ctx context.Context, from, to time.Time) ([]CITExpenseRow, error)
+	// ListCostOfSales returns the cost of goods sold posted in [from, to) per day
+	ListCostOfSales(ctx context.Context, from, to time.Time) ([]CostOfSalesRow, error)
+	// FindCITSetting returns the CIT estimate rules, or gorm.ErrRecordNotFound before they are saved
+	FindCITSetting(ctx context.Context) (*model.CITSetting, error)
+	SaveCITSetting(ctx context.Context, setting *model.CITSetting) error
+}
+
+type taxReportRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxReportRepository(db *gorm.DB) TaxReportRepository {
+	return &taxReportRepository{db: db}
+}
+
+// vatInvoiceSelect groups invoice lines per invoice, tax type and rate. Invoices voided by an
+// approved replacement are left out in favour of the replacement, as in revenue statistics.
+const vatInvoiceSelect = `
+	SELECT
+		i.id AS invoice_id,
+		i.invoice_no,
+		i.invoice_type,
+		i.approved_at AS invoice_date,
+		COALESCE(NULLIF(i.company_name, ''), p.name, '') AS partner_name,
+		%s AS tax_code,
+		COALESCE(l.tax_type, '') AS tax_type,
+		l.tax_rate,
+		SUM(l.amount) AS amount,
+		SUM(l.tax_amount) AS tax_amount
+	FROM invoices i
+	JOIN invoice_lines l ON l.invoice_id = i.id
+	LEFT JOIN partners p ON p.id = i.partner_id
+	%s
+	WHERE i.approval_status = 'APPROVED'
+	  AND i.voided_at IS NULL
+	  AND i.approved_at >= ? AND i.approved_at < ?
+	  AND %s
+	GROUP BY 1, 2, 3, 4, 5, 6, 7, 8`
+
+// vatInvoiceOrder lists the rows by rate, then by invoice
+const vatInvoiceOrder = `
+	ORDER BY tax_rate, invoice_date, invoice_no`
+
+// salesSideFeesSelect returns the side fees of sales invoices as untaxed lines: they are posted as
+// service revenue and no tax is charged on them
+const salesSideFeesSelect = `
+	SELECT
+		i.id AS invoice_id,
+		i.invoice_no,
+		i.invoice_type,
+		i.approved_at AS invoice_date,
+		COALESCE(NULLIF(i.company_name, ''), p.name, '') AS partner_name,
+		i.tax_code,
+		'' AS tax_type,
+		0 AS tax_rate,
+		i.side_fees AS amount,
+		0 AS tax_amount
+	FROM invoices i
+	LEFT JOIN partners p ON p.id = i.partner_id
+	WHERE i.approval_status = 'APPROVED'
+	  AND i.voided_at IS NULL
+	  AND i.approved_at >= ? AND i.approved_at < ?
+	  AND i.reference_type = ? AND i.side_fees <> 0`
+
+func (r *taxReportRepository) ListSalesVAT(ctx context.Context, refType string, taxTypes []string, from, to time.Time) ([]VATInvoiceRow, error) {
+	lines := fmt.Sprintf(vatInvoiceSelect, "i.tax_code", "",
+		"i.reference_type = ? AND (COALESCE(l.tax_type, '') = '' OR l.tax_type IN ?)")
+	query := `
+	SELECT invoice_id, invoice_no, invoice_type, invoice_date, partner_name, tax_code, tax_type, tax_rate,
+		SUM(amount) AS amount, SUM(tax_amount) AS tax_amount
+	FROM (` + lines + ` UNION ALL ` + salesSideFeesSelect + `) v
+	GROUP BY 1, 2, 3, 4, 5, 6, 7, 8` + vatInvoiceOrder
+	var rows []VATInvoiceRow
+	err := GetDB(ctx, r.db).Raw(query, from, to, refType, taxTypes, from, to, refType).Scan(&rows).Error
+	return rows, err
+}
+
+// ListPurchaseVAT takes the vendor tax code from the expense, which records it from the vendor's
+// invoice, falling back to the code on the invoice
+func (r *taxReportRepository) ListPurchaseVAT(ctx context.Context, taxTypes []string, from, to time.Time) ([]VATInvoiceRow, error) {
+	query := fmt.Sprintf(vatInvoiceSelect, "COALESCE(NULLIF(e.vendor_tax_code, ''), i.tax_code)",
+		"JOIN expenses e ON e.id = i.reference_id",
+		"i.reference_type = 'EXPENSE' AND e.is_deductible_expense AND l.tax_type IN ?") + vatInvoiceOrder
+	var rows []VATInvoiceRow
+	err := GetDB(ctx, r.db).Raw(query, from, to, taxTypes).Scan(&rows).Error
+	return rows, err
+}
+
+// FCTExpenseRow is a foreign vendor expense whose invoice was approved, with the FCT withheld on
+// the disbursements that paid it. Amounts are in the base currency.
+type FCTExpenseRow struct {
+	ExpenseID       uuid.UUID       `gorm:"column:expense_id"`
+	InvoiceNo       string          `gorm:"column:invoice_no"`
+	InvoiceDate     time.Time       `gorm:"column:invoice_date"`
+	VendorID        *uuid.UUID      `gorm:"column:vendor_id"`
+	VendorName      string          `gorm:"column:vendor_name"`
+	VendorTaxCode   string          `gorm:"column:vendor_tax_code"`
+	Country         string          `gorm:"column:country"`
+	Description     string          `gorm:"column:description"`
+	Currency        string          `gorm:"column:currency"`
+	OriginalAmount  decimal.Decimal `gorm:"column:original_amount"`
+	ConvertedAmount decimal.Decimal `gorm:"column:converted_amount"`
+	FCTType         string          `gorm:"column:fct_type"`
+	FCTRate         decimal.Decimal `gorm:"column:fct_rate"`
+	FCTAmount       decimal.Decimal `gorm:"column:fct_amount"`
+	VATRate         decimal.Decimal `gorm:"column:vat_rate"`
+	VATAmount       decimal.Decimal `gorm:"column:vat_amount"`
+	WithheldAmount  decimal.Decimal `gorm:"column:withheld_amount"`
+}
+
+// ListFCTExpenses returns the foreign vendor expenses whose invoice was approved in [from, to),
+// with the FCT withheld on disbursements dated before to. A disbursement's withholding is shared
+// between the invoices it pays in proportion to the base amount allocated to each. The vendor's
+// country is that of its default address, then its billing address.
+func (r *taxReportRepository) ListFCTExpenses(ctx context.Context, from, to time.Time) ([]FCTExpenseRow, error) {
+	var rows []FCTExpenseRow
+	err := GetDB(ctx, r.db).Raw(`
+		SELECT
+			e.id AS expense_id,
+			i.invoice_no,
+			i.approved_at AS invoice_date,
+			e.vendor_id,
+			COALESCE(NULLIF(p.company_name, ''), p.name, i.company_name, '') AS vendor_name,
+			COALESCE(NULLIF(e.vendor_tax_code, ''), p.tax_code, '') AS vendor_tax_code,
+			COALESCE((
+				SELECT a.country FROM partner_addresses a
+				WHERE a.partner_id = p.id
+				ORDER BY a.is_default DESC, a.address_type = 'BILLING' DESC, a.created_at
+				LIMIT 1
+			), '') AS country,
+			e.description,
+			e.currency,
+			e.original_amount,
+			e.converted_amount,
+			e.fct_type,
+			e.fct_rate,
+			e.fct_amount,
+			e.vat_rate,
+			e.vat_amount,
+			COALESCE((
+				SELECT SUM(pm.withheld_amount * pm.exchange_rate * pa.payment_amount_base / pm.amount_base)
+				FROM payment_allocations pa
+				JOIN payments pm ON pm.id = pa.payment_id
+				JOIN invoices ai ON ai.id = pa.invoice_id
+				WHERE ai.reference_type = 'EXPENSE' AND ai.reference_id = e.id
+				  AND pm.payment_type = 'DISBURSEMENT'
+				  AND pm.withheld_amount > 0 AND pm.amount_base > 0
+				  AND pm.payment_date < ?
+			), 0) AS withheld_amount
+		FROM expenses e
+		JOIN invoices i ON i.reference_type = 'EXPENSE' AND i.reference_id = e.id AND i.invoice_type = 'STANDARD'
+		LEFT JOIN partners p ON p.id = e.vendor_id
+		WHERE e.is_foreign_vendor
+		  AND i.approval_status = 'APPROVED'
+		  AND i.approved_at >= ? AND i.approved_at < ?
+		ORDER BY vendor_name, i.approved_at, i.invoice_no`, to, from, to).Scan(&rows).Error
+	return rows, err
+}
+
+// CITRevenueRow is an approved sales invoice or note; Revenue is its pre-tax amount including
+// side fees, in the base currency, negative for credit notes
+type CITRevenueRow struct {
+	InvoiceID   uuid.UUID       `gorm:"column:invoice_id"`
+	InvoiceNo   string          `gorm:"column:invoice_no"`
+	InvoiceType string          `gorm:"column:invoice_type"`
+	InvoiceDate time.Time       `gorm:"column:invoice_date"`
+	Revenue     decimal.Decimal `gorm:"column:revenue"`
+}
+
+// ListCITRevenue leaves out invoices voided by an approved replacement, as in revenue statistics
+func (r *taxReportRepository) ListCITRevenue(ctx context.Context, refType string, from, to time.Time) ([]CITRevenueRow, error) {
+	var rows []CITRevenueRow
+	err := GetDB(ctx, r.db).Raw(`
+		SELECT
+			i.id AS invoice_id,
+			i.invoice_no,
+			i.invoice_type,
+			i.approved_at AS invoice_date,
+			i.subtotal + i.side_fees AS revenue
+		FROM invoices i
+		WHERE i.reference_type = ?
+		  AND i.approval_status = 'APPROVED'
+		  AND i.voided_at IS NULL
+		  AND i.approved_at >= ? AND i.approved_at < ?
+		ORDER BY i.approved_at, i.invoice_no`, refType, from, to).Scan(&rows).Error
+	return rows, err
+}
+
+// CITExpenseRow is an expense whose invoice was approved, with the invoice total and the part
+// of it paid in cash. Amounts are in the base currency.
+type CITExpenseRow struct {
+	ExpenseID           uuid.UUID       `gorm:"column:expense_id"`
+	InvoiceNo           string          `gorm:"column:invoice_no"`
+	InvoiceDate         time.Time       `gorm:"column:invoice_date"`
+	VendorName          string          `gorm:"column:vendor_name"`
+	Description         string          `gorm:"column:description"`
+	DocumentType        string          `gorm:"column:document_type"`
+	IsDeductibleExpense bool            `gorm:"column:is_deductible_expense"`
+	ConvertedAmount     decimal.Decimal `gorm:"column:converted_amount"`
+	FCTType             string          `gorm:"column:fct_type"`
+	FCTAmount           decimal.Decimal `gorm:"column:fct_amount"`
+	InvoiceTotal        decimal.Decimal `gorm:"column:invoice_total"`
+	CashPaid            decimal.Decimal `gorm:"column:cash_paid"`
+}
+
+// ListCITExpenses counts the cash disbursements dated before to that paid the expense invoice.
+// Expenses already approved when their order's landed cost was last allocated are left out:
+// the allocation capitalized them into the cost layers, so they are deducted as cost of goods
+// sold when the goods are sold. Order expenses not (yet) allocated are deducted here.
+func (r *taxReportRepository) ListCITExpenses(ctx context.Context, from, to time.Time) ([]CITExpenseRow, error) {
+	var rows []CITExpenseRow
+	err := GetDB(ctx, r.db).Raw(`
+		SELECT
+			e.id AS expense_id,
+			i.invoice_no,
+			i.approved_at AS invoice_date,
+			COALESCE(NULLIF(p.company_name, ''), p.name, i.company_name, '') AS vendor_name,
+			e.description,
+			e.document_type,
+			e.is_deductible_expense,
+			e.converted_amount,
+			COALESCE(e.fct_type, '') AS fct_type,
+			e.fct_amount,
+			i.total_amount AS invoice_total,
+			COALESCE((
+				SELECT SUM(pa.payment_amount_base)
+				FROM payment_allocations pa
+				JOIN payments pm ON pm.id = pa.payment_id
+				WHERE pa.invoice_id = i.id
+				  AND pm.payment_type = 'DISBURSEMENT' AND pm.method = 'CASH'
+				  AND pm.payment_date < ?
+			), 0) AS cash_paid
+		FROM expenses e
+		JOIN invoices i ON i.reference_type = 'EXPENSE' AND i.reference_id = e.id AND i.invoice_type = 'STANDARD'
+		LEFT JOIN partners p ON p.id = e.vendor_id
+		WHERE NOT EXISTS (
+			SELECT 1 FROM landed_cost_allocations lca
+			WHERE lca.order_id = e.order_id AND lca.created_at >= i.approved_at
+		)
+		  AND i.approval_status = 'APPROVED'
+		  AND i.approved_at >= ? AND i.approved_at < ?
+		ORDER BY i.approved_at, i.invoice_no`, to, from, to).Scan(&rows).Error
+	return rows, err
+}
+
+// CostOfSalesRow is the cost of goods sold posted on a day, in the base currency
+type CostOfSalesRow struct {
+	EntryDate time.Time       `gorm:"column:entry_date"`
+	Amount    decimal.Decimal `gorm:"column:amount"`
+}
+
+// ListCostOfSales nets the debits and credits of account 632, so reversed entries cancel out
+func (r *taxReportRepository) ListCostOfSales(ctx context.Context, from, to time.Time) ([]CostOfSalesRow, error) {
+	var rows []CostOfSalesRow
+	err := GetDB(ctx, r.db).Raw(`
+		SELECT
+			CAST(je.entry_date AS date) AS entry_date,
+			SUM(jl.debit - jl.credit) AS amount
+		FROM journal_lines jl
+		JOIN journal_entries je ON je.id = jl.entry_id
+		WHERE jl.account_code LIKE ?
+		  AND je.entry_date >= ? AND je.entry_date < ?
+		GROUP BY 1
+		ORDER BY 1`, model.AccountCostOfGoodsSold+"%", from, to).Scan(&rows).Error
+	return rows, err
+}
+
+func (r *taxReportRepository) FindCITSetting(ctx context.Context) (*model.CITSetting, error) {
+	var setting model.CITSetting
+	if err := GetDB(ctx, r.db).First(&setting, 1).Error; err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (r *taxReportRepository) SaveCITSetting(ctx context.Context, setting *model.CITSetting) error {
+	return GetDB(ctx, r.db).Save(setting).Error
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TaxRuleRepository interface {
@@ -17,7 +18,18 @@ type TaxRuleRepository interface {
 	FindByID(ctx context.Context, id uuid.UUID) (*model.TaxRule, error)
 	List(ctx context.Context, search string, page, limit int) ([]model.TaxRule, int64, error)
 	FindActiveByType(ctx context.Context, taxType string, targetDate time.Time) (*model.TaxRule, error)
-	FindOverlapping(ctx context.Context, taxType string, from time.Time, to *time.Time, excludeID *uuid.UUID) (int64, error)
+	// ListActiveByType returns every rule of a type in effect on targetDate, whatever its conditions
+	ListActiveByType(ctx context.Context, taxType string, targetDate time.Time) ([]model.TaxRule, error)
+	// FindOverlapping counts the rules that would compete with rule on some date; with
+	// sideBySideRates, rules at another rate do not
+	FindOverlapping(ctx context.Context, rule model.TaxRule, sideBySideRates bool, excludeID *uuid.UUID) (int64, error)
+
+	CreateType(ctx context.Context, taxType *model.TaxTypeDefinition) error
+	// CreateTypeIfMissing inserts the tax type unless its code already exists
+	CreateTypeIfMissing(ctx context.Context, taxType *model.TaxTypeDefinition) error
+	UpdateType(ctx context.Context, taxType *model.TaxTypeDefinition) error
+	FindType(ctx context.Context, code string) (*model.TaxTypeDefinition, error)
+	ListTypes(ctx context.Context) ([]model.TaxTypeDefinition, error)
 }
 
 type taxRuleRepository struct {
@@ -73,31 +85,56 @@ func (r *taxRuleRepository) List(ctx context.Context, search string, page, limit
 	return rules, total, nil
 }
 
+// FindActiveByType returns the rule of a type in effect on targetDate that applies without
+// conditions. When several such rules are active at once, the highest priority wins, then the
+// latest start date, then the narrowest window.
 func (r *taxRuleRepository) FindActiveByType(ctx context.Context, taxType string, targetDate time.Time) (*model.TaxRule, error) {
 	var rule model.TaxRule
 	if err := GetDB(ctx, r.db).
 		Where("tax_type = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to >= ?)", taxType, targetDate, targetDate).
-		Order("effective_from DESC").
+		Where("COALESCE(product_category, '') = '' AND COALESCE(partner_type, '') = '' AND COALESCE(country, '') = ''").
+		Order("priority DESC, effective_from DESC, effective_to ASC NULLS LAST, created_at DESC").
 		First(&rule).Error; err != nil {
 		return nil, err
 	}
 	return &rule, nil
 }
 
-func (r *taxRuleRepository) FindOverlapping(ctx context.Context, taxType string, from time.Time, to *time.Time, excludeID *uuid.UUID) (int64, error) {
+func (r *taxRuleRepository) ListActiveByType(ctx context.Context, taxType string, targetDate time.Time) ([]model.TaxRule, error) {
+	var rules []model.TaxRule
+	if err := GetDB(ctx, r.db).
+		Where("tax_type = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to >= ?)", taxType, targetDate, targetDate).
+		Order("priority DESC, effective_from DESC, effective_to ASC NULLS LAST, created_at DESC").
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// FindOverlapping counts rules with the same type, priority and conditions as rule whose
+// validity overlaps its own. Rules for different conditions may run side by side, and so may
+// different rates of a type when sideBySideRates is set (e.g. VAT 10% standard with 5% and 8%
+// reduced); then only rules at the same rate are counted.
+func (r *taxRuleRepository) FindOverlapping(ctx context.Context, rule model.TaxRule, sideBySideRates bool, excludeID *uuid.UUID) (int64, error) {
 	var count int64
-	query := GetDB(ctx, r.db).Model(&model.TaxRule{}).Where("tax_type = ?", taxType)
+	query := GetDB(ctx, r.db).Model(&model.TaxRule{}).
+		Where("tax_type = ? AND priority = ?", rule.TaxType, rule.Priority).
+		Where("LOWER(COALESCE(product_category, '')) = LOWER(?) AND COALESCE(partner_type, '') = ? AND COALESCE(country, '') = ? AND COALESCE(hs_code, '') = ?",
+			rule.ProductCategory, rule.PartnerType, rule.Country, rule.HSCode)
 
+	if sideBySideRates {
+		query = query.Where("rate = ?", rule.Rate)
+	}
 	if excludeID != nil {
 		query = query.Where("id != ?", *excludeID)
 	}
 
-	if to != nil {
+	if rule.EffectiveTo != nil {
 		// New rule has end date: overlap if existing.from <= new.to AND (existing.to IS NULL OR existing.to >= new.from)
-		query = query.Where("effective_from <= ? AND (effective_to IS NULL OR effective_to >= ?)", *to, from)
+		query = query.Where("effective_from <= ? AND (effective_to IS NULL OR effective_to >= ?)", *rule.EffectiveTo, rule.EffectiveFrom)
 	} else {
 		// New rule has no end date: overlap if (existing.to IS NULL OR existing.to >= new.from)
-		query = query.Where("(effective_to IS NULL OR effective_to >= ?)", from)
+		query = query.Where("(effective_to IS NULL OR effective_to >= ?)", rule.EffectiveFrom)
 	}
 
 	if err := query.Count(&count).Error; err != nil {
@@ -105,3 +142,34 @@ func (r *taxRuleRepository) FindOverlapping(ctx context.Context, taxType string,
 	}
 	return count, nil
 }
+
+func (r *taxRuleRepository) CreateType(ctx context.Context, taxType *model.TaxTypeDefinition) error {
+	return GetDB(ctx, r.db).Create(taxType).Error
+}
+
+func (r *taxRuleRepository) CreateTypeIfMissing(ctx context.Context, taxType *model.TaxTypeDefinition) error {
+	return GetDB(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoNothing: true,
+	}).Create(taxType).Error
+}
+
+func (r *taxRuleRepository) UpdateType(ctx context.Context, taxType *model.TaxTypeDefinition) error {
+	return GetDB(ctx, r.db).Save(taxType).Error
+}
+
+func (r *taxRuleRepository) FindType(ctx context.Context, code string) (*model.TaxTypeDefinition, error) {
+	var taxType model.TaxTypeDefinition
+	if err := GetDB(ctx, r.db).First(&taxType, "code = ?", code).Error; err != nil {
+		return nil, err
+	}
+	return &taxType, nil
+}
+
+func (r *taxRuleRepository) ListTypes(ctx context.Context) ([]model.TaxTypeDefinition, error) {
+	var types []model.TaxTypeDefinition
+	if err := GetDB(ctx, r.db).Order("code ASC").Find(&types).Error; err != nil {
+		return nil, err
+	}
+	return types, nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type VehicleRepository interface {
+	Create(ctx context.Context, vehicle *model.Vehicle) error
+	Update(ctx context.Context, vehicle *model.Vehicle) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Vehicle, error)
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]model.Vehicle, error)
+	List(ctx context.Context, activeOnly bool) ([]model.Vehicle, error)
+}
+
+type vehicleRepository struct {
+	db *gorm.DB
+}
+
+func NewVehicleRepository(db *gorm.DB) VehicleRepository {
+	return &vehicleRepository{db: db}
+}
+
+func (r *vehicleRepository) Create(ctx context.Context, vehicle *model.Vehicle) error {
+	return GetDB(ctx, r.db).Create(vehicle).Error
+}
+
+func (r *vehicleRepository) Update(ctx context.Context, vehicle *model.Vehicle) error {
+	return GetDB(ctx, r.db).Save(vehicle).Error
+}
+
+func (r *vehicleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return GetDB(ctx, r.db).Where("id = ?", id).Delete(&model.Vehicle{}).Error
+}
+
+func (r *vehicleRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Vehicle, error) {
+	var vehicle model.Vehicle
+	if err := GetDB(ctx, r.db).First(&vehicle, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &vehicle, nil
+}
+
+func (r *vehicleRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]model.Vehicle, error) {
+	var vehicles []model.Vehicle
+	if err := GetDB(ctx, r.db).Where("id IN ?", ids).Order("plate_number ASC").Find(&vehicles).Error; err != nil {
+		return nil, err
+	}
+	return vehicles, nil
+}
+
+// List returns vehicles ordered by plate number, which is also the order route planning fills them
+func (r *vehicleRepository) List(ctx context.Context, activeOnly bool) ([]model.Vehicle, error) {
+	var vehicles []model.Vehicle
+	query := GetDB(ctx, r.db).Model(&model.Vehicle{})
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	if err := query.Order("plate_number ASC").Find(&vehicles).Error; err != nil {
+		return nil, err
+	}
+	return vehicles, nil
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/xlsx"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Aging report types
+const (
+	AgingReceivable = "receivable"
+	AgingPayable    = "payable"
+)
+
+// agingPaymentTypes maps report types to the payments settling their invoices
+var agingPaymentTypes = map[string]string{
+	AgingReceivable: model.PaymentTypeReceipt,
+	AgingPayable:    model.PaymentTypeDisbursement,
+}
+
+// agingNoPartner names the row of invoices without a partner, e.g. expenses without a vendor
+const agingNoPartner = "(no partner)"
+
+// agingBuckets are the bucket codes in report order; days overdue of 0 or less are current
+var agingBuckets = []string{"current", "1-30", "31-60", "61-90", "90+"}
+
+// --- DTOs ---
+
+type AgingFilter struct {
+	Type      string // receivable, payable
+	AsOf      string // YYYY-MM-DD, today when empty
+	PartnerID string
+	Bucket    string // Drill-down only: one of agingBuckets
+}
+
+// AgingBucketsResponse holds outstanding base-currency amounts by days past due
+type AgingBucketsResponse struct {
+	Current    string `json:"current"`
+	Days1To30  string `json:"days_1_30"`
+	Days31To60 string `json:"days_31_60"`
+	Days61To90 string `json:"days_61_90"`
+	Over90     string `json:"days_over_90"`
+	Total      string `json:"total"`
+}
+
+type AgingPartnerResponse struct {
+	PartnerID    string               `json:"partner_id"`
+	PartnerName  string               `json:"partner_name"`
+	InvoiceCount int                  `json:"invoice_count"`
+	Buckets      AgingBucketsResponse `json:"buckets"`
+	Unallocated  string               `json:"unallocated_amount"` // Payments not yet allocated to invoices
+	NetBalance   string               `json:"net_balance"`        // Total outstanding − unallocated
+}
+
+type AgingReportResponse struct {
+	Type     string                 `json:"type"`
+	AsOf     string                 `json:"as_of"`
+	Partners []AgingPartnerResponse `json:"partners"`
+	Totals   AgingPartnerResponse   `json:"totals"`
+}
+
+type AgingInvoiceResponse struct {
+	InvoiceID         string `json:"invoice_id"`
+	InvoiceNo         string `json:"invoice_no"`
+	ReferenceType     string `json:"reference_type"`
+	PartnerID         string `json:"partner_id"`
+	PartnerName       string `json:"partner_name"`
+	InvoiceDate       string `json:"invoice_date"`
+	DueDate           string `json:"due_date"`
+	DaysOverdue       int    `json:"days_overdue"`
+	Bucket            string `json:"bucket"`
+	NetAmount         string `json:"net_amount"`
+	PaidAmount        string `json:"paid_amount"`
+	OutstandingAmount string `json:"outstanding_amount"`
+}
+
+// --- Implementation ---
+
+// agingTotals accumulates outstanding amounts per bucket, in agingBuckets order
+type agingTotals struct {
+	buckets      [5]decimal.Decimal
+	invoiceCount int
+	unallocated  decimal.Decimal
+}
+
+func (t *agingTotals) add(bucket int, amount decimal.Decimal) {
+	t.buckets[bucket] = t.buckets[bucket].Add(amount)
+	t.invoiceCount++
+}
+
+func (t *agingTotals) total() decimal.Decimal {
+	sum := decimal.Zero
+	for _, b := range t.buckets {
+		sum = sum.Add(b)
+	}
+	return sum
+}
+
+// GetAgingReport buckets the invoices open at the end of the as-of date per partner, by the
+// days between their due date and that date. Amounts are in the base currency.
+func (s *revenueService) GetAgingReport(ctx context.Context, filter AgingFilter) (AgingReportResponse, error) {
+	asOf, rows, err := s.agingInvoices(ctx, filter)
+	if err != nil {
+		return AgingReportResponse{}, err
+	}
+	partnerID, _ := parseAgingPartner(filter.PartnerID)
+	unallocated, err := s.revenueRepo.GetAgingUnallocated(ctx, agingPaymentTypes[filter.Type], asOf.AddDate(0, 0, 1), partnerID)
+	if err != nil {
+		return AgingReportResponse{}, err
+	}
+
+	byPartner := make(map[uuid.UUID]*agingTotals)
+	names := make(map[uuid.UUID]string)
+	var order []uuid.UUID
+	partnerTotals := func(id uuid.UUID, name string) *agingTotals {
+		t, ok := byPartner[id]
+		if !ok {
+			t = &agingTotals{}
+			byPartner[id] = t
+			names[id] = name
+			order = append(order, id)
+		}
+		return t
+	}
+
+	var totals agingTotals
+	for _, row := range rows {
+		bucket := agingBucket(agingDaysOverdue(asOf, row.DueDate))
+		id, name := agingPartner(row)
+		partnerTotals(id, name).add(bucket, row.OutstandingAmount)
+		totals.add(bucket, row.OutstandingAmount)
+	}
+	for _, u := range unallocated {
+		partnerTotals(u.PartnerID, u.PartnerName).unallocated = u.Amount
+		totals.unallocated = totals.unallocated.Add(u.Amount)
+	}
+
+	partners := make([]AgingPartnerResponse, 0, len(order))
+	for _, id := range order {
+		partnerID := ""
+		if id != uuid.Nil {
+			partnerID = id.String()
+		}
+		partners = append(partners, toAgingPartnerResponse(partnerID, names[id], byPartner[id]))
+	}
+
+	return AgingReportResponse{
+		Type:     filter.Type,
+		AsOf:     asOf.Format("2006-01-02"),
+		Partners: partners,
+		Totals:   toAgingPartnerResponse("", "", &totals),
+	}, nil
+}
+
+// GetAgingInvoices lists the open invoices behind an aging report, optionally limited to one
+// partner and one bucket
+func (s *revenueService) GetAgingInvoices(ctx context.Context, filter AgingFilter) ([]AgingInvoiceResponse, error) {
+	bucket := -1
+	if filter.Bucket != "" {
+		for i, code := range agingBuckets {
+			if code == filter.Bucket {
+				bucket = i
+			}
+		}
+		if bucket < 0 {
+			return nil, fmt.Errorf("invalid bucket %q; use current, 1-30, 31-60, 61-90 or 90+", filter.Bucket)
+		}
+	}
+
+	asOf, rows, err := s.agingInvoices(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]AgingInvoiceResponse, 0, len(rows))
+	for _, row := range rows {
+		days := agingDaysOverdue(asOf, row.DueDate)
+		if bucket >= 0 && agingBucket(days) != bucket {
+			continue
+		}
+		partnerID, partnerName := "", agingNoPartner
+		if row.PartnerID != nil {
+			partnerID, partnerName = row.PartnerID.String(), row.PartnerName
+		}
+		result = append(result, AgingInvoiceResponse{
+			InvoiceID:         row.InvoiceID.String(),
+			InvoiceNo:         row.InvoiceNo,
+			ReferenceType:     row.ReferenceType,
+			PartnerID:         partnerID,
+			PartnerName:       partnerName,
+			InvoiceDate:       row.InvoiceDate.Format("2006-01-02"),
+			DueDate:           row.DueDate.Format("2006-01-02"),
+			DaysOverdue:       days,
+			Bucket:            agingBuckets[agingBucket(days)],
+			NetAmount:         row.NetAmount.StringFixed(4),
+			PaidAmount:        row.PaidAmount.StringFixed(4),
+			OutstandingAmount: row.OutstandingAmount.StringFixed(4),
+		})
+	}
+	return result, nil
+}
+
+// ExportAgingReport renders the aging report per partner, or its invoices when detail is set,
+// as CSV or XLSX. It returns the file, its name and content type.
+func (s *revenueService) ExportAgingReport(ctx context.Context, filter AgingFilter, format string, detail bool) ([]byte, string, string, error) {
+	if format != "csv" && format != "xlsx" {
+		return nil, "", "", fmt.Errorf("invalid format %q; use csv or xlsx", format)
+	}
+
+	var table [][]interface{}
+	var asOf string
+	if detail {
+		invoices, err := s.GetAgingInvoices(ctx, filter)
+		if err != nil {
+			return nil, "", "", err
+		}
+		table = append(table, []interface{}{"partner", "invoice_no", "reference_type", "invoice_date", "due_date", "days_overdue", "bucket", "net_amount", "paid_amount", "outstanding_amount"})
+		for _, inv := range invoices {
+			table = append(table, []interface{}{
+				inv.PartnerName, inv.InvoiceNo, inv.ReferenceType, inv.InvoiceDate, inv.DueDate, inv.DaysOverdue, inv.Bucket,
+				xlsx.Number(inv.NetAmount), xlsx.Number(inv.PaidAmount), xlsx.Number(inv.OutstandingAmount),
+			})
+		}
+		asOf = filter.AsOf
+	} else {
+		report, err := s.GetAgingReport(ctx, filter)
+		if err != nil {
+			return nil, "", "", err
+		}
+		table = append(table, []interface{}{"partner", "invoice_count", "current", "1-30", "31-60", "61-90", "90+", "total", "unallocated", "net_balance"})
+		report.Totals.PartnerName = "TOTAL"
+		for _, p := range append(report.Partners, report.Totals) {
+			table = append(table, []interface{}{
+				p.PartnerName, p.InvoiceCount,
+				xlsx.Number(p.Buckets.Current), xlsx.Number(p.Buckets.Days1To30), xlsx.Number(p.Buckets.Days31To60),
+				xlsx.Number(p.Buckets.Days61To90), xlsx.Number(p.Buckets.Over90), xlsx.Number(p.Buckets.Total),
+				xlsx.Number(p.Unallocated), xlsx.Number(p.NetBalance),
+			})
+		}
+		asOf = report.AsOf
+	}
+	if asOf == "" {
+		asOf = time.Now().UTC().Format("2006-01-02")
+	}
+
+	name := fmt.Sprintf("aging-%s-%s", filter.Type, asOf)
+	if detail {
+		name += "-invoices"
+	}
+
+	if format == "xlsx" {
+		data, err := xlsx.Write(xlsx.Sheet{Name: name, Header: true, Rows: table})
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to write aging workbook: %w", err)
+		}
+		return data, name + ".xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for _, row := range table {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = fmt.Sprint(v)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, "", "", fmt.Errorf("failed to write aging file: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, "", "", fmt.Errorf("failed to write aging file: %w", err)
+	}
+	return buf.Bytes(), name + ".csv", "text/csv", nil
+}
+
+// agingInvoices validates the filter and loads the invoices open at the end of the as-of date
+func (s *revenueService) agingInvoices(ctx context.Context, filter AgingFilter) (time.Time, []repository.AgingInvoiceRow, error) {
+	paymentType, ok := agingPaymentTypes[filter.Type]
+	if !ok {
+		return time.Time{}, nil, fmt.Errorf("invalid aging type %q; use receivable or payable", filter.Type)
+	}
+
+	asOf := time.Now().UTC().Truncate(24 * time.Hour)
+	if filter.AsOf != "" {
+		var err error
+		asOf, err = time.Parse("2006-01-02", filter.AsOf)
+		if err != nil {
+			return time.Time{}, nil, errors.New("invalid as_of, expected YYYY-MM-DD")
+		}
+	}
+	partnerID, err := parseAgingPartner(filter.PartnerID)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	rows, err := s.revenueRepo.GetAgingInvoices(ctx, paymentReferenceTypes[paymentType], asOf.AddDate(0, 0, 1), partnerID)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	return asOf, rows, nil
+}
+
+// agingPartner returns the partner an invoice is totalled under; invoices without a partner share
+// one row under uuid.Nil
+func agingPartner(row repository.AgingInvoiceRow) (uuid.UUID, string) {
+	if row.PartnerID == nil {
+		return uuid.Nil, agingNoPartner
+	}
+	return *row.PartnerID, row.PartnerName
+}
+
+func parseAgingPartner(id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	partnerID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid partner_id: %w", err)
+	}
+	return &partnerID, nil
+}
+
+// agingDaysOverdue counts calendar days from the due date to the as-of date
+func agingDaysOverdue(asOf, due time.Time) int {
+	dueDay := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC)
+	return int(asOf.Sub(dueDay).Hours() / 24)
+}
+
+// agingBucket returns the index in agingBuckets for a number of days overdue
+func agingBucket(days int) int {
+	switch {
+	case days <= 0:
+		return 0
+	case days <= 30:
+		return 1
+	case days <= 60:
+		return 2
+	case days <= 90:
+		return 3
+	}
+	return 4
+}
+
+// --- Mapping ---
+
+func toAgingPartnerResponse(id, name string, t *agingTotals) AgingPartnerResponse {
+	total := t.total()
+	return AgingPartnerResponse{
+		PartnerID:    id,
+		PartnerName:  name,
+		InvoiceCount: t.invoiceCount,
+		Buckets: AgingBucketsResponse{
+			Current:    t.buckets[0].StringFixed(4),
+			Days1To30:  t.buckets[1].StringFixed(4),
+			Days31To60: t.buckets[2].StringFixed(4),
+			Days61To90: t.buckets[3].StringFixed(4),
+			Over90:     t.buckets[4].StringFixed(4),
+			Total:      total.StringFixed(4),
+		},
+		Unallocated: t.unallocated.StringFixed(4),
+		NetBalance:  total.Sub(t.unallocated).StringFixed(4),
+	}
+}
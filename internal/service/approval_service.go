@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -67,6 +68,12 @@ type approvalService struct {
 	taxRuleRepo  repository.TaxRuleRepository
 	invTxRepo    repository.InventoryTxRepository
 	partnerRepo  repository.PartnerRepository
+	fulfillRepo  repository.FulfillmentRepository
+	costingRepo  repository.CostingRepository
+	sequenceRepo repository.DocumentSequenceRepository
+	ledgerRepo   repository.LedgerRepository
+	periodRepo   repository.FiscalPeriodRepository
+	paymentRepo  repository.PaymentRepository
 	txManager    repository.TransactionManager
 }
 
@@ -80,6 +87,12 @@ func NewApprovalService(
 	taxRuleRepo repository.TaxRuleRepository,
 	invTxRepo repository.InventoryTxRepository,
 	partnerRepo repository.PartnerRepository,
+	fulfillRepo repository.FulfillmentRepository,
+	costingRepo repository.CostingRepository,
+	sequenceRepo repository.DocumentSequenceRepository,
+	ledgerRepo repository.LedgerRepository,
+	periodRepo repository.FiscalPeriodRepository,
+	paymentRepo repository.PaymentRepository,
 	txManager repository.TransactionManager,
 ) ApprovalService {
 	return &approvalService{
@@ -92,6 +105,12 @@ func NewApprovalService(
 		taxRuleRepo:  taxRuleRepo,
 		invTxRepo:    invTxRepo,
 		partnerRepo:  partnerRepo,
+		fulfillRepo:  fulfillRepo,
+		costingRepo:  costingRepo,
+		sequenceRepo: sequenceRepo,
+		ledgerRepo:   ledgerRepo,
+		periodRepo:   periodRepo,
+		paymentRepo:  paymentRepo,
 		txManager:    txManager,
 	}
 }
@@ -206,7 +225,7 @@ func (s *approvalService) ApproveRequest(ctx context.Context, id string, userID
 	var approval *model.ApprovalRequest
 	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
 		var findErr error
-		approval, findErr = s.approvalRepo.FindByID(txCtx, approvalID)
+		approval, findErr = s.approvalRepo.FindByIDForUpdate(txCtx, approvalID)
 		if findErr != nil {
 			return fmt.Errorf("approval request not found: %w", findErr)
 		}
@@ -215,7 +234,11 @@ func (s *approvalService) ApproveRequest(ctx context.Context, id string, userID
 			return fmt.Errorf("approval request is already %s", approval.Status)
 		}
 
+		// The approval is the accounting date of the documents it creates
 		now := time.Now()
+		if periodErr := checkPeriodOpen(txCtx, s.periodRepo, now, true); periodErr != nil {
+			return periodErr
+		}
 		approval.Status = model.ApprovalApproved
 		approval.ApprovedBy = &approverID
 		approval.ApprovedAt = &now
@@ -249,6 +272,13 @@ func (s *approvalService) ApproveRequest(ctx context.Context, id string, userID
 	})
 
 	if err != nil {
+		// The approval was rolled back; the order is held so that it can be overridden
+		var limitErr *creditLimitError
+		if errors.As(err, &limitErr) {
+			if holdErr := s.placeCreditHold(ctx, limitErr, userID); holdErr != nil {
+				return ApprovalRequestResponse{}, holdErr
+			}
+		}
 		return ApprovalRequestResponse{}, err
 	}
 
@@ -261,6 +291,32 @@ func (s *approvalService) ApproveRequest(ctx context.Context, id string, userID
 	return toApprovalResponse(*reloaded), nil
 }
 
+// creditLimitError stops the approval of an export order that no longer fits its customer's
+// credit limit, e.g. because other orders were approved or invoiced since it was created
+type creditLimitError struct {
+	order model.Order
+	check *creditCheck
+}
+
+func (e *creditLimitError) Error() string {
+	return fmt.Sprintf("order %s has been put on credit hold (%s); a credit override is required before approval",
+		e.order.OrderCode, e.check.reason())
+}
+
+// placeCreditHold puts an order that failed the credit check on approval on credit hold, as if
+// it had failed it on creation
+func (s *approvalService) placeCreditHold(ctx context.Context, e *creditLimitError, userID string) error {
+	return s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		if err := s.orderRepo.UpdateColumns(txCtx, e.order.ID, map[string]interface{}{
+			"credit_hold":        true,
+			"credit_hold_reason": e.check.reason(),
+		}); err != nil {
+			return fmt.Errorf("failed to put order on credit hold: %w", err)
+		}
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionCreditHold, e.order.ID.String(), e.order.OrderCode, e.check.details()))
+	})
+}
+
 func (s *approvalService) RejectRequest(ctx context.Context, id string, userID string, reason string) (ApprovalRequestResponse, error) {
 	approvalID, err := uuid.Parse(id)
 	if err != nil {
@@ -275,7 +331,7 @@ func (s *approvalService) RejectRequest(ctx context.Context, id string, userID s
 	var approval *model.ApprovalRequest
 	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
 		var findErr error
-		approval, findErr = s.approvalRepo.FindByID(txCtx, approvalID)
+		approval, findErr = s.approvalRepo.FindByIDForUpdate(txCtx, approvalID)
 		if findErr != nil {
 			return fmt.Errorf("approval request not found: %w", findErr)
 		}
@@ -353,6 +409,20 @@ func (s *approvalService) executeOrderApproval(ctx context.Context, approval mod
 	if err != nil {
 		return fmt.Errorf("order not found: %w", err)
 	}
+	if order.CreditHold && order.CreditOverrideAt == nil {
+		return fmt.Errorf("order %s is on credit hold (%s); a credit override is required before approval", order.OrderCode, order.CreditHoldReason)
+	}
+	// Receivables and other orders may have grown since the order passed the check on creation;
+	// orders released by an override are not checked again
+	if !order.CreditHold {
+		check, checkErr := checkCreditLimit(ctx, s.paymentRepo, s.orderRepo, order.Type, order.Partner, order.TotalAmountBase, order.ID)
+		if checkErr != nil {
+			return checkErr
+		}
+		if check != nil && check.exceeded() {
+			return &creditLimitError{order: *order, check: check}
+		}
+	}
 
 	// Parse request data for tax info
 	var reqData struct {
@@ -362,6 +432,7 @@ func (s *approvalService) executeOrderApproval(ctx context.Context, approval mod
 	json.Unmarshal([]byte(approval.RequestData), &reqData)
 
 	// Process each order item — update stock + create inventory transactions
+	costOfSales, landedOfSales := decimal.Zero, decimal.Zero
 	for _, item := range order.Items {
 		product, findErr := s.productRepo.FindByIDForUpdate(ctx, item.ProductID)
 		if findErr != nil {
@@ -387,18 +458,31 @@ func (s *approvalService) executeOrderApproval(ctx context.Context, approval mod
 			return fmt.Errorf("failed to update stock for product %s: %w", product.Name, updateErr)
 		}
 
-		// Create inventory transaction
+		// Receipts open a cost layer; issues consume layers FIFO
 		txType := model.TxTypeIn
+		var costAmount decimal.Decimal
+		var costErr error
 		if order.Type == model.OrderTypeExport {
 			txType = model.TxTypeOut
+			var landed decimal.Decimal
+			costAmount, landed, costErr = consumeCostLayers(ctx, s.costingRepo, product.ID, item.Quantity)
+			costOfSales = costOfSales.Add(costAmount)
+			landedOfSales = landedOfSales.Add(landed)
+		} else {
+			costAmount, costErr = receiveCostLayer(ctx, s.costingRepo, order.ID, item, order.ExchangeRate, time.Now())
+		}
+		if costErr != nil {
+			return costErr
 		}
 
+		// Create inventory transaction
 		invTx := &model.InventoryTransaction{
 			ProductID:       product.ID,
 			OrderID:         &order.ID,
 			TransactionType: txType,
 			QuantityChanged: quantityChanged,
 			StockAfter:      stockAfter,
+			CostAmount:      costAmount,
 		}
 		if createErr := s.invTxRepo.Create(ctx, invTx); createErr != nil {
 			return fmt.Errorf("failed to record inventory transaction: %w", createErr)
@@ -410,34 +494,36 @@ func (s *approvalService) executeOrderApproval(ctx context.Context, approval mod
 		return fmt.Errorf("failed to update order status: %w", updateErr)
 	}
 
-	// Create invoice
-	subtotal := decimal.Zero
-	for _, item := range order.Items {
-		subtotal = subtotal.Add(decimal.NewFromFloat(item.UnitPrice).Mul(decimal.NewFromInt(int64(item.Quantity))))
-	}
-
-	sideFees := decimal.Zero
-	if reqData.SideFees != "" {
-		if parsed, parseErr := decimal.NewFromString(reqData.SideFees); parseErr == nil {
-			sideFees = parsed
+	// EXPORT orders enter the warehouse fulfillment flow with a generated pick list
+	if order.Type == model.OrderTypeExport {
+		if _, pickErr := createPickListForOrder(ctx, s.fulfillRepo, s.sequenceRepo, s.productRepo, s.orderRepo, s.auditRepo, order, approverID); pickErr != nil {
+			return pickErr
 		}
 	}
 
-	taxAmount := decimal.Zero
-	var taxRuleID *uuid.UUID
+	// Create invoice — each line is taxed with its item's rule, the order-level rule or the rule
+	// evaluated for its product and the partner on the approval date
+	var fallbackRuleID *uuid.UUID
 	if reqData.TaxRuleID != "" {
 		if parsed, parseErr := uuid.Parse(reqData.TaxRuleID); parseErr == nil {
-			taxRuleID = &parsed
-			taxRule, findErr := s.taxRuleRepo.FindByID(ctx, parsed)
-			if findErr == nil {
-				taxAmount = subtotal.Mul(taxRule.Rate)
-			}
+			fallbackRuleID = &parsed
 		}
 	}
+	taxLine := orderTaxLine(order.Type, order.Partner, *approval.ApprovedAt)
+	lines, err := buildOrderInvoiceLines(ctx, s.taxRuleRepo, order.Items, fallbackRuleID, taxLine, order.Currency)
+	if err != nil {
+		return err
+	}
+	subtotal, taxAmount, taxRuleID := sumInvoiceLines(lines)
 
-	totalAmount := subtotal.Add(taxAmount).Add(sideFees)
+	sideFees := decimal.Zero
+	if reqData.SideFees != "" {
+		if parsed, parseErr := decimal.NewFromString(reqData.SideFees); parseErr == nil {
+			sideFees = parsed
+		}
+	}
 
-	invoiceNo, err := s.generateInvoiceNo(ctx)
+	invoiceNo, err := nextDocumentNo(ctx, s.sequenceRepo, model.SequenceInvoice, time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to generate invoice number: %w", err)
 	}
@@ -449,35 +535,26 @@ func (s *approvalService) executeOrderApproval(ctx context.Context, approval mod
 
 	invoice := &model.Invoice{
 		InvoiceNo:      invoiceNo,
+		InvoiceType:    model.InvoiceTypeStandard,
 		ReferenceType:  refType,
 		ReferenceID:    order.ID,
 		TaxRuleID:      taxRuleID,
 		Subtotal:       subtotal,
 		TaxAmount:      taxAmount,
 		SideFees:       sideFees,
-		TotalAmount:    totalAmount,
+		Currency:       order.Currency,
+		ExchangeRate:   order.ExchangeRate,
 		ApprovalStatus: model.ApprovalApproved,
 		ApprovedBy:     approverID,
 		ApprovedAt:     approval.ApprovedAt,
 		Note:           order.Note,
+		Lines:          lines,
 	}
+	// Items are priced in the order currency; the invoice is booked in the base currency at the order's rate
+	convertInvoiceToBase(invoice)
 
 	// Populate partner hard-copy fields from the order's partner
-	if order.PartnerID != nil {
-		invoice.PartnerID = order.PartnerID
-		partner, partnerErr := s.partnerRepo.FindByID(ctx, *order.PartnerID)
-		if partnerErr == nil {
-			invoice.CompanyName = partner.CompanyName
-			invoice.TaxCode = partner.TaxCode
-			// Find first BILLING address
-			for _, addr := range partner.Addresses {
-				if addr.AddressType == model.AddressTypeBilling {
-					invoice.BillingAddress = addr.FullAddress
-					break
-				}
-			}
-		}
-	}
+	s.applyInvoicePartner(ctx, invoice, order.PartnerID, *approval.ApprovedAt)
 	if createErr := s.invoiceRepo.Create(ctx, invoice); createErr != nil {
 		return fmt.Errorf("failed to create invoice: %w", createErr)
 	}
@@ -485,7 +562,9 @@ func (s *approvalService) executeOrderApproval(ctx context.Context, approval mod
 	// Audit log for invoice creation
 	invoiceDetails, _ := json.Marshal(map[string]interface{}{
 		"invoice_no": invoiceNo,
-		"total":      totalAmount.StringFixed(4),
+		"total":      invoice.TotalAmount.StringFixed(4),
+		"currency":   invoice.Currency,
+		"doc_total":  invoice.DocTotalAmount.StringFixed(4),
 		"order_code": order.OrderCode,
 		"order_type": order.Type,
 	})
@@ -500,38 +579,84 @@ func (s *approvalService) executeOrderApproval(ctx context.Context, approval mod
 		return fmt.Errorf("failed to write invoice audit log: %w", auditErr)
 	}
 
+	// Post the invoice and, for exports, the cost of the goods issued
+	if postErr := postInvoiceJournal(ctx, s.ledgerRepo, s.sequenceRepo, s.expenseRepo, s.taxRuleRepo, *invoice, approverID); postErr != nil {
+		return postErr
+	}
+	if order.Type == model.OrderTypeExport {
+		return postCostOfSalesJournal(ctx, s.ledgerRepo, s.sequenceRepo, *order, costOfSales, landedOfSales, *approval.ApprovedAt, approverID)
+	}
 	return nil
 }
 
+// applyInvoicePartner snapshots the partner on an invoice generated on approval and sets its
+// due date from the partner's payment terms
+func (s *approvalService) applyInvoicePartner(ctx context.Context, invoice *model.Invoice, partnerID *uuid.UUID, approvedAt time.Time) {
+	var partner *model.Partner
+	if partnerID != nil {
+		invoice.PartnerID = partnerID
+		if found, err := s.partnerRepo.FindByID(ctx, *partnerID); err == nil {
+			partner = found
+			invoice.CompanyName = partner.CompanyName
+			invoice.TaxCode = partner.TaxCode
+			// Find first BILLING address
+			for _, addr := range partner.Addresses {
+				if addr.AddressType == model.AddressTypeBilling {
+					invoice.BillingAddress = addr.FullAddress
+					break
+				}
+			}
+		}
+	}
+	invoice.DueDate = invoiceDueDate(partner, approvedAt)
+}
+
 func (s *approvalService) executeExpenseApproval(ctx context.Context, approval model.ApprovalRequest, approverID *uuid.UUID) error {
 	expense, err := s.expenseRepo.FindByID(ctx, approval.ReferenceID)
 	if err != nil {
 		return fmt.Errorf("expense not found: %w", err)
 	}
 
-	invoiceNo, genErr := s.generateInvoiceNo(ctx)
+	invoiceNo, genErr := nextDocumentNo(ctx, s.sequenceRepo, model.SequenceInvoice, time.Now())
 	if genErr != nil {
 		return fmt.Errorf("failed to generate invoice number: %w", genErr)
 	}
 
-	subtotal := expense.ConvertedAmountUSD
+	subtotal := expense.ConvertedAmount
 	taxAmount := expense.VATAmount.Add(expense.FCTAmount)
 	totalAmount := subtotal.Add(taxAmount)
 
+	// Taxes are computed in the base currency; the invoice carries them in the expense currency too
+	docTaxAmount := taxAmount
+	if expense.Currency != baseCurrency {
+		docTaxAmount = roundAmount(taxAmount.Div(expense.ExchangeRate), expense.Currency)
+	}
+
 	invoice := &model.Invoice{
 		InvoiceNo:      invoiceNo,
+		InvoiceType:    model.InvoiceTypeStandard,
 		ReferenceType:  model.RefTypeExpense,
 		ReferenceID:    expense.ID,
 		Subtotal:       subtotal,
 		TaxAmount:      taxAmount,
 		SideFees:       decimal.Zero,
 		TotalAmount:    totalAmount,
+		Currency:       expense.Currency,
+		ExchangeRate:   expense.ExchangeRate,
+		DocSubtotal:    expense.OriginalAmount,
+		DocTaxAmount:   docTaxAmount,
+		DocSideFees:    decimal.Zero,
+		DocTotalAmount: expense.OriginalAmount.Add(docTaxAmount),
 		ApprovalStatus: model.ApprovalApproved,
 		ApprovedBy:     approverID,
 		ApprovedAt:     approval.ApprovedAt,
 		Note:           expense.Description,
+		Lines:          buildExpenseInvoiceLines(*expense),
 	}
 
+	// The vendor is the partner the expense is payable to
+	s.applyInvoicePartner(ctx, invoice, expense.VendorID, *approval.ApprovedAt)
+
 	if createErr := s.invoiceRepo.Create(ctx, invoice); createErr != nil {
 		return fmt.Errorf("failed to create invoice from expense: %w", createErr)
 	}
@@ -553,19 +678,7 @@ func (s *approvalService) executeExpenseApproval(ctx context.Context, approval m
 		return fmt.Errorf("failed to write invoice audit log: %w", auditErr)
 	}
 
-	return nil
-}
-
-func (s *approvalService) generateInvoiceNo(ctx context.Context) (string, error) {
-	today := time.Now().Format("20060102")
-	prefix := "INV-" + today + "-"
-
-	count, err := s.invoiceRepo.CountByPrefix(ctx, prefix)
-	if err != nil {
-		return "", err
-	}
-
-	return fmt.Sprintf("%s%05d", prefix, count+1), nil
+	return postInvoiceJournal(ctx, s.ledgerRepo, s.sequenceRepo, s.expenseRepo, s.taxRuleRepo, *invoice, approverID)
 }
 
 // --- Helpers ---
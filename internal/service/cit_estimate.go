@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Reasons an expense is not deductible for CIT
+const (
+	CITReasonMissingInvoice = "MISSING_INVOICE" // No VAT invoice (or other accepted document) supports the expense
+	CITReasonCashOverLimit  = "CASH_OVER_LIMIT" // Paid in cash although the purchase reaches the cash payment limit
+)
+
+// defaultCITSetting are the rules of the CIT estimate until they are saved: the standard 20%
+// rate, and purchases of 20 million dong or more (VAT included) must be paid by bank transfer
+func defaultCITSetting() model.CITSetting {
+	return model.CITSetting{
+		ID:               1,
+		Rate:             decimal.NewFromFloat(0.20),
+		CashPaymentLimit: decimal.NewFromInt(20000000),
+		DocumentTypes:    model.DocTypeVATInvoice + "," + model.DocTypeDirectInvoice,
+	}
+}
+
+// --- DTOs ---
+
+type CITSettingsResponse struct {
+	Rate             string   `json:"rate"`
+	CashPaymentLimit string   `json:"cash_payment_limit"` // VND, VAT included
+	DocumentTypes    []string `json:"document_types"`     // Expense documents that support a deduction
+	UpdatedAt        *string  `json:"updated_at"`         // Nil while the defaults apply
+}
+
+// UpdateCITSettingsRequest changes the rules of the CIT estimate; omitted fields are kept
+type UpdateCITSettingsRequest struct {
+	Rate             *string  `json:"rate"`               // Decimal string, e.g. "0.20"
+	CashPaymentLimit *string  `json:"cash_payment_limit"` // VND
+	DocumentTypes    []string `json:"document_types" binding:"omitempty,dive,oneof=VAT_INVOICE DIRECT_INVOICE RETAIL_RECEIPT"`
+}
+
+type CITReasonResponse struct {
+	Code        string `json:"code"` // MISSING_INVOICE, CASH_OVER_LIMIT
+	Description string `json:"description"`
+}
+
+type CITExpenseResponse struct {
+	ExpenseID    string              `json:"expense_id"`
+	InvoiceNo    string              `json:"invoice_no"`
+	InvoiceDate  string              `json:"invoice_date"`
+	VendorName   string              `json:"vendor_name"`
+	Description  string              `json:"description"`
+	DocumentType string              `json:"document_type"`
+	Amount       string              `json:"amount"`        // Cost for CIT: the converted amount, plus the FCT borne for NET contracts
+	InvoiceTotal string              `json:"invoice_total"` // VAT included, compared with the cash payment limit
+	CashPaid     string              `json:"cash_paid"`
+	Deductible   bool                `json:"deductible"`
+	Reasons      []CITReasonResponse `json:"reasons"`
+}
+
+// CITReasonSummaryResponse totals the non-deductible expenses of a reason; an expense with
+// several reasons counts under each
+type CITReasonSummaryResponse struct {
+	Code   string `json:"code"`
+	Count  int    `json:"count"`
+	Amount string `json:"amount"`
+}
+
+// CITEstimateResponse is the provisional corporate income tax of a quarter, in whole dong:
+// taxable revenue of the approved sales invoices and notes, less the cost of goods sold and the
+// deductible expenses whose invoice was approved in the quarter. Expenses of an order are not
+// listed; they are part of the cost of the goods and deducted through cost of goods sold.
+type CITEstimateResponse struct {
+	Period                string                     `json:"period"`
+	From                  string                     `json:"from"`
+	To                    string                     `json:"to"`
+	Currency              string                     `json:"currency"`
+	Settings              CITSettingsResponse        `json:"settings"`
+	InvoiceCount          int                        `json:"invoice_count"`
+	TaxableRevenue        string                     `json:"taxable_revenue"`
+	CostOfGoodsSold       string                     `json:"cost_of_goods_sold"`
+	DeductibleExpenses    string                     `json:"deductible_expenses"`
+	NonDeductibleExpenses string                     `json:"non_deductible_expenses"`
+	NonDeductibleReasons  []CITReasonSummaryResponse `json:"non_deductible_reasons"`
+	TaxableIncome         string                     `json:"taxable_income"` // Negative for a loss
+	EstimatedTax          string                     `json:"estimated_tax"`  // Zero for a loss
+	Expenses              []CITExpenseResponse       `json:"expenses"`
+}
+
+// --- Implementation ---
+
+func (s *taxReportService) GetCITSettings(ctx context.Context) (CITSettingsResponse, error) {
+	setting, err := s.loadCITSetting(ctx)
+	if err != nil {
+		return CITSettingsResponse{}, err
+	}
+	return toCITSettingsResponse(setting), nil
+}
+
+func (s *taxReportService) UpdateCITSettings(ctx context.Context, userID string, req UpdateCITSettingsRequest) (CITSettingsResponse, error) {
+	setting, err := s.loadCITSetting(ctx)
+	if err != nil {
+		return CITSettingsResponse{}, err
+	}
+
+	if req.Rate != nil {
+		rate, err := decimal.NewFromString(*req.Rate)
+		if err != nil || rate.IsNegative() || rate.GreaterThan(decimal.NewFromInt(1)) {
+			return CITSettingsResponse{}, errors.New("rate must be a decimal between 0 and 1")
+		}
+		setting.Rate = rate
+	}
+	if req.CashPaymentLimit != nil {
+		limit, err := decimal.NewFromString(*req.CashPaymentLimit)
+		if err != nil || !limit.IsPositive() {
+			return CITSettingsResponse{}, errors.New("cash_payment_limit must be a positive amount")
+		}
+		setting.CashPaymentLimit = limit.Round(0)
+	}
+	if req.DocumentTypes != nil {
+		setting.DocumentTypes = joinDocumentTypes(req.DocumentTypes)
+	}
+	setting.UpdatedBy = parseOptionalUUID(userID)
+
+	if err := s.taxReportRepo.SaveCITSetting(ctx, &setting); err != nil {
+		return CITSettingsResponse{}, fmt.Errorf("failed to save CIT rules: %w", err)
+	}
+
+	_ = s.auditRepo.Log(ctx, newAuditLog(userID, model.ActionUpdateCITRules, "1", "CIT rules", req))
+
+	return toCITSettingsResponse(setting), nil
+}
+
+func (s *taxReportService) GetCITEstimate(ctx context.Context, code string) (CITEstimateResponse, error) {
+	period, err := parseTaxPeriod(code)
+	if err != nil {
+		return CITEstimateResponse{}, err
+	}
+	if period.Type != TaxPeriodQuarter {
+		return CITEstimateResponse{}, errors.New("CIT is estimated per quarter; use YYYY-Qn")
+	}
+	setting, err := s.loadCITSetting(ctx)
+	if err != nil {
+		return CITEstimateResponse{}, err
+	}
+
+	revenueRows, err := s.taxReportRepo.ListCITRevenue(ctx, model.RefTypeOrderExport, period.From, period.To)
+	if err != nil {
+		return CITEstimateResponse{}, fmt.Errorf("failed to fetch sales invoices: %w", err)
+	}
+	costRows, err := s.taxReportRepo.ListCostOfSales(ctx, period.From, period.To)
+	if err != nil {
+		return CITEstimateResponse{}, fmt.Errorf("failed to fetch cost of goods sold: %w", err)
+	}
+	expenseRows, err := s.taxReportRepo.ListCITExpenses(ctx, period.From, period.To)
+	if err != nil {
+		return CITEstimateResponse{}, fmt.Errorf("failed to fetch expenses: %w", err)
+	}
+
+	conv := s.newVNDConverter(ctx)
+	revenue, cost := decimal.Zero, decimal.Zero
+	for _, r := range revenueRows {
+		amount, err := conv.convert(r.Revenue, r.InvoiceDate)
+		if err != nil {
+			return CITEstimateResponse{}, err
+		}
+		revenue = revenue.Add(amount)
+	}
+	for _, r := range costRows {
+		amount, err := conv.convert(r.Amount, r.EntryDate)
+		if err != nil {
+			return CITEstimateResponse{}, err
+		}
+		cost = cost.Add(amount)
+	}
+
+	accepted := strings.Split(setting.DocumentTypes, ",")
+	deductible, nonDeductible := decimal.Zero, decimal.Zero
+	reasonTotals := map[string]*CITReasonSummaryResponse{}
+	reasonAmounts := map[string]decimal.Decimal{}
+	expenses := make([]CITExpenseResponse, 0, len(expenseRows))
+	for _, r := range expenseRows {
+		amount := r.ConvertedAmount
+		if r.FCTType == model.FCTTypeNet {
+			amount = amount.Add(r.FCTAmount)
+		}
+		if amount, err = conv.convert(amount, r.InvoiceDate); err != nil {
+			return CITEstimateResponse{}, err
+		}
+		invoiceTotal, err := conv.convert(r.InvoiceTotal, r.InvoiceDate)
+		if err != nil {
+			return CITEstimateResponse{}, err
+		}
+		cashPaid, err := conv.convert(r.CashPaid, r.InvoiceDate)
+		if err != nil {
+			return CITEstimateResponse{}, err
+		}
+
+		reasons := citExpenseReasons(r, accepted, invoiceTotal, cashPaid, setting.CashPaymentLimit)
+		if len(reasons) == 0 {
+			deductible = deductible.Add(amount)
+		} else {
+			nonDeductible = nonDeductible.Add(amount)
+			for _, reason := range reasons {
+				total, ok := reasonTotals[reason.Code]
+				if !ok {
+					total = &CITReasonSummaryResponse{Code: reason.Code}
+					reasonTotals[reason.Code] = total
+				}
+				total.Count++
+				reasonAmounts[reason.Code] = reasonAmounts[reason.Code].Add(amount)
+			}
+		}
+
+		expenses = append(expenses, CITExpenseResponse{
+			ExpenseID:    r.ExpenseID.String(),
+			InvoiceNo:    r.InvoiceNo,
+			InvoiceDate:  r.InvoiceDate.Format("2006-01-02"),
+			VendorName:   r.VendorName,
+			Description:  r.Description,
+			DocumentType: r.DocumentType,
+			Amount:       amount.StringFixed(0),
+			InvoiceTotal: invoiceTotal.StringFixed(0),
+			CashPaid:     cashPaid.StringFixed(0),
+			Deductible:   len(reasons) == 0,
+			Reasons:      reasons,
+		})
+	}
+
+	income := revenue.Sub(cost).Sub(deductible)
+	tax := decimal.Zero
+	if income.IsPositive() {
+		tax = income.Mul(setting.Rate).Round(0)
+	}
+
+	resp := CITEstimateResponse{
+		Period:                period.Code,
+		From:                  period.From.Format("2006-01-02"),
+		To:                    period.LastDay.Format("2006-01-02"),
+		Currency:              declarationCurrency,
+		Settings:              toCITSettingsResponse(setting),
+		InvoiceCount:          len(revenueRows),
+		TaxableRevenue:        revenue.StringFixed(0),
+		CostOfGoodsSold:       cost.StringFixed(0),
+		DeductibleExpenses:    deductible.StringFixed(0),
+		NonDeductibleExpenses: nonDeductible.StringFixed(0),
+		NonDeductibleReasons:  make([]CITReasonSummaryResponse, 0, len(reasonTotals)),
+		TaxableIncome:         income.StringFixed(0),
+		EstimatedTax:          tax.StringFixed(0),
+		Expenses:              expenses,
+	}
+	for _, code := range []string{CITReasonMissingInvoice, CITReasonCashOverLimit} {
+		if total, ok := reasonTotals[code]; ok {
+			total.Amount = reasonAmounts[code].StringFixed(0)
+			resp.NonDeductibleReasons = append(resp.NonDeductibleReasons, *total)
+		}
+	}
+	return resp, nil
+}
+
+// --- Helpers ---
+
+// loadCITSetting returns the saved CIT rules, or the defaults before any are saved
+func (s *taxReportService) loadCITSetting(ctx context.Context) (model.CITSetting, error) {
+	setting, err := s.taxReportRepo.FindCITSetting(ctx)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return defaultCITSetting(), nil
+		}
+		return model.CITSetting{}, fmt.Errorf("failed to fetch CIT rules: %w", err)
+	}
+	return *setting, nil
+}
+
+// citExpenseReasons returns why an expense is not deductible; none means it is. A VAT invoice
+// supports the expense only when the expense was recorded as deductible (with the vendor tax
+// code); other documents when they are accepted by the rules. Amounts are in VND.
+func citExpenseReasons(r repository.CITExpenseRow, accepted []string, invoiceTotal, cashPaid, cashLimit decimal.Decimal) []CITReasonResponse {
+	reasons := make([]CITReasonResponse, 0)
+
+	supported := false
+	for _, d := range accepted {
+		if d == r.DocumentType {
+			supported = r.DocumentType != model.DocTypeVATInvoice || r.IsDeductibleExpense
+		}
+	}
+	if !supported {
+		description := fmt.Sprintf("missing VAT invoice: the expense is supported by %s", r.DocumentType)
+		if r.DocumentType == model.DocTypeVATInvoice {
+			description = "missing VAT invoice: the VAT invoice has no vendor tax code"
+		} else if r.DocumentType == model.DocTypeNone {
+			description = "missing VAT invoice: the expense has no supporting document"
+		}
+		reasons = append(reasons, CITReasonResponse{Code: CITReasonMissingInvoice, Description: description})
+	}
+
+	if cashPaid.IsPositive() && invoiceTotal.GreaterThanOrEqual(cashLimit) {
+		reasons = append(reasons, CITReasonResponse{
+			Code: CITReasonCashOverLimit,
+			Description: fmt.Sprintf("%s VND paid in cash on a purchase of %s VND, at or over the %s VND limit for cash payments",
+				cashPaid.StringFixed(0), invoiceTotal.StringFixed(0), cashLimit.StringFixed(0)),
+		})
+	}
+	return reasons
+}
+
+func toCITSettingsResponse(setting model.CITSetting) CITSettingsResponse {
+	documentTypes := []string{}
+	if setting.DocumentTypes != "" {
+		documentTypes = strings.Split(setting.DocumentTypes, ",")
+	}
+	resp := CITSettingsResponse{
+		Rate:             setting.Rate.StringFixed(4),
+		CashPaymentLimit: setting.CashPaymentLimit.StringFixed(0),
+		DocumentTypes:    documentTypes,
+	}
+	if !setting.UpdatedAt.IsZero() {
+		updatedAt := setting.UpdatedAt.Format(time.RFC3339)
+		resp.UpdatedAt = &updatedAt
+	}
+	return resp
+}
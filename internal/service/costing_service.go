@@ -0,0 +1,550 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// --- DTOs ---
+
+type AllocateLandedCostRequest struct {
+	Method string `json:"method" binding:"required,oneof=VALUE QUANTITY WEIGHT"`
+}
+
+type LandedCostLineResponse struct {
+	OrderItemID       string `json:"order_item_id"`
+	ProductID         string `json:"product_id"`
+	ProductSKU        string `json:"product_sku"`
+	ProductName       string `json:"product_name"`
+	Quantity          int    `json:"quantity"`
+	UnitCost          string `json:"unit_cost"`
+	Basis             string `json:"basis"`
+	FreightAmount     string `json:"freight_amount"`
+	DutyAmount        string `json:"duty_amount"`
+	ExpenseAmount     string `json:"expense_amount"`
+	TotalAmount       string `json:"total_amount"`
+	LandedCostPerUnit string `json:"landed_cost_per_unit"`
+	TotalUnitCost     string `json:"total_unit_cost"`
+}
+
+type LandedCostResponse struct {
+	ID            string                   `json:"id"`
+	OrderID       string                   `json:"order_id"`
+	OrderCode     string                   `json:"order_code"`
+	Method        string                   `json:"method"`
+	FreightAmount string                   `json:"freight_amount"`
+	DutyAmount    string                   `json:"duty_amount"`
+	ExpenseAmount string                   `json:"expense_amount"`
+	TotalAmount   string                   `json:"total_amount"`
+	IssuedAmount  string                   `json:"issued_amount"`
+	Lines         []LandedCostLineResponse `json:"lines"`
+	CreatedAt     string                   `json:"created_at"`
+}
+
+type InventoryValuationLine struct {
+	ProductID    string `json:"product_id"`
+	ProductSKU   string `json:"product_sku"`
+	ProductName  string `json:"product_name"`
+	Quantity     int    `json:"quantity"`
+	PurchaseCost string `json:"purchase_cost"`
+	LandedCost   string `json:"landed_cost"`
+	TotalValue   string `json:"total_value"`
+	AvgUnitCost  string `json:"avg_unit_cost"`
+}
+
+type InventoryValuationResponse struct {
+	Products   []InventoryValuationLine `json:"products"`
+	TotalValue string                   `json:"total_value"`
+}
+
+// --- Interface ---
+
+type CostingService interface {
+	AllocateLandedCost(ctx context.Context, orderID, userID string, req AllocateLandedCostRequest) (LandedCostResponse, error)
+	GetLandedCost(ctx context.Context, orderID string) (LandedCostResponse, error)
+	GetInventoryValuation(ctx context.Context) (InventoryValuationResponse, error)
+}
+
+type costingService struct {
+	costingRepo  repository.CostingRepository
+	orderRepo    repository.OrderRepository
+	invoiceRepo  repository.InvoiceRepository
+	expenseRepo  repository.ExpenseRepository
+	customsRepo  repository.CustomsRepository
+	auditRepo    repository.AuditRepository
+	ledgerRepo   repository.LedgerRepository
+	sequenceRepo repository.DocumentSequenceRepository
+	periodRepo   repository.FiscalPeriodRepository
+	txManager    repository.TransactionManager
+}
+
+func NewCostingService(
+	costingRepo repository.CostingRepository,
+	orderRepo repository.OrderRepository,
+	invoiceRepo repository.InvoiceRepository,
+	expenseRepo repository.ExpenseRepository,
+	customsRepo repository.CustomsRepository,
+	auditRepo repository.AuditRepository,
+	ledgerRepo repository.LedgerRepository,
+	sequenceRepo repository.DocumentSequenceRepository,
+	periodRepo repository.FiscalPeriodRepository,
+	txManager repository.TransactionManager,
+) CostingService {
+	return &costingService{
+		costingRepo:  costingRepo,
+		orderRepo:    orderRepo,
+		invoiceRepo:  invoiceRepo,
+		expenseRepo:  expenseRepo,
+		customsRepo:  customsRepo,
+		auditRepo:    auditRepo,
+		ledgerRepo:   ledgerRepo,
+		sequenceRepo: sequenceRepo,
+		periodRepo:   periodRepo,
+		txManager:    txManager,
+	}
+}
+
+// --- Implementation ---
+
+// AllocateLandedCost spreads the freight (import invoice side fees), import duty and handling
+// expenses of an approved IMPORT order over its items and writes the per-unit result into the
+// order's cost layers. Units already issued were relieved from 1562 at the previous per-unit
+// cost, so their landed cost is moved to 632 by a journal entry. Re-running replaces the
+// previous allocation and reverses its entry before posting the new one.
+func (s *costingService) AllocateLandedCost(ctx context.Context, orderID, userID string, req AllocateLandedCostRequest) (LandedCostResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return LandedCostResponse{}, fmt.Errorf("invalid order ID")
+	}
+
+	var (
+		order       model.Order
+		allocation  *model.LandedCostAllocation
+		layerByItem map[uuid.UUID]model.CostLayer
+	)
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		orders, err := s.orderRepo.FindByIDsWithProducts(txCtx, []uuid.UUID{oid})
+		if err != nil {
+			return fmt.Errorf("failed to fetch order: %w", err)
+		}
+		if len(orders) == 0 {
+			return fmt.Errorf("order not found")
+		}
+		order = orders[0]
+		if order.Type != model.OrderTypeImport {
+			return fmt.Errorf("landed cost only applies to IMPORT orders")
+		}
+		if order.Status != model.OrderStatusCompleted {
+			return fmt.Errorf("order must be approved before allocating landed cost")
+		}
+
+		now := time.Now()
+		if err := checkPeriodOpen(txCtx, s.periodRepo, now, false); err != nil {
+			return err
+		}
+
+		// Locking the layers serializes re-allocations of the order and export approvals issuing from it
+		layers, err := s.costingRepo.FindLayersByOrderIDForUpdate(txCtx, oid)
+		if err != nil {
+			return fmt.Errorf("failed to lock cost layers: %w", err)
+		}
+		layerByItem = make(map[uuid.UUID]model.CostLayer, len(layers))
+		for _, l := range layers {
+			layerByItem[l.OrderItemID] = l
+		}
+		for _, item := range order.Items {
+			if _, ok := layerByItem[item.ID]; !ok {
+				return fmt.Errorf("order %s was approved before cost layers were tracked", order.OrderCode)
+			}
+		}
+
+		freight, duty, expenses, err := s.landedCostComponents(txCtx, order)
+		if err != nil {
+			return err
+		}
+		if freight.Add(duty).Add(expenses).IsZero() {
+			return fmt.Errorf("order has no freight, import duty or handling expenses to allocate")
+		}
+
+		bases := make([]decimal.Decimal, len(order.Items))
+		for i, item := range order.Items {
+			bases[i] = allocationBasis(req.Method, item)
+		}
+		freightShares, err := allocateProportionally(freight, bases)
+		if err != nil {
+			return err
+		}
+		dutyShares, _ := allocateProportionally(duty, bases)
+		expenseShares, _ := allocateProportionally(expenses, bases)
+
+		// The previous allocation's entry is reversed below, so the new one has to cover
+		// everything consumption did not already relieve: issued × (new - old) + previous entry
+		issued := decimal.Zero
+		previous, err := s.costingRepo.FindAllocationByOrderID(txCtx, oid)
+		switch {
+		case err == nil:
+			issued = previous.IssuedAmount
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Errorf("failed to fetch previous allocation: %w", err)
+		}
+
+		allocation = &model.LandedCostAllocation{
+			OrderID:       oid,
+			Method:        req.Method,
+			FreightAmount: freight,
+			DutyAmount:    duty,
+			ExpenseAmount: expenses,
+			TotalAmount:   freight.Add(duty).Add(expenses),
+			AllocatedBy:   parseOptionalUUID(userID),
+		}
+		for i, item := range order.Items {
+			layer := layerByItem[item.ID]
+			lineTotal := freightShares[i].Add(dutyShares[i]).Add(expenseShares[i])
+			perUnit := lineTotal.Div(decimal.NewFromInt(int64(item.Quantity))).Round(4)
+
+			issuedQty := decimal.NewFromInt(int64(layer.Quantity - layer.RemainingQuantity))
+			issued = issued.Add(issuedQty.Mul(perUnit.Sub(layer.LandedCostPerUnit)))
+
+			allocation.Lines = append(allocation.Lines, model.LandedCostAllocationLine{
+				OrderItemID:       item.ID,
+				ProductID:         item.ProductID,
+				CostLayerID:       layer.ID,
+				Basis:             bases[i],
+				FreightAmount:     freightShares[i],
+				DutyAmount:        dutyShares[i],
+				ExpenseAmount:     expenseShares[i],
+				TotalAmount:       lineTotal,
+				LandedCostPerUnit: perUnit,
+			})
+		}
+		allocation.IssuedAmount = roundBase(issued)
+
+		if err := reverseJournalEntries(txCtx, s.ledgerRepo, s.sequenceRepo, model.JournalSourceLandedCost, []uuid.UUID{oid}, now, allocation.AllocatedBy, "landed cost re-allocated"); err != nil {
+			return err
+		}
+		if err := s.costingRepo.DeleteAllocationByOrderID(txCtx, oid); err != nil {
+			return fmt.Errorf("failed to remove previous allocation: %w", err)
+		}
+		if err := s.costingRepo.CreateAllocation(txCtx, allocation); err != nil {
+			return fmt.Errorf("failed to save allocation: %w", err)
+		}
+		for _, line := range allocation.Lines {
+			if err := s.costingRepo.UpdateLandedCost(txCtx, line.CostLayerID, line.LandedCostPerUnit); err != nil {
+				return fmt.Errorf("failed to update cost layer: %w", err)
+			}
+		}
+		if _, err := postJournalEntry(txCtx, s.ledgerRepo, s.sequenceRepo, &model.JournalEntry{
+			EntryDate:   now,
+			SourceType:  model.JournalSourceLandedCost,
+			SourceID:    oid,
+			SourceNo:    order.OrderCode,
+			Description: "Landed cost of goods already sold " + order.OrderCode,
+			CreatedBy:   allocation.AllocatedBy,
+		}, []postingLine{
+			debitLine(model.AccountCostOfGoodsSold, nil, allocation.IssuedAmount),
+			creditLine(model.AccountGoodsPurchaseFee, nil, allocation.IssuedAmount),
+		}); err != nil {
+			return err
+		}
+
+		details, _ := json.Marshal(map[string]interface{}{
+			"order_code": order.OrderCode,
+			"method":     req.Method,
+			"freight":    freight.StringFixed(4),
+			"duty":       duty.StringFixed(4),
+			"expenses":   expenses.StringFixed(4),
+			"issued":     allocation.IssuedAmount.StringFixed(4),
+		})
+		return s.auditRepo.Log(txCtx, &model.AuditLog{
+			UserID:     allocation.AllocatedBy,
+			Action:     model.ActionAllocateLandedCost,
+			EntityID:   order.ID.String(),
+			EntityName: order.OrderCode,
+			Details:    string(details),
+		})
+	})
+	if err != nil {
+		return LandedCostResponse{}, err
+	}
+
+	return toLandedCostResponse(order, *allocation, layerByItem), nil
+}
+
+func (s *costingService) GetLandedCost(ctx context.Context, orderID string) (LandedCostResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return LandedCostResponse{}, fmt.Errorf("invalid order ID")
+	}
+
+	allocation, err := s.costingRepo.FindAllocationByOrderID(ctx, oid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return LandedCostResponse{}, fmt.Errorf("no landed cost allocation for this order")
+		}
+		return LandedCostResponse{}, fmt.Errorf("failed to fetch allocation: %w", err)
+	}
+
+	orders, err := s.orderRepo.FindByIDsWithProducts(ctx, []uuid.UUID{oid})
+	if err != nil || len(orders) == 0 {
+		return LandedCostResponse{}, fmt.Errorf("order not found")
+	}
+	layers, err := s.costingRepo.FindLayersByOrderID(ctx, oid)
+	if err != nil {
+		return LandedCostResponse{}, fmt.Errorf("failed to fetch cost layers: %w", err)
+	}
+	layerByItem := make(map[uuid.UUID]model.CostLayer, len(layers))
+	for _, l := range layers {
+		layerByItem[l.OrderItemID] = l
+	}
+
+	return toLandedCostResponse(orders[0], *allocation, layerByItem), nil
+}
+
+func (s *costingService) GetInventoryValuation(ctx context.Context) (InventoryValuationResponse, error) {
+	rows, err := s.costingRepo.ListValuation(ctx)
+	if err != nil {
+		return InventoryValuationResponse{}, fmt.Errorf("failed to compute inventory valuation: %w", err)
+	}
+
+	res := InventoryValuationResponse{Products: make([]InventoryValuationLine, 0, len(rows))}
+	grandTotal := decimal.Zero
+	for _, r := range rows {
+		total := r.PurchaseCost.Add(r.LandedCost)
+		avg := decimal.Zero
+		if r.Quantity > 0 {
+			avg = total.Div(decimal.NewFromInt(int64(r.Quantity)))
+		}
+		res.Products = append(res.Products, InventoryValuationLine{
+			ProductID:    r.ProductID,
+			ProductSKU:   r.ProductSKU,
+			ProductName:  r.ProductName,
+			Quantity:     r.Quantity,
+			PurchaseCost: r.PurchaseCost.StringFixed(4),
+			LandedCost:   r.LandedCost.StringFixed(4),
+			TotalValue:   total.StringFixed(4),
+			AvgUnitCost:  avg.StringFixed(4),
+		})
+		grandTotal = grandTotal.Add(total)
+	}
+	res.TotalValue = grandTotal.StringFixed(4)
+
+	return res, nil
+}
+
+// landedCostComponents collects freight (side fees on the order's import invoices), import
+// duty and approved expenses linked to the order. Duty only comes from cleared customs
+// declarations whose duty expense (raised on clearance) has an approved invoice; until then
+// none is allocated.
+func (s *costingService) landedCostComponents(ctx context.Context, order model.Order) (decimal.Decimal, decimal.Decimal, decimal.Decimal, error) {
+	freight := decimal.Zero
+	invoices, err := s.invoiceRepo.FindByReferences(ctx, model.RefTypeOrderImport, []uuid.UUID{order.ID})
+	if err != nil {
+		return freight, decimal.Zero, decimal.Zero, fmt.Errorf("failed to fetch import invoices: %w", err)
+	}
+	for _, inv := range invoices {
+		if inv.ApprovalStatus == model.ApprovalApproved && inv.VoidedAt == nil {
+			freight = freight.Add(inv.SideFees)
+		}
+	}
+
+	duty, expenseTotal := decimal.Zero, decimal.Zero
+	declarations, err := s.customsRepo.ListByOrderID(ctx, order.ID)
+	if err != nil {
+		return freight, duty, expenseTotal, fmt.Errorf("failed to fetch customs declarations: %w", err)
+	}
+	expenses, err := s.expenseRepo.ListByOrderID(ctx, order.ID)
+	if err != nil {
+		return freight, duty, expenseTotal, fmt.Errorf("failed to fetch order expenses: %w", err)
+	}
+
+	// Only expenses that went through approval (and therefore have an approved invoice) count,
+	// including the expense raised on clearance that books the duty of a declaration
+	ids := make([]uuid.UUID, 0, len(expenses)+len(declarations))
+	for _, e := range expenses {
+		ids = append(ids, e.ID)
+	}
+	for _, d := range declarations {
+		if d.ExpenseID != nil {
+			ids = append(ids, *d.ExpenseID)
+		}
+	}
+	approved := make(map[uuid.UUID]bool)
+	if len(ids) > 0 {
+		expenseInvoices, err := s.invoiceRepo.FindByReferences(ctx, model.RefTypeExpense, ids)
+		if err != nil {
+			return freight, duty, expenseTotal, fmt.Errorf("failed to fetch expense invoices: %w", err)
+		}
+		for _, inv := range expenseInvoices {
+			if inv.ApprovalStatus == model.ApprovalApproved {
+				approved[inv.ReferenceID] = true
+			}
+		}
+	}
+
+	// The expense raised on clearance carries the same duty; skip it so it is not counted twice
+	customsExpenses := make(map[uuid.UUID]bool)
+	for _, d := range declarations {
+		if d.ExpenseID != nil {
+			customsExpenses[*d.ExpenseID] = true
+		}
+		if d.Status == model.CustomsStatusCleared && d.ExpenseID != nil && approved[*d.ExpenseID] {
+			duty = duty.Add(d.DutyAmount)
+		}
+	}
+	for _, e := range expenses {
+		if approved[e.ID] && !customsExpenses[e.ID] {
+			expenseTotal = expenseTotal.Add(e.ConvertedAmount)
+		}
+	}
+
+	return freight, duty, expenseTotal, nil
+}
+
+// --- Cost layer helpers (called inside the approval transaction) ---
+
+// receiveCostLayer opens the cost layer for one item of an approved IMPORT order, converting its
+// price from the order currency at the order's rate, and returns the base-currency cost received
+func receiveCostLayer(ctx context.Context, costingRepo repository.CostingRepository, orderID uuid.UUID, item model.OrderItem, rate decimal.Decimal, receivedAt time.Time) (decimal.Decimal, error) {
+	cost := roundBase(item.NetAmount().Mul(rate)) // Net of line discount
+	layer := &model.CostLayer{
+		ProductID:         item.ProductID,
+		OrderID:           orderID,
+		OrderItemID:       item.ID,
+		ReceivedAt:        receivedAt,
+		Quantity:          item.Quantity,
+		RemainingQuantity: item.Quantity,
+		UnitCost:          cost.Div(decimal.NewFromInt(int64(item.Quantity))).Round(4),
+	}
+	if err := costingRepo.CreateLayer(ctx, layer); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to create cost layer: %w", err)
+	}
+	return cost, nil
+}
+
+// consumeCostLayers issues quantity units of a product from its oldest layers (FIFO) and returns
+// the cost of the issued units and the landed part of it. Stock received before layers were
+// tracked has no layer; that part of the quantity is issued at zero recorded cost.
+func consumeCostLayers(ctx context.Context, costingRepo repository.CostingRepository, productID uuid.UUID, quantity int) (decimal.Decimal, decimal.Decimal, error) {
+	layers, err := costingRepo.FindOpenLayersForUpdate(ctx, productID)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("failed to lock cost layers: %w", err)
+	}
+
+	cost, landed := decimal.Zero, decimal.Zero
+	remaining := quantity
+	for i := range layers {
+		if remaining == 0 {
+			break
+		}
+		take := layers[i].RemainingQuantity
+		if take > remaining {
+			take = remaining
+		}
+		layers[i].RemainingQuantity -= take
+		remaining -= take
+		cost = cost.Add(layers[i].TotalUnitCost().Mul(decimal.NewFromInt(int64(take))))
+		landed = landed.Add(layers[i].LandedCostPerUnit.Mul(decimal.NewFromInt(int64(take))))
+
+		if err := costingRepo.UpdateLayer(ctx, &layers[i]); err != nil {
+			return decimal.Zero, decimal.Zero, fmt.Errorf("failed to update cost layer: %w", err)
+		}
+	}
+	return cost, landed, nil
+}
+
+// --- Allocation helpers ---
+
+func allocationBasis(method string, item model.OrderItem) decimal.Decimal {
+	qty := decimal.NewFromInt(int64(item.Quantity))
+	switch method {
+	case model.AllocationByQuantity:
+		return qty
+	case model.AllocationByWeight:
+		return qty.Mul(decimal.NewFromFloat(item.Product.WeightKg))
+	default:
+		return item.NetAmount()
+	}
+}
+
+// allocateProportionally splits amount by bases rounded to 4 decimals; the last non-zero basis
+// absorbs the rounding difference so the shares always sum to amount
+func allocateProportionally(amount decimal.Decimal, bases []decimal.Decimal) ([]decimal.Decimal, error) {
+	shares := make([]decimal.Decimal, len(bases))
+	totalBasis := decimal.Zero
+	last := -1
+	for i, b := range bases {
+		shares[i] = decimal.Zero
+		totalBasis = totalBasis.Add(b)
+		if b.IsPositive() {
+			last = i
+		}
+	}
+	if amount.IsZero() {
+		return shares, nil
+	}
+	if !totalBasis.IsPositive() {
+		return nil, fmt.Errorf("allocation basis is zero for every item; choose another method")
+	}
+
+	allocated := decimal.Zero
+	for i, b := range bases {
+		if i == last {
+			shares[i] = amount.Sub(allocated)
+			break
+		}
+		shares[i] = roundBase(amount.Mul(b).Div(totalBasis))
+		allocated = allocated.Add(shares[i])
+	}
+	return shares, nil
+}
+
+func toLandedCostResponse(order model.Order, a model.LandedCostAllocation, layerByItem map[uuid.UUID]model.CostLayer) LandedCostResponse {
+	items := make(map[uuid.UUID]model.OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		items[item.ID] = item
+	}
+
+	res := LandedCostResponse{
+		ID:            a.ID.String(),
+		OrderID:       order.ID.String(),
+		OrderCode:     order.OrderCode,
+		Method:        a.Method,
+		FreightAmount: a.FreightAmount.StringFixed(4),
+		DutyAmount:    a.DutyAmount.StringFixed(4),
+		ExpenseAmount: a.ExpenseAmount.StringFixed(4),
+		TotalAmount:   a.TotalAmount.StringFixed(4),
+		IssuedAmount:  a.IssuedAmount.StringFixed(4),
+		Lines:         make([]LandedCostLineResponse, 0, len(a.Lines)),
+		CreatedAt:     a.CreatedAt.Format(time.RFC3339),
+	}
+	for _, l := range a.Lines {
+		item := items[l.OrderItemID]
+		unitCost := layerByItem[l.OrderItemID].UnitCost
+		res.Lines = append(res.Lines, LandedCostLineResponse{
+			OrderItemID:       l.OrderItemID.String(),
+			ProductID:         l.ProductID.String(),
+			ProductSKU:        item.Product.SKU,
+			ProductName:       item.Product.Name,
+			Quantity:          item.Quantity,
+			UnitCost:          unitCost.StringFixed(4),
+			Basis:             l.Basis.StringFixed(4),
+			FreightAmount:     l.FreightAmount.StringFixed(4),
+			DutyAmount:        l.DutyAmount.StringFixed(4),
+			ExpenseAmount:     l.ExpenseAmount.StringFixed(4),
+			TotalAmount:       l.TotalAmount.StringFixed(4),
+			LandedCostPerUnit: l.LandedCostPerUnit.StringFixed(4),
+			TotalUnitCost:     unitCost.Add(l.LandedCostPerUnit).StringFixed(4),
+		})
+	}
+	return res
+}
@@ -0,0 +1,704 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// --- DTOs ---
+
+type CustomsLinePayload struct {
+	OrderItemID   string `json:"order_item_id" binding:"required"`
+	HSCode        string `json:"hs_code" binding:"required"`                          // 6, 8 or 10 digits, dots allowed (e.g. "8471.30.20")
+	OriginCountry string `json:"origin_country" binding:"omitempty,iso3166_1_alpha2"` // Defaults to the declaration origin
+	Quantity      int    `json:"quantity" binding:"omitempty,min=1"`                  // Defaults to the remaining undeclared quantity
+	CustomsValue  string `json:"customs_value" binding:"required"`                    // Decimal string in declaration currency
+}
+
+type CreateCustomsDeclarationRequest struct {
+	OrderID       string               `json:"order_id" binding:"required"`
+	CustomsOffice string               `json:"customs_office"`
+	OriginCountry string               `json:"origin_country" binding:"required,iso3166_1_alpha2"`
+	Currency      string               `json:"currency" binding:"required,len=3"`
+	ExchangeRate  string               `json:"exchange_rate" binding:"required"` // Customs rate to the base currency
+	Note          string               `json:"note"`
+	Lines         []CustomsLinePayload `json:"lines" binding:"required,min=1,dive"`
+}
+
+type UpdateCustomsDeclarationRequest struct {
+	CustomsOffice string               `json:"customs_office"`
+	OriginCountry string               `json:"origin_country" binding:"required,iso3166_1_alpha2"`
+	Currency      string               `json:"currency" binding:"required,len=3"`
+	ExchangeRate  string               `json:"exchange_rate" binding:"required"`
+	Note          string               `json:"note"`
+	Lines         []CustomsLinePayload `json:"lines" binding:"required,min=1,dive"`
+}
+
+type UpdateCustomsStatusRequest struct {
+	Status        string `json:"status" binding:"required,oneof=SUBMITTED CLEARED REJECTED CANCELLED"`
+	DeclarationNo string `json:"declaration_no"` // Required when submitting for the first time
+	Note          string `json:"note"`
+}
+
+type CustomsFilter struct {
+	Status  string
+	OrderID string
+	Page    int
+	Limit   int
+}
+
+type CustomsLineResponse struct {
+	ID               string `json:"id"`
+	OrderItemID      string `json:"order_item_id"`
+	ProductID        string `json:"product_id"`
+	ProductSKU       string `json:"product_sku"`
+	ProductName      string `json:"product_name"`
+	HSCode           string `json:"hs_code"`
+	OriginCountry    string `json:"origin_country"`
+	Quantity         int    `json:"quantity"`
+	CustomsValue     string `json:"customs_value"`
+	CustomsValueBase string `json:"customs_value_base"`
+	DutyRate         string `json:"duty_rate"`
+	DutyAmount       string `json:"duty_amount"`
+	VATRate          string `json:"vat_rate"`
+	VATAmount        string `json:"vat_amount"`
+}
+
+type CustomsDeclarationResponse struct {
+	ID               string                `json:"id"`
+	OrderID          string                `json:"order_id"`
+	OrderCode        string                `json:"order_code"`
+	Direction        string                `json:"direction"`
+	DeclarationNo    *string               `json:"declaration_no"`
+	CustomsOffice    string                `json:"customs_office"`
+	OriginCountry    string                `json:"origin_country"`
+	Status           string                `json:"status"`
+	Currency         string                `json:"currency"`
+	ExchangeRate     string                `json:"exchange_rate"`
+	CustomsValueBase string                `json:"customs_value_base"`
+	DutyRate         string                `json:"duty_rate"`
+	DutyAmount       string                `json:"duty_amount"`
+	VATRate          string                `json:"vat_rate"`
+	VATAmount        string                `json:"vat_amount"`
+	TotalTax         string                `json:"total_tax"`
+	ExpenseID        *string               `json:"expense_id"`
+	Note             string                `json:"note"`
+	RegisteredAt     *string               `json:"registered_at"`
+	ClearedAt        *string               `json:"cleared_at"`
+	CreatedAt        string                `json:"created_at"`
+	Lines            []CustomsLineResponse `json:"lines,omitempty"`
+}
+
+// --- Interface ---
+
+type CustomsService interface {
+	ListDeclarations(ctx context.Context, filter CustomsFilter) ([]CustomsDeclarationResponse, int64, error)
+	GetDeclaration(ctx context.Context, id string) (CustomsDeclarationResponse, error)
+	CreateDeclaration(ctx context.Context, userID string, req CreateCustomsDeclarationRequest) (CustomsDeclarationResponse, error)
+	UpdateDeclaration(ctx context.Context, id, userID string, req UpdateCustomsDeclarationRequest) (CustomsDeclarationResponse, error)
+	UpdateStatus(ctx context.Context, id, userID string, req UpdateCustomsStatusRequest) (CustomsDeclarationResponse, error)
+}
+
+type customsService struct {
+	customsRepo  repository.CustomsRepository
+	orderRepo    repository.OrderRepository
+	expenseRepo  repository.ExpenseRepository
+	approvalRepo repository.ApprovalRepository
+	auditRepo    repository.AuditRepository
+	txManager    repository.TransactionManager
+	taxService   TaxService
+}
+
+func NewCustomsService(
+	customsRepo repository.CustomsRepository,
+	orderRepo repository.OrderRepository,
+	expenseRepo repository.ExpenseRepository,
+	approvalRepo repository.ApprovalRepository,
+	auditRepo repository.AuditRepository,
+	txManager repository.TransactionManager,
+	taxService TaxService,
+) CustomsService {
+	return &customsService{
+		customsRepo:  customsRepo,
+		orderRepo:    orderRepo,
+		expenseRepo:  expenseRepo,
+		approvalRepo: approvalRepo,
+		auditRepo:    auditRepo,
+		txManager:    txManager,
+		taxService:   taxService,
+	}
+}
+
+// customsTransitions lists the statuses a declaration may move to from each status
+var customsTransitions = map[string][]string{
+	model.CustomsStatusDraft:     {model.CustomsStatusSubmitted, model.CustomsStatusCancelled},
+	model.CustomsStatusSubmitted: {model.CustomsStatusCleared, model.CustomsStatusRejected},
+	model.CustomsStatusRejected:  {model.CustomsStatusSubmitted, model.CustomsStatusCancelled},
+}
+
+var hsCodePattern = regexp.MustCompile(`^\d{6}(\d{2}){0,2}$`)
+
+// --- Implementation ---
+
+func (s *customsService) ListDeclarations(ctx context.Context, filter CustomsFilter) ([]CustomsDeclarationResponse, int64, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+
+	var orderID *uuid.UUID
+	if filter.OrderID != "" {
+		parsed, err := uuid.Parse(filter.OrderID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid order_id: %w", err)
+		}
+		orderID = &parsed
+	}
+
+	declarations, total, err := s.customsRepo.List(ctx, filter.Status, orderID, filter.Page, filter.Limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch customs declarations: %w", err)
+	}
+
+	res := make([]CustomsDeclarationResponse, 0, len(declarations))
+	for _, d := range declarations {
+		res = append(res, toCustomsDeclarationResponse(d))
+	}
+	return res, total, nil
+}
+
+func (s *customsService) GetDeclaration(ctx context.Context, id string) (CustomsDeclarationResponse, error) {
+	declaration, err := s.findDeclaration(ctx, id)
+	if err != nil {
+		return CustomsDeclarationResponse{}, err
+	}
+	return toCustomsDeclarationResponse(*declaration), nil
+}
+
+func (s *customsService) CreateDeclaration(ctx context.Context, userID string, req CreateCustomsDeclarationRequest) (CustomsDeclarationResponse, error) {
+	orderID, err := uuid.Parse(req.OrderID)
+	if err != nil {
+		return CustomsDeclarationResponse{}, fmt.Errorf("invalid order_id: %w", err)
+	}
+
+	order, err := s.orderRepo.FindByIDWithItems(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return CustomsDeclarationResponse{}, fmt.Errorf("order not found")
+		}
+		return CustomsDeclarationResponse{}, fmt.Errorf("failed to fetch order: %w", err)
+	}
+	if order.Status == model.OrderStatusRejected {
+		return CustomsDeclarationResponse{}, fmt.Errorf("cannot declare a rejected order")
+	}
+
+	exchangeRate, err := parseExchangeRate(req.ExchangeRate)
+	if err != nil {
+		return CustomsDeclarationResponse{}, err
+	}
+
+	declaration := model.CustomsDeclaration{
+		OrderID:       order.ID,
+		Direction:     order.Type,
+		CustomsOffice: strings.TrimSpace(req.CustomsOffice),
+		OriginCountry: strings.ToUpper(req.OriginCountry),
+		Status:        model.CustomsStatusDraft,
+		Currency:      strings.ToUpper(req.Currency),
+		ExchangeRate:  exchangeRate,
+		Note:          req.Note,
+		CreatedBy:     parseOptionalUUID(userID),
+	}
+
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		// Locked so that concurrent declarations of the order see each other's quantities
+		locked, lockErr := s.orderRepo.FindByIDWithItemsForUpdate(txCtx, order.ID)
+		if lockErr != nil {
+			return fmt.Errorf("failed to lock order: %w", lockErr)
+		}
+		lines, lineErr := s.buildLines(txCtx, *locked, uuid.Nil, declaration.OriginCountry, req.Lines)
+		if lineErr != nil {
+			return lineErr
+		}
+		declaration.Lines = lines
+		if taxErr := s.applyTaxes(txCtx, &declaration, time.Now()); taxErr != nil {
+			return taxErr
+		}
+
+		if createErr := s.customsRepo.Create(txCtx, &declaration); createErr != nil {
+			return fmt.Errorf("failed to create customs declaration: %w", createErr)
+		}
+
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionCreateCustomsDeclaration, declaration.ID.String(), order.OrderCode, map[string]interface{}{
+			"order_id":           order.ID.String(),
+			"direction":          declaration.Direction,
+			"customs_value_base": declaration.CustomsValueBase.StringFixed(4),
+			"duty_amount":        declaration.DutyAmount.StringFixed(4),
+			"vat_amount":         declaration.VATAmount.StringFixed(4),
+		}))
+	})
+	if err != nil {
+		return CustomsDeclarationResponse{}, err
+	}
+
+	return s.GetDeclaration(ctx, declaration.ID.String())
+}
+
+func (s *customsService) UpdateDeclaration(ctx context.Context, id, userID string, req UpdateCustomsDeclarationRequest) (CustomsDeclarationResponse, error) {
+	declarationID, err := uuid.Parse(id)
+	if err != nil {
+		return CustomsDeclarationResponse{}, fmt.Errorf("invalid declaration id: %w", err)
+	}
+	exchangeRate, err := parseExchangeRate(req.ExchangeRate)
+	if err != nil {
+		return CustomsDeclarationResponse{}, err
+	}
+
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		// Locked so that a concurrent submission cannot slip in between the status check and the
+		// update, and the order so that the quantities declared by the others stay put
+		declaration, findErr := s.customsRepo.FindByIDForUpdate(txCtx, declarationID)
+		if findErr != nil {
+			if errors.Is(findErr, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("customs declaration not found")
+			}
+			return fmt.Errorf("failed to fetch customs declaration: %w", findErr)
+		}
+		order, orderErr := s.orderRepo.FindByIDWithItemsForUpdate(txCtx, declaration.OrderID)
+		if orderErr != nil {
+			return fmt.Errorf("failed to fetch order: %w", orderErr)
+		}
+		if declaration.Status != model.CustomsStatusDraft && declaration.Status != model.CustomsStatusRejected {
+			return fmt.Errorf("only DRAFT or REJECTED declarations can be edited (current status: %s)", declaration.Status)
+		}
+
+		declaration.CustomsOffice = strings.TrimSpace(req.CustomsOffice)
+		declaration.OriginCountry = strings.ToUpper(req.OriginCountry)
+		declaration.Currency = strings.ToUpper(req.Currency)
+		declaration.ExchangeRate = exchangeRate
+		declaration.Note = req.Note
+
+		lines, lineErr := s.buildLines(txCtx, *order, declaration.ID, declaration.OriginCountry, req.Lines)
+		if lineErr != nil {
+			return lineErr
+		}
+		declaration.Lines = lines
+		if taxErr := s.applyTaxes(txCtx, declaration, time.Now()); taxErr != nil {
+			return taxErr
+		}
+
+		if replaceErr := s.customsRepo.ReplaceLines(txCtx, declaration.ID, declaration.Lines); replaceErr != nil {
+			return fmt.Errorf("failed to save declaration lines: %w", replaceErr)
+		}
+		if updateErr := s.customsRepo.Update(txCtx, declaration); updateErr != nil {
+			return fmt.Errorf("failed to update customs declaration: %w", updateErr)
+		}
+
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionUpdateCustomsDeclaration, declaration.ID.String(), order.OrderCode, map[string]interface{}{
+			"customs_value_base": declaration.CustomsValueBase.StringFixed(4),
+			"duty_amount":        declaration.DutyAmount.StringFixed(4),
+			"vat_amount":         declaration.VATAmount.StringFixed(4),
+			"lines":              len(declaration.Lines),
+		}))
+	})
+	if err != nil {
+		return CustomsDeclarationResponse{}, err
+	}
+
+	return s.GetDeclaration(ctx, declarationID.String())
+}
+
+// UpdateStatus moves a declaration through the customs workflow. Submitting registers the
+// declaration number and fixes the tax rates; clearing an IMPORT declaration raises an expense
+// for duty and import VAT, which then goes through the normal expense approval → invoice flow.
+func (s *customsService) UpdateStatus(ctx context.Context, id, userID string, req UpdateCustomsStatusRequest) (CustomsDeclarationResponse, error) {
+	declarationID, err := uuid.Parse(id)
+	if err != nil {
+		return CustomsDeclarationResponse{}, fmt.Errorf("invalid declaration id: %w", err)
+	}
+
+	now := time.Now()
+
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		// Locked so that concurrent transitions (e.g. two clearances raising two expenses) run one
+		// after the other and the second sees the status left by the first
+		declaration, findErr := s.customsRepo.FindByIDForUpdate(txCtx, declarationID)
+		if findErr != nil {
+			if errors.Is(findErr, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("customs declaration not found")
+			}
+			return fmt.Errorf("failed to fetch customs declaration: %w", findErr)
+		}
+
+		allowed := false
+		for _, next := range customsTransitions[declaration.Status] {
+			if next == req.Status {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("cannot change declaration status from %s to %s", declaration.Status, req.Status)
+		}
+		previous := declaration.Status
+
+		switch req.Status {
+		case model.CustomsStatusSubmitted:
+			if numErr := s.assignDeclarationNo(txCtx, declaration, req.DeclarationNo); numErr != nil {
+				return numErr
+			}
+			declaration.RegisteredAt = &now
+			if taxErr := s.applyTaxes(txCtx, declaration, now); taxErr != nil {
+				return taxErr
+			}
+			if replaceErr := s.customsRepo.ReplaceLines(txCtx, declaration.ID, declaration.Lines); replaceErr != nil {
+				return fmt.Errorf("failed to save declaration lines: %w", replaceErr)
+			}
+		case model.CustomsStatusCleared:
+			declaration.ClearedAt = &now
+			if declaration.Direction == model.OrderTypeImport && declaration.DutyAmount.Add(declaration.VATAmount).IsPositive() {
+				expenseID, expErr := s.raiseCustomsExpense(txCtx, *declaration, parseOptionalUUID(userID))
+				if expErr != nil {
+					return expErr
+				}
+				declaration.ExpenseID = &expenseID
+			}
+		}
+
+		declaration.Status = req.Status
+		if req.Note != "" {
+			declaration.Note = req.Note
+		}
+		if updateErr := s.customsRepo.Update(txCtx, declaration); updateErr != nil {
+			return fmt.Errorf("failed to update customs declaration: %w", updateErr)
+		}
+
+		details := map[string]interface{}{
+			"from": previous,
+			"to":   req.Status,
+		}
+		if declaration.DeclarationNo != nil {
+			details["declaration_no"] = *declaration.DeclarationNo
+		}
+		if declaration.ExpenseID != nil {
+			details["expense_id"] = declaration.ExpenseID.String()
+		}
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionChangeCustomsStatus, declaration.ID.String(), declarationLabel(*declaration), details))
+	})
+	if err != nil {
+		return CustomsDeclarationResponse{}, err
+	}
+
+	return s.GetDeclaration(ctx, declarationID.String())
+}
+
+// --- Helpers ---
+
+func (s *customsService) findDeclaration(ctx context.Context, id string) (*model.CustomsDeclaration, error) {
+	declarationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid declaration id: %w", err)
+	}
+	declaration, err := s.customsRepo.FindByID(ctx, declarationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("customs declaration not found")
+		}
+		return nil, fmt.Errorf("failed to fetch customs declaration: %w", err)
+	}
+	return declaration, nil
+}
+
+// buildLines validates the payload against the order items. The quantity declared for an item
+// across all non-cancelled declarations of the order may not exceed the ordered quantity; the
+// caller holds the order row lock so that the other declarations cannot change meanwhile.
+func (s *customsService) buildLines(ctx context.Context, order model.Order, declarationID uuid.UUID, defaultOrigin string, payload []CustomsLinePayload) ([]model.CustomsDeclarationLine, error) {
+	items := make(map[uuid.UUID]model.OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		items[item.ID] = item
+	}
+
+	others, err := s.customsRepo.ListByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch existing declarations: %w", err)
+	}
+	declared := make(map[uuid.UUID]int)
+	for _, d := range others {
+		if d.ID == declarationID || d.Status == model.CustomsStatusCancelled {
+			continue
+		}
+		for _, l := range d.Lines {
+			declared[l.OrderItemID] += l.Quantity
+		}
+	}
+
+	lines := make([]model.CustomsDeclarationLine, 0, len(payload))
+	seen := make(map[uuid.UUID]bool, len(payload))
+	for i, p := range payload {
+		itemID, err := uuid.Parse(p.OrderItemID)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid order_item_id: %w", i+1, err)
+		}
+		item, ok := items[itemID]
+		if !ok {
+			return nil, fmt.Errorf("line %d: order item %s does not belong to order %s", i+1, p.OrderItemID, order.OrderCode)
+		}
+		if seen[itemID] {
+			return nil, fmt.Errorf("line %d: order item %s is declared twice", i+1, p.OrderItemID)
+		}
+		seen[itemID] = true
+
+		hsCode, err := normalizeHSCode(p.HSCode)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		remaining := item.Quantity - declared[itemID]
+		qty := p.Quantity
+		if qty == 0 {
+			qty = remaining
+		}
+		if qty <= 0 || qty > remaining {
+			return nil, fmt.Errorf("line %d: quantity %d exceeds the undeclared quantity %d of the order item", i+1, qty, remaining)
+		}
+
+		value, err := decimal.NewFromString(p.CustomsValue)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid customs_value: %w", i+1, err)
+		}
+		if value.IsNegative() {
+			return nil, fmt.Errorf("line %d: customs_value must not be negative", i+1)
+		}
+
+		origin := strings.ToUpper(p.OriginCountry)
+		if origin == "" {
+			origin = defaultOrigin
+		}
+
+		lines = append(lines, model.CustomsDeclarationLine{
+			OrderItemID:   item.ID,
+			ProductID:     item.ProductID,
+			HSCode:        hsCode,
+			OriginCountry: origin,
+			Quantity:      qty,
+			CustomsValue:  value,
+		})
+	}
+	return lines, nil
+}
+
+// applyTaxes recomputes the base-currency customs value, import duty and import VAT of every line using the
+// IMPORT_TAX and VAT_INTL rules that apply on the given date to the line's HS code and origin country.
+// Import VAT is charged on value + duty. Exports carry no duty or VAT here (there is no export duty rule type).
+// The rates of the declaration are the effective rates over its lines.
+func (s *customsService) applyTaxes(ctx context.Context, d *model.CustomsDeclaration, on time.Time) error {
+	d.CustomsValueBase, d.DutyAmount, d.VATAmount = decimal.Zero, decimal.Zero, decimal.Zero
+	for i := range d.Lines {
+		line := &d.Lines[i]
+		line.DutyRate, line.VATRate = decimal.Zero, decimal.Zero
+		if d.Direction == model.OrderTypeImport {
+			taxLine := TaxLineContext{TaxType: model.TaxTypeImport, Date: on, Country: line.OriginCountry, HSCode: line.HSCode}
+			dutyRule, err := s.taxService.EvaluateTax(ctx, taxLine)
+			if err != nil {
+				return fmt.Errorf("failed to get import duty rate of HS code %s: %w", line.HSCode, err)
+			}
+			taxLine.TaxType = model.TaxTypeVATIntl
+			vatRule, err := s.taxService.EvaluateTax(ctx, taxLine)
+			if err != nil {
+				return fmt.Errorf("failed to get import VAT rate of HS code %s: %w", line.HSCode, err)
+			}
+			line.DutyRate, line.VATRate = dutyRule.Rate, vatRule.Rate
+		}
+
+		line.CustomsValueBase = roundBase(line.CustomsValue.Mul(d.ExchangeRate))
+		line.DutyAmount = roundBase(line.CustomsValueBase.Mul(line.DutyRate))
+		line.VATAmount = roundBase(line.CustomsValueBase.Add(line.DutyAmount).Mul(line.VATRate))
+
+		d.CustomsValueBase = d.CustomsValueBase.Add(line.CustomsValueBase)
+		d.DutyAmount = d.DutyAmount.Add(line.DutyAmount)
+		d.VATAmount = d.VATAmount.Add(line.VATAmount)
+	}
+
+	d.DutyRate, d.VATRate = decimal.Zero, decimal.Zero
+	if d.CustomsValueBase.IsPositive() {
+		d.DutyRate = d.DutyAmount.DivRound(d.CustomsValueBase, 4)
+		d.VATRate = d.VATAmount.DivRound(d.CustomsValueBase.Add(d.DutyAmount), 4)
+	}
+	return nil
+}
+
+func (s *customsService) assignDeclarationNo(ctx context.Context, d *model.CustomsDeclaration, declarationNo string) error {
+	declarationNo = strings.TrimSpace(declarationNo)
+	if declarationNo == "" {
+		if d.DeclarationNo != nil {
+			return nil // Resubmission keeps the number issued on first registration
+		}
+		return fmt.Errorf("declaration_no is required when submitting a declaration")
+	}
+	if len(declarationNo) > 30 {
+		return fmt.Errorf("declaration_no must be at most 30 characters")
+	}
+
+	exists, err := s.customsRepo.ExistsDeclarationNo(ctx, declarationNo, d.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check declaration number: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("declaration number %s is already used by another declaration", declarationNo)
+	}
+	d.DeclarationNo = &declarationNo
+	return nil
+}
+
+// raiseCustomsExpense records duty + import VAT payable to customs as an expense of the order and
+// opens its approval request, so the invoice is created by the usual expense approval
+func (s *customsService) raiseCustomsExpense(ctx context.Context, d model.CustomsDeclaration, userUUID *uuid.UUID) (uuid.UUID, error) {
+	description := fmt.Sprintf("Import duty and VAT - customs declaration %s", declarationLabel(d))
+
+	expense := model.Expense{
+		OrderID:             &d.OrderID,
+		Currency:            baseCurrency,
+		ExchangeRate:        decimal.NewFromInt(1),
+		RateSource:          model.ExpenseRateBase,
+		OriginalAmount:      d.DutyAmount,
+		ConvertedAmount:     d.DutyAmount,
+		TotalPayable:        d.DutyAmount.Add(d.VATAmount), // Duty and import VAT are both paid to customs
+		VATRate:             d.VATRate,
+		VATAmount:           d.VATAmount,
+		DocumentType:        model.DocTypeCustomsDeclaration,
+		IsDeductibleExpense: true,
+		Description:         description,
+	}
+
+	auditDetails := map[string]interface{}{
+		"customs_declaration_id": d.ID.String(),
+		"duty_amount":            d.DutyAmount.StringFixed(4),
+		"vat_amount":             d.VATAmount.StringFixed(4),
+		"document_type":          model.DocTypeCustomsDeclaration,
+		"description":            description,
+	}
+	requestData := map[string]interface{}{
+		"customs_declaration_id": d.ID.String(),
+		"declaration_no":         declarationLabel(d),
+		"currency":               baseCurrency,
+		"original_amount":        d.DutyAmount.StringFixed(4),
+		"vat_amount":             d.VATAmount.StringFixed(4),
+		"document_type":          model.DocTypeCustomsDeclaration,
+		"description":            description,
+	}
+
+	if err := submitExpenseForApproval(ctx, s.expenseRepo, s.auditRepo, s.approvalRepo, &expense, userUUID, auditDetails, requestData); err != nil {
+		return uuid.Nil, err
+	}
+	return expense.ID, nil
+}
+
+func normalizeHSCode(code string) (string, error) {
+	cleaned := strings.NewReplacer(".", "", " ", "").Replace(code)
+	if !hsCodePattern.MatchString(cleaned) {
+		return "", fmt.Errorf("invalid hs_code %q: expected 6, 8 or 10 digits", code)
+	}
+	return cleaned, nil
+}
+
+func parseExchangeRate(value string) (decimal.Decimal, error) {
+	rate, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid exchange_rate: %w", err)
+	}
+	if !rate.IsPositive() {
+		return decimal.Zero, fmt.Errorf("exchange_rate must be greater than 0")
+	}
+	return rate, nil
+}
+
+func declarationLabel(d model.CustomsDeclaration) string {
+	if d.DeclarationNo != nil {
+		return *d.DeclarationNo
+	}
+	return d.ID.String()
+}
+
+func newAuditLog(userID, action, entityID, entityName string, details interface{}) *model.AuditLog {
+	detailsJSON, _ := json.Marshal(details)
+	return &model.AuditLog{
+		UserID:     parseOptionalUUID(userID),
+		Action:     action,
+		EntityID:   entityID,
+		EntityName: entityName,
+		Details:    string(detailsJSON),
+	}
+}
+
+func toCustomsDeclarationResponse(d model.CustomsDeclaration) CustomsDeclarationResponse {
+	resp := CustomsDeclarationResponse{
+		ID:               d.ID.String(),
+		OrderID:          d.OrderID.String(),
+		Direction:        d.Direction,
+		DeclarationNo:    d.DeclarationNo,
+		CustomsOffice:    d.CustomsOffice,
+		OriginCountry:    d.OriginCountry,
+		Status:           d.Status,
+		Currency:         d.Currency,
+		ExchangeRate:     d.ExchangeRate.StringFixed(6),
+		CustomsValueBase: d.CustomsValueBase.StringFixed(4),
+		DutyRate:         d.DutyRate.StringFixed(4),
+		DutyAmount:       d.DutyAmount.StringFixed(4),
+		VATRate:          d.VATRate.StringFixed(4),
+		VATAmount:        d.VATAmount.StringFixed(4),
+		TotalTax:         d.DutyAmount.Add(d.VATAmount).StringFixed(4),
+		Note:             d.Note,
+		CreatedAt:        d.CreatedAt.Format(time.RFC3339),
+	}
+	if d.Order != nil {
+		resp.OrderCode = d.Order.OrderCode
+	}
+	if d.ExpenseID != nil {
+		id := d.ExpenseID.String()
+		resp.ExpenseID = &id
+	}
+	if d.RegisteredAt != nil {
+		t := d.RegisteredAt.Format(time.RFC3339)
+		resp.RegisteredAt = &t
+	}
+	if d.ClearedAt != nil {
+		t := d.ClearedAt.Format(time.RFC3339)
+		resp.ClearedAt = &t
+	}
+
+	for _, l := range d.Lines {
+		line := CustomsLineResponse{
+			ID:               l.ID.String(),
+			OrderItemID:      l.OrderItemID.String(),
+			ProductID:        l.ProductID.String(),
+			HSCode:           l.HSCode,
+			OriginCountry:    l.OriginCountry,
+			Quantity:         l.Quantity,
+			CustomsValue:     l.CustomsValue.StringFixed(4),
+			CustomsValueBase: l.CustomsValueBase.StringFixed(4),
+			DutyRate:         l.DutyRate.StringFixed(4),
+			DutyAmount:       l.DutyAmount.StringFixed(4),
+			VATRate:          l.VATRate.StringFixed(4),
+			VATAmount:        l.VATAmount.StringFixed(4),
+		}
+		if l.Product != nil {
+			line.ProductSKU = l.Product.SKU
+			line.ProductName = l.Product.Name
+		}
+		resp.Lines = append(resp.Lines, line)
+	}
+	return resp
+}
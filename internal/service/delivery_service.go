@@ -0,0 +1,472 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/routing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// --- Vehicle DTOs ---
+
+type VehicleRequest struct {
+	PlateNumber string  `json:"plate_number" binding:"required"`
+	Name        string  `json:"name"`
+	MaxWeightKg float64 `json:"max_weight_kg" binding:"min=0"` // 0 = not limited
+	MaxVolumeM3 float64 `json:"max_volume_m3" binding:"min=0"` // 0 = not limited
+	IsActive    *bool   `json:"is_active"`
+}
+
+type VehicleResponse struct {
+	ID          string  `json:"id"`
+	PlateNumber string  `json:"plate_number"`
+	Name        string  `json:"name"`
+	MaxWeightKg float64 `json:"max_weight_kg"`
+	MaxVolumeM3 float64 `json:"max_volume_m3"`
+	IsActive    bool    `json:"is_active"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+// --- Route planning DTOs ---
+
+type PlanRoutesRequest struct {
+	OrderIDs       []string `json:"order_ids" binding:"required,min=1"`
+	VehicleIDs     []string `json:"vehicle_ids"`                                      // Optional: defaults to all active vehicles
+	DepotLatitude  *float64 `json:"depot_latitude" binding:"required,min=-90,max=90"` // Warehouse the trucks leave from
+	DepotLongitude *float64 `json:"depot_longitude" binding:"required,min=-180,max=180"`
+	DepartureTime  string   `json:"departure_time"`  // Optional: RFC3339, defaults to now
+	AvgSpeedKmh    float64  `json:"avg_speed_kmh"`   // Optional: defaults to 30 km/h
+	ServiceMinutes *int     `json:"service_minutes"` // Optional: unloading time per stop, defaults to 10
+}
+
+type RouteStopResponse struct {
+	Sequence        int     `json:"sequence"`
+	OrderID         string  `json:"order_id"`
+	OrderCode       string  `json:"order_code"`
+	PartnerName     string  `json:"partner_name"`
+	Address         string  `json:"address"`
+	Latitude        float64 `json:"latitude"`
+	Longitude       float64 `json:"longitude"`
+	WeightKg        float64 `json:"weight_kg"`
+	VolumeM3        float64 `json:"volume_m3"`
+	DistanceKm      float64 `json:"distance_km"` // From the previous stop (or depot)
+	EstimatedArrive string  `json:"estimated_arrival"`
+	WaitMinutes     int     `json:"wait_minutes"`
+	EstimatedLeave  string  `json:"estimated_departure"`
+	WindowStart     *string `json:"delivery_window_start"`
+	WindowEnd       *string `json:"delivery_window_end"`
+}
+
+type VehicleRouteResponse struct {
+	VehicleID       string              `json:"vehicle_id"`
+	PlateNumber     string              `json:"plate_number"`
+	Stops           []RouteStopResponse `json:"stops"`
+	TotalDistanceKm float64             `json:"total_distance_km"`
+	LoadWeightKg    float64             `json:"load_weight_kg"`
+	LoadVolumeM3    float64             `json:"load_volume_m3"`
+	MaxWeightKg     float64             `json:"max_weight_kg"`
+	MaxVolumeM3     float64             `json:"max_volume_m3"`
+	ReturnAt        string              `json:"estimated_return"`
+}
+
+type UnassignedOrderResponse struct {
+	OrderID   string `json:"order_id"`
+	OrderCode string `json:"order_code"`
+	Reason    string `json:"reason"`
+}
+
+type RoutePlanResponse struct {
+	DepartureTime   string                    `json:"departure_time"`
+	Routes          []VehicleRouteResponse    `json:"routes"`
+	Unassigned      []UnassignedOrderResponse `json:"unassigned"`
+	TotalDistanceKm float64                   `json:"total_distance_km"`
+}
+
+// --- Interface ---
+
+type DeliveryService interface {
+	ListVehicles(ctx context.Context, activeOnly bool) ([]VehicleResponse, error)
+	CreateVehicle(ctx context.Context, userID string, req VehicleRequest) (VehicleResponse, error)
+	UpdateVehicle(ctx context.Context, userID, id string, req VehicleRequest) (VehicleResponse, error)
+	DeleteVehicle(ctx context.Context, userID, id string) error
+	PlanRoutes(ctx context.Context, req PlanRoutesRequest) (RoutePlanResponse, error)
+}
+
+type deliveryService struct {
+	vehicleRepo repository.VehicleRepository
+	orderRepo   repository.OrderRepository
+	auditRepo   repository.AuditRepository
+}
+
+func NewDeliveryService(vehicleRepo repository.VehicleRepository, orderRepo repository.OrderRepository, auditRepo repository.AuditRepository) DeliveryService {
+	return &deliveryService{vehicleRepo: vehicleRepo, orderRepo: orderRepo, auditRepo: auditRepo}
+}
+
+const (
+	defaultAvgSpeedKmh    = 30.0
+	defaultServiceMinutes = 10
+)
+
+// --- Vehicles ---
+
+func (s *deliveryService) ListVehicles(ctx context.Context, activeOnly bool) ([]VehicleResponse, error) {
+	vehicles, err := s.vehicleRepo.List(ctx, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicles: %w", err)
+	}
+
+	res := make([]VehicleResponse, 0, len(vehicles))
+	for _, v := range vehicles {
+		res = append(res, toVehicleResponse(v))
+	}
+	return res, nil
+}
+
+func (s *deliveryService) CreateVehicle(ctx context.Context, userID string, req VehicleRequest) (VehicleResponse, error) {
+	vehicle := model.Vehicle{
+		PlateNumber: req.PlateNumber,
+		Name:        req.Name,
+		MaxWeightKg: req.MaxWeightKg,
+		MaxVolumeM3: req.MaxVolumeM3,
+		IsActive:    true,
+	}
+	if req.IsActive != nil {
+		vehicle.IsActive = *req.IsActive
+	}
+
+	if err := s.vehicleRepo.Create(ctx, &vehicle); err != nil {
+		return VehicleResponse{}, fmt.Errorf("failed to create vehicle: %w", err)
+	}
+
+	s.writeAuditLog(ctx, userID, model.ActionCreateVehicle, vehicle.ID.String(), vehicle.PlateNumber, req)
+
+	return toVehicleResponse(vehicle), nil
+}
+
+func (s *deliveryService) UpdateVehicle(ctx context.Context, userID, id string, req VehicleRequest) (VehicleResponse, error) {
+	vehicleID, err := uuid.Parse(id)
+	if err != nil {
+		return VehicleResponse{}, fmt.Errorf("invalid vehicle id: %w", err)
+	}
+
+	vehicle, err := s.vehicleRepo.FindByID(ctx, vehicleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return VehicleResponse{}, fmt.Errorf("vehicle not found")
+		}
+		return VehicleResponse{}, fmt.Errorf("failed to fetch vehicle: %w", err)
+	}
+
+	vehicle.PlateNumber = req.PlateNumber
+	vehicle.Name = req.Name
+	vehicle.MaxWeightKg = req.MaxWeightKg
+	vehicle.MaxVolumeM3 = req.MaxVolumeM3
+	if req.IsActive != nil {
+		vehicle.IsActive = *req.IsActive
+	}
+
+	if err := s.vehicleRepo.Update(ctx, vehicle); err != nil {
+		return VehicleResponse{}, fmt.Errorf("failed to update vehicle: %w", err)
+	}
+
+	s.writeAuditLog(ctx, userID, model.ActionUpdateVehicle, vehicle.ID.String(), vehicle.PlateNumber, req)
+
+	return toVehicleResponse(*vehicle), nil
+}
+
+func (s *deliveryService) DeleteVehicle(ctx context.Context, userID, id string) error {
+	vehicleID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid vehicle id: %w", err)
+	}
+
+	vehicle, err := s.vehicleRepo.FindByID(ctx, vehicleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("vehicle not found")
+		}
+		return fmt.Errorf("failed to fetch vehicle: %w", err)
+	}
+
+	if err := s.vehicleRepo.Delete(ctx, vehicleID); err != nil {
+		return fmt.Errorf("failed to delete vehicle: %w", err)
+	}
+
+	s.writeAuditLog(ctx, userID, model.ActionDeleteVehicle, vehicle.ID.String(), vehicle.PlateNumber, map[string]string{"deleted_id": id})
+
+	return nil
+}
+
+// --- Route planning ---
+
+// PlanRoutes groups approved EXPORT orders into one route per vehicle. Orders that cannot be
+// planned (not approved, no coordinates, too heavy, window unreachable) are reported, not rejected.
+func (s *deliveryService) PlanRoutes(ctx context.Context, req PlanRoutesRequest) (RoutePlanResponse, error) {
+	orderIDs := make([]uuid.UUID, 0, len(req.OrderIDs))
+	for _, id := range req.OrderIDs {
+		oid, err := uuid.Parse(id)
+		if err != nil {
+			return RoutePlanResponse{}, fmt.Errorf("invalid order id %q", id)
+		}
+		orderIDs = append(orderIDs, oid)
+	}
+
+	departure := time.Now()
+	if req.DepartureTime != "" {
+		t, err := time.Parse(time.RFC3339, req.DepartureTime)
+		if err != nil {
+			return RoutePlanResponse{}, fmt.Errorf("departure_time must be an RFC3339 timestamp")
+		}
+		departure = t
+	}
+	speed := req.AvgSpeedKmh
+	if speed <= 0 {
+		speed = defaultAvgSpeedKmh
+	}
+	serviceMinutes := defaultServiceMinutes
+	if req.ServiceMinutes != nil {
+		if *req.ServiceMinutes < 0 {
+			return RoutePlanResponse{}, fmt.Errorf("service_minutes must not be negative")
+		}
+		serviceMinutes = *req.ServiceMinutes
+	}
+
+	vehicles, err := s.loadVehicles(ctx, req.VehicleIDs)
+	if err != nil {
+		return RoutePlanResponse{}, err
+	}
+	if len(vehicles) == 0 {
+		return RoutePlanResponse{}, fmt.Errorf("no active vehicles available for route planning")
+	}
+
+	orders, err := s.orderRepo.FindByIDsWithProducts(ctx, orderIDs)
+	if err != nil {
+		return RoutePlanResponse{}, fmt.Errorf("failed to fetch orders: %w", err)
+	}
+	ordersByID := make(map[string]model.Order, len(orders))
+	for _, o := range orders {
+		ordersByID[o.ID.String()] = o
+	}
+
+	plan := RoutePlanResponse{
+		DepartureTime: departure.Format(time.RFC3339),
+		Routes:        []VehicleRouteResponse{},
+		Unassigned:    []UnassignedOrderResponse{},
+	}
+
+	// Build solver stops, rejecting orders the solver cannot handle
+	stops := make([]routing.Stop, 0, len(orders))
+	seen := make(map[string]bool, len(req.OrderIDs))
+	for _, id := range orderIDs {
+		key := id.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		order, ok := ordersByID[key]
+		if !ok {
+			plan.Unassigned = append(plan.Unassigned, UnassignedOrderResponse{OrderID: key, Reason: "order not found"})
+			continue
+		}
+		if reason := routeIneligibility(order); reason != "" {
+			plan.Unassigned = append(plan.Unassigned, UnassignedOrderResponse{OrderID: key, OrderCode: order.OrderCode, Reason: reason})
+			continue
+		}
+
+		weight, volume := orderLoad(order)
+		stops = append(stops, routing.Stop{
+			ID:          key,
+			Location:    routing.Point{Lat: *order.ShippingAddress.Latitude, Lng: *order.ShippingAddress.Longitude},
+			WeightKg:    weight,
+			VolumeM3:    volume,
+			WindowStart: order.DeliveryWindowStart,
+			WindowEnd:   order.DeliveryWindowEnd,
+		})
+	}
+
+	vehiclesByID := make(map[string]model.Vehicle, len(vehicles))
+	fleet := make([]routing.Vehicle, 0, len(vehicles))
+	for _, v := range vehicles {
+		vehiclesByID[v.ID.String()] = v
+		fleet = append(fleet, routing.Vehicle{ID: v.ID.String(), MaxWeightKg: v.MaxWeightKg, MaxVolumeM3: v.MaxVolumeM3})
+	}
+
+	solution, err := routing.Solve(routing.Problem{
+		Depot:       routing.Point{Lat: *req.DepotLatitude, Lng: *req.DepotLongitude},
+		Departure:   departure,
+		SpeedKmh:    speed,
+		ServiceTime: time.Duration(serviceMinutes) * time.Minute,
+		Stops:       stops,
+		Vehicles:    fleet,
+	})
+	if err != nil {
+		return RoutePlanResponse{}, fmt.Errorf("failed to plan routes: %w", err)
+	}
+
+	for _, r := range solution.Routes {
+		v := vehiclesByID[r.VehicleID]
+		route := VehicleRouteResponse{
+			VehicleID:       r.VehicleID,
+			PlateNumber:     v.PlateNumber,
+			Stops:           make([]RouteStopResponse, 0, len(r.Stops)),
+			TotalDistanceKm: roundTo(r.TotalDistanceKm, 2),
+			LoadWeightKg:    roundTo(r.LoadWeightKg, 3),
+			LoadVolumeM3:    roundTo(r.LoadVolumeM3, 4),
+			MaxWeightKg:     v.MaxWeightKg,
+			MaxVolumeM3:     v.MaxVolumeM3,
+			ReturnAt:        r.ReturnAt.Format(time.RFC3339),
+		}
+		for _, rs := range r.Stops {
+			route.Stops = append(route.Stops, toRouteStopResponse(ordersByID[rs.StopID], rs))
+		}
+		plan.Routes = append(plan.Routes, route)
+		plan.TotalDistanceKm += r.TotalDistanceKm
+	}
+	plan.TotalDistanceKm = roundTo(plan.TotalDistanceKm, 2)
+
+	for _, u := range solution.Unassigned {
+		plan.Unassigned = append(plan.Unassigned, UnassignedOrderResponse{
+			OrderID:   u.StopID,
+			OrderCode: ordersByID[u.StopID].OrderCode,
+			Reason:    u.Reason,
+		})
+	}
+
+	return plan, nil
+}
+
+func (s *deliveryService) loadVehicles(ctx context.Context, ids []string) ([]model.Vehicle, error) {
+	if len(ids) == 0 {
+		vehicles, err := s.vehicleRepo.List(ctx, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch vehicles: %w", err)
+		}
+		return vehicles, nil
+	}
+
+	// Repeated IDs name the same vehicle, so look each one up once
+	vehicleIDs := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		vid, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vehicle id %q", id)
+		}
+		if !seen[vid] {
+			seen[vid] = true
+			vehicleIDs = append(vehicleIDs, vid)
+		}
+	}
+	vehicles, err := s.vehicleRepo.FindByIDs(ctx, vehicleIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicles: %w", err)
+	}
+	if len(vehicles) != len(vehicleIDs) {
+		return nil, fmt.Errorf("one or more vehicles not found")
+	}
+	for _, v := range vehicles {
+		if !v.IsActive {
+			return nil, fmt.Errorf("vehicle %s is inactive", v.PlateNumber)
+		}
+	}
+	return vehicles, nil
+}
+
+// routeIneligibility explains why an order cannot be put on a route, or returns "" when it can
+func routeIneligibility(order model.Order) string {
+	switch {
+	case order.Type != model.OrderTypeExport:
+		return "only EXPORT orders can be delivered"
+	case order.Status != model.OrderStatusCompleted:
+		return "order is not approved"
+	case order.FulfillmentStatus == model.FulfillmentShipped:
+		return "order has already shipped"
+	case order.ShippingAddress == nil:
+		return "order has no shipping address"
+	case order.ShippingAddress.Latitude == nil || order.ShippingAddress.Longitude == nil:
+		return "shipping address has no coordinates"
+	}
+	return ""
+}
+
+// orderLoad sums per-unit product weight and volume over the order lines
+func orderLoad(order model.Order) (float64, float64) {
+	weight, volume := 0.0, 0.0
+	for _, item := range order.Items {
+		weight += item.Product.WeightKg * float64(item.Quantity)
+		volume += item.Product.VolumeM3 * float64(item.Quantity)
+	}
+	return weight, volume
+}
+
+func toRouteStopResponse(order model.Order, rs routing.RouteStop) RouteStopResponse {
+	weight, volume := orderLoad(order)
+	res := RouteStopResponse{
+		Sequence:        rs.Sequence,
+		OrderID:         order.ID.String(),
+		OrderCode:       order.OrderCode,
+		Address:         order.ShippingAddress.FullAddress,
+		Latitude:        *order.ShippingAddress.Latitude,
+		Longitude:       *order.ShippingAddress.Longitude,
+		WeightKg:        roundTo(weight, 3),
+		VolumeM3:        roundTo(volume, 4),
+		DistanceKm:      roundTo(rs.DistanceKm, 2),
+		EstimatedArrive: rs.Arrival.Format(time.RFC3339),
+		WaitMinutes:     int(rs.Wait.Minutes()),
+		EstimatedLeave:  rs.Departure.Format(time.RFC3339),
+	}
+	if order.Partner != nil {
+		res.PartnerName = order.Partner.Name
+	}
+	if order.DeliveryWindowStart != nil {
+		ws := order.DeliveryWindowStart.Format(time.RFC3339)
+		res.WindowStart = &ws
+	}
+	if order.DeliveryWindowEnd != nil {
+		we := order.DeliveryWindowEnd.Format(time.RFC3339)
+		res.WindowEnd = &we
+	}
+	return res
+}
+
+func toVehicleResponse(v model.Vehicle) VehicleResponse {
+	return VehicleResponse{
+		ID:          v.ID.String(),
+		PlateNumber: v.PlateNumber,
+		Name:        v.Name,
+		MaxWeightKg: v.MaxWeightKg,
+		MaxVolumeM3: v.MaxVolumeM3,
+		IsActive:    v.IsActive,
+		CreatedAt:   v.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func roundTo(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
+
+func (s *deliveryService) writeAuditLog(ctx context.Context, userID, action, entityID, entityName string, details interface{}) {
+	detailsJSON, _ := json.Marshal(details)
+
+	log := model.AuditLog{
+		UserID:     parseOptionalUUID(userID),
+		Action:     action,
+		EntityID:   entityID,
+		EntityName: entityName,
+		Details:    string(detailsJSON),
+	}
+
+	// Best-effort audit log
+	_ = s.auditRepo.Log(ctx, &log)
+}
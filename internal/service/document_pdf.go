@@ -0,0 +1,449 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/pkg/numwords"
+
+	"github.com/boombuler/barcode/qr"
+	"github.com/go-pdf/fpdf"
+	"github.com/go-pdf/fpdf/contrib/barcode"
+	"github.com/shopspring/decimal"
+)
+
+// documentParty is the partner block of a printed document
+type documentParty struct {
+	Label   string
+	Name    string
+	TaxCode string
+	Address string
+}
+
+// renderInvoicePDF prints an invoice with its lines, tax breakdown and amount in words
+func renderInvoicePDF(tpl model.DocumentTemplate, inv model.Invoice, currency string) ([]byte, error) {
+	issued := inv.CreatedAt
+	if inv.ApprovedAt != nil {
+		issued = *inv.ApprovedAt
+	}
+	qrPayload := documentQRPayload(tpl, inv.ID.String(), inv.InvoiceNo, issued, inv.TotalAmount, currency)
+	pdf := newDocumentPDF(tpl, inv.InvoiceNo, issued, qrPayload)
+
+	name := inv.CompanyName
+	if name == "" && inv.Partner != nil {
+		name = inv.Partner.Name
+	}
+	// Sales invoices print the buyer; purchase and expense invoices the supplier
+	label := "Đơn vị bán hàng / Seller"
+	if inv.ReferenceType == model.RefTypeOrderExport {
+		label = "Đơn vị mua hàng / Buyer"
+	}
+	documentPartyBlock(pdf, documentParty{
+		Label:   label,
+		Name:    name,
+		TaxCode: inv.TaxCode,
+		Address: inv.BillingAddress,
+	})
+	if remark := invoiceAdjustmentRemark(inv); remark != "" {
+		pdf.SetFont(pdfFont, "B", 9)
+		pdf.MultiCell(0, 5, pdfText(remark), "", "L", false)
+		pdf.Ln(2)
+	}
+
+	widths := []float64{10, 60, 15, 25, 20, 25, 12, 23}
+	aligns := []string{"C", "L", "C", "R", "R", "R", "C", "R"}
+	slipTableHeader(pdf, widths, []string{"#", "Description", "Qty", "Unit price", "Discount", "Amount", "Tax %", "Tax"})
+	if len(inv.Lines) == 0 {
+		// Manual invoices carry only header amounts
+		documentTableRow(pdf, widths, aligns, []string{
+			"1", inv.Note, "1", formatMoney(inv.Subtotal), "", formatMoney(inv.Subtotal), "", formatMoney(inv.TaxAmount),
+		})
+	}
+	for _, l := range inv.Lines {
+		description := l.Description
+		if description == "" && l.Product != nil {
+			description = l.Product.SKU + " - " + l.Product.Name
+		}
+		taxRate := ""
+		if l.TaxType != "" {
+			taxRate = formatPercent(l.TaxRate)
+		}
+		documentTableRow(pdf, widths, aligns, []string{
+			fmt.Sprintf("%d", l.LineNo), description, fmt.Sprintf("%d", l.Quantity),
+			formatMoney(l.UnitPrice), formatMoney(l.Discount), formatMoney(l.Amount),
+			taxRate, formatMoney(l.TaxAmount),
+		})
+	}
+
+	totals := [][2]string{{"Cộng tiền hàng / Subtotal", formatMoney(inv.Subtotal)}}
+	for _, t := range invoiceTaxSummary(inv) {
+		label := "Không chịu thuế / Not subject to tax"
+		if t.TaxRate != nil {
+			rate, _ := decimal.NewFromString(*t.TaxRate)
+			label = fmt.Sprintf("Thuế %s %s / Tax on %s", strings.TrimSpace(t.TaxType), formatPercent(rate), formatMoneyString(t.TaxableAmount))
+		}
+		totals = append(totals, [2]string{label, formatMoneyString(t.TaxAmount)})
+	}
+	if !inv.SideFees.IsZero() {
+		totals = append(totals, [2]string{"Phụ phí / Side fees", formatMoney(inv.SideFees)})
+	}
+	totals = append(totals, [2]string{"Tổng cộng thanh toán / Total (" + currency + ")", formatMoney(inv.TotalAmount)})
+	documentTotals(pdf, totals)
+	documentAmountInWords(pdf, inv.TotalAmount, currency)
+
+	if inv.Note != "" && len(inv.Lines) > 0 {
+		pdf.SetFont(pdfFont, "I", 9)
+		pdf.MultiCell(0, 5, pdfText("Ghi chú / Note: "+inv.Note), "", "L", false)
+	}
+
+	slipSignatures(pdf, pdfText("Người mua hàng / Buyer"), pdfText("Người bán hàng / Seller"))
+	documentFooter(pdf, tpl)
+	return outputPDF(pdf)
+}
+
+// renderOrderPDF prints the delivery note of an EXPORT order or the goods receipt of an IMPORT order
+func renderOrderPDF(tpl model.DocumentTemplate, order model.Order, currency string) ([]byte, error) {
+	subtotal := decimal.Zero
+	for _, item := range order.Items {
+		subtotal = subtotal.Add(item.NetAmount())
+	}
+
+	qrPayload := documentQRPayload(tpl, order.ID.String(), order.OrderCode, order.CreatedAt, subtotal, currency)
+	pdf := newDocumentPDF(tpl, order.OrderCode, order.CreatedAt, qrPayload)
+
+	party := documentParty{Label: "Người nhận hàng / Consignee"}
+	if order.Type == model.OrderTypeImport {
+		party.Label = "Người giao hàng / Supplier"
+	}
+	if order.Partner != nil {
+		party.Name = order.Partner.CompanyName
+		if party.Name == "" {
+			party.Name = order.Partner.Name
+		}
+		party.TaxCode = order.Partner.TaxCode
+		party.Address = partnerBillingAddress(*order.Partner)
+	}
+	documentPartyBlock(pdf, party)
+	if order.Type == model.OrderTypeExport && order.ShippingAddress != nil {
+		pdf.MultiCell(0, 6, pdfText("Giao tới / Ship to: "+order.ShippingAddress.FullAddress), "", "L", false)
+	}
+	if order.Type == model.OrderTypeImport && order.OriginAddress != nil {
+		pdf.MultiCell(0, 6, pdfText("Xuất xứ / Ship from: "+order.OriginAddress.FullAddress), "", "L", false)
+	}
+	pdf.Ln(2)
+
+	widths := []float64{10, 30, 60, 15, 25, 20, 30}
+	aligns := []string{"C", "L", "L", "C", "R", "R", "R"}
+	slipTableHeader(pdf, widths, []string{"#", "SKU", "Product", "Qty", "Unit price", "Discount", "Amount"})
+	totalQty := 0
+	for i, item := range order.Items {
+		totalQty += item.Quantity
+		documentTableRow(pdf, widths, aligns, []string{
+			fmt.Sprintf("%d", i+1), item.Product.SKU, item.Product.Name, fmt.Sprintf("%d", item.Quantity),
+			formatMoney(decimal.NewFromFloat(item.UnitPrice)), formatMoney(decimal.NewFromFloat(item.Discount)),
+			formatMoney(item.NetAmount()),
+		})
+	}
+
+	documentTotals(pdf, [][2]string{
+		{"Tổng số lượng / Total quantity", fmt.Sprintf("%d", totalQty)},
+		{"Tổng tiền hàng / Total (" + currency + ", before tax)", formatMoney(subtotal)},
+	})
+	documentAmountInWords(pdf, subtotal, currency)
+
+	if order.Note != "" {
+		pdf.SetFont(pdfFont, "I", 9)
+		pdf.MultiCell(0, 5, pdfText("Ghi chú / Note: "+order.Note), "", "L", false)
+	}
+
+	if order.Type == model.OrderTypeImport {
+		slipSignatures(pdf, pdfText("Người giao hàng / Deliverer"), pdfText("Thủ kho / Storekeeper"))
+	} else {
+		slipSignatures(pdf, pdfText("Người nhận hàng / Receiver"), pdfText("Thủ kho / Storekeeper"))
+	}
+	documentFooter(pdf, tpl)
+	return outputPDF(pdf)
+}
+
+// renderExpenseVoucherPDF prints the payment voucher (phiếu chi) of an expense.
+// The payable amount is in the expense currency; taxes are shown in the base currency like on the expense.
+func renderExpenseVoucherPDF(tpl model.DocumentTemplate, expense model.Expense, vendor *model.Partner) ([]byte, error) {
+	docNo := expenseVoucherNo(expense)
+	qrPayload := documentQRPayload(tpl, expense.ID.String(), docNo, expense.CreatedAt, expense.TotalPayable, expense.Currency)
+	pdf := newDocumentPDF(tpl, docNo, expense.CreatedAt, qrPayload)
+
+	party := documentParty{Label: "Người nhận tiền / Payee"}
+	if vendor != nil {
+		party.Name = vendor.CompanyName
+		if party.Name == "" {
+			party.Name = vendor.Name
+		}
+		party.TaxCode = vendor.TaxCode
+		party.Address = partnerBillingAddress(*vendor)
+	}
+	if expense.VendorTaxCode != nil && *expense.VendorTaxCode != "" {
+		party.TaxCode = *expense.VendorTaxCode
+	}
+	documentPartyBlock(pdf, party)
+
+	widths := []float64{10, 80, 20, 30, 20, 30}
+	aligns := []string{"C", "L", "C", "R", "R", "R"}
+	slipTableHeader(pdf, widths, []string{"#", "Description", "Currency", "Amount", "Rate", "Amount (" + baseCurrency + ")"})
+	documentTableRow(pdf, widths, aligns, []string{
+		"1", expense.Description, expense.Currency, formatMoney(expense.OriginalAmount),
+		expense.ExchangeRate.String(), formatMoney(expense.ConvertedAmount),
+	})
+
+	totals := [][2]string{{"Số tiền / Amount (" + baseCurrency + ")", formatMoney(expense.ConvertedAmount)}}
+	if expense.VATAmount.IsPositive() {
+		totals = append(totals, [2]string{"Thuế GTGT " + formatPercent(expense.VATRate) + " / VAT (" + baseCurrency + ")", formatMoney(expense.VATAmount)})
+	}
+	if expense.FCTAmount.IsPositive() {
+		totals = append(totals, [2]string{"Thuế nhà thầu " + formatPercent(expense.FCTRate) + " (" + expense.FCTType + ") / FCT (" + baseCurrency + ")", formatMoney(expense.FCTAmount)})
+	}
+	totals = append(totals, [2]string{"Số tiền chi / Total payable (" + expense.Currency + ")", formatMoney(expense.TotalPayable)})
+	documentTotals(pdf, totals)
+	documentAmountInWords(pdf, expense.TotalPayable, expense.Currency)
+
+	pdf.SetFont(pdfFont, "", 9)
+	pdf.CellFormat(0, 5, pdfText("Chứng từ kèm theo / Document: "+expense.DocumentType), "", 1, "L", false, 0, "")
+
+	slipSignatures(pdf, pdfText("Người nhận tiền / Payee"), pdfText("Kế toán trưởng / Chief accountant"))
+	documentFooter(pdf, tpl)
+	return outputPDF(pdf)
+}
+
+// newDocumentPDF starts an A4 page with the company header, the QR code on the right and
+// the bilingual title
+func newDocumentPDF(tpl model.DocumentTemplate, docNo string, date time.Time, qrPayload string) *fpdf.Fpdf {
+	pdf := newPDF()
+	pdf.SetMargins(10, 10, 10)
+	pdf.AddPage()
+
+	const qrSize = 28.0
+	headerWidth := 190.0
+	if tpl.ShowQRCode && qrPayload != "" {
+		headerWidth -= qrSize + 4
+		key := barcode.RegisterQR(pdf, qrPayload, qr.M, qr.Unicode)
+		if pdf.Ok() {
+			barcode.Barcode(pdf, key, 200-qrSize, 10, qrSize, qrSize, false)
+		} else {
+			// A payload too long for a QR code should not prevent printing
+			pdf.ClearError()
+			headerWidth = 190
+		}
+	}
+
+	pdf.SetFont(pdfFont, "B", 11)
+	pdf.CellFormat(headerWidth, 6, pdfText(tpl.CompanyName), "", 1, "L", false, 0, "")
+	pdf.SetFont(pdfFont, "", 9)
+	for _, line := range []string{
+		labelled("MST / Tax code", tpl.CompanyTaxCode),
+		labelled("Địa chỉ / Address", tpl.CompanyAddress),
+		labelled("Điện thoại / Phone", tpl.CompanyPhone),
+		labelled("Email", tpl.CompanyEmail),
+		labelled("Tài khoản / Bank account", tpl.CompanyBankAccount),
+	} {
+		if line != "" {
+			pdf.MultiCell(headerWidth, 5, pdfText(line), "", "L", false)
+		}
+	}
+	if pdf.GetY() < 10+qrSize {
+		pdf.SetY(10 + qrSize)
+	}
+	pdf.Ln(4)
+
+	pdf.SetFont(pdfFont, "B", 16)
+	pdf.CellFormat(0, 9, pdfText(tpl.Title), "", 1, "C", false, 0, "")
+	if tpl.TitleEn != "" {
+		pdf.SetFont(pdfFont, "I", 11)
+		pdf.CellFormat(0, 6, pdfText(tpl.TitleEn), "", 1, "C", false, 0, "")
+	}
+	pdf.SetFont(pdfFont, "", 10)
+	pdf.CellFormat(95, 6, pdfText("Số / No: "+docNo), "", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 6, pdfText("Ngày / Date: "+date.Format("02/01/2006")), "", 1, "R", false, 0, "")
+	pdf.Ln(2)
+	return pdf
+}
+
+func documentPartyBlock(pdf *fpdf.Fpdf, p documentParty) {
+	pdf.SetFont(pdfFont, "B", 10)
+	pdf.CellFormat(0, 6, pdfText(p.Label+": "+p.Name), "", 1, "L", false, 0, "")
+	pdf.SetFont(pdfFont, "", 10)
+	if p.TaxCode != "" {
+		pdf.CellFormat(0, 6, pdfText("MST / Tax code: "+p.TaxCode), "", 1, "L", false, 0, "")
+	}
+	if p.Address != "" {
+		pdf.MultiCell(0, 6, pdfText("Địa chỉ / Address: "+p.Address), "", "L", false)
+	}
+	pdf.Ln(2)
+}
+
+func documentTableRow(pdf *fpdf.Fpdf, widths []float64, aligns []string, cells []string) {
+	pdf.SetFont(pdfFont, "", 9)
+	for i, c := range cells {
+		pdf.CellFormat(widths[i], 7, truncateForCell(pdf, pdfText(c), widths[i]), "1", 0, aligns[i], false, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+// documentTotals prints label/amount rows right-aligned under the table; the last row is the grand total
+func documentTotals(pdf *fpdf.Fpdf, rows [][2]string) {
+	pdf.Ln(2)
+	for i, row := range rows {
+		style := ""
+		if i == len(rows)-1 {
+			style = "B"
+		}
+		pdf.SetFont(pdfFont, style, 10)
+		pdf.CellFormat(150, 6, pdfText(row[0]), "", 0, "R", false, 0, "")
+		pdf.CellFormat(40, 6, pdfText(row[1]), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(2)
+}
+
+func documentAmountInWords(pdf *fpdf.Fpdf, amount decimal.Decimal, currency string) {
+	vi, en := amountInWords(amount, currency)
+	pdf.SetFont(pdfFont, "I", 10)
+	pdf.MultiCell(0, 5, pdfText("Số tiền viết bằng chữ: "+vi), "", "L", false)
+	pdf.MultiCell(0, 5, pdfText("In words: "+en), "", "L", false)
+	pdf.Ln(2)
+}
+
+func documentFooter(pdf *fpdf.Fpdf, tpl model.DocumentTemplate) {
+	if tpl.FooterNote == "" {
+		return
+	}
+	pdf.Ln(8)
+	pdf.SetFont(pdfFont, "I", 8)
+	pdf.MultiCell(0, 4, pdfText(tpl.FooterNote), "", "C", false)
+}
+
+// documentQRPayload links to the document when the template has a base URL, otherwise it
+// encodes the key facts so a scan can be matched against the books
+func documentQRPayload(tpl model.DocumentTemplate, id, docNo string, date time.Time, total decimal.Decimal, currency string) string {
+	if tpl.QRBaseURL != "" {
+		return strings.TrimRight(tpl.QRBaseURL, "/") + "/" + id
+	}
+	return strings.Join([]string{
+		tpl.DocType, docNo, date.Format("2006-01-02"), total.StringFixed(2), currency, tpl.CompanyTaxCode,
+	}, "|")
+}
+
+// amountInWords spells an amount in Vietnamese and English. Minor units are read as cents
+// except for currencies without them, such as VND.
+func amountInWords(amount decimal.Decimal, currency string) (string, string) {
+	amount = amount.Round(2)
+	whole := amount.Truncate(0)
+	minor := amount.Sub(whole).Mul(decimal.NewFromInt(100)).Abs().IntPart()
+
+	viUnit, viMinor, enUnit, enMinor := currency, "xu", currency, "cents"
+	switch currency {
+	case "USD":
+		viUnit, enUnit = "đô la Mỹ", "US dollars"
+	case "VND":
+		viUnit, enUnit = "đồng", "Vietnamese dong"
+	case "EUR":
+		viUnit, enUnit = "euro", "euros"
+	}
+	if model.CurrencyScale(currency) == 0 {
+		minor = 0
+	}
+
+	vi := numwords.Vietnamese(whole.IntPart()) + " " + viUnit
+	en := numwords.English(whole.IntPart()) + " " + enUnit
+	if minor > 0 {
+		vi += " và " + numwords.Vietnamese(minor) + " " + viMinor
+		en += " and " + numwords.English(minor) + " " + enMinor
+	}
+	return numwords.Capitalize(vi) + ".", numwords.Capitalize(en) + "."
+}
+
+// invoiceAdjustmentRemark explains how the invoice relates to the one it corrects, or that it
+// has been voided by a replacement
+func invoiceAdjustmentRemark(inv model.Invoice) string {
+	if inv.VoidedAt != nil {
+		return "ĐÃ BỊ THAY THẾ / VOIDED - " + inv.VoidedAt.Format("02/01/2006")
+	}
+	if inv.OriginalInvoice == nil {
+		return ""
+	}
+	remark := ""
+	switch inv.InvoiceType {
+	case model.InvoiceTypeCreditNote:
+		remark = "Điều chỉnh giảm cho hóa đơn / Credit note for invoice "
+	case model.InvoiceTypeDebitNote:
+		remark = "Điều chỉnh tăng cho hóa đơn / Debit note for invoice "
+	case model.InvoiceTypeReplacement:
+		remark = "Thay thế cho hóa đơn / Replaces invoice "
+	default:
+		return ""
+	}
+	remark += inv.OriginalInvoice.InvoiceNo + " (" + inv.OriginalInvoice.CreatedAt.Format("02/01/2006") + ")"
+	if inv.AdjustmentReason != "" {
+		remark += " - Lý do / Reason: " + inv.AdjustmentReason
+	}
+	return remark
+}
+
+// partnerBillingAddress returns the default billing address of a partner, falling back to any address
+func partnerBillingAddress(p model.Partner) string {
+	address := ""
+	for _, a := range p.Addresses {
+		if a.AddressType != model.AddressTypeBilling {
+			continue
+		}
+		if address == "" || a.IsDefault {
+			address = a.FullAddress
+		}
+	}
+	if address == "" && len(p.Addresses) > 0 {
+		address = p.Addresses[0].FullAddress
+	}
+	return address
+}
+
+// expenseVoucherNo derives the voucher number from the expense id, as expenses have no number of their own
+func expenseVoucherNo(expense model.Expense) string {
+	return "PC-" + strings.ToUpper(expense.ID.String()[:8])
+}
+
+func labelled(label, value string) string {
+	if value == "" {
+		return ""
+	}
+	return label + ": " + value
+}
+
+// formatMoney prints an amount with thousands separators and two decimals, e.g. 1,234,567.50
+func formatMoney(d decimal.Decimal) string {
+	s := d.StringFixed(2)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac := s[:len(s)-3], s[len(s)-3:]
+	var b strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return sign + b.String() + frac
+}
+
+func formatMoneyString(s string) string {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return s
+	}
+	return formatMoney(d)
+}
+
+// formatPercent prints a rate stored as a fraction (0.08) as a percentage (8%)
+func formatPercent(rate decimal.Decimal) string {
+	return rate.Mul(decimal.NewFromInt(100)).String() + "%"
+}
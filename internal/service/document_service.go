@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// --- DTOs ---
+
+type UpdateDocumentTemplateRequest struct {
+	Title              string `json:"title" binding:"required"`
+	TitleEn            string `json:"title_en"`
+	CompanyName        string `json:"company_name"`
+	CompanyTaxCode     string `json:"company_tax_code"`
+	CompanyAddress     string `json:"company_address"`
+	CompanyPhone       string `json:"company_phone"`
+	CompanyEmail       string `json:"company_email" binding:"omitempty,email"`
+	CompanyBankAccount string `json:"company_bank_account"`
+	FooterNote         string `json:"footer_note"`
+	ShowQRCode         *bool  `json:"show_qr_code"`                                // Optional: defaults to true
+	QRBaseURL          string `json:"qr_base_url" binding:"omitempty,url,max=200"` // Optional: QR links to <url>/<document id>
+}
+
+type DocumentTemplateResponse struct {
+	DocType            string `json:"doc_type"`
+	Title              string `json:"title"`
+	TitleEn            string `json:"title_en"`
+	CompanyName        string `json:"company_name"`
+	CompanyTaxCode     string `json:"company_tax_code"`
+	CompanyAddress     string `json:"company_address"`
+	CompanyPhone       string `json:"company_phone"`
+	CompanyEmail       string `json:"company_email"`
+	CompanyBankAccount string `json:"company_bank_account"`
+	FooterNote         string `json:"footer_note"`
+	ShowQRCode         bool   `json:"show_qr_code"`
+	QRBaseURL          string `json:"qr_base_url"`
+	IsDefault          bool   `json:"is_default"` // True while the built-in template is used
+	UpdatedAt          string `json:"updated_at,omitempty"`
+}
+
+// --- Interface ---
+
+type DocumentService interface {
+	ListTemplates(ctx context.Context) ([]DocumentTemplateResponse, error)
+	UpdateTemplate(ctx context.Context, docType, userID string, req UpdateDocumentTemplateRequest) (DocumentTemplateResponse, error)
+	RenderInvoice(ctx context.Context, id string) ([]byte, string, error)
+	RenderOrder(ctx context.Context, id string) ([]byte, string, error)
+	RenderExpenseVoucher(ctx context.Context, id string) ([]byte, string, error)
+}
+
+type documentService struct {
+	templateRepo repository.DocumentTemplateRepository
+	invoiceRepo  repository.InvoiceRepository
+	orderRepo    repository.OrderRepository
+	expenseRepo  repository.ExpenseRepository
+	partnerRepo  repository.PartnerRepository
+	auditRepo    repository.AuditRepository
+}
+
+func NewDocumentService(
+	templateRepo repository.DocumentTemplateRepository,
+	invoiceRepo repository.InvoiceRepository,
+	orderRepo repository.OrderRepository,
+	expenseRepo repository.ExpenseRepository,
+	partnerRepo repository.PartnerRepository,
+	auditRepo repository.AuditRepository,
+) DocumentService {
+	return &documentService{
+		templateRepo: templateRepo,
+		invoiceRepo:  invoiceRepo,
+		orderRepo:    orderRepo,
+		expenseRepo:  expenseRepo,
+		partnerRepo:  partnerRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+// documentTemplateTypes lists the printable documents in display order
+var documentTemplateTypes = []string{
+	model.DocTemplateInvoice,
+	model.DocTemplateDeliveryNote,
+	model.DocTemplateGoodsReceipt,
+	model.DocTemplatePaymentVoucher,
+}
+
+// defaultDocumentTemplate is used until a template has been configured
+func defaultDocumentTemplate(docType string) model.DocumentTemplate {
+	tpl := model.DocumentTemplate{DocType: docType, ShowQRCode: true}
+	switch docType {
+	case model.DocTemplateInvoice:
+		tpl.Title, tpl.TitleEn = "HÓA ĐƠN", "INVOICE"
+	case model.DocTemplateDeliveryNote:
+		tpl.Title, tpl.TitleEn = "PHIẾU XUẤT KHO KIÊM GIAO HÀNG", "DELIVERY NOTE"
+	case model.DocTemplateGoodsReceipt:
+		tpl.Title, tpl.TitleEn = "PHIẾU NHẬP KHO", "GOODS RECEIPT NOTE"
+	case model.DocTemplatePaymentVoucher:
+		tpl.Title, tpl.TitleEn = "PHIẾU CHI", "PAYMENT VOUCHER"
+	}
+	return tpl
+}
+
+func isDocumentTemplateType(docType string) bool {
+	for _, t := range documentTemplateTypes {
+		if t == docType {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Templates ---
+
+func (s *documentService) ListTemplates(ctx context.Context) ([]DocumentTemplateResponse, error) {
+	stored, err := s.templateRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch document templates: %w", err)
+	}
+	byType := make(map[string]model.DocumentTemplate, len(stored))
+	for _, t := range stored {
+		byType[t.DocType] = t
+	}
+
+	res := make([]DocumentTemplateResponse, 0, len(documentTemplateTypes))
+	for _, docType := range documentTemplateTypes {
+		if t, ok := byType[docType]; ok {
+			res = append(res, toDocumentTemplateResponse(t, false))
+			continue
+		}
+		res = append(res, toDocumentTemplateResponse(defaultDocumentTemplate(docType), true))
+	}
+	return res, nil
+}
+
+func (s *documentService) UpdateTemplate(ctx context.Context, docType, userID string, req UpdateDocumentTemplateRequest) (DocumentTemplateResponse, error) {
+	if !isDocumentTemplateType(docType) {
+		return DocumentTemplateResponse{}, fmt.Errorf("unknown document type %s", docType)
+	}
+
+	tpl, err := s.templateRepo.FindByDocType(ctx, docType)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return DocumentTemplateResponse{}, fmt.Errorf("failed to fetch document template: %w", err)
+		}
+		t := defaultDocumentTemplate(docType)
+		tpl = &t
+	}
+
+	tpl.Title = req.Title
+	tpl.TitleEn = req.TitleEn
+	tpl.CompanyName = req.CompanyName
+	tpl.CompanyTaxCode = req.CompanyTaxCode
+	tpl.CompanyAddress = req.CompanyAddress
+	tpl.CompanyPhone = req.CompanyPhone
+	tpl.CompanyEmail = req.CompanyEmail
+	tpl.CompanyBankAccount = req.CompanyBankAccount
+	tpl.FooterNote = req.FooterNote
+	tpl.ShowQRCode = true
+	if req.ShowQRCode != nil {
+		tpl.ShowQRCode = *req.ShowQRCode
+	}
+	tpl.QRBaseURL = req.QRBaseURL
+	tpl.UpdatedBy = parseOptionalUUID(userID)
+
+	if err := s.templateRepo.Save(ctx, tpl); err != nil {
+		return DocumentTemplateResponse{}, fmt.Errorf("failed to save document template: %w", err)
+	}
+
+	if err := s.auditRepo.Log(ctx, newAuditLog(userID, model.ActionUpdateDocumentTemplate, tpl.ID.String(), docType, req)); err != nil {
+		return DocumentTemplateResponse{}, fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return toDocumentTemplateResponse(*tpl, false), nil
+}
+
+// --- Rendering ---
+
+func (s *documentService) RenderInvoice(ctx context.Context, id string) ([]byte, string, error) {
+	invoiceID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid invoice id: %w", err)
+	}
+
+	invoice, err := s.invoiceRepo.FindByIDWithDetails(ctx, invoiceID)
+	if err != nil {
+		return nil, "", fmt.Errorf("invoice not found: %w", err)
+	}
+	tpl, err := s.template(ctx, model.DocTemplateInvoice)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pdfBytes, err := renderInvoicePDF(tpl, *invoice, baseCurrency)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render invoice: %w", err)
+	}
+	return pdfBytes, invoice.InvoiceNo + ".pdf", nil
+}
+
+// RenderOrder prints a delivery note for EXPORT orders and a goods receipt for IMPORT orders
+func (s *documentService) RenderOrder(ctx context.Context, id string) ([]byte, string, error) {
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid order id: %w", err)
+	}
+
+	order, err := s.orderRepo.FindByIDWithItems(ctx, orderID)
+	if err != nil {
+		return nil, "", fmt.Errorf("order not found: %w", err)
+	}
+
+	docType, prefix := model.DocTemplateDeliveryNote, "PXK-"
+	if order.Type == model.OrderTypeImport {
+		docType, prefix = model.DocTemplateGoodsReceipt, "PNK-"
+	}
+	tpl, err := s.template(ctx, docType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pdfBytes, err := renderOrderPDF(tpl, *order, baseCurrency)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render order document: %w", err)
+	}
+	return pdfBytes, prefix + order.OrderCode + ".pdf", nil
+}
+
+func (s *documentService) RenderExpenseVoucher(ctx context.Context, id string) ([]byte, string, error) {
+	expenseID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid expense id: %w", err)
+	}
+
+	expense, err := s.expenseRepo.FindByID(ctx, expenseID)
+	if err != nil {
+		return nil, "", fmt.Errorf("expense not found: %w", err)
+	}
+
+	var vendor *model.Partner
+	if expense.VendorID != nil {
+		// A vendor removed since the expense was recorded only blanks the payee block
+		if p, findErr := s.partnerRepo.FindByID(ctx, *expense.VendorID); findErr == nil {
+			vendor = p
+		}
+	}
+
+	tpl, err := s.template(ctx, model.DocTemplatePaymentVoucher)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pdfBytes, err := renderExpenseVoucherPDF(tpl, *expense, vendor)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render payment voucher: %w", err)
+	}
+	return pdfBytes, expenseVoucherNo(*expense) + ".pdf", nil
+}
+
+// template returns the configured template of a document type, or its built-in default
+func (s *documentService) template(ctx context.Context, docType string) (model.DocumentTemplate, error) {
+	return loadDocumentTemplate(ctx, s.templateRepo, docType)
+}
+
+func loadDocumentTemplate(ctx context.Context, templateRepo repository.DocumentTemplateRepository, docType string) (model.DocumentTemplate, error) {
+	tpl, err := templateRepo.FindByDocType(ctx, docType)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return defaultDocumentTemplate(docType), nil
+		}
+		return model.DocumentTemplate{}, fmt.Errorf("failed to fetch document template: %w", err)
+	}
+	return *tpl, nil
+}
+
+func toDocumentTemplateResponse(t model.DocumentTemplate, isDefault bool) DocumentTemplateResponse {
+	resp := DocumentTemplateResponse{
+		DocType:            t.DocType,
+		Title:              t.Title,
+		TitleEn:            t.TitleEn,
+		CompanyName:        t.CompanyName,
+		CompanyTaxCode:     t.CompanyTaxCode,
+		CompanyAddress:     t.CompanyAddress,
+		CompanyPhone:       t.CompanyPhone,
+		CompanyEmail:       t.CompanyEmail,
+		CompanyBankAccount: t.CompanyBankAccount,
+		FooterNote:         t.FooterNote,
+		ShowQRCode:         t.ShowQRCode,
+		QRBaseURL:          t.QRBaseURL,
+		IsDefault:          isDefault,
+	}
+	if !t.UpdatedAt.IsZero() {
+		resp.UpdatedAt = t.UpdatedAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/model"
+)
+
+// EInvoiceSubmission is a signed e-invoice handed to a provider
+type EInvoiceSubmission struct {
+	InvoiceNo     string
+	SellerTaxCode string
+	FormNo        string
+	Series        string
+	Number        int
+	XML           []byte
+}
+
+// EInvoiceProviderResult is the provider's answer. Status is one of the EInvoiceStatus
+// constants: ACCEPTED with the tax authority code, SUBMITTED while the authority has not
+// answered yet, or REJECTED with a message.
+type EInvoiceProviderResult struct {
+	Status           string
+	Reference        string
+	TaxAuthorityCode string
+	Message          string
+}
+
+// EInvoiceProvider transmits e-invoices to the tax authority, either directly or through an
+// accredited e-invoice service provider (T-VAN). Implementations must be safe for concurrent use.
+type EInvoiceProvider interface {
+	Name() string
+	Submit(ctx context.Context, sub EInvoiceSubmission) (EInvoiceProviderResult, error)
+	// Status polls a submission that was answered with SUBMITTED
+	Status(ctx context.Context, reference string) (EInvoiceProviderResult, error)
+}
+
+// mockEInvoiceProvider accepts every signed invoice immediately and issues a fake tax
+// authority code. It is meant for development and testing.
+type mockEInvoiceProvider struct {
+	mu      sync.Mutex
+	results map[string]EInvoiceProviderResult
+}
+
+func NewMockEInvoiceProvider() EInvoiceProvider {
+	return &mockEInvoiceProvider{results: make(map[string]EInvoiceProviderResult)}
+}
+
+func (p *mockEInvoiceProvider) Name() string {
+	return "mock"
+}
+
+func (p *mockEInvoiceProvider) Submit(ctx context.Context, sub EInvoiceSubmission) (EInvoiceProviderResult, error) {
+	if !bytes.Contains(sub.XML, []byte("<SignatureValue>")) {
+		return EInvoiceProviderResult{Status: model.EInvoiceStatusRejected, Message: "invoice is not signed"}, nil
+	}
+
+	sum := sha256.Sum256(sub.XML)
+	reference := "MOCK-" + hex.EncodeToString(sum[:8])
+	// MCCQT is 34 characters: "M", the year, and a unique code
+	code := fmt.Sprintf("M%s-%s", time.Now().Format("06"), strings.ToUpper(hex.EncodeToString(sum[:15])))
+	result := EInvoiceProviderResult{
+		Status:           model.EInvoiceStatusAccepted,
+		Reference:        reference,
+		TaxAuthorityCode: code[:34],
+		Message:          fmt.Sprintf("accepted %s/%s no. %d", sub.FormNo, sub.Series, sub.Number),
+	}
+
+	p.mu.Lock()
+	p.results[reference] = result
+	p.mu.Unlock()
+	return result, nil
+}
+
+func (p *mockEInvoiceProvider) Status(ctx context.Context, reference string) (EInvoiceProviderResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result, ok := p.results[reference]
+	if !ok {
+		return EInvoiceProviderResult{}, errors.New("unknown submission reference")
+	}
+	return result, nil
+}
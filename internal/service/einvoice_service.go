@@ -0,0 +1,548 @@
+package service
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/xsd"
+
+	"github.com/beevik/etree"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// einvoiceSchemas holds the hand-written schema of the parts of the Decision 1450 format this
+// system issues and the XML-DSig schema it imports. It is not the official GDT schema (see the
+// comment in the file) and is only used when no official schema is configured.
+//
+//go:embed schema/*.xsd
+var einvoiceSchemas embed.FS
+
+// LoadEInvoiceSchema compiles the schema e-invoices are validated against before submission:
+// the official XSD at path (with its imports beside it), or the bundled schema when path is empty
+func LoadEInvoiceSchema(path string) (*xsd.Schema, error) {
+	if path == "" {
+		return xsd.ParseFS(einvoiceSchemas, "schema/einvoice.xsd")
+	}
+	return xsd.Load(path)
+}
+
+// einvoiceFormatVersion is the PBan (data format version) written on every e-invoice
+const einvoiceFormatVersion = "2.0.1"
+
+// EInvoiceConfig holds the invoice form and series registered with the tax authority
+type EInvoiceConfig struct {
+	FormNo          string // KHMSHDon, "1" for VAT invoices
+	Series          string // KHHDon, e.g. "C26TAA"
+	ProviderTaxCode string // MSTTCGP, tax code of the e-invoice service provider (optional)
+	Schema          *xsd.Schema
+}
+
+// --- DTOs ---
+
+type IssueEInvoiceRequest struct {
+	ExchangeRate  string `json:"exchange_rate"`                   // VND per unit of the invoice currency; required unless the invoice is in VND
+	PaymentMethod string `json:"payment_method" binding:"max=50"` // Optional: defaults to "TM/CK" (cash or transfer)
+}
+
+type EInvoiceResponse struct {
+	ID               string  `json:"id"`
+	InvoiceID        string  `json:"invoice_id"`
+	FormNo           string  `json:"form_no"`
+	Series           string  `json:"series"`
+	Number           int     `json:"number"`
+	Status           string  `json:"status"`
+	Provider         string  `json:"provider"`
+	ProviderRef      string  `json:"provider_ref"`
+	TaxAuthorityCode string  `json:"tax_authority_code"`
+	CertSubject      string  `json:"cert_subject"`
+	ErrorMessage     string  `json:"error_message"`
+	SignedAt         *string `json:"signed_at"`
+	SubmittedAt      *string `json:"submitted_at"`
+	AcceptedAt       *string `json:"accepted_at"`
+	CreatedAt        string  `json:"created_at"`
+}
+
+// --- Interface ---
+
+type EInvoiceService interface {
+	IssueEInvoice(ctx context.Context, invoiceID, userID string, req IssueEInvoiceRequest) (EInvoiceResponse, error)
+	GetEInvoice(ctx context.Context, invoiceID string) (EInvoiceResponse, error)
+	GetEInvoiceXML(ctx context.Context, invoiceID string) ([]byte, string, error)
+}
+
+type einvoiceService struct {
+	einvoiceRepo repository.EInvoiceRepository
+	invoiceRepo  repository.InvoiceRepository
+	templateRepo repository.DocumentTemplateRepository
+	auditRepo    repository.AuditRepository
+	signer       *EInvoiceSigner  // nil when no certificate is configured
+	provider     EInvoiceProvider // nil when no provider is configured
+	config       EInvoiceConfig
+}
+
+func NewEInvoiceService(
+	einvoiceRepo repository.EInvoiceRepository,
+	invoiceRepo repository.InvoiceRepository,
+	templateRepo repository.DocumentTemplateRepository,
+	auditRepo repository.AuditRepository,
+	signer *EInvoiceSigner,
+	provider EInvoiceProvider,
+	config EInvoiceConfig,
+) EInvoiceService {
+	return &einvoiceService{
+		einvoiceRepo: einvoiceRepo,
+		invoiceRepo:  invoiceRepo,
+		templateRepo: templateRepo,
+		auditRepo:    auditRepo,
+		signer:       signer,
+		provider:     provider,
+		config:       config,
+	}
+}
+
+// IssueEInvoice builds the e-invoice XML of an approved sales invoice, signs it, validates it
+// against the schema and submits it. A REJECTED or unsent e-invoice is rebuilt and sent again.
+func (s *einvoiceService) IssueEInvoice(ctx context.Context, invoiceID, userID string, req IssueEInvoiceRequest) (EInvoiceResponse, error) {
+	id, err := uuid.Parse(invoiceID)
+	if err != nil {
+		return EInvoiceResponse{}, fmt.Errorf("invalid invoice id: %w", err)
+	}
+	if s.signer == nil {
+		return EInvoiceResponse{}, errors.New("e-invoice signing certificate is not configured")
+	}
+	if s.provider == nil {
+		return EInvoiceResponse{}, errors.New("e-invoice provider is not configured")
+	}
+	if s.config.Series == "" {
+		return EInvoiceResponse{}, errors.New("e-invoice series is not configured")
+	}
+
+	invoice, err := s.invoiceRepo.FindByIDWithDetails(ctx, id)
+	if err != nil {
+		return EInvoiceResponse{}, fmt.Errorf("invoice not found: %w", err)
+	}
+	if invoice.ApprovalStatus != model.ApprovalApproved {
+		return EInvoiceResponse{}, errors.New("only approved invoices can be issued as e-invoices")
+	}
+	if invoice.ReferenceType != model.RefTypeOrderExport {
+		return EInvoiceResponse{}, errors.New("only sales invoices (ORDER_EXPORT) are issued as e-invoices; purchase invoices are issued by the supplier")
+	}
+
+	if invoice.VoidedAt != nil {
+		return EInvoiceResponse{}, fmt.Errorf("invoice %s has been voided by a replacement", invoice.InvoiceNo)
+	}
+	// Notes and replacements quote the e-invoice they correct
+	var related *model.EInvoice
+	if invoice.OriginalInvoiceID != nil {
+		related, err = s.einvoiceRepo.FindByInvoiceID(ctx, *invoice.OriginalInvoiceID)
+		if err != nil || related.Status != model.EInvoiceStatusAccepted {
+			return EInvoiceResponse{}, errors.New("the original invoice must have an accepted e-invoice before its adjustment can be issued")
+		}
+	}
+
+	existing, err := s.einvoiceRepo.FindByInvoiceID(ctx, id)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return EInvoiceResponse{}, fmt.Errorf("failed to fetch e-invoice: %w", err)
+	}
+	if existing != nil && (existing.Status == model.EInvoiceStatusAccepted || existing.Status == model.EInvoiceStatusSubmitted) {
+		return EInvoiceResponse{}, fmt.Errorf("invoice %s has already been issued as an e-invoice (%s)", invoice.InvoiceNo, existing.Status)
+	}
+
+	number, err := einvoiceNumber(invoice.InvoiceNo)
+	if err != nil {
+		return EInvoiceResponse{}, err
+	}
+
+	currency := baseCurrency
+	exchangeRate := decimal.Zero
+	if currency != "VND" {
+		exchangeRate, err = decimal.NewFromString(req.ExchangeRate)
+		if err != nil || !exchangeRate.IsPositive() {
+			return EInvoiceResponse{}, fmt.Errorf("exchange_rate (VND per %s) is required", currency)
+		}
+	}
+	paymentMethod := req.PaymentMethod
+	if paymentMethod == "" {
+		paymentMethod = "TM/CK"
+	}
+
+	seller, err := loadDocumentTemplate(ctx, s.templateRepo, model.DocTemplateInvoice)
+	if err != nil {
+		return EInvoiceResponse{}, err
+	}
+	if seller.CompanyName == "" || seller.CompanyTaxCode == "" || seller.CompanyAddress == "" {
+		return EInvoiceResponse{}, errors.New("company name, tax code and address must be set on the INVOICE document template")
+	}
+
+	now := time.Now()
+	doc := buildEInvoiceXML(*invoice, related, seller, s.config, number, currency, exchangeRate, paymentMethod, now)
+	if err := s.signer.Sign(doc, now); err != nil {
+		return EInvoiceResponse{}, err
+	}
+	signedXML, err := doc.WriteToBytes()
+	if err != nil {
+		return EInvoiceResponse{}, fmt.Errorf("failed to serialize e-invoice: %w", err)
+	}
+	if err := s.config.Schema.Validate(signedXML); err != nil {
+		return EInvoiceResponse{}, fmt.Errorf("e-invoice failed schema validation: %w", err)
+	}
+
+	einvoice := existing
+	if einvoice == nil {
+		einvoice = &model.EInvoice{InvoiceID: invoice.ID}
+	}
+	einvoice.FormNo = s.config.FormNo
+	einvoice.Series = s.config.Series
+	einvoice.Number = number
+	einvoice.Status = model.EInvoiceStatusSigned
+	einvoice.Provider = s.provider.Name()
+	einvoice.ProviderRef = ""
+	einvoice.TaxAuthorityCode = ""
+	einvoice.SignedXML = string(signedXML)
+	einvoice.CertSubject = s.signer.Subject()
+	einvoice.ErrorMessage = ""
+	einvoice.SignedAt = &now
+	einvoice.SubmittedAt = nil
+	einvoice.AcceptedAt = nil
+	einvoice.IssuedBy = parseOptionalUUID(userID)
+
+	if einvoice.ID == uuid.Nil {
+		err = s.einvoiceRepo.Create(ctx, einvoice)
+	} else {
+		err = s.einvoiceRepo.Update(ctx, einvoice)
+	}
+	if err != nil {
+		return EInvoiceResponse{}, fmt.Errorf("failed to save e-invoice: %w", err)
+	}
+
+	// The signed XML is kept even when the provider cannot be reached, so it can be re-sent
+	result, submitErr := s.provider.Submit(ctx, EInvoiceSubmission{
+		InvoiceNo:     invoice.InvoiceNo,
+		SellerTaxCode: seller.CompanyTaxCode,
+		FormNo:        einvoice.FormNo,
+		Series:        einvoice.Series,
+		Number:        einvoice.Number,
+		XML:           signedXML,
+	})
+	submittedAt := time.Now()
+	if submitErr != nil {
+		einvoice.ErrorMessage = submitErr.Error()
+	} else {
+		einvoice.SubmittedAt = &submittedAt
+		if err := applyEInvoiceResult(einvoice, result); err != nil {
+			return EInvoiceResponse{}, err
+		}
+	}
+	if err := s.einvoiceRepo.Update(ctx, einvoice); err != nil {
+		return EInvoiceResponse{}, fmt.Errorf("failed to save e-invoice: %w", err)
+	}
+
+	if err := s.auditRepo.Log(ctx, newAuditLog(userID, model.ActionIssueEInvoice, einvoice.ID.String(), invoice.InvoiceNo, map[string]interface{}{
+		"invoice_id":         invoice.ID.String(),
+		"series":             einvoice.Series,
+		"number":             einvoice.Number,
+		"status":             einvoice.Status,
+		"provider":           einvoice.Provider,
+		"tax_authority_code": einvoice.TaxAuthorityCode,
+		"error":              einvoice.ErrorMessage,
+	})); err != nil {
+		return EInvoiceResponse{}, fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	if submitErr != nil {
+		return EInvoiceResponse{}, fmt.Errorf("e-invoice signed but submission failed: %w", submitErr)
+	}
+	return toEInvoiceResponse(*einvoice), nil
+}
+
+// GetEInvoice returns the e-invoice of an invoice, polling the provider while it is SUBMITTED
+func (s *einvoiceService) GetEInvoice(ctx context.Context, invoiceID string) (EInvoiceResponse, error) {
+	einvoice, err := s.findByInvoiceID(ctx, invoiceID)
+	if err != nil {
+		return EInvoiceResponse{}, err
+	}
+
+	if s.provider != nil && einvoice.Status == model.EInvoiceStatusSubmitted && einvoice.ProviderRef != "" && einvoice.Provider == s.provider.Name() {
+		result, statusErr := s.provider.Status(ctx, einvoice.ProviderRef)
+		if statusErr == nil && result.Status != model.EInvoiceStatusSubmitted {
+			if err := applyEInvoiceResult(einvoice, result); err != nil {
+				return EInvoiceResponse{}, err
+			}
+			if err := s.einvoiceRepo.Update(ctx, einvoice); err != nil {
+				return EInvoiceResponse{}, fmt.Errorf("failed to save e-invoice: %w", err)
+			}
+		}
+	}
+
+	return toEInvoiceResponse(*einvoice), nil
+}
+
+// GetEInvoiceXML returns the signed XML as stored (with MCCQT once accepted)
+func (s *einvoiceService) GetEInvoiceXML(ctx context.Context, invoiceID string) ([]byte, string, error) {
+	einvoice, err := s.findByInvoiceID(ctx, invoiceID)
+	if err != nil {
+		return nil, "", err
+	}
+	return []byte(einvoice.SignedXML), fmt.Sprintf("%s_%s_%08d.xml", einvoice.FormNo, einvoice.Series, einvoice.Number), nil
+}
+
+func (s *einvoiceService) findByInvoiceID(ctx context.Context, invoiceID string) (*model.EInvoice, error) {
+	id, err := uuid.Parse(invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid invoice id: %w", err)
+	}
+	einvoice, err := s.einvoiceRepo.FindByInvoiceID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invoice has not been issued as an e-invoice")
+		}
+		return nil, fmt.Errorf("failed to fetch e-invoice: %w", err)
+	}
+	return einvoice, nil
+}
+
+// applyEInvoiceResult records the provider's answer; an accepted invoice gets the tax
+// authority code written into its XML next to the signed data
+func applyEInvoiceResult(einvoice *model.EInvoice, result EInvoiceProviderResult) error {
+	einvoice.Status = result.Status
+	if result.Reference != "" {
+		einvoice.ProviderRef = result.Reference
+	}
+	if result.Status == model.EInvoiceStatusRejected {
+		einvoice.ErrorMessage = result.Message
+	}
+	if result.Status != model.EInvoiceStatusAccepted {
+		return nil
+	}
+
+	now := time.Now()
+	einvoice.AcceptedAt = &now
+	einvoice.TaxAuthorityCode = result.TaxAuthorityCode
+	einvoice.ErrorMessage = ""
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(einvoice.SignedXML); err != nil {
+		return fmt.Errorf("failed to read signed e-invoice: %w", err)
+	}
+	root := doc.Root()
+	if root.SelectElement("MCCQT") == nil && result.TaxAuthorityCode != "" {
+		code := etree.NewElement("MCCQT")
+		code.SetText(result.TaxAuthorityCode)
+		root.InsertChildAt(root.SelectElement("DLHDon").Index()+1, code)
+		xml, err := doc.WriteToString()
+		if err != nil {
+			return fmt.Errorf("failed to serialize e-invoice: %w", err)
+		}
+		einvoice.SignedXML = xml
+	}
+	return nil
+}
+
+// --- XML ---
+
+var trailingDigits = regexp.MustCompile(`(\d+)$`)
+
+// einvoiceNumber takes the sequence part of an invoice number (HD2026-0042 → 42) as SHDon
+func einvoiceNumber(invoiceNo string) (int, error) {
+	m := trailingDigits.FindString(invoiceNo)
+	n, err := strconv.Atoi(m)
+	if err != nil || n <= 0 || n > 99999999 {
+		return 0, fmt.Errorf("invoice number %s has no usable sequence for the e-invoice", invoiceNo)
+	}
+	return n, nil
+}
+
+// buildEInvoiceXML lays out the invoice in the Circular 78 format. Amounts are in the invoice
+// currency; TGia carries the VND exchange rate for foreign-currency invoices. Credit/debit notes
+// and replacements reference the e-invoice they correct in TTHDLQuan.
+func buildEInvoiceXML(inv model.Invoice, related *model.EInvoice, seller model.DocumentTemplate, cfg EInvoiceConfig, number int, currency string, exchangeRate decimal.Decimal, paymentMethod string, issued time.Time) *etree.Document {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+	root := doc.CreateElement("HDon")
+	data := root.CreateElement("DLHDon")
+	data.CreateAttr("Id", "data")
+
+	general := data.CreateElement("TTChung")
+	general.CreateElement("PBan").SetText(einvoiceFormatVersion)
+	general.CreateElement("THDon").SetText("HÓA ĐƠN GIÁ TRỊ GIA TĂNG")
+	general.CreateElement("KHMSHDon").SetText(cfg.FormNo)
+	general.CreateElement("KHHDon").SetText(cfg.Series)
+	general.CreateElement("SHDon").SetText(strconv.Itoa(number))
+	general.CreateElement("NLap").SetText(issued.Format("2006-01-02"))
+	general.CreateElement("DVTTe").SetText(currency)
+	if exchangeRate.IsPositive() {
+		general.CreateElement("TGia").SetText(exchangeRate.String())
+	}
+	general.CreateElement("HTTToan").SetText(paymentMethod)
+	if cfg.ProviderTaxCode != "" {
+		general.CreateElement("MSTTCGP").SetText(cfg.ProviderTaxCode)
+	}
+	if related != nil {
+		// TCHDon: 1 = replacement, 2 = adjustment; LHDCLQuan 1 = e-invoice under Decree 123
+		kind := "2"
+		if inv.InvoiceType == model.InvoiceTypeReplacement {
+			kind = "1"
+		}
+		relatedDate := related.CreatedAt
+		if related.SignedAt != nil {
+			relatedDate = *related.SignedAt
+		}
+		ref := general.CreateElement("TTHDLQuan")
+		ref.CreateElement("TCHDon").SetText(kind)
+		ref.CreateElement("LHDCLQuan").SetText("1")
+		ref.CreateElement("KHMSHDCLQuan").SetText(related.FormNo)
+		ref.CreateElement("KHHDCLQuan").SetText(related.Series)
+		ref.CreateElement("SHDCLQuan").SetText(strconv.Itoa(related.Number))
+		ref.CreateElement("NLHDCLQuan").SetText(relatedDate.Format("2006-01-02"))
+		optionalElement(ref, "GChu", inv.AdjustmentReason)
+	}
+
+	content := data.CreateElement("NDHDon")
+	sellerEl := content.CreateElement("NBan")
+	sellerEl.CreateElement("Ten").SetText(seller.CompanyName)
+	sellerEl.CreateElement("MST").SetText(seller.CompanyTaxCode)
+	sellerEl.CreateElement("DChi").SetText(seller.CompanyAddress)
+	optionalElement(sellerEl, "SDThoai", seller.CompanyPhone)
+	optionalElement(sellerEl, "DCTDTu", seller.CompanyEmail)
+	optionalElement(sellerEl, "STKNHang", seller.CompanyBankAccount)
+
+	buyerName := inv.CompanyName
+	if buyerName == "" && inv.Partner != nil {
+		buyerName = inv.Partner.Name
+	}
+	buyer := content.CreateElement("NMua")
+	optionalElement(buyer, "Ten", buyerName)
+	optionalElement(buyer, "MST", inv.TaxCode)
+	optionalElement(buyer, "DChi", inv.BillingAddress)
+
+	items := content.CreateElement("DSHHDVu")
+	if len(inv.Lines) == 0 {
+		description := inv.Note
+		if description == "" {
+			description = "Hóa đơn " + inv.InvoiceNo
+		}
+		item := items.CreateElement("HHDVu")
+		item.CreateElement("TChat").SetText("1")
+		item.CreateElement("STT").SetText("1")
+		item.CreateElement("THHDVu").SetText(description)
+		item.CreateElement("ThTien").SetText(inv.Subtotal.StringFixed(2))
+	}
+	for _, l := range inv.Lines {
+		description := l.Description
+		if description == "" && l.Product != nil {
+			description = l.Product.Name
+		}
+		item := items.CreateElement("HHDVu")
+		item.CreateElement("TChat").SetText("1")
+		item.CreateElement("STT").SetText(strconv.Itoa(l.LineNo))
+		if l.Product != nil {
+			optionalElement(item, "MHHDVu", l.Product.SKU)
+		}
+		item.CreateElement("THHDVu").SetText(description)
+		item.CreateElement("SLuong").SetText(strconv.Itoa(l.Quantity))
+		item.CreateElement("DGia").SetText(l.UnitPrice.StringFixed(4))
+		if l.Discount.IsPositive() {
+			item.CreateElement("STCKhau").SetText(l.Discount.StringFixed(2))
+		}
+		item.CreateElement("ThTien").SetText(l.Amount.StringFixed(2))
+		item.CreateElement("TSuat").SetText(einvoiceTaxRate(l.TaxType, l.TaxRate))
+	}
+
+	// Tax types that print the same rate (e.g. VAT_INLAND and VAT_INTL at 10%) share one row
+	totals := content.CreateElement("TToan")
+	byRate := totals.CreateElement("THTTLTSuat")
+	type rateTotal struct{ taxable, tax decimal.Decimal }
+	var order []string
+	sums := make(map[string]*rateTotal)
+	for _, t := range invoiceTaxSummary(inv) {
+		label := "KCT"
+		if t.TaxRate != nil {
+			label = einvoiceTaxRate(t.TaxType, decimal.RequireFromString(*t.TaxRate))
+		}
+		if _, ok := sums[label]; !ok {
+			sums[label] = &rateTotal{}
+			order = append(order, label)
+		}
+		sums[label].taxable = sums[label].taxable.Add(decimal.RequireFromString(t.TaxableAmount))
+		sums[label].tax = sums[label].tax.Add(decimal.RequireFromString(t.TaxAmount))
+	}
+	for _, label := range order {
+		row := byRate.CreateElement("LTSuat")
+		row.CreateElement("TSuat").SetText(label)
+		row.CreateElement("ThTien").SetText(sums[label].taxable.StringFixed(2))
+		row.CreateElement("TThue").SetText(sums[label].tax.StringFixed(2))
+	}
+	totals.CreateElement("TgTCThue").SetText(inv.Subtotal.StringFixed(2))
+	totals.CreateElement("TgTThue").SetText(inv.TaxAmount.StringFixed(2))
+	if !inv.SideFees.IsZero() {
+		totals.CreateElement("TgTPhi").SetText(inv.SideFees.StringFixed(2))
+	}
+	totals.CreateElement("TgTTTBSo").SetText(inv.TotalAmount.StringFixed(2))
+	inWords, _ := amountInWords(inv.TotalAmount, currency)
+	totals.CreateElement("TgTTTBChu").SetText(inWords)
+
+	signatures := root.CreateElement("DSCKS")
+	signatures.CreateElement("NBan")
+
+	doc.Indent(2)
+	return doc
+}
+
+// einvoiceTaxRate prints a line rate as the TSuat code: 0%, 5%, 8%, 10%, KCT for goods not
+// subject to VAT, or KHAC:x% for any other rate
+func einvoiceTaxRate(taxType string, rate decimal.Decimal) string {
+	if taxType == "" {
+		return "KCT"
+	}
+	percent := rate.Mul(decimal.NewFromInt(100)).Round(2)
+	switch percent.String() {
+	case "0", "5", "8", "10":
+		return percent.String() + "%"
+	}
+	return "KHAC:" + percent.String() + "%"
+}
+
+func optionalElement(parent *etree.Element, tag, value string) {
+	if value != "" {
+		parent.CreateElement(tag).SetText(value)
+	}
+}
+
+func toEInvoiceResponse(e model.EInvoice) EInvoiceResponse {
+	resp := EInvoiceResponse{
+		ID:               e.ID.String(),
+		InvoiceID:        e.InvoiceID.String(),
+		FormNo:           e.FormNo,
+		Series:           e.Series,
+		Number:           e.Number,
+		Status:           e.Status,
+		Provider:         e.Provider,
+		ProviderRef:      e.ProviderRef,
+		TaxAuthorityCode: e.TaxAuthorityCode,
+		CertSubject:      e.CertSubject,
+		ErrorMessage:     e.ErrorMessage,
+		CreatedAt:        e.CreatedAt.Format(time.RFC3339),
+	}
+	if e.SignedAt != nil {
+		t := e.SignedAt.Format(time.RFC3339)
+		resp.SignedAt = &t
+	}
+	if e.SubmittedAt != nil {
+		t := e.SubmittedAt.Format(time.RFC3339)
+		resp.SubmittedAt = &t
+	}
+	if e.AcceptedAt != nil {
+		t := e.AcceptedAt.Format(time.RFC3339)
+		resp.AcceptedAt = &t
+	}
+	return resp
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// EInvoiceSigner signs e-invoice XML with the seller's digital signature certificate
+// (chữ ký số), loaded from a PKCS#12 (.p12/.pfx) file.
+type EInvoiceSigner struct {
+	key   crypto.Signer
+	cert  *x509.Certificate
+	chain [][]byte // DER certificates, leaf first
+}
+
+// LoadEInvoiceSigner reads the certificate and private key from a PKCS#12 file
+func LoadEInvoiceSigner(path, password string) (*EInvoiceSigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+
+	key, cert, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PKCS#12 file: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("PKCS#12 private key cannot be used for signing")
+	}
+
+	chain := [][]byte{cert.Raw}
+	for _, ca := range caCerts {
+		chain = append(chain, ca.Raw)
+	}
+	return &EInvoiceSigner{key: signer, cert: cert, chain: chain}, nil
+}
+
+// Subject is the certificate holder shown on the e-invoice record
+func (s *EInvoiceSigner) Subject() string {
+	return s.cert.Subject.String()
+}
+
+// Sign adds an XML-DSig signature over DLHDon (referenced by its Id) into DSCKS/NBan.
+// The signature sits beside the signed data, so the tax authority code (MCCQT) can be
+// added later without breaking it.
+func (s *EInvoiceSigner) Sign(doc *etree.Document, at time.Time) error {
+	if at.Before(s.cert.NotBefore) || at.After(s.cert.NotAfter) {
+		return fmt.Errorf("signing certificate is only valid from %s to %s",
+			s.cert.NotBefore.Format("2006-01-02"), s.cert.NotAfter.Format("2006-01-02"))
+	}
+
+	data := doc.FindElement("/HDon/DLHDon")
+	target := doc.FindElement("/HDon/DSCKS/NBan")
+	if data == nil || target == nil {
+		return errors.New("e-invoice XML has no DLHDon or DSCKS/NBan element")
+	}
+
+	ctx, err := dsig.NewSigningContext(s.key, s.chain)
+	if err != nil {
+		return err
+	}
+	ctx.IdAttribute = "Id"
+	ctx.Prefix = ""
+	ctx.Canonicalizer = dsig.MakeC14N10RecCanonicalizer()
+
+	signature, err := ctx.ConstructSignature(data, false)
+	if err != nil {
+		return fmt.Errorf("failed to sign e-invoice: %w", err)
+	}
+	target.AddChild(signature)
+	return nil
+}
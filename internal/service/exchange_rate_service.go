@@ -0,0 +1,572 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Rate sheet quote enum constants
+const (
+	QuoteBasePerUnit  = "BASE_PER_UNIT"  // Rate is base currency per unit of the currency (e.g. EUR 1.08 with a USD base)
+	QuoteUnitsPerBase = "UNITS_PER_BASE" // Rate is units of the currency per unit of base currency (e.g. VND 25,450 with a USD base)
+)
+
+// exchangeRateMaxAge is how old the latest rate of a currency may be before it is no longer used
+// automatically: banks don't publish on weekends and holidays, but a rate older than a week is
+// more likely a missing import than a quiet market
+const exchangeRateMaxAge = 7 * 24 * time.Hour
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// baseCurrency is the currency the books are kept in (BASE_CURRENCY): ledger postings, invoice
+// lines, payment balances and taxes are stored in it, while orders, invoices, expenses and
+// payments may be issued in any currency at a rate to it. Set at startup by InitBaseCurrency.
+var baseCurrency = "USD"
+
+// InitBaseCurrency sets the company base currency; call it before serving requests
+func InitBaseCurrency(code string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !currencyCodePattern.MatchString(code) {
+		return fmt.Errorf("base currency must be a 3-letter ISO 4217 code, got %q", code)
+	}
+	baseCurrency = code
+	return nil
+}
+
+// roundBase rounds an amount in the base currency, to whole units for currencies such as VND
+func roundBase(amount decimal.Decimal) decimal.Decimal {
+	return amount.Round(model.CurrencyScale(baseCurrency))
+}
+
+// roundAmount rounds an amount in the given currency
+func roundAmount(amount decimal.Decimal, currency string) decimal.Decimal {
+	return amount.Round(model.CurrencyScale(currency))
+}
+
+// --- DTOs ---
+
+type CurrencyResponse struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	IsActive bool   `json:"is_active"`
+}
+
+type CreateCurrencyRequest struct {
+	Code string `json:"code" binding:"required"` // ISO 4217
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type UpdateCurrencyRequest struct {
+	Name     *string `json:"name" binding:"omitempty,max=100"`
+	IsActive *bool   `json:"is_active"`
+}
+
+// SetExchangeRateRequest enters the rate of a currency for a day by hand, replacing the stored one
+type SetExchangeRateRequest struct {
+	Currency string `json:"currency" binding:"required"`
+	RateDate string `json:"rate_date" binding:"required"` // YYYY-MM-DD
+	Rate     string `json:"rate" binding:"required"`      // Decimal string, quoted as Quote
+	Quote    string `json:"quote" binding:"omitempty,oneof=BASE_PER_UNIT UNITS_PER_BASE"`
+}
+
+type ExchangeRateResponse struct {
+	ID          string `json:"id"`
+	Currency    string `json:"currency"`
+	RateDate    string `json:"rate_date"`
+	Rate        string `json:"rate"`         // Base currency per unit of currency
+	InverseRate string `json:"inverse_rate"` // Units of currency per unit of base currency
+	Source      string `json:"source"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+type ExchangeRateListFilter struct {
+	Currency string
+	DateFrom string // YYYY-MM-DD
+	DateTo   string // YYYY-MM-DD
+	Page     int
+	Limit    int
+}
+
+type RateImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// RateImportResult reports a CSV import. Rows are imported all together or not at all: when
+// Errors is not empty nothing was stored.
+type RateImportResult struct {
+	Imported int               `json:"imported"`
+	Errors   []RateImportError `json:"errors"`
+}
+
+// --- Interface ---
+
+type ExchangeRateService interface {
+	SeedDefaultCurrencies(ctx context.Context) error
+	ListCurrencies(ctx context.Context) ([]CurrencyResponse, error)
+	CreateCurrency(ctx context.Context, userID string, req CreateCurrencyRequest) (CurrencyResponse, error)
+	UpdateCurrency(ctx context.Context, code string, userID string, req UpdateCurrencyRequest) (CurrencyResponse, error)
+
+	ListRates(ctx context.Context, filter ExchangeRateListFilter) ([]ExchangeRateResponse, int64, error)
+	SetRate(ctx context.Context, userID string, req SetExchangeRateRequest) (ExchangeRateResponse, error)
+	// ImportRates imports a CSV rate sheet with date, currency and rate columns (others are ignored)
+	ImportRates(ctx context.Context, userID string, quote string, sheet io.Reader) (RateImportResult, error)
+	// LookupRate returns the rate that applies to a currency on a date
+	LookupRate(ctx context.Context, currency string, date string) (ExchangeRateResponse, error)
+}
+
+type exchangeRateService struct {
+	rateRepo  repository.ExchangeRateRepository
+	auditRepo repository.AuditRepository
+	txManager repository.TransactionManager
+}
+
+func NewExchangeRateService(
+	rateRepo repository.ExchangeRateRepository,
+	auditRepo repository.AuditRepository,
+	txManager repository.TransactionManager,
+) ExchangeRateService {
+	return &exchangeRateService{
+		rateRepo:  rateRepo,
+		auditRepo: auditRepo,
+		txManager: txManager,
+	}
+}
+
+// defaultCurrencies are the currencies seeded at startup
+var defaultCurrencies = []model.Currency{
+	{Code: "USD", Name: "Đô la Mỹ"},
+	{Code: "VND", Name: "Đồng Việt Nam"},
+	{Code: "EUR", Name: "Euro"},
+	{Code: "CNY", Name: "Nhân dân tệ"},
+	{Code: "JPY", Name: "Yên Nhật"},
+	{Code: "KRW", Name: "Won Hàn Quốc"},
+	{Code: "SGD", Name: "Đô la Singapore"},
+	{Code: "THB", Name: "Baht Thái"},
+	{Code: "GBP", Name: "Bảng Anh"},
+}
+
+// --- Implementation ---
+
+func (s *exchangeRateService) SeedDefaultCurrencies(ctx context.Context) error {
+	return s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		for _, c := range defaultCurrencies {
+			currency := c
+			currency.IsActive = true
+			if err := s.rateRepo.CreateCurrencyIfMissing(txCtx, &currency); err != nil {
+				return fmt.Errorf("failed to seed currency %s: %w", c.Code, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *exchangeRateService) ListCurrencies(ctx context.Context) ([]CurrencyResponse, error) {
+	currencies, err := s.rateRepo.ListCurrencies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch currencies: %w", err)
+	}
+
+	result := make([]CurrencyResponse, 0, len(currencies))
+	for _, c := range currencies {
+		result = append(result, toCurrencyResponse(c))
+	}
+	return result, nil
+}
+
+func (s *exchangeRateService) CreateCurrency(ctx context.Context, userID string, req CreateCurrencyRequest) (CurrencyResponse, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if !currencyCodePattern.MatchString(code) {
+		return CurrencyResponse{}, fmt.Errorf("invalid currency code %q: expected a 3-letter ISO 4217 code", req.Code)
+	}
+
+	if _, err := s.rateRepo.FindCurrency(ctx, code); err == nil {
+		return CurrencyResponse{}, fmt.Errorf("currency %s already exists", code)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return CurrencyResponse{}, fmt.Errorf("failed to check currency: %w", err)
+	}
+
+	currency := &model.Currency{Code: code, Name: strings.TrimSpace(req.Name), IsActive: true}
+	if err := s.rateRepo.CreateCurrency(ctx, currency); err != nil {
+		return CurrencyResponse{}, fmt.Errorf("failed to create currency: %w", err)
+	}
+
+	_ = s.auditRepo.Log(ctx, newAuditLog(userID, model.ActionCreateCurrency, code, currency.Name, req))
+
+	return toCurrencyResponse(*currency), nil
+}
+
+func (s *exchangeRateService) UpdateCurrency(ctx context.Context, code string, userID string, req UpdateCurrencyRequest) (CurrencyResponse, error) {
+	code = strings.ToUpper(code)
+	currency, err := s.rateRepo.FindCurrency(ctx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return CurrencyResponse{}, fmt.Errorf("currency %s not found", code)
+		}
+		return CurrencyResponse{}, fmt.Errorf("failed to fetch currency: %w", err)
+	}
+
+	if req.Name != nil {
+		currency.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.IsActive != nil {
+		if !*req.IsActive && code == baseCurrency {
+			return CurrencyResponse{}, fmt.Errorf("%s is the base currency and cannot be deactivated", code)
+		}
+		currency.IsActive = *req.IsActive
+	}
+
+	if err := s.rateRepo.UpdateCurrency(ctx, currency); err != nil {
+		return CurrencyResponse{}, fmt.Errorf("failed to update currency: %w", err)
+	}
+
+	_ = s.auditRepo.Log(ctx, newAuditLog(userID, model.ActionUpdateCurrency, code, currency.Name, req))
+
+	return toCurrencyResponse(*currency), nil
+}
+
+func (s *exchangeRateService) ListRates(ctx context.Context, filter ExchangeRateListFilter) ([]ExchangeRateResponse, int64, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+
+	repoFilter := repository.ExchangeRateFilter{
+		Currency: strings.ToUpper(filter.Currency),
+		Page:     filter.Page,
+		Limit:    filter.Limit,
+	}
+	if filter.DateFrom != "" {
+		from, err := time.Parse("2006-01-02", filter.DateFrom)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid date_from, expected YYYY-MM-DD: %w", err)
+		}
+		repoFilter.DateFrom = &from
+	}
+	if filter.DateTo != "" {
+		to, err := time.Parse("2006-01-02", filter.DateTo)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid date_to, expected YYYY-MM-DD: %w", err)
+		}
+		repoFilter.DateTo = &to
+	}
+
+	rates, total, err := s.rateRepo.ListRates(ctx, repoFilter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch exchange rates: %w", err)
+	}
+
+	result := make([]ExchangeRateResponse, 0, len(rates))
+	for _, r := range rates {
+		result = append(result, toExchangeRateResponse(r))
+	}
+	return result, total, nil
+}
+
+func (s *exchangeRateService) SetRate(ctx context.Context, userID string, req SetExchangeRateRequest) (ExchangeRateResponse, error) {
+	rateDate, err := time.Parse("2006-01-02", req.RateDate)
+	if err != nil {
+		return ExchangeRateResponse{}, fmt.Errorf("invalid rate_date, expected YYYY-MM-DD: %w", err)
+	}
+	quoted, err := parseExchangeRate(req.Rate)
+	if err != nil {
+		return ExchangeRateResponse{}, err
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if err := checkRateCurrency(ctx, s.rateRepo, currency); err != nil {
+		return ExchangeRateResponse{}, err
+	}
+
+	rate := &model.ExchangeRate{
+		Currency:  currency,
+		RateDate:  rateDate,
+		Rate:      toBasePerUnit(quoted, req.Quote),
+		Source:    model.RateSourceManual,
+		CreatedBy: parseOptionalUUID(userID),
+	}
+	if err := s.rateRepo.UpsertRate(ctx, rate); err != nil {
+		return ExchangeRateResponse{}, fmt.Errorf("failed to save exchange rate: %w", err)
+	}
+
+	_ = s.auditRepo.Log(ctx, newAuditLog(userID, model.ActionSetExchangeRate, currency, currency+" "+req.RateDate, map[string]interface{}{
+		"rate":  rate.Rate.String(),
+		"quote": req.Quote,
+	}))
+
+	return toExchangeRateResponse(*rate), nil
+}
+
+func (s *exchangeRateService) ImportRates(ctx context.Context, userID string, quote string, sheet io.Reader) (RateImportResult, error) {
+	if quote == "" {
+		quote = QuoteBasePerUnit
+	}
+	if quote != QuoteBasePerUnit && quote != QuoteUnitsPerBase {
+		return RateImportResult{}, fmt.Errorf("quote must be %s or %s", QuoteBasePerUnit, QuoteUnitsPerBase)
+	}
+
+	reader := csv.NewReader(sheet)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return RateImportResult{}, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return RateImportResult{}, errors.New("the rate sheet is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"date", "currency", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return RateImportResult{}, fmt.Errorf("the rate sheet has no %q column", name)
+		}
+	}
+
+	result := RateImportResult{Errors: []RateImportError{}}
+	currencies := make(map[string]error)
+	seen := make(map[string]int)
+	rates := make([]*model.ExchangeRate, 0, len(records)-1)
+	for i, record := range records[1:] {
+		line := i + 2
+		field := func(name string) string {
+			if idx := columns[name]; idx < len(record) {
+				return strings.TrimSpace(record[idx])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		rateDate, err := parseRateSheetDate(field("date"))
+		if err != nil {
+			result.Errors = append(result.Errors, RateImportError{Line: line, Message: err.Error()})
+			continue
+		}
+
+		currency := strings.ToUpper(field("currency"))
+		currencyErr, checked := currencies[currency]
+		if !checked {
+			currencyErr = checkRateCurrency(ctx, s.rateRepo, currency)
+			currencies[currency] = currencyErr
+		}
+		if currencyErr != nil {
+			result.Errors = append(result.Errors, RateImportError{Line: line, Message: currencyErr.Error()})
+			continue
+		}
+
+		// Bank sheets write thousands separators: 25,450.00
+		quoted, err := parseExchangeRate(strings.ReplaceAll(field("rate"), ",", ""))
+		if err != nil {
+			result.Errors = append(result.Errors, RateImportError{Line: line, Message: err.Error()})
+			continue
+		}
+
+		key := currency + " " + rateDate.Format("2006-01-02")
+		if first, dup := seen[key]; dup {
+			result.Errors = append(result.Errors, RateImportError{Line: line, Message: fmt.Sprintf("%s is already on line %d", key, first)})
+			continue
+		}
+		seen[key] = line
+
+		rates = append(rates, &model.ExchangeRate{
+			Currency:  currency,
+			RateDate:  rateDate,
+			Rate:      toBasePerUnit(quoted, quote),
+			Source:    model.RateSourceImport,
+			CreatedBy: parseOptionalUUID(userID),
+		})
+	}
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+	if len(rates) == 0 {
+		return RateImportResult{}, errors.New("the rate sheet has no rates")
+	}
+
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		for _, rate := range rates {
+			if err := s.rateRepo.UpsertRate(txCtx, rate); err != nil {
+				return fmt.Errorf("failed to save exchange rate %s %s: %w", rate.Currency, rate.RateDate.Format("2006-01-02"), err)
+			}
+		}
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionImportExchangeRates, "", "exchange_rates", map[string]interface{}{
+			"quote": quote,
+			"rows":  len(rates),
+		}))
+	})
+	if err != nil {
+		return RateImportResult{}, err
+	}
+
+	result.Imported = len(rates)
+	return result, nil
+}
+
+func (s *exchangeRateService) LookupRate(ctx context.Context, currency string, date string) (ExchangeRateResponse, error) {
+	at := time.Now()
+	if date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return ExchangeRateResponse{}, fmt.Errorf("invalid date, expected YYYY-MM-DD: %w", err)
+		}
+		at = parsed
+	}
+
+	rate, err := findExchangeRate(ctx, s.rateRepo, strings.ToUpper(currency), at)
+	if err != nil {
+		return ExchangeRateResponse{}, err
+	}
+	return toExchangeRateResponse(*rate), nil
+}
+
+// --- Helpers ---
+
+// errNoExchangeRate is returned by findExchangeRate when the currency has no usable rate
+var errNoExchangeRate = errors.New("no exchange rate")
+
+// findExchangeRate returns the rate applying to a currency on a date: the latest one on or before
+// it, as long as it is not older than exchangeRateMaxAge
+func findExchangeRate(ctx context.Context, rateRepo repository.ExchangeRateRepository, currency string, at time.Time) (*model.ExchangeRate, error) {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	rate, err := rateRepo.FindRateOn(ctx, currency, day)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w for %s on %s", errNoExchangeRate, currency, day.Format("2006-01-02"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up exchange rate: %w", err)
+	}
+	if day.Sub(rate.RateDate) > exchangeRateMaxAge {
+		return nil, fmt.Errorf("%w for %s on %s: the latest one is from %s", errNoExchangeRate, currency, day.Format("2006-01-02"), rate.RateDate.Format("2006-01-02"))
+	}
+	return rate, nil
+}
+
+// documentRate is the exchange rate resolved for a new expense or order
+type documentRate struct {
+	Rate           decimal.Decimal
+	Source         string           // BASE, TABLE, OVERRIDE
+	RateDate       *time.Time       // Date of the table rate, when one was found
+	TableRate      *decimal.Decimal // Table rate an override replaced
+	OverrideReason string
+}
+
+// resolveDocumentRate picks the rate of an expense or order: 1 for the base currency, otherwise the table rate of the day
+// unless the user typed a different one together with a reason
+func resolveDocumentRate(ctx context.Context, rateRepo repository.ExchangeRateRepository, currency, typed, reason string, at time.Time) (documentRate, error) {
+	if currency == baseCurrency {
+		if typed != "" {
+			rate, err := decimal.NewFromString(typed)
+			if err != nil || !rate.Equal(decimal.NewFromInt(1)) {
+				return documentRate{}, fmt.Errorf("exchange_rate must be 1 for %s documents", baseCurrency)
+			}
+		}
+		return documentRate{Rate: decimal.NewFromInt(1), Source: model.ExpenseRateBase}, nil
+	}
+	if err := checkRateCurrency(ctx, rateRepo, currency); err != nil {
+		return documentRate{}, err
+	}
+
+	result := documentRate{}
+	table, err := findExchangeRate(ctx, rateRepo, currency, at)
+	switch {
+	case err == nil:
+		result.Rate, result.Source, result.RateDate = table.Rate, model.ExpenseRateTable, &table.RateDate
+	case errors.Is(err, errNoExchangeRate):
+		if typed == "" {
+			return documentRate{}, fmt.Errorf("%w; enter exchange_rate with exchange_rate_override_reason", err)
+		}
+	default:
+		return documentRate{}, err
+	}
+	if typed == "" {
+		return result, nil
+	}
+
+	rate, err := parseExchangeRate(typed)
+	if err != nil {
+		return documentRate{}, err
+	}
+	if table != nil && rate.Equal(table.Rate) {
+		return result, nil
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return documentRate{}, errors.New("exchange_rate_override_reason is required when exchange_rate differs from the exchange rate table")
+	}
+	if table != nil {
+		result.TableRate = &table.Rate
+	}
+	result.Rate, result.Source, result.OverrideReason = rate, model.ExpenseRateOverride, reason
+	return result, nil
+}
+
+// checkRateCurrency rejects rates for the base currency and for unknown or inactive currencies
+func checkRateCurrency(ctx context.Context, rateRepo repository.ExchangeRateRepository, code string) error {
+	if code == baseCurrency {
+		return fmt.Errorf("%s is the base currency, its rate is always 1", code)
+	}
+	currency, err := rateRepo.FindCurrency(ctx, code)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("unknown currency %q", code)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch currency: %w", err)
+	}
+	if !currency.IsActive {
+		return fmt.Errorf("currency %s is inactive", code)
+	}
+	return nil
+}
+
+// toBasePerUnit converts a quoted rate to base currency per unit of currency, the direction rates are stored in
+func toBasePerUnit(rate decimal.Decimal, quote string) decimal.Decimal {
+	if quote == QuoteUnitsPerBase {
+		return decimal.NewFromInt(1).DivRound(rate, 12)
+	}
+	return rate
+}
+
+// parseRateSheetDate accepts ISO dates and the DD/MM/YYYY dates of Vietnamese bank sheets
+func parseRateSheetDate(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "02/01/2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or DD/MM/YYYY", value)
+}
+
+func toCurrencyResponse(c model.Currency) CurrencyResponse {
+	return CurrencyResponse{Code: c.Code, Name: c.Name, IsActive: c.IsActive}
+}
+
+func toExchangeRateResponse(r model.ExchangeRate) ExchangeRateResponse {
+	return ExchangeRateResponse{
+		ID:          r.ID.String(),
+		Currency:    r.Currency,
+		RateDate:    r.RateDate.Format("2006-01-02"),
+		Rate:        r.Rate.String(),
+		InverseRate: decimal.NewFromInt(1).DivRound(r.Rate, 12).String(),
+		Source:      r.Source,
+		UpdatedAt:   r.UpdatedAt.Format(time.RFC3339),
+	}
+}
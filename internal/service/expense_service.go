@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"backend/internal/model"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 // --- DTOs ---
@@ -20,9 +23,13 @@ type CreateExpenseRequest struct {
 	VendorID string `json:"vendor_id"`
 
 	Currency       string `json:"currency" binding:"required"`
-	ExchangeRate   string `json:"exchange_rate" binding:"required"` // Decimal string
 	OriginalAmount string `json:"original_amount" binding:"required"`
 
+	// ExchangeRate (base currency per unit of currency) is looked up from the exchange rate table when
+	// empty. A typed rate that differs from the table's overrides it and needs a reason.
+	ExchangeRate               string `json:"exchange_rate"`
+	ExchangeRateOverrideReason string `json:"exchange_rate_override_reason" binding:"max=500"`
+
 	IsForeignVendor bool   `json:"is_foreign_vendor"`
 	FCTType         string `json:"fct_type"` // NET or GROSS
 
@@ -39,7 +46,10 @@ type ExpenseResponse struct {
 	Currency            string  `json:"currency"`
 	ExchangeRate        string  `json:"exchange_rate"`
 	OriginalAmount      string  `json:"original_amount"`
-	ConvertedAmountUSD  string  `json:"converted_amount_usd"`
+	ConvertedAmount     string  `json:"converted_amount"`
+	RateSource          string  `json:"rate_source"`
+	RateDate            *string `json:"rate_date"`
+	RateOverrideReason  string  `json:"rate_override_reason"`
 	IsForeignVendor     bool    `json:"is_foreign_vendor"`
 	FCTType             string  `json:"fct_type"`
 	FCTRate             string  `json:"fct_rate"`
@@ -55,32 +65,78 @@ type ExpenseResponse struct {
 	CreatedAt           string  `json:"created_at"`
 }
 
+type ExpenseRevaluationLine struct {
+	ExpenseID       string  `json:"expense_id"`
+	Description     string  `json:"description"`
+	CreatedAt       string  `json:"created_at"`
+	Currency        string  `json:"currency"`
+	OriginalAmount  string  `json:"original_amount"`
+	BookedRate      string  `json:"booked_rate"`
+	BookedAmount    string  `json:"booked_amount"`
+	CurrentRate     *string `json:"current_rate"` // Nil when the currency has no usable rate today
+	CurrentRateDate *string `json:"current_rate_date"`
+	CurrentAmount   *string `json:"current_amount"`
+	Difference      *string `json:"difference"` // Current minus booked, in the base currency
+}
+
+// ExpenseRevaluationReport restates foreign-currency expenses at today's rates. Totals only cover
+// the lines that have a current rate; currencies without one are listed in MissingRates.
+type ExpenseRevaluationReport struct {
+	AsOf               string                   `json:"as_of"`
+	BaseCurrency       string                   `json:"base_currency"` // Currency of the booked and current amounts
+	Lines              []ExpenseRevaluationLine `json:"lines"`
+	TotalBookedAmount  string                   `json:"total_booked_amount"`
+	TotalCurrentAmount string                   `json:"total_current_amount"`
+	TotalDifference    string                   `json:"total_difference"`
+	MissingRates       []string                 `json:"missing_rates"`
+}
+
+type ExpenseRevaluationFilter struct {
+	Currency string
+	DateFrom string // YYYY-MM-DD, on expense creation date
+	DateTo   string // YYYY-MM-DD, inclusive
+}
+
 // --- Interface ---
 
 type ExpenseService interface {
 	CreateExpense(ctx context.Context, userID string, req CreateExpenseRequest) (ExpenseResponse, error)
 	GetExpenses(ctx context.Context, page, limit int) ([]ExpenseResponse, int64, error)
+	// GetRevaluation reports what each foreign-currency expense would be at today's rate
+	GetRevaluation(ctx context.Context, filter ExpenseRevaluationFilter) (ExpenseRevaluationReport, error)
 }
 
 type expenseService struct {
 	expenseRepo  repository.ExpenseRepository
+	orderRepo    repository.OrderRepository
 	auditRepo    repository.AuditRepository
 	approvalRepo repository.ApprovalRepository
+	partnerRepo  repository.PartnerRepository
+	periodRepo   repository.FiscalPeriodRepository
+	rateRepo     repository.ExchangeRateRepository
 	txManager    repository.TransactionManager
 	taxService   TaxService
 }
 
 func NewExpenseService(
 	expenseRepo repository.ExpenseRepository,
+	orderRepo repository.OrderRepository,
 	auditRepo repository.AuditRepository,
 	approvalRepo repository.ApprovalRepository,
+	partnerRepo repository.PartnerRepository,
+	periodRepo repository.FiscalPeriodRepository,
+	rateRepo repository.ExchangeRateRepository,
 	txManager repository.TransactionManager,
 	taxService TaxService,
 ) ExpenseService {
 	return &expenseService{
 		expenseRepo:  expenseRepo,
+		orderRepo:    orderRepo,
 		auditRepo:    auditRepo,
 		approvalRepo: approvalRepo,
+		partnerRepo:  partnerRepo,
+		periodRepo:   periodRepo,
+		rateRepo:     rateRepo,
 		txManager:    txManager,
 		taxService:   taxService,
 	}
@@ -95,18 +151,34 @@ func (s *expenseService) CreateExpense(ctx context.Context, userID string, req C
 		return ExpenseResponse{}, fmt.Errorf("invalid original_amount: %w", err)
 	}
 
-	exchangeRate, err := decimal.NewFromString(req.ExchangeRate)
+	// ---- Currency Conversion ----
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	rate, err := resolveDocumentRate(ctx, s.rateRepo, currency, req.ExchangeRate, req.ExchangeRateOverrideReason, time.Now())
 	if err != nil {
-		return ExpenseResponse{}, fmt.Errorf("invalid exchange_rate: %w", err)
+		return ExpenseResponse{}, err
 	}
+	exchangeRate := rate.Rate
+	convertedAmount := roundBase(originalAmount.Mul(exchangeRate))
 
-	if exchangeRate.LessThanOrEqual(decimal.Zero) {
-		return ExpenseResponse{}, fmt.Errorf("exchange_rate must be greater than 0")
+	// ---- Vendor: tax rules may depend on its type and country ----
+	var vendorID *uuid.UUID
+	taxLine := TaxLineContext{Date: time.Now()}
+	if req.VendorID != "" {
+		parsed, parseErr := uuid.Parse(req.VendorID)
+		if parseErr != nil {
+			return ExpenseResponse{}, fmt.Errorf("invalid vendor_id: %w", parseErr)
+		}
+		vendor, findErr := s.partnerRepo.FindByID(ctx, parsed)
+		if findErr != nil {
+			if errors.Is(findErr, gorm.ErrRecordNotFound) {
+				return ExpenseResponse{}, errors.New("vendor not found")
+			}
+			return ExpenseResponse{}, fmt.Errorf("failed to fetch vendor: %w", findErr)
+		}
+		vendorID = &parsed
+		taxLine.PartnerType, taxLine.Country = partnerTaxContext(vendor)
 	}
 
-	// ---- Currency Conversion ----
-	convertedAmountUSD := originalAmount.Mul(exchangeRate)
-
 	// ---- FCT Logic ----
 	fctRate := decimal.Zero
 	fctAmount := decimal.Zero
@@ -117,21 +189,22 @@ func (s *expenseService) CreateExpense(ctx context.Context, userID string, req C
 			return ExpenseResponse{}, fmt.Errorf("fct_type must be NET or GROSS when is_foreign_vendor is true")
 		}
 
-		// Fetch active FCT rate from tax_rules
-		activeRate, fctErr := s.taxService.CalculateActiveTax(ctx, model.TaxTypeFCT, time.Now())
+		// Fetch the FCT rule that applies to the vendor from tax_rules
+		taxLine.TaxType = model.TaxTypeFCT
+		fctRule, fctErr := s.taxService.EvaluateTax(ctx, taxLine)
 		if fctErr != nil {
 			return ExpenseResponse{}, fmt.Errorf("failed to get active FCT rate: %w", fctErr)
 		}
-		fctRate = activeRate
+		fctRate = fctRule.Rate
 
 		switch req.FCTType {
 		case model.FCTTypeNet:
-			fctAmount = convertedAmountUSD.Mul(fctRate)
+			fctAmount = roundBase(convertedAmount.Mul(fctRate))
 		case model.FCTTypeGross:
-			fctAmount = convertedAmountUSD.Mul(fctRate).Div(decimal.NewFromInt(1).Add(fctRate))
+			fctAmount = roundBase(convertedAmount.Mul(fctRate).Div(decimal.NewFromInt(1).Add(fctRate)))
 		}
 
-		fctInOriginal := fctAmount.Div(exchangeRate)
+		fctInOriginal := roundAmount(fctAmount.Div(exchangeRate), currency)
 		totalPayable = originalAmount.Add(fctInOriginal)
 	}
 
@@ -144,10 +217,11 @@ func (s *expenseService) CreateExpense(ctx context.Context, userID string, req C
 		if req.IsForeignVendor {
 			vatType = model.TaxTypeVATIntl
 		}
-		activeVAT, vatErr := s.taxService.CalculateActiveTax(ctx, vatType, time.Now())
+		taxLine.TaxType = vatType
+		vatRule, vatErr := s.taxService.EvaluateTax(ctx, taxLine)
 		if vatErr == nil {
-			vatRate = activeVAT
-			vatAmount = convertedAmountUSD.Mul(vatRate)
+			vatRate = vatRule.Rate
+			vatAmount = roundBase(convertedAmount.Mul(vatRate))
 		}
 	}
 
@@ -162,10 +236,14 @@ func (s *expenseService) CreateExpense(ctx context.Context, userID string, req C
 
 	// ---- Build Model ----
 	expense := model.Expense{
-		Currency:            req.Currency,
+		VendorID:            vendorID,
+		Currency:            currency,
 		ExchangeRate:        exchangeRate,
 		OriginalAmount:      originalAmount,
-		ConvertedAmountUSD:  convertedAmountUSD,
+		ConvertedAmount:     convertedAmount,
+		RateSource:          rate.Source,
+		RateDate:            rate.RateDate,
+		RateOverrideReason:  rate.OverrideReason,
 		IsForeignVendor:     req.IsForeignVendor,
 		FCTType:             req.FCTType,
 		FCTRate:             fctRate,
@@ -186,14 +264,16 @@ func (s *expenseService) CreateExpense(ctx context.Context, userID string, req C
 		if parseErr != nil {
 			return ExpenseResponse{}, fmt.Errorf("invalid order_id: %w", parseErr)
 		}
-		expense.OrderID = &parsed
-	}
-	if req.VendorID != "" {
-		parsed, parseErr := uuid.Parse(req.VendorID)
-		if parseErr != nil {
-			return ExpenseResponse{}, fmt.Errorf("invalid vendor_id: %w", parseErr)
+		// Order costs are capitalised in 1562 and relieved through the landed cost of the goods,
+		// which only imports have
+		order, findErr := s.orderRepo.FindByIDWithItems(ctx, parsed)
+		if findErr != nil {
+			return ExpenseResponse{}, fmt.Errorf("order not found: %w", findErr)
+		}
+		if order.Type != model.OrderTypeImport {
+			return ExpenseResponse{}, fmt.Errorf("expenses can only be booked on IMPORT orders; order %s is %s", order.OrderCode, order.Type)
 		}
-		expense.VendorID = &parsed
+		expense.OrderID = &parsed
 	}
 
 	// Parse user UUID for audit/approval
@@ -207,70 +287,35 @@ func (s *expenseService) CreateExpense(ctx context.Context, userID string, req C
 
 	// ---- DB Transaction via TransactionManager ----
 	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
-		if createErr := s.expenseRepo.Create(txCtx, &expense); createErr != nil {
-			return fmt.Errorf("failed to create expense: %w", createErr)
+		if periodErr := checkPeriodOpen(txCtx, s.periodRepo, time.Now(), false); periodErr != nil {
+			return periodErr
 		}
-
-		// Audit log for expense creation
-		expenseAuditDetails, _ := json.Marshal(map[string]interface{}{
-			"currency":          req.Currency,
-			"exchange_rate":     req.ExchangeRate,
+		auditDetails := map[string]interface{}{
+			"currency":          currency,
+			"exchange_rate":     exchangeRate.String(),
+			"rate_source":       rate.Source,
 			"original_amount":   req.OriginalAmount,
 			"is_foreign_vendor": req.IsForeignVendor,
 			"document_type":     req.DocumentType,
 			"description":       req.Description,
-		})
-		expenseAudit := &model.AuditLog{
-			UserID:     userUUID,
-			Action:     model.ActionCreateExpense,
-			EntityID:   expense.ID.String(),
-			EntityName: req.Description,
-			Details:    string(expenseAuditDetails),
 		}
-		if auditErr := s.auditRepo.Log(txCtx, expenseAudit); auditErr != nil {
-			return fmt.Errorf("failed to write expense audit log: %w", auditErr)
+		if rate.Source == model.ExpenseRateOverride {
+			auditDetails["rate_override_reason"] = rate.OverrideReason
+			if rate.TableRate != nil {
+				auditDetails["table_rate"] = rate.TableRate.String()
+			}
 		}
-
-		// Create ApprovalRequest for this expense
-		requestData, _ := json.Marshal(map[string]interface{}{
-			"currency":          req.Currency,
-			"exchange_rate":     req.ExchangeRate,
+		requestData := map[string]interface{}{
+			"currency":          currency,
+			"exchange_rate":     exchangeRate.String(),
+			"rate_source":       rate.Source,
 			"original_amount":   req.OriginalAmount,
 			"is_foreign_vendor": req.IsForeignVendor,
 			"fct_type":          req.FCTType,
 			"document_type":     req.DocumentType,
 			"description":       req.Description,
-		})
-
-		approvalReq := &model.ApprovalRequest{
-			RequestType: model.ApprovalReqTypeCreateExpense,
-			ReferenceID: expense.ID,
-			RequestData: string(requestData),
-			RequestedBy: userUUID,
-			Status:      model.ApprovalPending,
-		}
-		if createErr := s.approvalRepo.Create(txCtx, approvalReq); createErr != nil {
-			return fmt.Errorf("failed to create approval request: %w", createErr)
-		}
-
-		// Audit log for approval request
-		auditDetails, _ := json.Marshal(map[string]interface{}{
-			"request_type": model.ApprovalReqTypeCreateExpense,
-			"reference_id": expense.ID.String(),
-			"description":  req.Description,
-		})
-		audit := &model.AuditLog{
-			UserID:     userUUID,
-			Action:     model.ActionCreateApprovalRequest,
-			EntityID:   approvalReq.ID.String(),
-			EntityName: model.ApprovalReqTypeCreateExpense,
-			Details:    string(auditDetails),
 		}
-		if auditErr := s.auditRepo.Log(txCtx, audit); auditErr != nil {
-			return fmt.Errorf("failed to write audit log: %w", auditErr)
-		}
-
-		return nil
+		return submitExpenseForApproval(txCtx, s.expenseRepo, s.auditRepo, s.approvalRepo, &expense, userUUID, auditDetails, requestData)
 	})
 
 	if err != nil {
@@ -300,15 +345,152 @@ func (s *expenseService) GetExpenses(ctx context.Context, page, limit int) ([]Ex
 	return result, total, nil
 }
 
+func (s *expenseService) GetRevaluation(ctx context.Context, filter ExpenseRevaluationFilter) (ExpenseRevaluationReport, error) {
+	var from, to *time.Time
+	if filter.DateFrom != "" {
+		t, err := time.Parse("2006-01-02", filter.DateFrom)
+		if err != nil {
+			return ExpenseRevaluationReport{}, fmt.Errorf("invalid date_from, expected YYYY-MM-DD: %w", err)
+		}
+		from = &t
+	}
+	if filter.DateTo != "" {
+		t, err := time.Parse("2006-01-02", filter.DateTo)
+		if err != nil {
+			return ExpenseRevaluationReport{}, fmt.Errorf("invalid date_to, expected YYYY-MM-DD: %w", err)
+		}
+		t = t.AddDate(0, 0, 1)
+		to = &t
+	}
+
+	expenses, err := s.expenseRepo.ListForeignCurrency(ctx, baseCurrency, strings.ToUpper(filter.Currency), from, to)
+	if err != nil {
+		return ExpenseRevaluationReport{}, fmt.Errorf("failed to fetch expenses: %w", err)
+	}
+
+	now := time.Now()
+	currentRates := make(map[string]*model.ExchangeRate)
+	report := ExpenseRevaluationReport{
+		AsOf:         now.Format("2006-01-02"),
+		BaseCurrency: baseCurrency,
+		Lines:        make([]ExpenseRevaluationLine, 0, len(expenses)),
+		MissingRates: []string{},
+	}
+	totalBooked, totalCurrent := decimal.Zero, decimal.Zero
+	for _, e := range expenses {
+		current, looked := currentRates[e.Currency]
+		if !looked {
+			current, err = findExchangeRate(ctx, s.rateRepo, e.Currency, now)
+			if err != nil && !errors.Is(err, errNoExchangeRate) {
+				return ExpenseRevaluationReport{}, err
+			}
+			if current == nil {
+				report.MissingRates = append(report.MissingRates, e.Currency)
+			}
+			currentRates[e.Currency] = current
+		}
+
+		line := ExpenseRevaluationLine{
+			ExpenseID:      e.ID.String(),
+			Description:    e.Description,
+			CreatedAt:      e.CreatedAt.Format(time.RFC3339),
+			Currency:       e.Currency,
+			OriginalAmount: e.OriginalAmount.StringFixed(4),
+			BookedRate:     e.ExchangeRate.String(),
+			BookedAmount:   e.ConvertedAmount.StringFixed(4),
+		}
+		if current != nil {
+			amount := roundBase(e.OriginalAmount.Mul(current.Rate))
+			diff := amount.Sub(e.ConvertedAmount)
+			rate, rateDate := current.Rate.String(), current.RateDate.Format("2006-01-02")
+			amountStr, diffStr := amount.StringFixed(4), diff.StringFixed(4)
+			line.CurrentRate, line.CurrentRateDate = &rate, &rateDate
+			line.CurrentAmount, line.Difference = &amountStr, &diffStr
+
+			totalBooked = totalBooked.Add(e.ConvertedAmount)
+			totalCurrent = totalCurrent.Add(amount)
+		}
+		report.Lines = append(report.Lines, line)
+	}
+
+	report.TotalBookedAmount = totalBooked.StringFixed(4)
+	report.TotalCurrentAmount = totalCurrent.StringFixed(4)
+	report.TotalDifference = totalCurrent.Sub(totalBooked).StringFixed(4)
+	return report, nil
+}
+
 // --- Helpers ---
 
+// submitExpenseForApproval stores an expense and opens its CREATE_EXPENSE approval request.
+// Must be called inside a transaction; the invoice is only created once the request is approved.
+func submitExpenseForApproval(
+	ctx context.Context,
+	expenseRepo repository.ExpenseRepository,
+	auditRepo repository.AuditRepository,
+	approvalRepo repository.ApprovalRepository,
+	expense *model.Expense,
+	userUUID *uuid.UUID,
+	auditDetails, requestData map[string]interface{},
+) error {
+	if createErr := expenseRepo.Create(ctx, expense); createErr != nil {
+		return fmt.Errorf("failed to create expense: %w", createErr)
+	}
+
+	// Audit log for expense creation
+	expenseAuditDetails, _ := json.Marshal(auditDetails)
+	expenseAudit := &model.AuditLog{
+		UserID:     userUUID,
+		Action:     model.ActionCreateExpense,
+		EntityID:   expense.ID.String(),
+		EntityName: expense.Description,
+		Details:    string(expenseAuditDetails),
+	}
+	if auditErr := auditRepo.Log(ctx, expenseAudit); auditErr != nil {
+		return fmt.Errorf("failed to write expense audit log: %w", auditErr)
+	}
+
+	// Create ApprovalRequest for this expense
+	requestJSON, _ := json.Marshal(requestData)
+	approvalReq := &model.ApprovalRequest{
+		RequestType: model.ApprovalReqTypeCreateExpense,
+		ReferenceID: expense.ID,
+		RequestData: string(requestJSON),
+		RequestedBy: userUUID,
+		Status:      model.ApprovalPending,
+	}
+	if createErr := approvalRepo.Create(ctx, approvalReq); createErr != nil {
+		return fmt.Errorf("failed to create approval request: %w", createErr)
+	}
+
+	// Audit log for approval request
+	approvalDetails, _ := json.Marshal(map[string]interface{}{
+		"request_type": model.ApprovalReqTypeCreateExpense,
+		"reference_id": expense.ID.String(),
+		"description":  expense.Description,
+	})
+	audit := &model.AuditLog{
+		UserID:     userUUID,
+		Action:     model.ActionCreateApprovalRequest,
+		EntityID:   approvalReq.ID.String(),
+		EntityName: model.ApprovalReqTypeCreateExpense,
+		Details:    string(approvalDetails),
+	}
+	if auditErr := auditRepo.Log(ctx, audit); auditErr != nil {
+		return fmt.Errorf("failed to write audit log: %w", auditErr)
+	}
+
+	return nil
+}
+
 func toExpenseResponse(e model.Expense) ExpenseResponse {
 	resp := ExpenseResponse{
 		ID:                  e.ID.String(),
 		Currency:            e.Currency,
-		ExchangeRate:        e.ExchangeRate.StringFixed(6),
+		ExchangeRate:        e.ExchangeRate.String(),
 		OriginalAmount:      e.OriginalAmount.StringFixed(4),
-		ConvertedAmountUSD:  e.ConvertedAmountUSD.StringFixed(4),
+		ConvertedAmount:     e.ConvertedAmount.StringFixed(4),
+		RateSource:          e.RateSource,
+		RateOverrideReason:  e.RateOverrideReason,
 		IsForeignVendor:     e.IsForeignVendor,
 		FCTType:             e.FCTType,
 		FCTRate:             e.FCTRate.StringFixed(4),
@@ -332,6 +514,10 @@ func toExpenseResponse(e model.Expense) ExpenseResponse {
 		s := e.VendorID.String()
 		resp.VendorID = &s
 	}
+	if e.RateDate != nil {
+		s := e.RateDate.Format("2006-01-02")
+		resp.RateDate = &s
+	}
 
 	return resp
 }
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/xlsx"
+
+	"github.com/beevik/etree"
+	"github.com/shopspring/decimal"
+)
+
+// --- DTOs ---
+
+// FCTAmountsResponse are the amounts of a foreign contractor, or of the whole declaration, in
+// whole dong
+type FCTAmountsResponse struct {
+	ContractValue  string `json:"contract_value"`  // Converted expense amount, as agreed with the contractor
+	TaxableRevenue string `json:"taxable_revenue"` // Revenue before FCT: the contract value for NET contracts, less the FCT for GROSS ones
+	VATAmount      string `json:"vat_amount"`
+	CITAmount      string `json:"cit_amount"`
+	TotalTax       string `json:"total_tax"`       // VAT plus CIT portion
+	WithheldAmount string `json:"withheld_amount"` // FCT withheld on disbursements by the end of the period
+}
+
+type FCTReturnLineResponse struct {
+	ExpenseID      string `json:"expense_id"`
+	InvoiceNo      string `json:"invoice_no"`
+	InvoiceDate    string `json:"invoice_date"`
+	VendorName     string `json:"vendor_name"`
+	VendorTaxCode  string `json:"vendor_tax_code"`
+	Country        string `json:"country"`
+	Description    string `json:"description"`
+	FCTType        string `json:"fct_type"` // NET or GROSS
+	Currency       string `json:"currency"`
+	OriginalAmount string `json:"original_amount"` // In the expense currency
+	VATRate        string `json:"vat_rate"`
+	CITRate        string `json:"cit_rate"`
+	FCTAmountsResponse
+}
+
+type FCTVendorResponse struct {
+	VendorID      *string `json:"vendor_id"`
+	VendorName    string  `json:"vendor_name"`
+	VendorTaxCode string  `json:"vendor_tax_code"`
+	Country       string  `json:"country"`
+	ContractCount int     `json:"contract_count"`
+	FCTAmountsResponse
+}
+
+// FCTReturnResponse is the foreign contractor tax declaration of a period, in whole dong: the
+// foreign vendor expenses whose invoice was approved in the period, with the VAT portion (the
+// expense VAT) and the CIT portion (the FCT computed on the expense) of each
+type FCTReturnResponse struct {
+	Period     string                  `json:"period"`
+	PeriodType string                  `json:"period_type"` // MONTH, QUARTER
+	From       string                  `json:"from"`
+	To         string                  `json:"to"`
+	Currency   string                  `json:"currency"`
+	Vendors    []FCTVendorResponse     `json:"vendors"`
+	Lines      []FCTReturnLineResponse `json:"lines"`
+	Totals     FCTAmountsResponse      `json:"totals"`
+}
+
+// --- Implementation ---
+
+// fctAmounts are declared amounts in VND
+type fctAmounts struct {
+	contractValue decimal.Decimal
+	revenue       decimal.Decimal
+	vat           decimal.Decimal
+	cit           decimal.Decimal
+	withheld      decimal.Decimal
+}
+
+func (a fctAmounts) add(b fctAmounts) fctAmounts {
+	return fctAmounts{
+		contractValue: a.contractValue.Add(b.contractValue),
+		revenue:       a.revenue.Add(b.revenue),
+		vat:           a.vat.Add(b.vat),
+		cit:           a.cit.Add(b.cit),
+		withheld:      a.withheld.Add(b.withheld),
+	}
+}
+
+type fctLine struct {
+	row repository.FCTExpenseRow
+	fctAmounts
+}
+
+type fctVendor struct {
+	row   repository.FCTExpenseRow // First expense of the vendor, for its details
+	count int
+	fctAmounts
+}
+
+// fctReturn holds a computed declaration; vendors are in the order of their first line
+type fctReturn struct {
+	period  taxPeriod
+	lines   []fctLine
+	vendors []*fctVendor
+	totals  fctAmounts
+}
+
+func (s *taxReportService) GetFCTReturn(ctx context.Context, period string) (FCTReturnResponse, error) {
+	r, err := s.buildFCTReturn(ctx, period)
+	if err != nil {
+		return FCTReturnResponse{}, err
+	}
+
+	resp := FCTReturnResponse{
+		Period:     r.period.Code,
+		PeriodType: r.period.Type,
+		From:       r.period.From.Format("2006-01-02"),
+		To:         r.period.LastDay.Format("2006-01-02"),
+		Currency:   declarationCurrency,
+		Vendors:    make([]FCTVendorResponse, 0, len(r.vendors)),
+		Lines:      make([]FCTReturnLineResponse, 0, len(r.lines)),
+		Totals:     toFCTAmountsResponse(r.totals),
+	}
+	for _, v := range r.vendors {
+		vendor := FCTVendorResponse{
+			VendorName:         v.row.VendorName,
+			VendorTaxCode:      v.row.VendorTaxCode,
+			Country:            v.row.Country,
+			ContractCount:      v.count,
+			FCTAmountsResponse: toFCTAmountsResponse(v.fctAmounts),
+		}
+		if v.row.VendorID != nil {
+			id := v.row.VendorID.String()
+			vendor.VendorID = &id
+		}
+		resp.Vendors = append(resp.Vendors, vendor)
+	}
+	for _, l := range r.lines {
+		resp.Lines = append(resp.Lines, FCTReturnLineResponse{
+			ExpenseID:          l.row.ExpenseID.String(),
+			InvoiceNo:          l.row.InvoiceNo,
+			InvoiceDate:        l.row.InvoiceDate.Format("2006-01-02"),
+			VendorName:         l.row.VendorName,
+			VendorTaxCode:      l.row.VendorTaxCode,
+			Country:            l.row.Country,
+			Description:        l.row.Description,
+			FCTType:            l.row.FCTType,
+			Currency:           l.row.Currency,
+			OriginalAmount:     l.row.OriginalAmount.StringFixed(4),
+			VATRate:            l.row.VATRate.StringFixed(4),
+			CITRate:            l.row.FCTRate.StringFixed(4),
+			FCTAmountsResponse: toFCTAmountsResponse(l.fctAmounts),
+		})
+	}
+	return resp, nil
+}
+
+// ExportFCTReturn renders the declaration as the XML of form 01/NTNN with one detail per
+// contract, or as an XLSX workbook with the contracts and the per-vendor summary
+func (s *taxReportService) ExportFCTReturn(ctx context.Context, period, format string) ([]byte, string, string, error) {
+	if format != "xml" && format != "xlsx" {
+		return nil, "", "", fmt.Errorf("invalid format %q; use xml or xlsx", format)
+	}
+	r, err := s.buildFCTReturn(ctx, period)
+	if err != nil {
+		return nil, "", "", err
+	}
+	name := "fct-return-" + r.period.Code
+
+	if format == "xlsx" {
+		data, err := xlsx.WriteBook(fctReturnSheets(r)...)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to write FCT return workbook: %w", err)
+		}
+		return data, name + ".xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+	}
+
+	company, err := s.loadTaxpayer(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+	doc := buildFCTReturnXML(r, company, time.Now())
+	doc.Indent(2)
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to write FCT return XML: %w", err)
+	}
+	return data, name + ".xml", "application/xml", nil
+}
+
+func (s *taxReportService) buildFCTReturn(ctx context.Context, code string) (fctReturn, error) {
+	period, err := parseTaxPeriod(code)
+	if err != nil {
+		return fctReturn{}, err
+	}
+	rows, err := s.taxReportRepo.ListFCTExpenses(ctx, period.From, period.To)
+	if err != nil {
+		return fctReturn{}, fmt.Errorf("failed to fetch foreign vendor expenses: %w", err)
+	}
+
+	conv := s.newVNDConverter(ctx)
+	r := fctReturn{period: period}
+	vendors := make(map[string]*fctVendor)
+	for _, row := range rows {
+		// NET contracts are priced before FCT, GROSS ones include it
+		revenue := row.ConvertedAmount
+		if row.FCTType == model.FCTTypeGross {
+			revenue = revenue.Sub(row.FCTAmount)
+		}
+		var a fctAmounts
+		for _, c := range []struct {
+			dst *decimal.Decimal
+			src decimal.Decimal
+		}{
+			{&a.contractValue, row.ConvertedAmount},
+			{&a.revenue, revenue},
+			{&a.vat, row.VATAmount},
+			{&a.cit, row.FCTAmount},
+			{&a.withheld, row.WithheldAmount},
+		} {
+			if *c.dst, err = conv.convert(c.src, row.InvoiceDate); err != nil {
+				return fctReturn{}, err
+			}
+		}
+		r.lines = append(r.lines, fctLine{row: row, fctAmounts: a})
+		r.totals = r.totals.add(a)
+
+		key := row.VendorName
+		if row.VendorID != nil {
+			key = row.VendorID.String()
+		}
+		v, ok := vendors[key]
+		if !ok {
+			v = &fctVendor{row: row}
+			vendors[key] = v
+			r.vendors = append(r.vendors, v)
+		}
+		v.count++
+		v.fctAmounts = v.fctAmounts.add(a)
+	}
+	return r, nil
+}
+
+// fctReturnSheets lays the declaration out as the list of contracts and the vendor summary
+func fctReturnSheets(r fctReturn) []xlsx.Sheet {
+	amounts := func(a fctAmounts) []interface{} {
+		return []interface{}{
+			xlsx.Number(a.contractValue.StringFixed(0)), xlsx.Number(a.revenue.StringFixed(0)),
+			xlsx.Number(a.vat.StringFixed(0)), xlsx.Number(a.cit.StringFixed(0)),
+			xlsx.Number(a.vat.Add(a.cit).StringFixed(0)), xlsx.Number(a.withheld.StringFixed(0)),
+		}
+	}
+	amountHeaders := []interface{}{"Giá trị hợp đồng", "Doanh thu tính thuế", "Thuế GTGT", "Thuế TNDN", "Tổng số thuế", "Số thuế đã khấu trừ"}
+
+	lines := xlsx.Sheet{Name: "01-NTNN", Header: true}
+	lines.AddRow(append([]interface{}{"STT", "Tên nhà thầu", "MST nhà thầu", "Quốc gia", "Số hóa đơn", "Ngày hóa đơn",
+		"Nội dung", "Loại hợp đồng", "Tiền tệ", "Giá trị nguyên tệ", "Tỷ lệ GTGT", "Tỷ lệ TNDN"}, amountHeaders...)...)
+	for i, l := range r.lines {
+		lines.AddRow(append([]interface{}{i + 1, l.row.VendorName, l.row.VendorTaxCode, l.row.Country, l.row.InvoiceNo,
+			l.row.InvoiceDate.Format("02/01/2006"), l.row.Description, l.row.FCTType, l.row.Currency,
+			xlsx.Number(l.row.OriginalAmount.StringFixed(4)), formatPercent(l.row.VATRate), formatPercent(l.row.FCTRate)},
+			amounts(l.fctAmounts)...)...)
+	}
+	lines.AddRow(append([]interface{}{nil, "Tổng cộng", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil}, amounts(r.totals)...)...)
+
+	vendors := xlsx.Sheet{Name: "Nhà thầu", Header: true}
+	vendors.AddRow(append([]interface{}{"STT", "Tên nhà thầu", "MST nhà thầu", "Quốc gia", "Số hợp đồng"}, amountHeaders...)...)
+	for i, v := range r.vendors {
+		vendors.AddRow(append([]interface{}{i + 1, v.row.VendorName, v.row.VendorTaxCode, v.row.Country, v.count},
+			amounts(v.fctAmounts)...)...)
+	}
+	vendors.AddRow(append([]interface{}{nil, "Tổng cộng", nil, nil, len(r.lines)}, amounts(r.totals)...)...)
+
+	return []xlsx.Sheet{lines, vendors}
+}
+
+// buildFCTReturnXML lays the declaration out as the XML of form 01/NTNN (Circular 80/2021), the
+// return the Vietnamese party files for the tax it withholds from foreign contractors: the
+// general information and one detail per contract with its VAT and CIT portions
+func buildFCTReturnXML(r fctReturn, company model.DocumentTemplate, now time.Time) *etree.Document {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+	root := doc.CreateElement("HSoThueDTu")
+	root.CreateAttr("xmlns", "http://kekhaithue.gdt.gov.vn/TKhaiThue")
+	filing := root.CreateElement("HSoKhaiThue")
+
+	general := filing.CreateElement("TTinChung")
+	provider := general.CreateElement("TTinDVu")
+	provider.CreateElement("maDVu").SetText("HTKK")
+	provider.CreateElement("tenDVu").SetText("HTKK")
+
+	info := general.CreateElement("TTinTKhaiThue")
+	form := info.CreateElement("TKhaiThue")
+	form.CreateElement("maTKhai").SetText("01/NTNN")
+	form.CreateElement("tenTKhai").SetText("TỜ KHAI THUẾ NHÀ THẦU NƯỚC NGOÀI (Mẫu số 01/NTNN)")
+	form.CreateElement("moTaBMau").SetText("(Ban hành kèm theo Thông tư số 80/2021/TT-BTC ngày 29 tháng 9 năm 2021 của Bộ trưởng Bộ Tài chính)")
+	form.CreateElement("loaiTKhai").SetText("C") // Chính thức (original return)
+	form.CreateElement("soLan").SetText("0")
+	declarationPeriodXML(form, r.period)
+	form.CreateElement("ngayLapTKhai").SetText(now.Format("2006-01-02"))
+	taxpayerXML(info, company)
+
+	body := filing.CreateElement("CTieuTKhaiChinh")
+	contracts := body.CreateElement("NhaThauNuocNgoai")
+	for i, l := range r.lines {
+		item := contracts.CreateElement("ChiTiet")
+		item.CreateElement("stt").SetText(fmt.Sprint(i + 1))
+		item.CreateElement("tenNhaThau").SetText(l.row.VendorName)
+		optionalElement(item, "mstNhaThau", l.row.VendorTaxCode)
+		optionalElement(item, "quocGia", l.row.Country)
+		item.CreateElement("soHDon").SetText(l.row.InvoiceNo)
+		item.CreateElement("ngayHDon").SetText(l.row.InvoiceDate.Format("02/01/2006"))
+		item.CreateElement("noiDung").SetText(l.row.Description)
+		item.CreateElement("giaTriHDong").SetText(l.contractValue.StringFixed(0))
+		item.CreateElement("dThuTinhThueGTGT").SetText(l.revenue.StringFixed(0))
+		item.CreateElement("tyLeGTGT").SetText(formatPercent(l.row.VATRate))
+		item.CreateElement("thueGTGT").SetText(l.vat.StringFixed(0))
+		item.CreateElement("dThuTinhThueTNDN").SetText(l.revenue.StringFixed(0))
+		item.CreateElement("tyLeTNDN").SetText(formatPercent(l.row.FCTRate))
+		item.CreateElement("thueTNDN").SetText(l.cit.StringFixed(0))
+		item.CreateElement("tongThue").SetText(l.vat.Add(l.cit).StringFixed(0))
+	}
+	totals := body.CreateElement("TongCong")
+	totals.CreateElement("giaTriHDong").SetText(r.totals.contractValue.StringFixed(0))
+	totals.CreateElement("dThuTinhThue").SetText(r.totals.revenue.StringFixed(0))
+	totals.CreateElement("thueGTGT").SetText(r.totals.vat.StringFixed(0))
+	totals.CreateElement("thueTNDN").SetText(r.totals.cit.StringFixed(0))
+	totals.CreateElement("tongThue").SetText(r.totals.vat.Add(r.totals.cit).StringFixed(0))
+	return doc
+}
+
+// --- Mapping ---
+
+func toFCTAmountsResponse(a fctAmounts) FCTAmountsResponse {
+	return FCTAmountsResponse{
+		ContractValue:  a.contractValue.StringFixed(0),
+		TaxableRevenue: a.revenue.StringFixed(0),
+		VATAmount:      a.vat.StringFixed(0),
+		CITAmount:      a.cit.StringFixed(0),
+		TotalTax:       a.vat.Add(a.cit).StringFixed(0),
+		WithheldAmount: a.withheld.StringFixed(0),
+	}
+}
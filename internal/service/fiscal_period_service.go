@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// --- DTOs ---
+
+type FiscalPeriodResponse struct {
+	Period       string  `json:"period"` // YYYY-MM
+	Year         int     `json:"year"`
+	Month        int     `json:"month"`
+	Status       string  `json:"status"`
+	ClosedBy     *string `json:"closed_by"`
+	ClosedAt     *string `json:"closed_at"`
+	ReopenedBy   *string `json:"reopened_by"`
+	ReopenedAt   *string `json:"reopened_at"`
+	ReopenReason string  `json:"reopen_reason"`
+}
+
+// ClosePeriodRequest moves a period to a stricter status
+type ClosePeriodRequest struct {
+	Status string `json:"status" binding:"required,oneof=SOFT_CLOSED CLOSED"`
+}
+
+// ReopenPeriodRequest moves a period back to a looser status; the reason is kept on the period
+// and in the audit log
+type ReopenPeriodRequest struct {
+	Status string `json:"status" binding:"required,oneof=OPEN SOFT_CLOSED"`
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// --- Interface ---
+
+type FiscalPeriodService interface {
+	ListPeriods(ctx context.Context, year int) ([]FiscalPeriodResponse, error)
+	ClosePeriod(ctx context.Context, period string, userID string, req ClosePeriodRequest) (FiscalPeriodResponse, error)
+	ReopenPeriod(ctx context.Context, period string, userID string, req ReopenPeriodRequest) (FiscalPeriodResponse, error)
+}
+
+type fiscalPeriodService struct {
+	periodRepo repository.FiscalPeriodRepository
+	auditRepo  repository.AuditRepository
+	txManager  repository.TransactionManager
+}
+
+func NewFiscalPeriodService(
+	periodRepo repository.FiscalPeriodRepository,
+	auditRepo repository.AuditRepository,
+	txManager repository.TransactionManager,
+) FiscalPeriodService {
+	return &fiscalPeriodService{
+		periodRepo: periodRepo,
+		auditRepo:  auditRepo,
+		txManager:  txManager,
+	}
+}
+
+// periodStatusRank orders statuses from loosest to strictest
+var periodStatusRank = map[string]int{
+	model.PeriodOpen:       0,
+	model.PeriodSoftClosed: 1,
+	model.PeriodClosed:     2,
+}
+
+// --- Implementation ---
+
+// ListPeriods returns the twelve months of a year (the current year when 0); months never
+// closed are reported as OPEN
+func (s *fiscalPeriodService) ListPeriods(ctx context.Context, year int) ([]FiscalPeriodResponse, error) {
+	if year == 0 {
+		year = time.Now().Year()
+	}
+
+	periods, err := s.periodRepo.ListByYear(ctx, year)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fiscal periods: %w", err)
+	}
+	byMonth := make(map[int]model.FiscalPeriod, len(periods))
+	for _, p := range periods {
+		byMonth[p.Month] = p
+	}
+
+	result := make([]FiscalPeriodResponse, 0, 12)
+	for month := 1; month <= 12; month++ {
+		p, ok := byMonth[month]
+		if !ok {
+			p = model.FiscalPeriod{Year: year, Month: month, Status: model.PeriodOpen}
+		}
+		result = append(result, toFiscalPeriodResponse(p))
+	}
+	return result, nil
+}
+
+func (s *fiscalPeriodService) ClosePeriod(ctx context.Context, period string, userID string, req ClosePeriodRequest) (FiscalPeriodResponse, error) {
+	year, month, err := parsePeriod(period)
+	if err != nil {
+		return FiscalPeriodResponse{}, err
+	}
+
+	var p *model.FiscalPeriod
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		var err error
+		p, err = lockFiscalPeriod(txCtx, s.periodRepo, year, month)
+		if err != nil {
+			return err
+		}
+		if periodStatusRank[req.Status] <= periodStatusRank[p.Status] {
+			return fmt.Errorf("period %s is already %s", period, p.Status)
+		}
+
+		from := p.Status
+		now := time.Now()
+		p.Status = req.Status
+		p.ClosedBy = parseOptionalUUID(userID)
+		p.ClosedAt = &now
+		if err := s.periodRepo.Save(txCtx, p); err != nil {
+			return fmt.Errorf("failed to save fiscal period: %w", err)
+		}
+
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionClosePeriod, p.ID.String(), period, map[string]interface{}{
+			"from_status": from,
+			"to_status":   p.Status,
+		}))
+	})
+	if err != nil {
+		return FiscalPeriodResponse{}, err
+	}
+
+	return toFiscalPeriodResponse(*p), nil
+}
+
+func (s *fiscalPeriodService) ReopenPeriod(ctx context.Context, period string, userID string, req ReopenPeriodRequest) (FiscalPeriodResponse, error) {
+	year, month, err := parsePeriod(period)
+	if err != nil {
+		return FiscalPeriodResponse{}, err
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return FiscalPeriodResponse{}, errors.New("reason is required to reopen a period")
+	}
+
+	var p *model.FiscalPeriod
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		var err error
+		p, err = lockFiscalPeriod(txCtx, s.periodRepo, year, month)
+		if err != nil {
+			return err
+		}
+		if periodStatusRank[req.Status] >= periodStatusRank[p.Status] {
+			return fmt.Errorf("period %s is %s and cannot be reopened to %s", period, p.Status, req.Status)
+		}
+
+		from := p.Status
+		now := time.Now()
+		p.Status = req.Status
+		p.ReopenedBy = parseOptionalUUID(userID)
+		p.ReopenedAt = &now
+		p.ReopenReason = reason
+		if err := s.periodRepo.Save(txCtx, p); err != nil {
+			return fmt.Errorf("failed to save fiscal period: %w", err)
+		}
+
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionReopenPeriod, p.ID.String(), period, map[string]interface{}{
+			"from_status": from,
+			"to_status":   p.Status,
+			"reason":      reason,
+		}))
+	})
+	if err != nil {
+		return FiscalPeriodResponse{}, err
+	}
+
+	return toFiscalPeriodResponse(*p), nil
+}
+
+// --- Enforcement ---
+
+// checkPeriodOpen rejects documents dated in a closed period. Soft-closed periods still accept
+// approvals of documents already pending (allowSoftClosed) but nothing new. It must run inside
+// the transaction storing the document: the period row stays share-locked until it ends, so the
+// period cannot be closed underneath it.
+func checkPeriodOpen(ctx context.Context, periodRepo repository.FiscalPeriodRepository, at time.Time, allowSoftClosed bool) error {
+	p, err := periodRepo.FindForShare(ctx, at.Year(), int(at.Month()))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check fiscal period: %w", err)
+	}
+
+	switch {
+	case p.Status == model.PeriodClosed:
+		return fmt.Errorf("accounting period %s is closed", formatPeriod(p.Year, p.Month))
+	case p.Status == model.PeriodSoftClosed && !allowSoftClosed:
+		return fmt.Errorf("accounting period %s is soft-closed: only documents already pending can be approved", formatPeriod(p.Year, p.Month))
+	}
+	return nil
+}
+
+// --- Helpers ---
+
+// lockFiscalPeriod locks the period row for a status change, creating it (as OPEN) first when
+// the month has never been closed
+func lockFiscalPeriod(ctx context.Context, periodRepo repository.FiscalPeriodRepository, year, month int) (*model.FiscalPeriod, error) {
+	p, err := periodRepo.FindForUpdate(ctx, year, month)
+	if err == nil {
+		return p, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to lock fiscal period: %w", err)
+	}
+
+	if err := periodRepo.CreateIfMissing(ctx, &model.FiscalPeriod{Year: year, Month: month, Status: model.PeriodOpen}); err != nil {
+		return nil, fmt.Errorf("failed to create fiscal period: %w", err)
+	}
+	p, err = periodRepo.FindForUpdate(ctx, year, month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock fiscal period: %w", err)
+	}
+	return p, nil
+}
+
+// parsePeriod parses a YYYY-MM period
+func parsePeriod(period string) (int, int, error) {
+	t, err := time.Parse("2006-01", period)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid period, expected YYYY-MM: %w", err)
+	}
+	return t.Year(), int(t.Month()), nil
+}
+
+func formatPeriod(year, month int) string {
+	return fmt.Sprintf("%04d-%02d", year, month)
+}
+
+func toFiscalPeriodResponse(p model.FiscalPeriod) FiscalPeriodResponse {
+	resp := FiscalPeriodResponse{
+		Period:       formatPeriod(p.Year, p.Month),
+		Year:         p.Year,
+		Month:        p.Month,
+		Status:       p.Status,
+		ReopenReason: p.ReopenReason,
+	}
+	if p.ClosedBy != nil {
+		s := p.ClosedBy.String()
+		resp.ClosedBy = &s
+	}
+	if p.ClosedAt != nil {
+		s := p.ClosedAt.Format(time.RFC3339)
+		resp.ClosedAt = &s
+	}
+	if p.ReopenedBy != nil {
+		s := p.ReopenedBy.String()
+		resp.ReopenedBy = &s
+	}
+	if p.ReopenedAt != nil {
+		s := p.ReopenedAt.Format(time.RFC3339)
+		resp.ReopenedAt = &s
+	}
+	return resp
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/text/unicode/norm"
+)
+
+// renderPickSlipPDF prints a pick list grouped by bin location with tick boxes for staff
+func renderPickSlipPDF(order model.Order, pickList model.PickList) ([]byte, error) {
+	pdf := newSlipPDF("PICK LIST", pickList.PickListNo, order)
+
+	widths := []float64{30, 35, 75, 25, 25}
+	slipTableHeader(pdf, widths, []string{"Bin", "SKU", "Product", "Qty", "Picked"})
+
+	currentBin := "\x00"
+	for _, item := range pickList.Items {
+		bin := item.BinLocation
+		if bin == "" {
+			bin = "(no bin)"
+		}
+		label := ""
+		if bin != currentBin {
+			label = bin
+			currentBin = bin
+		}
+		picked := "[   ]"
+		if item.QuantityPicked > 0 {
+			picked = fmt.Sprintf("%d", item.QuantityPicked)
+		}
+		slipTableRow(pdf, widths, []string{
+			label, item.ProductSKU, item.ProductName,
+			fmt.Sprintf("%d", item.QuantityRequired), picked,
+		})
+	}
+
+	slipSignatures(pdf, "Picked by", "Checked by")
+	return outputPDF(pdf)
+}
+
+// renderPackSlipPDF prints the content of every package of an order
+func renderPackSlipPDF(order model.Order, packages []model.Package) ([]byte, error) {
+	pdf := newSlipPDF("PACKING SLIP", order.OrderCode, order)
+
+	widths := []float64{35, 110, 45}
+	for _, p := range packages {
+		pdf.SetFont(pdfFont, "B", 11)
+		pdf.CellFormat(0, 8, pdfText(fmt.Sprintf("Package %s  -  %s kg", p.PackageNo, p.WeightKg.StringFixed(3))), "", 1, "L", false, 0, "")
+		slipTableHeader(pdf, widths, []string{"SKU", "Product", "Qty"})
+		for _, item := range p.Items {
+			slipTableRow(pdf, widths, []string{item.Product.SKU, item.Product.Name, fmt.Sprintf("%d", item.Quantity)})
+		}
+		if p.Note != "" {
+			pdf.SetFont(pdfFont, "I", 9)
+			pdf.MultiCell(0, 5, pdfText("Note: "+p.Note), "", "L", false)
+		}
+		pdf.Ln(4)
+	}
+
+	pdf.SetFont(pdfFont, "", 10)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Total packages: %d", len(packages)), "", 1, "L", false, 0, "")
+	slipSignatures(pdf, "Packed by", "Received by")
+	return outputPDF(pdf)
+}
+
+func newSlipPDF(title, docNo string, order model.Order) *fpdf.Fpdf {
+	pdf := newPDF()
+	pdf.SetMargins(10, 10, 10)
+	pdf.AddPage()
+
+	pdf.SetFont(pdfFont, "B", 16)
+	pdf.CellFormat(0, 10, title, "", 1, "C", false, 0, "")
+
+	pdf.SetFont(pdfFont, "", 10)
+	pdf.CellFormat(95, 6, pdfText("No: "+docNo), "", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 6, "Printed: "+time.Now().Format("2006-01-02 15:04"), "", 1, "R", false, 0, "")
+	pdf.CellFormat(0, 6, pdfText("Order: "+order.OrderCode), "", 1, "L", false, 0, "")
+	if order.Partner != nil {
+		pdf.CellFormat(0, 6, pdfText("Customer: "+order.Partner.Name), "", 1, "L", false, 0, "")
+	}
+	if order.ShippingAddress != nil {
+		pdf.MultiCell(0, 6, pdfText("Ship to: "+order.ShippingAddress.FullAddress), "", "L", false)
+	}
+	pdf.Ln(4)
+	return pdf
+}
+
+func slipTableHeader(pdf *fpdf.Fpdf, widths []float64, headers []string) {
+	pdf.SetFont(pdfFont, "B", 10)
+	pdf.SetFillColor(230, 230, 230)
+	for i, h := range headers {
+		pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+func slipTableRow(pdf *fpdf.Fpdf, widths []float64, cells []string) {
+	pdf.SetFont(pdfFont, "", 10)
+	for i, c := range cells {
+		align := "L"
+		if i >= len(cells)-2 && i > 0 {
+			align = "C"
+		}
+		pdf.CellFormat(widths[i], 7, truncateForCell(pdf, pdfText(c), widths[i]), "1", 0, align, false, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+func slipSignatures(pdf *fpdf.Fpdf, left, right string) {
+	pdf.Ln(12)
+	pdf.SetFont(pdfFont, "B", 10)
+	pdf.CellFormat(95, 6, left, "", 0, "C", false, 0, "")
+	pdf.CellFormat(95, 6, right, "", 1, "C", false, 0, "")
+	pdf.SetFont(pdfFont, "I", 8)
+	pdf.CellFormat(95, 5, "(signature, full name)", "", 0, "C", false, 0, "")
+	pdf.CellFormat(95, 5, "(signature, full name)", "", 1, "C", false, 0, "")
+}
+
+func outputPDF(pdf *fpdf.Fpdf) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// truncateForCell shortens text so it fits inside a fixed-width table cell
+func truncateForCell(pdf *fpdf.Fpdf, text string, width float64) string {
+	maxWidth := width - 2
+	if pdf.GetStringWidth(text) <= maxWidth {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// pdfFont is the embedded Unicode font family used by every printed document, so Vietnamese
+// text keeps its diacritics
+const pdfFont = "DejaVu"
+
+//go:embed fonts/DejaVuSansCondensed.ttf
+var pdfFontRegular []byte
+
+//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+var pdfFontBold []byte
+
+//go:embed fonts/DejaVuSansCondensed-Oblique.ttf
+var pdfFontItalic []byte
+
+//go:embed fonts/DejaVuSansCondensed-BoldOblique.ttf
+var pdfFontBoldItalic []byte
+
+// newPDF starts an A4 document with the embedded font registered in all styles
+func newPDF() *fpdf.Fpdf {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(pdfFont, "", pdfFontRegular)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", pdfFontBold)
+	pdf.AddUTF8FontFromBytes(pdfFont, "I", pdfFontItalic)
+	pdf.AddUTF8FontFromBytes(pdfFont, "BI", pdfFontBoldItalic)
+	return pdf
+}
+
+// pdfText composes combining marks (NFC) so decomposed Vietnamese input maps onto the
+// precomposed glyphs of the embedded font
+func pdfText(s string) string {
+	return norm.NFC.String(s)
+}
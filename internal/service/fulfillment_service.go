@@ -0,0 +1,782 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// --- DTOs ---
+
+type PickListItemResponse struct {
+	ID               string `json:"id"`
+	OrderItemID      string `json:"order_item_id"`
+	ProductID        string `json:"product_id"`
+	ProductSKU       string `json:"product_sku"`
+	ProductName      string `json:"product_name"`
+	BinLocation      string `json:"bin_location"`
+	QuantityRequired int    `json:"quantity_required"`
+	QuantityPicked   int    `json:"quantity_picked"`
+}
+
+// PickListBinGroup groups pick lines that live in the same warehouse bin
+type PickListBinGroup struct {
+	BinLocation string                 `json:"bin_location"`
+	Items       []PickListItemResponse `json:"items"`
+}
+
+type PickListResponse struct {
+	ID          string             `json:"id"`
+	PickListNo  string             `json:"pick_list_no"`
+	OrderID     string             `json:"order_id"`
+	Status      string             `json:"status"`
+	Bins        []PickListBinGroup `json:"bins"`
+	CompletedAt *string            `json:"completed_at"`
+	CreatedAt   string             `json:"created_at"`
+}
+
+type PickedItemPayload struct {
+	PickListItemID string `json:"pick_list_item_id" binding:"required"`
+	QuantityPicked int    `json:"quantity_picked" binding:"min=0"`
+}
+
+type ConfirmPickingRequest struct {
+	Items []PickedItemPayload `json:"items" binding:"required,min=1,dive"`
+}
+
+type PackageItemPayload struct {
+	OrderItemID string `json:"order_item_id" binding:"required"`
+	Quantity    int    `json:"quantity" binding:"required,gt=0"`
+}
+
+type CreatePackageRequest struct {
+	WeightKg string               `json:"weight_kg"` // Optional decimal string
+	Note     string               `json:"note"`
+	Items    []PackageItemPayload `json:"items" binding:"required,min=1,dive"`
+}
+
+type PackageItemResponse struct {
+	ID          string `json:"id"`
+	OrderItemID string `json:"order_item_id"`
+	ProductID   string `json:"product_id"`
+	ProductSKU  string `json:"product_sku"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+type PackageResponse struct {
+	ID        string                `json:"id"`
+	PackageNo string                `json:"package_no"`
+	OrderID   string                `json:"order_id"`
+	WeightKg  string                `json:"weight_kg"`
+	Note      string                `json:"note"`
+	Items     []PackageItemResponse `json:"items"`
+	CreatedAt string                `json:"created_at"`
+}
+
+type FulfillmentStatusResponse struct {
+	OrderID            string  `json:"order_id"`
+	OrderCode          string  `json:"order_code"`
+	FulfillmentStatus  string  `json:"fulfillment_status"`
+	PackingConfirmedAt *string `json:"packing_confirmed_at"`
+	ShippedAt          *string `json:"shipped_at"`
+}
+
+// --- Interface ---
+
+type FulfillmentService interface {
+	GetPickList(ctx context.Context, orderID string) (PickListResponse, error)
+	GeneratePickList(ctx context.Context, orderID string, userID string) (PickListResponse, error)
+	ConfirmPicking(ctx context.Context, orderID string, userID string, req ConfirmPickingRequest) (PickListResponse, error)
+	CreatePackage(ctx context.Context, orderID string, userID string, req CreatePackageRequest) (PackageResponse, error)
+	ListPackages(ctx context.Context, orderID string) ([]PackageResponse, error)
+	DeletePackage(ctx context.Context, orderID string, packageID string, userID string) error
+	ConfirmPacking(ctx context.Context, orderID string, userID string) (FulfillmentStatusResponse, error)
+	ShipOrder(ctx context.Context, orderID string, userID string) (FulfillmentStatusResponse, error)
+	RenderPickSlip(ctx context.Context, orderID string) ([]byte, string, error)
+	RenderPackSlip(ctx context.Context, orderID string) ([]byte, string, error)
+}
+
+type fulfillmentService struct {
+	fulfillmentRepo repository.FulfillmentRepository
+	orderRepo       repository.OrderRepository
+	productRepo     repository.ProductRepository
+	auditRepo       repository.AuditRepository
+	sequenceRepo    repository.DocumentSequenceRepository
+	txManager       repository.TransactionManager
+}
+
+func NewFulfillmentService(
+	fulfillmentRepo repository.FulfillmentRepository,
+	orderRepo repository.OrderRepository,
+	productRepo repository.ProductRepository,
+	auditRepo repository.AuditRepository,
+	sequenceRepo repository.DocumentSequenceRepository,
+	txManager repository.TransactionManager,
+) FulfillmentService {
+	return &fulfillmentService{
+		fulfillmentRepo: fulfillmentRepo,
+		orderRepo:       orderRepo,
+		productRepo:     productRepo,
+		auditRepo:       auditRepo,
+		sequenceRepo:    sequenceRepo,
+		txManager:       txManager,
+	}
+}
+
+// --- Implementation ---
+
+func (s *fulfillmentService) GetPickList(ctx context.Context, orderID string) (PickListResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return PickListResponse{}, fmt.Errorf("invalid order id: %w", err)
+	}
+
+	pickList, err := s.fulfillmentRepo.FindPickListByOrderID(ctx, oid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return PickListResponse{}, fmt.Errorf("no pick list found for this order")
+		}
+		return PickListResponse{}, fmt.Errorf("failed to fetch pick list: %w", err)
+	}
+
+	return toPickListResponse(*pickList), nil
+}
+
+// GeneratePickList creates a pick list for an approved EXPORT order that does not have one yet
+// (e.g. orders approved before the fulfillment workflow existed).
+func (s *fulfillmentService) GeneratePickList(ctx context.Context, orderID string, userID string) (PickListResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return PickListResponse{}, fmt.Errorf("invalid order id: %w", err)
+	}
+	uid := parseOptionalUUID(userID)
+
+	var pickList *model.PickList
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		// Locked so two requests cannot both find no pick list and each create one
+		order, findErr := s.orderRepo.FindByIDWithItemsForUpdate(txCtx, oid)
+		if findErr != nil {
+			return fmt.Errorf("order not found: %w", findErr)
+		}
+		if order.Type != model.OrderTypeExport || order.Status != model.OrderStatusCompleted {
+			return fmt.Errorf("pick lists can only be generated for approved EXPORT orders")
+		}
+		if _, existsErr := s.fulfillmentRepo.FindPickListByOrderID(txCtx, oid); existsErr == nil {
+			return fmt.Errorf("order %s already has a pick list", order.OrderCode)
+		}
+
+		var genErr error
+		pickList, genErr = createPickListForOrder(txCtx, s.fulfillmentRepo, s.sequenceRepo, s.productRepo, s.orderRepo, s.auditRepo, order, uid)
+		return genErr
+	})
+	if err != nil {
+		return PickListResponse{}, err
+	}
+
+	return s.GetPickList(ctx, pickList.OrderID.String())
+}
+
+func (s *fulfillmentService) ConfirmPicking(ctx context.Context, orderID string, userID string, req ConfirmPickingRequest) (PickListResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return PickListResponse{}, fmt.Errorf("invalid order id: %w", err)
+	}
+	uid := parseOptionalUUID(userID)
+
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		// The order row is locked so concurrent confirmations see the pick list the other left
+		if _, lockErr := s.orderRepo.FindByIDWithItemsForUpdate(txCtx, oid); lockErr != nil {
+			return fmt.Errorf("order not found: %w", lockErr)
+		}
+		pickList, findErr := s.fulfillmentRepo.FindPickListByOrderID(txCtx, oid)
+		if findErr != nil {
+			return fmt.Errorf("no pick list found for this order: %w", findErr)
+		}
+		if pickList.Status == model.PickListCompleted {
+			return fmt.Errorf("pick list %s is already completed", pickList.PickListNo)
+		}
+
+		itemsByID := make(map[uuid.UUID]*model.PickListItem, len(pickList.Items))
+		for i := range pickList.Items {
+			itemsByID[pickList.Items[i].ID] = &pickList.Items[i]
+		}
+
+		for i, payload := range req.Items {
+			itemID, parseErr := uuid.Parse(payload.PickListItemID)
+			if parseErr != nil {
+				return fmt.Errorf("items[%d]: invalid pick_list_item_id", i)
+			}
+			item, ok := itemsByID[itemID]
+			if !ok {
+				return fmt.Errorf("items[%d]: item does not belong to this pick list", i)
+			}
+			if payload.QuantityPicked > item.QuantityRequired {
+				return fmt.Errorf("items[%d]: picked quantity %d exceeds required %d for %s",
+					i, payload.QuantityPicked, item.QuantityRequired, item.ProductSKU)
+			}
+			item.QuantityPicked = payload.QuantityPicked
+			if updateErr := s.fulfillmentRepo.UpdatePickListItem(txCtx, item); updateErr != nil {
+				return fmt.Errorf("failed to update pick list item: %w", updateErr)
+			}
+		}
+
+		allPicked := true
+		for _, item := range pickList.Items {
+			if item.QuantityPicked < item.QuantityRequired {
+				allPicked = false
+				break
+			}
+		}
+		if allPicked {
+			now := time.Now()
+			pickList.Status = model.PickListCompleted
+			pickList.CompletedBy = uid
+			pickList.CompletedAt = &now
+			if updateErr := s.fulfillmentRepo.UpdatePickList(txCtx, pickList); updateErr != nil {
+				return fmt.Errorf("failed to update pick list: %w", updateErr)
+			}
+		}
+
+		details, _ := json.Marshal(map[string]interface{}{
+			"order_id":  oid.String(),
+			"items":     req.Items,
+			"completed": allPicked,
+		})
+		audit := &model.AuditLog{
+			UserID:     uid,
+			Action:     model.ActionConfirmPicking,
+			EntityID:   pickList.ID.String(),
+			EntityName: pickList.PickListNo,
+			Details:    string(details),
+		}
+		if auditErr := s.auditRepo.Log(txCtx, audit); auditErr != nil {
+			return fmt.Errorf("failed to write audit log: %w", auditErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return PickListResponse{}, err
+	}
+
+	return s.GetPickList(ctx, orderID)
+}
+
+func (s *fulfillmentService) CreatePackage(ctx context.Context, orderID string, userID string, req CreatePackageRequest) (PackageResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return PackageResponse{}, fmt.Errorf("invalid order id: %w", err)
+	}
+	uid := parseOptionalUUID(userID)
+
+	weight := decimal.Zero
+	if req.WeightKg != "" {
+		weight, err = decimal.NewFromString(req.WeightKg)
+		if err != nil {
+			return PackageResponse{}, fmt.Errorf("invalid weight_kg: %w", err)
+		}
+		if weight.IsNegative() {
+			return PackageResponse{}, fmt.Errorf("weight_kg cannot be negative")
+		}
+	}
+
+	var pkg *model.Package
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		// The order row is locked so concurrent packages are checked against each other's quantities
+		order, findErr := s.orderRepo.FindByIDWithItemsForUpdate(txCtx, oid)
+		if findErr != nil {
+			return fmt.Errorf("order not found: %w", findErr)
+		}
+		if order.FulfillmentStatus != model.FulfillmentPicking {
+			return fmt.Errorf("packages can only be added while the order is in %s (current: %q)",
+				model.FulfillmentPicking, order.FulfillmentStatus)
+		}
+
+		packed, packedErr := s.packedQuantities(txCtx, oid)
+		if packedErr != nil {
+			return packedErr
+		}
+
+		orderItems := make(map[uuid.UUID]model.OrderItem, len(order.Items))
+		for _, item := range order.Items {
+			orderItems[item.ID] = item
+		}
+
+		items := make([]model.PackageItem, 0, len(req.Items))
+		for i, payload := range req.Items {
+			itemID, parseErr := uuid.Parse(payload.OrderItemID)
+			if parseErr != nil {
+				return fmt.Errorf("items[%d]: invalid order_item_id", i)
+			}
+			orderItem, ok := orderItems[itemID]
+			if !ok {
+				return fmt.Errorf("items[%d]: item does not belong to this order", i)
+			}
+			packed[itemID] += payload.Quantity
+			if packed[itemID] > orderItem.Quantity {
+				return fmt.Errorf("items[%d]: packed quantity %d exceeds ordered quantity %d",
+					i, packed[itemID], orderItem.Quantity)
+			}
+			items = append(items, model.PackageItem{
+				OrderItemID: itemID,
+				ProductID:   orderItem.ProductID,
+				Quantity:    payload.Quantity,
+			})
+		}
+
+		packageNo, genErr := nextDocumentNo(txCtx, s.sequenceRepo, model.SequencePackage, time.Now())
+		if genErr != nil {
+			return fmt.Errorf("failed to generate package number: %w", genErr)
+		}
+
+		pkg = &model.Package{
+			PackageNo: packageNo,
+			OrderID:   oid,
+			WeightKg:  weight,
+			Note:      req.Note,
+			Items:     items,
+			CreatedBy: uid,
+		}
+		if createErr := s.fulfillmentRepo.CreatePackage(txCtx, pkg); createErr != nil {
+			return fmt.Errorf("failed to create package: %w", createErr)
+		}
+
+		details, _ := json.Marshal(map[string]interface{}{
+			"order_code": order.OrderCode,
+			"weight_kg":  weight.StringFixed(3),
+			"items":      req.Items,
+		})
+		audit := &model.AuditLog{
+			UserID:     uid,
+			Action:     model.ActionCreatePackage,
+			EntityID:   pkg.ID.String(),
+			EntityName: packageNo,
+			Details:    string(details),
+		}
+		if auditErr := s.auditRepo.Log(txCtx, audit); auditErr != nil {
+			return fmt.Errorf("failed to write audit log: %w", auditErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return PackageResponse{}, err
+	}
+
+	reloaded, err := s.fulfillmentRepo.FindPackageByID(ctx, pkg.ID)
+	if err != nil {
+		return PackageResponse{}, fmt.Errorf("failed to reload package: %w", err)
+	}
+	return toPackageResponse(*reloaded), nil
+}
+
+func (s *fulfillmentService) ListPackages(ctx context.Context, orderID string) ([]PackageResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order id: %w", err)
+	}
+
+	packages, err := s.fulfillmentRepo.ListPackagesByOrderID(ctx, oid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch packages: %w", err)
+	}
+
+	res := make([]PackageResponse, 0, len(packages))
+	for _, p := range packages {
+		res = append(res, toPackageResponse(p))
+	}
+	return res, nil
+}
+
+func (s *fulfillmentService) DeletePackage(ctx context.Context, orderID string, packageID string, userID string) error {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return fmt.Errorf("invalid order id: %w", err)
+	}
+	pid, err := uuid.Parse(packageID)
+	if err != nil {
+		return fmt.Errorf("invalid package id: %w", err)
+	}
+	uid := parseOptionalUUID(userID)
+
+	return s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		order, findErr := s.orderRepo.FindByIDWithItemsForUpdate(txCtx, oid)
+		if findErr != nil {
+			return fmt.Errorf("order not found: %w", findErr)
+		}
+		if order.FulfillmentStatus != model.FulfillmentPicking {
+			return fmt.Errorf("packages cannot be removed after packing is confirmed")
+		}
+
+		pkg, findErr := s.fulfillmentRepo.FindPackageByID(txCtx, pid)
+		if findErr != nil {
+			return fmt.Errorf("package not found: %w", findErr)
+		}
+		if pkg.OrderID != oid {
+			return fmt.Errorf("package does not belong to this order")
+		}
+
+		if delErr := s.fulfillmentRepo.DeletePackage(txCtx, pid); delErr != nil {
+			return fmt.Errorf("failed to delete package: %w", delErr)
+		}
+
+		audit := &model.AuditLog{
+			UserID:     uid,
+			Action:     model.ActionDeletePackage,
+			EntityID:   pkg.ID.String(),
+			EntityName: pkg.PackageNo,
+			Details:    `{"deleted": true}`,
+		}
+		if auditErr := s.auditRepo.Log(txCtx, audit); auditErr != nil {
+			return fmt.Errorf("failed to write audit log: %w", auditErr)
+		}
+		return nil
+	})
+}
+
+// ConfirmPacking verifies that every order line is fully picked and packed, then marks the order PACKED
+func (s *fulfillmentService) ConfirmPacking(ctx context.Context, orderID string, userID string) (FulfillmentStatusResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return FulfillmentStatusResponse{}, fmt.Errorf("invalid order id: %w", err)
+	}
+	uid := parseOptionalUUID(userID)
+
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		order, findErr := s.orderRepo.FindByIDWithItemsForUpdate(txCtx, oid)
+		if findErr != nil {
+			return fmt.Errorf("order not found: %w", findErr)
+		}
+		if order.FulfillmentStatus != model.FulfillmentPicking {
+			return fmt.Errorf("packing can only be confirmed while the order is in %s (current: %q)",
+				model.FulfillmentPicking, order.FulfillmentStatus)
+		}
+
+		pickList, pickErr := s.fulfillmentRepo.FindPickListByOrderID(txCtx, oid)
+		if pickErr != nil {
+			return fmt.Errorf("no pick list found for this order: %w", pickErr)
+		}
+		if pickList.Status != model.PickListCompleted {
+			return fmt.Errorf("pick list %s must be completed before packing is confirmed", pickList.PickListNo)
+		}
+
+		packed, packedErr := s.packedQuantities(txCtx, oid)
+		if packedErr != nil {
+			return packedErr
+		}
+		for _, item := range order.Items {
+			if packed[item.ID] != item.Quantity {
+				return fmt.Errorf("order item %s is not fully packed (packed %d of %d)",
+					item.ID, packed[item.ID], item.Quantity)
+			}
+		}
+
+		now := time.Now()
+		if updateErr := s.orderRepo.UpdateColumns(txCtx, oid, map[string]interface{}{
+			"fulfillment_status":   model.FulfillmentPacked,
+			"packing_confirmed_by": uid,
+			"packing_confirmed_at": now,
+		}); updateErr != nil {
+			return fmt.Errorf("failed to update order: %w", updateErr)
+		}
+
+		audit := &model.AuditLog{
+			UserID:     uid,
+			Action:     model.ActionConfirmPacking,
+			EntityID:   order.ID.String(),
+			EntityName: order.OrderCode,
+			Details:    `{"fulfillment_status": "PACKED"}`,
+		}
+		if auditErr := s.auditRepo.Log(txCtx, audit); auditErr != nil {
+			return fmt.Errorf("failed to write audit log: %w", auditErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return FulfillmentStatusResponse{}, err
+	}
+
+	return s.fulfillmentStatus(ctx, oid)
+}
+
+// ShipOrder marks a PACKED order as SHIPPED. Orders cannot skip the packing confirmation.
+func (s *fulfillmentService) ShipOrder(ctx context.Context, orderID string, userID string) (FulfillmentStatusResponse, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return FulfillmentStatusResponse{}, fmt.Errorf("invalid order id: %w", err)
+	}
+	uid := parseOptionalUUID(userID)
+
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		order, findErr := s.orderRepo.FindByIDWithItemsForUpdate(txCtx, oid)
+		if findErr != nil {
+			return fmt.Errorf("order not found: %w", findErr)
+		}
+		if order.FulfillmentStatus != model.FulfillmentPacked {
+			return fmt.Errorf("order %s cannot be shipped before packing is confirmed", order.OrderCode)
+		}
+
+		if updateErr := s.orderRepo.UpdateColumns(txCtx, oid, map[string]interface{}{
+			"fulfillment_status": model.FulfillmentShipped,
+			"shipped_at":         time.Now(),
+		}); updateErr != nil {
+			return fmt.Errorf("failed to update order: %w", updateErr)
+		}
+
+		audit := &model.AuditLog{
+			UserID:     uid,
+			Action:     model.ActionShipOrder,
+			EntityID:   order.ID.String(),
+			EntityName: order.OrderCode,
+			Details:    `{"fulfillment_status": "SHIPPED"}`,
+		}
+		if auditErr := s.auditRepo.Log(txCtx, audit); auditErr != nil {
+			return fmt.Errorf("failed to write audit log: %w", auditErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return FulfillmentStatusResponse{}, err
+	}
+
+	return s.fulfillmentStatus(ctx, oid)
+}
+
+func (s *fulfillmentService) RenderPickSlip(ctx context.Context, orderID string) ([]byte, string, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid order id: %w", err)
+	}
+
+	order, err := s.orderRepo.FindByIDWithItems(ctx, oid)
+	if err != nil {
+		return nil, "", fmt.Errorf("order not found: %w", err)
+	}
+	pickList, err := s.fulfillmentRepo.FindPickListByOrderID(ctx, oid)
+	if err != nil {
+		return nil, "", fmt.Errorf("no pick list found for this order: %w", err)
+	}
+
+	pdfBytes, err := renderPickSlipPDF(*order, *pickList)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render pick slip: %w", err)
+	}
+	return pdfBytes, pickList.PickListNo + ".pdf", nil
+}
+
+func (s *fulfillmentService) RenderPackSlip(ctx context.Context, orderID string) ([]byte, string, error) {
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid order id: %w", err)
+	}
+
+	order, err := s.orderRepo.FindByIDWithItems(ctx, oid)
+	if err != nil {
+		return nil, "", fmt.Errorf("order not found: %w", err)
+	}
+	packages, err := s.fulfillmentRepo.ListPackagesByOrderID(ctx, oid)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch packages: %w", err)
+	}
+	if len(packages) == 0 {
+		return nil, "", fmt.Errorf("order %s has no packages yet", order.OrderCode)
+	}
+
+	pdfBytes, err := renderPackSlipPDF(*order, packages)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render pack slip: %w", err)
+	}
+	return pdfBytes, "PACK-" + order.OrderCode + ".pdf", nil
+}
+
+// --- Helpers ---
+
+// packedQuantities sums packaged quantity per order item across all packages of an order
+func (s *fulfillmentService) packedQuantities(ctx context.Context, orderID uuid.UUID) (map[uuid.UUID]int, error) {
+	packages, err := s.fulfillmentRepo.ListPackagesByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch packages: %w", err)
+	}
+	packed := make(map[uuid.UUID]int)
+	for _, p := range packages {
+		for _, item := range p.Items {
+			packed[item.OrderItemID] += item.Quantity
+		}
+	}
+	return packed, nil
+}
+
+func (s *fulfillmentService) fulfillmentStatus(ctx context.Context, orderID uuid.UUID) (FulfillmentStatusResponse, error) {
+	order, err := s.orderRepo.FindByIDWithItems(ctx, orderID)
+	if err != nil {
+		return FulfillmentStatusResponse{}, fmt.Errorf("failed to reload order: %w", err)
+	}
+
+	resp := FulfillmentStatusResponse{
+		OrderID:           order.ID.String(),
+		OrderCode:         order.OrderCode,
+		FulfillmentStatus: order.FulfillmentStatus,
+	}
+	if order.PackingConfirmedAt != nil {
+		s := order.PackingConfirmedAt.Format(time.RFC3339)
+		resp.PackingConfirmedAt = &s
+	}
+	if order.ShippedAt != nil {
+		s := order.ShippedAt.Format(time.RFC3339)
+		resp.ShippedAt = &s
+	}
+	return resp, nil
+}
+
+// createPickListForOrder builds the pick list for an approved EXPORT order and moves the order
+// into PICKING. It must be called inside a transaction; the approval flow reuses it.
+func createPickListForOrder(
+	ctx context.Context,
+	fulfillmentRepo repository.FulfillmentRepository,
+	sequenceRepo repository.DocumentSequenceRepository,
+	productRepo repository.ProductRepository,
+	orderRepo repository.OrderRepository,
+	auditRepo repository.AuditRepository,
+	order *model.Order,
+	userID *uuid.UUID,
+) (*model.PickList, error) {
+	items := make([]model.PickListItem, 0, len(order.Items))
+	for _, orderItem := range order.Items {
+		product, err := productRepo.FindByID(ctx, orderItem.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("product not found: %s: %w", orderItem.ProductID, err)
+		}
+		items = append(items, model.PickListItem{
+			OrderItemID:      orderItem.ID,
+			ProductID:        product.ID,
+			ProductSKU:       product.SKU,
+			ProductName:      product.Name,
+			BinLocation:      product.BinLocation,
+			QuantityRequired: orderItem.Quantity,
+		})
+	}
+
+	pickListNo, err := nextDocumentNo(ctx, sequenceRepo, model.SequencePickList, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate pick list number: %w", err)
+	}
+
+	pickList := &model.PickList{
+		PickListNo: pickListNo,
+		OrderID:    order.ID,
+		Status:     model.PickListOpen,
+		Items:      items,
+	}
+	if err := fulfillmentRepo.CreatePickList(ctx, pickList); err != nil {
+		return nil, fmt.Errorf("failed to create pick list: %w", err)
+	}
+
+	if err := orderRepo.UpdateColumns(ctx, order.ID, map[string]interface{}{
+		"fulfillment_status": model.FulfillmentPicking,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update order fulfillment status: %w", err)
+	}
+
+	details, _ := json.Marshal(map[string]interface{}{
+		"order_code": order.OrderCode,
+		"lines":      len(items),
+	})
+	audit := &model.AuditLog{
+		UserID:     userID,
+		Action:     model.ActionGeneratePickList,
+		EntityID:   pickList.ID.String(),
+		EntityName: pickListNo,
+		Details:    string(details),
+	}
+	if err := auditRepo.Log(ctx, audit); err != nil {
+		return nil, fmt.Errorf("failed to write pick list audit log: %w", err)
+	}
+
+	return pickList, nil
+}
+
+// parseOptionalUUID returns nil for empty or malformed ids (used for audit user references)
+func parseOptionalUUID(id string) *uuid.UUID {
+	if id == "" {
+		return nil
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+// --- Mapping ---
+
+func toPickListResponse(p model.PickList) PickListResponse {
+	resp := PickListResponse{
+		ID:         p.ID.String(),
+		PickListNo: p.PickListNo,
+		OrderID:    p.OrderID.String(),
+		Status:     p.Status,
+		Bins:       make([]PickListBinGroup, 0),
+		CreatedAt:  p.CreatedAt.Format(time.RFC3339),
+	}
+	if p.CompletedAt != nil {
+		s := p.CompletedAt.Format(time.RFC3339)
+		resp.CompletedAt = &s
+	}
+
+	// Items arrive sorted by bin_location, so consecutive lines form the groups
+	for _, item := range p.Items {
+		line := PickListItemResponse{
+			ID:               item.ID.String(),
+			OrderItemID:      item.OrderItemID.String(),
+			ProductID:        item.ProductID.String(),
+			ProductSKU:       item.ProductSKU,
+			ProductName:      item.ProductName,
+			BinLocation:      item.BinLocation,
+			QuantityRequired: item.QuantityRequired,
+			QuantityPicked:   item.QuantityPicked,
+		}
+		last := len(resp.Bins) - 1
+		if last >= 0 && resp.Bins[last].BinLocation == item.BinLocation {
+			resp.Bins[last].Items = append(resp.Bins[last].Items, line)
+			continue
+		}
+		resp.Bins = append(resp.Bins, PickListBinGroup{
+			BinLocation: item.BinLocation,
+			Items:       []PickListItemResponse{line},
+		})
+	}
+
+	return resp
+}
+
+func toPackageResponse(p model.Package) PackageResponse {
+	items := make([]PackageItemResponse, 0, len(p.Items))
+	for _, item := range p.Items {
+		items = append(items, PackageItemResponse{
+			ID:          item.ID.String(),
+			OrderItemID: item.OrderItemID.String(),
+			ProductID:   item.ProductID.String(),
+			ProductSKU:  item.Product.SKU,
+			ProductName: item.Product.Name,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	return PackageResponse{
+		ID:        p.ID.String(),
+		PackageNo: p.PackageNo,
+		OrderID:   p.OrderID.String(),
+		WeightKg:  p.WeightKg.StringFixed(3),
+		Note:      p.Note,
+		Items:     items,
+		CreatedAt: p.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -6,12 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"backend/internal/model"
 	"backend/internal/repository"
 	ws "backend/internal/websocket"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -20,38 +22,66 @@ type OrderItemRequest struct {
 	ProductID string  `json:"product_id" binding:"required"`
 	Quantity  int     `json:"quantity" binding:"required,gt=0"`
 	UnitPrice float64 `json:"unit_price" binding:"required,gt=0"`
+	Discount  float64 `json:"discount" binding:"min=0"` // Optional: line discount amount
+	TaxRuleID string  `json:"tax_rule_id"`              // Optional: line tax rule, overrides the order-level tax_rule_id
 }
 
 type CreateOrderRequest struct {
-	OrderCode         string             `json:"order_code" binding:"required"`
-	Type              string             `json:"type" binding:"required,oneof=IMPORT EXPORT"`
-	Note              string             `json:"note"`
-	Items             []OrderItemRequest `json:"items" binding:"required,min=1,dive"`
-	TaxRuleID         string             `json:"tax_rule_id"`         // Optional: user-selected tax rule for invoice
-	SideFees          string             `json:"side_fees"`           // Optional: additional fees
-	PartnerID         string             `json:"partner_id"`          // Optional: selected partner
-	OriginAddressID   string             `json:"origin_address_id"`   // Optional: ORIGIN address
-	ShippingAddressID string             `json:"shipping_address_id"` // Optional: SHIPPING address
+	OrderCode           string             `json:"order_code" binding:"required"`
+	Type                string             `json:"type" binding:"required,oneof=IMPORT EXPORT"`
+	Note                string             `json:"note"`
+	Items               []OrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	TaxRuleID           string             `json:"tax_rule_id"`           // Optional: tax rule for items without their own
+	SideFees            string             `json:"side_fees"`             // Optional: additional fees
+	PartnerID           string             `json:"partner_id"`            // Optional: selected partner
+	OriginAddressID     string             `json:"origin_address_id"`     // Optional: ORIGIN address
+	ShippingAddressID   string             `json:"shipping_address_id"`   // Optional: SHIPPING address
+	DeliveryWindowStart string             `json:"delivery_window_start"` // Optional: RFC3339, earliest delivery time
+	DeliveryWindowEnd   string             `json:"delivery_window_end"`   // Optional: RFC3339, latest delivery time
+
+	// Item prices and side fees are in Currency (default: the base currency). ExchangeRate (base
+	// currency per unit of currency) is looked up from the exchange rate table when empty; a typed rate that differs
+	// from the table's overrides it and needs a reason.
+	Currency                   string `json:"currency"`
+	ExchangeRate               string `json:"exchange_rate"`
+	ExchangeRateOverrideReason string `json:"exchange_rate_override_reason" binding:"max=500"`
+}
+
+// CreditOverrideRequest releases an export order from credit hold so it can be approved
+type CreditOverrideRequest struct {
+	Reason string `json:"reason" binding:"required,max=1000"`
 }
 
 type CreateProductRequest struct {
-	SKU   string  `json:"sku" binding:"required"`
-	Name  string  `json:"name" binding:"required"`
-	Price float64 `json:"price" binding:"required,min=0"`
+	SKU         string  `json:"sku" binding:"required"`
+	Name        string  `json:"name" binding:"required"`
+	Category    string  `json:"category" binding:"max=100"` // Optional: matched by tax rule conditions
+	Price       float64 `json:"price" binding:"required,min=0"`
+	BinLocation string  `json:"bin_location"`
+	WeightKg    float64 `json:"weight_kg" binding:"min=0"`
+	VolumeM3    float64 `json:"volume_m3" binding:"min=0"`
 }
 
 type UpdateProductRequest struct {
-	SKU   string  `json:"sku" binding:"required"`
-	Name  string  `json:"name" binding:"required"`
-	Price float64 `json:"price" binding:"required,min=0"`
+	SKU         string  `json:"sku" binding:"required"`
+	Name        string  `json:"name" binding:"required"`
+	Category    string  `json:"category" binding:"max=100"` // Optional: matched by tax rule conditions
+	Price       float64 `json:"price" binding:"required,min=0"`
+	BinLocation string  `json:"bin_location"`
+	WeightKg    float64 `json:"weight_kg" binding:"min=0"`
+	VolumeM3    float64 `json:"volume_m3" binding:"min=0"`
 }
 
 type ProductResponse struct {
 	ID           string  `json:"id"`
 	SKU          string  `json:"sku"`
 	Name         string  `json:"name"`
+	Category     string  `json:"category"`
 	CurrentStock int     `json:"current_stock"`
 	Price        float64 `json:"price"`
+	BinLocation  string  `json:"bin_location"`
+	WeightKg     float64 `json:"weight_kg"`
+	VolumeM3     float64 `json:"volume_m3"`
 }
 
 // Websocket Payload
@@ -66,6 +96,7 @@ type InventoryService interface {
 	UpdateProduct(ctx context.Context, userID string, id string, req UpdateProductRequest) (ProductResponse, error)
 	DeleteProduct(ctx context.Context, userID string, id string) error
 	CreateOrder(ctx context.Context, userID string, req CreateOrderRequest) error
+	OverrideCreditHold(ctx context.Context, orderID string, userID string, req CreditOverrideRequest) error
 }
 
 type inventoryService struct {
@@ -74,6 +105,9 @@ type inventoryService struct {
 	approvalRepo repository.ApprovalRepository
 	auditRepo    repository.AuditRepository
 	partnerRepo  repository.PartnerRepository
+	taxRuleRepo  repository.TaxRuleRepository
+	paymentRepo  repository.PaymentRepository
+	rateRepo     repository.ExchangeRateRepository
 	txManager    repository.TransactionManager
 	hub          *ws.Hub
 }
@@ -84,6 +118,9 @@ func NewInventoryService(
 	approvalRepo repository.ApprovalRepository,
 	auditRepo repository.AuditRepository,
 	partnerRepo repository.PartnerRepository,
+	taxRuleRepo repository.TaxRuleRepository,
+	paymentRepo repository.PaymentRepository,
+	rateRepo repository.ExchangeRateRepository,
 	txManager repository.TransactionManager,
 	hub *ws.Hub,
 ) InventoryService {
@@ -93,6 +130,9 @@ func NewInventoryService(
 		approvalRepo: approvalRepo,
 		auditRepo:    auditRepo,
 		partnerRepo:  partnerRepo,
+		taxRuleRepo:  taxRuleRepo,
+		paymentRepo:  paymentRepo,
+		rateRepo:     rateRepo,
 		txManager:    txManager,
 		hub:          hub,
 	}
@@ -117,8 +157,12 @@ func (s *inventoryService) GetProducts(ctx context.Context, page, limit int, sea
 			ID:           p.ID.String(),
 			SKU:          p.SKU,
 			Name:         p.Name,
+			Category:     p.Category,
 			CurrentStock: p.CurrentStock,
 			Price:        p.Price,
+			BinLocation:  p.BinLocation,
+			WeightKg:     p.WeightKg,
+			VolumeM3:     p.VolumeM3,
 		})
 	}
 
@@ -129,7 +173,11 @@ func (s *inventoryService) CreateProduct(ctx context.Context, userID string, req
 	product := model.Product{
 		SKU:          req.SKU,
 		Name:         req.Name,
+		Category:     strings.TrimSpace(req.Category),
 		Price:        req.Price,
+		BinLocation:  req.BinLocation,
+		WeightKg:     req.WeightKg,
+		VolumeM3:     req.VolumeM3,
 		CurrentStock: 0,
 	}
 
@@ -166,8 +214,12 @@ func (s *inventoryService) CreateProduct(ctx context.Context, userID string, req
 		ID:           product.ID.String(),
 		SKU:          product.SKU,
 		Name:         product.Name,
+		Category:     product.Category,
 		CurrentStock: product.CurrentStock,
 		Price:        product.Price,
+		BinLocation:  product.BinLocation,
+		WeightKg:     product.WeightKg,
+		VolumeM3:     product.VolumeM3,
 	}, nil
 }
 
@@ -187,7 +239,11 @@ func (s *inventoryService) UpdateProduct(ctx context.Context, userID string, id
 
 	product.SKU = req.SKU
 	product.Name = req.Name
+	product.Category = strings.TrimSpace(req.Category)
 	product.Price = req.Price
+	product.BinLocation = req.BinLocation
+	product.WeightKg = req.WeightKg
+	product.VolumeM3 = req.VolumeM3
 
 	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
 		if err := s.productRepo.Update(txCtx, product); err != nil {
@@ -221,8 +277,12 @@ func (s *inventoryService) UpdateProduct(ctx context.Context, userID string, id
 		ID:           product.ID.String(),
 		SKU:          product.SKU,
 		Name:         product.Name,
+		Category:     product.Category,
 		CurrentStock: product.CurrentStock,
 		Price:        product.Price,
+		BinLocation:  product.BinLocation,
+		WeightKg:     product.WeightKg,
+		VolumeM3:     product.VolumeM3,
 	}, nil
 }
 
@@ -273,10 +333,23 @@ func (s *inventoryService) CreateOrder(ctx context.Context, userID string, req C
 			ProductName string  `json:"product_name"`
 			Quantity    int     `json:"quantity"`
 			UnitPrice   float64 `json:"unit_price"`
+			Discount    float64 `json:"discount,omitempty"`
+			TaxRuleID   string  `json:"tax_rule_id,omitempty"`
 		}
 		var auditItems []OrderItemAudit
 
-		for _, itemReq := range req.Items {
+		// Tax rules are validated up front; invoice lines are taxed with them on approval
+		documentType := model.RefTypeOrderImport
+		if req.Type == model.OrderTypeExport {
+			documentType = model.RefTypeOrderExport
+		}
+		orderTaxRuleID, ruleErr := s.parseTaxRuleID(txCtx, req.TaxRuleID, documentType)
+		if ruleErr != nil {
+			return ruleErr
+		}
+		orderItems := make([]model.OrderItem, 0, len(req.Items))
+
+		for i, itemReq := range req.Items {
 			pid, parseErr := uuid.Parse(itemReq.ProductID)
 			if parseErr != nil {
 				return fmt.Errorf("invalid product_id: %w", parseErr)
@@ -289,16 +362,35 @@ func (s *inventoryService) CreateOrder(ctx context.Context, userID string, req C
 				return fmt.Errorf("failed to find product %s: %w", itemReq.ProductID, findErr)
 			}
 
+			if itemReq.Discount > itemReq.UnitPrice*float64(itemReq.Quantity) {
+				return fmt.Errorf("item %d: discount exceeds the line amount", i+1)
+			}
+			ruleID, ruleErr := s.parseTaxRuleID(txCtx, itemReq.TaxRuleID, documentType)
+			if ruleErr != nil {
+				return fmt.Errorf("item %d: %w", i+1, ruleErr)
+			}
+			orderItems = append(orderItems, model.OrderItem{
+				ProductID: pid,
+				Product:   *product,
+				Quantity:  itemReq.Quantity,
+				UnitPrice: itemReq.UnitPrice,
+				Discount:  itemReq.Discount,
+				TaxRuleID: ruleID,
+			})
+
 			productNames = append(productNames, product.Name)
 			auditItems = append(auditItems, OrderItemAudit{
 				ProductID:   itemReq.ProductID,
 				ProductName: product.Name,
 				Quantity:    itemReq.Quantity,
 				UnitPrice:   itemReq.UnitPrice,
+				Discount:    itemReq.Discount,
+				TaxRuleID:   itemReq.TaxRuleID,
 			})
 		}
 
 		// 2. Validate Partner (if provided)
+		var partner *model.Partner
 		var partnerID *uuid.UUID
 		var originAddrID, shippingAddrID *uuid.UUID
 
@@ -307,10 +399,11 @@ func (s *inventoryService) CreateOrder(ctx context.Context, userID string, req C
 			if parseErr != nil {
 				return fmt.Errorf("invalid partner_id: %w", parseErr)
 			}
-			partner, findErr := s.partnerRepo.FindByID(txCtx, pid)
+			found, findErr := s.partnerRepo.FindByID(txCtx, pid)
 			if findErr != nil {
 				return fmt.Errorf("partner not found: %w", findErr)
 			}
+			partner = found
 			partnerID = &pid
 
 			// Validate origin address belongs to this partner
@@ -352,35 +445,84 @@ func (s *inventoryService) CreateOrder(ctx context.Context, userID string, req C
 			}
 		}
 
-		// 3. Create order with partner references
+		// 3. Parse the optional delivery time window
+		windowStart, err := parseOptionalRFC3339("delivery_window_start", req.DeliveryWindowStart)
+		if err != nil {
+			return err
+		}
+		windowEnd, err := parseOptionalRFC3339("delivery_window_end", req.DeliveryWindowEnd)
+		if err != nil {
+			return err
+		}
+		if windowStart != nil && windowEnd != nil && windowEnd.Before(*windowStart) {
+			return fmt.Errorf("delivery_window_end must not be before delivery_window_start")
+		}
+
+		// 4. Price the order in its currency the way its invoice will be (line taxes plus side
+		// fees) and convert it to the base currency
+		currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+		if currency == "" {
+			currency = baseCurrency
+		}
+		rate, err := resolveDocumentRate(txCtx, s.rateRepo, currency, req.ExchangeRate, req.ExchangeRateOverrideReason, time.Now())
+		if err != nil {
+			return err
+		}
+		total, err := s.priceOrder(txCtx, orderItems, orderTaxRuleID, orderTaxLine(req.Type, partner, time.Now()), req.SideFees, currency)
+		if err != nil {
+			return err
+		}
+		totalBase := roundBase(total.Mul(rate.Rate))
+
+		// 5. Create order with partner references; export orders over the customer's credit limit
+		// are put on credit hold
+		creditCheck, err := checkCreditLimit(txCtx, s.paymentRepo, s.orderRepo, req.Type, partner, totalBase, uuid.Nil)
+		if err != nil {
+			return err
+		}
+
 		order := model.Order{
-			OrderCode:         req.OrderCode,
-			Type:              req.Type,
-			Note:              req.Note,
-			Status:            model.OrderStatusPendingApproval,
-			PartnerID:         partnerID,
-			OriginAddressID:   originAddrID,
-			ShippingAddressID: shippingAddrID,
+			OrderCode:           req.OrderCode,
+			Type:                req.Type,
+			Note:                req.Note,
+			Status:              model.OrderStatusPendingApproval,
+			PartnerID:           partnerID,
+			OriginAddressID:     originAddrID,
+			ShippingAddressID:   shippingAddrID,
+			Currency:            currency,
+			ExchangeRate:        rate.Rate,
+			RateSource:          rate.Source,
+			RateDate:            rate.RateDate,
+			RateOverrideReason:  rate.OverrideReason,
+			TotalAmount:         total,
+			TotalAmountBase:     totalBase,
+			DeliveryWindowStart: windowStart,
+			DeliveryWindowEnd:   windowEnd,
+		}
+		if creditCheck != nil && creditCheck.exceeded() {
+			order.CreditHold = true
+			order.CreditHoldReason = creditCheck.reason()
 		}
 		if err := s.orderRepo.Create(txCtx, &order); err != nil {
 			return fmt.Errorf("failed to create order: %w", err)
 		}
 
-		// 4. Create order items
-		for _, itemReq := range req.Items {
-			pid, _ := uuid.Parse(itemReq.ProductID)
+		// 6. Create order items
+		for i := range orderItems {
 			orderItem := &model.OrderItem{
 				OrderID:   order.ID,
-				ProductID: pid,
-				Quantity:  itemReq.Quantity,
-				UnitPrice: itemReq.UnitPrice,
+				ProductID: orderItems[i].ProductID,
+				Quantity:  orderItems[i].Quantity,
+				UnitPrice: orderItems[i].UnitPrice,
+				Discount:  orderItems[i].Discount,
+				TaxRuleID: orderItems[i].TaxRuleID,
 			}
 			if err := s.orderRepo.CreateItem(txCtx, orderItem); err != nil {
 				return fmt.Errorf("failed to create order item: %w", err)
 			}
 		}
 
-		// 5. Audit log
+		// 7. Audit log
 		var uid *uuid.UUID
 		if parsed, err := uuid.Parse(userID); err == nil {
 			uid = &parsed
@@ -392,10 +534,21 @@ func (s *inventoryService) CreateOrder(ctx context.Context, userID string, req C
 		}
 
 		auditDetails := map[string]interface{}{
-			"order_code": req.OrderCode,
-			"type":       req.Type,
-			"note":       req.Note,
-			"items":      auditItems,
+			"order_code":        req.OrderCode,
+			"type":              req.Type,
+			"note":              req.Note,
+			"items":             auditItems,
+			"currency":          currency,
+			"exchange_rate":     rate.Rate.String(),
+			"rate_source":       rate.Source,
+			"total_amount":      total.StringFixed(4),
+			"total_amount_base": totalBase.StringFixed(4),
+		}
+		if rate.Source == model.ExpenseRateOverride {
+			auditDetails["rate_override_reason"] = rate.OverrideReason
+			if rate.TableRate != nil {
+				auditDetails["table_rate"] = rate.TableRate.String()
+			}
 		}
 		details, _ := json.Marshal(auditDetails)
 		audit := &model.AuditLog{
@@ -410,15 +563,29 @@ func (s *inventoryService) CreateOrder(ctx context.Context, userID string, req C
 		}
 
 		approvalData := map[string]interface{}{
-			"order_code":          req.OrderCode,
-			"type":                req.Type,
-			"note":                req.Note,
-			"items":               auditItems,
-			"tax_rule_id":         req.TaxRuleID,
-			"side_fees":           req.SideFees,
-			"partner_id":          req.PartnerID,
-			"origin_address_id":   req.OriginAddressID,
-			"shipping_address_id": req.ShippingAddressID,
+			"order_code":            req.OrderCode,
+			"type":                  req.Type,
+			"note":                  req.Note,
+			"items":                 auditItems,
+			"tax_rule_id":           req.TaxRuleID,
+			"side_fees":             req.SideFees,
+			"partner_id":            req.PartnerID,
+			"origin_address_id":     req.OriginAddressID,
+			"shipping_address_id":   req.ShippingAddressID,
+			"delivery_window_start": req.DeliveryWindowStart,
+			"delivery_window_end":   req.DeliveryWindowEnd,
+			"currency":              currency,
+			"exchange_rate":         rate.Rate.String(),
+			"total_amount":          total.StringFixed(4),
+			"total_amount_base":     totalBase.StringFixed(4),
+		}
+
+		if order.CreditHold {
+			approvalData["credit_hold"] = true
+			approvalData["credit_hold_reason"] = order.CreditHoldReason
+			if err := s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionCreditHold, order.ID.String(), req.OrderCode, creditCheck.details())); err != nil {
+				return fmt.Errorf("failed to record credit hold audit: %w", err)
+			}
 		}
 
 		// Enrich with readable partner info for display in approval detail
@@ -471,3 +638,159 @@ func (s *inventoryService) CreateOrder(ctx context.Context, userID string, req C
 		return nil
 	})
 }
+
+// OverrideCreditHold releases an export order from credit hold. The order still goes through
+// the normal approval afterwards.
+func (s *inventoryService) OverrideCreditHold(ctx context.Context, orderID string, userID string, req CreditOverrideRequest) error {
+	id, err := uuid.Parse(orderID)
+	if err != nil {
+		return fmt.Errorf("invalid order id: %w", err)
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return errors.New("reason is required")
+	}
+
+	return s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		order, err := s.orderRepo.FindByIDWithItems(txCtx, id)
+		if err != nil {
+			return fmt.Errorf("order not found: %w", err)
+		}
+		if !order.CreditHold {
+			return errors.New("order is not on credit hold")
+		}
+		if order.CreditOverrideAt != nil {
+			return errors.New("credit hold has already been overridden")
+		}
+		if order.Status != model.OrderStatusPendingApproval {
+			return fmt.Errorf("order is already %s", order.Status)
+		}
+
+		now := time.Now()
+		if err := s.orderRepo.UpdateColumns(txCtx, order.ID, map[string]interface{}{
+			"credit_override_by":     parseOptionalUUID(userID),
+			"credit_override_at":     now,
+			"credit_override_reason": reason,
+		}); err != nil {
+			return fmt.Errorf("failed to override credit hold: %w", err)
+		}
+
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionCreditOverride, order.ID.String(), order.OrderCode, map[string]interface{}{
+			"credit_hold_reason": order.CreditHoldReason,
+			"reason":             reason,
+		}))
+	})
+}
+
+// creditCheck compares a customer's exposure with its credit limit (all amounts in the base currency)
+type creditCheck struct {
+	partner     *model.Partner
+	outstanding decimal.Decimal // Open receivables less receipts not yet allocated
+	pending     decimal.Decimal // Other export orders awaiting approval
+	orderTotal  decimal.Decimal
+}
+
+func (c *creditCheck) exposure() decimal.Decimal {
+	return c.outstanding.Add(c.pending).Add(c.orderTotal)
+}
+
+func (c *creditCheck) exceeded() bool {
+	return c.exposure().GreaterThan(c.partner.CreditLimit)
+}
+
+func (c *creditCheck) reason() string {
+	return fmt.Sprintf("outstanding receivables %s + pending orders %s + order %s = %s exceed the credit limit %s of %s",
+		c.outstanding.StringFixed(4), c.pending.StringFixed(4), c.orderTotal.StringFixed(4), c.exposure().StringFixed(4),
+		c.partner.CreditLimit.StringFixed(4), c.partner.Name)
+}
+
+func (c *creditCheck) details() map[string]interface{} {
+	return map[string]interface{}{
+		"partner_id":   c.partner.ID.String(),
+		"partner_name": c.partner.Name,
+		"credit_limit": c.partner.CreditLimit.StringFixed(4),
+		"outstanding":  c.outstanding.StringFixed(4),
+		"pending":      c.pending.StringFixed(4),
+		"order_total":  c.orderTotal.StringFixed(4),
+		"exposure":     c.exposure().StringFixed(4),
+	}
+}
+
+// priceOrder totals the order the way its invoice will be, in the order currency: items net of
+// discounts, line taxes and side fees
+func (s *inventoryService) priceOrder(ctx context.Context, items []model.OrderItem, taxRuleID *uuid.UUID, taxLine TaxLineContext, sideFees, currency string) (decimal.Decimal, error) {
+	lines, err := buildOrderInvoiceLines(ctx, s.taxRuleRepo, items, taxRuleID, taxLine, currency)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	subtotal, taxAmount, _ := sumInvoiceLines(lines)
+	total := subtotal.Add(taxAmount)
+	if sideFees != "" {
+		fees, err := decimal.NewFromString(sideFees)
+		if err != nil || fees.IsNegative() {
+			return decimal.Zero, errors.New("side_fees must be a non-negative amount")
+		}
+		total = total.Add(fees)
+	}
+	return total, nil
+}
+
+// checkCreditLimit adds the base-currency total of an export order to the customer's outstanding
+// receivables and its other export orders awaiting approval (orderID is the order itself once it
+// exists). It returns nil for import orders and customers without a credit limit.
+func checkCreditLimit(ctx context.Context, paymentRepo repository.PaymentRepository, orderRepo repository.OrderRepository, orderType string, partner *model.Partner, totalBase decimal.Decimal, orderID uuid.UUID) (*creditCheck, error) {
+	if orderType != model.OrderTypeExport || partner == nil || !partner.CreditLimit.IsPositive() {
+		return nil, nil
+	}
+
+	balance, err := paymentRepo.GetPartnerBalance(ctx, partner.ID, model.PaymentTypeReceipt, paymentReferenceTypes[model.PaymentTypeReceipt])
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch receivables of %s: %w", partner.Name, err)
+	}
+	pending, err := orderRepo.SumPendingExportBase(ctx, partner.ID, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending orders of %s: %w", partner.Name, err)
+	}
+
+	return &creditCheck{
+		partner:     partner,
+		outstanding: balance.OutstandingAmount.Sub(balance.UnallocatedAmount),
+		pending:     pending,
+		orderTotal:  totalBase,
+	}, nil
+}
+
+// parseOptionalRFC3339 parses an optional timestamp field; empty means not set
+func parseOptionalRFC3339(field, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC3339 timestamp", field)
+	}
+	return &t, nil
+}
+
+// parseTaxRuleID checks that an optional tax_rule_id refers to an existing rule whose tax type
+// applies to the document type; empty means not set
+func (s *inventoryService) parseTaxRuleID(ctx context.Context, value, documentType string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tax_rule_id: %w", err)
+	}
+	rule, err := s.taxRuleRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("tax rule not found: %s", value)
+		}
+		return nil, fmt.Errorf("failed to fetch tax rule: %w", err)
+	}
+	if err := checkTaxRuleApplies(ctx, s.taxRuleRepo, rule, documentType); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
@@ -0,0 +1,481 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// --- DTOs ---
+
+// InvoiceAdjustmentLineRequest is one line of a credit note, debit note or replacement invoice.
+// Referencing an original line copies its product, description and tax rule.
+type InvoiceAdjustmentLineRequest struct {
+	OriginalLineID string `json:"original_line_id"` // Optional: line of the original invoice being corrected
+	Description    string `json:"description"`      // Required when no original line is given
+	Quantity       int    `json:"quantity" binding:"required,min=1"`
+	UnitPrice      string `json:"unit_price" binding:"required"` // Positive, in the invoice currency; the per-unit difference on notes, the new price on replacements
+	Discount       string `json:"discount"`                      // Optional, defaults to 0
+	TaxRuleID      string `json:"tax_rule_id"`                   // Optional: overrides the tax rule of the original line
+}
+
+// CreateInvoiceAdjustmentRequest raises a credit note (decrease) or debit note (increase)
+// against an approved invoice. Amounts are entered as positive differences.
+type CreateInvoiceAdjustmentRequest struct {
+	AdjustmentType string                         `json:"adjustment_type" binding:"required,oneof=CREDIT_NOTE DEBIT_NOTE"`
+	Reason         string                         `json:"reason" binding:"required"`
+	Lines          []InvoiceAdjustmentLineRequest `json:"lines" binding:"required,min=1,dive"`
+	SideFees       string                         `json:"side_fees"` // Optional difference in side fees
+	Note           string                         `json:"note"`
+}
+
+// ReplaceInvoiceRequest issues an invoice that voids and supersedes an approved one.
+// Lines, side fees and partner fields left empty are copied from the original.
+type ReplaceInvoiceRequest struct {
+	Reason         string                         `json:"reason" binding:"required"`
+	Lines          []InvoiceAdjustmentLineRequest `json:"lines" binding:"omitempty,dive"`
+	SideFees       *string                        `json:"side_fees"`
+	CompanyName    *string                        `json:"company_name"`
+	TaxCode        *string                        `json:"tax_code"`
+	BillingAddress *string                        `json:"billing_address"`
+	Note           string                         `json:"note"`
+}
+
+// --- Implementation ---
+
+// CreateAdjustment creates a PENDING credit or debit note for an approved invoice. The note
+// carries signed amounts (negative for credit notes) so approved notes net into revenue.
+func (s *invoiceService) CreateAdjustment(ctx context.Context, id string, userID string, req CreateInvoiceAdjustmentRequest) (InvoiceResponse, error) {
+	original, err := s.loadAdjustableInvoice(ctx, id)
+	if err != nil {
+		return InvoiceResponse{}, err
+	}
+
+	sign := decimal.NewFromInt(1)
+	if req.AdjustmentType == model.InvoiceTypeCreditNote {
+		sign = sign.Neg()
+	}
+
+	lines, err := s.buildAdjustmentLines(ctx, *original, req.Lines, sign)
+	if err != nil {
+		return InvoiceResponse{}, err
+	}
+	sideFees := decimal.Zero
+	if req.SideFees != "" {
+		sideFees, err = decimal.NewFromString(req.SideFees)
+		if err != nil || sideFees.IsNegative() {
+			return InvoiceResponse{}, errors.New("side_fees must be a non-negative amount")
+		}
+		sideFees = sideFees.Mul(sign)
+	}
+
+	subtotal, taxAmount, taxRuleID := sumInvoiceLines(lines)
+	total := subtotal.Add(taxAmount).Add(sideFees)
+
+	note := adjustmentInvoice(*original, req.AdjustmentType, req.Reason, req.Note)
+	note.TaxRuleID = taxRuleID
+	note.Subtotal = subtotal
+	note.TaxAmount = taxAmount
+	note.SideFees = sideFees
+	note.Lines = lines
+	convertInvoiceToBase(note)
+
+	checkNote := func(locked *model.Invoice, adjustments []model.Invoice) error {
+		for _, a := range adjustments {
+			if a.InvoiceType == model.InvoiceTypeReplacement && a.ApprovalStatus == model.ApprovalPending {
+				return fmt.Errorf("invoice %s has a pending replacement %s", locked.InvoiceNo, a.InvoiceNo)
+			}
+		}
+		if !sign.IsNegative() {
+			return nil
+		}
+		// Pending notes are counted too, so approving them in any order cannot push the invoice
+		// below zero. Notes are entered in the invoice currency, so the check is too.
+		remaining := locked.DocTotalAmount
+		for _, a := range adjustments {
+			if a.InvoiceType != model.InvoiceTypeReplacement && a.ApprovalStatus != model.ApprovalRejected {
+				remaining = remaining.Add(a.DocTotalAmount)
+			}
+		}
+		if remaining.Add(total).IsNegative() {
+			return fmt.Errorf("credit note of %s exceeds the remaining amount %s %s of invoice %s",
+				total.Neg().StringFixed(4), remaining.StringFixed(4), locked.Currency, locked.InvoiceNo)
+		}
+		return nil
+	}
+
+	if err := s.createAdjustmentInvoice(ctx, note, original, userID, checkNote); err != nil {
+		return InvoiceResponse{}, err
+	}
+
+	reloaded, err := s.invoiceRepo.FindByIDWithTaxRule(ctx, note.ID)
+	if err != nil {
+		return InvoiceResponse{}, fmt.Errorf("failed to reload invoice: %w", err)
+	}
+	return toInvoiceResponse(*reloaded), nil
+}
+
+// ReplaceInvoice creates a PENDING replacement invoice. The original stays valid until the
+// replacement is approved, at which point it is voided.
+func (s *invoiceService) ReplaceInvoice(ctx context.Context, id string, userID string, req ReplaceInvoiceRequest) (InvoiceResponse, error) {
+	original, err := s.loadAdjustableInvoice(ctx, id)
+	if err != nil {
+		return InvoiceResponse{}, err
+	}
+
+	var sideFees *decimal.Decimal
+	if req.SideFees != nil {
+		parsed, err := decimal.NewFromString(*req.SideFees)
+		if err != nil || parsed.IsNegative() {
+			return InvoiceResponse{}, errors.New("side_fees must be a non-negative amount")
+		}
+		sideFees = &parsed
+	}
+
+	replacement := adjustmentInvoice(*original, model.InvoiceTypeReplacement, req.Reason, req.Note)
+	if len(req.Lines) > 0 {
+		lines, err := s.buildAdjustmentLines(ctx, *original, req.Lines, decimal.NewFromInt(1))
+		if err != nil {
+			return InvoiceResponse{}, err
+		}
+		subtotal, taxAmount, taxRuleID := sumInvoiceLines(lines)
+		replacement.TaxRuleID = taxRuleID
+		replacement.Subtotal = subtotal
+		replacement.TaxAmount = taxAmount
+		replacement.SideFees = original.DocSideFees
+		if sideFees != nil {
+			replacement.SideFees = *sideFees
+		}
+		replacement.Lines = lines
+		convertInvoiceToBase(replacement)
+	} else {
+		// The lines (if any; manual invoices carry only header amounts) are copied as booked, in
+		// the base currency and in the invoice currency
+		for _, l := range original.Lines {
+			l.ID = uuid.Nil
+			l.InvoiceID = uuid.Nil
+			l.Product = nil
+			replacement.Lines = append(replacement.Lines, l)
+		}
+		replacement.TaxRuleID = original.TaxRuleID
+		replacement.Subtotal, replacement.DocSubtotal = original.Subtotal, original.DocSubtotal
+		replacement.TaxAmount, replacement.DocTaxAmount = original.TaxAmount, original.DocTaxAmount
+		replacement.SideFees, replacement.DocSideFees = original.SideFees, original.DocSideFees
+		if sideFees != nil {
+			replacement.DocSideFees = *sideFees
+			replacement.SideFees = roundBase(sideFees.Mul(original.ExchangeRate))
+		}
+		replacement.TotalAmount = replacement.Subtotal.Add(replacement.TaxAmount).Add(replacement.SideFees)
+		replacement.DocTotalAmount = replacement.DocSubtotal.Add(replacement.DocTaxAmount).Add(replacement.DocSideFees)
+	}
+	if req.CompanyName != nil {
+		replacement.CompanyName = *req.CompanyName
+	}
+	if req.TaxCode != nil {
+		replacement.TaxCode = *req.TaxCode
+	}
+	if req.BillingAddress != nil {
+		replacement.BillingAddress = *req.BillingAddress
+	}
+
+	checkReplacement := func(locked *model.Invoice, adjustments []model.Invoice) error {
+		for _, a := range adjustments {
+			if a.ApprovalStatus == model.ApprovalRejected {
+				continue
+			}
+			if a.InvoiceType == model.InvoiceTypeReplacement {
+				return fmt.Errorf("invoice %s already has a pending replacement %s", locked.InvoiceNo, a.InvoiceNo)
+			}
+			// The replacement would supersede the original but not its notes
+			return fmt.Errorf("invoice %s has been adjusted by %s; correct it with another credit or debit note instead", locked.InvoiceNo, a.InvoiceNo)
+		}
+		return nil
+	}
+
+	if err := s.createAdjustmentInvoice(ctx, replacement, original, userID, checkReplacement); err != nil {
+		return InvoiceResponse{}, err
+	}
+
+	reloaded, err := s.invoiceRepo.FindByIDWithTaxRule(ctx, replacement.ID)
+	if err != nil {
+		return InvoiceResponse{}, fmt.Errorf("failed to reload invoice: %w", err)
+	}
+	return toInvoiceResponse(*reloaded), nil
+}
+
+// applyApprovedAdjustment runs inside the approval transaction of a note or replacement:
+// the original must still be in force, and an approved replacement voids it and takes over
+// its payments
+func (s *invoiceService) applyApprovedAdjustment(ctx context.Context, invoice *model.Invoice, userID string, now time.Time) error {
+	// Locked like a payment allocation, so a note cannot race a payment on the same invoice
+	original, err := s.invoiceRepo.FindByIDForUpdate(ctx, *invoice.OriginalInvoiceID)
+	if err != nil {
+		return fmt.Errorf("original invoice not found: %w", err)
+	}
+	if original.VoidedAt != nil {
+		return fmt.Errorf("original invoice %s has been voided", original.InvoiceNo)
+	}
+
+	if invoice.InvoiceType == model.InvoiceTypeReplacement {
+		original.VoidedAt = &now
+		original.ReplacedByID = &invoice.ID
+		if err := s.invoiceRepo.Update(ctx, original); err != nil {
+			return fmt.Errorf("failed to void original invoice: %w", err)
+		}
+		if err := s.auditRepo.Log(ctx, newAuditLog(userID, model.ActionVoidInvoice, original.ID.String(), original.InvoiceNo, map[string]interface{}{
+			"replaced_by_id": invoice.ID.String(),
+			"replaced_by_no": invoice.InvoiceNo,
+			"reason":         invoice.AdjustmentReason,
+		})); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+
+		// The replacement is posted in full, so the original and its notes come off the books
+		adjustments, err := s.invoiceRepo.ListAdjustments(ctx, original.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch invoice adjustments: %w", err)
+		}
+		voided := []uuid.UUID{original.ID}
+		for _, adj := range adjustments {
+			if adj.InvoiceType != model.InvoiceTypeReplacement {
+				voided = append(voided, adj.ID)
+			}
+		}
+		if err := reverseJournalEntries(ctx, s.ledgerRepo, s.sequenceRepo, model.JournalSourceInvoice, voided, now, parseOptionalUUID(userID), "replaced by "+invoice.InvoiceNo); err != nil {
+			return err
+		}
+	}
+
+	if err := s.auditRepo.Log(ctx, newAuditLog(userID, model.ActionApproveInvoiceAdjustment, invoice.ID.String(), invoice.InvoiceNo, map[string]interface{}{
+		"invoice_type":        invoice.InvoiceType,
+		"original_invoice_id": original.ID.String(),
+		"original_invoice_no": original.InvoiceNo,
+		"total_amount":        invoice.TotalAmount.StringFixed(4),
+	})); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	if invoice.InvoiceType == model.InvoiceTypeReplacement {
+		// The replacement keeps the payment terms the original was issued under
+		invoice.DueDate = original.DueDate
+		if err := s.paymentRepo.ReassignAllocations(ctx, original.ID, invoice.ID); err != nil {
+			return fmt.Errorf("failed to move payments to the replacement invoice: %w", err)
+		}
+		if err := refreshInvoicePaymentStatus(ctx, s.invoiceRepo, s.paymentRepo, original); err != nil {
+			return err
+		}
+		return refreshInvoicePaymentStatus(ctx, s.invoiceRepo, s.paymentRepo, invoice)
+	}
+	return refreshInvoicePaymentStatus(ctx, s.invoiceRepo, s.paymentRepo, original)
+}
+
+// loadAdjustableInvoice returns an approved, non-voided invoice with its lines. Notes themselves
+// cannot be adjusted.
+func (s *invoiceService) loadAdjustableInvoice(ctx context.Context, id string) (*model.Invoice, error) {
+	invoiceID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid invoice id: %w", err)
+	}
+
+	original, err := s.invoiceRepo.FindByIDWithDetails(ctx, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("invoice not found: %w", err)
+	}
+	if err := checkAdjustable(original); err != nil {
+		return nil, err
+	}
+	return original, nil
+}
+
+func checkAdjustable(original *model.Invoice) error {
+	if original.ApprovalStatus != model.ApprovalApproved {
+		return fmt.Errorf("only approved invoices can be adjusted; edit or reject the %s invoice instead", original.ApprovalStatus)
+	}
+	if original.VoidedAt != nil {
+		return fmt.Errorf("invoice %s has been voided", original.InvoiceNo)
+	}
+	if original.InvoiceType == model.InvoiceTypeCreditNote || original.InvoiceType == model.InvoiceTypeDebitNote {
+		return errors.New("credit and debit notes cannot be adjusted; adjust the original invoice")
+	}
+	return nil
+}
+
+// createAdjustmentInvoice saves a note or replacement. The original is locked and re-read with
+// the notes and replacements already raised against it, which check validates, so concurrent
+// adjustments of the same invoice are checked one after the other.
+func (s *invoiceService) createAdjustmentInvoice(ctx context.Context, invoice *model.Invoice, original *model.Invoice, userID string, check func(locked *model.Invoice, adjustments []model.Invoice) error) error {
+	return s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		locked, err := s.invoiceRepo.FindByIDForUpdate(txCtx, original.ID)
+		if err != nil {
+			return fmt.Errorf("invoice not found: %w", err)
+		}
+		if err := checkAdjustable(locked); err != nil {
+			return err
+		}
+		adjustments, err := s.invoiceRepo.ListAdjustments(txCtx, locked.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch invoice adjustments: %w", err)
+		}
+		if err := check(locked, adjustments); err != nil {
+			return err
+		}
+
+		if err := checkPeriodOpen(txCtx, s.periodRepo, time.Now(), false); err != nil {
+			return err
+		}
+		invoiceNo, err := nextDocumentNo(txCtx, s.sequenceRepo, model.SequenceInvoice, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to generate invoice number: %w", err)
+		}
+		invoice.InvoiceNo = invoiceNo
+
+		if err := s.invoiceRepo.Create(txCtx, invoice); err != nil {
+			return fmt.Errorf("failed to create invoice: %w", err)
+		}
+
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionCreateInvoiceAdjustment, invoice.ID.String(), invoice.InvoiceNo, map[string]interface{}{
+			"invoice_type":        invoice.InvoiceType,
+			"original_invoice_id": original.ID.String(),
+			"original_invoice_no": original.InvoiceNo,
+			"reason":              invoice.AdjustmentReason,
+			"subtotal":            invoice.Subtotal.StringFixed(4),
+			"tax_amount":          invoice.TaxAmount.StringFixed(4),
+			"total_amount":        invoice.TotalAmount.StringFixed(4),
+			"currency":            invoice.Currency,
+			"doc_total_amount":    invoice.DocTotalAmount.StringFixed(4),
+		}))
+	})
+}
+
+// buildAdjustmentLines prices the requested lines; sign -1 turns them into credit note lines
+func (s *invoiceService) buildAdjustmentLines(ctx context.Context, original model.Invoice, reqLines []InvoiceAdjustmentLineRequest, sign decimal.Decimal) ([]model.InvoiceLine, error) {
+	originalLines := make(map[uuid.UUID]model.InvoiceLine, len(original.Lines))
+	for _, l := range original.Lines {
+		originalLines[l.ID] = l
+	}
+	rules := make(map[uuid.UUID]*model.TaxRule)
+
+	lines := make([]model.InvoiceLine, 0, len(reqLines))
+	for i, r := range reqLines {
+		unitPrice, err := decimal.NewFromString(r.UnitPrice)
+		if err != nil || unitPrice.IsNegative() {
+			return nil, fmt.Errorf("line %d: unit_price must be a non-negative amount", i+1)
+		}
+		discount := decimal.Zero
+		if r.Discount != "" {
+			discount, err = decimal.NewFromString(r.Discount)
+			if err != nil || discount.IsNegative() {
+				return nil, fmt.Errorf("line %d: discount must be a non-negative amount", i+1)
+			}
+		}
+		amount := unitPrice.Mul(decimal.NewFromInt(int64(r.Quantity))).Sub(discount)
+		if amount.IsNegative() {
+			return nil, fmt.Errorf("line %d: discount exceeds the line amount", i+1)
+		}
+
+		line := model.InvoiceLine{
+			LineNo:      i + 1,
+			Description: r.Description,
+			Quantity:    r.Quantity,
+		}
+		if r.OriginalLineID != "" {
+			lineID, err := uuid.Parse(r.OriginalLineID)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid original_line_id: %w", i+1, err)
+			}
+			ol, ok := originalLines[lineID]
+			if !ok {
+				return nil, fmt.Errorf("line %d: line %s is not on invoice %s", i+1, r.OriginalLineID, original.InvoiceNo)
+			}
+			line.OrderItemID = ol.OrderItemID
+			line.ProductID = ol.ProductID
+			if line.Description == "" {
+				line.Description = ol.Description
+			}
+			line.TaxRuleID = ol.TaxRuleID
+			line.TaxType = ol.TaxType
+			line.TaxRate = ol.TaxRate
+		} else if line.Description == "" {
+			return nil, fmt.Errorf("line %d: description is required when no original_line_id is given", i+1)
+		}
+
+		if r.TaxRuleID != "" {
+			ruleID, err := uuid.Parse(r.TaxRuleID)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid tax_rule_id: %w", i+1, err)
+			}
+			rule, ok := rules[ruleID]
+			if !ok {
+				rule, err = s.taxRuleRepo.FindByID(ctx, ruleID)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: tax rule not found: %w", i+1, err)
+				}
+				if err := checkTaxRuleApplies(ctx, s.taxRuleRepo, rule, original.ReferenceType); err != nil {
+					return nil, fmt.Errorf("line %d: %w", i+1, err)
+				}
+				rules[ruleID] = rule
+			}
+			line.TaxRuleID = &rule.ID
+			line.TaxType = rule.TaxType
+			line.TaxRate = rule.Rate
+		}
+
+		line.UnitPrice = unitPrice.Mul(sign)
+		line.Discount = discount.Mul(sign)
+		line.Amount = amount.Mul(sign)
+		if line.TaxType != "" {
+			line.TaxAmount = roundAmount(line.Amount.Mul(line.TaxRate), original.Currency)
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// adjustmentInvoice starts a note or replacement with the reference, currency and partner
+// snapshot of the original, so it is reported under the same order or expense and converted at
+// the rate the original was booked at
+func adjustmentInvoice(original model.Invoice, invoiceType, reason, note string) *model.Invoice {
+	return &model.Invoice{
+		InvoiceType:       invoiceType,
+		OriginalInvoiceID: &original.ID,
+		AdjustmentReason:  reason,
+		ReferenceType:     original.ReferenceType,
+		ReferenceID:       original.ReferenceID,
+		Currency:          original.Currency,
+		ExchangeRate:      original.ExchangeRate,
+		ApprovalStatus:    model.ApprovalPending,
+		Note:              note,
+		PartnerID:         original.PartnerID,
+		CompanyName:       original.CompanyName,
+		TaxCode:           original.TaxCode,
+		BillingAddress:    original.BillingAddress,
+	}
+}
+
+// netInvoiceDocTotal is netInvoiceTotal in the invoice currency
+func netInvoiceDocTotal(inv model.Invoice, adjustments []model.Invoice) decimal.Decimal {
+	net := inv.DocTotalAmount
+	for _, a := range adjustments {
+		if a.InvoiceType != model.InvoiceTypeReplacement && a.ApprovalStatus == model.ApprovalApproved {
+			net = net.Add(a.DocTotalAmount)
+		}
+	}
+	return net
+}
+
+// netInvoiceTotal is the invoice total after its approved credit and debit notes
+func netInvoiceTotal(inv model.Invoice, adjustments []model.Invoice) decimal.Decimal {
+	net := inv.TotalAmount
+	for _, a := range adjustments {
+		if a.InvoiceType != model.InvoiceTypeReplacement && a.ApprovalStatus == model.ApprovalApproved {
+			net = net.Add(a.TotalAmount)
+		}
+	}
+	return net
+}
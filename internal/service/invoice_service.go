@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"backend/internal/model"
@@ -10,6 +13,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 // --- DTOs ---
@@ -17,9 +21,9 @@ import (
 type CreateInvoiceRequest struct {
 	ReferenceType string `json:"reference_type" binding:"required,oneof=ORDER_IMPORT ORDER_EXPORT EXPENSE"`
 	ReferenceID   string `json:"reference_id" binding:"required"`
-	TaxRuleID     string `json:"tax_rule_id"` // Optional: user-selected tax rule
-	Subtotal      string `json:"subtotal" binding:"required"`
-	SideFees      string `json:"side_fees"` // Optional, defaults to 0
+	TaxRuleID     string `json:"tax_rule_id"`                 // Optional: user-selected tax rule
+	Subtotal      string `json:"subtotal" binding:"required"` // In the currency of the referenced order or expense
+	SideFees      string `json:"side_fees"`                   // Optional, defaults to 0
 	Note          string `json:"note"`
 }
 
@@ -27,6 +31,8 @@ type InvoiceFilter struct {
 	ApprovalStatus string // PENDING, APPROVED, REJECTED or empty for all
 	InvoiceNo      string // partial match on invoice_no
 	ReferenceType  string // ORDER_IMPORT, ORDER_EXPORT, EXPENSE or empty for all
+	InvoiceType    string // STANDARD, CREDIT_NOTE, DEBIT_NOTE, REPLACEMENT or empty for all
+	PaymentStatus  string // UNPAID, PARTIAL, PAID or empty for all
 	Page           int
 	Limit          int
 }
@@ -43,6 +49,12 @@ type InvoiceResponse struct {
 	TaxAmount      string  `json:"tax_amount"`
 	SideFees       string  `json:"side_fees"`
 	TotalAmount    string  `json:"total_amount"`
+	Currency       string  `json:"currency"`
+	ExchangeRate   string  `json:"exchange_rate"`
+	DocSubtotal    string  `json:"doc_subtotal"`
+	DocTaxAmount   string  `json:"doc_tax_amount"`
+	DocSideFees    string  `json:"doc_side_fees"`
+	DocTotalAmount string  `json:"doc_total_amount"`
 	ApprovalStatus string  `json:"approval_status"`
 	ApprovedBy     *string `json:"approved_by"`
 	ApprovedAt     *string `json:"approved_at"`
@@ -52,6 +64,64 @@ type InvoiceResponse struct {
 	TaxCode        string  `json:"tax_code"`
 	BillingAddress string  `json:"billing_address"`
 	CreatedAt      string  `json:"created_at"`
+
+	InvoiceType       string  `json:"invoice_type"`
+	OriginalInvoiceID *string `json:"original_invoice_id"`
+	AdjustmentReason  string  `json:"adjustment_reason"`
+	VoidedAt          *string `json:"voided_at"`
+	ReplacedByID      *string `json:"replaced_by_id"`
+
+	PaymentStatus string `json:"payment_status"`
+	PaidAmount    string `json:"paid_amount"`
+	DocPaidAmount string `json:"doc_paid_amount"`
+
+	TaxSummary []TaxSummaryResponse `json:"tax_summary"`
+}
+
+// TaxSummaryResponse totals the lines of an invoice taxed at the same type and rate.
+// TaxRate is nil for lines not subject to tax.
+type TaxSummaryResponse struct {
+	TaxType       string  `json:"tax_type"`
+	TaxRate       *string `json:"tax_rate"`
+	TaxableAmount string  `json:"taxable_amount"`
+	TaxAmount     string  `json:"tax_amount"`
+}
+
+type InvoiceLineResponse struct {
+	ID          string  `json:"id"`
+	LineNo      int     `json:"line_no"`
+	OrderItemID *string `json:"order_item_id"`
+	ProductID   *string `json:"product_id"`
+	ProductSKU  string  `json:"product_sku"`
+	ProductName string  `json:"product_name"`
+	Description string  `json:"description"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   string  `json:"unit_price"`
+	Discount    string  `json:"discount"`
+	Amount      string  `json:"amount"`
+	TaxRuleID   *string `json:"tax_rule_id"`
+	TaxType     string  `json:"tax_type"`
+	TaxRate     string  `json:"tax_rate"`
+	TaxAmount   string  `json:"tax_amount"`
+	Total       string  `json:"total"`
+}
+
+// InvoiceDetailResponse is the full invoice used for viewing and reprinting: header, lines,
+// tax breakdown (tax_summary), partner snapshot, the approvals that led to the invoice and
+// the credit/debit notes and replacements issued against it
+type InvoiceDetailResponse struct {
+	InvoiceResponse
+	PartnerName       string                    `json:"partner_name"` // Current partner name; company_name/tax_code/billing_address are the snapshot
+	ApproverName      string                    `json:"approver_name"`
+	OriginalInvoiceNo string                    `json:"original_invoice_no"`
+	Lines             []InvoiceLineResponse     `json:"lines"`
+	ApprovalHistory   []ApprovalRequestResponse `json:"approval_history"`
+	Adjustments       []InvoiceResponse         `json:"adjustments"`
+	NetTotalAmount    string                    `json:"net_total_amount"`   // total_amount plus approved credit and debit notes
+	OutstandingAmount string                    `json:"outstanding_amount"` // net_total_amount less paid_amount
+	// The same in the invoice currency; payments are allocated against doc_outstanding_amount
+	DocNetTotalAmount    string `json:"doc_net_total_amount"`
+	DocOutstandingAmount string `json:"doc_outstanding_amount"`
 }
 
 // UpdateInvoiceRequest allows editing partner hard-copy fields on PENDING invoices
@@ -67,18 +137,27 @@ type UpdateInvoiceRequest struct {
 type InvoiceService interface {
 	CreateInvoice(ctx context.Context, req CreateInvoiceRequest) (InvoiceResponse, error)
 	ListInvoices(ctx context.Context, filter InvoiceFilter) ([]InvoiceResponse, int64, error)
+	GetInvoice(ctx context.Context, id string) (InvoiceDetailResponse, error)
 	ApproveInvoice(ctx context.Context, id string, userID string) (InvoiceResponse, error)
 	RejectInvoice(ctx context.Context, id string, userID string) (InvoiceResponse, error)
 	UpdateInvoice(ctx context.Context, id string, req UpdateInvoiceRequest) (InvoiceResponse, error)
+	CreateAdjustment(ctx context.Context, id string, userID string, req CreateInvoiceAdjustmentRequest) (InvoiceResponse, error)
+	ReplaceInvoice(ctx context.Context, id string, userID string, req ReplaceInvoiceRequest) (InvoiceResponse, error)
 }
 
 type invoiceService struct {
-	invoiceRepo repository.InvoiceRepository
-	taxRuleRepo repository.TaxRuleRepository
-	orderRepo   repository.OrderRepository
-	expenseRepo repository.ExpenseRepository
-	partnerRepo repository.PartnerRepository
-	txManager   repository.TransactionManager
+	invoiceRepo  repository.InvoiceRepository
+	taxRuleRepo  repository.TaxRuleRepository
+	orderRepo    repository.OrderRepository
+	expenseRepo  repository.ExpenseRepository
+	partnerRepo  repository.PartnerRepository
+	approvalRepo repository.ApprovalRepository
+	auditRepo    repository.AuditRepository
+	sequenceRepo repository.DocumentSequenceRepository
+	paymentRepo  repository.PaymentRepository
+	ledgerRepo   repository.LedgerRepository
+	periodRepo   repository.FiscalPeriodRepository
+	txManager    repository.TransactionManager
 }
 
 func NewInvoiceService(
@@ -87,15 +166,27 @@ func NewInvoiceService(
 	orderRepo repository.OrderRepository,
 	expenseRepo repository.ExpenseRepository,
 	partnerRepo repository.PartnerRepository,
+	approvalRepo repository.ApprovalRepository,
+	auditRepo repository.AuditRepository,
+	sequenceRepo repository.DocumentSequenceRepository,
+	paymentRepo repository.PaymentRepository,
+	ledgerRepo repository.LedgerRepository,
+	periodRepo repository.FiscalPeriodRepository,
 	txManager repository.TransactionManager,
 ) InvoiceService {
 	return &invoiceService{
-		invoiceRepo: invoiceRepo,
-		taxRuleRepo: taxRuleRepo,
-		orderRepo:   orderRepo,
-		expenseRepo: expenseRepo,
-		partnerRepo: partnerRepo,
-		txManager:   txManager,
+		invoiceRepo:  invoiceRepo,
+		taxRuleRepo:  taxRuleRepo,
+		orderRepo:    orderRepo,
+		expenseRepo:  expenseRepo,
+		partnerRepo:  partnerRepo,
+		approvalRepo: approvalRepo,
+		auditRepo:    auditRepo,
+		sequenceRepo: sequenceRepo,
+		paymentRepo:  paymentRepo,
+		ledgerRepo:   ledgerRepo,
+		periodRepo:   periodRepo,
+		txManager:    txManager,
 	}
 }
 
@@ -120,16 +211,21 @@ func (s *invoiceService) CreateInvoice(ctx context.Context, req CreateInvoiceReq
 		return InvoiceResponse{}, fmt.Errorf("invalid reference_id: %w", err)
 	}
 
-	// Validate reference exists
+	// Validate reference exists; amounts are entered in the currency of the referenced document
+	currency, rate := baseCurrency, decimal.NewFromInt(1)
 	switch req.ReferenceType {
 	case model.RefTypeOrderImport, model.RefTypeOrderExport:
-		if _, err := s.orderRepo.FindByIDWithItems(ctx, refID); err != nil {
+		order, err := s.orderRepo.FindByIDWithItems(ctx, refID)
+		if err != nil {
 			return InvoiceResponse{}, fmt.Errorf("referenced order not found: %w", err)
 		}
+		currency, rate = order.Currency, order.ExchangeRate
 	case model.RefTypeExpense:
-		if _, err := s.expenseRepo.FindByID(ctx, refID); err != nil {
+		expense, err := s.expenseRepo.FindByID(ctx, refID)
+		if err != nil {
 			return InvoiceResponse{}, fmt.Errorf("referenced expense not found: %w", err)
 		}
+		currency, rate = expense.Currency, expense.ExchangeRate
 	}
 
 	// Calculate tax
@@ -146,28 +242,26 @@ func (s *invoiceService) CreateInvoice(ctx context.Context, req CreateInvoiceReq
 		if err != nil {
 			return InvoiceResponse{}, fmt.Errorf("tax rule not found: %w", err)
 		}
-		taxAmount = subtotal.Mul(taxRule.Rate)
-	}
-
-	totalAmount := subtotal.Add(taxAmount).Add(sideFees)
-
-	invoiceNo, err := s.generateInvoiceNo(ctx)
-	if err != nil {
-		return InvoiceResponse{}, fmt.Errorf("failed to generate invoice number: %w", err)
+		if err := checkTaxRuleApplies(ctx, s.taxRuleRepo, taxRule, req.ReferenceType); err != nil {
+			return InvoiceResponse{}, err
+		}
+		taxAmount = roundAmount(subtotal.Mul(taxRule.Rate), currency)
 	}
 
 	invoice := model.Invoice{
-		InvoiceNo:      invoiceNo,
+		InvoiceType:    model.InvoiceTypeStandard,
 		ReferenceType:  req.ReferenceType,
 		ReferenceID:    refID,
 		TaxRuleID:      taxRuleID,
 		Subtotal:       subtotal,
 		TaxAmount:      taxAmount,
 		SideFees:       sideFees,
-		TotalAmount:    totalAmount,
+		Currency:       currency,
+		ExchangeRate:   rate,
 		ApprovalStatus: model.ApprovalPending,
 		Note:           req.Note,
 	}
+	convertInvoiceToBase(&invoice)
 
 	// Auto-fill partner hard-copy fields from the Order's partner (if applicable)
 	if req.ReferenceType == model.RefTypeOrderImport || req.ReferenceType == model.RefTypeOrderExport {
@@ -189,8 +283,24 @@ func (s *invoiceService) CreateInvoice(ctx context.Context, req CreateInvoiceReq
 		}
 	}
 
-	if err := s.invoiceRepo.Create(ctx, &invoice); err != nil {
-		return InvoiceResponse{}, fmt.Errorf("failed to create invoice: %w", err)
+	// The number is allocated in the same transaction so a failed insert does not leave a gap
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		if err := checkPeriodOpen(txCtx, s.periodRepo, time.Now(), false); err != nil {
+			return err
+		}
+		invoiceNo, err := nextDocumentNo(txCtx, s.sequenceRepo, model.SequenceInvoice, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to generate invoice number: %w", err)
+		}
+		invoice.InvoiceNo = invoiceNo
+
+		if err := s.invoiceRepo.Create(txCtx, &invoice); err != nil {
+			return fmt.Errorf("failed to create invoice: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return InvoiceResponse{}, err
 	}
 
 	// Reload with relations
@@ -214,6 +324,8 @@ func (s *invoiceService) ListInvoices(ctx context.Context, filter InvoiceFilter)
 		ApprovalStatus: filter.ApprovalStatus,
 		InvoiceNo:      filter.InvoiceNo,
 		ReferenceType:  filter.ReferenceType,
+		InvoiceType:    filter.InvoiceType,
+		PaymentStatus:  filter.PaymentStatus,
 		Page:           filter.Page,
 		Limit:          filter.Limit,
 	})
@@ -228,6 +340,66 @@ func (s *invoiceService) ListInvoices(ctx context.Context, filter InvoiceFilter)
 	return result, total, nil
 }
 
+// GetInvoice returns an invoice with its lines, tax breakdown and approval history
+func (s *invoiceService) GetInvoice(ctx context.Context, id string) (InvoiceDetailResponse, error) {
+	invoiceID, err := uuid.Parse(id)
+	if err != nil {
+		return InvoiceDetailResponse{}, fmt.Errorf("invalid invoice id: %w", err)
+	}
+
+	invoice, err := s.invoiceRepo.FindByIDWithDetails(ctx, invoiceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return InvoiceDetailResponse{}, fmt.Errorf("invoice not found")
+		}
+		return InvoiceDetailResponse{}, fmt.Errorf("failed to fetch invoice: %w", err)
+	}
+
+	// Invoices generated on approval share the reference of the approved order or expense
+	approvals, err := s.approvalRepo.ListByReferenceID(ctx, invoice.ReferenceID)
+	if err != nil {
+		return InvoiceDetailResponse{}, fmt.Errorf("failed to fetch approval history: %w", err)
+	}
+
+	adjustments, err := s.invoiceRepo.ListAdjustments(ctx, invoice.ID)
+	if err != nil {
+		return InvoiceDetailResponse{}, fmt.Errorf("failed to fetch invoice adjustments: %w", err)
+	}
+
+	netTotal := netInvoiceTotal(*invoice, adjustments)
+	docNetTotal := netInvoiceDocTotal(*invoice, adjustments)
+	resp := InvoiceDetailResponse{
+		InvoiceResponse:      toInvoiceResponse(*invoice),
+		Lines:                make([]InvoiceLineResponse, 0, len(invoice.Lines)),
+		ApprovalHistory:      make([]ApprovalRequestResponse, 0, len(approvals)),
+		Adjustments:          make([]InvoiceResponse, 0, len(adjustments)),
+		NetTotalAmount:       netTotal.StringFixed(4),
+		OutstandingAmount:    netTotal.Sub(invoice.PaidAmount).StringFixed(4),
+		DocNetTotalAmount:    docNetTotal.StringFixed(4),
+		DocOutstandingAmount: docNetTotal.Sub(invoice.DocPaidAmount).StringFixed(4),
+	}
+	if invoice.Partner != nil {
+		resp.PartnerName = invoice.Partner.Name
+	}
+	if invoice.OriginalInvoice != nil {
+		resp.OriginalInvoiceNo = invoice.OriginalInvoice.InvoiceNo
+	}
+	for _, a := range adjustments {
+		resp.Adjustments = append(resp.Adjustments, toInvoiceResponse(a))
+	}
+	if invoice.Approver != nil {
+		resp.ApproverName = invoice.Approver.Username
+	}
+	for _, l := range invoice.Lines {
+		resp.Lines = append(resp.Lines, toInvoiceLineResponse(l))
+	}
+	for _, a := range approvals {
+		resp.ApprovalHistory = append(resp.ApprovalHistory, toApprovalResponse(a))
+	}
+
+	return resp, nil
+}
+
 func (s *invoiceService) ApproveInvoice(ctx context.Context, id string, userID string) (InvoiceResponse, error) {
 	return s.updateApproval(ctx, id, userID, model.ApprovalApproved)
 }
@@ -250,7 +422,7 @@ func (s *invoiceService) updateApproval(ctx context.Context, id string, userID s
 	var invoice *model.Invoice
 	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
 		var findErr error
-		invoice, findErr = s.invoiceRepo.FindByID(txCtx, invoiceID)
+		invoice, findErr = s.invoiceRepo.FindByIDForUpdate(txCtx, invoiceID)
 		if findErr != nil {
 			return fmt.Errorf("invoice not found: %w", findErr)
 		}
@@ -260,15 +432,43 @@ func (s *invoiceService) updateApproval(ctx context.Context, id string, userID s
 		}
 
 		now := time.Now()
+		if status == model.ApprovalApproved {
+			if err := checkPeriodOpen(txCtx, s.periodRepo, now, true); err != nil {
+				return err
+			}
+		}
 		invoice.ApprovalStatus = status
 		invoice.ApprovedBy = &approverID
 		invoice.ApprovedAt = &now
+		if status == model.ApprovalApproved {
+			var partner *model.Partner
+			if invoice.PartnerID != nil {
+				if found, err := s.partnerRepo.FindByID(txCtx, *invoice.PartnerID); err == nil {
+					partner = found
+				}
+			}
+			invoice.DueDate = invoiceDueDate(partner, now)
+		}
 
 		if updateErr := s.invoiceRepo.UpdateApproval(txCtx, invoice); updateErr != nil {
 			return fmt.Errorf("failed to update invoice: %w", updateErr)
 		}
 
-		return nil
+		if status != model.ApprovalApproved {
+			return nil
+		}
+		if invoice.OriginalInvoiceID != nil {
+			if err := s.applyApprovedAdjustment(txCtx, invoice, userID, now); err != nil {
+				return err
+			}
+		}
+
+		// FindByID does not load the lines the posting splits tax by
+		posted, err := s.invoiceRepo.FindByIDWithTaxRule(txCtx, invoice.ID)
+		if err != nil {
+			return fmt.Errorf("failed to reload invoice: %w", err)
+		}
+		return postInvoiceJournal(txCtx, s.ledgerRepo, s.sequenceRepo, s.expenseRepo, s.taxRuleRepo, *posted, &approverID)
 	})
 
 	if err != nil {
@@ -284,18 +484,6 @@ func (s *invoiceService) updateApproval(ctx context.Context, id string, userID s
 	return toInvoiceResponse(*reloaded), nil
 }
 
-func (s *invoiceService) generateInvoiceNo(ctx context.Context) (string, error) {
-	today := time.Now().Format("20060102")
-	prefix := "INV-" + today + "-"
-
-	count, err := s.invoiceRepo.CountByPrefix(ctx, prefix)
-	if err != nil {
-		return "", err
-	}
-
-	return fmt.Sprintf("%s%05d", prefix, count+1), nil
-}
-
 // --- Helpers ---
 
 // UpdateInvoice allows editing partner hard-copy fields on a PENDING invoice before issuing
@@ -351,12 +539,25 @@ func toInvoiceResponse(inv model.Invoice) InvoiceResponse {
 		TaxAmount:      inv.TaxAmount.StringFixed(4),
 		SideFees:       inv.SideFees.StringFixed(4),
 		TotalAmount:    inv.TotalAmount.StringFixed(4),
+		Currency:       inv.Currency,
+		ExchangeRate:   inv.ExchangeRate.String(),
+		DocSubtotal:    inv.DocSubtotal.StringFixed(4),
+		DocTaxAmount:   inv.DocTaxAmount.StringFixed(4),
+		DocSideFees:    inv.DocSideFees.StringFixed(4),
+		DocTotalAmount: inv.DocTotalAmount.StringFixed(4),
 		ApprovalStatus: inv.ApprovalStatus,
 		Note:           inv.Note,
 		CompanyName:    inv.CompanyName,
 		TaxCode:        inv.TaxCode,
 		BillingAddress: inv.BillingAddress,
 		CreatedAt:      inv.CreatedAt.Format(time.RFC3339),
+
+		InvoiceType:      inv.InvoiceType,
+		AdjustmentReason: inv.AdjustmentReason,
+
+		PaymentStatus: inv.PaymentStatus,
+		PaidAmount:    inv.PaidAmount.StringFixed(4),
+		DocPaidAmount: inv.DocPaidAmount.StringFixed(4),
 	}
 
 	if inv.TaxRuleID != nil {
@@ -380,6 +581,283 @@ func toInvoiceResponse(inv model.Invoice) InvoiceResponse {
 		s := inv.ApprovedAt.Format(time.RFC3339)
 		resp.ApprovedAt = &s
 	}
+	if inv.OriginalInvoiceID != nil {
+		s := inv.OriginalInvoiceID.String()
+		resp.OriginalInvoiceID = &s
+	}
+	if inv.VoidedAt != nil {
+		s := inv.VoidedAt.Format(time.RFC3339)
+		resp.VoidedAt = &s
+	}
+	if inv.ReplacedByID != nil {
+		s := inv.ReplacedByID.String()
+		resp.ReplacedByID = &s
+	}
+	resp.TaxSummary = invoiceTaxSummary(inv)
+
+	return resp
+}
+
+// --- Line & tax helpers ---
+
+// buildOrderInvoiceLines creates one invoice line per order item, taxed with the item's own rule
+// or, when the item has none, the order-level fallback rule. Items without either are taxed with
+// the rule the evaluator picks for taxLine and the item's product category; they are untaxed when
+// taxLine has no tax type. Taxes are rounded in the order currency.
+func buildOrderInvoiceLines(ctx context.Context, taxRuleRepo repository.TaxRuleRepository, items []model.OrderItem, fallbackRuleID *uuid.UUID, taxLine TaxLineContext, currency string) ([]model.InvoiceLine, error) {
+	rules := make(map[uuid.UUID]*model.TaxRule)
+	evaluated := make(map[string]*model.TaxRule) // By product category
+	lines := make([]model.InvoiceLine, 0, len(items))
+
+	for i, item := range items {
+		line := model.InvoiceLine{
+			LineNo:      i + 1,
+			OrderItemID: &item.ID,
+			ProductID:   &item.ProductID,
+			Description: orderItemDescription(item),
+			Quantity:    item.Quantity,
+			UnitPrice:   decimal.NewFromFloat(item.UnitPrice),
+			Discount:    decimal.NewFromFloat(item.Discount),
+			Amount:      item.NetAmount(),
+		}
+
+		ruleID := item.TaxRuleID
+		if ruleID == nil {
+			ruleID = fallbackRuleID
+		}
+		var rule *model.TaxRule
+		switch {
+		case ruleID != nil:
+			var ok bool
+			if rule, ok = rules[*ruleID]; !ok {
+				found, err := taxRuleRepo.FindByID(ctx, *ruleID)
+				if err != nil {
+					return nil, fmt.Errorf("tax rule %s not found: %w", ruleID.String(), err)
+				}
+				rule = found
+				rules[*ruleID] = rule
+			}
+		case taxLine.TaxType != "":
+			category := strings.ToLower(strings.TrimSpace(item.Product.Category))
+			var ok bool
+			if rule, ok = evaluated[category]; !ok {
+				productLine := taxLine
+				productLine.ProductCategory = item.Product.Category
+				found, err := evaluateTax(ctx, taxRuleRepo, productLine)
+				if err != nil {
+					return nil, fmt.Errorf("item %d: %w", i+1, err)
+				}
+				rule = found
+				evaluated[category] = rule
+			}
+		}
+		if rule != nil {
+			line.TaxRuleID = &rule.ID
+			line.TaxType = rule.TaxType
+			line.TaxRate = rule.Rate
+			line.TaxAmount = roundAmount(line.Amount.Mul(rule.Rate), currency)
+		}
+
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
 
+// buildExpenseInvoiceLines turns an expense into one service line carrying its VAT, plus a
+// tax-only line for the foreign contractor tax when the vendor is foreign
+func buildExpenseInvoiceLines(e model.Expense) []model.InvoiceLine {
+	line := model.InvoiceLine{
+		LineNo:      1,
+		Description: e.Description,
+		Quantity:    1,
+		UnitPrice:   e.ConvertedAmount,
+		Amount:      e.ConvertedAmount,
+	}
+	if e.VATAmount.IsPositive() {
+		line.TaxType = model.TaxTypeVATInland
+		if e.IsForeignVendor || e.DocumentType == model.DocTypeCustomsDeclaration {
+			line.TaxType = model.TaxTypeVATIntl
+		}
+		line.TaxRate = e.VATRate
+		line.TaxAmount = e.VATAmount
+	}
+	lines := []model.InvoiceLine{line}
+
+	if e.FCTAmount.IsPositive() {
+		lines = append(lines, model.InvoiceLine{
+			LineNo:      2,
+			Description: "Foreign contractor tax (" + e.FCTType + ")",
+			Quantity:    1,
+			UnitPrice:   decimal.Zero,
+			Amount:      decimal.Zero,
+			TaxType:     model.TaxTypeFCT,
+			TaxRate:     e.FCTRate,
+			TaxAmount:   e.FCTAmount,
+		})
+	}
+	return lines
+}
+
+// convertInvoiceToBase keeps the amounts of an invoice priced in its own currency (lines,
+// subtotal, tax and side fees) as the Doc* amounts and converts the invoice to the base currency
+// at its exchange rate. Lines are converted one by one so the header stays the sum of its lines.
+func convertInvoiceToBase(inv *model.Invoice) {
+	if inv.Currency == "" {
+		inv.Currency, inv.ExchangeRate = baseCurrency, decimal.NewFromInt(1)
+	}
+	inv.DocSubtotal, inv.DocTaxAmount, inv.DocSideFees = inv.Subtotal, inv.TaxAmount, inv.SideFees
+	inv.DocTotalAmount = inv.Subtotal.Add(inv.TaxAmount).Add(inv.SideFees)
+	inv.TotalAmount = inv.DocTotalAmount
+	if inv.Currency == baseCurrency {
+		return
+	}
+
+	rate := inv.ExchangeRate
+	for i := range inv.Lines {
+		l := &inv.Lines[i]
+		l.UnitPrice = roundBase(l.UnitPrice.Mul(rate))
+		l.Discount = roundBase(l.Discount.Mul(rate))
+		l.Amount = roundBase(l.Amount.Mul(rate))
+		l.TaxAmount = roundBase(l.TaxAmount.Mul(rate))
+	}
+	if len(inv.Lines) > 0 {
+		inv.Subtotal, inv.TaxAmount, _ = sumInvoiceLines(inv.Lines)
+	} else {
+		inv.Subtotal = roundBase(inv.Subtotal.Mul(rate))
+		inv.TaxAmount = roundBase(inv.TaxAmount.Mul(rate))
+	}
+	inv.SideFees = roundBase(inv.SideFees.Mul(rate))
+	inv.TotalAmount = inv.Subtotal.Add(inv.TaxAmount).Add(inv.SideFees)
+}
+
+func orderItemDescription(item model.OrderItem) string {
+	if item.Product.ID == uuid.Nil {
+		return ""
+	}
+	return item.Product.SKU + " - " + item.Product.Name
+}
+
+// sumInvoiceLines returns the subtotal and tax of the lines, plus the tax rule they all share
+// (nil when the lines use different rules or none)
+func sumInvoiceLines(lines []model.InvoiceLine) (decimal.Decimal, decimal.Decimal, *uuid.UUID) {
+	subtotal, tax := decimal.Zero, decimal.Zero
+	var shared *uuid.UUID
+	for i, l := range lines {
+		subtotal = subtotal.Add(l.Amount)
+		tax = tax.Add(l.TaxAmount)
+		switch {
+		case i == 0:
+			shared = l.TaxRuleID
+		case shared == nil || l.TaxRuleID == nil || *shared != *l.TaxRuleID:
+			shared = nil
+		}
+	}
+	return subtotal, tax, shared
+}
+
+// invoiceTaxSummary groups invoice lines by tax type and rate. Invoices without lines (manual
+// invoices and those created before lines were stored) are summarised from the header.
+func invoiceTaxSummary(inv model.Invoice) []TaxSummaryResponse {
+	type group struct {
+		taxType string
+		rate    *decimal.Decimal
+		taxable decimal.Decimal
+		tax     decimal.Decimal
+	}
+	var groups []*group
+	byKey := make(map[string]*group)
+
+	add := func(taxType string, rate *decimal.Decimal, taxable, tax decimal.Decimal) {
+		key := "NONE"
+		if rate != nil {
+			key = taxType + "|" + rate.StringFixed(4)
+		}
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{taxType: taxType, rate: rate}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.taxable = g.taxable.Add(taxable)
+		g.tax = g.tax.Add(tax)
+	}
+
+	if len(inv.Lines) == 0 {
+		var rate *decimal.Decimal
+		taxType := ""
+		if inv.TaxRule != nil {
+			rate = &inv.TaxRule.Rate
+			taxType = inv.TaxRule.TaxType
+		} else if !inv.TaxAmount.IsZero() && inv.Subtotal.IsPositive() {
+			effective := inv.TaxAmount.Div(inv.Subtotal).Round(4)
+			rate = &effective
+		}
+		add(taxType, rate, inv.Subtotal, inv.TaxAmount)
+	}
+	for _, l := range inv.Lines {
+		var rate *decimal.Decimal
+		if l.TaxType != "" {
+			r := l.TaxRate
+			rate = &r
+		}
+		add(l.TaxType, rate, l.Amount, l.TaxAmount)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].rate == nil || groups[j].rate == nil {
+			return groups[j].rate == nil && groups[i].rate != nil
+		}
+		if groups[i].taxType != groups[j].taxType {
+			return groups[i].taxType < groups[j].taxType
+		}
+		return groups[i].rate.LessThan(*groups[j].rate)
+	})
+
+	res := make([]TaxSummaryResponse, 0, len(groups))
+	for _, g := range groups {
+		item := TaxSummaryResponse{
+			TaxType:       g.taxType,
+			TaxableAmount: g.taxable.StringFixed(4),
+			TaxAmount:     g.tax.StringFixed(4),
+		}
+		if g.rate != nil {
+			r := g.rate.StringFixed(4)
+			item.TaxRate = &r
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+func toInvoiceLineResponse(l model.InvoiceLine) InvoiceLineResponse {
+	resp := InvoiceLineResponse{
+		ID:          l.ID.String(),
+		LineNo:      l.LineNo,
+		Description: l.Description,
+		Quantity:    l.Quantity,
+		UnitPrice:   l.UnitPrice.StringFixed(4),
+		Discount:    l.Discount.StringFixed(4),
+		Amount:      l.Amount.StringFixed(4),
+		TaxType:     l.TaxType,
+		TaxRate:     l.TaxRate.StringFixed(4),
+		TaxAmount:   l.TaxAmount.StringFixed(4),
+		Total:       l.Amount.Add(l.TaxAmount).StringFixed(4),
+	}
+	if l.OrderItemID != nil {
+		s := l.OrderItemID.String()
+		resp.OrderItemID = &s
+	}
+	if l.ProductID != nil {
+		s := l.ProductID.String()
+		resp.ProductID = &s
+	}
+	if l.Product != nil {
+		resp.ProductSKU = l.Product.SKU
+		resp.ProductName = l.Product.Name
+	}
+	if l.TaxRuleID != nil {
+		s := l.TaxRuleID.String()
+		resp.TaxRuleID = &s
+	}
 	return resp
 }
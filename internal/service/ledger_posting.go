@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// postingLine is one side of a journal entry being built: positive amounts are debits,
+// negative amounts credits, so negative documents such as credit notes flip sides naturally
+type postingLine struct {
+	AccountCode string
+	PartnerID   *uuid.UUID
+	Amount      decimal.Decimal
+	Description string
+}
+
+func debitLine(code string, partnerID *uuid.UUID, amount decimal.Decimal) postingLine {
+	return postingLine{AccountCode: code, PartnerID: partnerID, Amount: amount}
+}
+
+func creditLine(code string, partnerID *uuid.UUID, amount decimal.Decimal) postingLine {
+	return postingLine{AccountCode: code, PartnerID: partnerID, Amount: amount.Neg()}
+}
+
+// postJournalEntry validates and saves an entry built from posting lines. Zero lines are
+// dropped and an entry without any amount is skipped (nil entry); otherwise debits must equal
+// credits and every account must exist, be active and have no sub-accounts.
+func postJournalEntry(ctx context.Context, ledgerRepo repository.LedgerRepository, sequenceRepo repository.DocumentSequenceRepository, entry *model.JournalEntry, lines []postingLine) (*model.JournalEntry, error) {
+	balance := decimal.Zero
+	total := decimal.Zero
+	codes := make([]string, 0, len(lines))
+	seen := make(map[string]bool, len(lines))
+	entry.Lines = make([]model.JournalLine, 0, len(lines))
+	for _, l := range lines {
+		amount := roundBase(l.Amount)
+		if amount.IsZero() {
+			continue
+		}
+		line := model.JournalLine{
+			LineNo:      len(entry.Lines) + 1,
+			AccountCode: l.AccountCode,
+			PartnerID:   l.PartnerID,
+			Debit:       decimal.Zero,
+			Credit:      decimal.Zero,
+			Description: l.Description,
+		}
+		if amount.IsPositive() {
+			line.Debit = amount
+			total = total.Add(amount)
+		} else {
+			line.Credit = amount.Neg()
+		}
+		balance = balance.Add(amount)
+		entry.Lines = append(entry.Lines, line)
+		if !seen[l.AccountCode] {
+			seen[l.AccountCode] = true
+			codes = append(codes, l.AccountCode)
+		}
+	}
+	if len(entry.Lines) == 0 {
+		return nil, nil
+	}
+	if !balance.IsZero() {
+		return nil, fmt.Errorf("journal entry for %s %s is unbalanced by %s", entry.SourceType, entry.SourceNo, balance.StringFixed(4))
+	}
+
+	accounts, err := ledgerRepo.FindAccountsByCodes(ctx, codes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+	byCode := make(map[string]model.Account, len(accounts))
+	for _, a := range accounts {
+		byCode[a.Code] = a
+	}
+	for _, code := range codes {
+		account, ok := byCode[code]
+		if !ok {
+			return nil, fmt.Errorf("account %s does not exist in the chart of accounts", code)
+		}
+		if !account.IsActive {
+			return nil, fmt.Errorf("account %s is inactive", code)
+		}
+		children, err := ledgerRepo.CountChildAccounts(ctx, code)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check sub-accounts of %s: %w", code, err)
+		}
+		if children > 0 {
+			return nil, fmt.Errorf("account %s has sub-accounts and cannot take postings", code)
+		}
+	}
+
+	entryNo, err := nextDocumentNo(ctx, sequenceRepo, model.SequenceJournal, entry.EntryDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate journal entry number: %w", err)
+	}
+	entry.EntryNo = entryNo
+	entry.TotalAmount = total
+
+	if err := ledgerRepo.CreateEntry(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to create journal entry: %w", err)
+	}
+	return entry, nil
+}
+
+// invoiceTaxSplit separates an invoice's tax by where it is posted, using the category of each
+// line's tax type. Invoices without lines (manual invoices) treat their whole tax as VAT.
+func invoiceTaxSplit(inv model.Invoice, categories map[string]string) (vat, duty, other decimal.Decimal) {
+	vat, duty, other = decimal.Zero, decimal.Zero, decimal.Zero
+	if len(inv.Lines) == 0 {
+		return inv.TaxAmount, duty, other
+	}
+	for _, l := range inv.Lines {
+		switch categories[l.TaxType] {
+		case model.TaxCategoryVAT:
+			vat = vat.Add(l.TaxAmount)
+		case model.TaxCategoryImportDuty:
+			duty = duty.Add(l.TaxAmount)
+		default:
+			other = other.Add(l.TaxAmount)
+		}
+	}
+	return vat, duty, other
+}
+
+var invoiceTypeLabels = map[string]string{
+	model.InvoiceTypeStandard:    "Invoice",
+	model.InvoiceTypeCreditNote:  "Credit note",
+	model.InvoiceTypeDebitNote:   "Debit note",
+	model.InvoiceTypeReplacement: "Replacement invoice",
+}
+
+// postInvoiceJournal posts an approved invoice, note or replacement (its lines must be loaded):
+//
+//	ORDER_EXPORT: Dr 131 total / Cr 5111 goods, 5113 side fees, 33311 VAT, 3333 duty, 3338 other tax
+//	ORDER_IMPORT: Dr 1561 goods + non-deductible tax, 1562 side fees, 1331 VAT / Cr 331 total
+//	EXPENSE:      Dr 1562 (import order costs), 6418 (export order costs) or 6428 net +
+//	              non-deductible tax, 1331 VAT / Cr 331 total
+//
+// Amounts are rounded in the base currency and the goods amount is derived from the total, so
+// rounding never unbalances the entry.
+func postInvoiceJournal(ctx context.Context, ledgerRepo repository.LedgerRepository, sequenceRepo repository.DocumentSequenceRepository, expenseRepo repository.ExpenseRepository, taxRuleRepo repository.TaxRuleRepository, inv model.Invoice, userID *uuid.UUID) error {
+	categories, err := taxTypeCategories(ctx, taxRuleRepo)
+	if err != nil {
+		return err
+	}
+	vat, duty, other := invoiceTaxSplit(inv, categories)
+	vat, duty, other = roundBase(vat), roundBase(duty), roundBase(other)
+	total, sideFees := roundBase(inv.TotalAmount), roundBase(inv.SideFees)
+	goods := total.Sub(vat).Sub(duty).Sub(other).Sub(sideFees)
+	partner := inv.PartnerID
+
+	var lines []postingLine
+	switch inv.ReferenceType {
+	case model.RefTypeOrderExport:
+		lines = []postingLine{
+			debitLine(model.AccountReceivable, partner, total),
+			creditLine(model.AccountSalesRevenue, nil, goods),
+			creditLine(model.AccountServiceRevenue, nil, sideFees),
+			creditLine(model.AccountOutputVAT, nil, vat),
+			creditLine(model.AccountImportExportTax, nil, duty),
+			creditLine(model.AccountContractorTax, nil, other),
+		}
+	case model.RefTypeOrderImport:
+		lines = []postingLine{
+			debitLine(model.AccountGoodsPurchase, nil, goods.Add(duty).Add(other)),
+			debitLine(model.AccountGoodsPurchaseFee, nil, sideFees),
+			debitLine(model.AccountInputVAT, nil, vat),
+			creditLine(model.AccountPayable, partner, total),
+		}
+	case model.RefTypeExpense:
+		orderType, err := expenseRepo.FindOrderType(ctx, inv.ReferenceID)
+		if err != nil {
+			return fmt.Errorf("failed to find expense %s: %w", inv.ReferenceID, err)
+		}
+		expenseAccount := expenseAccountFor(orderType)
+		lines = []postingLine{
+			debitLine(expenseAccount, nil, goods.Add(duty).Add(other).Add(sideFees)),
+			debitLine(model.AccountInputVAT, nil, vat),
+			creditLine(model.AccountPayable, partner, total),
+		}
+	default:
+		return fmt.Errorf("invoice %s has unknown reference type %s", inv.InvoiceNo, inv.ReferenceType)
+	}
+
+	entryDate := time.Now()
+	if inv.ApprovedAt != nil {
+		entryDate = *inv.ApprovedAt
+	}
+	description := invoiceTypeLabels[inv.InvoiceType] + " " + inv.InvoiceNo
+	if inv.CompanyName != "" {
+		description += " - " + inv.CompanyName
+	}
+
+	_, err = postJournalEntry(ctx, ledgerRepo, sequenceRepo, &model.JournalEntry{
+		EntryDate:   entryDate,
+		SourceType:  model.JournalSourceInvoice,
+		SourceID:    inv.ID,
+		SourceNo:    inv.InvoiceNo,
+		Description: description,
+		CreatedBy:   userID,
+	}, lines)
+	return err
+}
+
+// expenseAccountFor returns the account an expense is debited to: only costs of bringing
+// imported goods in are capitalised in 1562 and later relieved through landed cost
+func expenseAccountFor(orderType string) string {
+	switch orderType {
+	case model.OrderTypeImport:
+		return model.AccountGoodsPurchaseFee
+	case model.OrderTypeExport:
+		return model.AccountSellingExpense
+	default:
+		return model.AccountAdminExpense
+	}
+}
+
+// postPaymentJournal posts a receipt (Dr cash/bank / Cr 131) or a disbursement (Dr 331 /
+// Cr cash/bank for the net paid, Cr 3338 for the FCT withheld), in the base currency
+func postPaymentJournal(ctx context.Context, ledgerRepo repository.LedgerRepository, sequenceRepo repository.DocumentSequenceRepository, payment model.Payment, userID *uuid.UUID) error {
+	moneyAccount := model.AccountBank
+	if payment.Method == model.PaymentMethodCash {
+		moneyAccount = model.AccountCash
+	}
+	partner := &payment.PartnerID
+	amountBase := roundBase(payment.AmountBase)
+
+	var lines []postingLine
+	description := "Receipt " + payment.PaymentNo
+	if payment.PaymentType == model.PaymentTypeReceipt {
+		lines = []postingLine{
+			debitLine(moneyAccount, nil, amountBase),
+			creditLine(model.AccountReceivable, partner, amountBase),
+		}
+	} else {
+		description = "Disbursement " + payment.PaymentNo
+		withheldBase := decimal.Zero
+		if payment.WithheldAmount.IsPositive() && payment.Amount.IsPositive() {
+			withheldBase = roundBase(payment.WithheldAmount.Mul(amountBase).Div(payment.Amount))
+		}
+		lines = []postingLine{
+			debitLine(model.AccountPayable, partner, amountBase),
+			creditLine(moneyAccount, nil, amountBase.Sub(withheldBase)),
+			creditLine(model.AccountContractorTax, nil, withheldBase),
+		}
+	}
+	if payment.Reference != "" {
+		description += " (" + payment.Reference + ")"
+	}
+
+	_, err := postJournalEntry(ctx, ledgerRepo, sequenceRepo, &model.JournalEntry{
+		EntryDate:   payment.PaymentDate,
+		SourceType:  model.JournalSourcePayment,
+		SourceID:    payment.ID,
+		SourceNo:    payment.PaymentNo,
+		Description: description,
+		CreatedBy:   userID,
+	}, lines)
+	return err
+}
+
+// postExchangeDifferenceJournal posts the net exchange gain (positive) or loss realized when a
+// payment settles invoices booked at another rate, against the partner's receivable or payable:
+// gains Dr 131/331 / Cr 515, losses Dr 635 / Cr 131/331
+func postExchangeDifferenceJournal(ctx context.Context, ledgerRepo repository.LedgerRepository, sequenceRepo repository.DocumentSequenceRepository, payment model.Payment, fx decimal.Decimal, at time.Time, userID *uuid.UUID) error {
+	fx = roundBase(fx)
+	if fx.IsZero() {
+		return nil
+	}
+	partnerAccount := model.AccountReceivable
+	if payment.PaymentType == model.PaymentTypeDisbursement {
+		partnerAccount = model.AccountPayable
+	}
+	partner := &payment.PartnerID
+
+	lines := []postingLine{
+		debitLine(partnerAccount, partner, fx),
+		creditLine(model.AccountFinancialIncome, nil, fx),
+	}
+	description := "Exchange gain " + payment.PaymentNo
+	if fx.IsNegative() {
+		loss := fx.Neg()
+		lines = []postingLine{
+			debitLine(model.AccountFinancialExpense, nil, loss),
+			creditLine(partnerAccount, partner, loss),
+		}
+		description = "Exchange loss " + payment.PaymentNo
+	}
+
+	_, err := postJournalEntry(ctx, ledgerRepo, sequenceRepo, &model.JournalEntry{
+		EntryDate:   at,
+		SourceType:  model.JournalSourcePayment,
+		SourceID:    payment.ID,
+		SourceNo:    payment.PaymentNo,
+		Description: description,
+		CreatedBy:   userID,
+	}, lines)
+	return err
+}
+
+// postCostOfSalesJournal moves the FIFO cost of an approved export order out of inventory:
+// Dr 632 / Cr 1561 purchase price, Cr 1562 the landed cost allocated to the layers, which was
+// debited to 1562 with the freight, duty and order expenses
+func postCostOfSalesJournal(ctx context.Context, ledgerRepo repository.LedgerRepository, sequenceRepo repository.DocumentSequenceRepository, order model.Order, cost, landed decimal.Decimal, at time.Time, userID *uuid.UUID) error {
+	cost, landed = roundBase(cost), roundBase(landed)
+	_, err := postJournalEntry(ctx, ledgerRepo, sequenceRepo, &model.JournalEntry{
+		EntryDate:   at,
+		SourceType:  model.JournalSourceOrder,
+		SourceID:    order.ID,
+		SourceNo:    order.OrderCode,
+		Description: "Cost of goods sold " + order.OrderCode,
+		CreatedBy:   userID,
+	}, []postingLine{
+		debitLine(model.AccountCostOfGoodsSold, nil, cost),
+		creditLine(model.AccountGoodsPurchase, nil, cost.Sub(landed)),
+		creditLine(model.AccountGoodsPurchaseFee, nil, landed),
+	})
+	return err
+}
+
+// reverseJournalEntries cancels the open entries of the given sources with entries that swap
+// their debits and credits, e.g. when a replacement voids an invoice and its notes
+func reverseJournalEntries(ctx context.Context, ledgerRepo repository.LedgerRepository, sequenceRepo repository.DocumentSequenceRepository, sourceType string, sourceIDs []uuid.UUID, at time.Time, userID *uuid.UUID, reason string) error {
+	entries, err := ledgerRepo.ListOpenEntriesBySource(ctx, sourceType, sourceIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch journal entries: %w", err)
+	}
+
+	for _, original := range entries {
+		lines := make([]postingLine, 0, len(original.Lines))
+		for _, l := range original.Lines {
+			lines = append(lines, postingLine{
+				AccountCode: l.AccountCode,
+				PartnerID:   l.PartnerID,
+				Amount:      l.Credit.Sub(l.Debit),
+				Description: l.Description,
+			})
+		}
+
+		originalID := original.ID
+		reversal, err := postJournalEntry(ctx, ledgerRepo, sequenceRepo, &model.JournalEntry{
+			EntryDate:    at,
+			SourceType:   original.SourceType,
+			SourceID:     original.SourceID,
+			SourceNo:     original.SourceNo,
+			Description:  "Reversal of " + original.EntryNo + ": " + reason,
+			ReversalOfID: &originalID,
+			CreatedBy:    userID,
+		}, lines)
+		if err != nil {
+			return err
+		}
+		if reversal == nil {
+			continue
+		}
+		if err := ledgerRepo.MarkEntryReversed(ctx, original.ID, reversal.ID); err != nil {
+			return fmt.Errorf("failed to mark journal entry %s reversed: %w", original.EntryNo, err)
+		}
+	}
+	return nil
+}
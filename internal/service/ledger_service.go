@@ -0,0 +1,929 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// --- DTOs ---
+
+type AccountResponse struct {
+	ID            string  `json:"id"`
+	Code          string  `json:"code"`
+	Name          string  `json:"name"`
+	AccountType   string  `json:"account_type"`
+	NormalBalance string  `json:"normal_balance"`
+	ParentCode    *string `json:"parent_code"`
+	IsSystem      bool    `json:"is_system"`
+	IsActive      bool    `json:"is_active"`
+}
+
+// CreateAccountRequest adds a company sub-account under an account of the chart. The code must
+// extend the parent's code (e.g. 1121VCB under 1121); type and normal balance are inherited.
+type CreateAccountRequest struct {
+	Code       string `json:"code" binding:"required,max=20"`
+	Name       string `json:"name" binding:"required,max=255"`
+	ParentCode string `json:"parent_code" binding:"required"`
+}
+
+type UpdateAccountRequest struct {
+	Name     *string `json:"name" binding:"omitempty,max=255"`
+	IsActive *bool   `json:"is_active"`
+}
+
+type JournalLineResponse struct {
+	LineNo      int     `json:"line_no"`
+	AccountCode string  `json:"account_code"`
+	PartnerID   *string `json:"partner_id"`
+	Debit       string  `json:"debit"`
+	Credit      string  `json:"credit"`
+	Description string  `json:"description"`
+}
+
+type JournalEntryResponse struct {
+	ID           string                `json:"id"`
+	EntryNo      string                `json:"entry_no"`
+	EntryDate    string                `json:"entry_date"`
+	SourceType   string                `json:"source_type"`
+	SourceID     string                `json:"source_id"`
+	SourceNo     string                `json:"source_no"`
+	Description  string                `json:"description"`
+	TotalAmount  string                `json:"total_amount"`
+	ReversalOfID *string               `json:"reversal_of_id"`
+	ReversedByID *string               `json:"reversed_by_id"`
+	CreatedBy    *string               `json:"created_by"`
+	CreatedAt    string                `json:"created_at"`
+	Lines        []JournalLineResponse `json:"lines"`
+}
+
+type JournalEntryFilter struct {
+	SourceType  string
+	SourceID    string
+	AccountCode string
+	DateFrom    string // YYYY-MM-DD
+	DateTo      string // YYYY-MM-DD, inclusive
+	Page        int
+	Limit       int
+}
+
+// LedgerPeriodFilter selects a reporting period; it defaults to the current year to date
+type LedgerPeriodFilter struct {
+	DateFrom  string // YYYY-MM-DD
+	DateTo    string // YYYY-MM-DD, inclusive
+	PartnerID string // General ledger only
+}
+
+// LedgerBalance is a balance split into debit and credit columns as on VAS reports: the net
+// amount appears on its own side and the other side is zero
+type LedgerBalance struct {
+	Debit  string `json:"debit"`
+	Credit string `json:"credit"`
+}
+
+type TrialBalanceRow struct {
+	Code         string        `json:"code"`
+	Name         string        `json:"name"`
+	AccountType  string        `json:"account_type"`
+	ParentCode   *string       `json:"parent_code"`
+	Level        int           `json:"level"` // 1 for accounts without a parent
+	Opening      LedgerBalance `json:"opening"`
+	PeriodDebit  string        `json:"period_debit"`
+	PeriodCredit string        `json:"period_credit"`
+	Closing      LedgerBalance `json:"closing"`
+}
+
+// TrialBalanceResponse lists every account with a balance or movement, parents rolled up from
+// their sub-accounts. Totals are the sums of the level-1 accounts; debits equal credits in
+// every column when the books balance.
+type TrialBalanceResponse struct {
+	DateFrom string            `json:"date_from"`
+	DateTo   string            `json:"date_to"`
+	Accounts []TrialBalanceRow `json:"accounts"`
+	Totals   TrialBalanceRow   `json:"totals"`
+	Balanced bool              `json:"balanced"`
+}
+
+type LedgerLineResponse struct {
+	EntryID        string   `json:"entry_id"`
+	EntryNo        string   `json:"entry_no"`
+	EntryDate      string   `json:"entry_date"`
+	SourceType     string   `json:"source_type"`
+	SourceNo       string   `json:"source_no"`
+	Description    string   `json:"description"`
+	AccountCode    string   `json:"account_code"`
+	PartnerID      *string  `json:"partner_id"`
+	PartnerName    string   `json:"partner_name"`
+	ContraAccounts []string `json:"contra_accounts"`
+	Debit          string   `json:"debit"`
+	Credit         string   `json:"credit"`
+	Balance        string   `json:"balance"` // Running balance, positive on the account's normal side
+}
+
+// GeneralLedgerResponse is the ledger (sổ cái) of an account and its sub-accounts for a period
+type GeneralLedgerResponse struct {
+	Account      AccountResponse      `json:"account"`
+	PartnerID    *string              `json:"partner_id"`
+	DateFrom     string               `json:"date_from"`
+	DateTo       string               `json:"date_to"`
+	Opening      LedgerBalance        `json:"opening"`
+	Lines        []LedgerLineResponse `json:"lines"`
+	PeriodDebit  string               `json:"period_debit"`
+	PeriodCredit string               `json:"period_credit"`
+	Closing      LedgerBalance        `json:"closing"`
+}
+
+type AccountPartnerBalance struct {
+	PartnerID    *string       `json:"partner_id"` // Nil for postings without a partner
+	PartnerName  string        `json:"partner_name"`
+	Opening      LedgerBalance `json:"opening"`
+	PeriodDebit  string        `json:"period_debit"`
+	PeriodCredit string        `json:"period_credit"`
+	Closing      LedgerBalance `json:"closing"`
+}
+
+// AccountDetailResponse breaks an account's balance down by partner (sổ chi tiết), e.g. the
+// receivable of each customer on 131
+type AccountDetailResponse struct {
+	Account      AccountResponse         `json:"account"`
+	DateFrom     string                  `json:"date_from"`
+	DateTo       string                  `json:"date_to"`
+	SubAccounts  []TrialBalanceRow       `json:"sub_accounts,omitempty"`
+	Partners     []AccountPartnerBalance `json:"partners"`
+	Opening      LedgerBalance           `json:"opening"`
+	PeriodDebit  string                  `json:"period_debit"`
+	PeriodCredit string                  `json:"period_credit"`
+	Closing      LedgerBalance           `json:"closing"`
+}
+
+// --- Interface ---
+
+type LedgerService interface {
+	SeedDefaultAccounts(ctx context.Context) error
+	ListAccounts(ctx context.Context) ([]AccountResponse, error)
+	CreateAccount(ctx context.Context, userID string, req CreateAccountRequest) (AccountResponse, error)
+	UpdateAccount(ctx context.Context, code string, userID string, req UpdateAccountRequest) (AccountResponse, error)
+	ListJournalEntries(ctx context.Context, filter JournalEntryFilter) ([]JournalEntryResponse, int64, error)
+	GetJournalEntry(ctx context.Context, id string) (JournalEntryResponse, error)
+	GetTrialBalance(ctx context.Context, filter LedgerPeriodFilter) (TrialBalanceResponse, error)
+	GetGeneralLedger(ctx context.Context, code string, filter LedgerPeriodFilter) (GeneralLedgerResponse, error)
+	GetAccountDetail(ctx context.Context, code string, filter LedgerPeriodFilter) (AccountDetailResponse, error)
+}
+
+type ledgerService struct {
+	ledgerRepo repository.LedgerRepository
+	auditRepo  repository.AuditRepository
+	txManager  repository.TransactionManager
+}
+
+func NewLedgerService(
+	ledgerRepo repository.LedgerRepository,
+	auditRepo repository.AuditRepository,
+	txManager repository.TransactionManager,
+) LedgerService {
+	return &ledgerService{
+		ledgerRepo: ledgerRepo,
+		auditRepo:  auditRepo,
+		txManager:  txManager,
+	}
+}
+
+// defaultAccount is one account of the seeded chart; children follow their parent
+type defaultAccount struct {
+	Code, Name, Type, Normal, Parent string
+}
+
+// defaultChartOfAccounts is the VAS chart of accounts (Circular 200/2014/TT-BTC), with the
+// level-2 and level-3 accounts a trading company uses
+var defaultChartOfAccounts = []defaultAccount{
+	{"111", "Tiền mặt", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"1111", "Tiền Việt Nam", model.AccountTypeAsset, model.BalanceDebit, "111"},
+	{"1112", "Ngoại tệ", model.AccountTypeAsset, model.BalanceDebit, "111"},
+	{"1113", "Vàng tiền tệ", model.AccountTypeAsset, model.BalanceDebit, "111"},
+	{"112", "Tiền gửi Ngân hàng", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"1121", "Tiền Việt Nam", model.AccountTypeAsset, model.BalanceDebit, "112"},
+	{"1122", "Ngoại tệ", model.AccountTypeAsset, model.BalanceDebit, "112"},
+	{"1123", "Vàng tiền tệ", model.AccountTypeAsset, model.BalanceDebit, "112"},
+	{"113", "Tiền đang chuyển", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"121", "Chứng khoán kinh doanh", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"128", "Đầu tư nắm giữ đến ngày đáo hạn", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"131", "Phải thu của khách hàng", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"133", "Thuế GTGT được khấu trừ", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"1331", "Thuế GTGT được khấu trừ của hàng hóa, dịch vụ", model.AccountTypeAsset, model.BalanceDebit, "133"},
+	{"1332", "Thuế GTGT được khấu trừ của TSCĐ", model.AccountTypeAsset, model.BalanceDebit, "133"},
+	{"136", "Phải thu nội bộ", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"138", "Phải thu khác", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"1381", "Tài sản thiếu chờ xử lý", model.AccountTypeAsset, model.BalanceDebit, "138"},
+	{"1388", "Phải thu khác", model.AccountTypeAsset, model.BalanceDebit, "138"},
+	{"141", "Tạm ứng", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"151", "Hàng mua đang đi đường", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"152", "Nguyên liệu, vật liệu", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"153", "Công cụ, dụng cụ", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"154", "Chi phí sản xuất, kinh doanh dở dang", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"155", "Thành phẩm", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"156", "Hàng hóa", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"1561", "Giá mua hàng hóa", model.AccountTypeAsset, model.BalanceDebit, "156"},
+	{"1562", "Chi phí thu mua hàng hóa", model.AccountTypeAsset, model.BalanceDebit, "156"},
+	{"1567", "Hàng hóa bất động sản", model.AccountTypeAsset, model.BalanceDebit, "156"},
+	{"157", "Hàng gửi đi bán", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"211", "Tài sản cố định hữu hình", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"213", "Tài sản cố định vô hình", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"214", "Hao mòn tài sản cố định", model.AccountTypeAsset, model.BalanceCredit, ""},
+	{"229", "Dự phòng tổn thất tài sản", model.AccountTypeAsset, model.BalanceCredit, ""},
+	{"241", "Xây dựng cơ bản dở dang", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"242", "Chi phí trả trước", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"244", "Cầm cố, thế chấp, ký quỹ, ký cược", model.AccountTypeAsset, model.BalanceDebit, ""},
+	{"331", "Phải trả cho người bán", model.AccountTypeLiability, model.BalanceCredit, ""},
+	{"333", "Thuế và các khoản phải nộp Nhà nước", model.AccountTypeLiability, model.BalanceCredit, ""},
+	{"3331", "Thuế giá trị gia tăng phải nộp", model.AccountTypeLiability, model.BalanceCredit, "333"},
+	{"33311", "Thuế GTGT đầu ra", model.AccountTypeLiability, model.BalanceCredit, "3331"},
+	{"33312", "Thuế GTGT hàng nhập khẩu", model.AccountTypeLiability, model.BalanceCredit, "3331"},
+	{"3332", "Thuế tiêu thụ đặc biệt", model.AccountTypeLiability, model.BalanceCredit, "333"},
+	{"3333", "Thuế xuất, nhập khẩu", model.AccountTypeLiability, model.BalanceCredit, "333"},
+	{"3334", "Thuế thu nhập doanh nghiệp", model.AccountTypeLiability, model.BalanceCredit, "333"},
+	{"3335", "Thuế thu nhập cá nhân", model.AccountTypeLiability, model.BalanceCredit, "333"},
+	{"3336", "Thuế tài nguyên", model.AccountTypeLiability, model.BalanceCredit, "333"},
+	{"3337", "Thuế nhà đất, tiền thuê đất", model.AccountTypeLiability, model.BalanceCredit, "333"},
+	{"3338", "Thuế bảo vệ môi trường và các loại thuế khác", model.AccountTypeLiability, model.BalanceCredit, "333"},
+	{"3339", "Phí, lệ phí và các khoản phải nộp khác", model.AccountTypeLiability, model.BalanceCredit, "333"},
+	{"334", "Phải trả người lao động", model.AccountTypeLiability, model.BalanceCredit, ""},
+	{"335", "Chi phí phải trả", model.AccountTypeLiability, model.BalanceCredit, ""},
+	{"336", "Phải trả nội bộ", model.AccountTypeLiability, model.BalanceCredit, ""},
+	{"338", "Phải trả, phải nộp khác", model.AccountTypeLiability, model.BalanceCredit, ""},
+	{"3383", "Bảo hiểm xã hội", model.AccountTypeLiability, model.BalanceCredit, "338"},
+	{"3384", "Bảo hiểm y tế", model.AccountTypeLiability, model.BalanceCredit, "338"},
+	{"3386", "Bảo hiểm thất nghiệp", model.AccountTypeLiability, model.BalanceCredit, "338"},
+	{"3387", "Doanh thu chưa thực hiện", model.AccountTypeLiability, model.BalanceCredit, "338"},
+	{"3388", "Phải trả, phải nộp khác", model.AccountTypeLiability, model.BalanceCredit, "338"},
+	{"341", "Vay và nợ thuê tài chính", model.AccountTypeLiability, model.BalanceCredit, ""},
+	{"352", "Dự phòng phải trả", model.AccountTypeLiability, model.BalanceCredit, ""},
+	{"353", "Quỹ khen thưởng, phúc lợi", model.AccountTypeLiability, model.BalanceCredit, ""},
+	{"411", "Vốn đầu tư của chủ sở hữu", model.AccountTypeEquity, model.BalanceCredit, ""},
+	{"4111", "Vốn góp của chủ sở hữu", model.AccountTypeEquity, model.BalanceCredit, "411"},
+	{"4112", "Thặng dư vốn cổ phần", model.AccountTypeEquity, model.BalanceCredit, "411"},
+	{"4118", "Vốn khác", model.AccountTypeEquity, model.BalanceCredit, "411"},
+	{"413", "Chênh lệch tỷ giá hối đoái", model.AccountTypeEquity, model.BalanceCredit, ""},
+	{"414", "Quỹ đầu tư phát triển", model.AccountTypeEquity, model.BalanceCredit, ""},
+	{"418", "Các quỹ khác thuộc vốn chủ sở hữu", model.AccountTypeEquity, model.BalanceCredit, ""},
+	{"421", "Lợi nhuận sau thuế chưa phân phối", model.AccountTypeEquity, model.BalanceCredit, ""},
+	{"4211", "Lợi nhuận sau thuế chưa phân phối năm trước", model.AccountTypeEquity, model.BalanceCredit, "421"},
+	{"4212", "Lợi nhuận sau thuế chưa phân phối năm nay", model.AccountTypeEquity, model.BalanceCredit, "421"},
+	{"511", "Doanh thu bán hàng và cung cấp dịch vụ", model.AccountTypeRevenue, model.BalanceCredit, ""},
+	{"5111", "Doanh thu bán hàng hóa", model.AccountTypeRevenue, model.BalanceCredit, "511"},
+	{"5112", "Doanh thu bán các thành phẩm", model.AccountTypeRevenue, model.BalanceCredit, "511"},
+	{"5113", "Doanh thu cung cấp dịch vụ", model.AccountTypeRevenue, model.BalanceCredit, "511"},
+	{"5118", "Doanh thu khác", model.AccountTypeRevenue, model.BalanceCredit, "511"},
+	{"515", "Doanh thu hoạt động tài chính", model.AccountTypeRevenue, model.BalanceCredit, ""},
+	{"521", "Các khoản giảm trừ doanh thu", model.AccountTypeRevenue, model.BalanceDebit, ""},
+	{"5211", "Chiết khấu thương mại", model.AccountTypeRevenue, model.BalanceDebit, "521"},
+	{"5212", "Hàng bán bị trả lại", model.AccountTypeRevenue, model.BalanceDebit, "521"},
+	{"5213", "Giảm giá hàng bán", model.AccountTypeRevenue, model.BalanceDebit, "521"},
+	{"632", "Giá vốn hàng bán", model.AccountTypeExpense, model.BalanceDebit, ""},
+	{"635", "Chi phí tài chính", model.AccountTypeExpense, model.BalanceDebit, ""},
+	{"641", "Chi phí bán hàng", model.AccountTypeExpense, model.BalanceDebit, ""},
+	{"6411", "Chi phí nhân viên", model.AccountTypeExpense, model.BalanceDebit, "641"},
+	{"6417", "Chi phí dịch vụ mua ngoài", model.AccountTypeExpense, model.BalanceDebit, "641"},
+	{"6418", "Chi phí bằng tiền khác", model.AccountTypeExpense, model.BalanceDebit, "641"},
+	{"642", "Chi phí quản lý doanh nghiệp", model.AccountTypeExpense, model.BalanceDebit, ""},
+	{"6421", "Chi phí nhân viên quản lý", model.AccountTypeExpense, model.BalanceDebit, "642"},
+	{"6422", "Chi phí vật liệu quản lý", model.AccountTypeExpense, model.BalanceDebit, "642"},
+	{"6423", "Chi phí đồ dùng văn phòng", model.AccountTypeExpense, model.BalanceDebit, "642"},
+	{"6424", "Chi phí khấu hao TSCĐ", model.AccountTypeExpense, model.BalanceDebit, "642"},
+	{"6425", "Thuế, phí và lệ phí", model.AccountTypeExpense, model.BalanceDebit, "642"},
+	{"6426", "Chi phí dự phòng", model.AccountTypeExpense, model.BalanceDebit, "642"},
+	{"6427", "Chi phí dịch vụ mua ngoài", model.AccountTypeExpense, model.BalanceDebit, "642"},
+	{"6428", "Chi phí bằng tiền khác", model.AccountTypeExpense, model.BalanceDebit, "642"},
+	{"711", "Thu nhập khác", model.AccountTypeRevenue, model.BalanceCredit, ""},
+	{"811", "Chi phí khác", model.AccountTypeExpense, model.BalanceDebit, ""},
+	{"821", "Chi phí thuế thu nhập doanh nghiệp", model.AccountTypeExpense, model.BalanceDebit, ""},
+	{"8211", "Chi phí thuế TNDN hiện hành", model.AccountTypeExpense, model.BalanceDebit, "821"},
+	{"8212", "Chi phí thuế TNDN hoãn lại", model.AccountTypeExpense, model.BalanceDebit, "821"},
+	{"911", "Xác định kết quả kinh doanh", model.AccountTypeEquity, model.BalanceCredit, ""},
+}
+
+// automaticPostingAccounts take the automatic postings, so they can be neither split into
+// sub-accounts nor deactivated without breaking approvals
+var automaticPostingAccounts = map[string]bool{
+	model.AccountCash: true, model.AccountBank: true, model.AccountReceivable: true,
+	model.AccountInputVAT: true, model.AccountGoodsPurchase: true, model.AccountGoodsPurchaseFee: true,
+	model.AccountPayable: true, model.AccountOutputVAT: true, model.AccountImportExportTax: true,
+	model.AccountContractorTax: true, model.AccountSalesRevenue: true, model.AccountServiceRevenue: true,
+	model.AccountCostOfGoodsSold: true, model.AccountAdminExpense: true, model.AccountSellingExpense: true,
+	model.AccountFinancialIncome: true, model.AccountFinancialExpense: true,
+}
+
+var accountCodePattern = regexp.MustCompile(`^[0-9A-Za-z]+$`)
+
+// --- Implementation ---
+
+// SeedDefaultAccounts creates the accounts of the VAS chart that do not exist yet; accounts
+// already present (including renamed ones) are left untouched
+func (s *ledgerService) SeedDefaultAccounts(ctx context.Context) error {
+	return s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		for _, a := range defaultChartOfAccounts {
+			account := &model.Account{
+				Code:          a.Code,
+				Name:          a.Name,
+				AccountType:   a.Type,
+				NormalBalance: a.Normal,
+				IsSystem:      true,
+				IsActive:      true,
+			}
+			if a.Parent != "" {
+				parent := a.Parent
+				account.ParentCode = &parent
+			}
+			if err := s.ledgerRepo.CreateAccountIfMissing(txCtx, account); err != nil {
+				return fmt.Errorf("failed to seed account %s: %w", a.Code, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *ledgerService) ListAccounts(ctx context.Context) ([]AccountResponse, error) {
+	accounts, err := s.ledgerRepo.ListAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+
+	result := make([]AccountResponse, 0, len(accounts))
+	for _, a := range accounts {
+		result = append(result, toAccountResponse(a))
+	}
+	return result, nil
+}
+
+func (s *ledgerService) CreateAccount(ctx context.Context, userID string, req CreateAccountRequest) (AccountResponse, error) {
+	code := strings.TrimSpace(req.Code)
+	parentCode := strings.TrimSpace(req.ParentCode)
+	if !accountCodePattern.MatchString(code) {
+		return AccountResponse{}, fmt.Errorf("account code may only contain letters and digits")
+	}
+	if len(code) <= len(parentCode) || !strings.HasPrefix(code, parentCode) {
+		return AccountResponse{}, fmt.Errorf("account code must start with its parent code %s", parentCode)
+	}
+	if automaticPostingAccounts[parentCode] {
+		return AccountResponse{}, fmt.Errorf("account %s takes automatic postings and cannot have sub-accounts", parentCode)
+	}
+
+	var account *model.Account
+	err := s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		parent, err := s.ledgerRepo.FindAccountByCode(txCtx, parentCode)
+		if err != nil {
+			return fmt.Errorf("parent account not found: %w", err)
+		}
+		if _, err := s.ledgerRepo.FindAccountByCode(txCtx, code); err == nil {
+			return fmt.Errorf("account %s already exists", code)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to check account code: %w", err)
+		}
+
+		// Only leaf accounts take postings, so a parent that already has some cannot be split
+		lines, err := s.ledgerRepo.CountAccountLines(txCtx, parent.Code)
+		if err != nil {
+			return fmt.Errorf("failed to check postings of %s: %w", parent.Code, err)
+		}
+		if lines > 0 {
+			return fmt.Errorf("account %s already has postings and cannot have sub-accounts", parent.Code)
+		}
+
+		account = &model.Account{
+			Code:          code,
+			Name:          strings.TrimSpace(req.Name),
+			AccountType:   parent.AccountType,
+			NormalBalance: parent.NormalBalance,
+			ParentCode:    &parent.Code,
+			IsActive:      true,
+		}
+		if err := s.ledgerRepo.CreateAccount(txCtx, account); err != nil {
+			return fmt.Errorf("failed to create account: %w", err)
+		}
+
+		if err := s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionCreateAccount, account.ID.String(), account.Code, map[string]interface{}{
+			"name":        account.Name,
+			"parent_code": parent.Code,
+		})); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return AccountResponse{}, err
+	}
+
+	return toAccountResponse(*account), nil
+}
+
+func (s *ledgerService) UpdateAccount(ctx context.Context, code string, userID string, req UpdateAccountRequest) (AccountResponse, error) {
+	var account *model.Account
+	err := s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		var err error
+		account, err = s.ledgerRepo.FindAccountByCode(txCtx, code)
+		if err != nil {
+			return fmt.Errorf("account not found: %w", err)
+		}
+
+		details := map[string]interface{}{}
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" {
+				return fmt.Errorf("name must not be empty")
+			}
+			details["name"] = map[string]string{"from": account.Name, "to": name}
+			account.Name = name
+		}
+		if req.IsActive != nil && *req.IsActive != account.IsActive {
+			if !*req.IsActive && automaticPostingAccounts[account.Code] {
+				return fmt.Errorf("account %s takes automatic postings and cannot be deactivated", account.Code)
+			}
+			details["is_active"] = *req.IsActive
+			account.IsActive = *req.IsActive
+		}
+
+		if err := s.ledgerRepo.UpdateAccount(txCtx, account); err != nil {
+			return fmt.Errorf("failed to update account: %w", err)
+		}
+		if err := s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionUpdateAccount, account.ID.String(), account.Code, details)); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return AccountResponse{}, err
+	}
+
+	return toAccountResponse(*account), nil
+}
+
+func (s *ledgerService) ListJournalEntries(ctx context.Context, filter JournalEntryFilter) ([]JournalEntryResponse, int64, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+
+	repoFilter := repository.JournalEntryFilter{
+		SourceType:  filter.SourceType,
+		AccountCode: filter.AccountCode,
+		Page:        filter.Page,
+		Limit:       filter.Limit,
+	}
+	if filter.SourceID != "" {
+		sourceID, err := uuid.Parse(filter.SourceID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid source_id: %w", err)
+		}
+		repoFilter.SourceID = &sourceID
+	}
+	if filter.DateFrom != "" {
+		from, err := time.Parse("2006-01-02", filter.DateFrom)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid date_from, expected YYYY-MM-DD: %w", err)
+		}
+		repoFilter.DateFrom = &from
+	}
+	if filter.DateTo != "" {
+		to, err := time.Parse("2006-01-02", filter.DateTo)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid date_to, expected YYYY-MM-DD: %w", err)
+		}
+		to = to.AddDate(0, 0, 1)
+		repoFilter.DateTo = &to
+	}
+
+	entries, total, err := s.ledgerRepo.ListEntries(ctx, repoFilter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch journal entries: %w", err)
+	}
+
+	result := make([]JournalEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, toJournalEntryResponse(e))
+	}
+	return result, total, nil
+}
+
+func (s *ledgerService) GetJournalEntry(ctx context.Context, id string) (JournalEntryResponse, error) {
+	entryID, err := uuid.Parse(id)
+	if err != nil {
+		return JournalEntryResponse{}, fmt.Errorf("invalid journal entry id: %w", err)
+	}
+
+	entry, err := s.ledgerRepo.FindEntryByID(ctx, entryID)
+	if err != nil {
+		return JournalEntryResponse{}, fmt.Errorf("journal entry not found: %w", err)
+	}
+	return toJournalEntryResponse(*entry), nil
+}
+
+// accountTotals accumulates the postings of one account for a report
+type accountTotals struct {
+	openingDebit, openingCredit, periodDebit, periodCredit decimal.Decimal
+}
+
+func (t *accountTotals) add(o accountTotals) {
+	t.openingDebit = t.openingDebit.Add(o.openingDebit)
+	t.openingCredit = t.openingCredit.Add(o.openingCredit)
+	t.periodDebit = t.periodDebit.Add(o.periodDebit)
+	t.periodCredit = t.periodCredit.Add(o.periodCredit)
+}
+
+func (t accountTotals) isZero() bool {
+	return t.openingDebit.IsZero() && t.openingCredit.IsZero() && t.periodDebit.IsZero() && t.periodCredit.IsZero()
+}
+
+func (t accountTotals) opening() decimal.Decimal {
+	return t.openingDebit.Sub(t.openingCredit)
+}
+
+func (t accountTotals) closing() decimal.Decimal {
+	return t.opening().Add(t.periodDebit).Sub(t.periodCredit)
+}
+
+func (s *ledgerService) GetTrialBalance(ctx context.Context, filter LedgerPeriodFilter) (TrialBalanceResponse, error) {
+	period, err := parseLedgerPeriod(filter)
+	if err != nil {
+		return TrialBalanceResponse{}, err
+	}
+
+	accounts, err := s.ledgerRepo.ListAccounts(ctx)
+	if err != nil {
+		return TrialBalanceResponse{}, fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+	rows, err := s.ledgerRepo.ListAccountTotals(ctx, period)
+	if err != nil {
+		return TrialBalanceResponse{}, fmt.Errorf("failed to fetch account totals: %w", err)
+	}
+
+	resp := TrialBalanceResponse{
+		DateFrom: period.From.Format("2006-01-02"),
+		DateTo:   period.To.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+	resp.Accounts, resp.Totals, resp.Balanced = buildTrialBalance(accounts, rows, "")
+	return resp, nil
+}
+
+// buildTrialBalance returns the rows of the accounts under prefix (all accounts when empty),
+// each account's postings rolled up to its ancestors, with the totals of the level-1 accounts
+func buildTrialBalance(accounts []model.Account, rows []repository.AccountTotalRow, prefix string) ([]TrialBalanceRow, TrialBalanceRow, bool) {
+	byCode := make(map[string]model.Account, len(accounts))
+	for _, a := range accounts {
+		byCode[a.Code] = a
+	}
+
+	totals := make(map[string]*accountTotals)
+	for _, r := range rows {
+		if !strings.HasPrefix(r.AccountCode, prefix) {
+			continue
+		}
+		t := accountTotals{r.OpeningDebit, r.OpeningCredit, r.PeriodDebit, r.PeriodCredit}
+		for code := r.AccountCode; code != ""; {
+			if totals[code] == nil {
+				totals[code] = &accountTotals{}
+			}
+			totals[code].add(t)
+			parent, ok := byCode[code]
+			if !ok || parent.ParentCode == nil || !strings.HasPrefix(*parent.ParentCode, prefix) {
+				break
+			}
+			code = *parent.ParentCode
+		}
+	}
+
+	codes := make([]string, 0, len(totals))
+	for code, t := range totals {
+		if !t.isZero() {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	var grand accountTotals
+	var openingDebit, openingCredit, closingDebit, closingCredit decimal.Decimal
+	result := make([]TrialBalanceRow, 0, len(codes))
+	for _, code := range codes {
+		account := byCode[code]
+		account.Code = code
+		level := accountLevel(byCode, code)
+		result = append(result, toTrialBalanceRow(account, *totals[code], level))
+
+		if level != 1 {
+			continue
+		}
+		t := *totals[code]
+		grand.add(accountTotals{periodDebit: t.periodDebit, periodCredit: t.periodCredit})
+		if t.opening().IsPositive() {
+			openingDebit = openingDebit.Add(t.opening())
+		} else {
+			openingCredit = openingCredit.Sub(t.opening())
+		}
+		if t.closing().IsPositive() {
+			closingDebit = closingDebit.Add(t.closing())
+		} else {
+			closingCredit = closingCredit.Sub(t.closing())
+		}
+	}
+
+	sum := TrialBalanceRow{
+		Opening:      LedgerBalance{Debit: openingDebit.StringFixed(4), Credit: openingCredit.StringFixed(4)},
+		PeriodDebit:  grand.periodDebit.StringFixed(4),
+		PeriodCredit: grand.periodCredit.StringFixed(4),
+		Closing:      LedgerBalance{Debit: closingDebit.StringFixed(4), Credit: closingCredit.StringFixed(4)},
+	}
+	balanced := openingDebit.Equal(openingCredit) && grand.periodDebit.Equal(grand.periodCredit) && closingDebit.Equal(closingCredit)
+	return result, sum, balanced
+}
+
+func (s *ledgerService) GetGeneralLedger(ctx context.Context, code string, filter LedgerPeriodFilter) (GeneralLedgerResponse, error) {
+	period, err := parseLedgerPeriod(filter)
+	if err != nil {
+		return GeneralLedgerResponse{}, err
+	}
+	account, err := s.ledgerRepo.FindAccountByCode(ctx, code)
+	if err != nil {
+		return GeneralLedgerResponse{}, fmt.Errorf("account not found: %w", err)
+	}
+
+	resp := GeneralLedgerResponse{
+		Account:  toAccountResponse(*account),
+		DateFrom: period.From.Format("2006-01-02"),
+		DateTo:   period.To.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+
+	// Opening balance of the account and its sub-accounts, or of one partner on them
+	var partnerID *uuid.UUID
+	opening := decimal.Zero
+	if filter.PartnerID != "" {
+		parsed, err := uuid.Parse(filter.PartnerID)
+		if err != nil {
+			return GeneralLedgerResponse{}, fmt.Errorf("invalid partner_id: %w", err)
+		}
+		partnerID = &parsed
+		resp.PartnerID = &filter.PartnerID
+
+		rows, err := s.ledgerRepo.ListPartnerTotals(ctx, account.Code, period)
+		if err != nil {
+			return GeneralLedgerResponse{}, fmt.Errorf("failed to fetch partner totals: %w", err)
+		}
+		for _, r := range rows {
+			if r.PartnerID != nil && *r.PartnerID == parsed {
+				opening = r.OpeningDebit.Sub(r.OpeningCredit)
+			}
+		}
+	} else {
+		rows, err := s.ledgerRepo.ListAccountTotals(ctx, period)
+		if err != nil {
+			return GeneralLedgerResponse{}, fmt.Errorf("failed to fetch account totals: %w", err)
+		}
+		for _, r := range rows {
+			if strings.HasPrefix(r.AccountCode, account.Code) {
+				opening = opening.Add(r.OpeningDebit).Sub(r.OpeningCredit)
+			}
+		}
+	}
+
+	lines, err := s.ledgerRepo.ListLedgerLines(ctx, account.Code, partnerID, period)
+	if err != nil {
+		return GeneralLedgerResponse{}, fmt.Errorf("failed to fetch ledger lines: %w", err)
+	}
+
+	// Running balances are shown on the account's normal side
+	sign := decimal.NewFromInt(1)
+	if account.NormalBalance == model.BalanceCredit {
+		sign = sign.Neg()
+	}
+
+	balance := opening
+	periodDebit, periodCredit := decimal.Zero, decimal.Zero
+	resp.Lines = make([]LedgerLineResponse, 0, len(lines))
+	for _, l := range lines {
+		balance = balance.Add(l.Debit).Sub(l.Credit)
+		periodDebit = periodDebit.Add(l.Debit)
+		periodCredit = periodCredit.Add(l.Credit)
+
+		line := LedgerLineResponse{
+			EntryID:        l.EntryID.String(),
+			EntryNo:        l.EntryNo,
+			EntryDate:      l.EntryDate.Format("2006-01-02"),
+			SourceType:     l.SourceType,
+			SourceNo:       l.SourceNo,
+			Description:    l.Description,
+			AccountCode:    l.AccountCode,
+			PartnerName:    l.PartnerName,
+			ContraAccounts: []string{},
+			Debit:          l.Debit.StringFixed(4),
+			Credit:         l.Credit.StringFixed(4),
+			Balance:        balance.Mul(sign).StringFixed(4),
+		}
+		if l.PartnerID != nil {
+			id := l.PartnerID.String()
+			line.PartnerID = &id
+		}
+		if l.ContraAccounts != "" {
+			line.ContraAccounts = strings.Split(l.ContraAccounts, ",")
+		}
+		resp.Lines = append(resp.Lines, line)
+	}
+
+	resp.Opening = toLedgerBalance(opening)
+	resp.PeriodDebit = periodDebit.StringFixed(4)
+	resp.PeriodCredit = periodCredit.StringFixed(4)
+	resp.Closing = toLedgerBalance(balance)
+	return resp, nil
+}
+
+func (s *ledgerService) GetAccountDetail(ctx context.Context, code string, filter LedgerPeriodFilter) (AccountDetailResponse, error) {
+	period, err := parseLedgerPeriod(filter)
+	if err != nil {
+		return AccountDetailResponse{}, err
+	}
+	account, err := s.ledgerRepo.FindAccountByCode(ctx, code)
+	if err != nil {
+		return AccountDetailResponse{}, fmt.Errorf("account not found: %w", err)
+	}
+
+	accounts, err := s.ledgerRepo.ListAccounts(ctx)
+	if err != nil {
+		return AccountDetailResponse{}, fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+	totals, err := s.ledgerRepo.ListAccountTotals(ctx, period)
+	if err != nil {
+		return AccountDetailResponse{}, fmt.Errorf("failed to fetch account totals: %w", err)
+	}
+	partners, err := s.ledgerRepo.ListPartnerTotals(ctx, account.Code, period)
+	if err != nil {
+		return AccountDetailResponse{}, fmt.Errorf("failed to fetch partner totals: %w", err)
+	}
+
+	resp := AccountDetailResponse{
+		Account:  toAccountResponse(*account),
+		DateFrom: period.From.Format("2006-01-02"),
+		DateTo:   period.To.AddDate(0, 0, -1).Format("2006-01-02"),
+		Partners: make([]AccountPartnerBalance, 0, len(partners)),
+	}
+
+	// Balances of the sub-accounts, without the account itself
+	rows, _, _ := buildTrialBalance(accounts, totals, account.Code)
+	for _, row := range rows {
+		if row.Code != account.Code {
+			resp.SubAccounts = append(resp.SubAccounts, row)
+		}
+	}
+
+	var sum accountTotals
+
+	for _, p := range partners {
+		t := accountTotals{p.OpeningDebit, p.OpeningCredit, p.PeriodDebit, p.PeriodCredit}
+		if t.isZero() {
+			continue
+		}
+		sum.add(t)
+
+		balance := AccountPartnerBalance{
+			PartnerName:  p.PartnerName,
+			Opening:      toLedgerBalance(t.opening()),
+			PeriodDebit:  t.periodDebit.StringFixed(4),
+			PeriodCredit: t.periodCredit.StringFixed(4),
+			Closing:      toLedgerBalance(t.closing()),
+		}
+		if p.PartnerID != nil {
+			id := p.PartnerID.String()
+			balance.PartnerID = &id
+		}
+		resp.Partners = append(resp.Partners, balance)
+	}
+
+	resp.Opening = toLedgerBalance(sum.opening())
+	resp.PeriodDebit = sum.periodDebit.StringFixed(4)
+	resp.PeriodCredit = sum.periodCredit.StringFixed(4)
+	resp.Closing = toLedgerBalance(sum.closing())
+	return resp, nil
+}
+
+// --- Helpers ---
+
+// parseLedgerPeriod turns an inclusive date range into a half-open period, defaulting to the
+// current year to date
+func parseLedgerPeriod(filter LedgerPeriodFilter) (repository.LedgerPeriod, error) {
+	now := time.Now()
+	from := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if filter.DateFrom != "" {
+		parsed, err := time.Parse("2006-01-02", filter.DateFrom)
+		if err != nil {
+			return repository.LedgerPeriod{}, fmt.Errorf("invalid date_from, expected YYYY-MM-DD: %w", err)
+		}
+		from = parsed
+	}
+	if filter.DateTo != "" {
+		parsed, err := time.Parse("2006-01-02", filter.DateTo)
+		if err != nil {
+			return repository.LedgerPeriod{}, fmt.Errorf("invalid date_to, expected YYYY-MM-DD: %w", err)
+		}
+		to = parsed
+	}
+	if to.Before(from) {
+		return repository.LedgerPeriod{}, fmt.Errorf("date_to must not be before date_from")
+	}
+
+	return repository.LedgerPeriod{From: from, To: to.AddDate(0, 0, 1)}, nil
+}
+
+func accountLevel(byCode map[string]model.Account, code string) int {
+	level := 1
+	for account, ok := byCode[code]; ok && account.ParentCode != nil; account, ok = byCode[*account.ParentCode] {
+		level++
+	}
+	return level
+}
+
+// toLedgerBalance puts a net debit-minus-credit amount in its column
+func toLedgerBalance(net decimal.Decimal) LedgerBalance {
+	if net.IsNegative() {
+		return LedgerBalance{Debit: decimal.Zero.StringFixed(4), Credit: net.Neg().StringFixed(4)}
+	}
+	return LedgerBalance{Debit: net.StringFixed(4), Credit: decimal.Zero.StringFixed(4)}
+}
+
+func toTrialBalanceRow(a model.Account, t accountTotals, level int) TrialBalanceRow {
+	return TrialBalanceRow{
+		Code:         a.Code,
+		Name:         a.Name,
+		AccountType:  a.AccountType,
+		ParentCode:   a.ParentCode,
+		Level:        level,
+		Opening:      toLedgerBalance(t.opening()),
+		PeriodDebit:  t.periodDebit.StringFixed(4),
+		PeriodCredit: t.periodCredit.StringFixed(4),
+		Closing:      toLedgerBalance(t.closing()),
+	}
+}
+
+func toAccountResponse(a model.Account) AccountResponse {
+	return AccountResponse{
+		ID:            a.ID.String(),
+		Code:          a.Code,
+		Name:          a.Name,
+		AccountType:   a.AccountType,
+		NormalBalance: a.NormalBalance,
+		ParentCode:    a.ParentCode,
+		IsSystem:      a.IsSystem,
+		IsActive:      a.IsActive,
+	}
+}
+
+func toJournalEntryResponse(e model.JournalEntry) JournalEntryResponse {
+	resp := JournalEntryResponse{
+		ID:          e.ID.String(),
+		EntryNo:     e.EntryNo,
+		EntryDate:   e.EntryDate.Format("2006-01-02"),
+		SourceType:  e.SourceType,
+		SourceID:    e.SourceID.String(),
+		SourceNo:    e.SourceNo,
+		Description: e.Description,
+		TotalAmount: e.TotalAmount.StringFixed(4),
+		CreatedAt:   e.CreatedAt.Format(time.RFC3339),
+		Lines:       make([]JournalLineResponse, 0, len(e.Lines)),
+	}
+	if e.ReversalOfID != nil {
+		id := e.ReversalOfID.String()
+		resp.ReversalOfID = &id
+	}
+	if e.ReversedByID != nil {
+		id := e.ReversedByID.String()
+		resp.ReversedByID = &id
+	}
+	if e.CreatedBy != nil {
+		id := e.CreatedBy.String()
+		resp.CreatedBy = &id
+	}
+	for _, l := range e.Lines {
+		line := JournalLineResponse{
+			LineNo:      l.LineNo,
+			AccountCode: l.AccountCode,
+			Debit:       l.Debit.StringFixed(4),
+			Credit:      l.Credit.StringFixed(4),
+			Description: l.Description,
+		}
+		if l.PartnerID != nil {
+			id := l.PartnerID.String()
+			line.PartnerID = &id
+		}
+		resp.Lines = append(resp.Lines, line)
+	}
+	return resp
+}
@@ -4,73 +4,107 @@ import (
 	"context"
 	"fmt"
 	"net/mail"
+	"strings"
 	"time"
 
 	"backend/internal/model"
 	"backend/internal/repository"
+	"backend/pkg/geo"
+	"backend/pkg/vnadmin"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 // --- Address DTO ---
 
 type AddressPayload struct {
-	AddressType string `json:"address_type"`
-	FullAddress string `json:"full_address"`
-	IsDefault   bool   `json:"is_default"`
+	AddressType string   `json:"address_type"`
+	FullAddress string   `json:"full_address"` // Optional when structured fields are given; composed from them
+	Street      string   `json:"street"`
+	Ward        string   `json:"ward"`
+	District    string   `json:"district"`
+	Province    string   `json:"province"`    // Validated against the bundled division list when country is VN
+	Country     string   `json:"country"`     // ISO 3166-1 alpha-2, defaults to VN
+	PostalCode  string   `json:"postal_code"` // 5 digits for VN
+	IsDefault   bool     `json:"is_default"`
+	Latitude    *float64 `json:"latitude"`  // Optional, used by delivery route planning
+	Longitude   *float64 `json:"longitude"` // Optional, used by delivery route planning
 }
 
 type AddressResponse struct {
-	ID          uuid.UUID `json:"id"`
-	PartnerID   uuid.UUID `json:"partner_id"`
-	AddressType string    `json:"address_type"`
-	FullAddress string    `json:"full_address"`
-	IsDefault   bool      `json:"is_default"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           uuid.UUID `json:"id"`
+	PartnerID    uuid.UUID `json:"partner_id"`
+	AddressType  string    `json:"address_type"`
+	FullAddress  string    `json:"full_address"`
+	Street       string    `json:"street"`
+	Ward         string    `json:"ward"`
+	District     string    `json:"district"`
+	Province     string    `json:"province"`
+	ProvinceCode string    `json:"province_code"`
+	Country      string    `json:"country"`
+	PostalCode   string    `json:"postal_code"`
+	IsDefault    bool      `json:"is_default"`
+	Latitude     *float64  `json:"latitude"`
+	Longitude    *float64  `json:"longitude"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ProvinceResponse struct {
+	Code   string   `json:"code"`
+	Name   string   `json:"name"`
+	Type   string   `json:"type"` // CITY, PROVINCE
+	Legacy []string `json:"legacy_names"`
 }
 
 // --- Partner DTOs ---
 
 type CreatePartnerRequest struct {
-	Name          string           `json:"name" binding:"required"`
-	Type          string           `json:"type" binding:"required"`
-	TaxCode       string           `json:"tax_code"`
-	CompanyName   string           `json:"company_name"`
-	BankAccount   string           `json:"bank_account"`
-	ContactPerson string           `json:"contact_person"`
-	Phone         string           `json:"phone"`
-	Email         string           `json:"email"`
-	Addresses     []AddressPayload `json:"addresses"`
+	Name            string           `json:"name" binding:"required"`
+	Type            string           `json:"type" binding:"required"`
+	TaxCode         string           `json:"tax_code"`
+	CompanyName     string           `json:"company_name"`
+	BankAccount     string           `json:"bank_account"`
+	ContactPerson   string           `json:"contact_person"`
+	Phone           string           `json:"phone"`
+	Email           string           `json:"email"`
+	PaymentTermDays int              `json:"payment_term_days" binding:"min=0,max=365"` // 0 = due on approval
+	CreditLimit     string           `json:"credit_limit"`                              // Base currency; empty or 0 = no limit
+	Addresses       []AddressPayload `json:"addresses"`
 }
 
 type UpdatePartnerRequest struct {
-	Name          *string           `json:"name"`
-	Type          *string           `json:"type"`
-	TaxCode       *string           `json:"tax_code"`
-	CompanyName   *string           `json:"company_name"`
-	BankAccount   *string           `json:"bank_account"`
-	ContactPerson *string           `json:"contact_person"`
-	Phone         *string           `json:"phone"`
-	Email         *string           `json:"email"`
-	IsActive      *bool             `json:"is_active"`
-	Addresses     *[]AddressPayload `json:"addresses"` // pointer so nil = not sent, [] = clear all
+	Name            *string           `json:"name"`
+	Type            *string           `json:"type"`
+	TaxCode         *string           `json:"tax_code"`
+	CompanyName     *string           `json:"company_name"`
+	BankAccount     *string           `json:"bank_account"`
+	ContactPerson   *string           `json:"contact_person"`
+	Phone           *string           `json:"phone"`
+	Email           *string           `json:"email"`
+	PaymentTermDays *int              `json:"payment_term_days" binding:"omitempty,min=0,max=365"`
+	CreditLimit     *string           `json:"credit_limit"`
+	IsActive        *bool             `json:"is_active"`
+	Addresses       *[]AddressPayload `json:"addresses"` // pointer so nil = not sent, [] = clear all
 }
 
 type PartnerResponse struct {
-	ID            uuid.UUID         `json:"id"`
-	Name          string            `json:"name"`
-	Type          string            `json:"type"`
-	TaxCode       string            `json:"tax_code"`
-	CompanyName   string            `json:"company_name"`
-	BankAccount   string            `json:"bank_account"`
-	ContactPerson string            `json:"contact_person"`
-	Phone         string            `json:"phone"`
-	Email         string            `json:"email"`
-	IsActive      bool              `json:"is_active"`
-	Addresses     []AddressResponse `json:"addresses"`
-	CreatedAt     time.Time         `json:"created_at"`
-	UpdatedAt     time.Time         `json:"updated_at"`
+	ID              uuid.UUID         `json:"id"`
+	Name            string            `json:"name"`
+	Type            string            `json:"type"`
+	TaxCode         string            `json:"tax_code"`
+	CompanyName     string            `json:"company_name"`
+	BankAccount     string            `json:"bank_account"`
+	ContactPerson   string            `json:"contact_person"`
+	Phone           string            `json:"phone"`
+	Email           string            `json:"email"`
+	PaymentTermDays int               `json:"payment_term_days"`
+	CreditLimit     string            `json:"credit_limit"`
+	IsActive        bool              `json:"is_active"`
+	Addresses       []AddressResponse `json:"addresses"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
 }
 
 // --- Interface ---
@@ -80,6 +114,7 @@ type PartnerService interface {
 	UpdatePartner(ctx context.Context, id string, req UpdatePartnerRequest) (PartnerResponse, error)
 	DeletePartner(ctx context.Context, id string) error
 	GetPartners(ctx context.Context, partnerType, search string, page, limit int) ([]PartnerResponse, int64, error)
+	ListProvinces() []ProvinceResponse
 }
 
 // --- Implementation ---
@@ -107,26 +142,124 @@ var validAddressTypes = map[string]bool{
 	model.AddressTypeOrigin:   true,
 }
 
-func validateAddresses(addresses []AddressPayload) error {
-	for i, addr := range addresses {
+// parseCreditLimit parses a base-currency credit limit; empty means no limit
+func parseCreditLimit(value string) (decimal.Decimal, error) {
+	if strings.TrimSpace(value) == "" {
+		return decimal.Zero, nil
+	}
+	limit, err := decimal.NewFromString(strings.TrimSpace(value))
+	if err != nil || limit.IsNegative() {
+		return decimal.Zero, fmt.Errorf("credit_limit must be a non-negative amount")
+	}
+	return limit, nil
+}
+
+// normalizeAddresses validates each address and fills derived fields in place: country defaults
+// to VN, VN provinces and wards are resolved to their canonical names, and full_address is
+// composed from the structured fields when left empty. Wards are checked within their province
+// when the vnadmin ward list covers it; the district (abolished in 2025) is stored as given.
+func normalizeAddresses(addresses []AddressPayload) error {
+	for i := range addresses {
+		addr := &addresses[i]
 		if !validAddressTypes[addr.AddressType] {
 			return fmt.Errorf("addresses[%d]: address_type must be one of: BILLING, SHIPPING, ORIGIN", i)
 		}
+
+		addr.Country = strings.ToUpper(strings.TrimSpace(addr.Country))
+		if addr.Country == "" {
+			addr.Country = vnadmin.CountryCode
+		}
+		if len(addr.Country) != 2 {
+			return fmt.Errorf("addresses[%d]: country must be an ISO 3166-1 alpha-2 code", i)
+		}
+
+		structured := addr.Street != "" || addr.Ward != "" || addr.District != "" || addr.Province != ""
+		if addr.Country == vnadmin.CountryCode {
+			if structured && addr.Province == "" {
+				return fmt.Errorf("addresses[%d]: province is required for structured VN addresses", i)
+			}
+			if addr.Province != "" {
+				province, ok := vnadmin.LookupProvince(addr.Province)
+				if !ok {
+					return fmt.Errorf("addresses[%d]: unknown province '%s'", i, addr.Province)
+				}
+				addr.Province = province.Name
+
+				if addr.Ward != "" && vnadmin.HasWards(province.Code) {
+					ward, ok := vnadmin.LookupWard(province.Code, addr.Ward)
+					if !ok {
+						return fmt.Errorf("addresses[%d]: unknown ward '%s' in %s", i, addr.Ward, province.Name)
+					}
+					addr.Ward = ward.Name
+				}
+			}
+			if addr.PostalCode != "" && !vnadmin.ValidPostalCode(addr.PostalCode) {
+				return fmt.Errorf("addresses[%d]: postal_code must be 5 digits", i)
+			}
+		}
+
+		if addr.FullAddress == "" {
+			addr.FullAddress = composeFullAddress(*addr)
+		}
 		if addr.FullAddress == "" {
 			return fmt.Errorf("addresses[%d]: full_address is required", i)
 		}
+
+		if (addr.Latitude == nil) != (addr.Longitude == nil) {
+			return fmt.Errorf("addresses[%d]: latitude and longitude must be provided together", i)
+		}
+		if addr.Latitude != nil && !geo.ValidCoordinates(*addr.Latitude, *addr.Longitude) {
+			return fmt.Errorf("addresses[%d]: latitude must be between -90 and 90 and longitude between -180 and 180", i)
+		}
 	}
 	return nil
 }
 
+// composeFullAddress joins the structured parts from street to country
+func composeFullAddress(addr AddressPayload) string {
+	parts := make([]string, 0, 5)
+	for _, part := range []string{addr.Street, addr.Ward, addr.District, addr.Province} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	if addr.Country != vnadmin.CountryCode {
+		parts = append(parts, addr.Country)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// provinceCode returns the division code of a canonical VN province name
+func provinceCode(country, province string) string {
+	if country != vnadmin.CountryCode || province == "" {
+		return ""
+	}
+	if p, ok := vnadmin.LookupProvince(province); ok {
+		return p.Code
+	}
+	return ""
+}
+
 func toAddressModels(partnerID uuid.UUID, payloads []AddressPayload) []model.PartnerAddress {
 	addresses := make([]model.PartnerAddress, 0, len(payloads))
 	for _, p := range payloads {
 		addresses = append(addresses, model.PartnerAddress{
-			PartnerID:   partnerID,
-			AddressType: p.AddressType,
-			FullAddress: p.FullAddress,
-			IsDefault:   p.IsDefault,
+			PartnerID:    partnerID,
+			AddressType:  p.AddressType,
+			FullAddress:  p.FullAddress,
+			Street:       p.Street,
+			Ward:         p.Ward,
+			District:     p.District,
+			Province:     p.Province,
+			ProvinceCode: provinceCode(p.Country, p.Province),
+			Country:      p.Country,
+			PostalCode:   p.PostalCode,
+			IsDefault:    p.IsDefault,
+			Latitude:     p.Latitude,
+			Longitude:    p.Longitude,
 		})
 	}
 	return addresses
@@ -146,21 +279,28 @@ func (s *partnerService) CreatePartner(ctx context.Context, req CreatePartnerReq
 			return PartnerResponse{}, fmt.Errorf("invalid email format")
 		}
 	}
-	if err := validateAddresses(req.Addresses); err != nil {
+	if err := normalizeAddresses(req.Addresses); err != nil {
+		return PartnerResponse{}, err
+	}
+
+	creditLimit, err := parseCreditLimit(req.CreditLimit)
+	if err != nil {
 		return PartnerResponse{}, err
 	}
 
 	partner := &model.Partner{
-		Name:          req.Name,
-		Type:          req.Type,
-		TaxCode:       req.TaxCode,
-		CompanyName:   req.CompanyName,
-		BankAccount:   req.BankAccount,
-		ContactPerson: req.ContactPerson,
-		Phone:         req.Phone,
-		Email:         req.Email,
-		IsActive:      true,
-		Addresses:     toAddressModels(uuid.Nil, req.Addresses), // GORM fills PartnerID on cascade create
+		Name:            req.Name,
+		Type:            req.Type,
+		TaxCode:         req.TaxCode,
+		CompanyName:     req.CompanyName,
+		BankAccount:     req.BankAccount,
+		ContactPerson:   req.ContactPerson,
+		Phone:           req.Phone,
+		Email:           req.Email,
+		PaymentTermDays: req.PaymentTermDays,
+		CreditLimit:     creditLimit,
+		IsActive:        true,
+		Addresses:       toAddressModels(uuid.Nil, req.Addresses), // GORM fills PartnerID on cascade create
 	}
 
 	// GORM creates partner + addresses in a single Create because of the association
@@ -218,13 +358,23 @@ func (s *partnerService) UpdatePartner(ctx context.Context, id string, req Updat
 	if req.Phone != nil {
 		partner.Phone = *req.Phone
 	}
+	if req.PaymentTermDays != nil {
+		partner.PaymentTermDays = *req.PaymentTermDays
+	}
+	if req.CreditLimit != nil {
+		limit, err := parseCreditLimit(*req.CreditLimit)
+		if err != nil {
+			return PartnerResponse{}, err
+		}
+		partner.CreditLimit = limit
+	}
 	if req.IsActive != nil {
 		partner.IsActive = *req.IsActive
 	}
 
 	// Validate addresses if provided
 	if req.Addresses != nil {
-		if err := validateAddresses(*req.Addresses); err != nil {
+		if err := normalizeAddresses(*req.Addresses); err != nil {
 			return PartnerResponse{}, err
 		}
 	}
@@ -279,35 +429,56 @@ func (s *partnerService) GetPartners(ctx context.Context, partnerType, search st
 	return res, total, nil
 }
 
+// ListProvinces returns the bundled provincial-level units for address pickers
+func (s *partnerService) ListProvinces() []ProvinceResponse {
+	provinces := vnadmin.Provinces()
+	res := make([]ProvinceResponse, 0, len(provinces))
+	for _, p := range provinces {
+		res = append(res, ProvinceResponse{Code: p.Code, Name: p.Name, Type: p.Type, Legacy: p.Legacy})
+	}
+	return res
+}
+
 // --- Response mappers ---
 
 func toPartnerResponse(p model.Partner) PartnerResponse {
 	addresses := make([]AddressResponse, 0, len(p.Addresses))
 	for _, a := range p.Addresses {
 		addresses = append(addresses, AddressResponse{
-			ID:          a.ID,
-			PartnerID:   a.PartnerID,
-			AddressType: a.AddressType,
-			FullAddress: a.FullAddress,
-			IsDefault:   a.IsDefault,
-			CreatedAt:   a.CreatedAt,
-			UpdatedAt:   a.UpdatedAt,
+			ID:           a.ID,
+			PartnerID:    a.PartnerID,
+			AddressType:  a.AddressType,
+			FullAddress:  a.FullAddress,
+			Street:       a.Street,
+			Ward:         a.Ward,
+			District:     a.District,
+			Province:     a.Province,
+			ProvinceCode: a.ProvinceCode,
+			Country:      a.Country,
+			PostalCode:   a.PostalCode,
+			IsDefault:    a.IsDefault,
+			Latitude:     a.Latitude,
+			Longitude:    a.Longitude,
+			CreatedAt:    a.CreatedAt,
+			UpdatedAt:    a.UpdatedAt,
 		})
 	}
 
 	return PartnerResponse{
-		ID:            p.ID,
-		Name:          p.Name,
-		Type:          p.Type,
-		TaxCode:       p.TaxCode,
-		CompanyName:   p.CompanyName,
-		BankAccount:   p.BankAccount,
-		ContactPerson: p.ContactPerson,
-		Phone:         p.Phone,
-		Email:         p.Email,
-		IsActive:      p.IsActive,
-		Addresses:     addresses,
-		CreatedAt:     p.CreatedAt,
-		UpdatedAt:     p.UpdatedAt,
+		ID:              p.ID,
+		Name:            p.Name,
+		Type:            p.Type,
+		TaxCode:         p.TaxCode,
+		CompanyName:     p.CompanyName,
+		BankAccount:     p.BankAccount,
+		ContactPerson:   p.ContactPerson,
+		Phone:           p.Phone,
+		Email:           p.Email,
+		PaymentTermDays: p.PaymentTermDays,
+		CreditLimit:     p.CreditLimit.StringFixed(4),
+		IsActive:        p.IsActive,
+		Addresses:       addresses,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
 	}
 }
@@ -0,0 +1,538 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// --- DTOs ---
+
+// CreatePaymentRunRequest proposes payment of the supplier invoices due by a date
+type CreatePaymentRunRequest struct {
+	DueBefore    string   `json:"due_before" binding:"required"`   // YYYY-MM-DD, inclusive
+	PaymentDate  string   `json:"payment_date" binding:"required"` // YYYY-MM-DD
+	PartnerIDs   []string `json:"partner_ids"`                     // Optional: limit the run to these suppliers
+	DebitAccount string   `json:"debit_account" binding:"max=100"`
+	Note         string   `json:"note"`
+}
+
+type PaymentRunLineResponse struct {
+	ID             string  `json:"id"`
+	InvoiceID      string  `json:"invoice_id"`
+	InvoiceNo      string  `json:"invoice_no"`
+	ReferenceType  string  `json:"reference_type"`
+	PartnerID      string  `json:"partner_id"`
+	PartnerName    string  `json:"partner_name"`
+	BankAccount    string  `json:"bank_account"`
+	DueDate        string  `json:"due_date"`
+	Currency       string  `json:"currency"`
+	ExchangeRate   string  `json:"exchange_rate"`
+	Amount         string  `json:"amount"`
+	WithheldAmount string  `json:"withheld_amount"`
+	TransferAmount string  `json:"transfer_amount"`
+	AmountBase     string  `json:"amount_base"`
+	PaymentID      *string `json:"payment_id"`
+}
+
+// PaymentRunTotalResponse totals a run per payment currency
+type PaymentRunTotalResponse struct {
+	Currency       string `json:"currency"`
+	Amount         string `json:"amount"`
+	WithheldAmount string `json:"withheld_amount"`
+	TransferAmount string `json:"transfer_amount"`
+}
+
+type PaymentRunResponse struct {
+	ID           string                    `json:"id"`
+	RunNo        string                    `json:"run_no"`
+	Status       string                    `json:"status"`
+	DueBefore    string                    `json:"due_before"`
+	PaymentDate  string                    `json:"payment_date"`
+	DebitAccount string                    `json:"debit_account"`
+	Note         string                    `json:"note"`
+	CreatedBy    *string                   `json:"created_by"`
+	ConfirmedBy  *string                   `json:"confirmed_by"`
+	ConfirmedAt  *string                   `json:"confirmed_at"`
+	CreatedAt    string                    `json:"created_at"`
+	LineCount    int                       `json:"line_count"`
+	AmountBase   string                    `json:"amount_base"`
+	Totals       []PaymentRunTotalResponse `json:"totals"`
+	Lines        []PaymentRunLineResponse  `json:"lines,omitempty"`
+	Skipped      []string                  `json:"skipped,omitempty"` // Due invoices left out of a new run, with the reason
+}
+
+// --- Implementation ---
+
+// CreatePaymentRun proposes a DRAFT run with every open supplier invoice due by due_before.
+// Each line is priced in the currency the supplier is paid in; invoices already in another
+// draft run, and suppliers without a bank account, are left out.
+func (s *paymentService) CreatePaymentRun(ctx context.Context, userID string, req CreatePaymentRunRequest) (PaymentRunResponse, error) {
+	dueBefore, err := time.Parse("2006-01-02", req.DueBefore)
+	if err != nil {
+		return PaymentRunResponse{}, fmt.Errorf("invalid due_before, expected YYYY-MM-DD: %w", err)
+	}
+	paymentDate, err := time.Parse("2006-01-02", req.PaymentDate)
+	if err != nil {
+		return PaymentRunResponse{}, fmt.Errorf("invalid payment_date, expected YYYY-MM-DD: %w", err)
+	}
+	partnerFilter := make(map[uuid.UUID]bool, len(req.PartnerIDs))
+	for _, id := range req.PartnerIDs {
+		partnerID, err := uuid.Parse(id)
+		if err != nil {
+			return PaymentRunResponse{}, fmt.Errorf("invalid partner id %q: %w", id, err)
+		}
+		partnerFilter[partnerID] = true
+	}
+
+	endOfDay := dueBefore.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	balances, err := s.paymentRepo.ListInvoiceBalances(ctx, repository.InvoiceBalanceFilter{
+		RefTypes:  paymentReferenceTypes[model.PaymentTypeDisbursement],
+		OpenOnly:  true,
+		DueBefore: &endOfDay,
+	})
+	if err != nil {
+		return PaymentRunResponse{}, fmt.Errorf("failed to fetch due invoices: %w", err)
+	}
+	proposed, err := s.paymentRepo.ListDraftRunInvoiceIDs(ctx)
+	if err != nil {
+		return PaymentRunResponse{}, fmt.Errorf("failed to fetch draft payment runs: %w", err)
+	}
+	inDraft := make(map[uuid.UUID]bool, len(proposed))
+	for _, id := range proposed {
+		inDraft[id] = true
+	}
+
+	run := &model.PaymentRun{
+		Status:       model.PaymentRunDraft,
+		DueBefore:    dueBefore,
+		PaymentDate:  paymentDate,
+		DebitAccount: req.DebitAccount,
+		Note:         req.Note,
+		CreatedBy:    parseOptionalUUID(userID),
+	}
+	var skipped []string
+	partners := make(map[uuid.UUID]*model.Partner)
+	for _, b := range balances {
+		if len(partnerFilter) > 0 && !partnerFilter[b.PartnerID] {
+			continue
+		}
+		// Negative balances are credits owed by the supplier, not payments
+		if !b.OutstandingAmount.IsPositive() {
+			continue
+		}
+		if inDraft[b.InvoiceID] {
+			skipped = append(skipped, fmt.Sprintf("%s: already in a draft payment run", b.InvoiceNo))
+			continue
+		}
+
+		partner, ok := partners[b.PartnerID]
+		if !ok {
+			partner, err = s.partnerRepo.FindByID(ctx, b.PartnerID)
+			if err != nil {
+				return PaymentRunResponse{}, fmt.Errorf("partner of invoice %s not found: %w", b.InvoiceNo, err)
+			}
+			partners[b.PartnerID] = partner
+		}
+		if strings.TrimSpace(partner.BankAccount) == "" {
+			skipped = append(skipped, fmt.Sprintf("%s: %s has no bank account", b.InvoiceNo, partner.Name))
+			continue
+		}
+
+		// Suppliers are paid in the invoice currency; the base amount is the balance at the booked
+		// rate until the run is confirmed at the rate of the payment date
+		line := model.PaymentRunLine{
+			InvoiceID:    b.InvoiceID,
+			PartnerID:    b.PartnerID,
+			DueDate:      b.DueDate,
+			Currency:     b.Currency,
+			ExchangeRate: b.ExchangeRate,
+			Amount:       b.DocOutstandingAmount,
+			AmountBase:   b.OutstandingAmount,
+		}
+		if b.ReferenceType == model.RefTypeExpense {
+			expense, err := s.expenseRepo.FindByID(ctx, b.ReferenceID)
+			if err != nil {
+				return PaymentRunResponse{}, fmt.Errorf("expense of invoice %s not found: %w", b.InvoiceNo, err)
+			}
+			line.WithheldAmount = expenseWithheldAmount(*expense, b.OutstandingAmount, b.NetAmount)
+		}
+		run.Lines = append(run.Lines, line)
+	}
+	if len(run.Lines) == 0 {
+		if len(skipped) > 0 {
+			return PaymentRunResponse{}, fmt.Errorf("no invoice due by %s can be paid: %s", req.DueBefore, strings.Join(skipped, "; "))
+		}
+		return PaymentRunResponse{}, fmt.Errorf("no supplier invoices are due by %s", req.DueBefore)
+	}
+
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		if err := checkPeriodOpen(txCtx, s.periodRepo, run.PaymentDate, false); err != nil {
+			return err
+		}
+		runNo, err := nextDocumentNo(txCtx, s.sequenceRepo, model.SequencePaymentRun, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to generate payment run number: %w", err)
+		}
+		run.RunNo = runNo
+
+		if err := s.paymentRepo.CreateRun(txCtx, run); err != nil {
+			return fmt.Errorf("failed to create payment run: %w", err)
+		}
+
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionCreatePaymentRun, run.ID.String(), run.RunNo, map[string]interface{}{
+			"due_before":   req.DueBefore,
+			"payment_date": req.PaymentDate,
+			"line_count":   len(run.Lines),
+			"skipped":      skipped,
+		}))
+	})
+	if err != nil {
+		return PaymentRunResponse{}, err
+	}
+
+	resp, err := s.GetPaymentRun(ctx, run.ID.String())
+	if err != nil {
+		return PaymentRunResponse{}, err
+	}
+	resp.Skipped = skipped
+	return resp, nil
+}
+
+func (s *paymentService) ListPaymentRuns(ctx context.Context, status string, page, limit int) ([]PaymentRunResponse, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	runs, total, err := s.paymentRepo.ListRuns(ctx, status, page, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch payment runs: %w", err)
+	}
+
+	result := make([]PaymentRunResponse, 0, len(runs))
+	for _, r := range runs {
+		resp := toPaymentRunResponse(r)
+		resp.Lines = nil
+		result = append(result, resp)
+	}
+	return result, total, nil
+}
+
+func (s *paymentService) GetPaymentRun(ctx context.Context, id string) (PaymentRunResponse, error) {
+	runID, err := uuid.Parse(id)
+	if err != nil {
+		return PaymentRunResponse{}, fmt.Errorf("invalid payment run id: %w", err)
+	}
+
+	run, err := s.paymentRepo.FindRunByID(ctx, runID)
+	if err != nil {
+		return PaymentRunResponse{}, fmt.Errorf("payment run not found: %w", err)
+	}
+	return toPaymentRunResponse(*run), nil
+}
+
+// ConfirmPaymentRun records one bank disbursement per supplier and currency and allocates it to
+// the invoices of the run. It fails if any invoice has been paid since the run was drafted;
+// cancel the run and draft a new one in that case.
+func (s *paymentService) ConfirmPaymentRun(ctx context.Context, id string, userID string) (PaymentRunResponse, error) {
+	runID, err := uuid.Parse(id)
+	if err != nil {
+		return PaymentRunResponse{}, fmt.Errorf("invalid payment run id: %w", err)
+	}
+
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		run, err := s.paymentRepo.FindRunByIDForUpdate(txCtx, runID)
+		if err != nil {
+			return fmt.Errorf("payment run not found: %w", err)
+		}
+		if run.Status != model.PaymentRunDraft {
+			return fmt.Errorf("payment run is already %s", run.Status)
+		}
+		// Confirming approves a run drafted earlier, so a soft-closed period still accepts it
+		if err := checkPeriodOpen(txCtx, s.periodRepo, run.PaymentDate, true); err != nil {
+			return err
+		}
+
+		type groupKey struct {
+			partnerID uuid.UUID
+			currency  string
+		}
+		var order []groupKey
+		groups := make(map[groupKey][]int)
+		for i, l := range run.Lines {
+			key := groupKey{l.PartnerID, l.Currency}
+			if _, ok := groups[key]; !ok {
+				order = append(order, key)
+			}
+			groups[key] = append(groups[key], i)
+		}
+
+		paymentNos := make([]string, 0, len(order))
+		for _, key := range order {
+			payment := &model.Payment{
+				PaymentType:  model.PaymentTypeDisbursement,
+				PartnerID:    key.partnerID,
+				Method:       model.PaymentMethodBank,
+				PaymentDate:  run.PaymentDate,
+				Currency:     key.currency,
+				ExchangeRate: decimal.NewFromInt(1),
+				Reference:    run.RunNo,
+				PaymentRunID: &run.ID,
+				CreatedBy:    parseOptionalUUID(userID),
+			}
+			// Foreign-currency payments convert at the rate of the payment date, so settling invoices
+			// booked at another rate realizes an exchange difference like any other disbursement
+			if key.currency != baseCurrency {
+				rate, err := findExchangeRate(txCtx, s.rateRepo, key.currency, run.PaymentDate)
+				if err != nil {
+					return fmt.Errorf("cannot pay %s invoices of payment run %s: %w", key.currency, run.RunNo, err)
+				}
+				payment.ExchangeRate = rate.Rate
+			}
+			allocations := make([]PaymentAllocationRequest, 0, len(groups[key]))
+			for _, i := range groups[key] {
+				l := run.Lines[i]
+				payment.Amount = payment.Amount.Add(l.Amount)
+				payment.WithheldAmount = payment.WithheldAmount.Add(l.WithheldAmount)
+				// Summed per invoice as allocated, so rounding cannot exceed the payment
+				payment.AmountBase = payment.AmountBase.Add(roundBase(l.Amount.Mul(payment.ExchangeRate)))
+				allocations = append(allocations, PaymentAllocationRequest{
+					InvoiceID: l.InvoiceID.String(),
+					Amount:    l.Amount.StringFixed(4),
+				})
+			}
+
+			if err := s.recordPayment(txCtx, payment, userID, allocations); err != nil {
+				return err
+			}
+			paymentNos = append(paymentNos, payment.PaymentNo)
+
+			for _, i := range groups[key] {
+				line := &run.Lines[i]
+				line.PaymentID = &payment.ID
+				if err := s.paymentRepo.UpdateRunLine(txCtx, line); err != nil {
+					return fmt.Errorf("failed to update payment run line: %w", err)
+				}
+			}
+		}
+
+		now := time.Now()
+		run.Status = model.PaymentRunConfirmed
+		run.ConfirmedBy = parseOptionalUUID(userID)
+		run.ConfirmedAt = &now
+		if err := s.paymentRepo.UpdateRun(txCtx, run); err != nil {
+			return fmt.Errorf("failed to update payment run: %w", err)
+		}
+
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionConfirmPaymentRun, run.ID.String(), run.RunNo, map[string]interface{}{
+			"payments": paymentNos,
+		}))
+	})
+	if err != nil {
+		return PaymentRunResponse{}, err
+	}
+
+	return s.GetPaymentRun(ctx, id)
+}
+
+// CancelPaymentRun discards a DRAFT run so its invoices can be proposed again
+func (s *paymentService) CancelPaymentRun(ctx context.Context, id string, userID string) (PaymentRunResponse, error) {
+	runID, err := uuid.Parse(id)
+	if err != nil {
+		return PaymentRunResponse{}, fmt.Errorf("invalid payment run id: %w", err)
+	}
+
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		run, err := s.paymentRepo.FindRunByIDForUpdate(txCtx, runID)
+		if err != nil {
+			return fmt.Errorf("payment run not found: %w", err)
+		}
+		if run.Status != model.PaymentRunDraft {
+			return fmt.Errorf("only draft payment runs can be cancelled; this run is %s", run.Status)
+		}
+
+		run.Status = model.PaymentRunCancelled
+		if err := s.paymentRepo.UpdateRun(txCtx, run); err != nil {
+			return fmt.Errorf("failed to update payment run: %w", err)
+		}
+
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionCancelPaymentRun, run.ID.String(), run.RunNo, map[string]interface{}{
+			"line_count": len(run.Lines),
+		}))
+	})
+	if err != nil {
+		return PaymentRunResponse{}, err
+	}
+
+	return s.GetPaymentRun(ctx, id)
+}
+
+// ExportPaymentRunFile builds the bank batch file of a confirmed run: a CSV with one transfer
+// per disbursement, for the amount net of FCT withheld
+func (s *paymentService) ExportPaymentRunFile(ctx context.Context, id string) ([]byte, string, error) {
+	runID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid payment run id: %w", err)
+	}
+
+	run, err := s.paymentRepo.FindRunByID(ctx, runID)
+	if err != nil {
+		return nil, "", fmt.Errorf("payment run not found: %w", err)
+	}
+	if run.Status != model.PaymentRunConfirmed {
+		return nil, "", errors.New("the bank file is available once the payment run is confirmed")
+	}
+
+	payments, err := s.paymentRepo.ListByRun(ctx, run.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch run payments: %w", err)
+	}
+	invoiceNos := make(map[uuid.UUID][]string)
+	for _, l := range run.Lines {
+		if l.PaymentID != nil && l.Invoice != nil {
+			invoiceNos[*l.PaymentID] = append(invoiceNos[*l.PaymentID], l.Invoice.InvoiceNo)
+		}
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	rows := [][]string{{
+		"no", "payment_no", "payment_date", "debit_account",
+		"beneficiary_name", "beneficiary_account", "amount", "currency", "description",
+	}}
+	for i, p := range payments {
+		name, account := "", ""
+		if p.Partner != nil {
+			name = p.Partner.CompanyName
+			if name == "" {
+				name = p.Partner.Name
+			}
+			account = p.Partner.BankAccount
+		}
+		description := p.PaymentNo + " " + strings.Join(invoiceNos[p.ID], ",")
+		if len(description) > 140 {
+			description = description[:140]
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			p.PaymentNo,
+			p.PaymentDate.Format("2006-01-02"),
+			run.DebitAccount,
+			name,
+			account,
+			p.Amount.Sub(p.WithheldAmount).StringFixed(2),
+			p.Currency,
+			description,
+		})
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, "", fmt.Errorf("failed to write bank file: %w", err)
+	}
+
+	return buf.Bytes(), run.RunNo + ".csv", nil
+}
+
+// --- Helpers ---
+
+// expenseWithheldAmount is the FCT withheld, in the expense currency, when paying the outstanding
+// base amount of an expense invoice: the whole FCT for a fully open invoice, which is paid to the
+// tax authority instead of the vendor, and a pro rata share for partly settled or credited ones.
+func expenseWithheldAmount(e model.Expense, outstandingBase, netBase decimal.Decimal) decimal.Decimal {
+	withheld := e.FCTAmount.Div(e.ExchangeRate)
+	if netBase.IsPositive() && !outstandingBase.Equal(netBase) {
+		withheld = withheld.Mul(outstandingBase.Div(netBase))
+	}
+	return roundAmount(withheld, e.Currency)
+}
+
+// --- Mapping ---
+
+func toPaymentRunResponse(r model.PaymentRun) PaymentRunResponse {
+	resp := PaymentRunResponse{
+		ID:           r.ID.String(),
+		RunNo:        r.RunNo,
+		Status:       r.Status,
+		DueBefore:    r.DueBefore.Format("2006-01-02"),
+		PaymentDate:  r.PaymentDate.Format("2006-01-02"),
+		DebitAccount: r.DebitAccount,
+		Note:         r.Note,
+		CreatedAt:    r.CreatedAt.Format(time.RFC3339),
+		LineCount:    len(r.Lines),
+		Totals:       []PaymentRunTotalResponse{},
+		Lines:        make([]PaymentRunLineResponse, 0, len(r.Lines)),
+	}
+	if r.CreatedBy != nil {
+		s := r.CreatedBy.String()
+		resp.CreatedBy = &s
+	}
+	if r.ConfirmedBy != nil {
+		s := r.ConfirmedBy.String()
+		resp.ConfirmedBy = &s
+	}
+	if r.ConfirmedAt != nil {
+		s := r.ConfirmedAt.Format(time.RFC3339)
+		resp.ConfirmedAt = &s
+	}
+
+	totalBase := decimal.Zero
+	var currencies []string
+	amounts := make(map[string][2]decimal.Decimal)
+	for _, l := range r.Lines {
+		totalBase = totalBase.Add(l.AmountBase)
+		t, ok := amounts[l.Currency]
+		if !ok {
+			currencies = append(currencies, l.Currency)
+		}
+		amounts[l.Currency] = [2]decimal.Decimal{t[0].Add(l.Amount), t[1].Add(l.WithheldAmount)}
+
+		lr := PaymentRunLineResponse{
+			ID:             l.ID.String(),
+			InvoiceID:      l.InvoiceID.String(),
+			PartnerID:      l.PartnerID.String(),
+			DueDate:        l.DueDate.Format("2006-01-02"),
+			Currency:       l.Currency,
+			ExchangeRate:   l.ExchangeRate.String(),
+			Amount:         l.Amount.StringFixed(4),
+			WithheldAmount: l.WithheldAmount.StringFixed(4),
+			TransferAmount: l.Amount.Sub(l.WithheldAmount).StringFixed(4),
+			AmountBase:     l.AmountBase.StringFixed(4),
+		}
+		if l.Invoice != nil {
+			lr.InvoiceNo = l.Invoice.InvoiceNo
+			lr.ReferenceType = l.Invoice.ReferenceType
+		}
+		if l.Partner != nil {
+			lr.PartnerName = l.Partner.Name
+			lr.BankAccount = l.Partner.BankAccount
+		}
+		if l.PaymentID != nil {
+			s := l.PaymentID.String()
+			lr.PaymentID = &s
+		}
+		resp.Lines = append(resp.Lines, lr)
+	}
+	resp.AmountBase = totalBase.StringFixed(4)
+	for _, c := range currencies {
+		t := amounts[c]
+		resp.Totals = append(resp.Totals, PaymentRunTotalResponse{
+			Currency:       c,
+			Amount:         t[0].StringFixed(4),
+			WithheldAmount: t[1].StringFixed(4),
+			TransferAmount: t[0].Sub(t[1]).StringFixed(4),
+		})
+	}
+	return resp
+}
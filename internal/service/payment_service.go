@@ -0,0 +1,820 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// --- DTOs ---
+
+// PaymentAllocationRequest applies part of a payment to an invoice; the amount is in the
+// invoice currency
+type PaymentAllocationRequest struct {
+	InvoiceID string `json:"invoice_id" binding:"required"`
+	Amount    string `json:"amount" binding:"required"`
+}
+
+type CreatePaymentRequest struct {
+	PartnerID    string                     `json:"partner_id" binding:"required"`
+	Method       string                     `json:"method" binding:"required,oneof=CASH BANK_TRANSFER"`
+	PaymentDate  string                     `json:"payment_date" binding:"required"` // YYYY-MM-DD
+	Currency     string                     `json:"currency" binding:"required,len=3"`
+	ExchangeRate string                     `json:"exchange_rate"` // Base currency per unit of currency; required unless the base currency
+	Amount       string                     `json:"amount" binding:"required"`
+	Withheld     string                     `json:"withheld_amount"` // Disbursements only: part of amount withheld as FCT, defaults to 0
+	Reference    string                     `json:"reference" binding:"max=100"`
+	Note         string                     `json:"note"`
+	Allocations  []PaymentAllocationRequest `json:"allocations" binding:"omitempty,dive"` // Optional: allocate right away
+}
+
+type AllocatePaymentRequest struct {
+	Allocations []PaymentAllocationRequest `json:"allocations" binding:"required,min=1,dive"`
+}
+
+type PaymentFilter struct {
+	PaymentType string
+	PartnerID   string
+	DateFrom    string // YYYY-MM-DD
+	DateTo      string // YYYY-MM-DD
+	Unallocated bool
+	Page        int
+	Limit       int
+}
+
+type PaymentAllocationResponse struct {
+	ID                string `json:"id"`
+	InvoiceID         string `json:"invoice_id"`
+	InvoiceNo         string `json:"invoice_no"`
+	Amount            string `json:"amount"`              // Base amount cleared from the invoice
+	Currency          string `json:"currency"`            // Invoice currency
+	DocAmount         string `json:"doc_amount"`          // In the invoice currency
+	PaymentAmountBase string `json:"payment_amount_base"` // Base amount drawn from the payment
+	FXGainLoss        string `json:"fx_gain_loss"`        // Realized exchange gain (positive) or loss
+	CreatedAt         string `json:"created_at"`
+}
+
+type PaymentResponse struct {
+	ID                string                      `json:"id"`
+	PaymentNo         string                      `json:"payment_no"`
+	PaymentType       string                      `json:"payment_type"`
+	PartnerID         string                      `json:"partner_id"`
+	PartnerName       string                      `json:"partner_name"`
+	Method            string                      `json:"method"`
+	PaymentDate       string                      `json:"payment_date"`
+	Currency          string                      `json:"currency"`
+	ExchangeRate      string                      `json:"exchange_rate"`
+	Amount            string                      `json:"amount"`
+	AmountBase        string                      `json:"amount_base"`
+	WithheldAmount    string                      `json:"withheld_amount"`
+	TransferAmount    string                      `json:"transfer_amount"` // amount less withheld_amount: what actually changes hands
+	AllocatedAmount   string                      `json:"allocated_amount"`
+	UnallocatedAmount string                      `json:"unallocated_amount"`
+	Reference         string                      `json:"reference"`
+	Note              string                      `json:"note"`
+	PaymentRunID      *string                     `json:"payment_run_id"`
+	CreatedBy         *string                     `json:"created_by"`
+	CreatedAt         string                      `json:"created_at"`
+	Allocations       []PaymentAllocationResponse `json:"allocations,omitempty"`
+}
+
+type InvoiceBalanceResponse struct {
+	InvoiceID         string `json:"invoice_id"`
+	InvoiceNo         string `json:"invoice_no"`
+	ReferenceType     string `json:"reference_type"`
+	ReferenceID       string `json:"reference_id"`
+	InvoiceDate       string `json:"invoice_date"`
+	DueDate           string `json:"due_date"`
+	NetAmount         string `json:"net_amount"` // Total after approved credit and debit notes
+	PaidAmount        string `json:"paid_amount"`
+	OutstandingAmount string `json:"outstanding_amount"`
+	PaymentStatus     string `json:"payment_status"`
+	// Payments are allocated in the invoice currency, against doc_outstanding_amount
+	Currency             string `json:"currency"`
+	DocOutstandingAmount string `json:"doc_outstanding_amount"`
+}
+
+// PartnerBalanceResponse is the open balance of a partner in the base currency. Balance is the outstanding
+// invoice amount less payments held on account.
+type PartnerBalanceResponse struct {
+	PartnerID         string `json:"partner_id"`
+	PartnerName       string `json:"partner_name"`
+	InvoiceCount      int64  `json:"invoice_count"`
+	NetAmount         string `json:"net_amount"`
+	PaidAmount        string `json:"paid_amount"`
+	OutstandingAmount string `json:"outstanding_amount"`
+	UnallocatedAmount string `json:"unallocated_amount"`
+	Balance           string `json:"balance"`
+}
+
+// PartnerStatementResponse lists the open invoices and unallocated payments behind a balance
+type PartnerStatementResponse struct {
+	PartnerBalanceResponse
+	Invoices []InvoiceBalanceResponse `json:"invoices"`
+	Payments []PaymentResponse        `json:"payments"`
+}
+
+// --- Interface ---
+
+type PaymentService interface {
+	CreateReceipt(ctx context.Context, userID string, req CreatePaymentRequest) (PaymentResponse, error)
+	AllocatePayment(ctx context.Context, id string, userID string, req AllocatePaymentRequest) (PaymentResponse, error)
+	ListPayments(ctx context.Context, filter PaymentFilter) ([]PaymentResponse, int64, error)
+	GetPayment(ctx context.Context, id string) (PaymentResponse, error)
+	ListReceivables(ctx context.Context) ([]PartnerBalanceResponse, error)
+	GetPartnerReceivables(ctx context.Context, partnerID string) (PartnerStatementResponse, error)
+
+	CreateDisbursement(ctx context.Context, userID string, req CreatePaymentRequest) (PaymentResponse, error)
+	ListPayables(ctx context.Context) ([]PartnerBalanceResponse, error)
+	GetPartnerPayables(ctx context.Context, partnerID string) (PartnerStatementResponse, error)
+
+	CreatePaymentRun(ctx context.Context, userID string, req CreatePaymentRunRequest) (PaymentRunResponse, error)
+	ListPaymentRuns(ctx context.Context, status string, page, limit int) ([]PaymentRunResponse, int64, error)
+	GetPaymentRun(ctx context.Context, id string) (PaymentRunResponse, error)
+	ConfirmPaymentRun(ctx context.Context, id string, userID string) (PaymentRunResponse, error)
+	CancelPaymentRun(ctx context.Context, id string, userID string) (PaymentRunResponse, error)
+	ExportPaymentRunFile(ctx context.Context, id string) ([]byte, string, error)
+}
+
+type paymentService struct {
+	paymentRepo  repository.PaymentRepository
+	invoiceRepo  repository.InvoiceRepository
+	expenseRepo  repository.ExpenseRepository
+	partnerRepo  repository.PartnerRepository
+	sequenceRepo repository.DocumentSequenceRepository
+	auditRepo    repository.AuditRepository
+	ledgerRepo   repository.LedgerRepository
+	periodRepo   repository.FiscalPeriodRepository
+	rateRepo     repository.ExchangeRateRepository
+	txManager    repository.TransactionManager
+}
+
+func NewPaymentService(
+	paymentRepo repository.PaymentRepository,
+	invoiceRepo repository.InvoiceRepository,
+	expenseRepo repository.ExpenseRepository,
+	partnerRepo repository.PartnerRepository,
+	sequenceRepo repository.DocumentSequenceRepository,
+	auditRepo repository.AuditRepository,
+	ledgerRepo repository.LedgerRepository,
+	periodRepo repository.FiscalPeriodRepository,
+	rateRepo repository.ExchangeRateRepository,
+	txManager repository.TransactionManager,
+) PaymentService {
+	return &paymentService{
+		paymentRepo:  paymentRepo,
+		invoiceRepo:  invoiceRepo,
+		expenseRepo:  expenseRepo,
+		partnerRepo:  partnerRepo,
+		sequenceRepo: sequenceRepo,
+		auditRepo:    auditRepo,
+		ledgerRepo:   ledgerRepo,
+		periodRepo:   periodRepo,
+		rateRepo:     rateRepo,
+		txManager:    txManager,
+	}
+}
+
+// paymentReferenceTypes lists the invoices a payment type can be allocated to
+var paymentReferenceTypes = map[string][]string{
+	model.PaymentTypeReceipt:      {model.RefTypeOrderExport},
+	model.PaymentTypeDisbursement: {model.RefTypeOrderImport, model.RefTypeExpense},
+}
+
+// paymentSequences maps payment types to the sequence numbering them
+var paymentSequences = map[string]string{
+	model.PaymentTypeReceipt:      model.SequenceReceipt,
+	model.PaymentTypeDisbursement: model.SequenceDisbursement,
+}
+
+// --- Implementation ---
+
+// CreateReceipt records money received from a customer and optionally allocates it to the
+// customer's sales invoices
+func (s *paymentService) CreateReceipt(ctx context.Context, userID string, req CreatePaymentRequest) (PaymentResponse, error) {
+	return s.createPayment(ctx, model.PaymentTypeReceipt, userID, req)
+}
+
+// CreateDisbursement records money paid to a supplier and optionally allocates it to the
+// supplier's purchase and expense invoices
+func (s *paymentService) CreateDisbursement(ctx context.Context, userID string, req CreatePaymentRequest) (PaymentResponse, error) {
+	return s.createPayment(ctx, model.PaymentTypeDisbursement, userID, req)
+}
+
+func (s *paymentService) createPayment(ctx context.Context, paymentType string, userID string, req CreatePaymentRequest) (PaymentResponse, error) {
+	partnerID, err := uuid.Parse(req.PartnerID)
+	if err != nil {
+		return PaymentResponse{}, fmt.Errorf("invalid partner_id: %w", err)
+	}
+	partner, err := s.partnerRepo.FindByID(ctx, partnerID)
+	if err != nil {
+		return PaymentResponse{}, fmt.Errorf("partner not found: %w", err)
+	}
+
+	paymentDate, err := time.Parse("2006-01-02", req.PaymentDate)
+	if err != nil {
+		return PaymentResponse{}, fmt.Errorf("invalid payment_date, expected YYYY-MM-DD: %w", err)
+	}
+
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil || !amount.IsPositive() {
+		return PaymentResponse{}, errors.New("amount must be greater than 0")
+	}
+
+	withheld := decimal.Zero
+	if req.Withheld != "" {
+		if paymentType != model.PaymentTypeDisbursement {
+			return PaymentResponse{}, errors.New("withheld_amount only applies to disbursements")
+		}
+		withheld, err = decimal.NewFromString(req.Withheld)
+		if err != nil || withheld.IsNegative() || withheld.GreaterThan(amount) {
+			return PaymentResponse{}, errors.New("withheld_amount must be between 0 and amount")
+		}
+	}
+
+	currency := strings.ToUpper(req.Currency)
+	exchangeRate := decimal.NewFromInt(1)
+	if req.ExchangeRate != "" {
+		exchangeRate, err = decimal.NewFromString(req.ExchangeRate)
+		if err != nil {
+			return PaymentResponse{}, fmt.Errorf("invalid exchange_rate: %w", err)
+		}
+	} else if currency != baseCurrency {
+		return PaymentResponse{}, fmt.Errorf("exchange_rate is required for %s payments", currency)
+	}
+	if !exchangeRate.IsPositive() {
+		return PaymentResponse{}, errors.New("exchange_rate must be greater than 0")
+	}
+	if currency == baseCurrency && !exchangeRate.Equal(decimal.NewFromInt(1)) {
+		return PaymentResponse{}, fmt.Errorf("exchange_rate must be 1 for %s payments", baseCurrency)
+	}
+
+	payment := &model.Payment{
+		PaymentType:    paymentType,
+		PartnerID:      partner.ID,
+		Method:         req.Method,
+		PaymentDate:    paymentDate,
+		Currency:       currency,
+		ExchangeRate:   exchangeRate,
+		Amount:         amount,
+		AmountBase:     roundBase(amount.Mul(exchangeRate)),
+		WithheldAmount: withheld,
+		Reference:      req.Reference,
+		Note:           req.Note,
+		CreatedBy:      parseOptionalUUID(userID),
+	}
+
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		if err := checkPeriodOpen(txCtx, s.periodRepo, payment.PaymentDate, false); err != nil {
+			return err
+		}
+		return s.recordPayment(txCtx, payment, userID, req.Allocations)
+	})
+	if err != nil {
+		return PaymentResponse{}, err
+	}
+
+	return s.GetPayment(ctx, payment.ID.String())
+}
+
+// recordPayment numbers and stores a payment and applies its allocations. It runs inside a
+// transaction so a failed allocation gives the number back.
+func (s *paymentService) recordPayment(ctx context.Context, payment *model.Payment, userID string, allocations []PaymentAllocationRequest) error {
+	// Payments are numbered in the cash book of the year they are dated in
+	paymentNo, err := nextDocumentNo(ctx, s.sequenceRepo, paymentSequences[payment.PaymentType], payment.PaymentDate)
+	if err != nil {
+		return fmt.Errorf("failed to generate payment number: %w", err)
+	}
+	payment.PaymentNo = paymentNo
+
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+		return fmt.Errorf("failed to create payment: %w", err)
+	}
+
+	details := map[string]interface{}{
+		"payment_type":  payment.PaymentType,
+		"partner_id":    payment.PartnerID.String(),
+		"method":        payment.Method,
+		"payment_date":  payment.PaymentDate.Format("2006-01-02"),
+		"currency":      payment.Currency,
+		"exchange_rate": payment.ExchangeRate.String(),
+		"amount":        payment.Amount.StringFixed(4),
+		"amount_base":   payment.AmountBase.StringFixed(4),
+	}
+	if payment.WithheldAmount.IsPositive() {
+		details["withheld_amount"] = payment.WithheldAmount.StringFixed(4)
+	}
+	if payment.PaymentRunID != nil {
+		details["payment_run_id"] = payment.PaymentRunID.String()
+	}
+	if err := s.auditRepo.Log(ctx, newAuditLog(userID, model.ActionCreatePayment, payment.ID.String(), payment.PaymentNo, details)); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	if err := postPaymentJournal(ctx, s.ledgerRepo, s.sequenceRepo, *payment, parseOptionalUUID(userID)); err != nil {
+		return err
+	}
+
+	if len(allocations) == 0 {
+		return nil
+	}
+	return s.allocate(ctx, payment, userID, allocations, payment.PaymentDate)
+}
+
+// AllocatePayment applies the unallocated part of a payment to invoices of the same partner
+func (s *paymentService) AllocatePayment(ctx context.Context, id string, userID string, req AllocatePaymentRequest) (PaymentResponse, error) {
+	paymentID, err := uuid.Parse(id)
+	if err != nil {
+		return PaymentResponse{}, fmt.Errorf("invalid payment id: %w", err)
+	}
+
+	err = s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		payment, err := s.paymentRepo.FindByIDForUpdate(txCtx, paymentID)
+		if err != nil {
+			return fmt.Errorf("payment not found: %w", err)
+		}
+		// The exchange difference is posted today, so today's period must be open
+		now := time.Now()
+		if err := checkPeriodOpen(txCtx, s.periodRepo, now, false); err != nil {
+			return err
+		}
+		return s.allocate(txCtx, payment, userID, req.Allocations, now)
+	})
+	if err != nil {
+		return PaymentResponse{}, err
+	}
+
+	return s.GetPayment(ctx, id)
+}
+
+// allocate runs inside a transaction holding the payment. Each invoice is locked so concurrent
+// payments and credit notes cannot take it below zero. Amounts are in the invoice currency; the
+// exchange difference between the invoice and payment rates is posted as realized at date at.
+func (s *paymentService) allocate(ctx context.Context, payment *model.Payment, userID string, reqs []PaymentAllocationRequest, at time.Time) error {
+	refTypes := paymentReferenceTypes[payment.PaymentType]
+	available := payment.AmountBase.Sub(payment.AllocatedAmount)
+
+	allocations := make([]model.PaymentAllocation, 0, len(reqs))
+	invoices := make([]*model.Invoice, 0, len(reqs))
+	details := make([]map[string]interface{}, 0, len(reqs))
+	total, fxTotal := decimal.Zero, decimal.Zero
+	seen := make(map[uuid.UUID]bool, len(reqs))
+	for i, r := range reqs {
+		invoiceID, err := uuid.Parse(r.InvoiceID)
+		if err != nil {
+			return fmt.Errorf("allocation %d: invalid invoice_id: %w", i+1, err)
+		}
+		if seen[invoiceID] {
+			return fmt.Errorf("allocation %d: invoice %s is listed more than once", i+1, r.InvoiceID)
+		}
+		seen[invoiceID] = true
+
+		amount, err := decimal.NewFromString(r.Amount)
+		if err != nil || !amount.IsPositive() {
+			return fmt.Errorf("allocation %d: amount must be greater than 0", i+1)
+		}
+
+		invoice, err := s.invoiceRepo.FindByIDForUpdate(ctx, invoiceID)
+		if err != nil {
+			return fmt.Errorf("allocation %d: invoice not found: %w", i+1, err)
+		}
+		if err := checkAllocatableInvoice(*invoice, payment, refTypes); err != nil {
+			return fmt.Errorf("allocation %d: %w", i+1, err)
+		}
+
+		outstanding, docOutstanding, err := invoiceOutstanding(ctx, s.invoiceRepo, s.paymentRepo, *invoice)
+		if err != nil {
+			return err
+		}
+		if amount.GreaterThan(docOutstanding) {
+			return fmt.Errorf("allocation %d: %s %s exceeds the outstanding amount %s of invoice %s",
+				i+1, amount.StringFixed(4), invoice.Currency, docOutstanding.StringFixed(4), invoice.InvoiceNo)
+		}
+
+		// The base amount cleared from the invoice is at its booked rate; settling the whole balance
+		// clears what is left so rounding never leaves cents open
+		cleared := amount
+		if invoice.Currency != baseCurrency {
+			cleared = roundBase(amount.Mul(invoice.ExchangeRate))
+			if amount.Equal(docOutstanding) || cleared.GreaterThan(outstanding) {
+				cleared = outstanding
+			}
+		}
+		paymentBase, err := allocationPaymentBase(ctx, s.rateRepo, payment, *invoice, amount, cleared)
+		if err != nil {
+			return fmt.Errorf("allocation %d: %w", i+1, err)
+		}
+		// Receiving more than was booked, or paying less, is a gain
+		fx := paymentBase.Sub(cleared)
+		if payment.PaymentType == model.PaymentTypeDisbursement {
+			fx = fx.Neg()
+		}
+
+		total = total.Add(paymentBase)
+		fxTotal = fxTotal.Add(fx)
+		invoices = append(invoices, invoice)
+		allocations = append(allocations, model.PaymentAllocation{
+			PaymentID:         payment.ID,
+			InvoiceID:         invoice.ID,
+			Amount:            cleared,
+			DocAmount:         amount,
+			PaymentAmountBase: paymentBase,
+			FXGainLoss:        fx,
+			AllocatedAt:       at,
+			CreatedBy:         parseOptionalUUID(userID),
+		})
+		detail := map[string]interface{}{
+			"invoice_id": invoice.ID.String(),
+			"invoice_no": invoice.InvoiceNo,
+			"amount":     cleared.StringFixed(4),
+		}
+		if invoice.Currency != baseCurrency {
+			detail["currency"] = invoice.Currency
+			detail["doc_amount"] = amount.StringFixed(4)
+			detail["payment_amount_base"] = paymentBase.StringFixed(4)
+			detail["fx_gain_loss"] = fx.StringFixed(4)
+		}
+		details = append(details, detail)
+	}
+	if total.GreaterThan(available) {
+		return fmt.Errorf("allocations of %s exceed the unallocated amount %s of payment %s",
+			total.StringFixed(4), available.StringFixed(4), payment.PaymentNo)
+	}
+
+	if err := s.paymentRepo.CreateAllocations(ctx, allocations); err != nil {
+		return fmt.Errorf("failed to create allocations: %w", err)
+	}
+	payment.AllocatedAmount = payment.AllocatedAmount.Add(total)
+	if err := s.paymentRepo.Update(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	for _, invoice := range invoices {
+		if err := refreshInvoicePaymentStatus(ctx, s.invoiceRepo, s.paymentRepo, invoice); err != nil {
+			return err
+		}
+	}
+
+	if err := postExchangeDifferenceJournal(ctx, s.ledgerRepo, s.sequenceRepo, *payment, fxTotal, at, parseOptionalUUID(userID)); err != nil {
+		return err
+	}
+
+	auditDetails := map[string]interface{}{
+		"allocations":      details,
+		"total":            total.StringFixed(4),
+		"allocated_amount": payment.AllocatedAmount.StringFixed(4),
+	}
+	if !fxTotal.IsZero() {
+		auditDetails["fx_gain_loss"] = fxTotal.StringFixed(4)
+	}
+	if err := s.auditRepo.Log(ctx, newAuditLog(userID, model.ActionAllocatePayment, payment.ID.String(), payment.PaymentNo, auditDetails)); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+func (s *paymentService) ListPayments(ctx context.Context, filter PaymentFilter) ([]PaymentResponse, int64, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+
+	repoFilter := repository.PaymentListFilter{
+		PaymentType: filter.PaymentType,
+		Unallocated: filter.Unallocated,
+		Page:        filter.Page,
+		Limit:       filter.Limit,
+	}
+	if filter.PartnerID != "" {
+		partnerID, err := uuid.Parse(filter.PartnerID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid partner_id: %w", err)
+		}
+		repoFilter.PartnerID = &partnerID
+	}
+	if filter.DateFrom != "" {
+		from, err := time.Parse("2006-01-02", filter.DateFrom)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid date_from, expected YYYY-MM-DD: %w", err)
+		}
+		repoFilter.DateFrom = &from
+	}
+	if filter.DateTo != "" {
+		to, err := time.Parse("2006-01-02", filter.DateTo)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid date_to, expected YYYY-MM-DD: %w", err)
+		}
+		repoFilter.DateTo = &to
+	}
+
+	payments, total, err := s.paymentRepo.List(ctx, repoFilter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch payments: %w", err)
+	}
+
+	result := make([]PaymentResponse, 0, len(payments))
+	for _, p := range payments {
+		result = append(result, toPaymentResponse(p))
+	}
+	return result, total, nil
+}
+
+func (s *paymentService) GetPayment(ctx context.Context, id string) (PaymentResponse, error) {
+	paymentID, err := uuid.Parse(id)
+	if err != nil {
+		return PaymentResponse{}, fmt.Errorf("invalid payment id: %w", err)
+	}
+
+	payment, err := s.paymentRepo.FindByID(ctx, paymentID)
+	if err != nil {
+		return PaymentResponse{}, fmt.Errorf("payment not found: %w", err)
+	}
+	return toPaymentResponse(*payment), nil
+}
+
+// ListReceivables returns the customers with an open balance, largest outstanding first
+func (s *paymentService) ListReceivables(ctx context.Context) ([]PartnerBalanceResponse, error) {
+	return s.partnerBalances(ctx, model.PaymentTypeReceipt)
+}
+
+// GetPartnerReceivables returns the open sales invoices and unallocated receipts of a customer
+func (s *paymentService) GetPartnerReceivables(ctx context.Context, partnerID string) (PartnerStatementResponse, error) {
+	return s.partnerStatement(ctx, model.PaymentTypeReceipt, partnerID)
+}
+
+// ListPayables returns the suppliers with an open balance, largest outstanding first
+func (s *paymentService) ListPayables(ctx context.Context) ([]PartnerBalanceResponse, error) {
+	return s.partnerBalances(ctx, model.PaymentTypeDisbursement)
+}
+
+// GetPartnerPayables returns the open purchase and expense invoices and unallocated
+// disbursements of a supplier
+func (s *paymentService) GetPartnerPayables(ctx context.Context, partnerID string) (PartnerStatementResponse, error) {
+	return s.partnerStatement(ctx, model.PaymentTypeDisbursement, partnerID)
+}
+
+func (s *paymentService) partnerBalances(ctx context.Context, paymentType string) ([]PartnerBalanceResponse, error) {
+	balances, err := s.paymentRepo.ListPartnerBalances(ctx, paymentType, paymentReferenceTypes[paymentType])
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch partner balances: %w", err)
+	}
+
+	result := make([]PartnerBalanceResponse, 0, len(balances))
+	for _, b := range balances {
+		result = append(result, toPartnerBalanceResponse(b))
+	}
+	return result, nil
+}
+
+func (s *paymentService) partnerStatement(ctx context.Context, paymentType string, id string) (PartnerStatementResponse, error) {
+	partnerID, err := uuid.Parse(id)
+	if err != nil {
+		return PartnerStatementResponse{}, fmt.Errorf("invalid partner id: %w", err)
+	}
+	partner, err := s.partnerRepo.FindByID(ctx, partnerID)
+	if err != nil {
+		return PartnerStatementResponse{}, fmt.Errorf("partner not found: %w", err)
+	}
+
+	invoices, err := s.paymentRepo.ListInvoiceBalances(ctx, repository.InvoiceBalanceFilter{
+		RefTypes:  paymentReferenceTypes[paymentType],
+		PartnerID: &partnerID,
+		OpenOnly:  true,
+	})
+	if err != nil {
+		return PartnerStatementResponse{}, fmt.Errorf("failed to fetch invoice balances: %w", err)
+	}
+	payments, err := s.paymentRepo.ListUnallocated(ctx, paymentType, partnerID)
+	if err != nil {
+		return PartnerStatementResponse{}, fmt.Errorf("failed to fetch unallocated payments: %w", err)
+	}
+
+	summary := repository.PartnerBalance{
+		PartnerID:    partner.ID,
+		PartnerName:  partner.Name,
+		InvoiceCount: int64(len(invoices)),
+	}
+	resp := PartnerStatementResponse{
+		Invoices: make([]InvoiceBalanceResponse, 0, len(invoices)),
+		Payments: make([]PaymentResponse, 0, len(payments)),
+	}
+	for _, inv := range invoices {
+		summary.NetAmount = summary.NetAmount.Add(inv.NetAmount)
+		summary.PaidAmount = summary.PaidAmount.Add(inv.PaidAmount)
+		summary.OutstandingAmount = summary.OutstandingAmount.Add(inv.OutstandingAmount)
+		resp.Invoices = append(resp.Invoices, toInvoiceBalanceResponse(inv))
+	}
+	for _, p := range payments {
+		p.Partner = partner
+		summary.UnallocatedAmount = summary.UnallocatedAmount.Add(p.AmountBase.Sub(p.AllocatedAmount))
+		resp.Payments = append(resp.Payments, toPaymentResponse(p))
+	}
+	resp.PartnerBalanceResponse = toPartnerBalanceResponse(summary)
+
+	return resp, nil
+}
+
+// --- Helpers ---
+
+// invoiceDueDate is the approval date plus the payment terms of the partner; invoices without
+// a partner fall due on approval
+func invoiceDueDate(partner *model.Partner, approvedAt time.Time) *time.Time {
+	due := approvedAt
+	if partner != nil {
+		due = approvedAt.AddDate(0, 0, partner.PaymentTermDays)
+	}
+	return &due
+}
+
+// checkAllocatableInvoice accepts approved invoices in force of the payment's partner; notes
+// are settled through the invoice they adjust
+func checkAllocatableInvoice(invoice model.Invoice, payment *model.Payment, refTypes []string) error {
+	if invoice.ApprovalStatus != model.ApprovalApproved {
+		return fmt.Errorf("invoice %s is %s; only approved invoices can be paid", invoice.InvoiceNo, invoice.ApprovalStatus)
+	}
+	if invoice.VoidedAt != nil {
+		return fmt.Errorf("invoice %s has been voided", invoice.InvoiceNo)
+	}
+	if invoice.InvoiceType != model.InvoiceTypeStandard && invoice.InvoiceType != model.InvoiceTypeReplacement {
+		return fmt.Errorf("invoice %s is a %s; allocate to the invoice it adjusts", invoice.InvoiceNo, invoice.InvoiceType)
+	}
+	matches := false
+	for _, t := range refTypes {
+		if invoice.ReferenceType == t {
+			matches = true
+			break
+		}
+	}
+	if !matches {
+		return fmt.Errorf("invoice %s is a %s invoice and cannot be settled by a %s", invoice.InvoiceNo, invoice.ReferenceType, payment.PaymentType)
+	}
+	if invoice.PartnerID == nil || *invoice.PartnerID != payment.PartnerID {
+		return fmt.Errorf("invoice %s belongs to another partner", invoice.InvoiceNo)
+	}
+	return nil
+}
+
+// invoiceOutstanding is the net invoice total (after approved notes) less the amount allocated,
+// in the base currency and in the invoice currency
+func invoiceOutstanding(ctx context.Context, invoiceRepo repository.InvoiceRepository, paymentRepo repository.PaymentRepository, invoice model.Invoice) (decimal.Decimal, decimal.Decimal, error) {
+	adjustments, err := invoiceRepo.ListAdjustments(ctx, invoice.ID)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("failed to fetch invoice adjustments: %w", err)
+	}
+	paid, err := paymentRepo.SumAllocatedByInvoice(ctx, invoice.ID)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("failed to sum invoice payments: %w", err)
+	}
+	docPaid, err := paymentRepo.SumAllocatedDocByInvoice(ctx, invoice.ID)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("failed to sum invoice payments: %w", err)
+	}
+	return netInvoiceTotal(invoice, adjustments).Sub(paid), netInvoiceDocTotal(invoice, adjustments).Sub(docPaid), nil
+}
+
+// refreshInvoicePaymentStatus recomputes the paid amount and payment status of an invoice from
+// its allocations and approved notes. An invoice is PAID once nothing is left outstanding in its
+// own currency, which includes invoices credited down to what was already paid.
+func refreshInvoicePaymentStatus(ctx context.Context, invoiceRepo repository.InvoiceRepository, paymentRepo repository.PaymentRepository, invoice *model.Invoice) error {
+	adjustments, err := invoiceRepo.ListAdjustments(ctx, invoice.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch invoice adjustments: %w", err)
+	}
+	paid, err := paymentRepo.SumAllocatedByInvoice(ctx, invoice.ID)
+	if err != nil {
+		return fmt.Errorf("failed to sum invoice payments: %w", err)
+	}
+	docPaid, err := paymentRepo.SumAllocatedDocByInvoice(ctx, invoice.ID)
+	if err != nil {
+		return fmt.Errorf("failed to sum invoice payments: %w", err)
+	}
+	outstanding := netInvoiceDocTotal(*invoice, adjustments).Sub(docPaid)
+
+	invoice.PaidAmount = paid
+	invoice.DocPaidAmount = docPaid
+	switch {
+	case !outstanding.IsPositive():
+		invoice.PaymentStatus = model.PaymentStatusPaid
+	case docPaid.IsPositive():
+		invoice.PaymentStatus = model.PaymentStatusPartial
+	default:
+		invoice.PaymentStatus = model.PaymentStatusUnpaid
+	}
+
+	if err := invoiceRepo.Update(ctx, invoice); err != nil {
+		return fmt.Errorf("failed to update invoice payment status: %w", err)
+	}
+	return nil
+}
+
+// allocationPaymentBase is the base amount a payment gives up to settle amount of an invoice (in
+// the invoice currency) clearing cleared of its base amount. A payment in the invoice currency
+// converts at its own rate and a base-currency payment at the rate table of the payment date.
+func allocationPaymentBase(ctx context.Context, rateRepo repository.ExchangeRateRepository, payment *model.Payment, invoice model.Invoice, amount, cleared decimal.Decimal) (decimal.Decimal, error) {
+	switch {
+	case invoice.Currency == baseCurrency:
+		return cleared, nil
+	case payment.Currency == invoice.Currency:
+		return roundBase(amount.Mul(payment.ExchangeRate)), nil
+	case payment.Currency == baseCurrency:
+		rate, err := findExchangeRate(ctx, rateRepo, invoice.Currency, payment.PaymentDate)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("cannot settle %s invoice %s from a %s payment: %w", invoice.Currency, invoice.InvoiceNo, baseCurrency, err)
+		}
+		return roundBase(amount.Mul(rate.Rate)), nil
+	default:
+		return decimal.Zero, fmt.Errorf("invoice %s is in %s and cannot be settled by a %s payment", invoice.InvoiceNo, invoice.Currency, payment.Currency)
+	}
+}
+
+// --- Mapping ---
+
+func toPaymentResponse(p model.Payment) PaymentResponse {
+	resp := PaymentResponse{
+		ID:                p.ID.String(),
+		PaymentNo:         p.PaymentNo,
+		PaymentType:       p.PaymentType,
+		PartnerID:         p.PartnerID.String(),
+		Method:            p.Method,
+		PaymentDate:       p.PaymentDate.Format("2006-01-02"),
+		Currency:          p.Currency,
+		ExchangeRate:      p.ExchangeRate.String(),
+		Amount:            p.Amount.StringFixed(4),
+		AmountBase:        p.AmountBase.StringFixed(4),
+		WithheldAmount:    p.WithheldAmount.StringFixed(4),
+		TransferAmount:    p.Amount.Sub(p.WithheldAmount).StringFixed(4),
+		AllocatedAmount:   p.AllocatedAmount.StringFixed(4),
+		UnallocatedAmount: p.AmountBase.Sub(p.AllocatedAmount).StringFixed(4),
+		Reference:         p.Reference,
+		Note:              p.Note,
+		CreatedAt:         p.CreatedAt.Format(time.RFC3339),
+	}
+	if p.Partner != nil {
+		resp.PartnerName = p.Partner.Name
+	}
+	if p.PaymentRunID != nil {
+		s := p.PaymentRunID.String()
+		resp.PaymentRunID = &s
+	}
+	if p.CreatedBy != nil {
+		s := p.CreatedBy.String()
+		resp.CreatedBy = &s
+	}
+	for _, a := range p.Allocations {
+		ar := PaymentAllocationResponse{
+			ID:                a.ID.String(),
+			InvoiceID:         a.InvoiceID.String(),
+			Amount:            a.Amount.StringFixed(4),
+			DocAmount:         a.DocAmount.StringFixed(4),
+			PaymentAmountBase: a.PaymentAmountBase.StringFixed(4),
+			FXGainLoss:        a.FXGainLoss.StringFixed(4),
+			CreatedAt:         a.CreatedAt.Format(time.RFC3339),
+		}
+		if a.Invoice != nil {
+			ar.InvoiceNo = a.Invoice.InvoiceNo
+			ar.Currency = a.Invoice.Currency
+		}
+		resp.Allocations = append(resp.Allocations, ar)
+	}
+	return resp
+}
+
+func toInvoiceBalanceResponse(b repository.InvoiceBalance) InvoiceBalanceResponse {
+	return InvoiceBalanceResponse{
+		InvoiceID:            b.InvoiceID.String(),
+		InvoiceNo:            b.InvoiceNo,
+		ReferenceType:        b.ReferenceType,
+		ReferenceID:          b.ReferenceID.String(),
+		InvoiceDate:          b.InvoiceDate.Format(time.RFC3339),
+		DueDate:              b.DueDate.Format("2006-01-02"),
+		NetAmount:            b.NetAmount.StringFixed(4),
+		PaidAmount:           b.PaidAmount.StringFixed(4),
+		OutstandingAmount:    b.OutstandingAmount.StringFixed(4),
+		PaymentStatus:        b.PaymentStatus,
+		Currency:             b.Currency,
+		DocOutstandingAmount: b.DocOutstandingAmount.StringFixed(4),
+	}
+}
+
+func toPartnerBalanceResponse(b repository.PartnerBalance) PartnerBalanceResponse {
+	return PartnerBalanceResponse{
+		PartnerID:         b.PartnerID.String(),
+		PartnerName:       b.PartnerName,
+		InvoiceCount:      b.InvoiceCount,
+		NetAmount:         b.NetAmount.StringFixed(4),
+		PaidAmount:        b.PaidAmount.StringFixed(4),
+		OutstandingAmount: b.OutstandingAmount.StringFixed(4),
+		UnallocatedAmount: b.UnallocatedAmount.StringFixed(4),
+		Balance:           b.OutstandingAmount.Sub(b.UnallocatedAmount).StringFixed(4),
+	}
+}
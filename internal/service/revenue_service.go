@@ -3,9 +3,12 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"backend/internal/model"
 	"backend/internal/repository"
+
+	"github.com/shopspring/decimal"
 )
 
 // --- DTOs ---
@@ -17,18 +20,24 @@ type RevenueDataPoint struct {
 	TotalTaxCollected string `json:"total_tax_collected"`
 	TotalTaxPaid      string `json:"total_tax_paid"`
 	TotalSideFees     string `json:"total_side_fees"`
+	Currency          string `json:"currency"`             // Reporting currency of the amounts
+	UnconvertedCount  int64  `json:"unconverted_invoices"` // Invoices left out for lack of an exchange rate
 }
 
 type RevenueFilter struct {
 	GroupBy   string // week, month, quarter
 	StartDate string // RFC3339
 	EndDate   string // RFC3339
+	Currency  string // Reporting currency, default the base currency
 }
 
 // --- Interface ---
 
 type RevenueService interface {
 	GetRevenueStatistics(ctx context.Context, filter RevenueFilter) ([]RevenueDataPoint, error)
+	GetAgingReport(ctx context.Context, filter AgingFilter) (AgingReportResponse, error)
+	GetAgingInvoices(ctx context.Context, filter AgingFilter) ([]AgingInvoiceResponse, error)
+	ExportAgingReport(ctx context.Context, filter AgingFilter, format string, detail bool) ([]byte, string, string, error)
 }
 
 type revenueService struct {
@@ -50,23 +59,38 @@ func (s *revenueService) GetRevenueStatistics(ctx context.Context, filter Revenu
 		groupBy = "month"
 	}
 
+	currency := strings.ToUpper(strings.TrimSpace(filter.Currency))
+	if currency == "" {
+		currency = baseCurrency
+	}
+	if !currencyCodePattern.MatchString(currency) {
+		return nil, fmt.Errorf("currency must be a 3-letter ISO 4217 code")
+	}
+
 	rows, err := s.revenueRepo.GetRevenueStatistics(ctx,
 		groupBy, filter.StartDate, filter.EndDate,
 		model.RefTypeOrderExport, model.RefTypeOrderImport, model.RefTypeExpense, model.ApprovalApproved,
+		currency, baseCurrency,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	// Amounts are shown to the decimals of the reporting currency, whole units for VND
+	scale := model.CurrencyScale(currency)
+	amount := func(v float64) string { return decimal.NewFromFloat(v).StringFixed(scale) }
+
 	result := make([]RevenueDataPoint, 0, len(rows))
 	for _, r := range rows {
 		result = append(result, RevenueDataPoint{
 			Period:            r.Period,
-			TotalRevenue:      fmt.Sprintf("%.4f", r.TotalRevenue),
-			TotalExpense:      fmt.Sprintf("%.4f", r.TotalExpense),
-			TotalTaxCollected: fmt.Sprintf("%.4f", r.TotalTaxCollected),
-			TotalTaxPaid:      fmt.Sprintf("%.4f", r.TotalTaxPaid),
-			TotalSideFees:     fmt.Sprintf("%.4f", r.TotalSideFees),
+			TotalRevenue:      amount(r.TotalRevenue),
+			TotalExpense:      amount(r.TotalExpense),
+			TotalTaxCollected: amount(r.TotalTaxCollected),
+			TotalTaxPaid:      amount(r.TotalTaxPaid),
+			TotalSideFees:     amount(r.TotalSideFees),
+			Currency:          currency,
+			UnconvertedCount:  r.UnconvertedCount,
 		})
 	}
 
@@ -248,10 +248,30 @@ func (s *roleService) SeedDefaultRolesAndPermissions(ctx context.Context) error
 		{Code: "invoices.write", Name: "Tạo Hóa đơn", Group: "invoices"},
 		{Code: "approvals.read", Name: "Xem Yêu cầu duyệt", Group: "approvals"},
 		{Code: "approvals.approve", Name: "Duyệt / Từ chối yêu cầu", Group: "approvals"},
+		{Code: "credit.override", Name: "Duyệt vượt hạn mức tín dụng", Group: "approvals"},
 		{Code: "finance.read", Name: "Xem Báo cáo Tài chính", Group: "finance"},
 		{Code: "partners.read", Name: "Xem Đối tác", Group: "partners"},
 		{Code: "partners.write", Name: "Quản lý Đối tác", Group: "partners"},
 		{Code: "partners.delete", Name: "Xóa Đối tác", Group: "partners"},
+		{Code: "fulfillment.read", Name: "Xem Soạn hàng & Đóng gói", Group: "fulfillment"},
+		{Code: "fulfillment.write", Name: "Soạn hàng, Đóng gói & Xuất giao", Group: "fulfillment"},
+		{Code: "delivery.read", Name: "Xem Xe & Lập tuyến giao hàng", Group: "delivery"},
+		{Code: "delivery.write", Name: "Quản lý Xe giao hàng", Group: "delivery"},
+		{Code: "costing.read", Name: "Xem Giá vốn & Định giá tồn kho", Group: "costing"},
+		{Code: "costing.write", Name: "Phân bổ Chi phí nhập hàng (Landed cost)", Group: "costing"},
+		{Code: "customs.read", Name: "Xem Tờ khai hải quan", Group: "customs"},
+		{Code: "customs.write", Name: "Lập & Cập nhật Tờ khai hải quan", Group: "customs"},
+		{Code: "documents.manage", Name: "Cấu hình Mẫu in chứng từ", Group: "documents"},
+		{Code: "einvoices.issue", Name: "Phát hành Hóa đơn điện tử", Group: "invoices"},
+		{Code: "payments.read", Name: "Xem Thu/Chi & Công nợ", Group: "payments"},
+		{Code: "payments.write", Name: "Ghi nhận Thu/Chi tiền", Group: "payments"},
+		{Code: "payments.approve", Name: "Duyệt Đợt chi trả nhà cung cấp", Group: "payments"},
+		{Code: "ledger.read", Name: "Xem Sổ kế toán", Group: "ledger"},
+		{Code: "ledger.manage", Name: "Quản lý Hệ thống tài khoản", Group: "ledger"},
+		{Code: "periods.close", Name: "Khóa sổ kỳ kế toán", Group: "ledger"},
+		{Code: "periods.reopen", Name: "Mở lại kỳ kế toán đã khóa", Group: "ledger"},
+		{Code: "exchange_rates.read", Name: "Xem Tỷ giá", Group: "exchange_rates"},
+		{Code: "exchange_rates.write", Name: "Cập nhật & Nhập Tỷ giá", Group: "exchange_rates"},
 	}
 
 	// Upsert permissions
@@ -280,9 +300,18 @@ func (s *roleService) SeedDefaultRolesAndPermissions(ctx context.Context) error
 				"users.read", "users.write", "users.delete",
 				"audit.read", "roles.manage",
 				"invoices.read", "invoices.write",
-				"approvals.read", "approvals.approve",
+				"approvals.read", "approvals.approve", "credit.override",
 				"finance.read",
 				"partners.read", "partners.write", "partners.delete",
+				"fulfillment.read", "fulfillment.write",
+				"delivery.read", "delivery.write",
+				"costing.read", "costing.write",
+				"customs.read", "customs.write",
+				"documents.manage",
+				"einvoices.issue",
+				"payments.read", "payments.write", "payments.approve",
+				"ledger.read", "ledger.manage", "periods.close", "periods.reopen",
+				"exchange_rates.read", "exchange_rates.write",
 			},
 		},
 		"manager": {
@@ -294,9 +323,18 @@ func (s *roleService) SeedDefaultRolesAndPermissions(ctx context.Context) error
 				"users.read", "users.write",
 				"audit.read",
 				"invoices.read", "invoices.write",
-				"approvals.read", "approvals.approve",
+				"approvals.read", "approvals.approve", "credit.override",
 				"finance.read",
 				"partners.read", "partners.write",
+				"fulfillment.read", "fulfillment.write",
+				"delivery.read", "delivery.write",
+				"costing.read", "costing.write",
+				"customs.read", "customs.write",
+				"documents.manage",
+				"einvoices.issue",
+				"payments.read", "payments.write", "payments.approve",
+				"ledger.read", "periods.close",
+				"exchange_rates.read", "exchange_rates.write",
 			},
 		},
 		"staff": {
@@ -309,6 +347,11 @@ func (s *roleService) SeedDefaultRolesAndPermissions(ctx context.Context) error
 				"invoices.read",
 				"approvals.read",
 				"partners.read",
+				"fulfillment.read", "fulfillment.write",
+				"delivery.read",
+				"customs.read", "customs.write",
+				"payments.read",
+				"exchange_rates.read",
 			},
 		},
 	}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// --- DTOs ---
+
+type DocumentSequenceResponse struct {
+	DocType    string  `json:"doc_type"`
+	Year       int     `json:"year"`
+	Pattern    string  `json:"pattern"`
+	NextValue  int64   `json:"next_value"`
+	NextNumber string  `json:"next_number"` // The number the next document will get
+	UpdatedBy  *string `json:"updated_by"`
+	UpdatedAt  *string `json:"updated_at"` // Nil while the year has not been configured or used
+}
+
+// UpdateDocumentSequenceRequest configures the numbering of a document type for one year.
+// The start value can only be changed before the first number of the year is issued.
+type UpdateDocumentSequenceRequest struct {
+	Year      int    `json:"year" binding:"required,min=2000,max=9999"`
+	Pattern   string `json:"pattern" binding:"required,max=50"`
+	NextValue *int64 `json:"next_value" binding:"omitempty,min=1"`
+}
+
+// --- Interface ---
+
+type DocumentSequenceService interface {
+	ListSequences(ctx context.Context, year int) ([]DocumentSequenceResponse, error)
+	UpdateSequence(ctx context.Context, docType string, userID string, req UpdateDocumentSequenceRequest) (DocumentSequenceResponse, error)
+}
+
+type documentSequenceService struct {
+	sequenceRepo repository.DocumentSequenceRepository
+	auditRepo    repository.AuditRepository
+	txManager    repository.TransactionManager
+}
+
+func NewDocumentSequenceService(
+	sequenceRepo repository.DocumentSequenceRepository,
+	auditRepo repository.AuditRepository,
+	txManager repository.TransactionManager,
+) DocumentSequenceService {
+	return &documentSequenceService{
+		sequenceRepo: sequenceRepo,
+		auditRepo:    auditRepo,
+		txManager:    txManager,
+	}
+}
+
+// defaultSequencePatterns are used the first time a document type is numbered
+var defaultSequencePatterns = map[string]string{
+	model.SequenceInvoice:      "HD{YYYY}-{SEQ:4}",
+	model.SequencePickList:     "PL{YYYY}-{SEQ:5}",
+	model.SequencePackage:      "PK{YYYY}-{SEQ:5}",
+	model.SequenceReceipt:      "PT{YYYY}-{SEQ:4}",
+	model.SequenceDisbursement: "PC{YYYY}-{SEQ:4}",
+	model.SequencePaymentRun:   "DC{YYYY}-{SEQ:4}",
+	model.SequenceJournal:      "BT{YYYY}-{SEQ:5}",
+}
+
+// sequenceDocTypes lists the numbered document types in display order
+var sequenceDocTypes = []string{
+	model.SequenceInvoice, model.SequencePickList, model.SequencePackage,
+	model.SequenceReceipt, model.SequenceDisbursement, model.SequencePaymentRun,
+	model.SequenceJournal,
+}
+
+// --- Implementation ---
+
+// ListSequences returns the numbering of every document type for a year (the current year
+// when zero), including types that have not issued a number yet
+func (s *documentSequenceService) ListSequences(ctx context.Context, year int) ([]DocumentSequenceResponse, error) {
+	if year == 0 {
+		year = time.Now().Year()
+	}
+
+	seqs, err := s.sequenceRepo.ListByYear(ctx, year)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch document sequences: %w", err)
+	}
+	byType := make(map[string]model.DocumentSequence, len(seqs))
+	for _, seq := range seqs {
+		byType[seq.DocType] = seq
+	}
+
+	result := make([]DocumentSequenceResponse, 0, len(sequenceDocTypes))
+	for _, docType := range sequenceDocTypes {
+		seq, ok := byType[docType]
+		if !ok {
+			pattern, err := s.inheritedPattern(ctx, docType)
+			if err != nil {
+				return nil, err
+			}
+			seq = model.DocumentSequence{DocType: docType, Year: year, Pattern: pattern, NextValue: 1}
+		}
+		result = append(result, toDocumentSequenceResponse(seq))
+	}
+	return result, nil
+}
+
+func (s *documentSequenceService) UpdateSequence(ctx context.Context, docType string, userID string, req UpdateDocumentSequenceRequest) (DocumentSequenceResponse, error) {
+	if _, ok := defaultSequencePatterns[docType]; !ok {
+		return DocumentSequenceResponse{}, fmt.Errorf("unknown document type %q", docType)
+	}
+	pattern := strings.TrimSpace(req.Pattern)
+	if err := validateSequencePattern(pattern); err != nil {
+		return DocumentSequenceResponse{}, err
+	}
+	// The trailing digits of an invoice number become the e-invoice number (SHDon)
+	if docType == model.SequenceInvoice && !trailingSequenceToken.MatchString(pattern) {
+		return DocumentSequenceResponse{}, errors.New("invoice number patterns must end with {SEQ} or {SEQ:n}")
+	}
+
+	var seq *model.DocumentSequence
+	err := s.txManager.RunInTx(ctx, func(txCtx context.Context) error {
+		var err error
+		seq, err = lockDocumentSequence(txCtx, s.sequenceRepo, docType, req.Year)
+		if err != nil {
+			return err
+		}
+
+		before := *seq
+		if req.NextValue != nil && *req.NextValue != seq.NextValue {
+			if seq.NextValue > 1 {
+				return fmt.Errorf("%s numbers have already been issued for %d; the sequence can no longer be restarted", docType, req.Year)
+			}
+			seq.NextValue = *req.NextValue
+		}
+		// Numbers already issued under the old pattern would no longer match the new one, and
+		// the new pattern could produce them again
+		if pattern != before.Pattern && before.NextValue > 1 {
+			return fmt.Errorf("%s numbers have already been issued for %d; the pattern can no longer be changed", docType, req.Year)
+		}
+		if len(formatDocumentNo(pattern, req.Year, seq.NextValue)) > 30 {
+			return errors.New("pattern produces numbers longer than 30 characters")
+		}
+		seq.Pattern = pattern
+		seq.UpdatedBy = parseOptionalUUID(userID)
+
+		if err := s.sequenceRepo.Save(txCtx, seq); err != nil {
+			return fmt.Errorf("failed to save document sequence: %w", err)
+		}
+
+		return s.auditRepo.Log(txCtx, newAuditLog(userID, model.ActionUpdateDocumentSequence, seq.ID.String(), docType, map[string]interface{}{
+			"year":           seq.Year,
+			"old_pattern":    before.Pattern,
+			"new_pattern":    seq.Pattern,
+			"old_next_value": before.NextValue,
+			"new_next_value": seq.NextValue,
+		}))
+	})
+	if err != nil {
+		return DocumentSequenceResponse{}, err
+	}
+
+	return toDocumentSequenceResponse(*seq), nil
+}
+
+func (s *documentSequenceService) inheritedPattern(ctx context.Context, docType string) (string, error) {
+	latest, err := s.sequenceRepo.FindLatest(ctx, docType)
+	if err == nil {
+		return latest.Pattern, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultSequencePatterns[docType], nil
+	}
+	return "", fmt.Errorf("failed to fetch document sequence: %w", err)
+}
+
+// --- Allocation ---
+
+// nextDocumentNo allocates the next number of a document type for the year of `at`. It must be
+// called inside the transaction that stores the document: the sequence row stays locked until
+// that transaction ends, and a rollback gives the number back, so numbers have no gaps.
+func nextDocumentNo(ctx context.Context, sequenceRepo repository.DocumentSequenceRepository, docType string, at time.Time) (string, error) {
+	seq, err := lockDocumentSequence(ctx, sequenceRepo, docType, at.Year())
+	if err != nil {
+		return "", err
+	}
+
+	number := formatDocumentNo(seq.Pattern, seq.Year, seq.NextValue)
+	seq.NextValue++
+	if err := sequenceRepo.Save(ctx, seq); err != nil {
+		return "", fmt.Errorf("failed to advance %s sequence: %w", docType, err)
+	}
+	return number, nil
+}
+
+// lockDocumentSequence locks the sequence of a document type and year, creating it on first use
+// with the pattern of the previous year (or the default pattern)
+func lockDocumentSequence(ctx context.Context, sequenceRepo repository.DocumentSequenceRepository, docType string, year int) (*model.DocumentSequence, error) {
+	seq, err := sequenceRepo.FindForUpdate(ctx, docType, year)
+	if err == nil {
+		return seq, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to lock %s sequence: %w", docType, err)
+	}
+
+	pattern := defaultSequencePatterns[docType]
+	if latest, latestErr := sequenceRepo.FindLatest(ctx, docType); latestErr == nil {
+		pattern = latest.Pattern
+	} else if !errors.Is(latestErr, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to fetch %s sequence: %w", docType, latestErr)
+	}
+	if pattern == "" {
+		return nil, fmt.Errorf("no numbering pattern for document type %s", docType)
+	}
+
+	// A concurrent transaction may create the same row; the insert then does nothing and the
+	// lock below waits for that transaction
+	if err := sequenceRepo.CreateIfMissing(ctx, &model.DocumentSequence{DocType: docType, Year: year, Pattern: pattern, NextValue: 1}); err != nil {
+		return nil, fmt.Errorf("failed to create %s sequence: %w", docType, err)
+	}
+	seq, err = sequenceRepo.FindForUpdate(ctx, docType, year)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %s sequence: %w", docType, err)
+	}
+	return seq, nil
+}
+
+var (
+	sequenceToken         = regexp.MustCompile(`\{[^}]*\}`)
+	trailingSequenceToken = regexp.MustCompile(`\{SEQ(:\d+)?\}$`)
+)
+
+// formatDocumentNo expands the pattern tokens: {YYYY}, {YY}, {SEQ} and {SEQ:n}
+func formatDocumentNo(pattern string, year int, value int64) string {
+	return sequenceToken.ReplaceAllStringFunc(pattern, func(token string) string {
+		switch name := token[1 : len(token)-1]; {
+		case name == "YYYY":
+			return fmt.Sprintf("%04d", year)
+		case name == "YY":
+			return fmt.Sprintf("%02d", year%100)
+		case name == "SEQ":
+			return strconv.FormatInt(value, 10)
+		case strings.HasPrefix(name, "SEQ:"):
+			width, _ := strconv.Atoi(name[4:])
+			return fmt.Sprintf("%0*d", width, value)
+		}
+		return token
+	})
+}
+
+// validateSequencePattern requires exactly one sequence token and a year token, since
+// sequences restart every year and numbers must stay unique
+func validateSequencePattern(pattern string) error {
+	seqTokens, yearTokens := 0, 0
+	for _, token := range sequenceToken.FindAllString(pattern, -1) {
+		name := token[1 : len(token)-1]
+		switch {
+		case name == "YYYY" || name == "YY":
+			yearTokens++
+		case name == "SEQ":
+			seqTokens++
+		case strings.HasPrefix(name, "SEQ:"):
+			width, err := strconv.Atoi(name[4:])
+			if err != nil || width < 1 || width > 10 {
+				return fmt.Errorf("invalid token %s: the width must be between 1 and 10", token)
+			}
+			seqTokens++
+		default:
+			return fmt.Errorf("unknown token %s; use {YYYY}, {YY}, {SEQ} or {SEQ:n}", token)
+		}
+	}
+	if seqTokens != 1 {
+		return errors.New("pattern must contain exactly one {SEQ} or {SEQ:n} token")
+	}
+	if yearTokens == 0 {
+		return errors.New("pattern must contain {YYYY} or {YY}, as numbering restarts every year")
+	}
+	return nil
+}
+
+// --- Mapping ---
+
+func toDocumentSequenceResponse(seq model.DocumentSequence) DocumentSequenceResponse {
+	resp := DocumentSequenceResponse{
+		DocType:    seq.DocType,
+		Year:       seq.Year,
+		Pattern:    seq.Pattern,
+		NextValue:  seq.NextValue,
+		NextNumber: formatDocumentNo(seq.Pattern, seq.Year, seq.NextValue),
+	}
+	if seq.UpdatedBy != nil {
+		s := seq.UpdatedBy.String()
+		resp.UpdatedBy = &s
+	}
+	if !seq.UpdatedAt.IsZero() {
+		t := seq.UpdatedAt.Format(time.RFC3339)
+		resp.UpdatedAt = &t
+	}
+	return resp
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/vnadmin"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// --- DTOs ---
+
+// TaxLineContext describes the line a tax rule is picked for. Empty fields are unknown, so rules
+// with a condition on them do not apply.
+type TaxLineContext struct {
+	TaxType         string
+	Date            time.Time
+	ProductCategory string
+	PartnerType     string // CUSTOMER, SUPPLIER, BOTH
+	Country         string // ISO 3166-1 alpha-2
+	HSCode          string // Customs lines only
+}
+
+// TaxDryRunRequest describes a line to evaluate the tax rules for. Product and partner details
+// are read from product_id and partner_id; explicit fields override them.
+type TaxDryRunRequest struct {
+	TaxType         string `json:"tax_type" binding:"required"`
+	Date            string `json:"date"` // YYYY-MM-DD, default today
+	ProductID       string `json:"product_id"`
+	ProductCategory string `json:"product_category"`
+	PartnerID       string `json:"partner_id"`
+	PartnerType     string `json:"partner_type" binding:"omitempty,oneof=CUSTOMER SUPPLIER BOTH"`
+	Country         string `json:"country" binding:"omitempty,len=2"`
+	HSCode          string `json:"hs_code" binding:"omitempty,numeric"`
+}
+
+type TaxLineContextResponse struct {
+	ProductCategory string `json:"product_category"`
+	PartnerType     string `json:"partner_type"`
+	Country         string `json:"country"`
+	HSCode          string `json:"hs_code"`
+}
+
+// TaxRuleCandidateResponse is a rule in effect on the date and whether it applies to the line
+type TaxRuleCandidateResponse struct {
+	Rule     TaxRuleResponse `json:"rule"`
+	Applies  bool            `json:"applies"`
+	Selected bool            `json:"selected"`
+	Reasons  []string        `json:"reasons"` // One per condition, or why the rule was outranked
+}
+
+// TaxDryRunResponse explains how the rule for a line was picked
+type TaxDryRunResponse struct {
+	TaxType     string                     `json:"tax_type"`
+	Date        string                     `json:"date"`
+	Line        TaxLineContextResponse     `json:"line"`
+	Matched     *TaxRuleResponse           `json:"matched"` // Nil when no rule applies
+	Rate        *string                    `json:"rate"`
+	Explanation string                     `json:"explanation"`
+	Candidates  []TaxRuleCandidateResponse `json:"candidates"` // In ranking order, applicable rules first
+}
+
+// --- Evaluation ---
+
+// taxCandidate is a rule in effect and the outcome of checking its conditions against a line
+type taxCandidate struct {
+	rule       model.TaxRule
+	applies    bool
+	conditions int // Number of conditions the rule has; more conditions rank a rule higher
+	reasons    []string
+}
+
+// evaluateTaxRules checks each rule's conditions against the line and ranks them: rules that
+// apply first, then by priority, number of conditions, length of the HS code (a heading is more
+// specific than its chapter), the latest start date, the narrowest window (a rule with an end
+// date outranks an open-ended one) and the latest created. The rate plays no part. The first
+// candidate is the selected rule if it applies.
+func evaluateTaxRules(rules []model.TaxRule, line TaxLineContext) []taxCandidate {
+	candidates := make([]taxCandidate, 0, len(rules))
+	for _, r := range rules {
+		c := taxCandidate{rule: r, applies: true}
+		check := func(name, want, have string, matches bool) {
+			if want == "" {
+				return
+			}
+			c.conditions++
+			switch {
+			case have == "":
+				c.applies = false
+				c.reasons = append(c.reasons, fmt.Sprintf("requires %s %s, the line has none", name, want))
+			case !matches:
+				c.applies = false
+				c.reasons = append(c.reasons, fmt.Sprintf("requires %s %s, the line has %s", name, want, have))
+			default:
+				c.reasons = append(c.reasons, fmt.Sprintf("%s %s matches", name, have))
+			}
+		}
+		check("product category", r.ProductCategory, line.ProductCategory, strings.EqualFold(r.ProductCategory, line.ProductCategory))
+		check("partner type", r.PartnerType, line.PartnerType, partnerTypeMatches(r.PartnerType, line.PartnerType))
+		check("country", r.Country, line.Country, strings.EqualFold(r.Country, line.Country))
+		check("HS code", r.HSCode, line.HSCode, strings.HasPrefix(line.HSCode, r.HSCode))
+		if c.conditions == 0 {
+			c.reasons = append(c.reasons, "no conditions, applies to any line")
+		}
+		candidates = append(candidates, c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch {
+		case a.applies != b.applies:
+			return a.applies
+		case a.rule.Priority != b.rule.Priority:
+			return a.rule.Priority > b.rule.Priority
+		case a.conditions != b.conditions:
+			return a.conditions > b.conditions
+		case len(a.rule.HSCode) != len(b.rule.HSCode):
+			return len(a.rule.HSCode) > len(b.rule.HSCode)
+		case !a.rule.EffectiveFrom.Equal(b.rule.EffectiveFrom):
+			return a.rule.EffectiveFrom.After(b.rule.EffectiveFrom)
+		case endsEarlier(a.rule.EffectiveTo, b.rule.EffectiveTo) != endsEarlier(b.rule.EffectiveTo, a.rule.EffectiveTo):
+			return endsEarlier(a.rule.EffectiveTo, b.rule.EffectiveTo)
+		default:
+			return a.rule.CreatedAt.After(b.rule.CreatedAt)
+		}
+	})
+
+	if len(candidates) > 0 && candidates[0].applies {
+		selected := candidates[0].rule
+		for i := 1; i < len(candidates) && candidates[i].applies; i++ {
+			c := &candidates[i]
+			switch {
+			case c.rule.Priority < selected.Priority:
+				c.reasons = append(c.reasons, fmt.Sprintf("outranked by priority %d", selected.Priority))
+			case c.conditions < candidates[0].conditions:
+				c.reasons = append(c.reasons, "outranked by a rule with more conditions")
+			case len(c.rule.HSCode) < len(selected.HSCode):
+				c.reasons = append(c.reasons, "outranked by the more specific HS code "+selected.HSCode)
+			case c.rule.EffectiveFrom.Before(selected.EffectiveFrom):
+				c.reasons = append(c.reasons, "outranked by a rule that started later, on "+selected.EffectiveFrom.Format("2006-01-02"))
+			case endsEarlier(selected.EffectiveTo, c.rule.EffectiveTo):
+				c.reasons = append(c.reasons, "outranked by a narrower rule ending on "+selected.EffectiveTo.Format("2006-01-02"))
+			default:
+				c.reasons = append(c.reasons, "outranked by a rule created later")
+			}
+		}
+	}
+	return candidates
+}
+
+// endsEarlier reports whether a validity window ending on a closes before one ending on b; a nil
+// end date is open-ended
+func endsEarlier(a, b *time.Time) bool {
+	return a != nil && (b == nil || a.Before(*b))
+}
+
+// partnerTypeMatches reports whether a partner of type have satisfies a rule for partner type
+// want; BOTH partners are customers and suppliers
+func partnerTypeMatches(want, have string) bool {
+	return want == have || have == model.PartnerTypeBoth
+}
+
+// partnerTaxContext returns the partner type and country tax rules are matched on. The country
+// is that of the default address, then the billing address, then any address.
+func partnerTaxContext(p *model.Partner) (string, string) {
+	country, best := "", -1
+	for _, a := range p.Addresses {
+		rank := 0
+		if a.IsDefault {
+			rank = 2
+		} else if a.AddressType == model.AddressTypeBilling {
+			rank = 1
+		}
+		if rank > best {
+			country, best = a.Country, rank
+		}
+	}
+	return p.Type, strings.ToUpper(country)
+}
+
+// evaluateTax picks the rule of the line's tax type that applies to it on its date
+func evaluateTax(ctx context.Context, taxRuleRepo repository.TaxRuleRepository, line TaxLineContext) (*model.TaxRule, error) {
+	rules, err := taxRuleRepo.ListActiveByType(ctx, line.TaxType, line.Date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tax rules: %w", err)
+	}
+	candidates := evaluateTaxRules(rules, line)
+	if len(candidates) == 0 || !candidates[0].applies {
+		return nil, fmt.Errorf("no active tax rule found for type '%s' on date %s", line.TaxType, line.Date.Format("2006-01-02"))
+	}
+	return &candidates[0].rule, nil
+}
+
+// orderTaxLine is the line context of the items of an order taxed without a chosen rule: domestic
+// VAT for the order's partner, to which each item adds its product category. Goods bought from a
+// foreign supplier carry no VAT on the supplier's invoice (import VAT is charged on the customs
+// declaration), so the tax type is left empty for them.
+func orderTaxLine(orderType string, partner *model.Partner, on time.Time) TaxLineContext {
+	line := TaxLineContext{TaxType: model.TaxTypeVATInland, Date: on}
+	if partner != nil {
+		line.PartnerType, line.Country = partnerTaxContext(partner)
+	}
+	if orderType == model.OrderTypeImport && line.Country != "" && line.Country != vnadmin.CountryCode {
+		line.TaxType = ""
+	}
+	return line
+}
+
+// --- Implementation ---
+
+func (s *taxService) EvaluateTax(ctx context.Context, line TaxLineContext) (*model.TaxRule, error) {
+	return evaluateTax(ctx, s.taxRuleRepo, line)
+}
+
+func (s *taxService) DryRunTax(ctx context.Context, req TaxDryRunRequest) (TaxDryRunResponse, error) {
+	if _, err := findActiveTaxType(ctx, s.taxRuleRepo, req.TaxType); err != nil {
+		return TaxDryRunResponse{}, err
+	}
+
+	line := TaxLineContext{TaxType: req.TaxType, Date: time.Now()}
+	if req.Date != "" {
+		date, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			return TaxDryRunResponse{}, fmt.Errorf("invalid date format (expected YYYY-MM-DD): %w", err)
+		}
+		line.Date = date
+	}
+	if req.ProductID != "" {
+		id, err := uuid.Parse(req.ProductID)
+		if err != nil {
+			return TaxDryRunResponse{}, fmt.Errorf("invalid product_id: %w", err)
+		}
+		product, err := s.productRepo.FindByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return TaxDryRunResponse{}, errors.New("product not found")
+			}
+			return TaxDryRunResponse{}, fmt.Errorf("failed to fetch product: %w", err)
+		}
+		line.ProductCategory = product.Category
+	}
+	if req.PartnerID != "" {
+		id, err := uuid.Parse(req.PartnerID)
+		if err != nil {
+			return TaxDryRunResponse{}, fmt.Errorf("invalid partner_id: %w", err)
+		}
+		partner, err := s.partnerRepo.FindByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return TaxDryRunResponse{}, errors.New("partner not found")
+			}
+			return TaxDryRunResponse{}, fmt.Errorf("failed to fetch partner: %w", err)
+		}
+		line.PartnerType, line.Country = partnerTaxContext(partner)
+	}
+	if req.ProductCategory != "" {
+		line.ProductCategory = strings.TrimSpace(req.ProductCategory)
+	}
+	if req.PartnerType != "" {
+		line.PartnerType = req.PartnerType
+	}
+	if req.Country != "" {
+		line.Country = strings.ToUpper(req.Country)
+	}
+	line.HSCode = req.HSCode
+
+	rules, err := s.taxRuleRepo.ListActiveByType(ctx, line.TaxType, line.Date)
+	if err != nil {
+		return TaxDryRunResponse{}, fmt.Errorf("failed to query tax rules: %w", err)
+	}
+	candidates := evaluateTaxRules(rules, line)
+
+	resp := TaxDryRunResponse{
+		TaxType: line.TaxType,
+		Date:    line.Date.Format("2006-01-02"),
+		Line: TaxLineContextResponse{
+			ProductCategory: line.ProductCategory,
+			PartnerType:     line.PartnerType,
+			Country:         line.Country,
+			HSCode:          line.HSCode,
+		},
+		Candidates: make([]TaxRuleCandidateResponse, 0, len(candidates)),
+	}
+	for i, c := range candidates {
+		resp.Candidates = append(resp.Candidates, TaxRuleCandidateResponse{
+			Rule:     toTaxRuleResponse(c.rule),
+			Applies:  c.applies,
+			Selected: i == 0 && c.applies,
+			Reasons:  c.reasons,
+		})
+	}
+
+	switch {
+	case len(candidates) == 0:
+		resp.Explanation = fmt.Sprintf("No %s rule is in effect on %s", line.TaxType, resp.Date)
+	case !candidates[0].applies:
+		resp.Explanation = fmt.Sprintf("None of the %d %s rules in effect on %s applies to the line", len(candidates), line.TaxType, resp.Date)
+	default:
+		matched := resp.Candidates[0].Rule
+		rate := matched.Rate
+		resp.Matched, resp.Rate = &matched, &rate
+		resp.Explanation = fmt.Sprintf("Rule %s (%s, priority %d) applies: %s", matched.ID,
+			formatPercent(candidates[0].rule.Rate), matched.Priority, strings.Join(candidates[0].reasons, "; "))
+	}
+	return resp, nil
+}
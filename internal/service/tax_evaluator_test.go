@@ -0,0 +1,176 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func TestEvaluateTaxRulesRanking(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	until := func(s string) *time.Time {
+		d := day(s)
+		return &d
+	}
+	rule := func(name, rate string, r model.TaxRule) model.TaxRule {
+		r.ID = uuid.NewSHA1(uuid.NameSpaceOID, []byte(name))
+		r.Description = name
+		r.TaxType = model.TaxTypeVATInland
+		r.Rate = decimal.RequireFromString(rate)
+		if r.EffectiveFrom.IsZero() {
+			r.EffectiveFrom = day("2024-01-01")
+		}
+		return r
+	}
+	line := TaxLineContext{
+		TaxType:         model.TaxTypeVATInland,
+		Date:            day("2025-03-01"),
+		ProductCategory: "FOOD",
+		PartnerType:     model.PartnerTypeCustomer,
+		Country:         "VN",
+		HSCode:          "84713020",
+	}
+
+	tests := []struct {
+		name       string
+		rules      []model.TaxRule
+		want       []string // Descriptions in ranking order
+		wantReason string   // Last reason of the runner-up
+	}{
+		{
+			name: "priority before conditions",
+			rules: []model.TaxRule{
+				rule("food", "0.05", model.TaxRule{ProductCategory: "FOOD"}),
+				rule("reduction", "0.08", model.TaxRule{Priority: 10}),
+			},
+			want:       []string{"reduction", "food"},
+			wantReason: "outranked by priority 10",
+		},
+		{
+			name: "more conditions",
+			rules: []model.TaxRule{
+				rule("any", "0.10", model.TaxRule{}),
+				rule("food", "0.05", model.TaxRule{ProductCategory: "food"}),
+			},
+			want:       []string{"food", "any"},
+			wantReason: "outranked by a rule with more conditions",
+		},
+		{
+			name: "longer HS code",
+			rules: []model.TaxRule{
+				rule("chapter", "0.10", model.TaxRule{HSCode: "84"}),
+				rule("heading", "0.05", model.TaxRule{HSCode: "8471"}),
+			},
+			want:       []string{"heading", "chapter"},
+			wantReason: "outranked by the more specific HS code 8471",
+		},
+		{
+			name: "later start date wins over a higher rate",
+			rules: []model.TaxRule{
+				rule("standard", "0.10", model.TaxRule{}),
+				rule("reduced", "0.08", model.TaxRule{EffectiveFrom: day("2025-01-01")}),
+			},
+			want:       []string{"reduced", "standard"},
+			wantReason: "outranked by a rule that started later, on 2025-01-01",
+		},
+		{
+			name: "narrower window wins over an open-ended rule",
+			rules: []model.TaxRule{
+				rule("standard", "0.10", model.TaxRule{}),
+				rule("reduced", "0.08", model.TaxRule{EffectiveTo: until("2025-06-30")}),
+			},
+			want:       []string{"reduced", "standard"},
+			wantReason: "outranked by a narrower rule ending on 2025-06-30",
+		},
+		{
+			name: "earlier end date",
+			rules: []model.TaxRule{
+				rule("year", "0.08", model.TaxRule{EffectiveTo: until("2025-12-31")}),
+				rule("half", "0.10", model.TaxRule{EffectiveTo: until("2025-06-30")}),
+			},
+			want:       []string{"half", "year"},
+			wantReason: "outranked by a narrower rule ending on 2025-06-30",
+		},
+		{
+			name: "created later",
+			rules: []model.TaxRule{
+				rule("old", "0.10", model.TaxRule{CreatedAt: day("2024-01-01")}),
+				rule("new", "0.05", model.TaxRule{CreatedAt: day("2024-02-01")}),
+			},
+			want:       []string{"new", "old"},
+			wantReason: "outranked by a rule created later",
+		},
+		{
+			name: "rules that do not apply rank last",
+			rules: []model.TaxRule{
+				rule("export", "0.00", model.TaxRule{Priority: 5, Country: "US"}),
+				rule("standard", "0.10", model.TaxRule{}),
+			},
+			want:       []string{"standard", "export"},
+			wantReason: "requires country US, the line has VN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := evaluateTaxRules(tt.rules, line)
+			var got []string
+			for _, c := range candidates {
+				got = append(got, c.rule.Description)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("ranking = %v, want %v", got, tt.want)
+			}
+			if !candidates[0].applies {
+				t.Fatalf("%s does not apply: %v", got[0], candidates[0].reasons)
+			}
+			reasons := candidates[1].reasons
+			if last := reasons[len(reasons)-1]; last != tt.wantReason {
+				t.Errorf("runner-up reason = %q, want %q", last, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestEvaluateTaxRulesReasons(t *testing.T) {
+	rules := []model.TaxRule{{
+		TaxType:         model.TaxTypeVATInland,
+		Rate:            decimal.RequireFromString("0.05"),
+		ProductCategory: "FOOD",
+		PartnerType:     model.PartnerTypeSupplier,
+		Country:         "VN",
+		HSCode:          "0901",
+	}, {
+		TaxType: model.TaxTypeVATInland,
+		Rate:    decimal.RequireFromString("0.10"),
+	}}
+	line := TaxLineContext{TaxType: model.TaxTypeVATInland, ProductCategory: "food", PartnerType: model.PartnerTypeBoth, HSCode: "8471"}
+
+	candidates := evaluateTaxRules(rules, line)
+	if len(candidates) != 2 || !candidates[0].applies || candidates[1].applies {
+		t.Fatalf("want the unconditional rule selected, got %+v", candidates)
+	}
+	if got := candidates[0].reasons; len(got) != 1 || got[0] != "no conditions, applies to any line" {
+		t.Errorf("selected reasons = %q", got)
+	}
+	want := []string{
+		"product category food matches",
+		"partner type BOTH matches",
+		"requires country VN, the line has none",
+		"requires HS code 0901, the line has 8471",
+	}
+	if got := candidates[1].reasons; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("reasons = %q, want %q", got, want)
+	}
+}
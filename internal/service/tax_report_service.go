@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/beevik/etree"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// declarationCurrency is the currency tax declarations are filed in
+const declarationCurrency = "VND"
+
+// Declaration period types
+const (
+	TaxPeriodMonth   = "MONTH"
+	TaxPeriodQuarter = "QUARTER"
+)
+
+var quarterPeriodPattern = regexp.MustCompile(`^(\d{4})-Q([1-4])$`)
+
+// --- Interface ---
+
+type TaxReportService interface {
+	// GetVATReturn builds the VAT return (form 01/GTGT) of a month (YYYY-MM) or quarter (YYYY-Qn)
+	GetVATReturn(ctx context.Context, filter VATReturnFilter) (VATReturnResponse, error)
+	// ExportVATReturn renders the VAT return as HTKK XML or XLSX. It returns the file, its name
+	// and content type.
+	ExportVATReturn(ctx context.Context, filter VATReturnFilter, format string) ([]byte, string, string, error)
+	// GetFCTReturn builds the foreign contractor tax declaration (form 01/NTNN) of a month or quarter
+	GetFCTReturn(ctx context.Context, period string) (FCTReturnResponse, error)
+	// ExportFCTReturn renders the FCT declaration as XML or XLSX. It returns the file, its name
+	// and content type.
+	ExportFCTReturn(ctx context.Context, period, format string) ([]byte, string, string, error)
+	// GetCITEstimate estimates the provisional corporate income tax of a quarter (YYYY-Qn) and
+	// explains which expenses are not deductible
+	GetCITEstimate(ctx context.Context, period string) (CITEstimateResponse, error)
+	GetCITSettings(ctx context.Context) (CITSettingsResponse, error)
+	UpdateCITSettings(ctx context.Context, userID string, req UpdateCITSettingsRequest) (CITSettingsResponse, error)
+}
+
+type taxReportService struct {
+	taxReportRepo repository.TaxReportRepository
+	templateRepo  repository.DocumentTemplateRepository
+	rateRepo      repository.ExchangeRateRepository
+	taxRuleRepo   repository.TaxRuleRepository
+	auditRepo     repository.AuditRepository
+}
+
+func NewTaxReportService(
+	taxReportRepo repository.TaxReportRepository,
+	templateRepo repository.DocumentTemplateRepository,
+	rateRepo repository.ExchangeRateRepository,
+	taxRuleRepo repository.TaxRuleRepository,
+	auditRepo repository.AuditRepository,
+) TaxReportService {
+	return &taxReportService{
+		taxReportRepo: taxReportRepo,
+		templateRepo:  templateRepo,
+		rateRepo:      rateRepo,
+		taxRuleRepo:   taxRuleRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+// --- Helpers ---
+
+// taxPeriod is a declaration period, the dates in [From, To)
+type taxPeriod struct {
+	Code    string // 2026-03 or 2026-Q1
+	Type    string // MONTH, QUARTER
+	Year    int
+	Number  int // Month or quarter
+	From    time.Time
+	To      time.Time
+	LastDay time.Time
+}
+
+// parseTaxPeriod parses a month (YYYY-MM) or a quarter (YYYY-Qn)
+func parseTaxPeriod(code string) (taxPeriod, error) {
+	p := taxPeriod{Code: code}
+	if m := quarterPeriodPattern.FindStringSubmatch(code); m != nil {
+		p.Type = TaxPeriodQuarter
+		p.Year, _ = strconv.Atoi(m[1])
+		p.Number, _ = strconv.Atoi(m[2])
+		p.From = time.Date(p.Year, time.Month(p.Number*3-2), 1, 0, 0, 0, 0, time.UTC)
+		p.To = p.From.AddDate(0, 3, 0)
+	} else {
+		year, month, err := parsePeriod(code)
+		if err != nil {
+			return taxPeriod{}, fmt.Errorf("invalid period %q; use YYYY-MM for a month or YYYY-Qn for a quarter", code)
+		}
+		p.Type, p.Year, p.Number = TaxPeriodMonth, year, month
+		p.From = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		p.To = p.From.AddDate(0, 1, 0)
+	}
+	p.LastDay = p.To.AddDate(0, 0, -1)
+	return p, nil
+}
+
+// vndConverter converts base-currency amounts to whole dong for declarations, at the latest VND
+// rate on or before each document date. Rates are looked up once per day.
+type vndConverter struct {
+	ctx      context.Context
+	rateRepo repository.ExchangeRateRepository
+	rates    map[string]decimal.Decimal
+}
+
+func (s *taxReportService) newVNDConverter(ctx context.Context) *vndConverter {
+	return &vndConverter{ctx: ctx, rateRepo: s.rateRepo, rates: make(map[string]decimal.Decimal)}
+}
+
+func (c *vndConverter) convert(amount decimal.Decimal, date time.Time) (decimal.Decimal, error) {
+	if baseCurrency == declarationCurrency {
+		return amount.Round(0), nil
+	}
+	day := date.Format("2006-01-02")
+	rate, ok := c.rates[day]
+	if !ok {
+		found, err := c.rateRepo.FindRateOn(c.ctx, declarationCurrency, date)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return decimal.Zero, fmt.Errorf("no %s exchange rate on or before %s to convert declared amounts", declarationCurrency, day)
+			}
+			return decimal.Zero, fmt.Errorf("failed to look up %s exchange rate: %w", declarationCurrency, err)
+		}
+		rate = found.Rate
+		c.rates[day] = rate
+	}
+	return amount.Div(rate).Round(0), nil
+}
+
+// loadTaxpayer returns the company details declarations are filed under, kept on the INVOICE
+// document template
+func (s *taxReportService) loadTaxpayer(ctx context.Context) (model.DocumentTemplate, error) {
+	company, err := loadDocumentTemplate(ctx, s.templateRepo, model.DocTemplateInvoice)
+	if err != nil {
+		return model.DocumentTemplate{}, err
+	}
+	if company.CompanyName == "" || company.CompanyTaxCode == "" {
+		return model.DocumentTemplate{}, errors.New("company name and tax code must be set on the INVOICE document template")
+	}
+	return company, nil
+}
+
+// taxpayerXML writes the taxpayer (NNT) block of a declaration
+func taxpayerXML(parent *etree.Element, company model.DocumentTemplate) {
+	taxpayer := parent.CreateElement("NNT")
+	taxpayer.CreateElement("mst").SetText(company.CompanyTaxCode)
+	taxpayer.CreateElement("tenNNT").SetText(company.CompanyName)
+	optionalElement(taxpayer, "dchiNNT", company.CompanyAddress)
+	optionalElement(taxpayer, "dthoaiNNT", company.CompanyPhone)
+	optionalElement(taxpayer, "emailNNT", company.CompanyEmail)
+}
+
+// declarationPeriodXML writes the period (KyKKhaiThue) block of a declaration
+func declarationPeriodXML(parent *etree.Element, p taxPeriod) {
+	period := parent.CreateElement("KyKKhaiThue")
+	if p.Type == TaxPeriodQuarter {
+		period.CreateElement("kieuKy").SetText("Q")
+		period.CreateElement("kyKKhai").SetText(fmt.Sprintf("%d/%d", p.Number, p.Year))
+	} else {
+		period.CreateElement("kieuKy").SetText("M")
+		period.CreateElement("kyKKhai").SetText(fmt.Sprintf("%02d/%d", p.Number, p.Year))
+	}
+	period.CreateElement("kyKKhaiTuNgay").SetText(p.From.Format("02/01/2006"))
+	period.CreateElement("kyKKhaiDenNgay").SetText(p.LastDay.Format("02/01/2006"))
+}
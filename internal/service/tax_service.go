@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"backend/internal/model"
@@ -18,19 +19,31 @@ import (
 // --- DTOs ---
 
 type CreateTaxRuleRequest struct {
-	TaxType       string `json:"tax_type" binding:"required,oneof=VAT_INLAND VAT_INTL FCT"`
+	TaxType       string `json:"tax_type" binding:"required"`       // Code of an active tax type
 	Rate          string `json:"rate" binding:"required"`           // Decimal string, e.g. "0.10"
 	EffectiveFrom string `json:"effective_from" binding:"required"` // YYYY-MM-DD
 	EffectiveTo   string `json:"effective_to"`                      // YYYY-MM-DD, nullable
 	Description   string `json:"description"`
+	TaxRuleConditions
 }
 
 type UpdateTaxRuleRequest struct {
-	TaxType       string `json:"tax_type" binding:"required,oneof=VAT_INLAND VAT_INTL FCT"`
+	TaxType       string `json:"tax_type" binding:"required"`
 	Rate          string `json:"rate" binding:"required"`
 	EffectiveFrom string `json:"effective_from" binding:"required"`
 	EffectiveTo   string `json:"effective_to"`
 	Description   string `json:"description"`
+	TaxRuleConditions
+}
+
+// TaxRuleConditions restrict a rule to some lines; empty fields match any line. Priority ranks
+// the rules that apply, e.g. a temporary 8% VAT reduction over the 10% standard rate.
+type TaxRuleConditions struct {
+	Priority        int    `json:"priority"`
+	ProductCategory string `json:"product_category" binding:"max=100"`
+	PartnerType     string `json:"partner_type" binding:"omitempty,oneof=CUSTOMER SUPPLIER"`
+	Country         string `json:"country" binding:"omitempty,len=2"`                // ISO 3166-1 alpha-2
+	HSCode          string `json:"hs_code" binding:"omitempty,numeric,min=2,max=10"` // Chapter, heading or full HS code of customs lines
 }
 
 type TaxRuleResponse struct {
@@ -40,7 +53,8 @@ type TaxRuleResponse struct {
 	EffectiveFrom string  `json:"effective_from"`
 	EffectiveTo   *string `json:"effective_to"`
 	Description   string  `json:"description"`
-	CreatedAt     string  `json:"created_at"`
+	TaxRuleConditions
+	CreatedAt string `json:"created_at"`
 }
 
 type ActiveTaxRateResponse struct {
@@ -57,16 +71,39 @@ type TaxService interface {
 	UpdateTaxRule(ctx context.Context, id string, req UpdateTaxRuleRequest, userID string) (TaxRuleResponse, error)
 	DeleteTaxRule(ctx context.Context, id string, userID string) error
 	GetActiveTaxRate(ctx context.Context, taxType string) (*ActiveTaxRateResponse, error)
+	// CalculateActiveTax returns the rate of the rule of a type that applies without conditions on a date
 	CalculateActiveTax(ctx context.Context, taxType string, targetDate time.Time) (decimal.Decimal, error)
+	// EvaluateTax picks the rule that applies to a line
+	EvaluateTax(ctx context.Context, line TaxLineContext) (*model.TaxRule, error)
+	// DryRunTax evaluates the rules for a line and explains which one matched and why
+	DryRunTax(ctx context.Context, req TaxDryRunRequest) (TaxDryRunResponse, error)
+
+	// SeedDefaultTaxTypes registers the tax types the calculations rely on
+	SeedDefaultTaxTypes(ctx context.Context) error
+	ListTaxTypes(ctx context.Context) ([]TaxTypeResponse, error)
+	CreateTaxType(ctx context.Context, userID string, req CreateTaxTypeRequest) (TaxTypeResponse, error)
+	UpdateTaxType(ctx context.Context, code string, userID string, req UpdateTaxTypeRequest) (TaxTypeResponse, error)
 }
 
 type taxService struct {
 	taxRuleRepo repository.TaxRuleRepository
+	partnerRepo repository.PartnerRepository
+	productRepo repository.ProductRepository
 	auditRepo   repository.AuditRepository
 }
 
-func NewTaxService(taxRuleRepo repository.TaxRuleRepository, auditRepo repository.AuditRepository) TaxService {
-	return &taxService{taxRuleRepo: taxRuleRepo, auditRepo: auditRepo}
+func NewTaxService(
+	taxRuleRepo repository.TaxRuleRepository,
+	partnerRepo repository.PartnerRepository,
+	productRepo repository.ProductRepository,
+	auditRepo repository.AuditRepository,
+) TaxService {
+	return &taxService{
+		taxRuleRepo: taxRuleRepo,
+		partnerRepo: partnerRepo,
+		productRepo: productRepo,
+		auditRepo:   auditRepo,
+	}
 }
 
 // --- Implementation ---
@@ -91,13 +128,9 @@ func (s *taxService) CreateTaxRule(ctx context.Context, req CreateTaxRuleRequest
 		return TaxRuleResponse{}, err
 	}
 
-	// Validate overlap
-	count, err := s.taxRuleRepo.FindOverlapping(ctx, req.TaxType, effectiveFrom, effectiveTo, nil)
+	taxType, err := findActiveTaxType(ctx, s.taxRuleRepo, req.TaxType)
 	if err != nil {
-		return TaxRuleResponse{}, fmt.Errorf("failed to check overlap: %w", err)
-	}
-	if count > 0 {
-		return TaxRuleResponse{}, fmt.Errorf("a tax rule for '%s' already exists with overlapping effective dates", req.TaxType)
+		return TaxRuleResponse{}, err
 	}
 
 	rule := model.TaxRule{
@@ -107,6 +140,16 @@ func (s *taxService) CreateTaxRule(ctx context.Context, req CreateTaxRuleRequest
 		EffectiveTo:   effectiveTo,
 		Description:   req.Description,
 	}
+	applyTaxRuleConditions(&rule, req.TaxRuleConditions)
+
+	// Validate overlap; VAT rates run side by side
+	count, err := s.taxRuleRepo.FindOverlapping(ctx, rule, taxType.Category == model.TaxCategoryVAT, nil)
+	if err != nil {
+		return TaxRuleResponse{}, fmt.Errorf("failed to check overlap: %w", err)
+	}
+	if count > 0 {
+		return TaxRuleResponse{}, fmt.Errorf("a tax rule for '%s' with this priority and conditions (and rate, for VAT) already exists with overlapping effective dates", req.TaxType)
+	}
 
 	if err := s.taxRuleRepo.Create(ctx, &rule); err != nil {
 		return TaxRuleResponse{}, fmt.Errorf("failed to create tax rule: %w", err)
@@ -136,13 +179,9 @@ func (s *taxService) UpdateTaxRule(ctx context.Context, id string, req UpdateTax
 		return TaxRuleResponse{}, err
 	}
 
-	// Validate overlap (exclude self)
-	count, err := s.taxRuleRepo.FindOverlapping(ctx, req.TaxType, effectiveFrom, effectiveTo, &ruleID)
+	taxType, err := findActiveTaxType(ctx, s.taxRuleRepo, req.TaxType)
 	if err != nil {
-		return TaxRuleResponse{}, fmt.Errorf("failed to check overlap: %w", err)
-	}
-	if count > 0 {
-		return TaxRuleResponse{}, fmt.Errorf("a tax rule for '%s' already exists with overlapping effective dates", req.TaxType)
+		return TaxRuleResponse{}, err
 	}
 
 	rule.TaxType = req.TaxType
@@ -150,6 +189,16 @@ func (s *taxService) UpdateTaxRule(ctx context.Context, id string, req UpdateTax
 	rule.EffectiveFrom = effectiveFrom
 	rule.EffectiveTo = effectiveTo
 	rule.Description = req.Description
+	applyTaxRuleConditions(rule, req.TaxRuleConditions)
+
+	// Validate overlap (exclude self)
+	count, err := s.taxRuleRepo.FindOverlapping(ctx, *rule, taxType.Category == model.TaxCategoryVAT, &ruleID)
+	if err != nil {
+		return TaxRuleResponse{}, fmt.Errorf("failed to check overlap: %w", err)
+	}
+	if count > 0 {
+		return TaxRuleResponse{}, fmt.Errorf("a tax rule for '%s' with this priority and conditions (and rate, for VAT) already exists with overlapping effective dates", req.TaxType)
+	}
 
 	if err := s.taxRuleRepo.Update(ctx, rule); err != nil {
 		return TaxRuleResponse{}, fmt.Errorf("failed to update tax rule: %w", err)
@@ -184,6 +233,10 @@ func (s *taxService) DeleteTaxRule(ctx context.Context, id string, userID string
 }
 
 func (s *taxService) GetActiveTaxRate(ctx context.Context, taxType string) (*ActiveTaxRateResponse, error) {
+	if _, err := findActiveTaxType(ctx, s.taxRuleRepo, taxType); err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	rule, err := s.taxRuleRepo.FindActiveByType(ctx, taxType, now)
 	if err != nil {
@@ -201,14 +254,10 @@ func (s *taxService) GetActiveTaxRate(ctx context.Context, taxType string) (*Act
 }
 
 func (s *taxService) CalculateActiveTax(ctx context.Context, taxType string, targetDate time.Time) (decimal.Decimal, error) {
-	rule, err := s.taxRuleRepo.FindActiveByType(ctx, taxType, targetDate)
+	rule, err := s.EvaluateTax(ctx, TaxLineContext{TaxType: taxType, Date: targetDate})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return decimal.Zero, fmt.Errorf("no active tax rule found for type '%s' on date %s", taxType, targetDate.Format("2006-01-02"))
-		}
-		return decimal.Zero, fmt.Errorf("failed to query tax rule: %w", err)
+		return decimal.Zero, err
 	}
-
 	return rule.Rate, nil
 }
 
@@ -237,6 +286,15 @@ func parseTaxRuleFields(rateStr, fromStr, toStr string) (decimal.Decimal, time.T
 	return rate, effectiveFrom, effectiveTo, nil
 }
 
+// applyTaxRuleConditions copies the normalized conditions of a request onto a rule
+func applyTaxRuleConditions(rule *model.TaxRule, c TaxRuleConditions) {
+	rule.Priority = c.Priority
+	rule.ProductCategory = strings.TrimSpace(c.ProductCategory)
+	rule.PartnerType = c.PartnerType
+	rule.Country = strings.ToUpper(strings.TrimSpace(c.Country))
+	rule.HSCode = strings.TrimSpace(c.HSCode)
+}
+
 func toTaxRuleResponse(r model.TaxRule) TaxRuleResponse {
 	resp := TaxRuleResponse{
 		ID:            r.ID.String(),
@@ -244,7 +302,14 @@ func toTaxRuleResponse(r model.TaxRule) TaxRuleResponse {
 		Rate:          r.Rate.StringFixed(4),
 		EffectiveFrom: r.EffectiveFrom.Format("2006-01-02"),
 		Description:   r.Description,
-		CreatedAt:     r.CreatedAt.Format(time.RFC3339),
+		TaxRuleConditions: TaxRuleConditions{
+			Priority:        r.Priority,
+			ProductCategory: r.ProductCategory,
+			PartnerType:     r.PartnerType,
+			Country:         r.Country,
+			HSCode:          r.HSCode,
+		},
+		CreatedAt: r.CreatedAt.Format(time.RFC3339),
 	}
 	if r.EffectiveTo != nil {
 		s := r.EffectiveTo.Format("2006-01-02")
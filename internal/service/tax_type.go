@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// DocumentTypeCustomsDeclaration is the document type of customs declarations; the other
+// document types are the invoice reference types
+const DocumentTypeCustomsDeclaration = "CUSTOMS_DECLARATION"
+
+var taxTypeCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,19}$`)
+
+// --- DTOs ---
+
+type TaxTypeResponse struct {
+	Code          string   `json:"code"`
+	Label         string   `json:"label"`
+	Category      string   `json:"category"`
+	DocumentTypes []string `json:"document_types"`
+	IsActive      bool     `json:"is_active"`
+}
+
+type CreateTaxTypeRequest struct {
+	Code          string   `json:"code" binding:"required"` // e.g. ENV_TAX
+	Label         string   `json:"label" binding:"required,max=255"`
+	Category      string   `json:"category" binding:"required,oneof=VAT IMPORT_DUTY CONTRACTOR OTHER"`
+	DocumentTypes []string `json:"document_types" binding:"required,min=1,dive,oneof=ORDER_IMPORT ORDER_EXPORT EXPENSE CUSTOMS_DECLARATION"`
+}
+
+type UpdateTaxTypeRequest struct {
+	Label         *string  `json:"label" binding:"omitempty,max=255"`
+	Category      *string  `json:"category" binding:"omitempty,oneof=VAT IMPORT_DUTY CONTRACTOR OTHER"`
+	DocumentTypes []string `json:"document_types" binding:"omitempty,min=1,dive,oneof=ORDER_IMPORT ORDER_EXPORT EXPENSE CUSTOMS_DECLARATION"`
+	IsActive      *bool    `json:"is_active"`
+}
+
+// defaultTaxTypes are the tax types the calculations rely on, seeded at startup
+var defaultTaxTypes = []model.TaxTypeDefinition{
+	{Code: model.TaxTypeVATInland, Label: "Thuế GTGT nội địa", Category: model.TaxCategoryVAT,
+		DocumentTypes: "ORDER_IMPORT,ORDER_EXPORT,EXPENSE"},
+	{Code: model.TaxTypeVATIntl, Label: "Thuế GTGT hàng nhập khẩu", Category: model.TaxCategoryVAT,
+		DocumentTypes: "ORDER_IMPORT,EXPENSE,CUSTOMS_DECLARATION"},
+	{Code: model.TaxTypeFCT, Label: "Thuế nhà thầu nước ngoài", Category: model.TaxCategoryContractor,
+		DocumentTypes: "EXPENSE"},
+	{Code: model.TaxTypeImport, Label: "Thuế nhập khẩu", Category: model.TaxCategoryImportDuty,
+		DocumentTypes: "ORDER_IMPORT,CUSTOMS_DECLARATION"},
+	{Code: model.TaxTypeContractor, Label: "Thuế nhà thầu", Category: model.TaxCategoryContractor,
+		DocumentTypes: "EXPENSE"},
+}
+
+// --- Implementation ---
+
+func (s *taxService) SeedDefaultTaxTypes(ctx context.Context) error {
+	for _, t := range defaultTaxTypes {
+		taxType := t
+		taxType.IsActive = true
+		if err := s.taxRuleRepo.CreateTypeIfMissing(ctx, &taxType); err != nil {
+			return fmt.Errorf("failed to seed tax type %s: %w", t.Code, err)
+		}
+	}
+	return nil
+}
+
+func (s *taxService) ListTaxTypes(ctx context.Context) ([]TaxTypeResponse, error) {
+	types, err := s.taxRuleRepo.ListTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tax types: %w", err)
+	}
+
+	result := make([]TaxTypeResponse, 0, len(types))
+	for _, t := range types {
+		result = append(result, toTaxTypeResponse(t))
+	}
+	return result, nil
+}
+
+func (s *taxService) CreateTaxType(ctx context.Context, userID string, req CreateTaxTypeRequest) (TaxTypeResponse, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if !taxTypeCodePattern.MatchString(code) {
+		return TaxTypeResponse{}, fmt.Errorf("invalid tax type code %q: expected up to 20 letters, digits or underscores", req.Code)
+	}
+
+	if _, err := s.taxRuleRepo.FindType(ctx, code); err == nil {
+		return TaxTypeResponse{}, fmt.Errorf("tax type %s already exists", code)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return TaxTypeResponse{}, fmt.Errorf("failed to check tax type: %w", err)
+	}
+
+	taxType := &model.TaxTypeDefinition{
+		Code:          code,
+		Label:         strings.TrimSpace(req.Label),
+		Category:      req.Category,
+		DocumentTypes: joinDocumentTypes(req.DocumentTypes),
+		IsActive:      true,
+	}
+	if err := s.taxRuleRepo.CreateType(ctx, taxType); err != nil {
+		return TaxTypeResponse{}, fmt.Errorf("failed to create tax type: %w", err)
+	}
+
+	_ = s.auditRepo.Log(ctx, newAuditLog(userID, model.ActionCreateTaxType, code, taxType.Label, req))
+
+	return toTaxTypeResponse(*taxType), nil
+}
+
+func (s *taxService) UpdateTaxType(ctx context.Context, code string, userID string, req UpdateTaxTypeRequest) (TaxTypeResponse, error) {
+	code = strings.ToUpper(code)
+	taxType, err := s.taxRuleRepo.FindType(ctx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return TaxTypeResponse{}, fmt.Errorf("tax type %s not found", code)
+		}
+		return TaxTypeResponse{}, fmt.Errorf("failed to fetch tax type: %w", err)
+	}
+
+	// The calculations post and declare the seeded types by their category on the seeded document
+	// types, which must stay put
+	if builtIn, ok := findDefaultTaxType(code); ok {
+		if req.Category != nil && *req.Category != taxType.Category {
+			return TaxTypeResponse{}, fmt.Errorf("%s is a built-in tax type and its category cannot be changed", code)
+		}
+		if req.IsActive != nil && !*req.IsActive {
+			return TaxTypeResponse{}, fmt.Errorf("%s is a built-in tax type and cannot be deactivated", code)
+		}
+		if req.DocumentTypes != nil {
+			updated := model.TaxTypeDefinition{DocumentTypes: joinDocumentTypes(req.DocumentTypes)}
+			for _, d := range strings.Split(builtIn.DocumentTypes, ",") {
+				if !updated.AppliesTo(d) {
+					return TaxTypeResponse{}, fmt.Errorf("%s is a built-in tax type and must keep document type %s", code, d)
+				}
+			}
+		}
+	}
+
+	if req.Label != nil {
+		taxType.Label = strings.TrimSpace(*req.Label)
+	}
+	if req.Category != nil {
+		taxType.Category = *req.Category
+	}
+	if req.DocumentTypes != nil {
+		taxType.DocumentTypes = joinDocumentTypes(req.DocumentTypes)
+	}
+	if req.IsActive != nil {
+		taxType.IsActive = *req.IsActive
+	}
+
+	if err := s.taxRuleRepo.UpdateType(ctx, taxType); err != nil {
+		return TaxTypeResponse{}, fmt.Errorf("failed to update tax type: %w", err)
+	}
+
+	_ = s.auditRepo.Log(ctx, newAuditLog(userID, model.ActionUpdateTaxType, code, taxType.Label, req))
+
+	return toTaxTypeResponse(*taxType), nil
+}
+
+// --- Helpers ---
+
+// findActiveTaxType returns the registered tax type of a code; unknown and inactive types are errors
+func findActiveTaxType(ctx context.Context, taxRuleRepo repository.TaxRuleRepository, code string) (*model.TaxTypeDefinition, error) {
+	taxType, err := taxRuleRepo.FindType(ctx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("unknown tax type '%s'", code)
+		}
+		return nil, fmt.Errorf("failed to fetch tax type: %w", err)
+	}
+	if !taxType.IsActive {
+		return nil, fmt.Errorf("tax type '%s' is inactive", code)
+	}
+	return taxType, nil
+}
+
+// checkTaxRuleApplies checks that the type of a rule is active and may be used on a document type
+func checkTaxRuleApplies(ctx context.Context, taxRuleRepo repository.TaxRuleRepository, rule *model.TaxRule, documentType string) error {
+	taxType, err := findActiveTaxType(ctx, taxRuleRepo, rule.TaxType)
+	if err != nil {
+		return err
+	}
+	if !taxType.AppliesTo(documentType) {
+		return fmt.Errorf("tax type '%s' does not apply to %s documents", rule.TaxType, documentType)
+	}
+	return nil
+}
+
+// taxTypeCategories maps every registered tax type code to its category
+func taxTypeCategories(ctx context.Context, taxRuleRepo repository.TaxRuleRepository) (map[string]string, error) {
+	types, err := taxRuleRepo.ListTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tax types: %w", err)
+	}
+	categories := make(map[string]string, len(types))
+	for _, t := range types {
+		categories[t.Code] = t.Category
+	}
+	return categories, nil
+}
+
+// taxTypesOfCategory returns the codes of the registered tax types of a category
+func taxTypesOfCategory(ctx context.Context, taxRuleRepo repository.TaxRuleRepository, category string) ([]string, error) {
+	categories, err := taxTypeCategories(ctx, taxRuleRepo)
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0)
+	for code, c := range categories {
+		if c == category {
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+// findDefaultTaxType returns the seeded definition of a built-in tax type
+func findDefaultTaxType(code string) (model.TaxTypeDefinition, bool) {
+	for _, t := range defaultTaxTypes {
+		if t.Code == code {
+			return t, true
+		}
+	}
+	return model.TaxTypeDefinition{}, false
+}
+
+// joinDocumentTypes stores document types comma-separated, without duplicates
+func joinDocumentTypes(documentTypes []string) string {
+	seen := make(map[string]bool, len(documentTypes))
+	unique := make([]string, 0, len(documentTypes))
+	for _, d := range documentTypes {
+		if !seen[d] {
+			seen[d] = true
+			unique = append(unique, d)
+		}
+	}
+	return strings.Join(unique, ",")
+}
+
+func toTaxTypeResponse(t model.TaxTypeDefinition) TaxTypeResponse {
+	documentTypes := []string{}
+	if t.DocumentTypes != "" {
+		documentTypes = strings.Split(t.DocumentTypes, ",")
+	}
+	return TaxTypeResponse{
+		Code:          t.Code,
+		Label:         t.Label,
+		Category:      t.Category,
+		DocumentTypes: documentTypes,
+		IsActive:      t.IsActive,
+	}
+}
@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/xlsx"
+
+	"github.com/beevik/etree"
+	"github.com/shopspring/decimal"
+)
+
+// vatNonTaxable is the VAT return category of lines not subject to VAT; other categories are
+// the rate, e.g. "10%"
+const vatNonTaxable = "NON_TAXABLE"
+
+// vatReturnLines are the amount lines of form 01/GTGT in form order. Sales at the reduced 8%
+// rate are declared with the 10% sales, as the reduction programs require.
+var vatReturnLines = []struct{ Code, Label string }{
+	{"22", "Thuế GTGT còn được khấu trừ kỳ trước chuyển sang"},
+	{"23", "Giá trị hàng hóa, dịch vụ mua vào"},
+	{"24", "Thuế GTGT của hàng hóa, dịch vụ mua vào"},
+	{"25", "Tổng số thuế GTGT được khấu trừ kỳ này"},
+	{"26", "Hàng hóa, dịch vụ bán ra không chịu thuế GTGT"},
+	{"27", "Hàng hóa, dịch vụ bán ra chịu thuế GTGT"},
+	{"28", "Thuế GTGT của hàng hóa, dịch vụ bán ra chịu thuế GTGT"},
+	{"29", "Hàng hóa, dịch vụ bán ra chịu thuế suất 0%"},
+	{"30", "Hàng hóa, dịch vụ bán ra chịu thuế suất 5%"},
+	{"31", "Thuế GTGT của hàng hóa, dịch vụ bán ra chịu thuế suất 5%"},
+	{"32", "Hàng hóa, dịch vụ bán ra chịu thuế suất 10% (gồm thuế suất giảm 8%)"},
+	{"33", "Thuế GTGT của hàng hóa, dịch vụ bán ra chịu thuế suất 10% (gồm thuế suất giảm 8%)"},
+	{"34", "Tổng doanh thu hàng hóa, dịch vụ bán ra"},
+	{"35", "Tổng số thuế GTGT của hàng hóa, dịch vụ bán ra"},
+	{"36", "Thuế GTGT phát sinh trong kỳ"},
+	{"40a", "Thuế GTGT phải nộp của hoạt động sản xuất kinh doanh trong kỳ"},
+	{"40", "Thuế GTGT còn phải nộp trong kỳ"},
+	{"41", "Thuế GTGT chưa khấu trừ hết kỳ này"},
+	{"43", "Thuế GTGT còn được khấu trừ chuyển kỳ sau"},
+}
+
+// vatGroupTags are the XML elements of the invoice list groups; rates not listed use the last one
+var vatGroupTags = map[string]string{
+	vatNonTaxable: "HHDVKhongChiuThue",
+	"0%":          "HHDVThueSuat0",
+	"5%":          "HHDVThueSuat5",
+	"8%":          "HHDVThueSuat8",
+	"10%":         "HHDVThueSuat10",
+	"":            "HHDVThueSuatKhac",
+}
+
+// --- DTOs ---
+
+type VATReturnFilter struct {
+	Period        string // YYYY-MM or YYYY-Qn
+	CarriedCredit string // Line 22: VAT credit carried from the previous return, in VND
+}
+
+type VATInvoiceResponse struct {
+	InvoiceID   string `json:"invoice_id"`
+	InvoiceNo   string `json:"invoice_no"`
+	InvoiceType string `json:"invoice_type"`
+	InvoiceDate string `json:"invoice_date"`
+	PartnerName string `json:"partner_name"`
+	TaxCode     string `json:"tax_code"`
+	Category    string `json:"category"` // NON_TAXABLE or the rate, e.g. 10%
+	Amount      string `json:"amount"`   // Before VAT
+	TaxAmount   string `json:"tax_amount"`
+}
+
+type VATRateGroupResponse struct {
+	Category     string `json:"category"`
+	InvoiceCount int    `json:"invoice_count"`
+	Amount       string `json:"amount"`
+	TaxAmount    string `json:"tax_amount"`
+}
+
+type VATSectionResponse struct {
+	Groups      []VATRateGroupResponse `json:"groups"`
+	Invoices    []VATInvoiceResponse   `json:"invoices"`
+	TotalAmount string                 `json:"total_amount"`
+	TotalTax    string                 `json:"total_tax"`
+}
+
+type VATReturnLineResponse struct {
+	Code   string `json:"code"` // Line of form 01/GTGT, e.g. 40a
+	Label  string `json:"label"`
+	Amount string `json:"amount"`
+}
+
+// VATReturnResponse is the VAT return of a period, in whole dong: output VAT of approved export
+// invoices and deductible input VAT of approved expense invoices, by rate
+type VATReturnResponse struct {
+	Period     string                  `json:"period"`
+	PeriodType string                  `json:"period_type"` // MONTH, QUARTER
+	From       string                  `json:"from"`
+	To         string                  `json:"to"`
+	Currency   string                  `json:"currency"`
+	Lines      []VATReturnLineResponse `json:"lines"`
+	Sales      VATSectionResponse      `json:"sales"`     // Invoices sold (PL 01-1)
+	Purchases  VATSectionResponse      `json:"purchases"` // Invoices purchased (PL 01-2)
+}
+
+// --- Implementation ---
+
+// vatSection holds the invoices of one side of the return converted to VND, grouped by category
+type vatSection struct {
+	invoices []vatInvoice
+	groups   map[string]*vatGroup
+	amount   decimal.Decimal
+	tax      decimal.Decimal
+}
+
+type vatInvoice struct {
+	row      repository.VATInvoiceRow
+	category string
+	amount   decimal.Decimal
+	tax      decimal.Decimal
+}
+
+type vatGroup struct {
+	category string
+	rate     decimal.Decimal
+	invoices map[string]bool
+	amount   decimal.Decimal
+	tax      decimal.Decimal
+}
+
+// vatReturn holds a computed return
+type vatReturn struct {
+	period    taxPeriod
+	lines     map[string]decimal.Decimal
+	sales     vatSection
+	purchases vatSection
+}
+
+func (s *taxReportService) GetVATReturn(ctx context.Context, filter VATReturnFilter) (VATReturnResponse, error) {
+	r, err := s.buildVATReturn(ctx, filter)
+	if err != nil {
+		return VATReturnResponse{}, err
+	}
+
+	lines := make([]VATReturnLineResponse, 0, len(vatReturnLines))
+	for _, l := range vatReturnLines {
+		lines = append(lines, VATReturnLineResponse{Code: l.Code, Label: l.Label, Amount: r.lines[l.Code].StringFixed(0)})
+	}
+	return VATReturnResponse{
+		Period:     r.period.Code,
+		PeriodType: r.period.Type,
+		From:       r.period.From.Format("2006-01-02"),
+		To:         r.period.LastDay.Format("2006-01-02"),
+		Currency:   declarationCurrency,
+		Lines:      lines,
+		Sales:      toVATSectionResponse(r.sales),
+		Purchases:  toVATSectionResponse(r.purchases),
+	}, nil
+}
+
+// ExportVATReturn renders the return as the XML HTKK imports for form 01/GTGT, with the invoice
+// lists as appendices, or as an XLSX workbook with the form and both lists
+func (s *taxReportService) ExportVATReturn(ctx context.Context, filter VATReturnFilter, format string) ([]byte, string, string, error) {
+	if format != "xml" && format != "xlsx" {
+		return nil, "", "", fmt.Errorf("invalid format %q; use xml or xlsx", format)
+	}
+	r, err := s.buildVATReturn(ctx, filter)
+	if err != nil {
+		return nil, "", "", err
+	}
+	name := "vat-return-" + r.period.Code
+
+	if format == "xlsx" {
+		data, err := xlsx.WriteBook(vatReturnSheets(r)...)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to write VAT return workbook: %w", err)
+		}
+		return data, name + ".xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+	}
+
+	company, err := s.loadTaxpayer(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+	doc := buildVATReturnXML(r, company, time.Now())
+	doc.Indent(2)
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to write VAT return XML: %w", err)
+	}
+	return data, name + ".xml", "application/xml", nil
+}
+
+func (s *taxReportService) buildVATReturn(ctx context.Context, filter VATReturnFilter) (vatReturn, error) {
+	period, err := parseTaxPeriod(filter.Period)
+	if err != nil {
+		return vatReturn{}, err
+	}
+	carried := decimal.Zero
+	if filter.CarriedCredit != "" {
+		carried, err = decimal.NewFromString(filter.CarriedCredit)
+		if err != nil || carried.IsNegative() {
+			return vatReturn{}, errors.New("carried_credit must be a non-negative amount")
+		}
+		carried = carried.Round(0)
+	}
+
+	// Only taxes of the VAT category are declared; duty and contractor tax have their own returns
+	vatTypes, err := taxTypesOfCategory(ctx, s.taxRuleRepo, model.TaxCategoryVAT)
+	if err != nil {
+		return vatReturn{}, err
+	}
+	salesRows, err := s.taxReportRepo.ListSalesVAT(ctx, model.RefTypeOrderExport, vatTypes, period.From, period.To)
+	if err != nil {
+		return vatReturn{}, fmt.Errorf("failed to fetch sales invoices: %w", err)
+	}
+	purchaseRows, err := s.taxReportRepo.ListPurchaseVAT(ctx, vatTypes, period.From, period.To)
+	if err != nil {
+		return vatReturn{}, fmt.Errorf("failed to fetch purchase invoices: %w", err)
+	}
+
+	conv := s.newVNDConverter(ctx)
+	r := vatReturn{period: period, lines: make(map[string]decimal.Decimal)}
+	if r.sales, err = buildVATSection(conv, salesRows); err != nil {
+		return vatReturn{}, err
+	}
+	if r.purchases, err = buildVATSection(conv, purchaseRows); err != nil {
+		return vatReturn{}, err
+	}
+
+	l := r.lines
+	l["22"] = carried
+	l["23"], l["24"] = r.purchases.amount, r.purchases.tax
+	l["25"] = l["24"]
+	for category, g := range r.sales.groups {
+		switch category {
+		case vatNonTaxable:
+			l["26"] = l["26"].Add(g.amount)
+		case "0%":
+			l["29"] = l["29"].Add(g.amount)
+		case "5%":
+			l["30"], l["31"] = l["30"].Add(g.amount), l["31"].Add(g.tax)
+		default:
+			l["32"], l["33"] = l["32"].Add(g.amount), l["33"].Add(g.tax)
+		}
+	}
+	l["27"] = l["29"].Add(l["30"]).Add(l["32"])
+	l["28"] = l["31"].Add(l["33"])
+	l["34"] = l["26"].Add(l["27"])
+	l["35"] = l["28"]
+	l["36"] = l["35"].Sub(l["25"])
+	balance := l["36"].Sub(l["22"])
+	if balance.IsPositive() {
+		l["40a"] = balance
+	} else {
+		l["41"] = balance.Neg()
+	}
+	l["40"], l["43"] = l["40a"], l["41"]
+	return r, nil
+}
+
+// buildVATSection converts the rows to VND and groups them by category
+func buildVATSection(conv *vndConverter, rows []repository.VATInvoiceRow) (vatSection, error) {
+	section := vatSection{groups: make(map[string]*vatGroup)}
+	for _, row := range rows {
+		amount, err := conv.convert(row.Amount, row.InvoiceDate)
+		if err != nil {
+			return vatSection{}, err
+		}
+		tax, err := conv.convert(row.TaxAmount, row.InvoiceDate)
+		if err != nil {
+			return vatSection{}, err
+		}
+
+		category := vatNonTaxable
+		if row.TaxType != "" {
+			category = row.TaxRate.Mul(decimal.NewFromInt(100)).String() + "%"
+		}
+		g, ok := section.groups[category]
+		if !ok {
+			g = &vatGroup{category: category, rate: row.TaxRate, invoices: make(map[string]bool)}
+			section.groups[category] = g
+		}
+		g.invoices[row.InvoiceID.String()] = true
+		g.amount, g.tax = g.amount.Add(amount), g.tax.Add(tax)
+		section.amount, section.tax = section.amount.Add(amount), section.tax.Add(tax)
+		section.invoices = append(section.invoices, vatInvoice{row: row, category: category, amount: amount, tax: tax})
+	}
+	return section, nil
+}
+
+// sortedGroups lists the groups with lines not subject to VAT first, then by rate
+func (s vatSection) sortedGroups() []*vatGroup {
+	groups := make([]*vatGroup, 0, len(s.groups))
+	for _, g := range s.groups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if (groups[i].category == vatNonTaxable) != (groups[j].category == vatNonTaxable) {
+			return groups[i].category == vatNonTaxable
+		}
+		return groups[i].rate.LessThan(groups[j].rate)
+	})
+	return groups
+}
+
+// invoicesOf returns the invoices of a category, in date order
+func (s vatSection) invoicesOf(category string) []vatInvoice {
+	var result []vatInvoice
+	for _, inv := range s.invoices {
+		if inv.category == category {
+			result = append(result, inv)
+		}
+	}
+	return result
+}
+
+// vatReturnSheets lays the return out as the form and the two invoice lists
+func vatReturnSheets(r vatReturn) []xlsx.Sheet {
+	form := xlsx.Sheet{Name: "01-GTGT", Header: true}
+	form.AddRow("Chỉ tiêu", "Nội dung", "Số tiền ("+declarationCurrency+")")
+	for _, l := range vatReturnLines {
+		form.AddRow("["+l.Code+"]", l.Label, xlsx.Number(r.lines[l.Code].StringFixed(0)))
+	}
+
+	list := func(name, partnerLabel string, section vatSection) xlsx.Sheet {
+		sheet := xlsx.Sheet{Name: name, Header: true}
+		sheet.AddRow("STT", "Nhóm thuế suất", "Số hóa đơn", "Loại hóa đơn", "Ngày hóa đơn",
+			"Tên "+partnerLabel, "MST "+partnerLabel, "Giá trị chưa có thuế", "Thuế GTGT")
+		no := 0
+		for _, g := range section.sortedGroups() {
+			for _, inv := range section.invoicesOf(g.category) {
+				no++
+				sheet.AddRow(no, g.category, inv.row.InvoiceNo, inv.row.InvoiceType, inv.row.InvoiceDate.Format("02/01/2006"),
+					inv.row.PartnerName, inv.row.TaxCode, xlsx.Number(inv.amount.StringFixed(0)), xlsx.Number(inv.tax.StringFixed(0)))
+			}
+			sheet.AddRow(nil, "Tổng "+g.category, nil, nil, nil, nil, nil,
+				xlsx.Number(g.amount.StringFixed(0)), xlsx.Number(g.tax.StringFixed(0)))
+		}
+		sheet.AddRow(nil, "Tổng cộng", nil, nil, nil, nil, nil,
+			xlsx.Number(section.amount.StringFixed(0)), xlsx.Number(section.tax.StringFixed(0)))
+		return sheet
+	}
+
+	return []xlsx.Sheet{
+		form,
+		list("PL01-1 Bán ra", "người mua", r.sales),
+		list("PL01-2 Mua vào", "người bán", r.purchases),
+	}
+}
+
+// buildVATReturnXML lays the return out as the HTKK XML of form 01/GTGT (Circular 80/2021): the
+// general information, the form lines, and the invoice lists sold and purchased as appendices.
+// Lines the system does not track (adjustments of earlier periods, refunds) are declared as 0.
+func buildVATReturnXML(r vatReturn, company model.DocumentTemplate, now time.Time) *etree.Document {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+	root := doc.CreateElement("HSoThueDTu")
+	root.CreateAttr("xmlns", "http://kekhaithue.gdt.gov.vn/TKhaiThue")
+	filing := root.CreateElement("HSoKhaiThue")
+
+	general := filing.CreateElement("TTinChung")
+	provider := general.CreateElement("TTinDVu")
+	provider.CreateElement("maDVu").SetText("HTKK")
+	provider.CreateElement("tenDVu").SetText("HTKK")
+
+	info := general.CreateElement("TTinTKhaiThue")
+	form := info.CreateElement("TKhaiThue")
+	form.CreateElement("maTKhai").SetText("842")
+	form.CreateElement("tenTKhai").SetText("TỜ KHAI THUẾ GIÁ TRỊ GIA TĂNG (Mẫu số 01/GTGT)")
+	form.CreateElement("moTaBMau").SetText("(Ban hành kèm theo Thông tư số 80/2021/TT-BTC ngày 29 tháng 9 năm 2021 của Bộ trưởng Bộ Tài chính)")
+	form.CreateElement("loaiTKhai").SetText("C") // Chính thức (original return)
+	form.CreateElement("soLan").SetText("0")
+	declarationPeriodXML(form, r.period)
+	form.CreateElement("ngayLapTKhai").SetText(now.Format("2006-01-02"))
+
+	taxpayerXML(info, company)
+
+	amount := func(parent *etree.Element, code string) {
+		parent.CreateElement("ct" + code).SetText(r.lines[code].StringFixed(0))
+	}
+	body := filing.CreateElement("CTieuTKhaiChinh")
+	noActivity := "0"
+	if len(r.sales.invoices) == 0 && len(r.purchases.invoices) == 0 {
+		noActivity = "1"
+	}
+	body.CreateElement("ct21").SetText(noActivity)
+	amount(body, "22")
+	purchases := body.CreateElement("GiaTriVaThueGTGTHHDVMuaVao")
+	amount(purchases, "23")
+	amount(purchases, "24")
+	amount(body, "25")
+	amount(body, "26")
+	taxable := body.CreateElement("HHDVBRaChiuThueGTGT")
+	amount(taxable, "27")
+	amount(taxable, "28")
+	amount(body, "29")
+	rate5 := body.CreateElement("HHDVBRaChiuTSuat5")
+	amount(rate5, "30")
+	amount(rate5, "31")
+	rate10 := body.CreateElement("HHDVBRaChiuTSuat10")
+	amount(rate10, "32")
+	amount(rate10, "33")
+	body.CreateElement("ct32a").SetText("0")
+	sales := body.CreateElement("TongDThuVaThueGTGTHHDVBRa")
+	amount(sales, "34")
+	amount(sales, "35")
+	amount(body, "36")
+	for _, code := range []string{"37", "38", "39a"} {
+		body.CreateElement("ct" + code).SetText("0")
+	}
+	amount(body, "40a")
+	body.CreateElement("ct40b").SetText("0")
+	amount(body, "40")
+	amount(body, "41")
+	body.CreateElement("ct42").SetText("0")
+	amount(body, "43")
+
+	appendices := filing.CreateElement("PLuc")
+	vatInvoiceListXML(appendices.CreateElement("PL01_1_GTGT"), r.sales, "NMUA")
+	vatInvoiceListXML(appendices.CreateElement("PL01_2_GTGT"), r.purchases, "NBAN")
+	return doc
+}
+
+// vatInvoiceListXML writes the invoices of a section per group, party being NMUA (buyer) or
+// NBAN (seller)
+func vatInvoiceListXML(parent *etree.Element, section vatSection, party string) {
+	for _, g := range section.sortedGroups() {
+		tag, ok := vatGroupTags[g.category]
+		if !ok {
+			tag = vatGroupTags[""]
+		}
+		group := parent.CreateElement(tag)
+		for i, inv := range section.invoicesOf(g.category) {
+			item := group.CreateElement("ChiTiet")
+			item.CreateElement("stt").SetText(fmt.Sprint(i + 1))
+			item.CreateElement("soHDon").SetText(inv.row.InvoiceNo)
+			item.CreateElement("ngayHDon").SetText(inv.row.InvoiceDate.Format("02/01/2006"))
+			item.CreateElement("ten" + party).SetText(inv.row.PartnerName)
+			item.CreateElement("mst" + party).SetText(inv.row.TaxCode)
+			rate := g.category
+			if rate == vatNonTaxable {
+				rate = "KCT" // Không chịu thuế
+			}
+			item.CreateElement("thueSuat").SetText(rate)
+			item.CreateElement("giaTriChuaThue").SetText(inv.amount.StringFixed(0))
+			item.CreateElement("thueGTGT").SetText(inv.tax.StringFixed(0))
+		}
+		group.CreateElement("tongGiaTriChuaThue").SetText(g.amount.StringFixed(0))
+		group.CreateElement("tongThueGTGT").SetText(g.tax.StringFixed(0))
+	}
+	parent.CreateElement("tongGiaTriChuaThue").SetText(section.amount.StringFixed(0))
+	parent.CreateElement("tongThueGTGT").SetText(section.tax.StringFixed(0))
+}
+
+// --- Mapping ---
+
+func toVATSectionResponse(section vatSection) VATSectionResponse {
+	resp := VATSectionResponse{
+		Groups:      make([]VATRateGroupResponse, 0, len(section.groups)),
+		Invoices:    make([]VATInvoiceResponse, 0, len(section.invoices)),
+		TotalAmount: section.amount.StringFixed(0),
+		TotalTax:    section.tax.StringFixed(0),
+	}
+	for _, g := range section.sortedGroups() {
+		resp.Groups = append(resp.Groups, VATRateGroupResponse{
+			Category:     g.category,
+			InvoiceCount: len(g.invoices),
+			Amount:       g.amount.StringFixed(0),
+			TaxAmount:    g.tax.StringFixed(0),
+		})
+		for _, inv := range section.invoicesOf(g.category) {
+			resp.Invoices = append(resp.Invoices, VATInvoiceResponse{
+				InvoiceID:   inv.row.InvoiceID.String(),
+				InvoiceNo:   inv.row.InvoiceNo,
+				InvoiceType: inv.row.InvoiceType,
+				InvoiceDate: inv.row.InvoiceDate.Format("2006-01-02"),
+				PartnerName: inv.row.PartnerName,
+				TaxCode:     inv.row.TaxCode,
+				Category:    g.category,
+				Amount:      inv.amount.StringFixed(0),
+				TaxAmount:   inv.tax.StringFixed(0),
+			})
+		}
+	}
+	return resp
+}
@@ -0,0 +1,29 @@
+// Package geo holds small geographic helpers shared by shipping cost and route planning.
+package geo
+
+import "math"
+
+// Point is a WGS84 coordinate
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// EarthRadiusKm is the mean Earth radius used by HaversineKm
+const EarthRadiusKm = 6371.0
+
+// HaversineKm returns the great-circle distance between two points in kilometres
+func HaversineKm(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// ValidCoordinates reports whether lat/lng are inside the WGS84 range
+func ValidCoordinates(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
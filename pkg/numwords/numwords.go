@@ -0,0 +1,156 @@
+// Package numwords spells out whole numbers in Vietnamese and English for "amount in words"
+// lines on printed invoices and vouchers.
+package numwords
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var viDigits = []string{"không", "một", "hai", "ba", "bốn", "năm", "sáu", "bảy", "tám", "chín"}
+
+// Vietnamese spells n the way amounts are read on Vietnamese invoices,
+// e.g. 1_005_021 → "một triệu không trăm linh năm nghìn không trăm hai mươi mốt"
+func Vietnamese(n int64) string {
+	if n == 0 {
+		return viDigits[0]
+	}
+	prefix := ""
+	u := uint64(n)
+	if n < 0 {
+		prefix = "âm "
+		u = uint64(-n)
+	}
+
+	groups := splitThousands(u)
+	var parts []string
+	for i := len(groups) - 1; i >= 0; i-- {
+		if groups[i] == 0 {
+			continue
+		}
+		// Every group after the leading one is read in full ("không trăm", "linh")
+		parts = append(parts, viTriple(groups[i], i != len(groups)-1))
+		if scale := viScale(i); scale != "" {
+			parts = append(parts, scale)
+		}
+	}
+	return prefix + strings.Join(parts, " ")
+}
+
+// viScale names the i-th group of three digits: nghìn, triệu, tỷ, nghìn tỷ, ...
+func viScale(i int) string {
+	name := []string{"", "nghìn", "triệu"}[i%3]
+	for k := 0; k < i/3; k++ {
+		name = strings.TrimSpace(name + " tỷ")
+	}
+	return name
+}
+
+func viTriple(n int, full bool) string {
+	h, t, u := n/100, n/10%10, n%10
+	var parts []string
+	if full || h > 0 {
+		parts = append(parts, viDigits[h], "trăm")
+	}
+	switch {
+	case t == 0:
+		if u > 0 {
+			if full || h > 0 {
+				parts = append(parts, "linh")
+			}
+			parts = append(parts, viDigits[u])
+		}
+	case t == 1:
+		parts = append(parts, "mười")
+		if u == 5 {
+			parts = append(parts, "lăm")
+		} else if u > 0 {
+			parts = append(parts, viDigits[u])
+		}
+	default:
+		parts = append(parts, viDigits[t], "mươi")
+		switch u {
+		case 0:
+		case 1:
+			parts = append(parts, "mốt")
+		case 4:
+			parts = append(parts, "tư")
+		case 5:
+			parts = append(parts, "lăm")
+		default:
+			parts = append(parts, viDigits[u])
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+var (
+	enOnes = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	enTens   = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	enScales = []string{"", "thousand", "million", "billion", "trillion", "quadrillion", "quintillion"}
+)
+
+// English spells n in short-scale English, e.g. 1_234 → "one thousand two hundred thirty-four"
+func English(n int64) string {
+	if n == 0 {
+		return enOnes[0]
+	}
+	prefix := ""
+	u := uint64(n)
+	if n < 0 {
+		prefix = "minus "
+		u = uint64(-n)
+	}
+
+	groups := splitThousands(u)
+	var parts []string
+	for i := len(groups) - 1; i >= 0; i-- {
+		if groups[i] == 0 {
+			continue
+		}
+		parts = append(parts, enTriple(groups[i]))
+		if enScales[i] != "" {
+			parts = append(parts, enScales[i])
+		}
+	}
+	return prefix + strings.Join(parts, " ")
+}
+
+func enTriple(n int) string {
+	h, rest := n/100, n%100
+	var parts []string
+	if h > 0 {
+		parts = append(parts, enOnes[h], "hundred")
+	}
+	switch {
+	case rest == 0:
+	case rest < 20:
+		parts = append(parts, enOnes[rest])
+	case rest%10 == 0:
+		parts = append(parts, enTens[rest/10])
+	default:
+		parts = append(parts, enTens[rest/10]+"-"+enOnes[rest%10])
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitThousands returns the groups of three digits of n, least significant first
+func splitThousands(n uint64) []int {
+	var groups []int
+	for n > 0 {
+		groups = append(groups, int(n%1000))
+		n /= 1000
+	}
+	return groups
+}
+
+// Capitalize upper-cases the first letter, as amount-in-words lines start a sentence
+func Capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
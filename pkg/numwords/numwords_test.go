@@ -0,0 +1,79 @@
+package numwords
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVietnamese(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "không"},
+		{5, "năm"},
+		{10, "mười"},
+		{11, "mười một"},
+		{15, "mười lăm"},
+		{20, "hai mươi"},
+		{21, "hai mươi mốt"},
+		{24, "hai mươi tư"},
+		{25, "hai mươi lăm"},
+		{100, "một trăm"},
+		{105, "một trăm linh năm"},
+		{110, "một trăm mười"},
+		{1000, "một nghìn"},
+		{1005, "một nghìn không trăm linh năm"},
+		{1015, "một nghìn không trăm mười lăm"},
+		{1_005_021, "một triệu không trăm linh năm nghìn không trăm hai mươi mốt"},
+		{2_000_000, "hai triệu"},
+		{1_000_000_000, "một tỷ"},
+		{1_000_000_000_000, "một nghìn tỷ"},
+		{-1005, "âm một nghìn không trăm linh năm"},
+		{math.MaxInt64, "chín tỷ tỷ hai trăm hai mươi ba triệu tỷ ba trăm bảy mươi hai nghìn tỷ không trăm ba mươi sáu tỷ " +
+			"tám trăm năm mươi tư triệu bảy trăm bảy mươi lăm nghìn tám trăm linh bảy"},
+	}
+	for _, tt := range tests {
+		if got := Vietnamese(tt.n); got != tt.want {
+			t.Errorf("Vietnamese(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestEnglish(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "zero"},
+		{13, "thirteen"},
+		{40, "forty"},
+		{99, "ninety-nine"},
+		{100, "one hundred"},
+		{1005, "one thousand five"},
+		{1234, "one thousand two hundred thirty-four"},
+		{1_000_001, "one million one"},
+		{-12, "minus twelve"},
+		{math.MinInt64, "minus nine quintillion two hundred twenty-three quadrillion three hundred seventy-two trillion " +
+			"thirty-six billion eight hundred fifty-four million seven hundred seventy-five thousand eight hundred eight"},
+	}
+	for _, tt := range tests {
+		if got := English(tt.n); got != tt.want {
+			t.Errorf("English(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestCapitalize(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"", ""},
+		{"một nghìn", "Một nghìn"},
+		{"âm năm", "Âm năm"},
+		{"one", "One"},
+	}
+	for _, tt := range tests {
+		if got := Capitalize(tt.in); got != tt.want {
+			t.Errorf("Capitalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+// Package routing plans delivery routes for a fleet of vehicles with a local heuristic:
+// nearest-neighbour construction that respects capacity and time windows, a cheapest-insertion
+// pass for stops the greedy walk skipped, then 2-opt improvement of each route. No external map service is used — distances are
+// great-circle distances and travel time assumes a constant average speed.
+package routing
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"backend/pkg/geo"
+)
+
+// Point is a WGS84 coordinate
+type Point = geo.Point
+
+// Stop is a delivery location with its load and optional time window
+type Stop struct {
+	ID          string
+	Location    Point
+	WeightKg    float64
+	VolumeM3    float64
+	WindowStart *time.Time // Earliest arrival; vehicle waits if early
+	WindowEnd   *time.Time // Latest arrival
+}
+
+// Vehicle is a truck with weight and volume capacity. Zero capacity means unlimited.
+type Vehicle struct {
+	ID          string
+	MaxWeightKg float64
+	MaxVolumeM3 float64
+}
+
+// Problem describes one planning run
+type Problem struct {
+	Depot       Point
+	Departure   time.Time
+	SpeedKmh    float64
+	ServiceTime time.Duration // Time spent unloading at each stop
+	Stops       []Stop
+	Vehicles    []Vehicle
+}
+
+// RouteStop is a scheduled visit within a route
+type RouteStop struct {
+	StopID     string
+	Sequence   int
+	DistanceKm float64 // From the previous stop (or depot)
+	Arrival    time.Time
+	Wait       time.Duration
+	Departure  time.Time
+}
+
+// Route is the stop sequence assigned to one vehicle
+type Route struct {
+	VehicleID       string
+	Stops           []RouteStop
+	TotalDistanceKm float64
+	LoadWeightKg    float64
+	LoadVolumeM3    float64
+	ReturnAt        time.Time
+}
+
+// Unassigned reports a stop that could not be placed on any route
+type Unassigned struct {
+	StopID string
+	Reason string
+}
+
+// Solution is the result of Solve
+type Solution struct {
+	Routes     []Route
+	Unassigned []Unassigned
+}
+
+var (
+	ErrNoVehicles = errors.New("routing: at least one vehicle is required")
+	ErrBadSpeed   = errors.New("routing: average speed must be greater than 0")
+)
+
+// Solve builds one route per vehicle (vehicles left without stops are omitted)
+func Solve(p Problem) (Solution, error) {
+	if len(p.Vehicles) == 0 {
+		return Solution{}, ErrNoVehicles
+	}
+	if p.SpeedKmh <= 0 {
+		return Solution{}, ErrBadSpeed
+	}
+
+	var sol Solution
+	remaining := make([]Stop, 0, len(p.Stops))
+	for _, st := range p.Stops {
+		if !fitsAnyVehicle(st, p.Vehicles) {
+			sol.Unassigned = append(sol.Unassigned, Unassigned{StopID: st.ID, Reason: "load exceeds the capacity of every vehicle"})
+			continue
+		}
+		remaining = append(remaining, st)
+	}
+
+	sequences := make([][]Stop, len(p.Vehicles))
+	for i, v := range p.Vehicles {
+		if len(remaining) == 0 {
+			break
+		}
+		sequences[i], remaining = nearestNeighbour(p, v, remaining)
+	}
+
+	// Nearest neighbour can walk past a stop whose window closes early; try to slot it in anywhere
+	var skipped []Stop
+	for _, st := range remaining {
+		if !insertCheapest(p, sequences, st) {
+			skipped = append(skipped, st)
+		}
+	}
+
+	for i, v := range p.Vehicles {
+		if len(sequences[i]) == 0 {
+			continue
+		}
+		sol.Routes = append(sol.Routes, buildRoute(p, v, twoOpt(p, sequences[i])))
+	}
+
+	for _, st := range skipped {
+		sol.Unassigned = append(sol.Unassigned, Unassigned{StopID: st.ID, Reason: "no vehicle can reach the stop within its time window and capacity"})
+	}
+	sort.Slice(sol.Unassigned, func(i, j int) bool { return sol.Unassigned[i].StopID < sol.Unassigned[j].StopID })
+
+	return sol, nil
+}
+
+// nearestNeighbour greedily appends the closest feasible stop until nothing else fits
+func nearestNeighbour(p Problem, v Vehicle, candidates []Stop) ([]Stop, []Stop) {
+	pool := append([]Stop(nil), candidates...)
+	var seq []Stop
+	current := p.Depot
+	clock := p.Departure
+	weight, volume := 0.0, 0.0
+
+	for {
+		best := -1
+		bestDist := math.MaxFloat64
+		for i, st := range pool {
+			if !withinCapacity(v, weight+st.WeightKg, volume+st.VolumeM3) {
+				continue
+			}
+			d := geo.HaversineKm(current, st.Location)
+			arrival := clock.Add(travelTime(d, p.SpeedKmh))
+			if st.WindowEnd != nil && arrival.After(*st.WindowEnd) {
+				continue
+			}
+			if d < bestDist {
+				best, bestDist = i, d
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		st := pool[best]
+		arrival := clock.Add(travelTime(bestDist, p.SpeedKmh))
+		if st.WindowStart != nil && arrival.Before(*st.WindowStart) {
+			arrival = *st.WindowStart
+		}
+		clock = arrival.Add(p.ServiceTime)
+		current = st.Location
+		weight += st.WeightKg
+		volume += st.VolumeM3
+		seq = append(seq, st)
+		pool = append(pool[:best], pool[best+1:]...)
+	}
+
+	return seq, pool
+}
+
+// insertCheapest places st at the feasible position (over all routes) that adds the least distance
+func insertCheapest(p Problem, sequences [][]Stop, st Stop) bool {
+	bestRoute, bestPos := -1, -1
+	bestDelta := math.MaxFloat64
+
+	for r, seq := range sequences {
+		weight, volume := st.WeightKg, st.VolumeM3
+		for _, other := range seq {
+			weight += other.WeightKg
+			volume += other.VolumeM3
+		}
+		if !withinCapacity(p.Vehicles[r], weight, volume) {
+			continue
+		}
+
+		base := routeDistance(p.Depot, seq)
+		for pos := 0; pos <= len(seq); pos++ {
+			candidate := inserted(seq, pos, st)
+			delta := routeDistance(p.Depot, candidate) - base
+			if delta < bestDelta && scheduleFeasible(p, candidate) {
+				bestRoute, bestPos, bestDelta = r, pos, delta
+			}
+		}
+	}
+
+	if bestRoute < 0 {
+		return false
+	}
+	sequences[bestRoute] = inserted(sequences[bestRoute], bestPos, st)
+	return true
+}
+
+// twoOpt reverses route segments while doing so shortens the route and keeps every window feasible
+func twoOpt(p Problem, seq []Stop) []Stop {
+	if len(seq) < 3 {
+		return seq
+	}
+	bestDist := routeDistance(p.Depot, seq)
+	improved := true
+	for improved {
+		improved = false
+		for i := 0; i < len(seq)-1; i++ {
+			for k := i + 1; k < len(seq); k++ {
+				candidate := reversed(seq, i, k)
+				d := routeDistance(p.Depot, candidate)
+				if d+1e-9 < bestDist && scheduleFeasible(p, candidate) {
+					seq, bestDist, improved = candidate, d, true
+				}
+			}
+		}
+	}
+	return seq
+}
+
+func buildRoute(p Problem, v Vehicle, seq []Stop) Route {
+	route := Route{VehicleID: v.ID}
+	current := p.Depot
+	clock := p.Departure
+
+	for i, st := range seq {
+		d := geo.HaversineKm(current, st.Location)
+		arrival := clock.Add(travelTime(d, p.SpeedKmh))
+		var wait time.Duration
+		if st.WindowStart != nil && arrival.Before(*st.WindowStart) {
+			wait = st.WindowStart.Sub(arrival)
+			arrival = *st.WindowStart
+		}
+		departure := arrival.Add(p.ServiceTime)
+
+		route.Stops = append(route.Stops, RouteStop{
+			StopID:     st.ID,
+			Sequence:   i + 1,
+			DistanceKm: d,
+			Arrival:    arrival,
+			Wait:       wait,
+			Departure:  departure,
+		})
+		route.TotalDistanceKm += d
+		route.LoadWeightKg += st.WeightKg
+		route.LoadVolumeM3 += st.VolumeM3
+		clock = departure
+		current = st.Location
+	}
+
+	back := geo.HaversineKm(current, p.Depot)
+	route.TotalDistanceKm += back
+	route.ReturnAt = clock.Add(travelTime(back, p.SpeedKmh))
+	return route
+}
+
+func scheduleFeasible(p Problem, seq []Stop) bool {
+	current := p.Depot
+	clock := p.Departure
+	for _, st := range seq {
+		arrival := clock.Add(travelTime(geo.HaversineKm(current, st.Location), p.SpeedKmh))
+		if st.WindowEnd != nil && arrival.After(*st.WindowEnd) {
+			return false
+		}
+		if st.WindowStart != nil && arrival.Before(*st.WindowStart) {
+			arrival = *st.WindowStart
+		}
+		clock = arrival.Add(p.ServiceTime)
+		current = st.Location
+	}
+	return true
+}
+
+// routeDistance is the closed-tour length depot → stops → depot
+func routeDistance(depot Point, seq []Stop) float64 {
+	total := 0.0
+	current := depot
+	for _, st := range seq {
+		total += geo.HaversineKm(current, st.Location)
+		current = st.Location
+	}
+	return total + geo.HaversineKm(current, depot)
+}
+
+func reversed(seq []Stop, i, k int) []Stop {
+	out := make([]Stop, len(seq))
+	copy(out, seq)
+	for a, b := i, k; a < b; a, b = a+1, b-1 {
+		out[a], out[b] = out[b], out[a]
+	}
+	return out
+}
+
+func inserted(seq []Stop, pos int, st Stop) []Stop {
+	out := make([]Stop, 0, len(seq)+1)
+	out = append(out, seq[:pos]...)
+	out = append(out, st)
+	return append(out, seq[pos:]...)
+}
+
+func fitsAnyVehicle(st Stop, vehicles []Vehicle) bool {
+	for _, v := range vehicles {
+		if withinCapacity(v, st.WeightKg, st.VolumeM3) {
+			return true
+		}
+	}
+	return false
+}
+
+func withinCapacity(v Vehicle, weight, volume float64) bool {
+	if v.MaxWeightKg > 0 && weight > v.MaxWeightKg {
+		return false
+	}
+	if v.MaxVolumeM3 > 0 && volume > v.MaxVolumeM3 {
+		return false
+	}
+	return true
+}
+
+func travelTime(distanceKm, speedKmh float64) time.Duration {
+	return time.Duration(distanceKm / speedKmh * float64(time.Hour))
+}
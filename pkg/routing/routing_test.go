@@ -0,0 +1,202 @@
+package routing
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	depot     = Point{Lat: 21.0285, Lng: 105.8542}
+	departure = time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+)
+
+// north returns a point about km kilometres north of the depot
+func north(km float64) Point {
+	return Point{Lat: depot.Lat + km/111.2, Lng: depot.Lng}
+}
+
+func at(d time.Duration) *time.Time {
+	t := departure.Add(d)
+	return &t
+}
+
+func TestSolveErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Problem
+		want error
+	}{
+		{"no vehicles", Problem{SpeedKmh: 40}, ErrNoVehicles},
+		{"zero speed", Problem{Vehicles: []Vehicle{{ID: "v1"}}}, ErrBadSpeed},
+		{"negative speed", Problem{SpeedKmh: -1, Vehicles: []Vehicle{{ID: "v1"}}}, ErrBadSpeed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Solve(tt.p); !errors.Is(err, tt.want) {
+				t.Fatalf("Solve() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolveInfeasibleStops(t *testing.T) {
+	tests := []struct {
+		name       string
+		vehicles   []Vehicle
+		stops      []Stop
+		assigned   []string
+		unassigned map[string]string // Stop ID → substring of the reason
+	}{
+		{
+			name:       "heavier than every vehicle",
+			vehicles:   []Vehicle{{ID: "v1", MaxWeightKg: 1000}, {ID: "v2", MaxWeightKg: 1500}},
+			stops:      []Stop{{ID: "a", Location: north(5), WeightKg: 2000}, {ID: "b", Location: north(6), WeightKg: 100}},
+			assigned:   []string{"b"},
+			unassigned: map[string]string{"a": "capacity of every vehicle"},
+		},
+		{
+			name:       "larger than every vehicle",
+			vehicles:   []Vehicle{{ID: "v1", MaxVolumeM3: 10}},
+			stops:      []Stop{{ID: "a", Location: north(5), VolumeM3: 12}},
+			unassigned: map[string]string{"a": "capacity of every vehicle"},
+		},
+		{
+			name:       "fits alone but not with the other stops",
+			vehicles:   []Vehicle{{ID: "v1", MaxWeightKg: 1000}},
+			stops:      []Stop{{ID: "a", Location: north(5), WeightKg: 600}, {ID: "b", Location: north(10), WeightKg: 600}},
+			assigned:   []string{"a"},
+			unassigned: map[string]string{"b": "time window and capacity"},
+		},
+		{
+			name:     "second vehicle takes the overflow",
+			vehicles: []Vehicle{{ID: "v1", MaxWeightKg: 1000}, {ID: "v2", MaxWeightKg: 1000}},
+			stops:    []Stop{{ID: "a", Location: north(5), WeightKg: 600}, {ID: "b", Location: north(10), WeightKg: 600}},
+			assigned: []string{"a", "b"},
+		},
+		{
+			name:     "zero capacity is unlimited",
+			vehicles: []Vehicle{{ID: "v1"}},
+			stops:    []Stop{{ID: "a", Location: north(5), WeightKg: 1e6, VolumeM3: 1e6}},
+			assigned: []string{"a"},
+		},
+		{
+			name:       "window closes before the vehicle can arrive",
+			vehicles:   []Vehicle{{ID: "v1"}},
+			stops:      []Stop{{ID: "a", Location: north(100), WindowEnd: at(30 * time.Minute)}, {ID: "b", Location: north(5)}},
+			assigned:   []string{"b"},
+			unassigned: map[string]string{"a": "time window"},
+		},
+		{
+			name:     "early window is reached by visiting it first",
+			vehicles: []Vehicle{{ID: "v1"}},
+			// Nearest neighbour visits b first and then misses a; insertion puts a before b
+			stops:    []Stop{{ID: "a", Location: north(20), WindowEnd: at(32 * time.Minute)}, {ID: "b", Location: north(-2)}},
+			assigned: []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sol, err := Solve(Problem{Depot: depot, Departure: departure, SpeedKmh: 40, Stops: tt.stops, Vehicles: tt.vehicles})
+			if err != nil {
+				t.Fatalf("Solve() error = %v", err)
+			}
+
+			var assigned []string
+			for _, r := range sol.Routes {
+				for _, s := range r.Stops {
+					assigned = append(assigned, s.StopID)
+				}
+			}
+			sort.Strings(assigned)
+			if strings.Join(assigned, ",") != strings.Join(tt.assigned, ",") {
+				t.Errorf("assigned stops = %v, want %v", assigned, tt.assigned)
+			}
+
+			if len(sol.Unassigned) != len(tt.unassigned) {
+				t.Fatalf("unassigned = %+v, want %d stops", sol.Unassigned, len(tt.unassigned))
+			}
+			for _, u := range sol.Unassigned {
+				want, ok := tt.unassigned[u.StopID]
+				if !ok {
+					t.Errorf("stop %s unexpectedly unassigned: %s", u.StopID, u.Reason)
+				} else if !strings.Contains(u.Reason, want) {
+					t.Errorf("stop %s reason = %q, want it to mention %q", u.StopID, u.Reason, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSolveSchedule(t *testing.T) {
+	sol, err := Solve(Problem{
+		Depot:       depot,
+		Departure:   departure,
+		SpeedKmh:    40,
+		ServiceTime: 15 * time.Minute,
+		Stops:       []Stop{{ID: "a", Location: north(20), WindowStart: at(2 * time.Hour)}},
+		Vehicles:    []Vehicle{{ID: "v1"}},
+	})
+	if err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	if len(sol.Routes) != 1 || len(sol.Routes[0].Stops) != 1 {
+		t.Fatalf("routes = %+v, want one route with one stop", sol.Routes)
+	}
+
+	stop := sol.Routes[0].Stops[0]
+	if !stop.Arrival.Equal(*at(2 * time.Hour)) {
+		t.Errorf("arrival = %v, want the window start %v", stop.Arrival, *at(2 * time.Hour))
+	}
+	// 20 km at 40 km/h takes 30 minutes, so the vehicle waits 90 minutes
+	if stop.Wait < 89*time.Minute || stop.Wait > 91*time.Minute {
+		t.Errorf("wait = %v, want about 90m", stop.Wait)
+	}
+	if !stop.Departure.Equal(stop.Arrival.Add(15 * time.Minute)) {
+		t.Errorf("departure = %v, want arrival + service time", stop.Departure)
+	}
+	if d := sol.Routes[0].TotalDistanceKm; d < 39.5 || d > 40.5 {
+		t.Errorf("total distance = %.2f km, want about 40 km there and back", d)
+	}
+}
+
+func TestSolveUnusedVehiclesOmitted(t *testing.T) {
+	sol, err := Solve(Problem{
+		Depot:     depot,
+		Departure: departure,
+		SpeedKmh:  40,
+		Stops:     []Stop{{ID: "a", Location: north(5)}},
+		Vehicles:  []Vehicle{{ID: "v1"}, {ID: "v2"}},
+	})
+	if err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	if len(sol.Routes) != 1 || sol.Routes[0].VehicleID != "v1" {
+		t.Errorf("routes = %+v, want a single route for v1", sol.Routes)
+	}
+}
+
+func TestTwoOptRemovesCrossing(t *testing.T) {
+	// Corners of a square next to the depot, visited diagonally so the route crosses itself
+	p := Problem{Depot: depot, Departure: departure, SpeedKmh: 40}
+	off := func(dLat, dLng float64) Point { return Point{Lat: depot.Lat + dLat, Lng: depot.Lng + dLng} }
+	seq := []Stop{
+		{ID: "a", Location: off(0.1, 0)},
+		{ID: "c", Location: off(0, 0.1)},
+		{ID: "b", Location: off(0.1, 0.1)},
+	}
+	improved := twoOpt(p, seq)
+	if routeDistance(depot, improved) >= routeDistance(depot, seq) {
+		t.Fatalf("twoOpt did not shorten the crossing route")
+	}
+	var order []string
+	for _, s := range improved {
+		order = append(order, s.ID)
+	}
+	if got := strings.Join(order, ""); got != "abc" && got != "cba" {
+		t.Errorf("twoOpt order = %s, want abc or cba", got)
+	}
+}
@@ -0,0 +1,119 @@
+// Package vnadmin validates Vietnamese addresses against a bundled list of provincial-level
+// administrative units (34 units in force since 1 July 2025) and the commune-level units (wards,
+// communes, special zones) of each. Names from before the 2025 mergers are still accepted and
+// resolved to the unit that absorbed them.
+package vnadmin
+
+import (
+	_ "embed"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// CountryCode is the ISO 3166-1 alpha-2 code handled by this package
+const CountryCode = "VN"
+
+// Province types
+const (
+	TypeCity     = "CITY"     // Thành phố trực thuộc trung ương
+	TypeProvince = "PROVINCE" // Tỉnh
+)
+
+// Province is a provincial-level administrative unit
+type Province struct {
+	Code   string   `json:"code"`
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Legacy []string `json:"legacy"` // Names merged into this unit, plus common aliases
+}
+
+//go:embed provinces.json
+var provincesJSON []byte
+
+var (
+	provinces []Province
+	byKey     map[string]int // normalized name/alias -> index in provinces
+	byCode    map[string]int
+)
+
+func init() {
+	if err := json.Unmarshal(provincesJSON, &provinces); err != nil {
+		panic("vnadmin: invalid bundled dataset: " + err.Error())
+	}
+	byKey = make(map[string]int, len(provinces)*2)
+	byCode = make(map[string]int, len(provinces))
+	for i, p := range provinces {
+		byCode[p.Code] = i
+		byKey[normalize(p.Name)] = i
+		for _, alias := range p.Legacy {
+			byKey[normalize(alias)] = i
+		}
+	}
+	// Wards refer to the provinces, so they are loaded once these are indexed
+	if err := LoadWards(wardsJSON); err != nil {
+		panic("vnadmin: invalid bundled ward list: " + err.Error())
+	}
+}
+
+// Provinces returns every current provincial-level unit ordered by code
+func Provinces() []Province {
+	out := make([]Province, len(provinces))
+	copy(out, provinces)
+	return out
+}
+
+// LookupProvince resolves a province name (with or without "Tỉnh"/"Thành phố", diacritics
+// optional, legacy names accepted) to its current unit
+func LookupProvince(name string) (Province, bool) {
+	i, ok := byKey[normalize(name)]
+	if !ok {
+		return Province{}, false
+	}
+	return provinces[i], true
+}
+
+// ProvinceByCode returns the unit with the given two-digit code
+func ProvinceByCode(code string) (Province, bool) {
+	i, ok := byCode[code]
+	if !ok {
+		return Province{}, false
+	}
+	return provinces[i], true
+}
+
+var postalCodePattern = regexp.MustCompile(`^\d{5}$`)
+
+// ValidPostalCode reports whether code has the 5-digit national postal code format
+func ValidPostalCode(code string) bool {
+	return postalCodePattern.MatchString(code)
+}
+
+var unitPrefixes = []string{"thanh pho ", "tp. ", "tp.", "tp ", "tinh "}
+
+// normalize lowercases, strips diacritics, administrative prefixes and punctuation
+func normalize(s string) string {
+	s = strings.NewReplacer("đ", "d", "Đ", "D").Replace(s)
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == '-' || r == ',' || r == '_':
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	s = strings.Join(strings.Fields(b.String()), " ")
+	for _, prefix := range unitPrefixes {
+		if strings.HasPrefix(s, prefix) {
+			s = strings.TrimSpace(strings.TrimPrefix(s, prefix))
+			break
+		}
+	}
+	return s
+}
@@ -0,0 +1,182 @@
+package vnadmin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBundledProvinces(t *testing.T) {
+	all := Provinces()
+	if len(all) != 34 {
+		t.Fatalf("Provinces() returned %d units, want 34", len(all))
+	}
+	seen := make(map[string]bool, len(all))
+	for _, p := range all {
+		if seen[p.Code] {
+			t.Errorf("duplicate province code %s", p.Code)
+		}
+		seen[p.Code] = true
+		if p.Type != TypeCity && p.Type != TypeProvince {
+			t.Errorf("%s: unexpected type %q", p.Name, p.Type)
+		}
+		if got, ok := ProvinceByCode(p.Code); !ok || got.Name != p.Name {
+			t.Errorf("ProvinceByCode(%s) = %q, %v", p.Code, got.Name, ok)
+		}
+		if got, ok := LookupProvince(p.Name); !ok || got.Code != p.Code {
+			t.Errorf("LookupProvince(%q) = %q, %v", p.Name, got.Code, ok)
+		}
+	}
+}
+
+func TestLookupProvince(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantCode string
+	}{
+		{"Thành phố Hà Nội", "01"},
+		{"Hà Nội", "01"},
+		{"ha noi", "01"},
+		{"TP. Hà Nội", "01"},
+		{"  HÀ   NỘI ", "01"},
+		{"Tỉnh Hà Giang", "08"}, // Merged into Tuyên Quang
+		{"ha giang", "08"},
+	}
+	for _, tt := range tests {
+		got, ok := LookupProvince(tt.name)
+		if !ok || got.Code != tt.wantCode {
+			t.Errorf("LookupProvince(%q) = %q, %v; want %s", tt.name, got.Code, ok, tt.wantCode)
+		}
+	}
+	for _, name := range []string{"", "Atlantis", "Hà"} {
+		if got, ok := LookupProvince(name); ok {
+			t.Errorf("LookupProvince(%q) = %q, want no match", name, got.Name)
+		}
+	}
+}
+
+func TestValidPostalCode(t *testing.T) {
+	for code, want := range map[string]bool{
+		"10000":  true,
+		"70000":  true,
+		"1000":   false,
+		"100000": false,
+		"1000a":  false,
+		"":       false,
+	} {
+		if got := ValidPostalCode(code); got != want {
+			t.Errorf("ValidPostalCode(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+// withWards loads the test ward list for the duration of a test
+func withWards(t *testing.T, data string) {
+	t.Helper()
+	t.Cleanup(func() {
+		if err := LoadWards(wardsJSON); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if err := LoadWards([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const testWards = `[
+	{"code": "00070", "name": "Phường Hoàn Kiếm", "type": "WARD", "province_code": "01", "legacy": ["Phường Hàng Trống"]},
+	{"code": "00004", "name": "Phường Ba Đình", "type": "WARD", "province_code": "01"},
+	{"code": "99001", "name": "Phường 1", "type": "WARD", "province_code": "01"}
+]`
+
+func TestLookupWard(t *testing.T) {
+	withWards(t, testWards)
+
+	tests := []struct {
+		province, name, want string
+	}{
+		{"01", "Phường Hoàn Kiếm", "Phường Hoàn Kiếm"},
+		{"01", "hoan kiem", "Phường Hoàn Kiếm"},
+		{"01", "P. Hoàn Kiếm", "Phường Hoàn Kiếm"},
+		{"01", "Phường Hàng Trống", "Phường Hoàn Kiếm"}, // Merged in 2025
+		{"01", "Ba Đình", "Phường Ba Đình"},
+		{"01", "Phường 01", "Phường 1"},
+	}
+	for _, tt := range tests {
+		got, ok := LookupWard(tt.province, tt.name)
+		if !ok || got.Name != tt.want {
+			t.Errorf("LookupWard(%s, %q) = %q, %v; want %q", tt.province, tt.name, got.Name, ok, tt.want)
+		}
+	}
+
+	// A ward only exists within its province
+	if got, ok := LookupWard("79", "Hoàn Kiếm"); ok {
+		t.Errorf("LookupWard(79, Hoàn Kiếm) = %q, want no match", got.Name)
+	}
+	if got, ok := LookupWard("01", "Bến Nghé"); ok {
+		t.Errorf("LookupWard(01, Bến Nghé) = %q, want no match", got.Name)
+	}
+
+	if !HasWards("01") || HasWards("79") {
+		t.Errorf("HasWards(01) = %v, HasWards(79) = %v; want true, false", HasWards("01"), HasWards("79"))
+	}
+	if got := Wards("01"); len(got) != 3 || got[0].Name != "Phường Hoàn Kiếm" {
+		t.Errorf("Wards(01) = %+v", got)
+	}
+}
+
+func TestLookupWardSharedName(t *testing.T) {
+	withWards(t, `[
+		{"code": "1", "name": "Phường Tân Phong", "type": "WARD", "province_code": "01"},
+		{"code": "2", "name": "Xã Tân Phong", "type": "COMMUNE", "province_code": "01"}
+	]`)
+
+	if got, ok := LookupWard("01", "Tân Phong"); ok {
+		t.Errorf("LookupWard(01, Tân Phong) = %q, want no match for an ambiguous name", got.Name)
+	}
+	for name, want := range map[string]string{"Phường Tân Phong": "1", "xa tan phong": "2"} {
+		if got, ok := LookupWard("01", name); !ok || got.Code != want {
+			t.Errorf("LookupWard(01, %q) = %q, %v; want %s", name, got.Code, ok, want)
+		}
+	}
+}
+
+func TestLoadWardsErrors(t *testing.T) {
+	withWards(t, testWards)
+
+	for name, data := range map[string]string{
+		"malformed":        `[{"name": }]`,
+		"unknown province": `[{"code": "1", "name": "Phường A", "province_code": "00"}]`,
+		"missing name":     `[{"code": "1", "name": " ", "province_code": "01"}]`,
+		"duplicate name":   `[{"code": "1", "name": "Phường A", "province_code": "01"}, {"code": "2", "name": "Phường A", "province_code": "01"}]`,
+	} {
+		if err := LoadWards([]byte(data)); err == nil {
+			t.Errorf("%s: LoadWards succeeded", name)
+		}
+	}
+	// A failed load keeps the previous list
+	if _, ok := LookupWard("01", "Hoàn Kiếm"); !ok {
+		t.Error("failed load replaced the ward list")
+	}
+}
+
+func TestLoadWardsFile(t *testing.T) {
+	t.Cleanup(func() {
+		if err := LoadWards(wardsJSON); err != nil {
+			t.Fatal(err)
+		}
+	})
+	path := filepath.Join(t.TempDir(), "wards.json")
+	if err := os.WriteFile(path, []byte(testWards), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadWardsFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := LookupWard("01", "Ba Đình"); !ok {
+		t.Error("ward list from file not loaded")
+	}
+	if err := LoadWardsFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadWardsFile succeeded on a missing file")
+	}
+}
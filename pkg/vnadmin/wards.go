@@ -0,0 +1,130 @@
+package vnadmin
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Ward types
+const (
+	TypeWard        = "WARD"         // Phường
+	TypeCommune     = "COMMUNE"      // Xã
+	TypeSpecialZone = "SPECIAL_ZONE" // Đặc khu
+)
+
+// Ward is a commune-level administrative unit of a province
+type Ward struct {
+	Code         string   `json:"code"`
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	ProvinceCode string   `json:"province_code"`
+	Legacy       []string `json:"legacy"` // Names merged into this unit, plus common aliases
+}
+
+// wardsJSON is the bundled ward list, in the format LoadWards reads. Provinces missing from it
+// have their wards accepted as given.
+//
+//go:embed wards.json
+var wardsJSON []byte
+
+var (
+	wardsByProvince map[string][]Ward
+	wardByKey       map[string]int // province code + normalized name/alias -> index in wardsByProvince[code], -1 when ambiguous
+)
+
+// LoadWards replaces the ward list with data, a JSON array of wards (e.g. the full official
+// list). It is not safe for concurrent use with the lookups, so call it at startup.
+func LoadWards(data []byte) error {
+	var wards []Ward
+	if err := json.Unmarshal(data, &wards); err != nil {
+		return err
+	}
+	byProvince := make(map[string][]Ward)
+	byKey := make(map[string]int, len(wards))
+	for i, w := range wards {
+		if _, ok := byCode[w.ProvinceCode]; !ok {
+			return fmt.Errorf("ward %d (%s): unknown province code %q", i+1, w.Name, w.ProvinceCode)
+		}
+		if strings.TrimSpace(w.Name) == "" {
+			return fmt.Errorf("ward %d: name is required", i+1)
+		}
+		idx := len(byProvince[w.ProvinceCode])
+		for _, name := range append([]string{w.Name}, w.Legacy...) {
+			full := w.ProvinceCode + "|" + normalize(name)
+			if prev, ok := byKey[full]; ok && prev != idx {
+				return fmt.Errorf("ward %d (%s): name %q is already used in province %s", i+1, w.Name, name, w.ProvinceCode)
+			}
+			byKey[full] = idx
+			// Without its prefix the name may be shared by a ward and a commune, which then
+			// have to be told apart by the prefix
+			short := w.ProvinceCode + "|" + normalizeWard(name)
+			if prev, ok := byKey[short]; ok && prev != idx {
+				byKey[short] = -1
+			} else if !ok {
+				byKey[short] = idx
+			}
+		}
+		byProvince[w.ProvinceCode] = append(byProvince[w.ProvinceCode], w)
+	}
+	wardsByProvince, wardByKey = byProvince, byKey
+	return nil
+}
+
+// LoadWardsFile is LoadWards reading the list from a file
+func LoadWardsFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := LoadWards(data); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// HasWards reports whether the ward list covers the province, i.e. whether its wards can be
+// validated
+func HasWards(provinceCode string) bool {
+	return len(wardsByProvince[provinceCode]) > 0
+}
+
+// Wards returns the wards of a province in list order
+func Wards(provinceCode string) []Ward {
+	out := make([]Ward, len(wardsByProvince[provinceCode]))
+	copy(out, wardsByProvince[provinceCode])
+	return out
+}
+
+// LookupWard resolves a ward name (with or without "Phường"/"Xã"/"Đặc khu", diacritics optional,
+// legacy names accepted) within a province to its current unit
+func LookupWard(provinceCode, name string) (Ward, bool) {
+	i, ok := wardByKey[provinceCode+"|"+normalize(name)]
+	if !ok {
+		i, ok = wardByKey[provinceCode+"|"+normalizeWard(name)]
+	}
+	if !ok || i < 0 {
+		return Ward{}, false
+	}
+	return wardsByProvince[provinceCode][i], true
+}
+
+var wardPrefixes = []string{"phuong ", "p. ", "p.", "xa ", "dac khu "}
+
+// normalizeWard is normalize for ward names, whose numbered wards ("Phường 01") match without
+// the leading zero
+func normalizeWard(s string) string {
+	s = normalize(s)
+	for _, prefix := range wardPrefixes {
+		if strings.HasPrefix(s, prefix) {
+			s = strings.TrimSpace(strings.TrimPrefix(s, prefix))
+			break
+		}
+	}
+	if trimmed := strings.TrimLeft(s, "0"); trimmed != "" && trimmed[0] >= '1' && trimmed[0] <= '9' {
+		s = trimmed
+	}
+	return s
+}
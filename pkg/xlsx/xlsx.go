@@ -0,0 +1,195 @@
+// Package xlsx writes simple Office Open XML workbooks: text and number cells,
+// with an optional bold header row. It covers report exports without a spreadsheet library.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Number is a cell value written as a number; use it for amounts so spreadsheets can sum them.
+// The string must be a plain decimal such as "-1234.5000".
+type Number string
+
+// numberPattern accepts plain decimals; ParseFloat would also let NaN, Inf and hex floats through
+var numberPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// Sheet is a worksheet of rows. The first row is rendered bold when Header is set.
+type Sheet struct {
+	Name   string
+	Header bool
+	Rows   [][]interface{}
+}
+
+// AddRow appends a row; values may be string, Number, int, int64 or float64
+func (s *Sheet) AddRow(values ...interface{}) {
+	s.Rows = append(s.Rows, values)
+}
+
+// Write encodes the sheet as an .xlsx workbook
+func Write(sheet Sheet) ([]byte, error) {
+	return WriteBook(sheet)
+}
+
+// WriteBook encodes the sheets, in order, as one .xlsx workbook
+func WriteBook(sheets ...Sheet) ([]byte, error) {
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("a workbook needs at least one sheet")
+	}
+
+	var overrides, entries, rels strings.Builder
+	var worksheets []part
+	seen := make(map[string]bool)
+	for i, sheet := range sheets {
+		name := sheetName(sheet.Name, i)
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("duplicate sheet name %q", name)
+		}
+		seen[strings.ToLower(name)] = true
+
+		body, err := sheetXML(sheet)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %q: %w", name, err)
+		}
+		n := i + 1
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&entries, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+		worksheets = append(worksheets, part{fmt.Sprintf("xl/worksheets/sheet%d.xml", n), body})
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(sheets)+1)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := []part{
+		{"[Content_Types].xml", fmt.Sprintf(contentTypesXML, overrides.String())},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", fmt.Sprintf(workbookXML, entries.String())},
+		{"xl/_rels/workbook.xml.rels", fmt.Sprintf(workbookRelsXML, rels.String())},
+		{"xl/styles.xml", stylesXML},
+	}
+	for _, f := range append(parts, worksheets...) {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(f.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// part is a file of the workbook package
+type part struct {
+	name    string
+	content string
+}
+
+// sheetName cleans a sheet name: names are limited to 31 characters and may not contain []:*?/\
+func sheetName(name string, index int) string {
+	if name == "" {
+		name = "Sheet" + strconv.Itoa(index+1)
+	}
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	if r := []rune(name); len(r) > 31 {
+		name = string(r[:31])
+	}
+	return name
+}
+
+func sheetXML(sheet Sheet) (string, error) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range sheet.Rows {
+		rowNo := i + 1
+		fmt.Fprintf(&b, `<row r="%d">`, rowNo)
+		style := ""
+		if sheet.Header && i == 0 {
+			style = ` s="1"`
+		}
+		for j, value := range row {
+			ref := columnName(j) + strconv.Itoa(rowNo)
+			switch v := value.(type) {
+			case nil:
+				continue
+			case string:
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escape(v))
+			case Number:
+				if !numberPattern.MatchString(string(v)) {
+					return "", fmt.Errorf("cell %s: invalid number %q", ref, v)
+				}
+				fmt.Fprintf(&b, `<c r="%s"%s><v>%s</v></c>`, ref, style, v)
+			case int:
+				fmt.Fprintf(&b, `<c r="%s"%s><v>%d</v></c>`, ref, style, v)
+			case int64:
+				fmt.Fprintf(&b, `<c r="%s"%s><v>%d</v></c>`, ref, style, v)
+			case float64:
+				fmt.Fprintf(&b, `<c r="%s"%s><v>%s</v></c>`, ref, style, strconv.FormatFloat(v, 'f', -1, 64))
+			default:
+				return "", fmt.Errorf("cell %s: unsupported value type %T", ref, value)
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String(), nil
+}
+
+// columnName converts a zero-based column index to its letters: 0 → A, 26 → AA
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const contentTypesXML = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`%s` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const rootRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbookXML = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets>%s</sheets>` +
+	`</workbook>`
+
+const workbookRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`%s` +
+	`</Relationships>`
+
+// stylesXML defines cell format 0 (default) and 1 (bold, for the header row)
+const stylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
@@ -0,0 +1,193 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readPart returns a file of the workbook package
+func readPart(t *testing.T, data []byte, name string) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("workbook is not a zip archive: %v", err)
+	}
+	for _, f := range zr.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatalf("open %s: %v", name, err)
+			}
+			defer rc.Close()
+			content, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("read %s: %v", name, err)
+			}
+			return content
+		}
+	}
+	t.Fatalf("workbook has no part %s", name)
+	return nil
+}
+
+type parsedSheet struct {
+	Rows []struct {
+		R     string `xml:"r,attr"`
+		Cells []struct {
+			R      string `xml:"r,attr"`
+			T      string `xml:"t,attr"`
+			S      string `xml:"s,attr"`
+			V      string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestWriteEscapesText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string // What a reader sees after decoding
+	}{
+		{"markup characters", `A & B <c> "d" 'e'`, `A & B <c> "d" 'e'`},
+		{"closing tags", `</t></is></c>`, `</t></is></c>`},
+		{"vietnamese", "Công ty TNHH Đại Việt", "Công ty TNHH Đại Việt"},
+		{"surrounding spaces are kept", "  padded  ", "  padded  "},
+		{"line breaks", "line 1\nline 2", "line 1\nline 2"},
+		{"characters not allowed in XML", "a\x01b", "a�b"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet := Sheet{Name: "Data"}
+			sheet.AddRow(tt.in)
+			data, err := Write(sheet)
+			if err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			var parsed parsedSheet
+			if err := xml.Unmarshal(readPart(t, data, "xl/worksheets/sheet1.xml"), &parsed); err != nil {
+				t.Fatalf("sheet XML does not parse: %v", err)
+			}
+			if len(parsed.Rows) != 1 || len(parsed.Rows[0].Cells) != 1 {
+				t.Fatalf("rows = %+v, want one cell", parsed.Rows)
+			}
+			cell := parsed.Rows[0].Cells[0]
+			if cell.T != "inlineStr" || cell.Inline != tt.want {
+				t.Errorf("cell = %q (type %s), want %q", cell.Inline, cell.T, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCells(t *testing.T) {
+	sheet := Sheet{Name: "Aging", Header: true}
+	sheet.AddRow("Partner", "Amount")
+	sheet.AddRow("ACME", Number("-1234.5000"), nil, 7, int64(8), 2.5)
+	data, err := Write(sheet)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var parsed parsedSheet
+	if err := xml.Unmarshal(readPart(t, data, "xl/worksheets/sheet1.xml"), &parsed); err != nil {
+		t.Fatalf("sheet XML does not parse: %v", err)
+	}
+	if len(parsed.Rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(parsed.Rows))
+	}
+	for _, c := range parsed.Rows[0].Cells {
+		if c.S != "1" {
+			t.Errorf("header cell %s has style %q, want bold (1)", c.R, c.S)
+		}
+	}
+
+	got := make(map[string]string)
+	for _, c := range parsed.Rows[1].Cells {
+		if c.S != "" {
+			t.Errorf("body cell %s has style %q, want none", c.R, c.S)
+		}
+		got[c.R] = c.V + c.Inline
+	}
+	want := map[string]string{"A2": "ACME", "B2": "-1234.5000", "D2": "7", "E2": "8", "F2": "2.5"}
+	for ref, v := range want {
+		if got[ref] != v {
+			t.Errorf("cell %s = %q, want %q", ref, got[ref], v)
+		}
+	}
+	if _, ok := got["C2"]; ok {
+		t.Errorf("nil value was written to C2")
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		sheets  []Sheet
+		wantErr string
+	}{
+		{"no sheets", nil, "at least one sheet"},
+		{"duplicate names", []Sheet{{Name: "Data"}, {Name: "DATA"}}, `duplicate sheet name "DATA"`},
+		{"duplicate after cleaning", []Sheet{{Name: "A/B"}, {Name: "A-B"}}, `duplicate sheet name "A-B"`},
+		{"unsupported value", []Sheet{{Rows: [][]interface{}{{true}}}}, "unsupported value type bool"},
+		{"number with a comma", []Sheet{{Rows: [][]interface{}{{Number("1,5")}}}}, `invalid number "1,5"`},
+		{"NaN", []Sheet{{Rows: [][]interface{}{{Number("NaN")}}}}, `invalid number "NaN"`},
+		{"infinity", []Sheet{{Rows: [][]interface{}{{Number("Inf")}}}}, `invalid number "Inf"`},
+		{"exponent", []Sheet{{Rows: [][]interface{}{{Number("1e5")}}}}, `invalid number "1e5"`},
+		{"hex float", []Sheet{{Rows: [][]interface{}{{"x", Number("0x1p-2")}}}}, `cell B1: invalid number "0x1p-2"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := WriteBook(tt.sheets...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("WriteBook() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteBookSheetNames(t *testing.T) {
+	data, err := WriteBook(Sheet{Name: "Phải thu & <nợ>"}, Sheet{}, Sheet{Name: "Q1/2026: [tổng hợp]*?"}, Sheet{Name: strings.Repeat("x", 40)})
+	if err != nil {
+		t.Fatalf("WriteBook() error = %v", err)
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(readPart(t, data, "xl/workbook.xml"), &workbook); err != nil {
+		t.Fatalf("workbook XML does not parse: %v", err)
+	}
+	want := []string{"Phải thu & <nợ>", "Sheet2", "Q1-2026- -tổng hợp---", strings.Repeat("x", 31)}
+	if len(workbook.Sheets) != len(want) {
+		t.Fatalf("got %d sheets, want %d", len(workbook.Sheets), len(want))
+	}
+	for i, s := range workbook.Sheets {
+		if s.Name != want[i] {
+			t.Errorf("sheet %d name = %q, want %q", i+1, s.Name, want[i])
+		}
+	}
+	readPart(t, data, "xl/worksheets/sheet4.xml")
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"}, {25, "Z"}, {26, "AA"}, {51, "AZ"}, {52, "BA"}, {701, "ZZ"}, {702, "AAA"}, {16383, "XFD"},
+	}
+	for _, tt := range tests {
+		if got := columnName(tt.index); got != tt.want {
+			t.Errorf("columnName(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Package xsd validates XML documents against W3C XML Schemas with libxml2, so complete
+// schemas such as the official e-invoice XSD (xs:choice, xs:import, element references) load
+// as published. It needs cgo and the libxml2 headers at build time (libxml2-dev / libxml2-devel).
+// Schemas are never fetched over the network: imports must be reachable as local files.
+package xsd
+
+/*
+#cgo pkg-config: libxml-2.0
+#include <stdio.h>
+#include <stdlib.h>
+#include <libxml/parser.h>
+#include <libxml/xmlschemas.h>
+
+#define XSD_MAX_ERRORS 20
+
+#if LIBXML_VERSION >= 21200
+typedef const xmlError xsdError;
+#else
+typedef xmlError xsdError;
+#endif
+
+// xsdErrors collects the first XSD_MAX_ERRORS messages, one per line
+typedef struct {
+	char   buf[8192];
+	size_t len;
+	int    count;
+} xsdErrors;
+
+static void xsdCollect(void *data, xsdError *err) {
+	xsdErrors *e = (xsdErrors *)data;
+	if (err == NULL || err->level < XML_ERR_ERROR) {
+		return;
+	}
+	e->count++;
+	if (e->count > XSD_MAX_ERRORS || e->len >= sizeof(e->buf) - 1) {
+		return;
+	}
+	const char *msg = err->message != NULL ? err->message : "unknown error\n";
+	int n = err->line > 0
+		? snprintf(e->buf + e->len, sizeof(e->buf) - e->len, "line %d: %s\n", err->line, msg)
+		: snprintf(e->buf + e->len, sizeof(e->buf) - e->len, "%s\n", msg);
+	if (n > 0) {
+		e->len += (size_t)n;
+		if (e->len >= sizeof(e->buf)) {
+			e->len = sizeof(e->buf) - 1;
+		}
+	}
+}
+
+static xmlSchemaPtr xsdParse(xmlSchemaParserCtxtPtr ctxt, xsdErrors *e) {
+	if (ctxt == NULL) {
+		return NULL;
+	}
+	xmlSchemaSetParserStructuredErrors(ctxt, xsdCollect, e);
+	xmlSchemaPtr schema = xmlSchemaParse(ctxt);
+	xmlSchemaFreeParserCtxt(ctxt);
+	return schema;
+}
+
+static xmlSchemaPtr xsdParseFile(const char *path, xsdErrors *e) {
+	return xsdParse(xmlSchemaNewParserCtxt(path), e);
+}
+
+static xmlSchemaPtr xsdParseMemory(const char *buf, int size, xsdErrors *e) {
+	return xsdParse(xmlSchemaNewMemParserCtxt(buf, size), e);
+}
+
+// xsdValidate returns 0 when the document is valid, a positive number when it is not, -1 on an
+// internal error and -2 when the document is not well-formed XML
+static int xsdValidate(xmlSchemaPtr schema, const char *buf, int size, xsdErrors *e) {
+	xmlParserCtxtPtr pctxt = xmlNewParserCtxt();
+	if (pctxt == NULL) {
+		return -1;
+	}
+	xmlDocPtr doc = xmlCtxtReadMemory(pctxt, buf, size, NULL, NULL,
+		XML_PARSE_NONET | XML_PARSE_NOERROR | XML_PARSE_NOWARNING);
+	if (doc == NULL) {
+		xsdError *err = xmlCtxtGetLastError(pctxt);
+		if (err != NULL) {
+			xsdCollect(e, err);
+		}
+		xmlFreeParserCtxt(pctxt);
+		return -2;
+	}
+	xmlFreeParserCtxt(pctxt);
+
+	xmlSchemaValidCtxtPtr vctxt = xmlSchemaNewValidCtxt(schema);
+	if (vctxt == NULL) {
+		xmlFreeDoc(doc);
+		return -1;
+	}
+	xmlSchemaSetValidStructuredErrors(vctxt, xsdCollect, e);
+	int rc = xmlSchemaValidateDoc(vctxt, doc);
+	xmlSchemaFreeValidCtxt(vctxt);
+	xmlFreeDoc(doc);
+	return rc;
+}
+
+// xsdSilent drops the messages libxml2 prints outside the collected errors (e.g. pattern
+// compilation details), which are reported through xsdCollect as well
+static void xsdSilent(void *ctx, const char *msg, ...) {
+}
+
+static void xsdInit(void) {
+	xmlInitParser();
+	xmlSetExternalEntityLoader(xmlNoNetExternalEntityLoader);
+	xmlThrDefSetGenericErrorFunc(NULL, xsdSilent);
+	xmlSetGenericErrorFunc(NULL, xsdSilent);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unsafe"
+)
+
+// maxReportedErrors caps the number of validation errors joined into one error
+const maxReportedErrors = C.XSD_MAX_ERRORS
+
+func init() {
+	C.xsdInit()
+}
+
+// Schema is a compiled schema. It is read-only once parsed and safe for concurrent use.
+type Schema struct {
+	ptr C.xmlSchemaPtr
+}
+
+// Load compiles the schema file at path; xs:import and xs:include locations are resolved
+// relative to it
+func Load(path string) (*Schema, error) {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+
+	var collected C.xsdErrors
+	ptr := C.xsdParseFile(cpath, &collected)
+	return newSchema(ptr, &collected, path)
+}
+
+// Parse compiles a schema held in memory. Relative import locations are resolved from the
+// working directory; use Load or ParseFS for schemas that import others.
+func Parse(data []byte) (*Schema, error) {
+	if len(data) == 0 {
+		return nil, errors.New("xsd: empty schema")
+	}
+	var collected C.xsdErrors
+	ptr := C.xsdParseMemory((*C.char)(unsafe.Pointer(&data[0])), C.int(len(data)), &collected)
+	runtime.KeepAlive(data)
+	return newSchema(ptr, &collected, "schema")
+}
+
+// MustParse is Parse panicking on error, for schemas embedded in the binary
+func MustParse(data []byte) *Schema {
+	s, err := Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// ParseFS compiles the schema name of fsys together with the schemas it imports, which are
+// looked up in fsys relative to name (e.g. schemas embedded with go:embed)
+func ParseFS(fsys fs.FS, name string) (*Schema, error) {
+	dir, err := os.MkdirTemp("", "xsd-")
+	if err != nil {
+		return nil, fmt.Errorf("xsd: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(path))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o700)
+		}
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o600)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("xsd: failed to copy schemas: %w", err)
+	}
+	// Everything is resolved while parsing, so the copies can go once the schema is compiled
+	return Load(filepath.Join(dir, filepath.FromSlash(name)))
+}
+
+// MustParseFS is ParseFS panicking on error, for schemas embedded in the binary
+func MustParseFS(fsys fs.FS, name string) *Schema {
+	s, err := ParseFS(fsys, name)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Validate checks that doc is well-formed and valid against the schema. The error joins the
+// first violations found, each prefixed with its line.
+func (s *Schema) Validate(doc []byte) error {
+	if len(doc) == 0 {
+		return errors.New("malformed XML: empty document")
+	}
+	var collected C.xsdErrors
+	rc := C.xsdValidate(s.ptr, (*C.char)(unsafe.Pointer(&doc[0])), C.int(len(doc)), &collected)
+	runtime.KeepAlive(doc)
+	runtime.KeepAlive(s)
+
+	switch {
+	case rc == 0:
+		return nil
+	case rc == -2:
+		return fmt.Errorf("malformed XML: %w", collectedError(&collected))
+	case rc < 0:
+		return errors.New("xsd: internal validation error")
+	default:
+		return collectedError(&collected)
+	}
+}
+
+func newSchema(ptr C.xmlSchemaPtr, collected *C.xsdErrors, name string) (*Schema, error) {
+	if ptr == nil {
+		return nil, fmt.Errorf("xsd: invalid %s: %w", name, collectedError(collected))
+	}
+	s := &Schema{ptr: ptr}
+	runtime.SetFinalizer(s, func(s *Schema) { C.xmlSchemaFree(s.ptr) })
+	return s, nil
+}
+
+// collectedError turns the collected messages into one error
+func collectedError(collected *C.xsdErrors) error {
+	text := C.GoStringN(&collected.buf[0], C.int(collected.len))
+	var errs []error
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			errs = append(errs, errors.New(line))
+		}
+	}
+	if len(errs) == 0 {
+		return errors.New("unknown error")
+	}
+	if extra := int(collected.count) - maxReportedErrors; extra > 0 {
+		errs = append(errs, fmt.Errorf("... and %d more errors", extra))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,268 @@
+package xsd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const testSchema = `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:simpleType name="sCode">
+    <xs:restriction base="xs:string">
+      <xs:pattern value="\d{3}"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="sRate">
+    <xs:restriction base="xs:string">
+      <xs:pattern value="0%|5%|10%"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="sAmount">
+    <xs:restriction base="xs:decimal">
+      <xs:totalDigits value="6"/>
+      <xs:fractionDigits value="2"/>
+      <xs:minInclusive value="0"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:simpleType name="sShortCode">
+    <xs:restriction base="sCode">
+      <xs:enumeration value="100"/>
+      <xs:enumeration value="200"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:complexType name="tLine">
+    <xs:sequence>
+      <xs:element name="Code" type="sCode"/>
+      <xs:element name="Rate" type="sRate" minOccurs="0"/>
+      <xs:element name="Amount" type="sAmount"/>
+    </xs:sequence>
+    <xs:attribute name="no" type="xs:positiveInteger" use="required"/>
+  </xs:complexType>
+  <xs:element name="Doc">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="Kind" type="sShortCode"/>
+        <xs:element name="Date" type="xs:date"/>
+        <xs:element name="Note" minOccurs="0" maxOccurs="2"/>
+        <xs:element name="Line" type="tLine" maxOccurs="unbounded"/>
+        <xs:any namespace="urn:sig" processContents="lax" minOccurs="0"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+
+func TestValidate(t *testing.T) {
+	schema, err := Parse([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	line := `<Line no="1"><Code>123</Code><Rate>10%</Rate><Amount>1500.50</Amount></Line>`
+	doc := func(body string) string {
+		return `<Doc><Kind>100</Kind><Date>2026-03-02</Date>` + body + `</Doc>`
+	}
+
+	tests := []struct {
+		name    string
+		xml     string
+		wantErr string // Empty for a valid document
+	}{
+		{"valid", doc(line), ""},
+		{"optional elements present", doc(`<Note>a</Note><Note>b</Note>` + line + line + `<s:Sig xmlns:s="urn:sig"/>`), ""},
+		{"optional element omitted", doc(`<Line no="2"><Code>123</Code><Amount>1</Amount></Line>`), ""},
+
+		// Patterns are anchored: a match inside a longer value is not enough
+		{"pattern with a trailing extra", doc(`<Line no="1"><Code>1234</Code><Amount>1</Amount></Line>`), "The value '1234' is not accepted by the pattern"},
+		{"pattern with a leading extra", doc(`<Line no="1"><Code>a123</Code><Amount>1</Amount></Line>`), "The value 'a123' is not accepted by the pattern"},
+		{"alternation is anchored as a whole", doc(`<Line no="1"><Code>123</Code><Rate>15%</Rate><Amount>1</Amount></Line>`), "The value '15%' is not accepted by the pattern"},
+		{"alternation prefix", doc(`<Line no="1"><Code>123</Code><Rate>10%x</Rate><Amount>1</Amount></Line>`), "The value '10%x' is not accepted by the pattern"},
+
+		// Occurrence bounds
+		{"required element missing", doc(``), "Missing child element(s)"},
+		{"maxOccurs exceeded", doc(`<Note>a</Note><Note>b</Note><Note>c</Note>` + line), "Element 'Note': This element is not expected"},
+		{"elements out of order", `<Doc><Date>2026-03-02</Date><Kind>100</Kind>` + line + `</Doc>`, "Element 'Date': This element is not expected"},
+		{"any from another namespace", doc(line + `<s:Sig xmlns:s="urn:other"/>`), "Element '{urn:other}Sig': This element is not expected"},
+		{"unknown trailing element", doc(line + `<Extra/>`), "Element 'Extra': This element is not expected"},
+
+		// Facets and built-in types
+		{"enumeration of a derived type", `<Doc><Kind>300</Kind><Date>2026-03-02</Date>` + line + `</Doc>`, "[facet 'enumeration'] The value '300'"},
+		{"base pattern of a derived type", `<Doc><Kind>1x</Kind><Date>2026-03-02</Date>` + line + `</Doc>`, "The value '1x' is not accepted by the pattern"},
+		{"too many decimal places", doc(`<Line no="1"><Code>123</Code><Amount>1.005</Amount></Line>`), "[facet 'fractionDigits']"},
+		{"trailing zeros are not digits", doc(`<Line no="1"><Code>123</Code><Amount>1.500000</Amount></Line>`), ""},
+		{"too many digits", doc(`<Line no="1"><Code>123</Code><Amount>12345.67</Amount></Line>`), "[facet 'totalDigits']"},
+		{"below minInclusive", doc(`<Line no="1"><Code>123</Code><Amount>-1</Amount></Line>`), "[facet 'minInclusive']"},
+		{"not a decimal", doc(`<Line no="1"><Code>123</Code><Amount>1,5</Amount></Line>`), "'1,5' is not a valid value"},
+		{"invalid date", `<Doc><Kind>100</Kind><Date>02/03/2026</Date>` + line + `</Doc>`, "'02/03/2026' is not a valid value of the atomic type 'xs:date'"},
+
+		// Attributes and content
+		{"required attribute missing", doc(`<Line><Code>123</Code><Amount>1</Amount></Line>`), "The attribute 'no' is required but missing"},
+		{"attribute type", doc(`<Line no="0"><Code>123</Code><Amount>1</Amount></Line>`), "'0' is not a valid value of the atomic type 'xs:positiveInteger'"},
+		{"undeclared attribute", doc(`<Line no="1" x="y"><Code>123</Code><Amount>1</Amount></Line>`), "The attribute 'x' is not allowed"},
+		{"text in a complex element", `<Doc>text<Kind>100</Kind><Date>2026-03-02</Date>` + line + `</Doc>`, "Character content other than whitespace is not allowed"},
+		{"children in a simple element", doc(`<Line no="1"><Code><b/></Code><Amount>1</Amount></Line>`), "Element content is not allowed"},
+
+		// Documents
+		{"unknown root", `<Other/>`, "No matching global declaration available for the validation root"},
+		{"malformed", `<Doc>`, "malformed XML"},
+		{"empty", ``, "malformed XML"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate([]byte(tt.xml))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() error = %v, want none", err)
+			case tt.wantErr != "" && err == nil:
+				t.Errorf("Validate() = nil, want an error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsLines(t *testing.T) {
+	schema := MustParse([]byte(testSchema))
+	err := schema.Validate([]byte("<Doc><Kind>100</Kind><Date>2026-03-02</Date>\n" +
+		`<Line no="1"><Code>123</Code><Amount>1</Amount></Line>` + "\n" +
+		`<Line no="2"><Code>12</Code><Amount>1</Amount></Line></Doc>`))
+	if err == nil || !strings.Contains(err.Error(), "line 3: Element 'Code'") {
+		t.Errorf("Validate() error = %v, want the line of the second item", err)
+	}
+}
+
+func TestValidateCapsReportedErrors(t *testing.T) {
+	schema := MustParse([]byte(testSchema))
+	lines := strings.Repeat(`<Line no="0"><Code>123</Code><Amount>1</Amount></Line>`, maxReportedErrors+5)
+	err := schema.Validate([]byte(`<Doc><Kind>100</Kind><Date>2026-03-02</Date>` + lines + `</Doc>`))
+	if err == nil {
+		t.Fatal("Validate() = nil, want an error")
+	}
+	if got := strings.Count(err.Error(), "positiveInteger"); got != maxReportedErrors {
+		t.Errorf("reported %d errors, want %d", got, maxReportedErrors)
+	}
+	if !strings.Contains(err.Error(), "and 5 more errors") {
+		t.Errorf("Validate() error = %v, want the number of errors left out", err)
+	}
+}
+
+// Constructs of complete schemas such as the official e-invoice XSD
+const (
+	importingSchema = `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:sig="urn:sig">
+  <xs:import namespace="urn:sig" schemaLocation="sig/signature.xsd"/>
+  <xs:element name="Doc">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:choice>
+          <xs:element name="TaxCode" type="xs:string"/>
+          <xs:element name="IDNumber" type="xs:string"/>
+        </xs:choice>
+        <xs:element ref="sig:Signature"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+
+	importedSchema = `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:sig" elementFormDefault="qualified">
+  <xs:element name="Signature">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="Version" type="xs:positiveInteger"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`
+)
+
+func TestImportsChoicesAndReferences(t *testing.T) {
+	fsys := fstest.MapFS{
+		"schema/doc.xsd":               {Data: []byte(importingSchema)},
+		"schema/sig/signature.xsd":     {Data: []byte(importedSchema)},
+		"schema/unrelated/ignored.txt": {Data: []byte("not a schema")},
+	}
+	fromFS, err := ParseFS(fsys, "schema/doc.xsd")
+	if err != nil {
+		t.Fatalf("ParseFS() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sig"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"doc.xsd": importingSchema, "sig/signature.xsd": importedSchema} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fromFile, err := Load(filepath.Join(dir, "doc.xsd"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	signature := `<s:Signature xmlns:s="urn:sig"><s:Version>1</s:Version></s:Signature>`
+	tests := []struct {
+		name    string
+		xml     string
+		wantErr string
+	}{
+		{"first choice", `<Doc><TaxCode>0101234567</TaxCode>` + signature + `</Doc>`, ""},
+		{"second choice", `<Doc><IDNumber>001099012345</IDNumber>` + signature + `</Doc>`, ""},
+		{"both choices", `<Doc><TaxCode>1</TaxCode><IDNumber>2</IDNumber>` + signature + `</Doc>`, "Element 'IDNumber': This element is not expected"},
+		{"no choice", `<Doc>` + signature + `</Doc>`, "This element is not expected"},
+		{"imported element checked", `<Doc><TaxCode>1</TaxCode><s:Signature xmlns:s="urn:sig"><s:Version>0</s:Version></s:Signature></Doc>`, "'0' is not a valid value of the atomic type 'xs:positiveInteger'"},
+		{"imported element missing", `<Doc><TaxCode>1</TaxCode></Doc>`, "Missing child element(s)"},
+	}
+	for name, schema := range map[string]*Schema{"ParseFS": fromFS, "Load": fromFile} {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				err := schema.Validate([]byte(tt.xml))
+				switch {
+				case tt.wantErr == "" && err != nil:
+					t.Errorf("Validate() error = %v, want none", err)
+				case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+					t.Errorf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	wrap := func(body string) string {
+		return `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">` + body + `</xs:schema>`
+	}
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{"empty", ``, "empty schema"},
+		{"not a schema", `<schema/>`, "is not a schema document"},
+		{"malformed", `<xs:schema`, "invalid schema"},
+		{"unknown type", wrap(`<xs:element name="A" type="tMissing"/>`), "tMissing"},
+		{"unresolved reference", wrap(`<xs:element name="A"><xs:complexType><xs:sequence><xs:element ref="B"/></xs:sequence></xs:complexType></xs:element>`), "'B'"},
+		{"invalid maxOccurs", wrap(`<xs:element name="A"><xs:complexType><xs:sequence><xs:element name="B" maxOccurs="many"/></xs:sequence></xs:complexType></xs:element>`), "maxOccurs"},
+		{"invalid pattern", wrap(`<xs:simpleType name="s"><xs:restriction base="xs:string"><xs:pattern value="("/></xs:restriction></xs:simpleType>`), "pattern"},
+		{"import not found", wrap(`<xs:import namespace="urn:x" schemaLocation="missing.xsd"/><xs:element name="A" xmlns:x="urn:x" type="x:T"/>`), "urn:x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.schema))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.xsd")); err == nil {
+		t.Error("Load() of a missing file succeeded")
+	}
+}